@@ -6,16 +6,24 @@ import (
 	"time"
 
 	userroles "github.com/dannyswat/pjeasy/internal/user_roles"
+	"github.com/dannyswat/pjeasy/internal/user_sessions"
+	"github.com/dannyswat/pjeasy/internal/users"
 	"github.com/labstack/echo/v4"
 )
 
 type AdminHandler struct {
-	adminService *userroles.SystemAdminService
+	adminService   *userroles.SystemAdminService
+	settingService *userroles.SystemSettingService
+	sessionService *user_sessions.SessionService
+	userService    *users.UserService
 }
 
-func NewAdminHandler(adminService *userroles.SystemAdminService) *AdminHandler {
+func NewAdminHandler(adminService *userroles.SystemAdminService, settingService *userroles.SystemSettingService, sessionService *user_sessions.SessionService, userService *users.UserService) *AdminHandler {
 	return &AdminHandler{
-		adminService: adminService,
+		adminService:   adminService,
+		settingService: settingService,
+		sessionService: sessionService,
+		userService:    userService,
 	}
 }
 
@@ -37,6 +45,56 @@ type UnassignAdminRequest struct {
 	UserID int `json:"userId" validate:"required"`
 }
 
+type UpdateSecuritySettingsRequest struct {
+	RequireTwoFactor bool `json:"requireTwoFactor"`
+}
+
+type DirectoryUserResponse struct {
+	ID              int        `json:"id"`
+	LoginID         string     `json:"loginId"`
+	Name            string     `json:"name"`
+	Email           string     `json:"email,omitempty"`
+	ProfileImageURL string     `json:"profileImageUrl,omitempty"`
+	IsActive        bool       `json:"isActive"`
+	DeactivatedAt   *time.Time `json:"deactivatedAt,omitempty"`
+	LastLoginAt     *time.Time `json:"lastLoginAt,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+}
+
+type UserDirectoryResponse struct {
+	Users    []DirectoryUserResponse `json:"users"`
+	Total    int64                   `json:"total"`
+	Page     int                     `json:"page"`
+	PageSize int                     `json:"pageSize"`
+}
+
+func toDirectoryUserResponse(user *users.User) DirectoryUserResponse {
+	return DirectoryUserResponse{
+		ID:              user.ID,
+		LoginID:         user.LoginID,
+		Name:            user.Name,
+		Email:           user.Email,
+		ProfileImageURL: user.ProfileImageURL,
+		IsActive:        user.IsActive(),
+		DeactivatedAt:   user.DeactivatedAt,
+		LastLoginAt:     user.LastLoginAt,
+		CreatedAt:       user.CreatedAt,
+	}
+}
+
+type LoginLockResponse struct {
+	Type          string     `json:"type"`
+	Value         string     `json:"value"`
+	FailureCount  int        `json:"failureCount"`
+	LastFailureAt time.Time  `json:"lastFailureAt"`
+	LockedUntil   *time.Time `json:"lockedUntil"`
+}
+
+type UnlockLoginRequest struct {
+	Type  string `json:"type" validate:"required,oneof=login_id ip"`
+	Value string `json:"value" validate:"required"`
+}
+
 // ListAdmins returns all system admins
 func (h *AdminHandler) ListAdmins(c echo.Context) error {
 	admins, err := h.adminService.GetAllAdmins()
@@ -74,12 +132,12 @@ func (h *AdminHandler) AssignAdmin(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	admin, err := h.adminService.AssignAdminByLoginID(req.LoginID, req.ExpiredAfter)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	expiredAfter := admin.ExpiredAfter
@@ -105,7 +163,7 @@ func (h *AdminHandler) UnassignAdmin(c echo.Context) error {
 
 	err = h.adminService.UnassignAdmin(userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{
@@ -130,6 +188,227 @@ func (h *AdminHandler) CheckAdmin(c echo.Context) error {
 	})
 }
 
+// GetSecuritySettings returns the system-wide security settings
+func (h *AdminHandler) GetSecuritySettings(c echo.Context) error {
+	settings, err := h.settingService.GetSecuritySettings()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch security settings")
+	}
+
+	return c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSecuritySettings updates the system-wide security settings
+func (h *AdminHandler) UpdateSecuritySettings(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	req := new(UpdateSecuritySettingsRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	settings := &userroles.SecuritySettings{
+		RequireTwoFactor: req.RequireTwoFactor,
+	}
+	if err := h.settingService.UpdateSecuritySettings(settings, userID); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update security settings")
+	}
+
+	return c.JSON(http.StatusOK, settings)
+}
+
+// ListLoginLocks returns login IDs and IP addresses that are locked out after failed logins
+func (h *AdminHandler) ListLoginLocks(c echo.Context) error {
+	locks, err := h.sessionService.GetLockedLogins()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch login locks")
+	}
+
+	response := make([]LoginLockResponse, 0, len(locks))
+	for _, lock := range locks {
+		response = append(response, LoginLockResponse{
+			Type:          lock.Kind,
+			Value:         lock.Value,
+			FailureCount:  lock.FailureCount,
+			LastFailureAt: lock.LastFailureAt,
+			LockedUntil:   lock.LockedUntil,
+		})
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// UnlockLogin clears the lockout and failure count of a login ID or IP address
+func (h *AdminHandler) UnlockLogin(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	req := new(UnlockLoginRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	if err := h.sessionService.UnlockLogin(req.Type, req.Value, userID, c.Request().UserAgent(), c.RealIP()); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Login unlocked successfully",
+	})
+}
+
+// ListUsers searches the user directory by login ID or name
+func (h *AdminHandler) ListUsers(c echo.Context) error {
+	page, err := strconv.Atoi(c.QueryParam("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(c.QueryParam("pageSize"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	userList, total, err := h.userService.ListUsers(c.QueryParam("search"), c.QueryParam("status"), page, pageSize)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	response := make([]DirectoryUserResponse, 0, len(userList))
+	for i := range userList {
+		response = append(response, toDirectoryUserResponse(&userList[i]))
+	}
+
+	return c.JSON(http.StatusOK, UserDirectoryResponse{
+		Users:    response,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	})
+}
+
+// DeactivateUser blocks a user from logging in and signs them out everywhere
+func (h *AdminHandler) DeactivateUser(c echo.Context) error {
+	adminUserID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	userID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid user ID")
+	}
+
+	user, err := h.sessionService.DeactivateUser(userID, adminUserID, c.Request().UserAgent(), c.RealIP())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, toDirectoryUserResponse(user))
+}
+
+// ReactivateUser allows a deactivated user to log in again
+func (h *AdminHandler) ReactivateUser(c echo.Context) error {
+	adminUserID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	userID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid user ID")
+	}
+
+	user, err := h.sessionService.ReactivateUser(userID, adminUserID, c.Request().UserAgent(), c.RealIP())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, toDirectoryUserResponse(user))
+}
+
+// ForcePasswordReset expires a user's password so the next login must set a new one
+func (h *AdminHandler) ForcePasswordReset(c echo.Context) error {
+	adminUserID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	userID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid user ID")
+	}
+
+	if err := h.sessionService.ForcePasswordReset(userID, adminUserID, c.Request().UserAgent(), c.RealIP()); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "User must set a new password at next login",
+	})
+}
+
+// ListUserSessions returns the active sessions of any user
+func (h *AdminHandler) ListUserSessions(c echo.Context) error {
+	userID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid user ID")
+	}
+
+	sessions, err := h.sessionService.GetActiveSessions(userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch sessions")
+	}
+
+	return c.JSON(http.StatusOK, toSessionResponses(sessions, GetSessionIDFromContext(c)))
+}
+
+// RevokeUserSession revokes a session of any user by ID
+func (h *AdminHandler) RevokeUserSession(c echo.Context) error {
+	adminUserID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	userID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid user ID")
+	}
+
+	if err := h.sessionService.RevokeUserSession(userID, c.Param("sessionId"), adminUserID, c.Request().UserAgent(), c.RealIP()); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Session revoked successfully",
+	})
+}
+
+// RevokeAllUserSessions signs a user out everywhere
+func (h *AdminHandler) RevokeAllUserSessions(c echo.Context) error {
+	userID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid user ID")
+	}
+
+	if err := h.sessionService.RevokeAllUserSessions(userID, c.Request().UserAgent(), c.RealIP()); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to revoke sessions")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "All sessions revoked successfully",
+	})
+}
+
 func (h *AdminHandler) RegisterRoutes(e *echo.Echo, authMiddleware *AuthMiddleware) {
 	// All admin routes require authentication
 	adminGroup := e.Group("/api/admins", authMiddleware.RequireAuth)
@@ -137,9 +416,20 @@ func (h *AdminHandler) RegisterRoutes(e *echo.Echo, authMiddleware *AuthMiddlewa
 	// Check admin status (available to all authenticated users)
 	adminGroup.GET("/check", h.CheckAdmin)
 
-	// Admin-only routes
-	adminOnlyGroup := e.Group("/api/admins", authMiddleware.RequireAuth, authMiddleware.RequireAdmin)
+	// Admin-only routes need an interactive session so that personal access tokens cannot administer the system
+	adminOnlyGroup := e.Group("/api/admins", authMiddleware.RequireAuth, authMiddleware.RequireSessionAuth, authMiddleware.RequireAdmin)
 	adminOnlyGroup.GET("", h.ListAdmins)
 	adminOnlyGroup.POST("", h.AssignAdmin)
 	adminOnlyGroup.DELETE("/:userId", h.UnassignAdmin)
+	adminOnlyGroup.GET("/settings/security", h.GetSecuritySettings)
+	adminOnlyGroup.PUT("/settings/security", h.UpdateSecuritySettings)
+	adminOnlyGroup.GET("/login-locks", h.ListLoginLocks)
+	adminOnlyGroup.POST("/login-locks/unlock", h.UnlockLogin)
+	adminOnlyGroup.GET("/users", h.ListUsers)
+	adminOnlyGroup.POST("/users/:userId/deactivate", h.DeactivateUser)
+	adminOnlyGroup.POST("/users/:userId/reactivate", h.ReactivateUser)
+	adminOnlyGroup.POST("/users/:userId/force-password-reset", h.ForcePasswordReset)
+	adminOnlyGroup.GET("/users/:userId/sessions", h.ListUserSessions)
+	adminOnlyGroup.DELETE("/users/:userId/sessions", h.RevokeAllUserSessions)
+	adminOnlyGroup.DELETE("/users/:userId/sessions/:sessionId", h.RevokeUserSession)
 }
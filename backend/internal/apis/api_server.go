@@ -9,12 +9,19 @@ import (
 	"strings"
 	"time"
 
+	"github.com/dannyswat/pjeasy/internal/cachebus"
 	"github.com/dannyswat/pjeasy/internal/comments"
 	"github.com/dannyswat/pjeasy/internal/config"
+	"github.com/dannyswat/pjeasy/internal/custom_fields"
 	"github.com/dannyswat/pjeasy/internal/features"
 	"github.com/dannyswat/pjeasy/internal/ideas"
 	"github.com/dannyswat/pjeasy/internal/issues"
 	"github.com/dannyswat/pjeasy/internal/item_follow_ups"
+	"github.com/dannyswat/pjeasy/internal/item_links"
+	"github.com/dannyswat/pjeasy/internal/item_search"
+	"github.com/dannyswat/pjeasy/internal/portfolios"
+	"github.com/dannyswat/pjeasy/internal/project_archives"
+	"github.com/dannyswat/pjeasy/internal/project_templates"
 	"github.com/dannyswat/pjeasy/internal/projects"
 	"github.com/dannyswat/pjeasy/internal/releases"
 	"github.com/dannyswat/pjeasy/internal/repositories"
@@ -24,7 +31,9 @@ import (
 	"github.com/dannyswat/pjeasy/internal/sprints"
 	"github.com/dannyswat/pjeasy/internal/status_changes"
 	"github.com/dannyswat/pjeasy/internal/tasks"
+	"github.com/dannyswat/pjeasy/internal/trash"
 	"github.com/dannyswat/pjeasy/internal/user_dailies"
+	"github.com/dannyswat/pjeasy/internal/user_groups"
 	userroles "github.com/dannyswat/pjeasy/internal/user_roles"
 	"github.com/dannyswat/pjeasy/internal/user_sessions"
 	"github.com/dannyswat/pjeasy/internal/users"
@@ -41,49 +50,80 @@ type APIServer struct {
 	gorm       *gorm.DB
 	uowFactory *repositories.UnitOfWorkFactory
 	globalUOW  *repositories.UnitOfWork
-
-	userService          *users.UserService
-	sessionService       *user_sessions.SessionService
-	adminService         *userroles.SystemAdminService
-	projectService       *projects.ProjectService
-	ideaService          *ideas.IdeaService
-	issueService         *issues.IssueService
-	featureService       *features.FeatureService
-	serviceTicketService *service_tickets.ServiceTicketService
-	commentService       *comments.CommentService
-	itemFollowUpService  *item_follow_ups.ItemFollowUpService
-	sequenceService      *sequences.SequenceService
-	taskService          *tasks.TaskService
-	sprintService        *sprints.SprintService
-	reviewService        *reviews.ReviewService
-	releaseService       *releases.ReleaseService
-	wikiPageService      *wiki_pages.WikiPageService
-	statusChangeService  *status_changes.StatusChangeService
-	userDailyService     *user_dailies.UserDailyService
-	statusFlowHandler    *StatusFlowHandler
-	tokenService         *user_sessions.TokenService
-	userHandler          *UserHandler
-	sessionHandler       *SessionHandler
-	adminHandler         *AdminHandler
-	projectHandler       *ProjectHandler
-	ideaHandler          *IdeaHandler
-	issueHandler         *IssueHandler
-	featureHandler       *FeatureHandler
-	serviceTicketHandler *ServiceTicketHandler
-	commentHandler       *CommentHandler
-	itemFollowUpHandler  *ItemFollowUpHandler
-	sequenceHandler      *SequenceHandler
-	taskHandler          *TaskHandler
-	sprintHandler        *SprintHandler
-	reviewHandler        *ReviewHandler
-	releaseHandler       *ReleaseHandler
-	wikiPageHandler      *WikiPageHandler
-	statusChangeHandler  *StatusChangeHandler
-	userDailyHandler     *UserDailyHandler
-	dashboardHandler     *DashboardHandler
-	authMiddleware       *AuthMiddleware
-	projectMiddleware    *ProjectMiddleware
-	workflowEngine       *workflow.WorkflowEngine
+	cacheBus   cachebus.Bus
+
+	userService           *users.UserService
+	sessionService        *user_sessions.SessionService
+	patService            *user_sessions.PersonalAccessTokenService
+	securityEventService  *user_sessions.SecurityEventService
+	passwordResetService  *users.PasswordResetService
+	emailChangeService    *users.EmailChangeService
+	adminService          *userroles.SystemAdminService
+	systemSettingService  *userroles.SystemSettingService
+	projectService        *projects.ProjectService
+	projectRoleService    *projects.ProjectRoleService
+	projectGroupService   *projects.ProjectGroupService
+	projectSettingService *projects.ProjectSettingService
+	archiveService        *project_archives.ProjectArchiveService
+	trashService          *trash.TrashService
+	portfolioService      *portfolios.PortfolioService
+	itemSearchService     *item_search.ItemSearchService
+	userGroupService      *user_groups.UserGroupService
+	ideaService           *ideas.IdeaService
+	issueService          *issues.IssueService
+	featureService        *features.FeatureService
+	serviceTicketService  *service_tickets.ServiceTicketService
+	commentService        *comments.CommentService
+	itemFollowUpService   *item_follow_ups.ItemFollowUpService
+	sequenceService       *sequences.SequenceService
+	taskService           *tasks.TaskService
+	sprintService         *sprints.SprintService
+	reviewService         *reviews.ReviewService
+	releaseService        *releases.ReleaseService
+	wikiPageService       *wiki_pages.WikiPageService
+	customFieldService    *custom_fields.CustomFieldService
+	itemLinkService       *item_links.ItemLinkService
+	statusChangeService   *status_changes.StatusChangeService
+	userDailyService      *user_dailies.UserDailyService
+	statusFlowHandler     *StatusFlowHandler
+	tokenService          *user_sessions.TokenService
+	oidcClient            *users.OIDCClient
+	userHandler           *UserHandler
+	sessionHandler        *SessionHandler
+	oidcHandler           *OIDCHandler
+	patHandler            *PersonalAccessTokenHandler
+	adminHandler          *AdminHandler
+	securityEventHandler  *SecurityEventHandler
+	jwksHandler           *JWKSHandler
+	projectHandler        *ProjectHandler
+	projectRoleHandler    *ProjectRoleHandler
+	projectGroupHandler   *ProjectGroupHandler
+	projectSettingHandler *ProjectSettingHandler
+	archiveHandler        *ProjectArchiveHandler
+	trashHandler          *TrashHandler
+	userGroupHandler      *UserGroupHandler
+	ideaHandler           *IdeaHandler
+	issueHandler          *IssueHandler
+	featureHandler        *FeatureHandler
+	serviceTicketHandler  *ServiceTicketHandler
+	commentHandler        *CommentHandler
+	itemFollowUpHandler   *ItemFollowUpHandler
+	sequenceHandler       *SequenceHandler
+	taskHandler           *TaskHandler
+	sprintHandler         *SprintHandler
+	reviewHandler         *ReviewHandler
+	releaseHandler        *ReleaseHandler
+	wikiPageHandler       *WikiPageHandler
+	statusChangeHandler   *StatusChangeHandler
+	userDailyHandler      *UserDailyHandler
+	dashboardHandler      *DashboardHandler
+	portfolioHandler      *PortfolioHandler
+	itemSearchHandler     *ItemSearchHandler
+	customFieldHandler    *CustomFieldHandler
+	itemLinkHandler       *ItemLinkHandler
+	authMiddleware        *AuthMiddleware
+	projectMiddleware     *ProjectMiddleware
+	workflowEngine        *workflow.WorkflowEngine
 }
 
 const wikiPageSlugIndexName = "idx_project_wiki_slug"
@@ -114,6 +154,19 @@ func (s *APIServer) SetupDatabase() error {
 	return nil
 }
 
+// newCacheBus creates the bus selected in config that keeps the caches of API replicas in sync
+func (s *APIServer) newCacheBus() cachebus.Bus {
+	cfg := &s.config.CacheBus
+	switch cfg.Provider {
+	case "postgres":
+		bus := cachebus.NewPostgresBus(s.gorm, s.config.Database.ToRepositoryConfig().GetPostgresConnectionString(), cfg.Channel)
+		bus.Start()
+		return bus
+	default:
+		return cachebus.NewNoopBus()
+	}
+}
+
 func (s *APIServer) AutoMigrate() error {
 	if !s.config.AutoMigrate {
 		return nil
@@ -127,11 +180,26 @@ func (s *APIServer) AutoMigrate() error {
 	if err := s.gorm.AutoMigrate(
 		users.User{},
 		users.UserCredential{},
+		&users.PasswordResetToken{},
+		&users.EmailChangeToken{},
+		&users.PasswordHistory{},
+		&users.UserPreference{},
 		&user_sessions.UserSession{},
+		&user_sessions.PersonalAccessToken{},
+		&user_sessions.LoginThrottle{},
+		&user_sessions.SecurityEvent{},
 		&userroles.SystemAdmin{},
+		&userroles.SystemSetting{},
+		&user_groups.UserGroup{},
+		&user_groups.UserGroupMember{},
 		&projects.Project{},
 		&projects.ProjectMember{},
 		&projects.ProjectInvitation{},
+		&projects.ProjectInvitationAcceptance{},
+		&projects.ProjectRole{},
+		&projects.ProjectRolePermission{},
+		&projects.ProjectGroup{},
+		&projects.ProjectSetting{},
 		&ideas.Idea{},
 		&issues.Issue{},
 		&features.Feature{},
@@ -149,12 +217,29 @@ func (s *APIServer) AutoMigrate() error {
 		&wiki_pages.WikiPageChange{},
 		&status_changes.StatusChange{},
 		&status_changes.StatusFlow{},
+		&custom_fields.CustomField{},
+		&custom_fields.CustomFieldValue{},
+		&custom_fields.CustomFieldChange{},
+		&item_links.ItemLink{},
 		&user_dailies.UserDailyItem{},
 		&user_dailies.UserDailyTimeLog{},
 	); err != nil {
 		return err
 	}
-	return s.ensureWikiPageSlugIndex()
+	if err := s.ensureWikiPageSlugIndex(); err != nil {
+		return err
+	}
+	return s.ensureTextSearchIndexes()
+}
+
+// ensureTextSearchIndexes creates the expression indexes used by the project text search
+func (s *APIServer) ensureTextSearchIndexes() error {
+	for _, statement := range item_search.TextSearchIndexStatements() {
+		if err := s.gorm.Exec(statement).Error; err != nil {
+			return fmt.Errorf("create text search index: %w", err)
+		}
+	}
+	return nil
 }
 
 func (s *APIServer) repairWikiPageSlugIndex() error {
@@ -201,29 +286,41 @@ func wikiPageSlugIndexNeedsRepair(indexDef string) bool {
 	normalized = strings.ReplaceAll(normalized, `"`, "")
 	normalized = strings.Join(strings.Fields(normalized), " ")
 
-	return normalized != "" &&
-		!strings.Contains(normalized, "(project_id, slug)") &&
-		!strings.Contains(normalized, "(slug, project_id)")
+	if normalized == "" {
+		return false
+	}
+	// The index is per project and ignores pages in the trash
+	hasColumns := strings.Contains(normalized, "(project_id, slug)") || strings.Contains(normalized, "(slug, project_id)")
+	return !hasColumns || !strings.Contains(normalized, "where (deleted_at is null)")
 }
 
 func (s *APIServer) SetupAPIServer() error {
 	s.uowFactory = repositories.NewUnitOfWorkFactory(s.gorm)
 	// Global Unit of Work for read operations
 	s.globalUOW = s.uowFactory.NewUnitOfWork()
+	s.cacheBus = s.newCacheBus()
 
 	// Register global middleware
 	s.echo.Use(LoggingMiddleware)
+	s.echo.Use(ArchivedProjectMiddleware)
 
 	// Register custom validator
 	s.echo.Validator = NewValidator()
 
-	s.SetupUserService()
+	if err := s.SetupUserService(); err != nil {
+		return err
+	}
 
 	// Initialize admin service
 	adminRepo := userroles.NewSystemAdminRepository(s.globalUOW)
 	userRepo := users.NewUserRepository(s.globalUOW)
 	s.adminService = userroles.NewSystemAdminService(adminRepo, userRepo)
 
+	// Initialize system settings and apply the two-factor policy to logins
+	systemSettingRepo := userroles.NewSystemSettingRepository(s.globalUOW)
+	s.systemSettingService = userroles.NewSystemSettingService(systemSettingRepo)
+	s.sessionService.SetTwoFactorPolicy(s.systemSettingService)
+
 	// Initialize sequence service
 	sequenceRepo := sequences.NewSequenceRepository(s.globalUOW)
 	s.sequenceService = sequences.NewSequenceService(sequenceRepo)
@@ -232,27 +329,57 @@ func (s *APIServer) SetupAPIServer() error {
 	projectRepo := projects.NewProjectRepository(s.globalUOW)
 	memberRepo := projects.NewProjectMemberRepository(s.globalUOW)
 	invitationRepo := projects.NewProjectInvitationRepository(s.globalUOW)
-	memberCache := projects.NewProjectMemberCache(memberRepo, 1*time.Hour)
-	s.projectService = projects.NewProjectService(projectRepo, memberRepo, invitationRepo, userRepo, sequenceRepo, memberCache)
+	roleRepo := projects.NewProjectRoleRepository(s.globalUOW)
+	projectGroupRepo := projects.NewProjectGroupRepository(s.globalUOW)
+	memberCache := projects.NewProjectMemberCache(memberRepo, roleRepo, projectGroupRepo, 1*time.Hour)
+	memberCache.SetBus(s.cacheBus)
+	s.projectService = projects.NewProjectService(projectRepo, memberRepo, invitationRepo, roleRepo, userRepo, sequenceRepo, memberCache, s.uowFactory)
+	s.projectService.SetTemplateCopier(project_templates.NewTemplateCopier())
+	s.archiveService = project_archives.NewProjectArchiveService(s.uowFactory, memberRepo, userRepo, s.uploadRootDir(), s.imageUploadDir())
+	s.projectSettingService = projects.NewProjectSettingService(s.uowFactory, projects.NewProjectSettingRepository(s.globalUOW), projectRepo, memberRepo)
+	s.projectRoleService = projects.NewProjectRoleService(s.uowFactory, roleRepo, projectRepo, memberRepo, memberCache)
+	if err := s.projectRoleService.MigrateMemberRoles(); err != nil {
+		return fmt.Errorf("migrate project member roles: %w", err)
+	}
+
+	// Initialize user groups; group changes refresh the project access of their members
+	userGroupRepo := user_groups.NewUserGroupRepository(s.globalUOW)
+	s.userGroupService = user_groups.NewUserGroupService(s.uowFactory, userGroupRepo, userRepo)
+	s.projectGroupService = projects.NewProjectGroupService(projectGroupRepo, roleRepo, projectRepo, memberRepo, userGroupRepo, memberCache)
+	s.userGroupService.SetGroupChangeHandler(s.projectGroupService)
 	statusChangeRepo := status_changes.NewStatusChangeRepository(s.globalUOW)
 	statusFlowRepo := status_changes.NewStatusFlowRepository(s.globalUOW)
-	s.statusChangeService = status_changes.NewStatusChangeService(statusChangeRepo, statusFlowRepo, memberRepo)
+	s.statusChangeService = status_changes.NewStatusChangeService(statusChangeRepo, statusFlowRepo, memberRepo, projectRepo)
+
+	// Initialize custom field service; item services validate and save custom field values through it
+	s.customFieldService = custom_fields.NewCustomFieldService(
+		custom_fields.NewCustomFieldRepository(s.globalUOW),
+		custom_fields.NewCustomFieldValueRepository(s.globalUOW),
+		custom_fields.NewCustomFieldChangeRepository(s.globalUOW),
+		memberRepo, projectRepo, s.uowFactory)
+
+	// Initialize item link service; links are backfilled from item references made before links existed
+	itemLinkRepo := item_links.NewItemLinkRepository(s.globalUOW)
+	s.itemLinkService = item_links.NewItemLinkService(itemLinkRepo, memberRepo, projectRepo, s.uowFactory)
+	if err := s.itemLinkService.MigrateItemReferences(); err != nil {
+		return fmt.Errorf("migrate item links: %w", err)
+	}
 
 	// Initialize idea service
 	ideaRepo := ideas.NewIdeaRepository(s.globalUOW)
-	s.ideaService = ideas.NewIdeaService(ideaRepo, memberRepo, projectRepo, sequenceRepo, s.statusChangeService, s.uowFactory)
+	s.ideaService = ideas.NewIdeaService(ideaRepo, memberRepo, projectRepo, sequenceRepo, s.statusChangeService, s.customFieldService, s.uowFactory)
 
 	// Initialize issue service
 	issueRepo := issues.NewIssueRepository(s.globalUOW)
-	s.issueService = issues.NewIssueService(issueRepo, memberRepo, projectRepo, sequenceRepo, s.statusChangeService, s.uowFactory)
+	s.issueService = issues.NewIssueService(issueRepo, memberRepo, projectRepo, s.projectSettingService, sequenceRepo, s.statusChangeService, s.customFieldService, s.uowFactory)
 
 	// Initialize feature service
 	featureRepo := features.NewFeatureRepository(s.globalUOW)
-	s.featureService = features.NewFeatureService(featureRepo, memberRepo, projectRepo, sequenceRepo, s.statusChangeService, s.uowFactory)
+	s.featureService = features.NewFeatureService(featureRepo, memberRepo, projectRepo, s.projectSettingService, sequenceRepo, s.statusChangeService, s.customFieldService, itemLinkRepo, s.uowFactory)
 
 	// Initialize service ticket service
 	serviceTicketRepo := service_tickets.NewServiceTicketRepository(s.globalUOW)
-	s.serviceTicketService = service_tickets.NewServiceTicketService(serviceTicketRepo, memberRepo, projectRepo, sequenceRepo, s.statusChangeService, s.uowFactory)
+	s.serviceTicketService = service_tickets.NewServiceTicketService(serviceTicketRepo, memberRepo, projectRepo, s.projectSettingService, sequenceRepo, s.statusChangeService, s.customFieldService, s.uowFactory)
 
 	// Initialize task repository (needed for workflow engine)
 	taskRepo := tasks.NewTaskRepository(s.globalUOW)
@@ -275,19 +402,19 @@ func (s *APIServer) SetupAPIServer() error {
 	commentRepo := comments.NewCommentRepository(s.globalUOW)
 	itemFollowUpRepo := item_follow_ups.NewItemFollowUpRepository(s.globalUOW)
 	wikiPageRepo := wiki_pages.NewWikiPageRepository(s.globalUOW)
-	s.commentService = comments.NewCommentService(commentRepo, userRepo, memberRepo, ideaRepo, issueRepo, featureRepo, taskRepo, serviceTicketRepo, wikiPageRepo)
+	s.commentService = comments.NewCommentService(commentRepo, userRepo, memberRepo, projectRepo, ideaRepo, issueRepo, featureRepo, taskRepo, serviceTicketRepo, wikiPageRepo)
 
 	// Initialize wiki page service
 	wikiPageChangeRepo := wiki_pages.NewWikiPageChangeRepository(s.globalUOW)
 	s.wikiPageService = wiki_pages.NewWikiPageService(wikiPageRepo, wikiPageChangeRepo, memberRepo, projectRepo, featureRepo, issueRepo, taskRepo, s.statusChangeService, s.uowFactory)
 
 	// Initialize task service
-	s.taskService = tasks.NewTaskService(taskRepo, memberRepo, projectRepo, sequenceRepo, serviceTicketRepo, s.wikiPageService, s.statusChangeService, s.uowFactory)
+	s.taskService = tasks.NewTaskService(taskRepo, memberRepo, projectRepo, s.projectSettingService, sequenceRepo, serviceTicketRepo, s.wikiPageService, s.statusChangeService, s.customFieldService, s.uowFactory)
 
 	// Initialize user daily service
 	userDailyItemRepo := user_dailies.NewUserDailyItemRepository(s.globalUOW)
 	userDailyTimeLogRepo := user_dailies.NewUserDailyTimeLogRepository(s.globalUOW)
-	s.userDailyService = user_dailies.NewUserDailyService(userDailyItemRepo, userDailyTimeLogRepo, projectRepo, memberRepo, featureRepo, issueRepo, taskRepo, s.featureService, s.issueService, s.taskService)
+	s.userDailyService = user_dailies.NewUserDailyService(userDailyItemRepo, userDailyTimeLogRepo, projectRepo, s.projectSettingService, memberRepo, featureRepo, issueRepo, taskRepo, s.featureService, s.issueService, s.taskService)
 
 	// Connect workflow engine to task service
 	taskWorkflowAdapter := workflow.NewTaskWorkflowAdapter(s.workflowEngine)
@@ -299,18 +426,36 @@ func (s *APIServer) SetupAPIServer() error {
 
 	// Initialize sprint service
 	sprintRepo := sprints.NewSprintRepository(s.globalUOW)
-	s.sprintService = sprints.NewSprintService(sprintRepo, taskRepo, featureRepo, issueRepo, releaseRepo, memberRepo, projectRepo, s.statusChangeService, s.uowFactory)
+	s.sprintService = sprints.NewSprintService(sprintRepo, taskRepo, featureRepo, issueRepo, releaseRepo, memberRepo, projectRepo, s.projectSettingService, s.statusChangeService, s.uowFactory)
 
 	// Initialize review service
 	reviewRepo := reviews.NewReviewRepository(s.globalUOW)
 	s.reviewService = reviews.NewReviewService(reviewRepo, sprintRepo, taskRepo, featureRepo, issueRepo, ideaRepo, memberRepo, projectRepo, s.statusChangeService, s.uowFactory)
-	s.itemFollowUpService = item_follow_ups.NewItemFollowUpService(itemFollowUpRepo, userRepo, memberRepo, ideaRepo, issueRepo, featureRepo, taskRepo, serviceTicketRepo, wikiPageRepo, reviewRepo)
+	s.itemFollowUpService = item_follow_ups.NewItemFollowUpService(itemFollowUpRepo, userRepo, memberRepo, projectRepo, ideaRepo, issueRepo, featureRepo, taskRepo, serviceTicketRepo, wikiPageRepo, reviewRepo)
+
+	// Initialize trash service; expired projects and items are purged in the background
+	s.trashService = trash.NewTrashService(s.uowFactory, trash.NewTrashRepository(s.globalUOW), projectRepo, memberRepo, s.config.Trash.GetRetentionPeriod())
+	s.trashService.StartPurging(s.config.Trash.GetPurgeInterval())
+	s.portfolioService = portfolios.NewPortfolioService(portfolios.NewPortfolioRepository(s.globalUOW), projectRepo, s.adminService)
+	s.itemSearchService = item_search.NewItemSearchService(item_search.NewItemSearchRepository(s.globalUOW), item_search.NewTextSearchRepository(s.globalUOW), memberRepo)
 
 	// Initialize handlers
-	s.userHandler = NewUserHandler(s.userService, s.projectService)
-	s.sessionHandler = NewSessionHandler(s.userService, s.sessionService, s.projectService)
-	s.adminHandler = NewAdminHandler(s.adminService)
+	s.userHandler = NewUserHandler(s.userService, s.emailChangeService, s.projectService, s.systemSettingService)
+	s.sessionHandler = NewSessionHandler(s.userService, s.sessionService, s.projectService, s.passwordResetService)
+	if s.oidcClient != nil {
+		s.oidcHandler = NewOIDCHandler(s.oidcClient, s.sessionService, s.projectService, s.sessionHandler, s.config.Auth.OIDC)
+	}
+	s.patHandler = NewPersonalAccessTokenHandler(s.patService)
+	s.adminHandler = NewAdminHandler(s.adminService, s.systemSettingService, s.sessionService, s.userService)
+	s.securityEventHandler = NewSecurityEventHandler(s.securityEventService)
+	s.jwksHandler = NewJWKSHandler(s.tokenService)
 	s.projectHandler = NewProjectHandler(s.projectService, s.sequenceService)
+	s.projectRoleHandler = NewProjectRoleHandler(s.projectRoleService)
+	s.projectGroupHandler = NewProjectGroupHandler(s.projectGroupService)
+	s.projectSettingHandler = NewProjectSettingHandler(s.projectSettingService)
+	s.archiveHandler = NewProjectArchiveHandler(s.archiveService)
+	s.trashHandler = NewTrashHandler(s.trashService)
+	s.userGroupHandler = NewUserGroupHandler(s.userGroupService)
 	s.ideaHandler = NewIdeaHandler(s.ideaService)
 	s.issueHandler = NewIssueHandler(s.issueService)
 	s.featureHandler = NewFeatureHandler(s.featureService)
@@ -327,14 +472,30 @@ func (s *APIServer) SetupAPIServer() error {
 	s.userDailyHandler = NewUserDailyHandler(s.userDailyService)
 	s.statusFlowHandler = NewStatusFlowHandler(s.statusChangeService)
 	s.dashboardHandler = NewDashboardHandler(s.projectService, s.taskService, s.issueService, s.featureService, s.serviceTicketService, s.sprintService)
-	s.authMiddleware = NewAuthMiddleware(s.tokenService, s.adminService)
+	s.portfolioHandler = NewPortfolioHandler(s.portfolioService)
+	s.itemSearchHandler = NewItemSearchHandler(s.itemSearchService)
+	s.customFieldHandler = NewCustomFieldHandler(s.customFieldService)
+	s.itemLinkHandler = NewItemLinkHandler(s.itemLinkService)
+	s.authMiddleware = NewAuthMiddleware(s.tokenService, s.sessionService, s.adminService, s.patService)
 	s.projectMiddleware = NewProjectMiddleware(memberCache)
 
 	// Register routes
 	s.userHandler.RegisterRoutes(s.echo, s.authMiddleware)
-	s.sessionHandler.RegisterRoutes(s.echo)
+	s.sessionHandler.RegisterRoutes(s.echo, s.authMiddleware)
+	if s.oidcHandler != nil {
+		s.oidcHandler.RegisterRoutes(s.echo)
+	}
+	s.patHandler.RegisterRoutes(s.echo, s.authMiddleware)
 	s.adminHandler.RegisterRoutes(s.echo, s.authMiddleware)
+	s.securityEventHandler.RegisterRoutes(s.echo, s.authMiddleware)
+	s.jwksHandler.RegisterRoutes(s.echo)
 	s.projectHandler.RegisterRoutes(s.echo, s.authMiddleware, s.projectMiddleware)
+	s.projectRoleHandler.RegisterRoutes(s.echo, s.authMiddleware, s.projectMiddleware)
+	s.projectGroupHandler.RegisterRoutes(s.echo, s.authMiddleware, s.projectMiddleware)
+	s.projectSettingHandler.RegisterRoutes(s.echo, s.authMiddleware, s.projectMiddleware)
+	s.archiveHandler.RegisterRoutes(s.echo, s.authMiddleware, s.projectMiddleware)
+	s.trashHandler.RegisterRoutes(s.echo, s.authMiddleware, s.projectMiddleware)
+	s.userGroupHandler.RegisterRoutes(s.echo, s.authMiddleware)
 	s.ideaHandler.RegisterRoutes(s.echo, s.authMiddleware, s.projectMiddleware)
 	s.issueHandler.RegisterRoutes(s.echo, s.authMiddleware, s.projectMiddleware)
 	s.featureHandler.RegisterRoutes(s.echo, s.authMiddleware, s.projectMiddleware)
@@ -351,6 +512,10 @@ func (s *APIServer) SetupAPIServer() error {
 	s.userDailyHandler.RegisterRoutes(s.echo, s.authMiddleware)
 	s.statusFlowHandler.RegisterRoutes(s.echo, s.authMiddleware, s.projectMiddleware)
 	s.dashboardHandler.RegisterRoutes(s.echo, s.authMiddleware, s.projectMiddleware)
+	s.portfolioHandler.RegisterRoutes(s.echo, s.authMiddleware)
+	s.itemSearchHandler.RegisterRoutes(s.echo, s.authMiddleware, s.projectMiddleware)
+	s.customFieldHandler.RegisterRoutes(s.echo, s.authMiddleware, s.projectMiddleware)
+	s.itemLinkHandler.RegisterRoutes(s.echo, s.authMiddleware, s.projectMiddleware)
 
 	// Register upload routes
 	RegisterUploadRoutes(s.echo, s, s.authMiddleware, s.projectMiddleware)
@@ -19,18 +19,23 @@ func TestWikiPageSlugIndexNeedsRepair(t *testing.T) {
 			want:     true,
 		},
 		{
-			name:     "project and slug index is accepted",
+			name:     "project and slug index without trash condition needs repair",
 			indexDef: "CREATE UNIQUE INDEX idx_project_wiki_slug ON public.wiki_pages USING btree (project_id, slug)",
+			want:     true,
+		},
+		{
+			name:     "project and slug index is accepted",
+			indexDef: "CREATE UNIQUE INDEX idx_project_wiki_slug ON public.wiki_pages USING btree (project_id, slug) WHERE (deleted_at IS NULL)",
 			want:     false,
 		},
 		{
 			name:     "slug then project index is accepted",
-			indexDef: "CREATE UNIQUE INDEX idx_project_wiki_slug ON public.wiki_pages USING btree (slug, project_id)",
+			indexDef: "CREATE UNIQUE INDEX idx_project_wiki_slug ON public.wiki_pages USING btree (slug, project_id) WHERE (deleted_at IS NULL)",
 			want:     false,
 		},
 		{
 			name:     "quoted columns are normalized",
-			indexDef: "CREATE UNIQUE INDEX idx_project_wiki_slug ON public.wiki_pages USING btree (\"project_id\", \"slug\")",
+			indexDef: "CREATE UNIQUE INDEX idx_project_wiki_slug ON public.wiki_pages USING btree (\"project_id\", \"slug\") WHERE (\"deleted_at\" IS NULL)",
 			want:     false,
 		},
 	}
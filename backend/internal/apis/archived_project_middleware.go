@@ -0,0 +1,32 @@
+package apis
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dannyswat/pjeasy/internal/projects"
+	"github.com/labstack/echo/v4"
+)
+
+// ProjectArchivedCode identifies responses rejecting a change to an archived project
+const ProjectArchivedCode = "project_archived"
+
+// ArchivedProjectMiddleware turns every rejection of a change to an archived project into
+// a 409 Conflict with the project_archived code, whichever status the handler chose.
+// Handlers keep the service error as the Internal error of the HTTPError they return.
+func ArchivedProjectMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return archivedProjectError(next(c))
+	}
+}
+
+func archivedProjectError(err error) error {
+	if !errors.Is(err, projects.ErrProjectArchived) {
+		return err
+	}
+
+	return echo.NewHTTPError(http.StatusConflict, map[string]string{
+		"code":    ProjectArchivedCode,
+		"message": projects.ErrProjectArchived.Error(),
+	})
+}
@@ -0,0 +1,49 @@
+package apis
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/dannyswat/pjeasy/internal/projects"
+	"github.com/labstack/echo/v4"
+)
+
+func TestArchivedProjectError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		archived bool
+	}{
+		{name: "no error", err: nil},
+		{name: "other error", err: errors.New("task not found")},
+		{name: "other http error", err: echo.NewHTTPError(http.StatusBadRequest, "task not found")},
+		{name: "archived error", err: projects.ErrProjectArchived, archived: true},
+		{name: "wrapped archived error", err: fmt.Errorf("update task: %w", projects.ErrProjectArchived), archived: true},
+		{name: "archived http error", err: echo.NewHTTPError(http.StatusBadRequest, projects.ErrProjectArchived.Error()).SetInternal(projects.ErrProjectArchived), archived: true},
+		{name: "reworded archived http error", err: echo.NewHTTPError(http.StatusBadRequest, "cannot save").SetInternal(fmt.Errorf("save: %w", projects.ErrProjectArchived)), archived: true},
+		{name: "archived message without the error", err: echo.NewHTTPError(http.StatusBadRequest, projects.ErrProjectArchived.Error())},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := archivedProjectError(tt.err)
+			if !tt.archived {
+				if got != tt.err {
+					t.Fatalf("archivedProjectError() = %v, want the original error", got)
+				}
+				return
+			}
+
+			he, ok := got.(*echo.HTTPError)
+			if !ok || he.Code != http.StatusConflict {
+				t.Fatalf("archivedProjectError() = %v, want a 409 error", got)
+			}
+			body, ok := he.Message.(map[string]string)
+			if !ok || body["code"] != ProjectArchivedCode {
+				t.Fatalf("archivedProjectError() message = %v, want code %q", he.Message, ProjectArchivedCode)
+			}
+		})
+	}
+}
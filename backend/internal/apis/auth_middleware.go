@@ -12,17 +12,27 @@ import (
 )
 
 type AuthMiddleware struct {
-	tokenService *user_sessions.TokenService
-	adminService *userroles.SystemAdminService
+	tokenService   *user_sessions.TokenService
+	sessionService *user_sessions.SessionService
+	adminService   *userroles.SystemAdminService
+	patService     *user_sessions.PersonalAccessTokenService
 }
 
-func NewAuthMiddleware(tokenService *user_sessions.TokenService, adminService *userroles.SystemAdminService) *AuthMiddleware {
+func NewAuthMiddleware(tokenService *user_sessions.TokenService, sessionService *user_sessions.SessionService, adminService *userroles.SystemAdminService, patService *user_sessions.PersonalAccessTokenService) *AuthMiddleware {
 	return &AuthMiddleware{
-		tokenService: tokenService,
-		adminService: adminService,
+		tokenService:   tokenService,
+		sessionService: sessionService,
+		adminService:   adminService,
+		patService:     patService,
 	}
 }
 
+// Authentication methods stored in the request context
+const (
+	authMethodSession             = "session"
+	authMethodPersonalAccessToken = "personal_access_token"
+)
+
 // RequireAuth middleware validates the access token and sets user info in context
 func (m *AuthMiddleware) RequireAuth(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
@@ -51,20 +61,95 @@ func (m *AuthMiddleware) RequireAuth(next echo.HandlerFunc) echo.HandlerFunc {
 			return echo.NewHTTPError(http.StatusUnauthorized, "Missing access token")
 		}
 
+		if user_sessions.IsPersonalAccessToken(token) {
+			return m.authenticatePersonalAccessToken(c, token, next)
+		}
+
 		// Validate token
 		claims, err := m.tokenService.ValidateAccessToken(token)
 		if err != nil {
 			return echo.NewHTTPError(http.StatusUnauthorized, "Invalid or expired access token")
 		}
 
+		// Access tokens of revoked sessions stay signed until they expire
+		if claims.SessionID != "" && m.sessionService.IsSessionRevoked(claims.SessionID) {
+			return echo.NewHTTPError(http.StatusUnauthorized, "Session has been revoked")
+		}
+
 		// Set user info in context
 		c.Set("user_id", claims.UserID)
 		c.Set("login_id", claims.LoginID)
+		c.Set("session_id", claims.SessionID)
+		c.Set("auth_method", authMethodSession)
+
+		return next(c)
+	}
+}
+
+// authenticatePersonalAccessToken validates a personal access token and enforces its scope
+func (m *AuthMiddleware) authenticatePersonalAccessToken(c echo.Context, token string, next echo.HandlerFunc) error {
+	pat, user, err := m.patService.Authenticate(token)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid or expired access token")
+	}
 
+	if !pat.CanWrite() && !isReadOnlyMethod(c.Request().Method) {
+		return echo.NewHTTPError(http.StatusForbidden, "Personal access token does not allow write access")
+	}
+
+	if pat.ProjectIDs() != nil {
+		projectID, ok := routeProjectID(c)
+		if ok && !pat.AllowsProject(projectID) {
+			return echo.NewHTTPError(http.StatusForbidden, "Personal access token is not allowed for this project")
+		}
+		// Routes that cannot be tied to a project are off limits for restricted tokens
+		if !ok && c.Path() != "/api/users/me" {
+			return echo.NewHTTPError(http.StatusForbidden, "Personal access token is restricted to specific projects")
+		}
+	}
+
+	c.Set("user_id", user.ID)
+	c.Set("login_id", user.LoginID)
+	c.Set("auth_method", authMethodPersonalAccessToken)
+
+	return next(c)
+}
+
+// RequireSessionAuth rejects requests authenticated with a personal access token.
+// Used for account management so a token cannot mint or widen other tokens.
+func (m *AuthMiddleware) RequireSessionAuth(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if method, _ := c.Get("auth_method").(string); method != authMethodSession {
+			return echo.NewHTTPError(http.StatusForbidden, "This action requires an interactive session")
+		}
 		return next(c)
 	}
 }
 
+func isReadOnlyMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// routeProjectID returns the project ID of project-scoped routes (/api/projects/:projectId or /api/projects/:id)
+func routeProjectID(c echo.Context) (int, bool) {
+	path := c.Path()
+	var projectIDStr string
+	switch {
+	case strings.HasPrefix(path, "/api/projects/:projectId"):
+		projectIDStr = c.Param("projectId")
+	case strings.HasPrefix(path, "/api/projects/:id"):
+		projectIDStr = c.Param("id")
+	default:
+		return 0, false
+	}
+
+	projectID, err := strconv.Atoi(projectIDStr)
+	if err != nil {
+		return 0, false
+	}
+	return projectID, true
+}
+
 // RequireAdmin middleware ensures the user is a system admin
 func (m *AuthMiddleware) RequireAdmin(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
@@ -96,6 +181,13 @@ func GetUserIDFromContext(c echo.Context) (int, error) {
 	return userID, nil
 }
 
+// GetSessionIDFromContext returns the session of the access token, or empty for
+// personal access tokens and tokens issued before session IDs were embedded
+func GetSessionIDFromContext(c echo.Context) string {
+	sessionID, _ := c.Get("session_id").(string)
+	return sessionID
+}
+
 // ProjectMiddleware handles project-level authorization
 type ProjectMiddleware struct {
 	memberCache *projects.ProjectMemberCache
@@ -178,3 +270,37 @@ func (m *ProjectMiddleware) RequireProjectAdmin(next echo.HandlerFunc) echo.Hand
 		return next(c)
 	}
 }
+
+// RequirePermission ensures the user's project role grants the action on the item type
+func (m *ProjectMiddleware) RequirePermission(itemType, action string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			userID, err := GetUserIDFromContext(c)
+			if err != nil {
+				return err
+			}
+
+			projectIDStr := c.Param("projectId")
+			if projectIDStr == "" {
+				projectIDStr = c.Param("id")
+			}
+
+			projectID, err := strconv.Atoi(projectIDStr)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "Invalid project ID")
+			}
+
+			allowed, err := m.memberCache.HasPermission(projectID, userID, itemType, action)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to verify project permissions")
+			}
+
+			if !allowed {
+				return echo.NewHTTPError(http.StatusForbidden, projects.PermissionDeniedError(itemType, action).Error())
+			}
+
+			c.Set("project_id", projectID)
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package apis
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const avatarURLPrefix = "/uploads/avatars/"
+
+type AvatarResponse struct {
+	ProfileImageURL string         `json:"profileImageUrl"`
+	URLs            map[int]string `json:"urls"` // Keyed by size in pixels
+}
+
+func (s *APIServer) avatarUploadDir() string {
+	return filepath.Join(s.uploadRootDir(), "avatars")
+}
+
+// avatarFilename names the file of one avatar size; stem is "<userID>_<uuid>"
+func avatarFilename(stem string, size int) string {
+	return fmt.Sprintf("%s_%d.png", stem, size)
+}
+
+// avatarStem extracts the stem from a profile image URL set by UploadAvatar
+func avatarStem(profileImageURL string) (string, bool) {
+	largest := strconv.Itoa(avatarSizes[len(avatarSizes)-1])
+	filename, ok := strings.CutPrefix(profileImageURL, avatarURLPrefix)
+	if !ok {
+		return "", false
+	}
+	stem, ok := strings.CutSuffix(filename, "_"+largest+".png")
+	if !ok || !isSafeUploadIdentifier(stem) {
+		return "", false
+	}
+	return stem, true
+}
+
+func toAvatarResponse(stem string) *AvatarResponse {
+	response := &AvatarResponse{URLs: make(map[int]string, len(avatarSizes))}
+	for _, size := range avatarSizes {
+		response.URLs[size] = avatarURLPrefix + avatarFilename(stem, size)
+	}
+	response.ProfileImageURL = response.URLs[avatarSizes[len(avatarSizes)-1]]
+	return response
+}
+
+// removeAvatarFiles deletes the files of a replaced avatar. Failures are ignored.
+func (s *APIServer) removeAvatarFiles(profileImageURL string) {
+	stem, ok := avatarStem(profileImageURL)
+	if !ok {
+		return
+	}
+	for _, size := range avatarSizes {
+		_ = os.Remove(filepath.Join(s.avatarUploadDir(), avatarFilename(stem, size)))
+	}
+}
+
+// UploadAvatar crops the uploaded image to a square, stores it in every avatar size
+// and sets it as the current user's profile image
+func (s *APIServer) UploadAvatar(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	file, _, err := formImageFile(c, allowedAvatarTypes, "Invalid file type. Allowed: jpg, jpeg, png, gif")
+	if err != nil {
+		return err
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to read uploaded file")
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, maxUploadSize+1))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to read uploaded file")
+	}
+	if len(data) > maxUploadSize {
+		return echo.NewHTTPError(http.StatusBadRequest, "File size exceeds 5MB limit")
+	}
+
+	img, err := decodeAvatarImage(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	rendered, err := renderAvatars(img)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to process image")
+	}
+
+	uploadDir := s.avatarUploadDir()
+	if err := ensureUploadDir(uploadDir); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create upload directory")
+	}
+
+	stem := fmt.Sprintf("%d_%s", userID, uuid.New().String())
+	for _, size := range avatarSizes {
+		if err := writeFileBytes(filepath.Join(uploadDir, avatarFilename(stem, size)), rendered[size]); err != nil {
+			s.removeAvatarFiles(toAvatarResponse(stem).ProfileImageURL)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save file")
+		}
+	}
+
+	response := toAvatarResponse(stem)
+	previous, err := s.userService.UpdateProfileImage(userID, response.ProfileImageURL)
+	if err != nil {
+		s.removeAvatarFiles(response.ProfileImageURL)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+	s.removeAvatarFiles(previous)
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// DeleteAvatar clears the current user's profile image
+func (s *APIServer) DeleteAvatar(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	previous, err := s.userService.UpdateProfileImage(userID, "")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+	s.removeAvatarFiles(previous)
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ServeAvatar serves avatar images
+func (s *APIServer) ServeAvatar(c echo.Context) error {
+	filename := c.Param("filename")
+	baseName, ok := strings.CutSuffix(filename, ".png")
+	if !ok || !isSafeUploadIdentifier(baseName) {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid filename")
+	}
+
+	filePath := filepath.Join(s.avatarUploadDir(), filename)
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		return echo.NewHTTPError(http.StatusNotFound, "Avatar not found")
+	}
+
+	// File names change on every upload so they can be cached indefinitely
+	c.Response().Header().Set("Content-Type", "image/png")
+	c.Response().Header().Set("Cache-Control", "public, max-age=31536000")
+
+	return c.File(filePath)
+}
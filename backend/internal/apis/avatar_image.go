@@ -0,0 +1,106 @@
+package apis
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+)
+
+// Avatar sizes in pixels; the largest is stored as the user's profile image URL
+var avatarSizes = []int{32, 64, 128, 256}
+
+// Avatars must be raster formats the standard library can decode
+var allowedAvatarTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/jpg":  true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// Rejects images whose header claims huge dimensions before decoding the pixels
+const maxAvatarSourcePixels = 40_000_000
+
+// decodeAvatarImage decodes an uploaded JPEG, PNG or GIF image
+func decodeAvatarImage(data []byte) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New("unsupported or corrupt image")
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxAvatarSourcePixels {
+		return nil, errors.New("image dimensions are too large")
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New("unsupported or corrupt image")
+	}
+	return img, nil
+}
+
+// cropSquare returns the largest centered square of the image
+func cropSquare(img image.Image) image.Image {
+	bounds := img.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	x0 := bounds.Min.X + (bounds.Dx()-side)/2
+	y0 := bounds.Min.Y + (bounds.Dy()-side)/2
+
+	square := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(square, square.Bounds(), img, image.Pt(x0, y0), draw.Src)
+	return square
+}
+
+// resizeSquare scales a square image to size x size. Downscaling averages each
+// destination pixel's source area; upscaling uses the nearest source pixel.
+func resizeSquare(src image.Image, size int) *image.RGBA {
+	bounds := src.Bounds()
+	side := bounds.Dx()
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+
+	for y := 0; y < size; y++ {
+		sy0 := y * side / size
+		sy1 := max((y+1)*side/size, sy0+1)
+		for x := 0; x < size; x++ {
+			sx0 := x * side / size
+			sx1 := max((x+1)*side/size, sx0+1)
+
+			var r, g, b, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					cr, cg, cb, ca := src.At(bounds.Min.X+sx, bounds.Min.Y+sy).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					b += uint64(cb)
+					a += uint64(ca)
+					n++
+				}
+			}
+
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(b / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return dst
+}
+
+// renderAvatars crops the image to a square and encodes a PNG for every avatar size
+func renderAvatars(img image.Image) (map[int][]byte, error) {
+	square := cropSquare(img)
+	rendered := make(map[int][]byte, len(avatarSizes))
+	for _, size := range avatarSizes {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, resizeSquare(square, size)); err != nil {
+			return nil, err
+		}
+		rendered[size] = buf.Bytes()
+	}
+	return rendered, nil
+}
@@ -0,0 +1,86 @@
+package apis
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestCropSquare(t *testing.T) {
+	// 6x4 image: left and right columns red, the centered 4x4 square blue
+	img := image.NewRGBA(image.Rect(0, 0, 6, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 6; x++ {
+			c := color.RGBA{B: 255, A: 255}
+			if x == 0 || x == 5 {
+				c = color.RGBA{R: 255, A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	square := cropSquare(img)
+	if square.Bounds().Dx() != 4 || square.Bounds().Dy() != 4 {
+		t.Fatalf("cropSquare() bounds = %v, want 4x4", square.Bounds())
+	}
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			if r, _, _, _ := square.At(x, y).RGBA(); r != 0 {
+				t.Fatalf("cropSquare() kept an edge pixel at %d,%d", x, y)
+			}
+		}
+	}
+}
+
+func TestResizeSquare(t *testing.T) {
+	// 4x4 checkerboard of black and white pixels
+	src := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			v := uint8(0)
+			if (x+y)%2 == 0 {
+				v = 255
+			}
+			src.SetRGBA(x, y, color.RGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+
+	tests := []struct {
+		name string
+		size int
+		want func(x, y int) uint8
+	}{
+		{name: "downscale averages", size: 2, want: func(x, y int) uint8 { return 127 }},
+		{name: "same size copies", size: 4, want: func(x, y int) uint8 { return src.RGBAAt(x, y).R }},
+		{name: "upscale repeats pixels", size: 8, want: func(x, y int) uint8 { return src.RGBAAt(x/2, y/2).R }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := resizeSquare(src, tt.size)
+			if dst.Bounds().Dx() != tt.size || dst.Bounds().Dy() != tt.size {
+				t.Fatalf("resizeSquare() bounds = %v, want %dx%d", dst.Bounds(), tt.size, tt.size)
+			}
+			for y := 0; y < tt.size; y++ {
+				for x := 0; x < tt.size; x++ {
+					if got := dst.RGBAAt(x, y); got.R != tt.want(x, y) || got.A != 255 {
+						t.Fatalf("resizeSquare() pixel %d,%d = %v, want gray %d", x, y, got, tt.want(x, y))
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestAvatarStem(t *testing.T) {
+	stem := "12_0f8fad5b-d9cb-469f-a165-70867728950e"
+	if got, ok := avatarStem(toAvatarResponse(stem).ProfileImageURL); !ok || got != stem {
+		t.Fatalf("avatarStem() = %q, %v, want %q", got, ok, stem)
+	}
+
+	for _, url := range []string{"", "/uploads/images/12_x.png", "/uploads/avatars/../x_256.png", "/uploads/avatars/12_x_64.png"} {
+		if _, ok := avatarStem(url); ok {
+			t.Fatalf("avatarStem(%q) accepted a foreign URL", url)
+		}
+	}
+}
@@ -98,7 +98,7 @@ func (h *CommentHandler) CreateComment(c echo.Context) error {
 
 	comment, err := h.commentService.CreateComment(itemID, itemType, req.Content, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusCreated, toCommentResponse(comment))
@@ -125,7 +125,7 @@ func (h *CommentHandler) GetCommentsByItem(c echo.Context) error {
 	if err != nil {
 		// Log the actual error for debugging
 		c.Logger().Errorf("Error fetching comments for %s/%d: %v", itemType, itemID, err)
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	var commentResponses []CommentResponse
@@ -157,7 +157,7 @@ func (h *CommentHandler) GetComment(c echo.Context) error {
 
 	comment, err := h.commentService.GetComment(commentID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toCommentResponse(comment))
@@ -187,12 +187,12 @@ func (h *CommentHandler) UpdateComment(c echo.Context) error {
 	comment, err := h.commentService.UpdateComment(commentID, req.Content, userID)
 	if err != nil {
 		if err.Error() == "comment not found" {
-			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
 		}
 		if err.Error() == "unauthorized: you can only edit your own comments" {
-			return echo.NewHTTPError(http.StatusForbidden, err.Error())
+			return echo.NewHTTPError(http.StatusForbidden, err.Error()).SetInternal(err)
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toCommentResponse(comment))
@@ -212,12 +212,12 @@ func (h *CommentHandler) DeleteComment(c echo.Context) error {
 
 	if err := h.commentService.DeleteComment(commentID, userID); err != nil {
 		if err.Error() == "comment not found" {
-			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
 		}
 		if err.Error() == "unauthorized: you can only delete your own comments" {
-			return echo.NewHTTPError(http.StatusForbidden, err.Error())
+			return echo.NewHTTPError(http.StatusForbidden, err.Error()).SetInternal(err)
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.NoContent(http.StatusNoContent)
@@ -0,0 +1,256 @@
+package apis
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/custom_fields"
+	"github.com/labstack/echo/v4"
+)
+
+type CustomFieldHandler struct {
+	customFieldService *custom_fields.CustomFieldService
+}
+
+func NewCustomFieldHandler(customFieldService *custom_fields.CustomFieldService) *CustomFieldHandler {
+	return &CustomFieldHandler{customFieldService: customFieldService}
+}
+
+type CreateCustomFieldRequest struct {
+	ItemType  string   `json:"itemType" validate:"required"`
+	Key       string   `json:"key" validate:"required"`
+	Name      string   `json:"name" validate:"required"`
+	FieldType string   `json:"fieldType" validate:"required"`
+	Options   []string `json:"options"`
+	Required  bool     `json:"required"`
+	SortOrder int      `json:"sortOrder"`
+}
+
+type UpdateCustomFieldRequest struct {
+	Name      string   `json:"name" validate:"required"`
+	Options   []string `json:"options"`
+	Required  bool     `json:"required"`
+	SortOrder int      `json:"sortOrder"`
+}
+
+type CustomFieldResponse struct {
+	ID        int       `json:"id"`
+	ProjectID int       `json:"projectId"`
+	ItemType  string    `json:"itemType"`
+	Key       string    `json:"key"`
+	Name      string    `json:"name"`
+	FieldType string    `json:"fieldType"`
+	Options   []string  `json:"options"`
+	Required  bool      `json:"required"`
+	SortOrder int       `json:"sortOrder"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type CustomFieldChangeResponse struct {
+	ID        int       `json:"id"`
+	ProjectID int       `json:"projectId"`
+	ItemType  string    `json:"itemType"`
+	ItemID    int       `json:"itemId"`
+	FieldID   int       `json:"fieldId"`
+	FieldName string    `json:"fieldName"`
+	OldValue  string    `json:"oldValue"`
+	NewValue  string    `json:"newValue"`
+	ChangedBy *int      `json:"changedBy,omitempty"`
+	ChangedAt time.Time `json:"changedAt"`
+}
+
+func toCustomFieldResponse(field *custom_fields.CustomField) CustomFieldResponse {
+	options := make([]string, len(field.Options))
+	copy(options, field.Options)
+
+	return CustomFieldResponse{
+		ID:        field.ID,
+		ProjectID: field.ProjectID,
+		ItemType:  field.ItemType,
+		Key:       field.Key,
+		Name:      field.Name,
+		FieldType: field.FieldType,
+		Options:   options,
+		Required:  field.Required,
+		SortOrder: field.SortOrder,
+		CreatedAt: field.CreatedAt,
+		UpdatedAt: field.UpdatedAt,
+	}
+}
+
+// customFieldFilters collects the cf.<key> query parameters of list requests filtering on custom fields
+func customFieldFilters(c echo.Context) map[string]string {
+	filters := make(map[string]string)
+	for name, values := range c.QueryParams() {
+		if key, ok := strings.CutPrefix(name, custom_fields.FilterParamPrefix); ok && len(values) > 0 {
+			filters[key] = values[0]
+		}
+	}
+	return filters
+}
+
+func (h *CustomFieldHandler) ListCustomFields(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := strconv.Atoi(c.Param("projectId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid project ID")
+	}
+
+	fields, err := h.customFieldService.ListFields(projectID, c.QueryParam("itemType"), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	response := make([]CustomFieldResponse, len(fields))
+	for i := range fields {
+		response[i] = toCustomFieldResponse(&fields[i])
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *CustomFieldHandler) CreateCustomField(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := strconv.Atoi(c.Param("projectId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid project ID")
+	}
+
+	req := new(CreateCustomFieldRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	field, err := h.customFieldService.CreateField(projectID, req.ItemType, req.Key, req.Name, req.FieldType, req.Options, req.Required, req.SortOrder, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusCreated, toCustomFieldResponse(field))
+}
+
+func (h *CustomFieldHandler) UpdateCustomField(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := strconv.Atoi(c.Param("projectId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid project ID")
+	}
+
+	fieldID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid custom field ID")
+	}
+
+	req := new(UpdateCustomFieldRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	field, err := h.customFieldService.UpdateField(projectID, fieldID, req.Name, req.Options, req.Required, req.SortOrder, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, toCustomFieldResponse(field))
+}
+
+func (h *CustomFieldHandler) DeleteCustomField(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := strconv.Atoi(c.Param("projectId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid project ID")
+	}
+
+	fieldID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid custom field ID")
+	}
+
+	if err := h.customFieldService.DeleteField(projectID, fieldID, userID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListChangesByItem returns the custom field history of an item
+func (h *CustomFieldHandler) ListChangesByItem(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := strconv.Atoi(c.QueryParam("projectId"))
+	if err != nil || projectID <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid project ID")
+	}
+
+	itemID, err := strconv.Atoi(c.QueryParam("itemId"))
+	if err != nil || itemID <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid item ID")
+	}
+
+	itemType := c.QueryParam("itemType")
+	if itemType == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Item type is required")
+	}
+
+	changes, err := h.customFieldService.GetChanges(projectID, itemType, itemID, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	response := make([]CustomFieldChangeResponse, len(changes))
+	for i, change := range changes {
+		response[i] = CustomFieldChangeResponse{
+			ID:        change.ID,
+			ProjectID: change.ProjectID,
+			ItemType:  change.ItemType,
+			ItemID:    change.ItemID,
+			FieldID:   change.FieldID,
+			FieldName: change.FieldName,
+			OldValue:  change.OldValue,
+			NewValue:  change.NewValue,
+			ChangedBy: change.ChangedBy,
+			ChangedAt: change.ChangedAt,
+		}
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *CustomFieldHandler) RegisterRoutes(e *echo.Echo, authMiddleware *AuthMiddleware, projectMiddleware *ProjectMiddleware) {
+	customFields := e.Group("/api/projects/:projectId/custom-fields", authMiddleware.RequireAuth, projectMiddleware.RequireProjectMember)
+	customFields.GET("", h.ListCustomFields)
+	customFields.POST("", h.CreateCustomField)
+	customFields.PUT("/:id", h.UpdateCustomField)
+	customFields.DELETE("/:id", h.DeleteCustomField)
+
+	customFieldChanges := e.Group("/api/custom-field-changes", authMiddleware.RequireAuth)
+	customFieldChanges.GET("", h.ListChangesByItem)
+}
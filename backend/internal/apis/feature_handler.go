@@ -6,7 +6,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/dannyswat/pjeasy/internal/custom_fields"
 	"github.com/dannyswat/pjeasy/internal/features"
+	"github.com/dannyswat/pjeasy/internal/projects"
 	"github.com/labstack/echo/v4"
 )
 
@@ -21,35 +23,39 @@ func NewFeatureHandler(featureService *features.FeatureService) *FeatureHandler
 }
 
 type CreateFeatureRequest struct {
-	Title              string  `json:"title" validate:"required"`
-	Description        string  `json:"description"`
-	Priority           string  `json:"priority"`
-	AssignedTo         int     `json:"assignedTo"`
-	SprintID           int     `json:"sprintId"`
-	Points             int     `json:"points"`
-	Deadline           *string `json:"deadline"` // ISO 8601 format
-	ReleaseID          *int    `json:"releaseId"`
-	DependsOnFeatureID *int    `json:"dependsOnFeatureId"`
-	ItemType           string  `json:"itemType"`
-	ItemID             *int    `json:"itemId"`
-	Tags               string  `json:"tags"`
-	CascadeCompletion  bool    `json:"cascadeCompletion"`
+	Title               string               `json:"title" validate:"required"`
+	Description         string               `json:"description"`
+	Priority            string               `json:"priority"`
+	AssignedTo          int                  `json:"assignedTo"`
+	SprintID            int                  `json:"sprintId"`
+	Points              int                  `json:"points"`
+	Deadline            *string              `json:"deadline"` // ISO 8601 format
+	ReleaseID           *int                 `json:"releaseId"`
+	DependsOnFeatureID  *int                 `json:"dependsOnFeatureId"`  // Used when dependsOnFeatureIds is not set
+	DependsOnFeatureIDs []int                `json:"dependsOnFeatureIds"` // Replaces all dependencies when set
+	ItemType            string               `json:"itemType"`
+	ItemID              *int                 `json:"itemId"`
+	Tags                string               `json:"tags"`
+	CascadeCompletion   bool                 `json:"cascadeCompletion"`
+	CustomFields        custom_fields.Values `json:"customFields"`
 }
 
 type UpdateFeatureRequest struct {
-	Title              string  `json:"title" validate:"required"`
-	Description        string  `json:"description"`
-	Priority           string  `json:"priority"`
-	AssignedTo         int     `json:"assignedTo"`
-	SprintID           int     `json:"sprintId"`
-	Points             int     `json:"points"`
-	Deadline           *string `json:"deadline"` // ISO 8601 format
-	ReleaseID          *int    `json:"releaseId"`
-	DependsOnFeatureID *int    `json:"dependsOnFeatureId"`
-	ItemType           string  `json:"itemType"`
-	ItemID             *int    `json:"itemId"`
-	Tags               string  `json:"tags"`
-	CascadeCompletion  bool    `json:"cascadeCompletion"`
+	Title               string               `json:"title" validate:"required"`
+	Description         string               `json:"description"`
+	Priority            string               `json:"priority"`
+	AssignedTo          int                  `json:"assignedTo"`
+	SprintID            int                  `json:"sprintId"`
+	Points              int                  `json:"points"`
+	Deadline            *string              `json:"deadline"` // ISO 8601 format
+	ReleaseID           *int                 `json:"releaseId"`
+	DependsOnFeatureID  *int                 `json:"dependsOnFeatureId"`  // Replaces the oldest dependency when dependsOnFeatureIds is not set
+	DependsOnFeatureIDs []int                `json:"dependsOnFeatureIds"` // Replaces all dependencies when set
+	ItemType            string               `json:"itemType"`
+	ItemID              *int                 `json:"itemId"`
+	Tags                string               `json:"tags"`
+	CascadeCompletion   bool                 `json:"cascadeCompletion"`
+	CustomFields        custom_fields.Values `json:"customFields"`
 }
 
 type UpdateFeatureStatusRequest struct {
@@ -66,27 +72,66 @@ type UpdateFeatureAssigneeRequest struct {
 }
 
 type FeatureResponse struct {
-	ID                 int     `json:"id"`
-	RefNum             string  `json:"refNum"`
-	ProjectID          int     `json:"projectId"`
-	Title              string  `json:"title"`
-	Description        string  `json:"description"`
-	Status             string  `json:"status"`
-	Priority           string  `json:"priority"`
-	AssignedTo         int     `json:"assignedTo,omitempty"`
-	SprintID           int     `json:"sprintId,omitempty"`
-	Points             int     `json:"points"`
-	Deadline           *string `json:"deadline,omitempty"`
-	ReleaseID          *int    `json:"releaseId,omitempty"`
-	DependsOnFeatureID *int    `json:"dependsOnFeatureId,omitempty"`
-	ItemType           string  `json:"itemType,omitempty"`
-	ItemID             *int    `json:"itemId,omitempty"`
-	LinkedIdeaLabel    string  `json:"linkedIdeaLabel,omitempty"`
-	Tags               string  `json:"tags,omitempty"`
-	CascadeCompletion  bool    `json:"cascadeCompletion"`
-	CreatedBy          int     `json:"createdBy"`
-	CreatedAt          string  `json:"createdAt"`
-	UpdatedAt          string  `json:"updatedAt"`
+	ID                  int                  `json:"id"`
+	RefNum              string               `json:"refNum"`
+	ProjectID           int                  `json:"projectId"`
+	Title               string               `json:"title"`
+	Description         string               `json:"description"`
+	Status              string               `json:"status"`
+	Priority            string               `json:"priority"`
+	AssignedTo          int                  `json:"assignedTo,omitempty"`
+	SprintID            int                  `json:"sprintId,omitempty"`
+	Points              int                  `json:"points"`
+	Deadline            *string              `json:"deadline,omitempty"`
+	ReleaseID           *int                 `json:"releaseId,omitempty"`
+	DependsOnFeatureID  *int                 `json:"dependsOnFeatureId,omitempty"`
+	DependsOnFeatureIDs []int                `json:"dependsOnFeatureIds,omitempty"`
+	ItemType            string               `json:"itemType,omitempty"`
+	ItemID              *int                 `json:"itemId,omitempty"`
+	LinkedIdeaLabel     string               `json:"linkedIdeaLabel,omitempty"`
+	Tags                string               `json:"tags,omitempty"`
+	CascadeCompletion   bool                 `json:"cascadeCompletion"`
+	CustomFields        custom_fields.Values `json:"customFields,omitempty"`
+	CreatedBy           int                  `json:"createdBy"`
+	CreatedAt           string               `json:"createdAt"`
+	UpdatedAt           string               `json:"updatedAt"`
+}
+
+type DependencyNodeResponse struct {
+	FeatureID         int     `json:"featureId"`
+	RefNum            string  `json:"refNum"`
+	Title             string  `json:"title"`
+	Status            string  `json:"status"`
+	Points            int     `json:"points"`
+	Deadline          *string `json:"deadline,omitempty"`
+	EstimatedHours    float64 `json:"estimatedHours"`
+	DurationDays      int     `json:"durationDays"`
+	DurationSource    string  `json:"durationSource"` // estimate, points, deadline, default or done
+	EarliestStartDay  int     `json:"earliestStartDay"`
+	EarliestFinishDay int     `json:"earliestFinishDay"`
+	LatestStartDay    int     `json:"latestStartDay"`
+	LatestFinishDay   int     `json:"latestFinishDay"`
+	SlackDays         int     `json:"slackDays"`
+	EarliestStart     string  `json:"earliestStart"`
+	EarliestFinish    string  `json:"earliestFinish"`
+	Critical          bool    `json:"critical"`
+	Late              bool    `json:"late"`
+}
+
+type DependencyEdgeResponse struct {
+	LinkID        int  `json:"linkId"`
+	FromFeatureID int  `json:"fromFeatureId"` // The dependency
+	ToFeatureID   int  `json:"toFeatureId"`   // The feature depending on it
+	Critical      bool `json:"critical"`
+}
+
+type DependencyGraphResponse struct {
+	Nodes        []DependencyNodeResponse `json:"nodes"`
+	Edges        []DependencyEdgeResponse `json:"edges"`
+	CriticalPath []int                    `json:"criticalPath"`
+	DurationDays int                      `json:"durationDays"`
+	Start        string                   `json:"start"`
+	Finish       string                   `json:"finish"`
 }
 
 type FeaturesListResponse struct {
@@ -100,6 +145,17 @@ type BatchUpdateFeatureStatusResponse struct {
 	Features []FeatureResponse `json:"features"`
 }
 
+// dependencyIDs returns the dependencies of a new feature, accepting the single dependency of older clients
+func dependencyIDs(dependsOnFeatureIDs []int, dependsOnFeatureID *int) []int {
+	if dependsOnFeatureIDs != nil {
+		return dependsOnFeatureIDs
+	}
+	if dependsOnFeatureID != nil {
+		return []int{*dependsOnFeatureID}
+	}
+	return nil
+}
+
 // toFeatureResponse converts a feature model to response
 func toFeatureResponse(feature *features.Feature) FeatureResponse {
 	var deadline *string
@@ -109,27 +165,29 @@ func toFeatureResponse(feature *features.Feature) FeatureResponse {
 	}
 
 	return FeatureResponse{
-		ID:                 feature.ID,
-		RefNum:             feature.RefNum,
-		ProjectID:          feature.ProjectID,
-		Title:              feature.Title,
-		Description:        feature.Description,
-		Status:             feature.Status,
-		Priority:           feature.Priority,
-		AssignedTo:         feature.AssignedTo,
-		SprintID:           feature.SprintID,
-		Points:             feature.Points,
-		Deadline:           deadline,
-		ReleaseID:          feature.ReleaseID,
-		DependsOnFeatureID: feature.DependsOnFeatureID,
-		ItemType:           feature.ItemType,
-		ItemID:             feature.ItemID,
-		LinkedIdeaLabel:    feature.LinkedIdeaLabel,
-		Tags:               feature.Tags,
-		CascadeCompletion:  feature.CascadeCompletion,
-		CreatedBy:          feature.CreatedBy,
-		CreatedAt:          feature.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:          feature.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		ID:                  feature.ID,
+		RefNum:              feature.RefNum,
+		ProjectID:           feature.ProjectID,
+		Title:               feature.Title,
+		Description:         feature.Description,
+		Status:              feature.Status,
+		Priority:            feature.Priority,
+		AssignedTo:          feature.AssignedTo,
+		SprintID:            feature.SprintID,
+		Points:              feature.Points,
+		Deadline:            deadline,
+		ReleaseID:           feature.ReleaseID,
+		DependsOnFeatureID:  feature.DependsOnFeatureID,
+		DependsOnFeatureIDs: feature.DependsOnFeatureIDs,
+		ItemType:            feature.ItemType,
+		ItemID:              feature.ItemID,
+		LinkedIdeaLabel:     feature.LinkedIdeaLabel,
+		Tags:                feature.Tags,
+		CascadeCompletion:   feature.CascadeCompletion,
+		CustomFields:        feature.CustomFields,
+		CreatedBy:           feature.CreatedBy,
+		CreatedAt:           feature.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:           feature.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 	}
 }
 
@@ -173,7 +231,7 @@ func (h *FeatureHandler) CreateFeature(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	deadline, err := parseDeadline(req.Deadline)
@@ -181,9 +239,9 @@ func (h *FeatureHandler) CreateFeature(c echo.Context) error {
 		return err
 	}
 
-	feature, err := h.featureService.CreateFeature(projectID, req.Title, req.Description, req.Priority, req.AssignedTo, req.SprintID, req.Points, deadline, req.ReleaseID, req.DependsOnFeatureID, req.ItemType, req.ItemID, req.Tags, req.CascadeCompletion, userID)
+	feature, err := h.featureService.CreateFeature(projectID, req.Title, req.Description, req.Priority, req.AssignedTo, req.SprintID, req.Points, deadline, req.ReleaseID, dependencyIDs(req.DependsOnFeatureIDs, req.DependsOnFeatureID), req.ItemType, req.ItemID, req.Tags, req.CascadeCompletion, req.CustomFields, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	response := toFeatureResponse(feature)
@@ -208,7 +266,7 @@ func (h *FeatureHandler) UpdateFeature(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	deadline, err := parseDeadline(req.Deadline)
@@ -216,9 +274,9 @@ func (h *FeatureHandler) UpdateFeature(c echo.Context) error {
 		return err
 	}
 
-	feature, err := h.featureService.UpdateFeature(featureID, req.Title, req.Description, req.Priority, req.AssignedTo, req.SprintID, req.Points, deadline, req.ReleaseID, req.DependsOnFeatureID, req.ItemType, req.ItemID, req.Tags, req.CascadeCompletion, userID)
+	feature, err := h.featureService.UpdateFeature(featureID, req.Title, req.Description, req.Priority, req.AssignedTo, req.SprintID, req.Points, deadline, req.ReleaseID, req.DependsOnFeatureIDs, req.DependsOnFeatureID, req.ItemType, req.ItemID, req.Tags, req.CascadeCompletion, req.CustomFields, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	response := toFeatureResponse(feature)
@@ -243,12 +301,12 @@ func (h *FeatureHandler) UpdateFeatureStatus(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	feature, err := h.featureService.UpdateFeatureStatus(featureID, req.Status, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	response := toFeatureResponse(feature)
@@ -273,14 +331,14 @@ func (h *FeatureHandler) BatchUpdateFeatureStatus(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	updatedFeatures := make([]FeatureResponse, 0, len(req.FeatureIDs))
 	for _, featureID := range req.FeatureIDs {
 		feature, err := h.featureService.GetFeature(featureID, userID)
 		if err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 		}
 		if feature.ProjectID != projectID {
 			return echo.NewHTTPError(http.StatusBadRequest, "feature does not belong to project")
@@ -288,7 +346,7 @@ func (h *FeatureHandler) BatchUpdateFeatureStatus(c echo.Context) error {
 
 		updatedFeature, err := h.featureService.UpdateFeatureStatus(featureID, req.Status, userID)
 		if err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 		}
 
 		updatedFeatures = append(updatedFeatures, toFeatureResponse(updatedFeature))
@@ -315,12 +373,12 @@ func (h *FeatureHandler) UpdateFeatureAssignee(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	feature, err := h.featureService.UpdateFeatureAssignee(featureID, req.AssignedTo, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	response := toFeatureResponse(feature)
@@ -340,7 +398,7 @@ func (h *FeatureHandler) DeleteFeature(c echo.Context) error {
 	}
 
 	if err := h.featureService.DeleteFeature(featureID, userID); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{"message": "Feature deleted successfully"})
@@ -360,7 +418,7 @@ func (h *FeatureHandler) GetFeature(c echo.Context) error {
 
 	feature, err := h.featureService.GetFeature(featureID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	response := toFeatureResponse(feature)
@@ -419,9 +477,9 @@ func (h *FeatureHandler) GetProjectFeatures(c echo.Context) error {
 		selectedFeatureID = &selectedID
 	}
 
-	featureList, total, err := h.featureService.GetProjectFeatures(projectID, statuses, priority, search, excludeFeatureID, dependencySelectable, selectedFeatureID, page, pageSize, userID)
+	featureList, total, err := h.featureService.GetProjectFeatures(projectID, statuses, priority, search, excludeFeatureID, dependencySelectable, selectedFeatureID, customFieldFilters(c), page, pageSize, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	featuresResp := make([]FeatureResponse, len(featureList))
@@ -466,7 +524,7 @@ func (h *FeatureHandler) GetMyFeatures(c echo.Context) error {
 
 	featuresList, total, err := h.featureService.GetMyFeatures(projectID, search, page, pageSize, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	featuresResp := make([]FeatureResponse, len(featuresList))
@@ -519,7 +577,7 @@ func (h *FeatureHandler) GetFeaturesByItemReference(c echo.Context) error {
 
 	featureList, total, err := h.featureService.GetFeaturesByItemReference(projectID, itemType, itemID, page, pageSize, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	featuresResp := make([]FeatureResponse, len(featureList))
@@ -537,15 +595,81 @@ func (h *FeatureHandler) GetFeaturesByItemReference(c echo.Context) error {
 	return c.JSON(http.StatusOK, response)
 }
 
+// GetDependencyGraph returns the feature dependency graph of a project with its schedule and critical path
+func (h *FeatureHandler) GetDependencyGraph(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := strconv.Atoi(c.Param("projectId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid project ID")
+	}
+
+	graph, err := h.featureService.GetDependencyGraph(projectID, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	const dateLayout = "2006-01-02"
+	response := DependencyGraphResponse{
+		Nodes:        make([]DependencyNodeResponse, len(graph.Nodes)),
+		Edges:        make([]DependencyEdgeResponse, len(graph.Edges)),
+		CriticalPath: graph.CriticalPath,
+		DurationDays: graph.DurationDays,
+		Start:        graph.Start.Format(dateLayout),
+		Finish:       graph.Finish.Format(dateLayout),
+	}
+	for i, node := range graph.Nodes {
+		var deadline *string
+		if node.Feature.Deadline != nil {
+			formatted := node.Feature.Deadline.Format("2006-01-02T15:04:05Z07:00")
+			deadline = &formatted
+		}
+		response.Nodes[i] = DependencyNodeResponse{
+			FeatureID:         node.Feature.ID,
+			RefNum:            node.Feature.RefNum,
+			Title:             node.Feature.Title,
+			Status:            node.Feature.Status,
+			Points:            node.Feature.Points,
+			Deadline:          deadline,
+			EstimatedHours:    node.EstimatedHours,
+			DurationDays:      node.DurationDays,
+			DurationSource:    node.DurationSource,
+			EarliestStartDay:  node.EarliestStartDay,
+			EarliestFinishDay: node.EarliestFinishDay,
+			LatestStartDay:    node.LatestStartDay,
+			LatestFinishDay:   node.LatestFinishDay,
+			SlackDays:         node.SlackDays,
+			EarliestStart:     node.EarliestStart.Format(dateLayout),
+			EarliestFinish:    node.EarliestFinish.Format(dateLayout),
+			Critical:          node.Critical,
+			Late:              node.Late,
+		}
+	}
+	for i, edge := range graph.Edges {
+		response.Edges[i] = DependencyEdgeResponse{
+			LinkID:        edge.LinkID,
+			FromFeatureID: edge.FromFeatureID,
+			ToFeatureID:   edge.ToFeatureID,
+			Critical:      edge.Critical,
+		}
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
 // RegisterRoutes registers feature-related routes
 func (h *FeatureHandler) RegisterRoutes(e *echo.Echo, authMiddleware *AuthMiddleware, projectMiddleware *ProjectMiddleware) {
 	featuresGroup := e.Group("/api/projects/:projectId/features", authMiddleware.RequireAuth, projectMiddleware.RequireProjectMember)
 
-	featuresGroup.POST("", h.CreateFeature)
+	featuresGroup.POST("", h.CreateFeature, projectMiddleware.RequirePermission(projects.PermissionItemFeature, projects.PermissionActionCreate))
 	featuresGroup.GET("", h.GetProjectFeatures)
 	featuresGroup.GET("/my-features", h.GetMyFeatures)
 	featuresGroup.GET("/by-item", h.GetFeaturesByItemReference)
-	featuresGroup.PATCH("/status", h.BatchUpdateFeatureStatus)
+	featuresGroup.GET("/dependency-graph", h.GetDependencyGraph)
+	featuresGroup.PATCH("/status", h.BatchUpdateFeatureStatus, projectMiddleware.RequirePermission(projects.PermissionItemFeature, projects.PermissionActionChangeStatus))
 
 	featureItem := e.Group("/api/features/:id", authMiddleware.RequireAuth)
 
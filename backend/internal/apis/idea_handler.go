@@ -5,7 +5,9 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/dannyswat/pjeasy/internal/custom_fields"
 	"github.com/dannyswat/pjeasy/internal/ideas"
+	"github.com/dannyswat/pjeasy/internal/projects"
 	"github.com/labstack/echo/v4"
 )
 
@@ -20,23 +22,25 @@ func NewIdeaHandler(ideaService *ideas.IdeaService) *IdeaHandler {
 }
 
 type CreateIdeaRequest struct {
-	Title             string `json:"title" validate:"required"`
-	Label             string `json:"label" validate:"max=100"`
-	Description       string `json:"description"`
-	ReleaseID         *int   `json:"releaseId"`
-	ItemType          string `json:"itemType"`
-	ItemID            *int   `json:"itemId"`
-	Tags              string `json:"tags"`
-	CascadeCompletion bool   `json:"cascadeCompletion"`
+	Title             string               `json:"title" validate:"required"`
+	Label             string               `json:"label" validate:"max=100"`
+	Description       string               `json:"description"`
+	ReleaseID         *int                 `json:"releaseId"`
+	ItemType          string               `json:"itemType"`
+	ItemID            *int                 `json:"itemId"`
+	Tags              string               `json:"tags"`
+	CascadeCompletion bool                 `json:"cascadeCompletion"`
+	CustomFields      custom_fields.Values `json:"customFields"`
 }
 
 type UpdateIdeaRequest struct {
-	Title             string `json:"title" validate:"required"`
-	Label             string `json:"label" validate:"max=100"`
-	Description       string `json:"description"`
-	ReleaseID         *int   `json:"releaseId"`
-	Tags              string `json:"tags"`
-	CascadeCompletion bool   `json:"cascadeCompletion"`
+	Title             string               `json:"title" validate:"required"`
+	Label             string               `json:"label" validate:"max=100"`
+	Description       string               `json:"description"`
+	ReleaseID         *int                 `json:"releaseId"`
+	Tags              string               `json:"tags"`
+	CascadeCompletion bool                 `json:"cascadeCompletion"`
+	CustomFields      custom_fields.Values `json:"customFields"`
 }
 
 type UpdateIdeaStatusRequest struct {
@@ -44,21 +48,22 @@ type UpdateIdeaStatusRequest struct {
 }
 
 type IdeaResponse struct {
-	ID                int    `json:"id"`
-	RefNum            string `json:"refNum"`
-	ProjectID         int    `json:"projectId"`
-	Title             string `json:"title"`
-	Label             string `json:"label,omitempty"`
-	Description       string `json:"description"`
-	Status            string `json:"status"`
-	ReleaseID         *int   `json:"releaseId,omitempty"`
-	ItemType          string `json:"itemType,omitempty"`
-	ItemID            *int   `json:"itemId,omitempty"`
-	Tags              string `json:"tags,omitempty"`
-	CascadeCompletion bool   `json:"cascadeCompletion"`
-	CreatedBy         int    `json:"createdBy"`
-	CreatedAt         string `json:"createdAt"`
-	UpdatedAt         string `json:"updatedAt"`
+	ID                int                  `json:"id"`
+	RefNum            string               `json:"refNum"`
+	ProjectID         int                  `json:"projectId"`
+	Title             string               `json:"title"`
+	Label             string               `json:"label,omitempty"`
+	Description       string               `json:"description"`
+	Status            string               `json:"status"`
+	ReleaseID         *int                 `json:"releaseId,omitempty"`
+	ItemType          string               `json:"itemType,omitempty"`
+	ItemID            *int                 `json:"itemId,omitempty"`
+	Tags              string               `json:"tags,omitempty"`
+	CascadeCompletion bool                 `json:"cascadeCompletion"`
+	CustomFields      custom_fields.Values `json:"customFields,omitempty"`
+	CreatedBy         int                  `json:"createdBy"`
+	CreatedAt         string               `json:"createdAt"`
+	UpdatedAt         string               `json:"updatedAt"`
 }
 
 type IdeasListResponse struct {
@@ -83,6 +88,7 @@ func toIdeaResponse(idea *ideas.Idea) IdeaResponse {
 		ItemID:            idea.ItemID,
 		Tags:              idea.Tags,
 		CascadeCompletion: idea.CascadeCompletion,
+		CustomFields:      idea.CustomFields,
 		CreatedBy:         idea.CreatedBy,
 		CreatedAt:         idea.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		UpdatedAt:         idea.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
@@ -107,12 +113,12 @@ func (h *IdeaHandler) CreateIdea(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
-	idea, err := h.ideaService.CreateIdea(projectID, req.Title, req.Label, req.Description, req.ReleaseID, req.ItemType, req.ItemID, req.Tags, req.CascadeCompletion, userID)
+	idea, err := h.ideaService.CreateIdea(projectID, req.Title, req.Label, req.Description, req.ReleaseID, req.ItemType, req.ItemID, req.Tags, req.CascadeCompletion, req.CustomFields, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	response := toIdeaResponse(idea)
@@ -137,12 +143,12 @@ func (h *IdeaHandler) UpdateIdea(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
-	idea, err := h.ideaService.UpdateIdea(ideaID, req.Title, req.Label, req.Description, req.ReleaseID, req.Tags, req.CascadeCompletion, userID)
+	idea, err := h.ideaService.UpdateIdea(ideaID, req.Title, req.Label, req.Description, req.ReleaseID, req.Tags, req.CascadeCompletion, req.CustomFields, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	response := toIdeaResponse(idea)
@@ -167,12 +173,12 @@ func (h *IdeaHandler) UpdateIdeaStatus(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	idea, err := h.ideaService.UpdateIdeaStatus(ideaID, req.Status, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	response := toIdeaResponse(idea)
@@ -192,7 +198,7 @@ func (h *IdeaHandler) DeleteIdea(c echo.Context) error {
 	}
 
 	if err := h.ideaService.DeleteIdea(ideaID, userID); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{"message": "Idea deleted successfully"})
@@ -212,7 +218,7 @@ func (h *IdeaHandler) GetIdea(c echo.Context) error {
 
 	idea, err := h.ideaService.GetIdea(ideaID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	response := toIdeaResponse(idea)
@@ -249,9 +255,9 @@ func (h *IdeaHandler) GetProjectIdeas(c echo.Context) error {
 		statuses = strings.Split(statusParam, ",")
 	}
 
-	ideaList, total, err := h.ideaService.GetProjectIdeas(projectID, statuses, page, pageSize, userID)
+	ideaList, total, err := h.ideaService.GetProjectIdeas(projectID, statuses, customFieldFilters(c), page, pageSize, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	ideas := make([]IdeaResponse, len(ideaList))
@@ -314,7 +320,7 @@ func (h *IdeaHandler) GetIdeasByItemReference(c echo.Context) error {
 
 	ideaList, total, err := h.ideaService.GetIdeasByItemReference(projectID, itemType, itemID, page, pageSize, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	ideas := make([]IdeaResponse, len(ideaList))
@@ -336,7 +342,7 @@ func (h *IdeaHandler) GetIdeasByItemReference(c echo.Context) error {
 func (h *IdeaHandler) RegisterRoutes(e *echo.Echo, authMiddleware *AuthMiddleware, projectMiddleware *ProjectMiddleware) {
 	ideas := e.Group("/api/projects/:projectId/ideas", authMiddleware.RequireAuth, projectMiddleware.RequireProjectMember)
 
-	ideas.POST("", h.CreateIdea)
+	ideas.POST("", h.CreateIdea, projectMiddleware.RequirePermission(projects.PermissionItemIdea, projects.PermissionActionCreate))
 	ideas.GET("", h.GetProjectIdeas)
 	ideas.GET("/by-item", h.GetIdeasByItemReference)
 
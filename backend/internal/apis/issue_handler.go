@@ -5,7 +5,9 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/dannyswat/pjeasy/internal/custom_fields"
 	"github.com/dannyswat/pjeasy/internal/issues"
+	"github.com/dannyswat/pjeasy/internal/projects"
 	"github.com/labstack/echo/v4"
 )
 
@@ -20,31 +22,33 @@ func NewIssueHandler(issueService *issues.IssueService) *IssueHandler {
 }
 
 type CreateIssueRequest struct {
-	Title             string `json:"title" validate:"required"`
-	Description       string `json:"description"`
-	Priority          string `json:"priority"`
-	AssignedTo        int    `json:"assignedTo"`
-	SprintID          int    `json:"sprintId"`
-	Points            int    `json:"points"`
-	ReleaseID         *int   `json:"releaseId"`
-	ItemType          string `json:"itemType"`
-	ItemID            *int   `json:"itemId"`
-	Tags              string `json:"tags"`
-	CascadeCompletion bool   `json:"cascadeCompletion"`
+	Title             string               `json:"title" validate:"required"`
+	Description       string               `json:"description"`
+	Priority          string               `json:"priority"`
+	AssignedTo        int                  `json:"assignedTo"`
+	SprintID          int                  `json:"sprintId"`
+	Points            int                  `json:"points"`
+	ReleaseID         *int                 `json:"releaseId"`
+	ItemType          string               `json:"itemType"`
+	ItemID            *int                 `json:"itemId"`
+	Tags              string               `json:"tags"`
+	CascadeCompletion bool                 `json:"cascadeCompletion"`
+	CustomFields      custom_fields.Values `json:"customFields"`
 }
 
 type UpdateIssueRequest struct {
-	Title             string `json:"title" validate:"required"`
-	Description       string `json:"description"`
-	Priority          string `json:"priority"`
-	AssignedTo        int    `json:"assignedTo"`
-	SprintID          int    `json:"sprintId"`
-	Points            int    `json:"points"`
-	ReleaseID         *int   `json:"releaseId"`
-	ItemType          string `json:"itemType"`
-	ItemID            *int   `json:"itemId"`
-	Tags              string `json:"tags"`
-	CascadeCompletion bool   `json:"cascadeCompletion"`
+	Title             string               `json:"title" validate:"required"`
+	Description       string               `json:"description"`
+	Priority          string               `json:"priority"`
+	AssignedTo        int                  `json:"assignedTo"`
+	SprintID          int                  `json:"sprintId"`
+	Points            int                  `json:"points"`
+	ReleaseID         *int                 `json:"releaseId"`
+	ItemType          string               `json:"itemType"`
+	ItemID            *int                 `json:"itemId"`
+	Tags              string               `json:"tags"`
+	CascadeCompletion bool                 `json:"cascadeCompletion"`
+	CustomFields      custom_fields.Values `json:"customFields"`
 }
 
 type UpdateIssueStatusRequest struct {
@@ -61,25 +65,26 @@ type UpdateIssueAssigneeRequest struct {
 }
 
 type IssueResponse struct {
-	ID                int    `json:"id"`
-	RefNum            string `json:"refNum"`
-	ProjectID         int    `json:"projectId"`
-	Title             string `json:"title"`
-	Description       string `json:"description"`
-	Status            string `json:"status"`
-	Priority          string `json:"priority"`
-	AssignedTo        int    `json:"assignedTo,omitempty"`
-	SprintID          int    `json:"sprintId,omitempty"`
-	Points            int    `json:"points"`
-	ReleaseID         *int   `json:"releaseId,omitempty"`
-	ItemType          string `json:"itemType,omitempty"`
-	ItemID            *int   `json:"itemId,omitempty"`
-	LinkedIdeaLabel   string `json:"linkedIdeaLabel,omitempty"`
-	Tags              string `json:"tags,omitempty"`
-	CascadeCompletion bool   `json:"cascadeCompletion"`
-	CreatedBy         int    `json:"createdBy"`
-	CreatedAt         string `json:"createdAt"`
-	UpdatedAt         string `json:"updatedAt"`
+	ID                int                  `json:"id"`
+	RefNum            string               `json:"refNum"`
+	ProjectID         int                  `json:"projectId"`
+	Title             string               `json:"title"`
+	Description       string               `json:"description"`
+	Status            string               `json:"status"`
+	Priority          string               `json:"priority"`
+	AssignedTo        int                  `json:"assignedTo,omitempty"`
+	SprintID          int                  `json:"sprintId,omitempty"`
+	Points            int                  `json:"points"`
+	ReleaseID         *int                 `json:"releaseId,omitempty"`
+	ItemType          string               `json:"itemType,omitempty"`
+	ItemID            *int                 `json:"itemId,omitempty"`
+	LinkedIdeaLabel   string               `json:"linkedIdeaLabel,omitempty"`
+	Tags              string               `json:"tags,omitempty"`
+	CascadeCompletion bool                 `json:"cascadeCompletion"`
+	CustomFields      custom_fields.Values `json:"customFields,omitempty"`
+	CreatedBy         int                  `json:"createdBy"`
+	CreatedAt         string               `json:"createdAt"`
+	UpdatedAt         string               `json:"updatedAt"`
 }
 
 type IssuesListResponse struct {
@@ -112,6 +117,7 @@ func toIssueResponse(issue *issues.Issue) IssueResponse {
 		LinkedIdeaLabel:   issue.LinkedIdeaLabel,
 		Tags:              issue.Tags,
 		CascadeCompletion: issue.CascadeCompletion,
+		CustomFields:      issue.CustomFields,
 		CreatedBy:         issue.CreatedBy,
 		CreatedAt:         issue.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		UpdatedAt:         issue.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
@@ -136,12 +142,12 @@ func (h *IssueHandler) CreateIssue(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
-	issue, err := h.issueService.CreateIssue(projectID, req.Title, req.Description, req.Priority, req.AssignedTo, req.SprintID, req.Points, req.ReleaseID, req.ItemType, req.ItemID, req.Tags, req.CascadeCompletion, userID)
+	issue, err := h.issueService.CreateIssue(projectID, req.Title, req.Description, req.Priority, req.AssignedTo, req.SprintID, req.Points, req.ReleaseID, req.ItemType, req.ItemID, req.Tags, req.CascadeCompletion, req.CustomFields, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	response := toIssueResponse(issue)
@@ -166,12 +172,12 @@ func (h *IssueHandler) UpdateIssue(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
-	issue, err := h.issueService.UpdateIssue(issueID, req.Title, req.Description, req.Priority, req.AssignedTo, req.SprintID, req.Points, req.ReleaseID, req.ItemType, req.ItemID, req.Tags, req.CascadeCompletion, userID)
+	issue, err := h.issueService.UpdateIssue(issueID, req.Title, req.Description, req.Priority, req.AssignedTo, req.SprintID, req.Points, req.ReleaseID, req.ItemType, req.ItemID, req.Tags, req.CascadeCompletion, req.CustomFields, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	response := toIssueResponse(issue)
@@ -196,12 +202,12 @@ func (h *IssueHandler) UpdateIssueStatus(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	issue, err := h.issueService.UpdateIssueStatus(issueID, req.Status, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	response := toIssueResponse(issue)
@@ -226,14 +232,14 @@ func (h *IssueHandler) BatchUpdateIssueStatus(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	updatedIssues := make([]IssueResponse, 0, len(req.IssueIDs))
 	for _, issueID := range req.IssueIDs {
 		issue, err := h.issueService.GetIssue(issueID, userID)
 		if err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 		}
 		if issue.ProjectID != projectID {
 			return echo.NewHTTPError(http.StatusBadRequest, "issue does not belong to project")
@@ -241,7 +247,7 @@ func (h *IssueHandler) BatchUpdateIssueStatus(c echo.Context) error {
 
 		updatedIssue, err := h.issueService.UpdateIssueStatus(issueID, req.Status, userID)
 		if err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 		}
 
 		updatedIssues = append(updatedIssues, toIssueResponse(updatedIssue))
@@ -268,12 +274,12 @@ func (h *IssueHandler) UpdateIssueAssignee(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	issue, err := h.issueService.UpdateIssueAssignee(issueID, req.AssignedTo, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	response := toIssueResponse(issue)
@@ -293,7 +299,7 @@ func (h *IssueHandler) DeleteIssue(c echo.Context) error {
 	}
 
 	if err := h.issueService.DeleteIssue(issueID, userID); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{"message": "Issue deleted successfully"})
@@ -313,7 +319,7 @@ func (h *IssueHandler) GetIssue(c echo.Context) error {
 
 	issue, err := h.issueService.GetIssue(issueID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	response := toIssueResponse(issue)
@@ -352,9 +358,9 @@ func (h *IssueHandler) GetProjectIssues(c echo.Context) error {
 
 	priority := c.QueryParam("priority")
 
-	issueList, total, err := h.issueService.GetProjectIssues(projectID, statuses, priority, page, pageSize, userID)
+	issueList, total, err := h.issueService.GetProjectIssues(projectID, statuses, priority, customFieldFilters(c), page, pageSize, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	issuesResp := make([]IssueResponse, len(issueList))
@@ -397,7 +403,7 @@ func (h *IssueHandler) GetMyIssues(c echo.Context) error {
 
 	issueList, total, err := h.issueService.GetMyIssues(projectID, userID, page, pageSize)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	issuesResp := make([]IssueResponse, len(issueList))
@@ -460,7 +466,7 @@ func (h *IssueHandler) GetIssuesByItemReference(c echo.Context) error {
 
 	issueList, total, err := h.issueService.GetIssuesByItemReference(projectID, itemType, itemID, page, pageSize, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	issuesResp := make([]IssueResponse, len(issueList))
@@ -482,11 +488,11 @@ func (h *IssueHandler) GetIssuesByItemReference(c echo.Context) error {
 func (h *IssueHandler) RegisterRoutes(e *echo.Echo, authMiddleware *AuthMiddleware, projectMiddleware *ProjectMiddleware) {
 	issuesGroup := e.Group("/api/projects/:projectId/issues", authMiddleware.RequireAuth, projectMiddleware.RequireProjectMember)
 
-	issuesGroup.POST("", h.CreateIssue)
+	issuesGroup.POST("", h.CreateIssue, projectMiddleware.RequirePermission(projects.PermissionItemIssue, projects.PermissionActionCreate))
 	issuesGroup.GET("", h.GetProjectIssues)
 	issuesGroup.GET("/my-issues", h.GetMyIssues)
 	issuesGroup.GET("/by-item", h.GetIssuesByItemReference)
-	issuesGroup.PATCH("/status", h.BatchUpdateIssueStatus)
+	issuesGroup.PATCH("/status", h.BatchUpdateIssueStatus, projectMiddleware.RequirePermission(projects.PermissionItemIssue, projects.PermissionActionChangeStatus))
 
 	issueItem := e.Group("/api/issues/:id", authMiddleware.RequireAuth)
 
@@ -98,7 +98,7 @@ func (h *ItemFollowUpHandler) CreateItemFollowUp(c echo.Context) error {
 
 	followUp, err := h.service.CreateItemFollowUp(itemID, itemType, followUpDate, req.Content, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusCreated, toItemFollowUpResponse(followUp))
@@ -122,7 +122,7 @@ func (h *ItemFollowUpHandler) GetItemFollowUpsByItem(c echo.Context) error {
 
 	followUps, err := h.service.GetItemFollowUpsByItem(itemID, itemType, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	responses := make([]ItemFollowUpResponse, 0, len(followUps))
@@ -152,7 +152,7 @@ func (h *ItemFollowUpHandler) GetItemFollowUp(c echo.Context) error {
 
 	followUp, err := h.service.GetItemFollowUp(followUpID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toItemFollowUpResponse(followUp))
@@ -185,12 +185,12 @@ func (h *ItemFollowUpHandler) UpdateItemFollowUp(c echo.Context) error {
 	followUp, err := h.service.UpdateItemFollowUp(followUpID, followUpDate, req.Content, userID)
 	if err != nil {
 		if err.Error() == "item follow-up not found" {
-			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
 		}
 		if err.Error() == "unauthorized: you can only edit your own follow-ups" {
-			return echo.NewHTTPError(http.StatusForbidden, err.Error())
+			return echo.NewHTTPError(http.StatusForbidden, err.Error()).SetInternal(err)
 		}
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toItemFollowUpResponse(followUp))
@@ -209,12 +209,12 @@ func (h *ItemFollowUpHandler) DeleteItemFollowUp(c echo.Context) error {
 
 	if err := h.service.DeleteItemFollowUp(followUpID, userID); err != nil {
 		if err.Error() == "item follow-up not found" {
-			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
 		}
 		if err.Error() == "unauthorized: you can only delete your own follow-ups" {
-			return echo.NewHTTPError(http.StatusForbidden, err.Error())
+			return echo.NewHTTPError(http.StatusForbidden, err.Error()).SetInternal(err)
 		}
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.NoContent(http.StatusNoContent)
@@ -0,0 +1,194 @@
+package apis
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/item_links"
+	"github.com/labstack/echo/v4"
+)
+
+type ItemLinkHandler struct {
+	itemLinkService *item_links.ItemLinkService
+}
+
+func NewItemLinkHandler(itemLinkService *item_links.ItemLinkService) *ItemLinkHandler {
+	return &ItemLinkHandler{itemLinkService: itemLinkService}
+}
+
+type CreateItemLinkRequest struct {
+	SourceType string `json:"sourceType" validate:"required"`
+	SourceID   int    `json:"sourceId" validate:"required"`
+	LinkType   string `json:"linkType" validate:"required"`
+	TargetType string `json:"targetType" validate:"required"`
+	TargetID   int    `json:"targetId" validate:"required"`
+}
+
+type UpdateItemLinkRequest struct {
+	LinkType string `json:"linkType" validate:"required"`
+}
+
+type ItemLinkResponse struct {
+	ID         int       `json:"id"`
+	ProjectID  int       `json:"projectId"`
+	SourceType string    `json:"sourceType"`
+	SourceID   int       `json:"sourceId"`
+	LinkType   string    `json:"linkType"`
+	TargetType string    `json:"targetType"`
+	TargetID   int       `json:"targetId"`
+	CreatedBy  *int      `json:"createdBy,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// ItemLinkViewResponse is a link as seen from the requested item, with the item at the other end
+type ItemLinkViewResponse struct {
+	ItemLinkResponse
+	Direction string                 `json:"direction"`
+	Label     string                 `json:"label"`
+	Item      item_links.ItemSummary `json:"item"`
+}
+
+func toItemLinkResponse(link *item_links.ItemLink) ItemLinkResponse {
+	return ItemLinkResponse{
+		ID:         link.ID,
+		ProjectID:  link.ProjectID,
+		SourceType: link.SourceType,
+		SourceID:   link.SourceID,
+		LinkType:   link.LinkType,
+		TargetType: link.TargetType,
+		TargetID:   link.TargetID,
+		CreatedBy:  link.CreatedBy,
+		CreatedAt:  link.CreatedAt,
+	}
+}
+
+// ListItemLinks returns the links from and to an item, with inverse links labelled as read from the item
+func (h *ItemLinkHandler) ListItemLinks(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := strconv.Atoi(c.Param("projectId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid project ID")
+	}
+
+	itemID, err := strconv.Atoi(c.QueryParam("itemId"))
+	if err != nil || itemID <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid item ID")
+	}
+
+	itemType := c.QueryParam("itemType")
+	if itemType == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Item type is required")
+	}
+
+	views, err := h.itemLinkService.ListItemLinks(projectID, itemType, itemID, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	response := make([]ItemLinkViewResponse, len(views))
+	for i := range views {
+		response[i] = ItemLinkViewResponse{
+			ItemLinkResponse: toItemLinkResponse(&views[i].Link),
+			Direction:        views[i].Direction,
+			Label:            views[i].Label,
+			Item:             views[i].Item,
+		}
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *ItemLinkHandler) CreateItemLink(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := strconv.Atoi(c.Param("projectId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid project ID")
+	}
+
+	req := new(CreateItemLinkRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	link, err := h.itemLinkService.CreateLink(projectID, req.SourceType, req.SourceID, req.LinkType, req.TargetType, req.TargetID, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusCreated, toItemLinkResponse(link))
+}
+
+func (h *ItemLinkHandler) UpdateItemLink(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := strconv.Atoi(c.Param("projectId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid project ID")
+	}
+
+	linkID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid link ID")
+	}
+
+	req := new(UpdateItemLinkRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	link, err := h.itemLinkService.UpdateLink(projectID, linkID, req.LinkType, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, toItemLinkResponse(link))
+}
+
+func (h *ItemLinkHandler) DeleteItemLink(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := strconv.Atoi(c.Param("projectId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid project ID")
+	}
+
+	linkID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid link ID")
+	}
+
+	if err := h.itemLinkService.DeleteLink(projectID, linkID, userID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *ItemLinkHandler) RegisterRoutes(e *echo.Echo, authMiddleware *AuthMiddleware, projectMiddleware *ProjectMiddleware) {
+	itemLinks := e.Group("/api/projects/:projectId/item-links", authMiddleware.RequireAuth, projectMiddleware.RequireProjectMember)
+	itemLinks.GET("", h.ListItemLinks)
+	itemLinks.POST("", h.CreateItemLink)
+	itemLinks.PUT("/:id", h.UpdateItemLink)
+	itemLinks.DELETE("/:id", h.DeleteItemLink)
+}
@@ -0,0 +1,200 @@
+package apis
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dannyswat/pjeasy/internal/item_search"
+	"github.com/labstack/echo/v4"
+)
+
+type ItemSearchHandler struct {
+	searchService *item_search.ItemSearchService
+}
+
+func NewItemSearchHandler(searchService *item_search.ItemSearchService) *ItemSearchHandler {
+	return &ItemSearchHandler{
+		searchService: searchService,
+	}
+}
+
+type ItemSearchResultResponse struct {
+	ItemType   string  `json:"itemType"`
+	ID         int     `json:"id"`
+	ProjectID  int     `json:"projectId"`
+	RefNum     string  `json:"refNum,omitempty"`
+	Title      string  `json:"title"`
+	Status     string  `json:"status"`
+	Priority   string  `json:"priority,omitempty"`
+	AssigneeID *int    `json:"assigneeId,omitempty"`
+	SprintID   *int    `json:"sprintId,omitempty"`
+	ReleaseID  *int    `json:"releaseId,omitempty"`
+	Tags       string  `json:"tags,omitempty"`
+	Deadline   *string `json:"deadline,omitempty"`
+	CreatedBy  int     `json:"createdBy"`
+	CreatedAt  string  `json:"createdAt"`
+	UpdatedAt  string  `json:"updatedAt"`
+}
+
+type ItemSearchResponse struct {
+	Items    []ItemSearchResultResponse `json:"items"`
+	Total    int64                      `json:"total"`
+	Page     int                        `json:"page"`
+	PageSize int                        `json:"pageSize"`
+}
+
+type TextSearchResultResponse struct {
+	ResultType string  `json:"resultType"`
+	ID         int     `json:"id"`
+	ItemType   string  `json:"itemType"`
+	ItemID     int     `json:"itemId"`
+	RefNum     string  `json:"refNum,omitempty"`
+	Title      string  `json:"title"`
+	Snippet    string  `json:"snippet"` // HTML escaped, with the matches wrapped in <mark>
+	Rank       float64 `json:"rank"`
+	UpdatedAt  string  `json:"updatedAt"`
+}
+
+type TextSearchResponse struct {
+	Results  []TextSearchResultResponse `json:"results"`
+	Total    int64                      `json:"total"`
+	Page     int                        `json:"page"`
+	PageSize int                        `json:"pageSize"`
+}
+
+func toItemSearchResultResponse(item *item_search.Item) ItemSearchResultResponse {
+	var deadline *string
+	if item.Deadline != nil {
+		formatted := item.Deadline.Format("2006-01-02T15:04:05Z07:00")
+		deadline = &formatted
+	}
+
+	return ItemSearchResultResponse{
+		ItemType:   item.ItemType,
+		ID:         item.ID,
+		ProjectID:  item.ProjectID,
+		RefNum:     item.RefNum,
+		Title:      item.Title,
+		Status:     item.Status,
+		Priority:   item.Priority,
+		AssigneeID: item.AssigneeID,
+		SprintID:   item.SprintID,
+		ReleaseID:  item.ReleaseID,
+		Tags:       item.Tags,
+		Deadline:   deadline,
+		CreatedBy:  item.CreatedBy,
+		CreatedAt:  item.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:  item.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+// SearchItems searches every item type of a project with the query in the q parameter
+func (h *ItemSearchHandler) SearchItems(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := strconv.Atoi(c.Param("projectId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid project ID")
+	}
+
+	page, err := strconv.Atoi(c.QueryParam("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(c.QueryParam("pageSize"))
+	if err != nil || pageSize < 1 {
+		pageSize = 20
+	}
+
+	items, total, err := h.searchService.SearchItems(projectID, c.QueryParam("q"), page, pageSize, userID)
+	if err != nil {
+		var parseErr *item_search.ParseError
+		if errors.As(err, &parseErr) {
+			return echo.NewHTTPError(http.StatusBadRequest, map[string]interface{}{
+				"message":  parseErr.Error(),
+				"token":    parseErr.Token,
+				"position": parseErr.Position,
+			})
+		}
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	response := ItemSearchResponse{
+		Items:    make([]ItemSearchResultResponse, len(items)),
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}
+	for i := range items {
+		response.Items[i] = toItemSearchResultResponse(&items[i])
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// SearchText ranks the items, comments and wiki pages of a project matching the text in the q parameter.
+// The optional types parameter is a comma separated list of result types.
+func (h *ItemSearchHandler) SearchText(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := strconv.Atoi(c.Param("projectId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid project ID")
+	}
+
+	page, err := strconv.Atoi(c.QueryParam("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(c.QueryParam("pageSize"))
+	if err != nil || pageSize < 1 {
+		pageSize = 20
+	}
+
+	var resultTypes []string
+	if types := c.QueryParam("types"); types != "" {
+		resultTypes = strings.Split(types, ",")
+	}
+
+	results, total, err := h.searchService.SearchText(projectID, c.QueryParam("q"), resultTypes, page, pageSize, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	response := TextSearchResponse{
+		Results:  make([]TextSearchResultResponse, len(results)),
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}
+	for i, result := range results {
+		response.Results[i] = TextSearchResultResponse{
+			ResultType: result.ResultType,
+			ID:         result.ID,
+			ItemType:   result.ItemType,
+			ItemID:     result.ItemID,
+			RefNum:     result.RefNum,
+			Title:      result.Title,
+			Snippet:    result.Snippet,
+			Rank:       result.Rank,
+			UpdatedAt:  result.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		}
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *ItemSearchHandler) RegisterRoutes(e *echo.Echo, authMiddleware *AuthMiddleware, projectMiddleware *ProjectMiddleware) {
+	e.GET("/api/projects/:projectId/items/search", h.SearchItems, authMiddleware.RequireAuth, projectMiddleware.RequireProjectMember)
+	e.GET("/api/projects/:projectId/search", h.SearchText, authMiddleware.RequireAuth, projectMiddleware.RequireProjectMember)
+}
@@ -0,0 +1,29 @@
+package apis
+
+import (
+	"net/http"
+
+	"github.com/dannyswat/pjeasy/internal/user_sessions"
+	"github.com/labstack/echo/v4"
+)
+
+// JWKSHandler publishes the public keys that verify access tokens
+type JWKSHandler struct {
+	tokenService *user_sessions.TokenService
+}
+
+func NewJWKSHandler(tokenService *user_sessions.TokenService) *JWKSHandler {
+	return &JWKSHandler{
+		tokenService: tokenService,
+	}
+}
+
+// GetJWKS returns the JSON Web Key Set, empty when tokens are signed with a shared secret
+func (h *JWKSHandler) GetJWKS(c echo.Context) error {
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return c.JSON(http.StatusOK, h.tokenService.JWKS())
+}
+
+func (h *JWKSHandler) RegisterRoutes(e *echo.Echo) {
+	e.GET("/.well-known/jwks.json", h.GetJWKS)
+}
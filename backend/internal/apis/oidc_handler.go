@@ -0,0 +1,186 @@
+package apis
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/config"
+	"github.com/dannyswat/pjeasy/internal/projects"
+	"github.com/dannyswat/pjeasy/internal/user_sessions"
+	"github.com/dannyswat/pjeasy/internal/users"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	oidcFlowCookieName = "oidc_flow"
+	oidcFlowCookiePath = "/api/auth/oidc"
+	oidcFlowMaxAge     = 10 * time.Minute
+)
+
+// OIDCHandler drives the OpenID Connect authorization code flow with PKCE
+type OIDCHandler struct {
+	oidcClient     *users.OIDCClient
+	sessionService *user_sessions.SessionService
+	projectService *projects.ProjectService
+	sessionHandler *SessionHandler
+	config         config.OIDCConfig
+}
+
+func NewOIDCHandler(oidcClient *users.OIDCClient, sessionService *user_sessions.SessionService, projectService *projects.ProjectService, sessionHandler *SessionHandler, cfg config.OIDCConfig) *OIDCHandler {
+	return &OIDCHandler{
+		oidcClient:     oidcClient,
+		sessionService: sessionService,
+		projectService: projectService,
+		sessionHandler: sessionHandler,
+		config:         cfg,
+	}
+}
+
+// oidcFlowState is kept in a short-lived httpOnly cookie between login and callback
+type oidcFlowState struct {
+	State           string `json:"state"`
+	Nonce           string `json:"nonce"`
+	Verifier        string `json:"verifier"`
+	InvitationToken string `json:"invitationToken,omitempty"`
+}
+
+// Login redirects the browser to the identity provider
+func (h *OIDCHandler) Login(c echo.Context) error {
+	invitationToken := c.QueryParam("invitationToken")
+	if invitationToken != "" {
+		if _, err := h.projectService.ResolveInvitation(invitationToken); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+		}
+	}
+
+	flow := oidcFlowState{InvitationToken: invitationToken}
+	var err error
+	if flow.State, err = users.GenerateOIDCRandomString(); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to start login")
+	}
+	if flow.Nonce, err = users.GenerateOIDCRandomString(); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to start login")
+	}
+	if flow.Verifier, err = users.GenerateOIDCRandomString(); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to start login")
+	}
+
+	authURL, err := h.oidcClient.AuthCodeURL(c.Request().Context(), flow.State, flow.Nonce, flow.Verifier)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadGateway, "Identity provider is unavailable")
+	}
+
+	payload, err := json.Marshal(flow)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to start login")
+	}
+	h.setFlowCookie(c, base64.RawURLEncoding.EncodeToString(payload), int(oidcFlowMaxAge.Seconds()))
+
+	return c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback completes the flow, creates a session and redirects to the frontend
+func (h *OIDCHandler) Callback(c echo.Context) error {
+	flow, err := h.readFlowCookie(c)
+	// The flow cookie is single-use regardless of the outcome
+	h.setFlowCookie(c, "", -1)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Login session expired, please try again")
+	}
+
+	if providerErr := c.QueryParam("error"); providerErr != "" {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Identity provider rejected the login: "+providerErr)
+	}
+
+	state := c.QueryParam("state")
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(flow.State)) != 1 {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid login state")
+	}
+
+	code := c.QueryParam("code")
+	if code == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Missing authorization code")
+	}
+
+	identity, err := h.oidcClient.Exchange(c.Request().Context(), code, flow.Verifier, flow.Nonce)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid credentials")
+	}
+
+	result, err := h.sessionService.LoginWithOIDC(identity, h.config.LoginIDClaim, h.config.AutoCreateUsers, c.Request().UserAgent(), c.RealIP())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, err.Error()).SetInternal(err)
+	}
+
+	if flow.InvitationToken != "" {
+		if _, err := h.projectService.AcceptInvitation(flow.InvitationToken, result.User.ID); err != nil {
+			_ = h.sessionService.RevokeSession(result.SessionID.String(), result.RefreshToken, c.Request().UserAgent(), c.RealIP())
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+		}
+	}
+
+	h.sessionHandler.setAuthCookies(c, result.SessionID.String(), result.AccessToken, result.RefreshToken)
+
+	return c.Redirect(http.StatusFound, h.postLoginRedirect())
+}
+
+// Status reports whether OIDC login is available so the frontend can show the button
+func (h *OIDCHandler) Status(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]bool{"enabled": true})
+}
+
+func (h *OIDCHandler) postLoginRedirect() string {
+	redirect := h.config.PostLoginRedirect
+	// Only allow local paths to avoid an open redirect
+	if redirect == "" || !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") {
+		return "/"
+	}
+	return redirect
+}
+
+func (h *OIDCHandler) setFlowCookie(c echo.Context, value string, maxAge int) {
+	options := resolveAuthCookieOptions(c)
+
+	cookie := &http.Cookie{
+		Name:     oidcFlowCookieName,
+		Value:    value,
+		Path:     oidcFlowCookiePath,
+		HttpOnly: true,
+		Secure:   options.secure,
+		// Lax is required so the cookie survives the top-level redirect back from the provider
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   maxAge,
+	}
+	if maxAge < 0 {
+		cookie.Expires = time.Unix(0, 0)
+	}
+	c.SetCookie(cookie)
+}
+
+func (h *OIDCHandler) readFlowCookie(c echo.Context) (*oidcFlowState, error) {
+	cookie, err := c.Cookie(oidcFlowCookieName)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	var flow oidcFlowState
+	if err := json.Unmarshal(payload, &flow); err != nil {
+		return nil, err
+	}
+	return &flow, nil
+}
+
+func (h *OIDCHandler) RegisterRoutes(e *echo.Echo) {
+	e.GET("/api/auth/oidc", h.Status)
+	e.GET("/api/auth/oidc/login", h.Login)
+	e.GET("/api/auth/oidc/callback", h.Callback)
+}
@@ -0,0 +1,135 @@
+package apis
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/user_sessions"
+	"github.com/labstack/echo/v4"
+)
+
+type PersonalAccessTokenHandler struct {
+	patService *user_sessions.PersonalAccessTokenService
+}
+
+func NewPersonalAccessTokenHandler(patService *user_sessions.PersonalAccessTokenService) *PersonalAccessTokenHandler {
+	return &PersonalAccessTokenHandler{
+		patService: patService,
+	}
+}
+
+type CreatePersonalAccessTokenRequest struct {
+	Name       string    `json:"name" validate:"required,max=100"`
+	Scope      string    `json:"scope" validate:"required,oneof=read write"`
+	ExpiresAt  time.Time `json:"expiresAt" validate:"required"`
+	ProjectIDs []int     `json:"projectIds"`
+}
+
+type PersonalAccessTokenResponse struct {
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	TokenPrefix string     `json:"tokenPrefix"`
+	Scope       string     `json:"scope"`
+	ProjectIDs  []int      `json:"projectIds"`
+	ExpiresAt   time.Time  `json:"expiresAt"`
+	LastUsedAt  *time.Time `json:"lastUsedAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	IsExpired   bool       `json:"isExpired"`
+}
+
+type CreatePersonalAccessTokenResponse struct {
+	PersonalAccessTokenResponse
+	Token string `json:"token"`
+}
+
+func toPersonalAccessTokenResponse(token *user_sessions.PersonalAccessToken) PersonalAccessTokenResponse {
+	projectIDs := token.ProjectIDs()
+	if projectIDs == nil {
+		projectIDs = []int{}
+	}
+	return PersonalAccessTokenResponse{
+		ID:          token.ID,
+		Name:        token.Name,
+		TokenPrefix: token.TokenPrefix,
+		Scope:       token.Scope,
+		ProjectIDs:  projectIDs,
+		ExpiresAt:   token.ExpiresAt,
+		LastUsedAt:  token.LastUsedAt,
+		CreatedAt:   token.CreatedAt,
+		IsExpired:   !token.IsValid(),
+	}
+}
+
+// ListTokens returns the current user's personal access tokens
+func (h *PersonalAccessTokenHandler) ListTokens(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	tokens, err := h.patService.ListTokens(userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch tokens")
+	}
+
+	response := make([]PersonalAccessTokenResponse, 0, len(tokens))
+	for _, token := range tokens {
+		response = append(response, toPersonalAccessTokenResponse(token))
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// CreateToken creates a personal access token; the token value is only returned once
+func (h *PersonalAccessTokenHandler) CreateToken(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	req := new(CreatePersonalAccessTokenRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	token, plainToken, err := h.patService.CreateToken(userID, req.Name, req.Scope, req.ExpiresAt, req.ProjectIDs)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusCreated, &CreatePersonalAccessTokenResponse{
+		PersonalAccessTokenResponse: toPersonalAccessTokenResponse(token),
+		Token:                       plainToken,
+	})
+}
+
+// RevokeToken revokes one of the current user's personal access tokens
+func (h *PersonalAccessTokenHandler) RevokeToken(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	tokenID, err := strconv.Atoi(c.Param("tokenId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid token ID")
+	}
+
+	if err := h.patService.RevokeToken(userID, tokenID); err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Token revoked successfully"})
+}
+
+func (h *PersonalAccessTokenHandler) RegisterRoutes(e *echo.Echo, authMiddleware *AuthMiddleware) {
+	tokens := e.Group("/api/users/me/tokens", authMiddleware.RequireAuth, authMiddleware.RequireSessionAuth)
+	tokens.GET("", h.ListTokens)
+	tokens.POST("", h.CreateToken)
+	tokens.DELETE("/:tokenId", h.RevokeToken)
+}
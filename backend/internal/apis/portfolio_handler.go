@@ -0,0 +1,193 @@
+package apis
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/portfolios"
+	"github.com/labstack/echo/v4"
+)
+
+// defaultPortfolioPeriodDays is the number of days time logged is summed over when no period is given
+const defaultPortfolioPeriodDays = 30
+
+type PortfolioHandler struct {
+	portfolioService *portfolios.PortfolioService
+}
+
+func NewPortfolioHandler(portfolioService *portfolios.PortfolioService) *PortfolioHandler {
+	return &PortfolioHandler{
+		portfolioService: portfolioService,
+	}
+}
+
+type PortfolioItemCountResponse struct {
+	ItemType string `json:"itemType"`
+	Open     int    `json:"open"`
+	Overdue  int    `json:"overdue"`
+}
+
+type PortfolioSprintResponse struct {
+	SprintID        int     `json:"sprintId"`
+	Name            string  `json:"name"`
+	StartDate       *string `json:"startDate,omitempty"`
+	EndDate         *string `json:"endDate,omitempty"`
+	TotalItems      int     `json:"totalItems"`
+	CompletedItems  int     `json:"completedItems"`
+	TotalPoints     int     `json:"totalPoints"`
+	CompletedPoints int     `json:"completedPoints"`
+}
+
+type PortfolioReleaseResponse struct {
+	ID         int    `json:"id"`
+	Version    string `json:"version"`
+	Status     string `json:"status"`
+	TargetDate string `json:"targetDate"`
+	Overdue    bool   `json:"overdue"`
+}
+
+type PortfolioTicketBacklogResponse struct {
+	OpenCount      int     `json:"openCount"`
+	AverageAgeDays float64 `json:"averageAgeDays"`
+	OldestAgeDays  int     `json:"oldestAgeDays"`
+	UnderWeek      int     `json:"underWeek"`
+	UnderMonth     int     `json:"underMonth"`
+	OverMonth      int     `json:"overMonth"`
+}
+
+type PortfolioTimeLoggedResponse struct {
+	TotalUnits int     `json:"totalUnits"`
+	TotalHours float64 `json:"totalHours"`
+}
+
+type PortfolioProjectResponse struct {
+	ProjectID        int                            `json:"projectId"`
+	Name             string                         `json:"name"`
+	IsArchived       bool                           `json:"isArchived"`
+	ItemCounts       []PortfolioItemCountResponse   `json:"itemCounts"`
+	ActiveSprint     *PortfolioSprintResponse       `json:"activeSprint,omitempty"`
+	UpcomingReleases []PortfolioReleaseResponse     `json:"upcomingReleases"`
+	TicketBacklog    PortfolioTicketBacklogResponse `json:"ticketBacklog"`
+	TimeLogged       PortfolioTimeLoggedResponse    `json:"timeLogged"`
+}
+
+type PortfolioResponse struct {
+	Projects  []PortfolioProjectResponse `json:"projects"`
+	StartDate string                     `json:"startDate"`
+	EndDate   string                     `json:"endDate"`
+	Total     int64                      `json:"total"`
+	Page      int                        `json:"page"`
+	PageSize  int                        `json:"pageSize"`
+}
+
+func formatPortfolioDate(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := t.Format("2006-01-02")
+	return &formatted
+}
+
+func toPortfolioProjectResponse(project *portfolios.ProjectPortfolio) PortfolioProjectResponse {
+	itemCounts := make([]PortfolioItemCountResponse, len(project.ItemCounts))
+	for i, counts := range project.ItemCounts {
+		itemCounts[i] = PortfolioItemCountResponse(counts)
+	}
+
+	var activeSprint *PortfolioSprintResponse
+	if sprint := project.ActiveSprint; sprint != nil {
+		activeSprint = &PortfolioSprintResponse{
+			SprintID:        sprint.SprintID,
+			Name:            sprint.Name,
+			StartDate:       formatPortfolioDate(sprint.StartDate),
+			EndDate:         formatPortfolioDate(sprint.EndDate),
+			TotalItems:      sprint.TotalItems,
+			CompletedItems:  sprint.CompletedItems,
+			TotalPoints:     sprint.TotalPoints,
+			CompletedPoints: sprint.CompletedPoints,
+		}
+	}
+
+	releases := make([]PortfolioReleaseResponse, len(project.UpcomingReleases))
+	for i, release := range project.UpcomingReleases {
+		releases[i] = PortfolioReleaseResponse{
+			ID:         release.ID,
+			Version:    release.Version,
+			Status:     release.Status,
+			TargetDate: release.TargetDate.Format("2006-01-02"),
+			Overdue:    release.Overdue,
+		}
+	}
+
+	return PortfolioProjectResponse{
+		ProjectID:        project.Project.ID,
+		Name:             project.Project.Name,
+		IsArchived:       project.Project.IsArchived,
+		ItemCounts:       itemCounts,
+		ActiveSprint:     activeSprint,
+		UpcomingReleases: releases,
+		TicketBacklog:    PortfolioTicketBacklogResponse(project.TicketBacklog),
+		TimeLogged:       PortfolioTimeLoggedResponse(project.TimeLogged),
+	}
+}
+
+// GetPortfolio returns the status of every project the user administers, or every project for system admins
+func (h *PortfolioHandler) GetPortfolio(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	today := time.Now().UTC()
+	endDate := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	if raw := c.QueryParam("to"); raw != "" {
+		endDate, err = time.Parse("2006-01-02", raw)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid to date format")
+		}
+	}
+
+	startDate := endDate.AddDate(0, 0, -(defaultPortfolioPeriodDays - 1))
+	if raw := c.QueryParam("from"); raw != "" {
+		startDate, err = time.Parse("2006-01-02", raw)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid from date format")
+		}
+	}
+
+	includeArchived := c.QueryParam("includeArchived") == "true"
+
+	page, err := strconv.Atoi(c.QueryParam("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(c.QueryParam("pageSize"))
+	if err != nil || pageSize < 1 {
+		pageSize = 20
+	}
+
+	portfolio, err := h.portfolioService.GetPortfolio(userID, includeArchived, startDate, endDate, page, pageSize)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	response := PortfolioResponse{
+		Projects:  make([]PortfolioProjectResponse, len(portfolio.Projects)),
+		StartDate: portfolio.StartDate.Format("2006-01-02"),
+		EndDate:   portfolio.EndDate.Format("2006-01-02"),
+		Total:     portfolio.Total,
+		Page:      page,
+		PageSize:  pageSize,
+	}
+	for i := range portfolio.Projects {
+		response.Projects[i] = toPortfolioProjectResponse(&portfolio.Projects[i])
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *PortfolioHandler) RegisterRoutes(e *echo.Echo, authMiddleware *AuthMiddleware) {
+	e.GET("/api/portfolio", h.GetPortfolio, authMiddleware.RequireAuth)
+}
@@ -0,0 +1,107 @@
+package apis
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/dannyswat/pjeasy/internal/project_archives"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	maxProjectArchiveSize = 500 * 1024 * 1024 // 500MB
+)
+
+var archiveFileNameUnsafe = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+type ProjectArchiveHandler struct {
+	archiveService *project_archives.ProjectArchiveService
+}
+
+func NewProjectArchiveHandler(archiveService *project_archives.ProjectArchiveService) *ProjectArchiveHandler {
+	return &ProjectArchiveHandler{
+		archiveService: archiveService,
+	}
+}
+
+type ImportProjectResponse struct {
+	Project         ProjectResponse `json:"project"`
+	UnmatchedUsers  []string        `json:"unmatchedUsers"`
+	RenumberedItems int             `json:"renumberedItems"`
+}
+
+// ExportProject downloads the project with all its items, history and uploaded files as a zip archive
+func (h *ProjectArchiveHandler) ExportProject(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := GetProjectIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	archive, err := h.archiveService.LoadProjectArchive(projectID, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	name := strings.Trim(archiveFileNameUnsafe.ReplaceAllString(archive.Project.Name, "-"), "-")
+	if name == "" {
+		name = fmt.Sprintf("project-%d", projectID)
+	}
+	fileName := fmt.Sprintf("%s-%s.zip", name, archive.Manifest.ExportedAt.Format("20060102-150405"))
+
+	c.Response().Header().Set(echo.HeaderContentType, "application/zip")
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", fileName))
+	c.Response().WriteHeader(http.StatusOK)
+
+	// Headers are sent at this point, so a failure can only abort the download
+	if err := h.archiveService.WriteProjectArchive(archive, c.Response()); err != nil {
+		c.Logger().Errorf("failed to export project %d: %v", projectID, err)
+	}
+	return nil
+}
+
+// ImportProject creates a new project from an uploaded archive; the importer becomes its admin.
+// Restricted to system admins because archived users are matched to existing accounts by login ID,
+// which gives them memberships, items, comments and time logs.
+func (h *ProjectArchiveHandler) ImportProject(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	file, err := c.FormFile("archive")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "No archive file provided")
+	}
+	if file.Size > maxProjectArchiveSize {
+		return echo.NewHTTPError(http.StatusBadRequest, "Archive size exceeds 500MB limit")
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to read archive")
+	}
+	defer src.Close()
+
+	result, err := h.archiveService.ImportProject(src, file.Size, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusCreated, ImportProjectResponse{
+		Project:         toProjectResponse(result.Project),
+		UnmatchedUsers:  result.UnmatchedUsers,
+		RenumberedItems: result.RenumberedItems,
+	})
+}
+
+func (h *ProjectArchiveHandler) RegisterRoutes(e *echo.Echo, authMiddleware *AuthMiddleware, projectMiddleware *ProjectMiddleware) {
+	e.POST("/api/projects/import", h.ImportProject, authMiddleware.RequireAuth, authMiddleware.RequireSessionAuth, authMiddleware.RequireAdmin)
+	e.GET("/api/projects/:id/export", h.ExportProject, authMiddleware.RequireAuth, projectMiddleware.RequireProjectAdmin)
+}
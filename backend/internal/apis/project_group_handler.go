@@ -0,0 +1,175 @@
+package apis
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/projects"
+	"github.com/labstack/echo/v4"
+)
+
+type ProjectGroupHandler struct {
+	groupService *projects.ProjectGroupService
+}
+
+func NewProjectGroupHandler(groupService *projects.ProjectGroupService) *ProjectGroupHandler {
+	return &ProjectGroupHandler{
+		groupService: groupService,
+	}
+}
+
+type AddProjectGroupRequest struct {
+	GroupID int `json:"groupId" validate:"required"`
+	RoleID  int `json:"roleId" validate:"required"`
+}
+
+type UpdateProjectGroupRoleRequest struct {
+	RoleID int `json:"roleId" validate:"required"`
+}
+
+type ProjectGroupResponse struct {
+	GroupID   int       `json:"groupId"`
+	ProjectID int       `json:"projectId"`
+	Name      string    `json:"name"`
+	RoleID    int       `json:"roleId"`
+	IsAdmin   bool      `json:"isAdmin"`
+	IsUser    bool      `json:"isUser"`
+	AddedAt   time.Time `json:"addedAt"`
+}
+
+func toProjectGroupResponse(grant *projects.ProjectGroupWithInfo) ProjectGroupResponse {
+	return ProjectGroupResponse{
+		GroupID:   grant.Grant.GroupID,
+		ProjectID: grant.Grant.ProjectID,
+		Name:      grant.Group.Name,
+		RoleID:    grant.Grant.RoleID,
+		IsAdmin:   grant.Grant.IsAdmin,
+		IsUser:    grant.Grant.IsUser,
+		AddedAt:   grant.Grant.AddedAt,
+	}
+}
+
+// ListProjectGroups returns the groups granted access to a project
+func (h *ProjectGroupHandler) ListProjectGroups(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := GetProjectIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	grants, err := h.groupService.ListGroups(projectID, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	response := make([]ProjectGroupResponse, 0, len(grants))
+	for i := range grants {
+		response = append(response, toProjectGroupResponse(&grants[i]))
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// AddProjectGroup grants a user group access to a project with a role
+func (h *ProjectGroupHandler) AddProjectGroup(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := GetProjectIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	req := new(AddProjectGroupRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	grant, err := h.groupService.AddGroup(projectID, req.GroupID, req.RoleID, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusCreated, toProjectGroupResponse(grant))
+}
+
+// UpdateProjectGroupRole changes the role a group holds in a project
+func (h *ProjectGroupHandler) UpdateProjectGroupRole(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := GetProjectIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	groupID, err := parseGroupID(c)
+	if err != nil {
+		return err
+	}
+
+	req := new(UpdateProjectGroupRoleRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	grant, err := h.groupService.UpdateGroupRole(projectID, groupID, req.RoleID, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"groupId": grant.GroupID,
+		"roleId":  grant.RoleID,
+		"isAdmin": grant.IsAdmin,
+		"isUser":  grant.IsUser,
+	})
+}
+
+// RemoveProjectGroup revokes the access of a group to a project
+func (h *ProjectGroupHandler) RemoveProjectGroup(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := GetProjectIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	groupID, err := parseGroupID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.groupService.RemoveGroup(projectID, groupID, userID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *ProjectGroupHandler) RegisterRoutes(e *echo.Echo, authMiddleware *AuthMiddleware, projectMiddleware *ProjectMiddleware) {
+	groups := e.Group("/api/projects/:id/groups", authMiddleware.RequireAuth)
+	groups.GET("", h.ListProjectGroups, projectMiddleware.RequireProjectMember)
+	groups.POST("", h.AddProjectGroup, projectMiddleware.RequireProjectAdmin)
+	groups.PUT("/:groupId/role", h.UpdateProjectGroupRole, projectMiddleware.RequireProjectAdmin)
+	groups.DELETE("/:groupId", h.RemoveProjectGroup, projectMiddleware.RequireProjectAdmin)
+}
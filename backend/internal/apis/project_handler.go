@@ -23,9 +23,23 @@ func NewProjectHandler(projectService *projects.ProjectService, sequenceService
 }
 
 type CreateProjectRequest struct {
-	Name          string `json:"name" validate:"required"`
-	Description   string `json:"description"`
-	RepositoryURL string `json:"repositoryUrl" validate:"omitempty,httpurl"`
+	Name          string                     `json:"name" validate:"required"`
+	Description   string                     `json:"description"`
+	RepositoryURL string                     `json:"repositoryUrl" validate:"omitempty,httpurl"`
+	Template      *CreateFromTemplateRequest `json:"template,omitempty"`
+}
+
+type CreateFromTemplateRequest struct {
+	ProjectID   int  `json:"projectId" validate:"required"`
+	StatusFlows bool `json:"statusFlows"`
+	Sequences   bool `json:"sequences"`
+	WikiPages   bool `json:"wikiPages"`
+	Members     bool `json:"members"`
+	OpenItems   bool `json:"openItems"`
+}
+
+type SetProjectTemplateRequest struct {
+	IsTemplate bool `json:"isTemplate"`
 }
 
 type UpdateProjectRequest struct {
@@ -46,8 +60,11 @@ type UpdateMemberRoleRequest struct {
 }
 
 type CreateProjectInvitationRequest struct {
-	Role      string `json:"role" validate:"required,oneof=member user"`
-	ExpiresAt string `json:"expiresAt"`
+	Role             string   `json:"role" validate:"required,oneof=member user"`
+	ExpiresAt        string   `json:"expiresAt"`
+	MaxUses          *int     `json:"maxUses" validate:"omitempty,min=1"` // Unlimited when omitted
+	AllowedDomains   []string `json:"allowedDomains"`                     // Verified email or provisioned login ID domains, any user when empty
+	RequiresApproval bool     `json:"requiresApproval"`
 }
 
 type ProjectResponse struct {
@@ -56,10 +73,12 @@ type ProjectResponse struct {
 	Description   string `json:"description"`
 	RepositoryURL string `json:"repositoryUrl"`
 	IsArchived    bool   `json:"isArchived"`
+	IsTemplate    bool   `json:"isTemplate"`
 	CreatedBy     int    `json:"createdBy"`
 	CreatedAt     string `json:"createdAt"`
 	UpdatedAt     string `json:"updatedAt"`
 	ArchivedAt    string `json:"archivedAt,omitempty"`
+	DeletedAt     string `json:"deletedAt,omitempty"` // Set for projects in the trash
 }
 
 type MemberResponse struct {
@@ -69,6 +88,7 @@ type MemberResponse struct {
 	User      UserResponse `json:"user"`
 	IsAdmin   bool         `json:"isAdmin"`
 	IsUser    bool         `json:"isUser"`
+	RoleID    int          `json:"roleId"`
 	AddedAt   string       `json:"addedAt"`
 }
 
@@ -78,17 +98,100 @@ type ProjectWithMembersResponse struct {
 }
 
 type ProjectInvitationResponse struct {
-	ID          int    `json:"id"`
-	Token       string `json:"token,omitempty"`
-	ProjectID   int    `json:"projectId"`
-	ProjectName string `json:"projectName"`
-	Role        string `json:"role"`
-	ExpiresAt   string `json:"expiresAt,omitempty"`
-	CreatedAt   string `json:"createdAt,omitempty"`
-	RevokedAt   string `json:"revokedAt,omitempty"`
+	ID               int                            `json:"id"`
+	Token            string                         `json:"token,omitempty"`
+	ProjectID        int                            `json:"projectId"`
+	ProjectName      string                         `json:"projectName"`
+	Role             string                         `json:"role"`
+	ExpiresAt        string                         `json:"expiresAt,omitempty"`
+	CreatedAt        string                         `json:"createdAt,omitempty"`
+	RevokedAt        string                         `json:"revokedAt,omitempty"`
+	MaxUses          *int                           `json:"maxUses,omitempty"`
+	UseCount         int                            `json:"useCount"`
+	AllowedDomains   []string                       `json:"allowedDomains,omitempty"`
+	RequiresApproval bool                           `json:"requiresApproval"`
+	Acceptances      []InvitationAcceptanceResponse `json:"acceptances,omitempty"` // Only listed to project admins
+}
+
+type InvitationAcceptanceResponse struct {
+	ID           int          `json:"id"`
+	InvitationID int          `json:"invitationId"`
+	ProjectID    int          `json:"projectId"`
+	UserID       int          `json:"userId"`
+	User         UserResponse `json:"user"`
+	Status       string       `json:"status"`
+	AcceptedAt   string       `json:"acceptedAt"`
+	ReviewedBy   *int         `json:"reviewedBy,omitempty"`
+	ReviewedAt   string       `json:"reviewedAt,omitempty"`
+}
+
+func toProjectInvitationResponse(invitation *projects.ProjectInvitation, project *projects.Project) ProjectInvitationResponse {
+	response := ProjectInvitationResponse{
+		ID:               invitation.ID,
+		ProjectID:        project.ID,
+		ProjectName:      project.Name,
+		Role:             invitation.Role(),
+		CreatedAt:        invitation.CreatedAt.Format(time.RFC3339),
+		MaxUses:          invitation.MaxUses,
+		UseCount:         invitation.UseCount,
+		AllowedDomains:   invitation.Domains(),
+		RequiresApproval: invitation.RequiresApproval,
+	}
+
+	if invitation.ExpiresAt != nil {
+		response.ExpiresAt = invitation.ExpiresAt.Format(time.RFC3339)
+	}
+	if invitation.RevokedAt != nil {
+		response.RevokedAt = invitation.RevokedAt.Format(time.RFC3339)
+	}
+
+	return response
+}
+
+func toInvitationAcceptanceResponse(item projects.InvitationAcceptanceWithUser) InvitationAcceptanceResponse {
+	acceptance := item.Acceptance
+	response := InvitationAcceptanceResponse{
+		ID:           acceptance.ID,
+		InvitationID: acceptance.InvitationID,
+		ProjectID:    acceptance.ProjectID,
+		UserID:       acceptance.UserID,
+		Status:       acceptance.Status,
+		AcceptedAt:   acceptance.AcceptedAt.Format(time.RFC3339),
+		ReviewedBy:   acceptance.ReviewedBy,
+	}
+
+	if item.User != nil {
+		response.User = toUserResponse(item.User)
+	}
+	if acceptance.ReviewedAt != nil {
+		response.ReviewedAt = acceptance.ReviewedAt.Format(time.RFC3339)
+	}
+
+	return response
 }
 
-// CreateProject creates a new project
+func toProjectResponse(project *projects.Project) ProjectResponse {
+	response := ProjectResponse{
+		ID:            project.ID,
+		Name:          project.Name,
+		Description:   project.Description,
+		RepositoryURL: project.RepositoryURL,
+		IsArchived:    project.IsArchived,
+		IsTemplate:    project.IsTemplate,
+		CreatedBy:     project.CreatedBy,
+		CreatedAt:     project.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:     project.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if !project.ArchivedAt.IsZero() {
+		response.ArchivedAt = project.ArchivedAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+	if project.DeletedAt.Valid {
+		response.DeletedAt = project.DeletedAt.Time.Format("2006-01-02T15:04:05Z07:00")
+	}
+	return response
+}
+
+// CreateProject creates a new project, optionally from a template project
 func (h *ProjectHandler) CreateProject(c echo.Context) error {
 	userID, err := GetUserIDFromContext(c)
 	if err != nil {
@@ -101,12 +204,24 @@ func (h *ProjectHandler) CreateProject(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	var template *projects.ProjectTemplateOptions
+	if req.Template != nil {
+		template = &projects.ProjectTemplateOptions{
+			TemplateProjectID: req.Template.ProjectID,
+			StatusFlows:       req.Template.StatusFlows,
+			Sequences:         req.Template.Sequences,
+			WikiPages:         req.Template.WikiPages,
+			Members:           req.Template.Members,
+			OpenItems:         req.Template.OpenItems,
+		}
 	}
 
-	project, err := h.projectService.CreateProject(req.Name, req.Description, req.RepositoryURL, userID)
+	project, err := h.projectService.CreateProject(req.Name, req.Description, req.RepositoryURL, userID, template)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	if err := h.sequenceService.GenerateProjectSequences(project.ID); err != nil {
@@ -114,18 +229,7 @@ func (h *ProjectHandler) CreateProject(c echo.Context) error {
 		c.Logger().Errorf("failed to generate default sequences for project %d: %v", project.ID, err)
 	}
 
-	response := ProjectResponse{
-		ID:            project.ID,
-		Name:          project.Name,
-		Description:   project.Description,
-		RepositoryURL: project.RepositoryURL,
-		IsArchived:    project.IsArchived,
-		CreatedBy:     project.CreatedBy,
-		CreatedAt:     project.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:     project.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}
-
-	return c.JSON(http.StatusCreated, response)
+	return c.JSON(http.StatusCreated, toProjectResponse(project))
 }
 
 // UpdateProject updates a project
@@ -147,26 +251,15 @@ func (h *ProjectHandler) UpdateProject(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	project, err := h.projectService.UpdateProject(projectID, req.Name, req.Description, req.RepositoryURL, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
-	}
-
-	response := ProjectResponse{
-		ID:            project.ID,
-		Name:          project.Name,
-		Description:   project.Description,
-		RepositoryURL: project.RepositoryURL,
-		IsArchived:    project.IsArchived,
-		CreatedBy:     project.CreatedBy,
-		CreatedAt:     project.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:     project.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
-	return c.JSON(http.StatusOK, response)
+	return c.JSON(http.StatusOK, toProjectResponse(project))
 }
 
 // GetProject returns a single project with members
@@ -179,7 +272,7 @@ func (h *ProjectHandler) GetProject(c echo.Context) error {
 
 	projectWithMembers, err := h.projectService.GetProjectWithMembers(projectID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
 	}
 
 	members := make([]MemberResponse, 0, len(projectWithMembers.Members))
@@ -196,28 +289,16 @@ func (h *ProjectHandler) GetProject(c echo.Context) error {
 			},
 			IsAdmin: m.Member.IsAdmin,
 			IsUser:  m.Member.IsUser,
+			RoleID:  m.Member.RoleID,
 			AddedAt: m.Member.AddedAt.Format("2006-01-02T15:04:05Z07:00"),
 		})
 	}
 
 	response := ProjectWithMembersResponse{
-		Project: ProjectResponse{
-			ID:            projectWithMembers.Project.ID,
-			Name:          projectWithMembers.Project.Name,
-			Description:   projectWithMembers.Project.Description,
-			RepositoryURL: projectWithMembers.Project.RepositoryURL,
-			IsArchived:    projectWithMembers.Project.IsArchived,
-			CreatedBy:     projectWithMembers.Project.CreatedBy,
-			CreatedAt:     projectWithMembers.Project.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			UpdatedAt:     projectWithMembers.Project.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		},
+		Project: toProjectResponse(&projectWithMembers.Project),
 		Members: members,
 	}
 
-	if !projectWithMembers.Project.ArchivedAt.IsZero() {
-		response.Project.ArchivedAt = projectWithMembers.Project.ArchivedAt.Format("2006-01-02T15:04:05Z07:00")
-	}
-
 	return c.JSON(http.StatusOK, response)
 }
 
@@ -247,21 +328,8 @@ func (h *ProjectHandler) ListProjects(c echo.Context) error {
 	}
 
 	projects := make([]ProjectResponse, 0, len(projectsList))
-	for _, p := range projectsList {
-		pr := ProjectResponse{
-			ID:            p.ID,
-			Name:          p.Name,
-			Description:   p.Description,
-			RepositoryURL: p.RepositoryURL,
-			IsArchived:    p.IsArchived,
-			CreatedBy:     p.CreatedBy,
-			CreatedAt:     p.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			UpdatedAt:     p.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		}
-		if !p.ArchivedAt.IsZero() {
-			pr.ArchivedAt = p.ArchivedAt.Format("2006-01-02T15:04:05Z07:00")
-		}
-		projects = append(projects, pr)
+	for i := range projectsList {
+		projects = append(projects, toProjectResponse(&projectsList[i]))
 	}
 
 	response := map[string]interface{}{
@@ -293,11 +361,11 @@ func (h *ProjectHandler) AddMember(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	if err := h.projectService.AddMemberByLoginID(projectID, req.LoginID, req.IsAdmin, req.IsUser, userID); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusCreated, map[string]string{
@@ -325,7 +393,7 @@ func (h *ProjectHandler) RemoveMember(c echo.Context) error {
 	}
 
 	if err := h.projectService.RemoveMember(projectID, memberID, userID); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{
@@ -350,7 +418,7 @@ func (h *ProjectHandler) CreateInvitation(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	var expiresAt *time.Time
@@ -362,28 +430,26 @@ func (h *ProjectHandler) CreateInvitation(c echo.Context) error {
 		expiresAt = &parsed
 	}
 
-	invitation, token, err := h.projectService.CreateInvitation(projectID, req.Role == "user", expiresAt, userID)
-	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	options := projects.ProjectInvitationOptions{
+		IsUser:           req.Role == "user",
+		ExpiresAt:        expiresAt,
+		MaxUses:          req.MaxUses,
+		AllowedDomains:   req.AllowedDomains,
+		RequiresApproval: req.RequiresApproval,
 	}
 
-	project, err := h.projectService.GetProject(projectID)
+	invitation, token, err := h.projectService.CreateInvitation(projectID, options, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
-	response := ProjectInvitationResponse{
-		ID:          invitation.ID,
-		Token:       token,
-		ProjectID:   projectID,
-		ProjectName: project.Name,
-		Role:        invitation.Role(),
-		CreatedAt:   invitation.CreatedAt.Format(time.RFC3339),
+	project, err := h.projectService.GetProject(projectID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
-	if invitation.ExpiresAt != nil {
-		response.ExpiresAt = invitation.ExpiresAt.Format(time.RFC3339)
-	}
+	response := toProjectInvitationResponse(invitation, project)
+	response.Token = token
 
 	return c.JSON(http.StatusCreated, response)
 }
@@ -401,25 +467,15 @@ func (h *ProjectHandler) ListInvitations(c echo.Context) error {
 
 	items, err := h.projectService.ListInvitations(projectID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	responses := make([]ProjectInvitationResponse, 0, len(items))
 	for _, item := range items {
-		response := ProjectInvitationResponse{
-			ID:          item.Invitation.ID,
-			Token:       item.Invitation.Token,
-			ProjectID:   item.Project.ID,
-			ProjectName: item.Project.Name,
-			Role:        item.Invitation.Role(),
-			CreatedAt:   item.Invitation.CreatedAt.Format(time.RFC3339),
-		}
-
-		if item.Invitation.ExpiresAt != nil {
-			response.ExpiresAt = item.Invitation.ExpiresAt.Format(time.RFC3339)
-		}
-		if item.Invitation.RevokedAt != nil {
-			response.RevokedAt = item.Invitation.RevokedAt.Format(time.RFC3339)
+		response := toProjectInvitationResponse(item.Invitation, item.Project)
+		response.Token = item.Invitation.Token
+		for _, acceptance := range item.Acceptances {
+			response.Acceptances = append(response.Acceptances, toInvitationAcceptanceResponse(acceptance))
 		}
 
 		responses = append(responses, response)
@@ -433,22 +489,10 @@ func (h *ProjectHandler) ListInvitations(c echo.Context) error {
 func (h *ProjectHandler) GetInvitation(c echo.Context) error {
 	details, err := h.projectService.ResolveInvitation(c.Param("token"))
 	if err != nil {
-		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
 	}
 
-	response := ProjectInvitationResponse{
-		ID:          details.Invitation.ID,
-		ProjectID:   details.Project.ID,
-		ProjectName: details.Project.Name,
-		Role:        details.Invitation.Role(),
-		CreatedAt:   details.Invitation.CreatedAt.Format(time.RFC3339),
-	}
-
-	if details.Invitation.ExpiresAt != nil {
-		response.ExpiresAt = details.Invitation.ExpiresAt.Format(time.RFC3339)
-	}
-
-	return c.JSON(http.StatusOK, response)
+	return c.JSON(http.StatusOK, toProjectInvitationResponse(details.Invitation, details.Project))
 }
 
 func (h *ProjectHandler) RevokeInvitation(c echo.Context) error {
@@ -468,7 +512,7 @@ func (h *ProjectHandler) RevokeInvitation(c echo.Context) error {
 	}
 
 	if err := h.projectService.RevokeInvitation(projectID, invitationID, userID); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{
@@ -482,17 +526,84 @@ func (h *ProjectHandler) AcceptInvitation(c echo.Context) error {
 		return err
 	}
 
-	member, err := h.projectService.AcceptInvitation(c.Param("token"), userID)
+	acceptance, err := h.projectService.AcceptInvitation(c.Param("token"), userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	message := "Invitation accepted successfully"
+	if acceptance.IsPending() {
+		message = "Membership request sent for approval"
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"message":   "Invitation accepted successfully",
-		"projectId": member.ProjectID,
+		"message":   message,
+		"projectId": acceptance.ProjectID,
+		"status":    acceptance.Status,
 	})
 }
 
+// ListMembershipRequests lists the membership requests waiting for admin approval
+func (h *ProjectHandler) ListMembershipRequests(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid project ID")
+	}
+
+	requests, err := h.projectService.ListMembershipRequests(projectID, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	responses := make([]InvitationAcceptanceResponse, 0, len(requests))
+	for _, request := range requests {
+		responses = append(responses, toInvitationAcceptanceResponse(request))
+	}
+
+	return c.JSON(http.StatusOK, map[string][]InvitationAcceptanceResponse{
+		"requests": responses,
+	})
+}
+
+// ApproveMembershipRequest adds the requesting user to the project
+func (h *ProjectHandler) ApproveMembershipRequest(c echo.Context) error {
+	return h.reviewMembershipRequest(c, true)
+}
+
+// RejectMembershipRequest declines a membership request
+func (h *ProjectHandler) RejectMembershipRequest(c echo.Context) error {
+	return h.reviewMembershipRequest(c, false)
+}
+
+func (h *ProjectHandler) reviewMembershipRequest(c echo.Context, approve bool) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid project ID")
+	}
+
+	requestID, err := strconv.Atoi(c.Param("requestId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request ID")
+	}
+
+	acceptance, err := h.projectService.ReviewMembershipRequest(projectID, requestID, approve, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, toInvitationAcceptanceResponse(projects.InvitationAcceptanceWithUser{Acceptance: *acceptance}))
+}
+
 // UpdateMemberRole updates a member's role
 func (h *ProjectHandler) UpdateMemberRole(c echo.Context) error {
 	userID, err := GetUserIDFromContext(c)
@@ -518,7 +629,7 @@ func (h *ProjectHandler) UpdateMemberRole(c echo.Context) error {
 	}
 
 	if err := h.projectService.UpdateMemberRole(projectID, memberID, req.IsAdmin, req.IsUser, userID); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{
@@ -540,7 +651,7 @@ func (h *ProjectHandler) ArchiveProject(c echo.Context) error {
 	}
 
 	if err := h.projectService.ArchiveProject(projectID, userID); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{
@@ -562,7 +673,7 @@ func (h *ProjectHandler) UnarchiveProject(c echo.Context) error {
 	}
 
 	if err := h.projectService.UnarchiveProject(projectID, userID); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{
@@ -570,6 +681,113 @@ func (h *ProjectHandler) UnarchiveProject(c echo.Context) error {
 	})
 }
 
+// DeleteProject moves a project to the trash
+func (h *ProjectHandler) DeleteProject(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid project ID")
+	}
+
+	if err := h.projectService.DeleteProject(projectID, userID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Project moved to trash",
+	})
+}
+
+// ListDeletedProjects returns the projects in the trash that the user administers
+func (h *ProjectHandler) ListDeletedProjects(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := h.projectService.GetDeletedProjects(userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch deleted projects")
+	}
+
+	response := make([]ProjectResponse, len(deleted))
+	for i := range deleted {
+		response[i] = toProjectResponse(&deleted[i])
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// RestoreProject takes a project out of the trash. Project access is checked by the service
+// because members of a deleted project do not pass the project middleware.
+func (h *ProjectHandler) RestoreProject(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid project ID")
+	}
+
+	project, err := h.projectService.RestoreProject(projectID, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, toProjectResponse(project))
+}
+
+// ListTemplates returns the template projects the user can create projects from
+func (h *ProjectHandler) ListTemplates(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	templates, err := h.projectService.GetTemplateProjects(userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch project templates")
+	}
+
+	response := make([]ProjectResponse, 0, len(templates))
+	for i := range templates {
+		response = append(response, toProjectResponse(&templates[i]))
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// SetProjectTemplate marks or unmarks a project as a template
+func (h *ProjectHandler) SetProjectTemplate(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := GetProjectIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	req := new(SetProjectTemplateRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	project, err := h.projectService.SetProjectTemplate(projectID, req.IsTemplate, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, toProjectResponse(project))
+}
+
 func (h *ProjectHandler) RegisterRoutes(e *echo.Echo, authMiddleware *AuthMiddleware, projectMiddleware *ProjectMiddleware) {
 	projectGroup := e.Group("/api/projects", authMiddleware.RequireAuth)
 
@@ -578,13 +796,21 @@ func (h *ProjectHandler) RegisterRoutes(e *echo.Echo, authMiddleware *AuthMiddle
 
 	projectGroup.POST("", h.CreateProject)
 	projectGroup.GET("", h.ListProjects)
+	projectGroup.GET("/templates", h.ListTemplates)
+	projectGroup.GET("/trash", h.ListDeletedProjects)
 	projectGroup.GET("/:id", h.GetProject, projectMiddleware.RequireProjectMember)
 	projectGroup.PUT("/:id", h.UpdateProject, projectMiddleware.RequireProjectAdmin)
+	projectGroup.DELETE("/:id", h.DeleteProject, projectMiddleware.RequireProjectAdmin)
+	projectGroup.POST("/:id/restore", h.RestoreProject)
 	projectGroup.POST("/:id/archive", h.ArchiveProject, projectMiddleware.RequireProjectAdmin)
 	projectGroup.POST("/:id/unarchive", h.UnarchiveProject, projectMiddleware.RequireProjectAdmin)
+	projectGroup.PUT("/:id/template", h.SetProjectTemplate, projectMiddleware.RequireProjectAdmin)
 	projectGroup.GET("/:id/invitations", h.ListInvitations, projectMiddleware.RequireProjectAdmin)
 	projectGroup.POST("/:id/invitations", h.CreateInvitation, projectMiddleware.RequireProjectAdmin)
 	projectGroup.DELETE("/:id/invitations/:invitationId", h.RevokeInvitation, projectMiddleware.RequireProjectAdmin)
+	projectGroup.GET("/:id/membership-requests", h.ListMembershipRequests, projectMiddleware.RequireProjectAdmin)
+	projectGroup.POST("/:id/membership-requests/:requestId/approve", h.ApproveMembershipRequest, projectMiddleware.RequireProjectAdmin)
+	projectGroup.POST("/:id/membership-requests/:requestId/reject", h.RejectMembershipRequest, projectMiddleware.RequireProjectAdmin)
 	projectGroup.POST("/:id/members", h.AddMember, projectMiddleware.RequireProjectAdmin)
 	projectGroup.DELETE("/:id/members/:memberId", h.RemoveMember, projectMiddleware.RequireProjectAdmin)
 	projectGroup.PUT("/:id/members/:memberId", h.UpdateMemberRole, projectMiddleware.RequireProjectAdmin)
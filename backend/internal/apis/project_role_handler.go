@@ -0,0 +1,263 @@
+package apis
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/dannyswat/pjeasy/internal/projects"
+	"github.com/labstack/echo/v4"
+)
+
+type ProjectRoleHandler struct {
+	roleService *projects.ProjectRoleService
+}
+
+func NewProjectRoleHandler(roleService *projects.ProjectRoleService) *ProjectRoleHandler {
+	return &ProjectRoleHandler{
+		roleService: roleService,
+	}
+}
+
+type ProjectRolePermissionRequest struct {
+	ItemType string `json:"itemType" validate:"required"`
+	Action   string `json:"action" validate:"required"`
+}
+
+type ProjectRoleRequest struct {
+	Name        string                         `json:"name" validate:"required,max=100"`
+	Description string                         `json:"description" validate:"max=500"`
+	IsLimited   bool                           `json:"isLimited"`
+	Permissions []ProjectRolePermissionRequest `json:"permissions" validate:"dive"`
+}
+
+type AssignMemberRoleRequest struct {
+	RoleID int `json:"roleId" validate:"required"`
+}
+
+type ProjectRolePermissionResponse struct {
+	ItemType string `json:"itemType"`
+	Action   string `json:"action"`
+}
+
+type ProjectRoleResponse struct {
+	ID          int                             `json:"id"`
+	ProjectID   int                             `json:"projectId"`
+	Name        string                          `json:"name"`
+	Description string                          `json:"description"`
+	BuiltInKey  string                          `json:"builtInKey,omitempty"`
+	IsAdmin     bool                            `json:"isAdmin"`
+	IsLimited   bool                            `json:"isLimited"`
+	Permissions []ProjectRolePermissionResponse `json:"permissions"`
+}
+
+type PermissionMatrixEntry struct {
+	ItemType string   `json:"itemType"`
+	Actions  []string `json:"actions"`
+}
+
+type ProjectRolesResponse struct {
+	Roles  []ProjectRoleResponse   `json:"roles"`
+	Matrix []PermissionMatrixEntry `json:"matrix"` // Actions that can be granted per item type
+}
+
+func toProjectRoleResponse(role *projects.ProjectRole) ProjectRoleResponse {
+	permissions := make([]ProjectRolePermissionResponse, 0, len(role.Permissions))
+	for _, permission := range role.Permissions {
+		permissions = append(permissions, ProjectRolePermissionResponse{
+			ItemType: permission.ItemType,
+			Action:   permission.Action,
+		})
+	}
+
+	return ProjectRoleResponse{
+		ID:          role.ID,
+		ProjectID:   role.ProjectID,
+		Name:        role.Name,
+		Description: role.Description,
+		BuiltInKey:  role.BuiltInKey,
+		IsAdmin:     role.IsAdmin,
+		IsLimited:   role.IsLimited,
+		Permissions: permissions,
+	}
+}
+
+func (req *ProjectRoleRequest) toPermissions() []projects.ProjectRolePermission {
+	permissions := make([]projects.ProjectRolePermission, 0, len(req.Permissions))
+	for _, permission := range req.Permissions {
+		permissions = append(permissions, projects.ProjectRolePermission{
+			ItemType: permission.ItemType,
+			Action:   permission.Action,
+		})
+	}
+	return permissions
+}
+
+// ListRoles returns the roles of a project and the permissions that can be granted
+func (h *ProjectRoleHandler) ListRoles(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := GetProjectIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	roles, err := h.roleService.ListRoles(projectID, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	response := ProjectRolesResponse{
+		Roles:  make([]ProjectRoleResponse, 0, len(roles)),
+		Matrix: make([]PermissionMatrixEntry, 0),
+	}
+	for i := range roles {
+		response.Roles = append(response.Roles, toProjectRoleResponse(&roles[i]))
+	}
+	for _, itemType := range projects.PermissionItemTypes() {
+		response.Matrix = append(response.Matrix, PermissionMatrixEntry{
+			ItemType: itemType,
+			Actions:  projects.PermissionActions(itemType),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// CreateRole adds a custom role to a project
+func (h *ProjectRoleHandler) CreateRole(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := GetProjectIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	req := new(ProjectRoleRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	role, err := h.roleService.CreateRole(projectID, req.Name, req.Description, req.IsLimited, req.toPermissions(), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusCreated, toProjectRoleResponse(role))
+}
+
+// UpdateRole changes a custom role
+func (h *ProjectRoleHandler) UpdateRole(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := GetProjectIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	roleID, err := strconv.Atoi(c.Param("roleId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid role ID")
+	}
+
+	req := new(ProjectRoleRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	role, err := h.roleService.UpdateRole(projectID, roleID, req.Name, req.Description, req.IsLimited, req.toPermissions(), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, toProjectRoleResponse(role))
+}
+
+// DeleteRole removes a custom role
+func (h *ProjectRoleHandler) DeleteRole(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := GetProjectIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	roleID, err := strconv.Atoi(c.Param("roleId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid role ID")
+	}
+
+	if err := h.roleService.DeleteRole(projectID, roleID, userID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// AssignMemberRole gives a member one of the project's roles
+func (h *ProjectRoleHandler) AssignMemberRole(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := GetProjectIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	memberID, err := strconv.Atoi(c.Param("memberId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid member ID")
+	}
+
+	req := new(AssignMemberRoleRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	member, err := h.roleService.AssignMemberRole(projectID, memberID, req.RoleID, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"userId":  member.UserID,
+		"roleId":  member.RoleID,
+		"isAdmin": member.IsAdmin,
+		"isUser":  member.IsUser,
+	})
+}
+
+func (h *ProjectRoleHandler) RegisterRoutes(e *echo.Echo, authMiddleware *AuthMiddleware, projectMiddleware *ProjectMiddleware) {
+	roles := e.Group("/api/projects/:id/roles", authMiddleware.RequireAuth)
+	roles.GET("", h.ListRoles, projectMiddleware.RequireProjectMember)
+	roles.POST("", h.CreateRole, projectMiddleware.RequireProjectAdmin)
+	roles.PUT("/:roleId", h.UpdateRole, projectMiddleware.RequireProjectAdmin)
+	roles.DELETE("/:roleId", h.DeleteRole, projectMiddleware.RequireProjectAdmin)
+
+	e.PUT("/api/projects/:id/members/:memberId/role", h.AssignMemberRole, authMiddleware.RequireAuth, projectMiddleware.RequireProjectAdmin)
+}
@@ -0,0 +1,119 @@
+package apis
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/projects"
+	"github.com/labstack/echo/v4"
+)
+
+type ProjectSettingHandler struct {
+	settingService *projects.ProjectSettingService
+}
+
+func NewProjectSettingHandler(settingService *projects.ProjectSettingService) *ProjectSettingHandler {
+	return &ProjectSettingHandler{
+		settingService: settingService,
+	}
+}
+
+type ProjectSettingsRequest struct {
+	Timezone                 string `json:"timezone" validate:"required"`
+	WorkingDays              []int  `json:"workingDays" validate:"required,min=1,dive,min=0,max=6"` // 0 is Sunday
+	DefaultPriority          string `json:"defaultPriority" validate:"required,oneof=Immediate Urgent High Normal Low"`
+	DefaultCascadeCompletion bool   `json:"defaultCascadeCompletion"`
+	DefaultSprintLengthDays  int    `json:"defaultSprintLengthDays" validate:"required,min=1"`
+	AllowOpenDependencies    bool   `json:"allowOpenDependencies"`
+}
+
+type ProjectSettingsResponse struct {
+	Timezone                 string `json:"timezone"`
+	WorkingDays              []int  `json:"workingDays"`
+	DefaultPriority          string `json:"defaultPriority"`
+	DefaultCascadeCompletion bool   `json:"defaultCascadeCompletion"`
+	DefaultSprintLengthDays  int    `json:"defaultSprintLengthDays"`
+	AllowOpenDependencies    bool   `json:"allowOpenDependencies"`
+}
+
+func toProjectSettingsResponse(settings *projects.ProjectSettings) ProjectSettingsResponse {
+	workingDays := make([]int, 0, len(settings.WorkingDays))
+	for _, day := range settings.WorkingDays {
+		workingDays = append(workingDays, int(day))
+	}
+
+	return ProjectSettingsResponse{
+		Timezone:                 settings.Timezone,
+		WorkingDays:              workingDays,
+		DefaultPriority:          settings.DefaultPriority,
+		DefaultCascadeCompletion: settings.DefaultCascadeCompletion,
+		DefaultSprintLengthDays:  settings.DefaultSprintLengthDays,
+		AllowOpenDependencies:    settings.AllowOpenDependencies,
+	}
+}
+
+// GetSettings returns the settings of a project
+func (h *ProjectSettingHandler) GetSettings(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := GetProjectIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	settings, err := h.settingService.GetSettings(projectID, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, toProjectSettingsResponse(settings))
+}
+
+// UpdateSettings replaces the settings of a project
+func (h *ProjectSettingHandler) UpdateSettings(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := GetProjectIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	req := new(ProjectSettingsRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	settings := &projects.ProjectSettings{
+		Timezone:                 req.Timezone,
+		DefaultPriority:          req.DefaultPriority,
+		DefaultCascadeCompletion: req.DefaultCascadeCompletion,
+		DefaultSprintLengthDays:  req.DefaultSprintLengthDays,
+		AllowOpenDependencies:    req.AllowOpenDependencies,
+	}
+	for _, day := range req.WorkingDays {
+		settings.WorkingDays = append(settings.WorkingDays, time.Weekday(day))
+	}
+
+	settings, err = h.settingService.UpdateSettings(projectID, settings, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, toProjectSettingsResponse(settings))
+}
+
+func (h *ProjectSettingHandler) RegisterRoutes(e *echo.Echo, authMiddleware *AuthMiddleware, projectMiddleware *ProjectMiddleware) {
+	settings := e.Group("/api/projects/:id/settings", authMiddleware.RequireAuth)
+	settings.GET("", h.GetSettings, projectMiddleware.RequireProjectMember)
+	settings.PUT("", h.UpdateSettings, projectMiddleware.RequireProjectAdmin)
+}
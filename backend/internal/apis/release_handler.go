@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/dannyswat/pjeasy/internal/projects"
 	"github.com/dannyswat/pjeasy/internal/releases"
 	"github.com/labstack/echo/v4"
 )
@@ -122,7 +123,7 @@ func (h *ReleaseHandler) CreateRelease(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	var targetDate *time.Time
@@ -141,7 +142,7 @@ func (h *ReleaseHandler) CreateRelease(c echo.Context) error {
 
 	release, err := h.releaseService.CreateRelease(projectID, req.Version, req.Description, targetDate, selectedItems, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusCreated, toReleaseResponse(release))
@@ -165,7 +166,7 @@ func (h *ReleaseHandler) UpdateRelease(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	var targetDate *time.Time
@@ -179,7 +180,7 @@ func (h *ReleaseHandler) UpdateRelease(c echo.Context) error {
 
 	release, err := h.releaseService.UpdateRelease(releaseID, req.Version, req.Description, targetDate, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toReleaseResponse(release))
@@ -203,7 +204,7 @@ func (h *ReleaseHandler) UpdateReleaseStatus(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	var confirmedItems []releases.ConfirmedReleaseItem
@@ -216,7 +217,7 @@ func (h *ReleaseHandler) UpdateReleaseStatus(c echo.Context) error {
 
 	release, err := h.releaseService.UpdateReleaseStatus(releaseID, req.Status, confirmedItems, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toReleaseResponse(release))
@@ -257,7 +258,7 @@ func (h *ReleaseHandler) GetReleaseCandidateItems(c echo.Context) error {
 
 	items, err := h.releaseService.GetReleaseCandidateItems(projectID, releaseID, excludeDone, linkedOnly, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	response := make([]ReleaseCandidateItemResponse, len(items))
@@ -302,7 +303,7 @@ func (h *ReleaseHandler) CompleteRelease(c echo.Context) error {
 
 	release, err := h.releaseService.CompleteRelease(releaseID, confirmedItems, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toReleaseResponse(release))
@@ -321,7 +322,7 @@ func (h *ReleaseHandler) DeleteRelease(c echo.Context) error {
 	}
 
 	if err := h.releaseService.DeleteRelease(releaseID, userID); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{"message": "Release deleted successfully"})
@@ -341,7 +342,7 @@ func (h *ReleaseHandler) GetRelease(c echo.Context) error {
 
 	release, err := h.releaseService.GetRelease(releaseID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toReleaseResponse(release))
@@ -377,7 +378,7 @@ func (h *ReleaseHandler) GetProjectReleases(c echo.Context) error {
 
 	releaseList, total, err := h.releaseService.GetProjectReleases(projectID, statuses, page, pageSize, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	releasesResp := make([]ReleaseResponse, len(releaseList))
@@ -409,7 +410,7 @@ func (h *ReleaseHandler) GetReleaseItems(c echo.Context) error {
 
 	items, err := h.releaseService.GetReleaseItems(releaseID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	itemResponses := make([]ReleaseItemResponse, len(items))
@@ -431,7 +432,7 @@ func (h *ReleaseHandler) GetReleaseItems(c echo.Context) error {
 func (h *ReleaseHandler) RegisterRoutes(e *echo.Echo, authMiddleware *AuthMiddleware, projectMiddleware *ProjectMiddleware) {
 	releasesGroup := e.Group("/api/projects/:projectId/releases", authMiddleware.RequireAuth, projectMiddleware.RequireProjectMember)
 
-	releasesGroup.POST("", h.CreateRelease)
+	releasesGroup.POST("", h.CreateRelease, projectMiddleware.RequirePermission(projects.PermissionItemRelease, projects.PermissionActionCreate))
 	releasesGroup.GET("", h.GetProjectReleases)
 	releasesGroup.GET("/candidates", h.GetReleaseCandidateItems)
 
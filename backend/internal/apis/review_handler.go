@@ -232,7 +232,7 @@ func (h *ReviewHandler) CreateSprintReview(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	userID, err := GetUserIDFromContext(c)
@@ -248,7 +248,7 @@ func (h *ReviewHandler) CreateSprintReview(c echo.Context) error {
 		userID,
 	)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusCreated, toReviewResponse(review))
@@ -262,7 +262,7 @@ func (h *ReviewHandler) CreateCustomReview(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	userID, err := GetUserIDFromContext(c)
@@ -297,7 +297,7 @@ func (h *ReviewHandler) CreateCustomReview(c echo.Context) error {
 		userID,
 	)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusCreated, toReviewResponse(review))
@@ -338,7 +338,7 @@ func (h *ReviewHandler) GetProjectReviews(c echo.Context) error {
 
 	reviewList, total, err := h.reviewService.GetProjectReviews(projectID, page, pageSize, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toReviewListResponse(reviewList, total, page, pageSize))
@@ -358,7 +358,7 @@ func (h *ReviewHandler) GetReview(c echo.Context) error {
 
 	review, err := h.reviewService.GetReview(id, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toReviewResponse(review))
@@ -378,17 +378,17 @@ func (h *ReviewHandler) GetReviewDetail(c echo.Context) error {
 
 	review, err := h.reviewService.GetReview(id, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	items, err := h.reviewService.GetReviewItems(id, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	followUps, err := h.itemFollowUpService.GetFollowUpsForReview(id, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, ReviewDetailResponse{
@@ -411,7 +411,7 @@ func (h *ReviewHandler) UpdateReview(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	userID, err := GetUserIDFromContext(c)
@@ -421,7 +421,7 @@ func (h *ReviewHandler) UpdateReview(c echo.Context) error {
 
 	review, err := h.reviewService.UpdateReview(id, req.Title, req.Description, req.Summary, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toReviewResponse(review))
@@ -441,7 +441,7 @@ func (h *ReviewHandler) PublishReview(c echo.Context) error {
 
 	review, err := h.reviewService.PublishReview(id, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toReviewResponse(review))
@@ -461,7 +461,7 @@ func (h *ReviewHandler) RegenerateReviewItems(c echo.Context) error {
 
 	review, err := h.reviewService.RegenerateReviewItems(id, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toReviewResponse(review))
@@ -480,7 +480,7 @@ func (h *ReviewHandler) DeleteReview(c echo.Context) error {
 	}
 
 	if err := h.reviewService.DeleteReview(id, userID); err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{"message": "Review deleted successfully"})
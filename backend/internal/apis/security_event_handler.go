@@ -0,0 +1,139 @@
+package apis
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/user_sessions"
+	"github.com/labstack/echo/v4"
+)
+
+type SecurityEventHandler struct {
+	eventService *user_sessions.SecurityEventService
+}
+
+func NewSecurityEventHandler(eventService *user_sessions.SecurityEventService) *SecurityEventHandler {
+	return &SecurityEventHandler{
+		eventService: eventService,
+	}
+}
+
+type SecurityEventResponse struct {
+	ID        int       `json:"id"`
+	UserID    *int      `json:"userId,omitempty"`
+	LoginID   string    `json:"loginId"`
+	EventType string    `json:"eventType"`
+	IPAddress string    `json:"ipAddress"`
+	UserAgent string    `json:"userAgent"`
+	Details   string    `json:"details,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type SecurityEventListResponse struct {
+	Events   []SecurityEventResponse `json:"events"`
+	Total    int64                   `json:"total"`
+	Page     int                     `json:"page"`
+	PageSize int                     `json:"pageSize"`
+}
+
+// ListMyEvents returns the security events of the current user
+func (h *SecurityEventHandler) ListMyEvents(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	page, pageSize := parseSecurityEventPaging(c)
+	events, total, err := h.eventService.ListUserEvents(userID, page, pageSize)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch security events")
+	}
+
+	return c.JSON(http.StatusOK, toSecurityEventListResponse(events, total, page, pageSize))
+}
+
+// ListEvents returns security events of every user for system admins
+func (h *SecurityEventHandler) ListEvents(c echo.Context) error {
+	filter := user_sessions.SecurityEventFilter{
+		LoginID:   c.QueryParam("loginId"),
+		EventType: c.QueryParam("eventType"),
+		IPAddress: c.QueryParam("ipAddress"),
+	}
+
+	if userIDStr := c.QueryParam("userId"); userIDStr != "" {
+		userID, err := strconv.Atoi(userIDStr)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid user ID")
+		}
+		filter.UserID = &userID
+	}
+
+	if fromStr := c.QueryParam("from"); fromStr != "" {
+		from, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid from time")
+		}
+		filter.From = &from
+	}
+
+	if toStr := c.QueryParam("to"); toStr != "" {
+		to, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid to time")
+		}
+		filter.To = &to
+	}
+
+	page, pageSize := parseSecurityEventPaging(c)
+	events, total, err := h.eventService.ListEvents(filter, page, pageSize)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, toSecurityEventListResponse(events, total, page, pageSize))
+}
+
+func parseSecurityEventPaging(c echo.Context) (int, int) {
+	page, err := strconv.Atoi(c.QueryParam("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(c.QueryParam("pageSize"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	return page, pageSize
+}
+
+func toSecurityEventListResponse(events []user_sessions.SecurityEvent, total int64, page, pageSize int) *SecurityEventListResponse {
+	response := make([]SecurityEventResponse, 0, len(events))
+	for _, event := range events {
+		response = append(response, SecurityEventResponse{
+			ID:        event.ID,
+			UserID:    event.UserID,
+			LoginID:   event.LoginID,
+			EventType: event.EventType,
+			IPAddress: event.IPAddress,
+			UserAgent: event.UserAgent,
+			Details:   event.Details,
+			CreatedAt: event.CreatedAt,
+		})
+	}
+
+	return &SecurityEventListResponse{
+		Events:   response,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}
+}
+
+func (h *SecurityEventHandler) RegisterRoutes(e *echo.Echo, authMiddleware *AuthMiddleware) {
+	e.GET("/api/users/me/security-events", h.ListMyEvents, authMiddleware.RequireAuth)
+
+	adminOnlyGroup := e.Group("/api/admins", authMiddleware.RequireAuth, authMiddleware.RequireSessionAuth, authMiddleware.RequireAdmin)
+	adminOnlyGroup.GET("/security-events", h.ListEvents)
+}
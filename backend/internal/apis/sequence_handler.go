@@ -45,7 +45,7 @@ func (h *SequenceHandler) GenerateProjectSequences(c echo.Context) error {
 	projectID := c.Get("project_id").(int)
 
 	if err := h.sequenceService.GenerateProjectSequences(projectID); err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{
@@ -59,7 +59,7 @@ func (h *SequenceHandler) ListProjectSequences(c echo.Context) error {
 
 	sequences, err := h.sequenceService.ListSequencesByProject(projectID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	var response []SequenceResponse
@@ -5,6 +5,8 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/dannyswat/pjeasy/internal/custom_fields"
+	"github.com/dannyswat/pjeasy/internal/projects"
 	"github.com/dannyswat/pjeasy/internal/service_tickets"
 	"github.com/labstack/echo/v4"
 )
@@ -20,17 +22,19 @@ func NewServiceTicketHandler(ticketService *service_tickets.ServiceTicketService
 }
 
 type CreateServiceTicketRequest struct {
-	Title             string `json:"title" validate:"required"`
-	Description       string `json:"description"`
-	Priority          string `json:"priority"`
-	CascadeCompletion bool   `json:"cascadeCompletion"`
+	Title             string               `json:"title" validate:"required"`
+	Description       string               `json:"description"`
+	Priority          string               `json:"priority"`          // Defaults to the project's default priority
+	CascadeCompletion *bool                `json:"cascadeCompletion"` // Defaults to the project's service ticket setting
+	CustomFields      custom_fields.Values `json:"customFields"`
 }
 
 type UpdateServiceTicketRequest struct {
-	Title             string `json:"title" validate:"required"`
-	Description       string `json:"description"`
-	Priority          string `json:"priority"`
-	CascadeCompletion bool   `json:"cascadeCompletion"`
+	Title             string               `json:"title" validate:"required"`
+	Description       string               `json:"description"`
+	Priority          string               `json:"priority"`
+	CascadeCompletion bool                 `json:"cascadeCompletion"`
+	CustomFields      custom_fields.Values `json:"customFields"`
 }
 
 type UpdateServiceTicketStatusRequest struct {
@@ -38,17 +42,18 @@ type UpdateServiceTicketStatusRequest struct {
 }
 
 type ServiceTicketResponse struct {
-	ID                int    `json:"id"`
-	RefNum            string `json:"refNum"`
-	ProjectID         int    `json:"projectId"`
-	Title             string `json:"title"`
-	Description       string `json:"description"`
-	Status            string `json:"status"`
-	Priority          string `json:"priority"`
-	CascadeCompletion bool   `json:"cascadeCompletion"`
-	CreatedBy         int    `json:"createdBy"`
-	CreatedAt         string `json:"createdAt"`
-	UpdatedAt         string `json:"updatedAt"`
+	ID                int                  `json:"id"`
+	RefNum            string               `json:"refNum"`
+	ProjectID         int                  `json:"projectId"`
+	Title             string               `json:"title"`
+	Description       string               `json:"description"`
+	Status            string               `json:"status"`
+	Priority          string               `json:"priority"`
+	CascadeCompletion bool                 `json:"cascadeCompletion"`
+	CustomFields      custom_fields.Values `json:"customFields,omitempty"`
+	CreatedBy         int                  `json:"createdBy"`
+	CreatedAt         string               `json:"createdAt"`
+	UpdatedAt         string               `json:"updatedAt"`
 }
 
 type ServiceTicketsListResponse struct {
@@ -69,6 +74,7 @@ func toServiceTicketResponse(ticket *service_tickets.ServiceTicket) ServiceTicke
 		Status:            ticket.Status,
 		Priority:          ticket.Priority,
 		CascadeCompletion: ticket.CascadeCompletion,
+		CustomFields:      ticket.CustomFields,
 		CreatedBy:         ticket.CreatedBy,
 		CreatedAt:         ticket.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		UpdatedAt:         ticket.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
@@ -93,12 +99,12 @@ func (h *ServiceTicketHandler) CreateServiceTicket(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
-	ticket, err := h.ticketService.CreateServiceTicket(projectID, req.Title, req.Description, req.Priority, req.CascadeCompletion, userID)
+	ticket, err := h.ticketService.CreateServiceTicket(projectID, req.Title, req.Description, req.Priority, req.CascadeCompletion, req.CustomFields, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	response := toServiceTicketResponse(ticket)
@@ -119,7 +125,7 @@ func (h *ServiceTicketHandler) GetServiceTicket(c echo.Context) error {
 
 	ticket, err := h.ticketService.GetServiceTicket(ticketID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	response := toServiceTicketResponse(ticket)
@@ -144,12 +150,12 @@ func (h *ServiceTicketHandler) UpdateServiceTicket(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
-	ticket, err := h.ticketService.UpdateServiceTicket(ticketID, req.Title, req.Description, req.Priority, req.CascadeCompletion, userID)
+	ticket, err := h.ticketService.UpdateServiceTicket(ticketID, req.Title, req.Description, req.Priority, req.CascadeCompletion, req.CustomFields, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	response := toServiceTicketResponse(ticket)
@@ -174,12 +180,12 @@ func (h *ServiceTicketHandler) UpdateServiceTicketStatus(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	ticket, err := h.ticketService.UpdateServiceTicketStatus(ticketID, req.Status, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	response := toServiceTicketResponse(ticket)
@@ -199,7 +205,7 @@ func (h *ServiceTicketHandler) DeleteServiceTicket(c echo.Context) error {
 	}
 
 	if err := h.ticketService.DeleteServiceTicket(ticketID, userID); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.NoContent(http.StatusNoContent)
@@ -240,9 +246,9 @@ func (h *ServiceTicketHandler) ListServiceTickets(c echo.Context) error {
 		sortBy = "priority"
 	}
 
-	tickets, total, err := h.ticketService.ListServiceTickets(projectID, page, pageSize, statuses, priority, sortBy, userID)
+	tickets, total, err := h.ticketService.ListServiceTickets(projectID, page, pageSize, statuses, priority, customFieldFilters(c), sortBy, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	ticketResponses := make([]ServiceTicketResponse, len(tickets))
@@ -264,7 +270,7 @@ func (h *ServiceTicketHandler) ListServiceTickets(c echo.Context) error {
 func (h *ServiceTicketHandler) RegisterRoutes(e *echo.Echo, authMiddleware *AuthMiddleware, projectMiddleware *ProjectMiddleware) {
 	tickets := e.Group("/api/projects/:projectId/service-tickets", authMiddleware.RequireAuth, projectMiddleware.RequireProjectMember)
 
-	tickets.POST("", h.CreateServiceTicket)
+	tickets.POST("", h.CreateServiceTicket, projectMiddleware.RequirePermission(projects.PermissionItemServiceTicket, projects.PermissionActionCreate))
 	tickets.GET("", h.ListServiceTickets)
 	tickets.GET("/count-new", h.CountNewServiceTickets)
 
@@ -290,7 +296,7 @@ func (h *ServiceTicketHandler) CountNewServiceTickets(c echo.Context) error {
 
 	count, err := h.ticketService.CountNewServiceTickets(projectID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, map[string]int64{"count": count})
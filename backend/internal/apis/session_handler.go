@@ -1,7 +1,10 @@
 package apis
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,16 +20,18 @@ type authCookieOptions struct {
 }
 
 type SessionHandler struct {
-	userService    *users.UserService
-	sessionService *user_sessions.SessionService
-	projectService *projects.ProjectService
+	userService          *users.UserService
+	sessionService       *user_sessions.SessionService
+	projectService       *projects.ProjectService
+	passwordResetService *users.PasswordResetService
 }
 
-func NewSessionHandler(userService *users.UserService, sessionService *user_sessions.SessionService, projectService *projects.ProjectService) *SessionHandler {
+func NewSessionHandler(userService *users.UserService, sessionService *user_sessions.SessionService, projectService *projects.ProjectService, passwordResetService *users.PasswordResetService) *SessionHandler {
 	return &SessionHandler{
-		userService:    userService,
-		sessionService: sessionService,
-		projectService: projectService,
+		userService:          userService,
+		sessionService:       sessionService,
+		projectService:       projectService,
+		passwordResetService: passwordResetService,
 	}
 }
 
@@ -38,10 +43,66 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	User         UserResponse `json:"user"`
-	SessionID    string       `json:"sessionId,omitempty"`
-	AccessToken  string       `json:"accessToken,omitempty"`
-	RefreshToken string       `json:"refreshToken,omitempty"`
+	Status         string       `json:"status,omitempty"`
+	User           UserResponse `json:"user"`
+	SessionID      string       `json:"sessionId,omitempty"`
+	AccessToken    string       `json:"accessToken,omitempty"`
+	RefreshToken   string       `json:"refreshToken,omitempty"`
+	ChallengeToken string       `json:"challengeToken,omitempty"`
+	RecoveryCodes  []string     `json:"recoveryCodes,omitempty"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	NewPassword     string `json:"newPassword" validate:"required"`
+}
+
+type SessionResponse struct {
+	ID              string     `json:"id"`
+	UserAgent       string     `json:"userAgent"`
+	IPAddress       string     `json:"ipAddress"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	LastRefreshedAt *time.Time `json:"lastRefreshedAt,omitempty"`
+	ExpiresAt       time.Time  `json:"expiresAt"`
+	Current         bool       `json:"current"`
+}
+
+type ForgotPasswordRequest struct {
+	LoginID string `json:"loginId" validate:"required"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"newPassword" validate:"required"`
+}
+
+type ExpiredPasswordChangeRequest struct {
+	ChallengeToken  string `json:"challengeToken" validate:"required"`
+	NewPassword     string `json:"newPassword" validate:"required"`
+	InvitationToken string `json:"invitationToken"`
+	UseCookie       bool   `json:"useCookie"`
+}
+
+type PasswordPolicyResponse struct {
+	MinLength    int      `json:"minLength"`
+	HistoryCount int      `json:"historyCount"`
+	Requirements []string `json:"requirements"`
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken  string `json:"challengeToken" validate:"required"`
+	Code            string `json:"code" validate:"required"`
+	InvitationToken string `json:"invitationToken"`
+	UseCookie       bool   `json:"useCookie"`
+}
+
+type TwoFactorEnrollmentStartRequest struct {
+	ChallengeToken string `json:"challengeToken" validate:"required"`
+}
+
+type TOTPEnrollmentResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioningUri"`
 }
 
 type RefreshTokenRequest struct {
@@ -62,12 +123,12 @@ func (h *SessionHandler) Login(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	if req.InvitationToken != "" {
 		if _, err := h.projectService.ResolveInvitation(req.InvitationToken); err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 		}
 	}
 
@@ -78,29 +139,137 @@ func (h *SessionHandler) Login(c echo.Context) error {
 	// Login and create session
 	result, err := h.sessionService.Login(req.LoginID, req.Password, userAgent, ipAddress)
 	if err != nil {
+		if throttleErr := loginThrottledError(c, err); throttleErr != nil {
+			return throttleErr
+		}
 		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid credentials")
 	}
 
-	if req.InvitationToken != "" {
-		if _, err := h.projectService.AcceptInvitation(req.InvitationToken, result.User.ID); err != nil {
-			_ = h.sessionService.RevokeSession(result.SessionID.String(), result.RefreshToken)
-			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	return h.respondWithSession(c, result, req.InvitationToken, req.UseCookie)
+}
+
+// VerifyTwoFactor completes a login with a TOTP or recovery code
+func (h *SessionHandler) VerifyTwoFactor(c echo.Context) error {
+	req := new(TwoFactorLoginRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	result, err := h.sessionService.CompleteTwoFactorLogin(req.ChallengeToken, req.Code, c.Request().UserAgent(), c.RealIP())
+	if err != nil {
+		if throttleErr := loginThrottledError(c, err); throttleErr != nil {
+			return throttleErr
+		}
+		return echo.NewHTTPError(http.StatusUnauthorized, err.Error()).SetInternal(err)
+	}
+
+	return h.respondWithSession(c, result, req.InvitationToken, req.UseCookie)
+}
+
+// StartTwoFactorEnrollment returns a TOTP secret for a user who must enable 2FA to log in
+func (h *SessionHandler) StartTwoFactorEnrollment(c echo.Context) error {
+	req := new(TwoFactorEnrollmentStartRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	enrollment, err := h.sessionService.BeginTwoFactorEnrollment(req.ChallengeToken)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, &TOTPEnrollmentResponse{
+		Secret:          enrollment.Secret,
+		ProvisioningURI: enrollment.ProvisioningURI,
+	})
+}
+
+// CompleteTwoFactorEnrollment confirms the TOTP secret and completes the login
+func (h *SessionHandler) CompleteTwoFactorEnrollment(c echo.Context) error {
+	req := new(TwoFactorLoginRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	result, err := h.sessionService.CompleteTwoFactorEnrollment(req.ChallengeToken, req.Code, c.Request().UserAgent(), c.RealIP())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, err.Error()).SetInternal(err)
+	}
+
+	return h.respondWithSession(c, result, req.InvitationToken, req.UseCookie)
+}
+
+// CompletePasswordChange sets a new password when login reported the password as expired
+func (h *SessionHandler) CompletePasswordChange(c echo.Context) error {
+	req := new(ExpiredPasswordChangeRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	result, err := h.sessionService.CompletePasswordChange(req.ChallengeToken, req.NewPassword, c.Request().UserAgent(), c.RealIP())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return h.respondWithSession(c, result, req.InvitationToken, req.UseCookie)
+}
+
+// GetPasswordPolicy returns the password requirements so clients can show them
+func (h *SessionHandler) GetPasswordPolicy(c echo.Context) error {
+	policy := h.userService.GetPasswordPolicy()
+
+	return c.JSON(http.StatusOK, &PasswordPolicyResponse{
+		MinLength:    policy.MinLength,
+		HistoryCount: policy.HistoryCount,
+		Requirements: policy.Requirements(),
+	})
+}
+
+// respondWithSession accepts a pending invitation and returns the new session to the client.
+// When another login step is required only the challenge token is returned.
+func (h *SessionHandler) respondWithSession(c echo.Context, result *user_sessions.LoginResult, invitationToken string, useCookie bool) error {
+	if result.ChallengeToken != "" {
+		return c.JSON(http.StatusOK, &LoginResponse{
+			Status:         result.Status,
+			User:           toUserResponse(result.User),
+			ChallengeToken: result.ChallengeToken,
+			RecoveryCodes:  result.RecoveryCodes,
+		})
+	}
+
+	if invitationToken != "" {
+		if _, err := h.projectService.AcceptInvitation(invitationToken, result.User.ID); err != nil {
+			_ = h.sessionService.RevokeSession(result.SessionID.String(), result.RefreshToken, c.Request().UserAgent(), c.RealIP())
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 		}
 	}
 
 	response := &LoginResponse{
-		User: UserResponse{
-			ID:              result.User.ID,
-			LoginID:         result.User.LoginID,
-			Name:            result.User.Name,
-			ProfileImageURL: result.User.ProfileImageURL,
-		},
-		SessionID:    result.SessionID.String(),
-		AccessToken:  result.AccessToken,
-		RefreshToken: result.RefreshToken,
+		Status:        result.Status,
+		User:          toUserResponse(result.User),
+		SessionID:     result.SessionID.String(),
+		AccessToken:   result.AccessToken,
+		RefreshToken:  result.RefreshToken,
+		RecoveryCodes: result.RecoveryCodes,
 	}
 
-	if req.UseCookie {
+	if useCookie {
 		// Set cookies in addition to the JSON payload so existing token-based
 		// clients continue to work.
 		h.setAuthCookies(c, result.SessionID.String(), result.AccessToken, result.RefreshToken)
@@ -129,12 +298,12 @@ func (h *SessionHandler) RefreshToken(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
-	result, err := h.sessionService.RefreshToken(req.SessionID, req.RefreshToken)
+	result, err := h.sessionService.RefreshToken(req.SessionID, req.RefreshToken, c.Request().UserAgent(), c.RealIP())
 	if err != nil {
-		return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+		return echo.NewHTTPError(http.StatusUnauthorized, err.Error()).SetInternal(err)
 	}
 
 	response := &LoginResponse{
@@ -176,10 +345,10 @@ func (h *SessionHandler) RevokeSession(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
-	err := h.sessionService.RevokeSession(req.SessionID, req.RefreshToken)
+	err := h.sessionService.RevokeSession(req.SessionID, req.RefreshToken, c.Request().UserAgent(), c.RealIP())
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to revoke session")
 	}
@@ -191,14 +360,12 @@ func (h *SessionHandler) RevokeSession(c echo.Context) error {
 }
 
 func (h *SessionHandler) RevokeAllSessions(c echo.Context) error {
-	// TODO: Get userID from authenticated context
-	// For now, we'll expect it in the request
 	userID, err := GetUserIDFromContext(c)
 	if err != nil {
 		return err
 	}
 
-	err = h.sessionService.RevokeAllUserSessions(userID)
+	err = h.sessionService.RevokeAllUserSessions(userID, c.Request().UserAgent(), c.RealIP())
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to revoke sessions")
 	}
@@ -209,6 +376,140 @@ func (h *SessionHandler) RevokeAllSessions(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]string{"message": "All sessions revoked successfully"})
 }
 
+// ListMySessions returns the current user's active sessions
+func (h *SessionHandler) ListMySessions(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := h.sessionService.GetActiveSessions(userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch sessions")
+	}
+
+	return c.JSON(http.StatusOK, toSessionResponses(sessions, GetSessionIDFromContext(c)))
+}
+
+// RevokeMySession revokes one of the current user's sessions by ID
+func (h *SessionHandler) RevokeMySession(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	sessionID := c.Param("sessionId")
+	if err := h.sessionService.RevokeUserSession(userID, sessionID, userID, c.Request().UserAgent(), c.RealIP()); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	if sessionID == GetSessionIDFromContext(c) {
+		h.clearAuthCookies(c)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Session revoked successfully"})
+}
+
+func toSessionResponses(sessions []*user_sessions.UserSession, currentSessionID string) []SessionResponse {
+	response := make([]SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, SessionResponse{
+			ID:              session.ID.String(),
+			UserAgent:       session.UserAgent,
+			IPAddress:       session.IPAddress,
+			CreatedAt:       session.CreatedAt,
+			LastRefreshedAt: session.LastRefreshedAt,
+			ExpiresAt:       session.ExpiresAt,
+			Current:         session.ID.String() == currentSessionID,
+		})
+	}
+	return response
+}
+
+// ChangePassword changes the current user's password and signs out their other sessions
+func (h *SessionHandler) ChangePassword(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	req := new(ChangePasswordRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	// Keep the session making the request alive
+	sessionID := GetSessionIDFromContext(c)
+
+	if err := h.sessionService.ChangePassword(userID, sessionID, req.CurrentPassword, req.NewPassword, c.Request().UserAgent(), c.RealIP()); err != nil {
+		if throttleErr := loginThrottledError(c, err); throttleErr != nil {
+			return throttleErr
+		}
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Password changed successfully"})
+}
+
+// ForgotPassword emails a password reset link; the response never reveals whether the account exists
+func (h *SessionHandler) ForgotPassword(c echo.Context) error {
+	req := new(ForgotPasswordRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	if err := h.passwordResetService.RequestReset(req.LoginID, c.RealIP()); err != nil {
+		c.Logger().Errorf("password reset request failed: %v", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "If the account exists, a password reset link has been sent",
+	})
+}
+
+// ResetPassword sets a new password with a reset token and signs out every session
+func (h *SessionHandler) ResetPassword(c echo.Context) error {
+	req := new(ResetPasswordRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	user, err := h.passwordResetService.ResetPassword(req.Token, req.NewPassword)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	if err := h.sessionService.RevokeAllUserSessions(user.ID, c.Request().UserAgent(), c.RealIP()); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to revoke sessions")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Password reset successfully"})
+}
+
+// loginThrottledError converts a throttled login into 429 with a Retry-After header
+func loginThrottledError(c echo.Context, err error) error {
+	var throttled *user_sessions.LoginThrottledError
+	if !errors.As(err, &throttled) {
+		return nil
+	}
+
+	seconds := int(math.Ceil(throttled.RetryAfter.Seconds()))
+	c.Response().Header().Set("Retry-After", strconv.Itoa(seconds))
+	return echo.NewHTTPError(http.StatusTooManyRequests, throttled.Error())
+}
+
 func resolveAuthCookieOptions(c echo.Context) authCookieOptions {
 	request := c.Request()
 	secure := request.TLS != nil
@@ -279,9 +580,21 @@ func (h *SessionHandler) clearAuthCookies(c echo.Context) {
 	}
 }
 
-func (h *SessionHandler) RegisterRoutes(e *echo.Echo) {
+func (h *SessionHandler) RegisterRoutes(e *echo.Echo, authMiddleware *AuthMiddleware) {
 	e.POST("/api/auth/login", h.Login)
+	e.POST("/api/auth/login/two-factor", h.VerifyTwoFactor)
+	e.POST("/api/auth/login/two-factor/setup", h.StartTwoFactorEnrollment)
+	e.POST("/api/auth/login/two-factor/enable", h.CompleteTwoFactorEnrollment)
+	e.POST("/api/auth/login/password-change", h.CompletePasswordChange)
+	e.GET("/api/auth/password-policy", h.GetPasswordPolicy)
 	e.POST("/api/auth/refresh-token", h.RefreshToken)
 	e.POST("/api/auth/revoke-session", h.RevokeSession)
-	e.POST("/api/auth/revoke-all-sessions", h.RevokeAllSessions)
+	e.POST("/api/auth/revoke-all-sessions", h.RevokeAllSessions, authMiddleware.RequireAuth, authMiddleware.RequireSessionAuth)
+	e.POST("/api/auth/change-password", h.ChangePassword, authMiddleware.RequireAuth, authMiddleware.RequireSessionAuth)
+	e.POST("/api/auth/forgot-password", h.ForgotPassword)
+	e.POST("/api/auth/reset-password", h.ResetPassword)
+
+	sessions := e.Group("/api/users/me/sessions", authMiddleware.RequireAuth, authMiddleware.RequireSessionAuth)
+	sessions.GET("", h.ListMySessions)
+	sessions.DELETE("/:sessionId", h.RevokeMySession)
 }
@@ -172,7 +172,7 @@ func (h *SprintHandler) CreateSprint(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	userID, err := GetUserIDFromContext(c)
@@ -208,7 +208,7 @@ func (h *SprintHandler) CreateSprint(c echo.Context) error {
 		userID,
 	)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusCreated, toSprintResponse(sprint))
@@ -227,7 +227,7 @@ func (h *SprintHandler) UpdateSprint(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	userID, err := GetUserIDFromContext(c)
@@ -263,7 +263,7 @@ func (h *SprintHandler) UpdateSprint(c echo.Context) error {
 		userID,
 	)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toSprintResponse(sprint))
@@ -283,7 +283,7 @@ func (h *SprintHandler) StartSprint(c echo.Context) error {
 
 	sprint, err := h.sprintService.StartSprint(sprintID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toSprintResponse(sprint))
@@ -324,7 +324,7 @@ func (h *SprintHandler) CloseSprint(c echo.Context) error {
 		userID,
 	)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	response := CloseSprintResponse{
@@ -355,7 +355,7 @@ func (h *SprintHandler) DeleteSprint(c echo.Context) error {
 	}
 
 	if err := h.sprintService.DeleteSprint(sprintID, userID); err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.NoContent(http.StatusNoContent)
@@ -375,7 +375,7 @@ func (h *SprintHandler) GetSprint(c echo.Context) error {
 
 	sprint, err := h.sprintService.GetSprint(sprintID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toSprintResponse(sprint))
@@ -410,7 +410,7 @@ func (h *SprintHandler) GetProjectSprints(c echo.Context) error {
 
 	sprintList, total, err := h.sprintService.GetProjectSprints(projectID, page, pageSize, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toSprintListResponse(sprintList, total, page, pageSize))
@@ -435,7 +435,7 @@ func (h *SprintHandler) GetActiveSprint(c echo.Context) error {
 
 	sprint, err := h.sprintService.GetActiveSprint(projectID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	if sprint == nil {
@@ -469,7 +469,7 @@ func (h *SprintHandler) GetSprintTasks(c echo.Context) error {
 
 	taskList, total, err := h.sprintService.GetSprintTasks(sprintID, page, pageSize, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toTaskListResponse(taskList, total, page, pageSize))
@@ -488,7 +488,7 @@ func (h *SprintHandler) AddTaskToSprint(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	userID, err := GetUserIDFromContext(c)
@@ -498,7 +498,7 @@ func (h *SprintHandler) AddTaskToSprint(c echo.Context) error {
 
 	task, err := h.sprintService.AddTaskToSprint(req.TaskID, sprintID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toTaskResponse(task))
@@ -517,7 +517,7 @@ func (h *SprintHandler) AddCompletedItemsToRelease(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	userID, err := GetUserIDFromContext(c)
@@ -527,7 +527,7 @@ func (h *SprintHandler) AddCompletedItemsToRelease(c echo.Context) error {
 
 	result, err := h.sprintService.AddCompletedItemsToRelease(sprintID, req.ReleaseID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, AddCompletedItemsToReleaseResponse{
@@ -551,7 +551,7 @@ func (h *SprintHandler) RemoveTaskFromSprint(c echo.Context) error {
 
 	task, err := h.sprintService.RemoveTaskFromSprint(taskID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toTaskResponse(task))
@@ -571,12 +571,12 @@ func (h *SprintHandler) GetSprintBoard(c echo.Context) error {
 
 	sprint, err := h.sprintService.GetSprint(sprintID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	tasksByStatus, err := h.sprintService.GetSprintTasksByStatus(sprintID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	// Convert tasks to response format
@@ -605,12 +605,12 @@ func (h *SprintHandler) GetSprintSwimlane(c echo.Context) error {
 
 	sprint, err := h.sprintService.GetSprint(sprintID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	tasksByAssignee, unassignedTasks, err := h.sprintService.GetSprintTasksByAssignee(sprintID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	// Convert tasks to response format
@@ -64,7 +64,7 @@ func (h *StatusChangeHandler) ListByItem(c echo.Context) error {
 
 	changes, err := h.statusChangeService.GetByItem(projectID, itemType, itemID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	response := make([]StatusChangeResponse, len(changes))
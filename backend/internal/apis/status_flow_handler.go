@@ -71,7 +71,7 @@ func (h *StatusFlowHandler) ListStatusFlows(c echo.Context) error {
 
 	flows, err := h.statusChangeService.ListStatusFlows(projectID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	response := make([]StatusFlowResponse, len(flows))
@@ -98,12 +98,12 @@ func (h *StatusFlowHandler) CreateStatusFlow(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
 	}
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	flow, err := h.statusChangeService.CreateStatusFlow(projectID, req.ItemType, req.FromStatus, req.ToStatuses, req.Disabled, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusCreated, toStatusFlowResponse(flow))
@@ -130,12 +130,12 @@ func (h *StatusFlowHandler) UpdateStatusFlow(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
 	}
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	flow, err := h.statusChangeService.UpdateStatusFlow(projectID, flowID, req.ItemType, req.FromStatus, req.ToStatuses, req.Disabled, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toStatusFlowResponse(flow))
@@ -158,7 +158,7 @@ func (h *StatusFlowHandler) DeleteStatusFlow(c echo.Context) error {
 	}
 
 	if err := h.statusChangeService.DeleteStatusFlow(projectID, flowID, userID); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.NoContent(http.StatusNoContent)
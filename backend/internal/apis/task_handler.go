@@ -6,6 +6,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/dannyswat/pjeasy/internal/custom_fields"
+	"github.com/dannyswat/pjeasy/internal/projects"
 	"github.com/dannyswat/pjeasy/internal/tasks"
 	"github.com/labstack/echo/v4"
 )
@@ -21,33 +23,35 @@ func NewTaskHandler(taskService *tasks.TaskService) *TaskHandler {
 }
 
 type CreateTaskRequest struct {
-	ProjectID      int     `json:"projectId" validate:"required"`
-	Title          string  `json:"title" validate:"required,min=1,max=200"`
-	Description    string  `json:"description"`
-	Status         string  `json:"status" validate:"omitempty,oneof='Open' 'In Progress' 'On Hold' 'Blocked' 'Completed' 'Rejected' 'Reopened' 'Closed'"`
-	Priority       string  `json:"priority" validate:"omitempty,oneof=Immediate Urgent High Normal Low"`
-	EstimatedHours float64 `json:"estimatedHours" validate:"gte=0"`
-	AssigneeID     *int    `json:"assigneeId"`
-	Deadline       *string `json:"deadline"`
-	SprintID       *int    `json:"sprintId"`
-	ReleaseID      *int    `json:"releaseId"`
-	ItemType       string  `json:"itemType"`
-	ItemID         *int    `json:"itemId"`
-	Tags           string  `json:"tags"`
+	ProjectID      int                  `json:"projectId" validate:"required"`
+	Title          string               `json:"title" validate:"required,min=1,max=200"`
+	Description    string               `json:"description"`
+	Status         string               `json:"status" validate:"omitempty,oneof='Open' 'In Progress' 'On Hold' 'Blocked' 'Completed' 'Rejected' 'Reopened' 'Closed'"`
+	Priority       string               `json:"priority" validate:"omitempty,oneof=Immediate Urgent High Normal Low"`
+	EstimatedHours float64              `json:"estimatedHours" validate:"gte=0"`
+	AssigneeID     *int                 `json:"assigneeId"`
+	Deadline       *string              `json:"deadline"`
+	SprintID       *int                 `json:"sprintId"`
+	ReleaseID      *int                 `json:"releaseId"`
+	ItemType       string               `json:"itemType"`
+	ItemID         *int                 `json:"itemId"`
+	Tags           string               `json:"tags"`
+	CustomFields   custom_fields.Values `json:"customFields"`
 }
 
 type UpdateTaskRequest struct {
-	Title          string  `json:"title" validate:"required,min=1,max=200"`
-	Description    string  `json:"description"`
-	Priority       string  `json:"priority" validate:"omitempty,oneof=Immediate Urgent High Normal Low"`
-	EstimatedHours float64 `json:"estimatedHours" validate:"gte=0"`
-	AssigneeID     *int    `json:"assigneeId"`
-	Deadline       *string `json:"deadline"`
-	SprintID       *int    `json:"sprintId"`
-	ReleaseID      *int    `json:"releaseId"`
-	ItemType       string  `json:"itemType"`
-	ItemID         *int    `json:"itemId"`
-	Tags           string  `json:"tags"`
+	Title          string               `json:"title" validate:"required,min=1,max=200"`
+	Description    string               `json:"description"`
+	Priority       string               `json:"priority" validate:"omitempty,oneof=Immediate Urgent High Normal Low"`
+	EstimatedHours float64              `json:"estimatedHours" validate:"gte=0"`
+	AssigneeID     *int                 `json:"assigneeId"`
+	Deadline       *string              `json:"deadline"`
+	SprintID       *int                 `json:"sprintId"`
+	ReleaseID      *int                 `json:"releaseId"`
+	ItemType       string               `json:"itemType"`
+	ItemID         *int                 `json:"itemId"`
+	Tags           string               `json:"tags"`
+	CustomFields   custom_fields.Values `json:"customFields"`
 }
 
 type UpdateTaskStatusRequest struct {
@@ -66,23 +70,24 @@ type UpdateTaskAssigneeRequest struct {
 type TaskResponse struct {
 	ID int `json:"id"`
 
-	ProjectID       int       `json:"projectId"`
-	Title           string    `json:"title"`
-	Description     string    `json:"description"`
-	Status          string    `json:"status"`
-	Priority        string    `json:"priority"`
-	EstimatedHours  float64   `json:"estimatedHours"`
-	AssigneeID      *int      `json:"assigneeId"`
-	Deadline        *string   `json:"deadline"`
-	SprintID        *int      `json:"sprintId"`
-	ReleaseID       *int      `json:"releaseId"`
-	ItemType        string    `json:"itemType"`
-	ItemID          *int      `json:"itemId"`
-	LinkedIdeaLabel string    `json:"linkedIdeaLabel,omitempty"`
-	Tags            string    `json:"tags"`
-	CreatedBy       int       `json:"createdBy"`
-	CreatedAt       time.Time `json:"createdAt"`
-	UpdatedAt       time.Time `json:"updatedAt"`
+	ProjectID       int                  `json:"projectId"`
+	Title           string               `json:"title"`
+	Description     string               `json:"description"`
+	Status          string               `json:"status"`
+	Priority        string               `json:"priority"`
+	EstimatedHours  float64              `json:"estimatedHours"`
+	AssigneeID      *int                 `json:"assigneeId"`
+	Deadline        *string              `json:"deadline"`
+	SprintID        *int                 `json:"sprintId"`
+	ReleaseID       *int                 `json:"releaseId"`
+	ItemType        string               `json:"itemType"`
+	ItemID          *int                 `json:"itemId"`
+	LinkedIdeaLabel string               `json:"linkedIdeaLabel,omitempty"`
+	Tags            string               `json:"tags"`
+	CustomFields    custom_fields.Values `json:"customFields,omitempty"`
+	CreatedBy       int                  `json:"createdBy"`
+	CreatedAt       time.Time            `json:"createdAt"`
+	UpdatedAt       time.Time            `json:"updatedAt"`
 }
 
 type TaskListResponse struct {
@@ -119,6 +124,7 @@ func toTaskResponse(task *tasks.Task) TaskResponse {
 		ItemID:          task.ItemID,
 		LinkedIdeaLabel: task.LinkedIdeaLabel,
 		Tags:            task.Tags,
+		CustomFields:    task.CustomFields,
 		CreatedBy:       task.CreatedBy,
 		CreatedAt:       task.CreatedAt,
 		UpdatedAt:       task.UpdatedAt,
@@ -147,7 +153,7 @@ func (h *TaskHandler) CreateTask(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	userID, err := GetUserIDFromContext(c)
@@ -178,10 +184,11 @@ func (h *TaskHandler) CreateTask(c echo.Context) error {
 		req.ReleaseID,
 		req.ItemType,
 		req.ItemID,
+		req.CustomFields,
 		userID,
 	)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusCreated, toTaskResponse(task))
@@ -200,7 +207,7 @@ func (h *TaskHandler) UpdateTask(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	userID, err := GetUserIDFromContext(c)
@@ -230,10 +237,11 @@ func (h *TaskHandler) UpdateTask(c echo.Context) error {
 		req.ReleaseID,
 		req.ItemType,
 		req.ItemID,
+		req.CustomFields,
 		userID,
 	)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toTaskResponse(task))
@@ -252,7 +260,7 @@ func (h *TaskHandler) UpdateTaskStatus(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	userID, err := GetUserIDFromContext(c)
@@ -262,7 +270,7 @@ func (h *TaskHandler) UpdateTaskStatus(c echo.Context) error {
 
 	task, err := h.taskService.UpdateTaskStatus(taskID, req.Status, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toTaskResponse(task))
@@ -281,7 +289,7 @@ func (h *TaskHandler) BatchUpdateTaskStatus(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	userID, err := GetUserIDFromContext(c)
@@ -293,7 +301,7 @@ func (h *TaskHandler) BatchUpdateTaskStatus(c echo.Context) error {
 	for _, taskID := range req.TaskIDs {
 		task, err := h.taskService.GetTask(taskID, userID)
 		if err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 		}
 		if task.ProjectID != projectID {
 			return echo.NewHTTPError(http.StatusBadRequest, "task does not belong to project")
@@ -301,7 +309,7 @@ func (h *TaskHandler) BatchUpdateTaskStatus(c echo.Context) error {
 
 		updatedTask, err := h.taskService.UpdateTaskStatus(taskID, req.Status, userID)
 		if err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 		}
 
 		updatedTasks = append(updatedTasks, toTaskResponse(updatedTask))
@@ -329,7 +337,7 @@ func (h *TaskHandler) UpdateTaskAssignee(c echo.Context) error {
 
 	task, err := h.taskService.UpdateTaskAssignee(taskID, req.AssigneeID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toTaskResponse(task))
@@ -348,7 +356,7 @@ func (h *TaskHandler) DeleteTask(c echo.Context) error {
 	}
 
 	if err := h.taskService.DeleteTask(taskID, userID); err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.NoContent(http.StatusNoContent)
@@ -368,7 +376,7 @@ func (h *TaskHandler) GetTask(c echo.Context) error {
 
 	task, err := h.taskService.GetTask(taskID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toTaskResponse(task))
@@ -409,9 +417,9 @@ func (h *TaskHandler) GetProjectTasks(c echo.Context) error {
 		return err
 	}
 
-	taskList, total, err := h.taskService.GetProjectTasks(projectID, statuses, page, pageSize, userID)
+	taskList, total, err := h.taskService.GetProjectTasks(projectID, statuses, customFieldFilters(c), page, pageSize, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toTaskListResponse(taskList, total, page, pageSize))
@@ -443,7 +451,7 @@ func (h *TaskHandler) GetMyTasks(c echo.Context) error {
 
 	taskList, total, err := h.taskService.GetMyTasks(page, pageSize, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toTaskListResponse(taskList, total, page, pageSize))
@@ -494,7 +502,7 @@ func (h *TaskHandler) GetTasksByItemReference(c echo.Context) error {
 
 	taskList, total, err := h.taskService.GetTasksByItemReference(projectID, itemType, itemID, page, pageSize, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toTaskListResponse(taskList, total, page, pageSize))
@@ -504,10 +512,10 @@ func (h *TaskHandler) GetTasksByItemReference(c echo.Context) error {
 func (h *TaskHandler) RegisterRoutes(e *echo.Echo, authMiddleware *AuthMiddleware, projectMiddleware *ProjectMiddleware) {
 	tasks := e.Group("/api/projects/:projectId/tasks", authMiddleware.RequireAuth, projectMiddleware.RequireProjectMember)
 
-	tasks.POST("", h.CreateTask)
+	tasks.POST("", h.CreateTask, projectMiddleware.RequirePermission(projects.PermissionItemTask, projects.PermissionActionCreate))
 	tasks.GET("", h.GetProjectTasks)
 	tasks.GET("/by-item", h.GetTasksByItemReference)
-	tasks.PATCH("/status", h.BatchUpdateTaskStatus)
+	tasks.PATCH("/status", h.BatchUpdateTaskStatus, projectMiddleware.RequirePermission(projects.PermissionItemTask, projects.PermissionActionChangeStatus))
 
 	taskItem := e.Group("/api/tasks/:id", authMiddleware.RequireAuth)
 
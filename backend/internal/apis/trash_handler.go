@@ -0,0 +1,149 @@
+package apis
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/dannyswat/pjeasy/internal/trash"
+	"github.com/labstack/echo/v4"
+)
+
+type TrashHandler struct {
+	trashService *trash.TrashService
+}
+
+func NewTrashHandler(trashService *trash.TrashService) *TrashHandler {
+	return &TrashHandler{
+		trashService: trashService,
+	}
+}
+
+type TrashItemResponse struct {
+	ItemType  string `json:"itemType"`
+	ID        int    `json:"id"`
+	ProjectID int    `json:"projectId"`
+	RefNum    string `json:"refNum,omitempty"`
+	Title     string `json:"title"`
+	DeletedAt string `json:"deletedAt"`
+	DeletedBy *int   `json:"deletedBy,omitempty"`
+	PurgeAt   string `json:"purgeAt"`
+}
+
+type TrashListResponse struct {
+	Items    []TrashItemResponse `json:"items"`
+	Total    int64               `json:"total"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"pageSize"`
+}
+
+func toTrashItemResponse(item *trash.TrashItem) TrashItemResponse {
+	return TrashItemResponse{
+		ItemType:  item.ItemType,
+		ID:        item.ID,
+		ProjectID: item.ProjectID,
+		RefNum:    item.RefNum,
+		Title:     item.Title,
+		DeletedAt: item.DeletedAt.Format("2006-01-02T15:04:05Z07:00"),
+		DeletedBy: item.DeletedBy,
+		PurgeAt:   item.PurgeAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+// ListTrash returns the deleted items of a project
+func (h *TrashHandler) ListTrash(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := GetProjectIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	page, err := strconv.Atoi(c.QueryParam("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(c.QueryParam("pageSize"))
+	if err != nil || pageSize < 1 {
+		pageSize = 20
+	}
+
+	items, total, err := h.trashService.ListTrash(projectID, page, pageSize, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	response := TrashListResponse{
+		Items:    make([]TrashItemResponse, len(items)),
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}
+	for i := range items {
+		response.Items[i] = toTrashItemResponse(&items[i])
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// RestoreItem takes an item out of the trash
+func (h *TrashHandler) RestoreItem(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := GetProjectIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	itemID, err := strconv.Atoi(c.Param("itemId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid item ID")
+	}
+
+	item, err := h.trashService.RestoreItem(projectID, c.Param("itemType"), itemID, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, toTrashItemResponse(item))
+}
+
+// PurgeItem permanently deletes an item in the trash
+func (h *TrashHandler) PurgeItem(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	projectID, err := GetProjectIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	itemID, err := strconv.Atoi(c.Param("itemId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid item ID")
+	}
+
+	if err := h.trashService.PurgeItem(projectID, c.Param("itemType"), itemID, userID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Item purged successfully",
+	})
+}
+
+func (h *TrashHandler) RegisterRoutes(e *echo.Echo, authMiddleware *AuthMiddleware, projectMiddleware *ProjectMiddleware) {
+	trashGroup := e.Group("/api/projects/:id/trash", authMiddleware.RequireAuth, projectMiddleware.RequireProjectMember)
+
+	trashGroup.GET("", h.ListTrash)
+	trashGroup.POST("/:itemType/:itemId/restore", h.RestoreItem)
+	trashGroup.DELETE("/:itemType/:itemId", h.PurgeItem, projectMiddleware.RequireProjectAdmin)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -120,27 +121,36 @@ func getPngBinaryFromBase64DataURL(dataURL string) ([]byte, error) {
 	return base64.StdEncoding.DecodeString(parts[1])
 }
 
-// UploadImage handles image file uploads
-func (s *APIServer) UploadImage(c echo.Context) error {
-	userID, err := GetUserIDFromContext(c)
-	if err != nil {
-		return err
-	}
-	// Get file from request
+// formImageFile returns the "image" form file after checking its size and content type
+func formImageFile(c echo.Context, allowedTypes map[string]bool, invalidTypeMessage string) (*multipart.FileHeader, string, error) {
 	file, err := c.FormFile("image")
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "No image file provided")
+		return nil, "", echo.NewHTTPError(http.StatusBadRequest, "No image file provided")
 	}
 
 	// Validate file size
 	if file.Size > maxUploadSize {
-		return echo.NewHTTPError(http.StatusBadRequest, "File size exceeds 5MB limit")
+		return nil, "", echo.NewHTTPError(http.StatusBadRequest, "File size exceeds 5MB limit")
 	}
 
 	// Validate file type
 	contentType := file.Header.Get("Content-Type")
-	if !allowedImageTypes[contentType] {
-		return echo.NewHTTPError(http.StatusBadRequest, "Invalid file type. Allowed: jpg, jpeg, png, gif, svg, webp")
+	if !allowedTypes[contentType] {
+		return nil, "", echo.NewHTTPError(http.StatusBadRequest, invalidTypeMessage)
+	}
+
+	return file, contentType, nil
+}
+
+// UploadImage handles image file uploads
+func (s *APIServer) UploadImage(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+	file, contentType, err := formImageFile(c, allowedImageTypes, "Invalid file type. Allowed: jpg, jpeg, png, gif, svg, webp")
+	if err != nil {
+		return err
 	}
 
 	// Open uploaded file
@@ -255,6 +265,11 @@ func (s *APIServer) SaveDiagram(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid PNG content")
 	}
 
+	// Diagram files belong to the project, so archived projects keep them unchanged
+	if err := s.projectService.EnsureWritable(projectID); err != nil {
+		return err
+	}
+
 	sourceDir := s.projectUploadDir(projectID, "diagramsrc")
 	diagramDir := s.projectUploadDir(projectID, "diagram")
 	if err := ensureUploadDir(sourceDir); err != nil {
@@ -346,6 +361,11 @@ func RegisterUploadRoutes(e *echo.Echo, server *APIServer, authMiddleware *AuthM
 	// Serve uploaded images (public access)
 	e.GET("/uploads/images/:filename", server.ServeUploadedImage)
 
+	// Avatars of the current user; served publicly like other uploaded images
+	e.POST("/api/users/me/avatar", server.UploadAvatar, authMiddleware.RequireAuth)
+	e.DELETE("/api/users/me/avatar", server.DeleteAvatar, authMiddleware.RequireAuth)
+	e.GET("/uploads/avatars/:filename", server.ServeAvatar)
+
 	diagrams := e.Group("/api/projects/:projectId/diagrams", authMiddleware.RequireAuth, projectMiddleware.RequireProjectMember)
 	diagrams.POST("", server.SaveDiagram)
 	diagrams.GET("/source/:id", server.GetDiagramSource)
@@ -201,7 +201,7 @@ func (h *UserDailyHandler) GetBoard(c echo.Context) error {
 	}
 	board, err := h.service.GetBoard(userID, workDate)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 	return c.JSON(http.StatusOK, toUserDailyBoardResponse(board))
 }
@@ -216,7 +216,7 @@ func (h *UserDailyHandler) AddItem(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request")
 	}
 	if err := c.Validate(&req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 	workDate, err := parseUserDailyDate(req.Date)
 	if err != nil {
@@ -224,7 +224,7 @@ func (h *UserDailyHandler) AddItem(c echo.Context) error {
 	}
 	item, err := h.service.AddItem(userID, workDate, req.ItemType, req.ItemID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 	return c.JSON(http.StatusCreated, toUserDailyItemResponse(*item))
 }
@@ -239,7 +239,7 @@ func (h *UserDailyHandler) RemoveItem(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid daily item ID")
 	}
 	if err := h.service.RemoveItem(userID, dailyItemID); err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 	return c.NoContent(http.StatusNoContent)
 }
@@ -258,11 +258,11 @@ func (h *UserDailyHandler) UpdateItemStatus(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request")
 	}
 	if err := c.Validate(&req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 	item, err := h.service.UpdateItemStatus(userID, dailyItemID, req.Status)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 	return c.JSON(http.StatusOK, toUserDailyItemResponse(*item))
 }
@@ -277,11 +277,11 @@ func (h *UserDailyHandler) CreateTimeLog(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request")
 	}
 	if err := c.Validate(&req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 	log, err := h.service.CreateTimeLog(userID, req.UserDailyItemID, 0, req.DurationUnits)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 	return c.JSON(http.StatusCreated, toUserDailyTimeLogResponse(*log))
 }
@@ -300,11 +300,11 @@ func (h *UserDailyHandler) UpdateTimeLog(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request")
 	}
 	if err := c.Validate(&req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 	log, err := h.service.UpdateTimeLog(userID, timeLogID, 0, req.DurationUnits)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 	return c.JSON(http.StatusOK, toUserDailyTimeLogResponse(*log))
 }
@@ -319,7 +319,7 @@ func (h *UserDailyHandler) DeleteTimeLog(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid time log ID")
 	}
 	if err := h.service.DeleteTimeLog(userID, timeLogID); err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 	return c.NoContent(http.StatusNoContent)
 }
@@ -335,7 +335,7 @@ func (h *UserDailyHandler) GetSummary(c echo.Context) error {
 	}
 	summary, err := h.service.GetSummary(userID, workDate, c.QueryParam("range"))
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 	}
 	projects := make([]userDailyProjectSummaryResponse, 0, len(summary.Projects))
 	for _, project := range summary.Projects {
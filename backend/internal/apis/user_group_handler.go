@@ -0,0 +1,257 @@
+package apis
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/user_groups"
+	"github.com/labstack/echo/v4"
+)
+
+type UserGroupHandler struct {
+	groupService *user_groups.UserGroupService
+}
+
+func NewUserGroupHandler(groupService *user_groups.UserGroupService) *UserGroupHandler {
+	return &UserGroupHandler{
+		groupService: groupService,
+	}
+}
+
+type UserGroupRequest struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description" validate:"max=500"`
+}
+
+type AddGroupMemberRequest struct {
+	LoginID string `json:"loginId" validate:"required"`
+}
+
+type UserGroupResponse struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	MemberCount *int64    `json:"memberCount,omitempty"` // Only returned when listing groups
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+type UserGroupsResponse struct {
+	Groups   []UserGroupResponse `json:"groups"`
+	Total    int64               `json:"total"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"pageSize"`
+}
+
+type GroupMemberResponse struct {
+	UserID  int          `json:"userId"`
+	User    UserResponse `json:"user"`
+	AddedAt time.Time    `json:"addedAt"`
+}
+
+func toUserGroupResponse(group *user_groups.UserGroup, memberCount *int64) UserGroupResponse {
+	return UserGroupResponse{
+		ID:          group.ID,
+		Name:        group.Name,
+		Description: group.Description,
+		MemberCount: memberCount,
+		CreatedAt:   group.CreatedAt,
+		UpdatedAt:   group.UpdatedAt,
+	}
+}
+
+func toGroupMemberResponse(member *user_groups.GroupMemberWithUser) GroupMemberResponse {
+	return GroupMemberResponse{
+		UserID: member.Member.UserID,
+		User: UserResponse{
+			ID:              member.User.ID,
+			LoginID:         member.User.LoginID,
+			Name:            member.User.Name,
+			ProfileImageURL: member.User.ProfileImageURL,
+		},
+		AddedAt: member.Member.AddedAt,
+	}
+}
+
+func parseGroupID(c echo.Context) (int, error) {
+	groupID, err := strconv.Atoi(c.Param("groupId"))
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "Invalid group ID")
+	}
+	return groupID, nil
+}
+
+// ListGroups searches user groups by name
+func (h *UserGroupHandler) ListGroups(c echo.Context) error {
+	page, err := strconv.Atoi(c.QueryParam("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(c.QueryParam("pageSize"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	groups, total, err := h.groupService.ListGroups(c.QueryParam("search"), page, pageSize)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch groups")
+	}
+
+	response := make([]UserGroupResponse, 0, len(groups))
+	for i := range groups {
+		response = append(response, toUserGroupResponse(&groups[i].Group, &groups[i].MemberCount))
+	}
+
+	return c.JSON(http.StatusOK, UserGroupsResponse{
+		Groups:   response,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	})
+}
+
+// CreateGroup adds a user group
+func (h *UserGroupHandler) CreateGroup(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	req := new(UserGroupRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	group, err := h.groupService.CreateGroup(req.Name, req.Description, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusCreated, toUserGroupResponse(group, nil))
+}
+
+// UpdateGroup renames a user group or changes its description
+func (h *UserGroupHandler) UpdateGroup(c echo.Context) error {
+	groupID, err := parseGroupID(c)
+	if err != nil {
+		return err
+	}
+
+	req := new(UserGroupRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	group, err := h.groupService.UpdateGroup(groupID, req.Name, req.Description)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, toUserGroupResponse(group, nil))
+}
+
+// DeleteGroup removes a user group and its project access
+func (h *UserGroupHandler) DeleteGroup(c echo.Context) error {
+	groupID, err := parseGroupID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.groupService.DeleteGroup(groupID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListGroupMembers returns the members of a user group
+func (h *UserGroupHandler) ListGroupMembers(c echo.Context) error {
+	groupID, err := parseGroupID(c)
+	if err != nil {
+		return err
+	}
+
+	members, err := h.groupService.GetGroupMembers(groupID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error()).SetInternal(err)
+	}
+
+	response := make([]GroupMemberResponse, 0, len(members))
+	for i := range members {
+		response = append(response, toGroupMemberResponse(&members[i]))
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// AddGroupMember adds a user to a group by login ID
+func (h *UserGroupHandler) AddGroupMember(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	groupID, err := parseGroupID(c)
+	if err != nil {
+		return err
+	}
+
+	req := new(AddGroupMemberRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	member, err := h.groupService.AddMemberByLoginID(groupID, req.LoginID, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusCreated, toGroupMemberResponse(member))
+}
+
+// RemoveGroupMember removes a user from a group
+func (h *UserGroupHandler) RemoveGroupMember(c echo.Context) error {
+	groupID, err := parseGroupID(c)
+	if err != nil {
+		return err
+	}
+
+	userID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid user ID")
+	}
+
+	if err := h.groupService.RemoveMember(groupID, userID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *UserGroupHandler) RegisterRoutes(e *echo.Echo, authMiddleware *AuthMiddleware) {
+	// Group names are visible to every user so project admins can add groups to their projects
+	e.GET("/api/groups", h.ListGroups, authMiddleware.RequireAuth)
+
+	groups := e.Group("/api/admins/groups", authMiddleware.RequireAuth, authMiddleware.RequireSessionAuth, authMiddleware.RequireAdmin)
+	groups.GET("", h.ListGroups)
+	groups.POST("", h.CreateGroup)
+	groups.PUT("/:groupId", h.UpdateGroup)
+	groups.DELETE("/:groupId", h.DeleteGroup)
+	groups.GET("/:groupId/members", h.ListGroupMembers)
+	groups.POST("/:groupId/members", h.AddGroupMember)
+	groups.DELETE("/:groupId/members/:userId", h.RemoveGroupMember)
+}
@@ -1,29 +1,36 @@
 package apis
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/dannyswat/pjeasy/internal/projects"
+	userroles "github.com/dannyswat/pjeasy/internal/user_roles"
 	"github.com/dannyswat/pjeasy/internal/users"
 	"github.com/labstack/echo/v4"
 )
 
 type UserHandler struct {
-	userService    *users.UserService
-	projectService *projects.ProjectService
+	userService        *users.UserService
+	emailChangeService *users.EmailChangeService
+	projectService     *projects.ProjectService
+	settingService     *userroles.SystemSettingService
 }
 
-func NewUserHandler(userService *users.UserService, projectService *projects.ProjectService) *UserHandler {
+func NewUserHandler(userService *users.UserService, emailChangeService *users.EmailChangeService, projectService *projects.ProjectService, settingService *userroles.SystemSettingService) *UserHandler {
 	return &UserHandler{
-		userService:    userService,
-		projectService: projectService,
+		userService:        userService,
+		emailChangeService: emailChangeService,
+		projectService:     projectService,
+		settingService:     settingService,
 	}
 }
 
 type RegisterRequest struct {
 	LoginID         string `json:"loginId" validate:"required"`
 	Name            string `json:"name" validate:"required"`
-	Password        string `json:"password" validate:"required,complexpassword"`
+	Email           string `json:"email" validate:"omitempty,email"`
+	Password        string `json:"password" validate:"required"`
 	InvitationToken string `json:"invitationToken"`
 }
 
@@ -31,9 +38,65 @@ type UserResponse struct {
 	ID              int    `json:"id"`
 	LoginID         string `json:"loginId"`
 	Name            string `json:"name"`
+	Email           string `json:"email,omitempty"`         // Only returned to the user themselves
+	EmailVerified   bool   `json:"emailVerified,omitempty"` // Only returned to the user themselves
 	ProfileImageURL string `json:"profileImageUrl,omitempty"`
 }
 
+// RegisterResponse is the new user and whether their email must be confirmed before the invitation applies
+type RegisterResponse struct {
+	UserResponse
+	EmailConfirmationRequired bool `json:"emailConfirmationRequired,omitempty"`
+}
+
+type UpdateProfileRequest struct {
+	Name string `json:"name" validate:"required,max=255"`
+}
+
+type ChangeEmailRequest struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	Email           string `json:"email" validate:"required,email,max=255"`
+}
+
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type UserPreferencesRequest struct {
+	Timezone         string `json:"timezone" validate:"required"`
+	Locale           string `json:"locale" validate:"required"`
+	DefaultProjectID *int   `json:"defaultProjectId"`
+}
+
+type UserPreferencesResponse struct {
+	Timezone         string `json:"timezone"`
+	Locale           string `json:"locale"`
+	DefaultProjectID *int   `json:"defaultProjectId,omitempty"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type TwoFactorStatusResponse struct {
+	Enabled                bool `json:"enabled"`
+	Required               bool `json:"required"`
+	RecoveryCodesRemaining int  `json:"recoveryCodesRemaining"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+func toUserResponse(user *users.User) UserResponse {
+	return UserResponse{
+		ID:              user.ID,
+		LoginID:         user.LoginID,
+		Name:            user.Name,
+		ProfileImageURL: user.ProfileImageURL,
+	}
+}
+
 func (h *UserHandler) Register(c echo.Context) error {
 	req := new(RegisterRequest)
 	if err := c.Bind(req); err != nil {
@@ -41,31 +104,32 @@ func (h *UserHandler) Register(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	if req.InvitationToken != "" {
-		if _, err := h.projectService.ResolveInvitation(req.InvitationToken); err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		if err := h.projectService.CheckInvitationDomain(req.InvitationToken, req.LoginID, req.Email); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 		}
 	}
 
-	user, err := h.userService.RegisterWithPassword(req.LoginID, req.Name, req.Password)
+	user, err := h.userService.RegisterWithPassword(req.LoginID, req.Name, req.Email, req.Password)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
+	response := &RegisterResponse{UserResponse: toUserResponse(user)}
+
 	if req.InvitationToken != "" {
-		if _, err := h.projectService.AcceptInvitation(req.InvitationToken, user.ID); err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		_, err := h.projectService.AcceptInvitation(req.InvitationToken, user.ID)
+		if errors.Is(err, projects.ErrInvitationDomainNotAllowed) && req.Email != "" {
+			// Domain restricted links need a verified email, so the user confirms it and opens the link again
+			err = h.emailChangeService.RequestEmailChange(user.ID, req.Password, req.Email, c.Request().UserAgent(), c.RealIP())
+			response.EmailConfirmationRequired = err == nil
+		}
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 		}
-	}
-
-	response := &UserResponse{
-		ID:              user.ID,
-		LoginID:         user.LoginID,
-		Name:            user.Name,
-		ProfileImageURL: user.ProfileImageURL,
 	}
 
 	return c.JSON(http.StatusCreated, response)
@@ -88,12 +152,159 @@ func (h *UserHandler) Me(c echo.Context) error {
 		ID:              user.ID,
 		LoginID:         user.LoginID,
 		Name:            user.Name,
+		Email:           user.Email,
+		EmailVerified:   user.IsEmailVerified(),
 		ProfileImageURL: user.ProfileImageURL,
 	}
 
 	return c.JSON(http.StatusOK, response)
 }
 
+// UpdateMe updates the current user's name
+func (h *UserHandler) UpdateMe(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	req := new(UpdateProfileRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	user, err := h.userService.UpdateProfile(userID, req.Name)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	response := toUserResponse(user)
+	response.Email = user.Email
+	response.EmailVerified = user.IsEmailVerified()
+	return c.JSON(http.StatusOK, response)
+}
+
+// ChangeMyEmail emails a confirmation link to the new address after checking the current password.
+// The email stays unchanged until the link is confirmed.
+func (h *UserHandler) ChangeMyEmail(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	req := new(ChangeEmailRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	if err := h.emailChangeService.RequestEmailChange(userID, req.CurrentPassword, req.Email, c.Request().UserAgent(), c.RealIP()); err != nil {
+		if throttleErr := loginThrottledError(c, err); throttleErr != nil {
+			return throttleErr
+		}
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "A confirmation link has been sent to the new address"})
+}
+
+// ConfirmEmailChange sets the new email address of a confirmation link
+func (h *UserHandler) ConfirmEmailChange(c echo.Context) error {
+	req := new(ConfirmEmailChangeRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	if _, err := h.emailChangeService.ConfirmEmailChange(req.Token); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Email address confirmed"})
+}
+
+// GetMyPreferences returns the current user's preferences
+func (h *UserHandler) GetMyPreferences(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	preference, err := h.userService.GetPreferences(userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch preferences")
+	}
+
+	// Drop a landing project the user no longer has access to
+	if preference.DefaultProjectID != nil {
+		isMember, err := h.projectService.IsUserProjectMember(*preference.DefaultProjectID, userID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch preferences")
+		}
+		if !isMember {
+			preference.DefaultProjectID = nil
+		}
+	}
+
+	return c.JSON(http.StatusOK, toUserPreferencesResponse(preference))
+}
+
+// UpdateMyPreferences replaces the current user's preferences
+func (h *UserHandler) UpdateMyPreferences(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	req := new(UserPreferencesRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	if req.DefaultProjectID != nil {
+		isMember, err := h.projectService.IsUserProjectMember(*req.DefaultProjectID, userID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to verify project membership")
+		}
+		if !isMember {
+			return echo.NewHTTPError(http.StatusBadRequest, "Default project must be a project you are a member of")
+		}
+	}
+
+	preference, err := h.userService.UpdatePreferences(&users.UserPreference{
+		UserID:           userID,
+		Timezone:         req.Timezone,
+		Locale:           req.Locale,
+		DefaultProjectID: req.DefaultProjectID,
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, toUserPreferencesResponse(preference))
+}
+
+func toUserPreferencesResponse(preference *users.UserPreference) *UserPreferencesResponse {
+	return &UserPreferencesResponse{
+		Timezone:         preference.Timezone,
+		Locale:           preference.Locale,
+		DefaultProjectID: preference.DefaultProjectID,
+	}
+}
+
 func (h *UserHandler) GetUserByID(c echo.Context) error {
 	// Parse user ID from URL parameter
 	userID := 0
@@ -117,8 +328,141 @@ func (h *UserHandler) GetUserByID(c echo.Context) error {
 	return c.JSON(http.StatusOK, response)
 }
 
+// GetTwoFactorStatus returns the current user's two-factor authentication status
+func (h *UserHandler) GetTwoFactorStatus(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	status, err := h.userService.GetTwoFactorStatus(userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch two-factor status")
+	}
+
+	required, err := h.settingService.IsTwoFactorRequired()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch two-factor status")
+	}
+
+	return c.JSON(http.StatusOK, &TwoFactorStatusResponse{
+		Enabled:                status.Enabled,
+		Required:               required,
+		RecoveryCodesRemaining: status.RecoveryCodesRemaining,
+	})
+}
+
+// SetupTwoFactor starts TOTP enrollment for the current user
+func (h *UserHandler) SetupTwoFactor(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	enrollment, err := h.userService.BeginTOTPEnrollment(userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, &TOTPEnrollmentResponse{
+		Secret:          enrollment.Secret,
+		ProvisioningURI: enrollment.ProvisioningURI,
+	})
+}
+
+// EnableTwoFactor confirms TOTP enrollment and returns the recovery codes
+func (h *UserHandler) EnableTwoFactor(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	req := new(TwoFactorCodeRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	codes, err := h.userService.ConfirmTOTPEnrollment(userID, req.Code)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, &RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor removes TOTP from the current user's account
+func (h *UserHandler) DisableTwoFactor(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	req := new(TwoFactorCodeRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	required, err := h.settingService.IsTwoFactorRequired()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch two-factor status")
+	}
+	if required {
+		return echo.NewHTTPError(http.StatusForbidden, "Two-factor authentication is required for all users")
+	}
+
+	if err := h.userService.DisableTOTP(userID, req.Code); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes replaces the current user's recovery codes
+func (h *UserHandler) RegenerateRecoveryCodes(c echo.Context) error {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	req := new(TwoFactorCodeRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	codes, err := h.userService.RegenerateRecoveryCodes(userID, req.Code)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, &RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
 func (h *UserHandler) RegisterRoutes(e *echo.Echo, authMiddleware *AuthMiddleware) {
 	e.POST("/api/users/register", h.Register) // No logging for registration (security)
 	e.GET("/api/users/me", h.Me, authMiddleware.RequireAuth)
+	e.PUT("/api/users/me", h.UpdateMe, authMiddleware.RequireAuth)
+	e.POST("/api/users/me/email", h.ChangeMyEmail, authMiddleware.RequireAuth, authMiddleware.RequireSessionAuth)
+	e.POST("/api/users/email/confirm", h.ConfirmEmailChange)
+	e.GET("/api/users/me/preferences", h.GetMyPreferences, authMiddleware.RequireAuth)
+	e.PUT("/api/users/me/preferences", h.UpdateMyPreferences, authMiddleware.RequireAuth)
+
+	twoFactor := e.Group("/api/users/me/two-factor", authMiddleware.RequireAuth, authMiddleware.RequireSessionAuth)
+	twoFactor.GET("", h.GetTwoFactorStatus)
+	twoFactor.POST("/setup", h.SetupTwoFactor)
+	twoFactor.POST("/enable", h.EnableTwoFactor)
+	twoFactor.POST("/disable", h.DisableTwoFactor)
+	twoFactor.POST("/recovery-codes", h.RegenerateRecoveryCodes)
 	e.GET("/api/users/:id", h.GetUserByID, authMiddleware.RequireAuth)
 }
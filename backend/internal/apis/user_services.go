@@ -1,19 +1,34 @@
 package apis
 
 import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/config"
+	"github.com/dannyswat/pjeasy/internal/mailer"
 	"github.com/dannyswat/pjeasy/internal/user_sessions"
 	"github.com/dannyswat/pjeasy/internal/users"
 )
 
-func (s *APIServer) SetupUserService() {
+func (s *APIServer) SetupUserService() error {
 
 	// Initialize repositories
 	userRepo := users.NewUserRepository(s.globalUOW)
 	credRepo := users.NewUserCredentialRepository(s.globalUOW)
+	preferenceRepo := users.NewUserPreferenceRepository(s.globalUOW)
 	sessionRepo := user_sessions.NewUserSessionRepository(s.globalUOW)
+	patRepo := user_sessions.NewPersonalAccessTokenRepository(s.globalUOW)
+	resetRepo := users.NewPasswordResetTokenRepository(s.globalUOW)
+	emailChangeRepo := users.NewEmailChangeTokenRepository(s.globalUOW)
+	throttleRepo := user_sessions.NewLoginThrottleRepository(s.globalUOW)
+	eventRepo := user_sessions.NewSecurityEventRepository(s.globalUOW)
 
-	// Initialize credential provider
+	// Initialize credential providers
 	passwordProvider := &users.PasswordCredential{}
+	oidcProvider := &users.OIDCCredential{}
+	totpProvider := &users.TOTPCredential{}
+	recoveryProvider := &users.RecoveryCodeCredential{}
 
 	// Initialize token service from config
 	s.tokenService = user_sessions.NewTokenService(
@@ -21,8 +36,148 @@ func (s *APIServer) SetupUserService() {
 		s.config.Auth.GetAccessTokenDuration(),
 		s.config.Auth.GetRefreshTokenDuration(),
 	)
+	keySet, err := newKeySet(&s.config.Auth.Signing)
+	if err != nil {
+		return fmt.Errorf("load signing keys: %w", err)
+	}
+	if keySet != nil {
+		s.tokenService.SetKeySet(keySet)
+	}
 
 	// Initialize services
-	s.userService = users.NewUserService(s.uowFactory, userRepo, credRepo, passwordProvider)
-	s.sessionService = user_sessions.NewSessionService(s.userService, sessionRepo, s.tokenService)
+	s.userService = users.NewUserService(s.uowFactory, userRepo, credRepo, preferenceRepo, passwordProvider, oidcProvider, totpProvider, recoveryProvider)
+	s.userService.SetPasswordPolicy(newPasswordPolicy(&s.config.Auth.PasswordPolicy))
+	s.securityEventService = user_sessions.NewSecurityEventService(eventRepo)
+	throttleService := user_sessions.NewLoginThrottleService(s.uowFactory, throttleRepo, newLoginThrottlePolicy(&s.config.Auth.LoginThrottle))
+	throttleService.StartPurging(s.config.Auth.LoginThrottle.GetPurgeInterval())
+	sessionDenylist := user_sessions.NewSessionDenylist(s.tokenService.GetAccessTokenDuration())
+	sessionDenylist.SetBus(s.cacheBus)
+	s.sessionService = user_sessions.NewSessionService(s.userService, sessionRepo, s.tokenService, throttleService, s.securityEventService, sessionDenylist)
+	sessionDenylist.SetRestore(s.sessionService.RestoreDenylist)
+	if err := s.sessionService.RestoreDenylist(); err != nil {
+		log.Printf("failed to restore revoked sessions: %v", err)
+	}
+	s.patService = user_sessions.NewPersonalAccessTokenService(patRepo, s.userService, s.tokenService)
+	accountMailer := newMailer(&s.config.Mail)
+	s.passwordResetService = users.NewPasswordResetService(
+		s.uowFactory,
+		resetRepo,
+		s.userService,
+		accountMailer,
+		s.config.Auth.PasswordResetURL,
+		s.config.Auth.GetPasswordResetTokenDuration(),
+	)
+	s.emailChangeService = users.NewEmailChangeService(
+		s.uowFactory,
+		emailChangeRepo,
+		s.userService,
+		accountMailer,
+		s.config.Auth.EmailChangeURL,
+		s.config.Auth.GetEmailChangeTokenDuration(),
+	)
+	s.emailChangeService.SetPasswordVerifier(s.sessionService)
+
+	// Initialize OIDC client when external login is configured
+	if s.config.Auth.OIDC.Enabled {
+		s.oidcClient = users.NewOIDCClient(users.OIDCClientConfig{
+			IssuerURL:    s.config.Auth.OIDC.IssuerURL,
+			ClientID:     s.config.Auth.OIDC.ClientID,
+			ClientSecret: s.config.Auth.OIDC.ClientSecret,
+			RedirectURL:  s.config.Auth.OIDC.RedirectURL,
+			Scopes:       s.config.Auth.OIDC.Scopes,
+		})
+	}
+
+	return nil
+}
+
+// newMailer creates the mail sender selected in config
+func newMailer(cfg *config.MailConfig) mailer.Mailer {
+	switch cfg.Provider {
+	case "smtp":
+		return mailer.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From)
+	case "file":
+		return mailer.NewFileMailer(cfg.FileDir, cfg.From)
+	default:
+		return mailer.NewFileMailer("", cfg.From)
+	}
+}
+
+// newPasswordPolicy converts the configured password policy
+func newPasswordPolicy(cfg *config.PasswordPolicyConfig) *users.PasswordPolicy {
+	minLength := cfg.MinLength
+	if minLength < 1 {
+		minLength = 1
+	}
+	return &users.PasswordPolicy{
+		MinLength:             minLength,
+		RequireUppercase:      cfg.RequireUppercase,
+		RequireLowercase:      cfg.RequireLowercase,
+		RequireDigit:          cfg.RequireDigit,
+		RequireSymbol:         cfg.RequireSymbol,
+		RejectCommonPasswords: cfg.RejectCommonPasswords,
+		HistoryCount:          cfg.HistoryCount,
+		MaxAge:                cfg.GetMaxAge(),
+	}
+}
+
+// newLoginThrottlePolicy converts the configured login throttling
+func newLoginThrottlePolicy(cfg *config.LoginThrottleConfig) *user_sessions.LoginThrottlePolicy {
+	return &user_sessions.LoginThrottlePolicy{
+		FreeAttempts:       cfg.FreeAttempts,
+		BaseDelay:          cfg.GetBaseDelay(),
+		MaxDelay:           cfg.GetMaxDelay(),
+		LockoutThreshold:   cfg.LockoutThreshold,
+		IPLockoutThreshold: cfg.IPLockoutThreshold,
+		LockoutDuration:    cfg.GetLockoutDuration(),
+		ResetAfter:         cfg.GetResetAfter(),
+	}
+}
+
+// newKeySet loads the asymmetric signing keys, or returns nil when tokens are signed with HS256
+func newKeySet(cfg *config.SigningConfig) (*user_sessions.KeySet, error) {
+	switch cfg.Algorithm {
+	case "", user_sessions.SigningAlgorithmHS256:
+		return nil, nil
+	case user_sessions.SigningAlgorithmRS256, user_sessions.SigningAlgorithmEdDSA:
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", cfg.Algorithm)
+	}
+
+	privateKey, err := user_sessions.LoadOrGenerateSigningKey(cfg.KeyFile, cfg.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	activeKey, err := user_sessions.NewSigningKey(cfg.KeyID, privateKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	if activeKey.Algorithm != cfg.Algorithm {
+		return nil, fmt.Errorf("key %s is not a %s key", cfg.KeyFile, cfg.Algorithm)
+	}
+
+	keys := []*user_sessions.SigningKey{activeKey}
+	gracePeriod := cfg.GetGracePeriod()
+	for _, retired := range cfg.RetiredKeys {
+		retiredAt, err := time.Parse(time.RFC3339, retired.RetiredAt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retiredAt for %s: %w", retired.KeyFile, err)
+		}
+		// Keys past their grace period are no longer needed
+		if time.Since(retiredAt) > gracePeriod {
+			continue
+		}
+
+		privateKey, err := user_sessions.LoadSigningKeyPEM(retired.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		key, err := user_sessions.NewSigningKey(retired.KeyID, privateKey, &retiredAt)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return user_sessions.NewKeySet(keys, gracePeriod)
 }
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/dannyswat/pjeasy/internal/projects"
 	"github.com/dannyswat/pjeasy/internal/wiki_pages"
 	"github.com/labstack/echo/v4"
 )
@@ -185,12 +186,12 @@ func (h *WikiPageHandler) CreateWikiPage(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	page, err := h.wikiPageService.CreateWikiPage(projectID, req.Title, req.Content, req.ParentID, req.SortOrder, req.Protected, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusCreated, toWikiPageResponse(page))
@@ -210,7 +211,7 @@ func (h *WikiPageHandler) GetWikiPage(c echo.Context) error {
 
 	page, err := h.wikiPageService.GetWikiPage(pageID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 	if page == nil {
 		return echo.NewHTTPError(http.StatusNotFound, "Wiki page not found")
@@ -238,7 +239,7 @@ func (h *WikiPageHandler) GetWikiPageBySlug(c echo.Context) error {
 
 	page, err := h.wikiPageService.GetWikiPageBySlug(projectID, slug, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 	if page == nil {
 		return echo.NewHTTPError(http.StatusNotFound, "Wiki page not found")
@@ -273,7 +274,7 @@ func (h *WikiPageHandler) ListWikiPages(c echo.Context) error {
 
 	pages, total, err := h.wikiPageService.ListWikiPages(projectID, page, pageSize, status, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	response := WikiPageListResponse{
@@ -304,7 +305,7 @@ func (h *WikiPageHandler) GetWikiPageTree(c echo.Context) error {
 
 	pages, err := h.wikiPageService.GetWikiPageTree(projectID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	response := WikiPageTreeResponse{
@@ -336,12 +337,12 @@ func (h *WikiPageHandler) UpdateWikiPage(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	page, err := h.wikiPageService.UpdateWikiPage(pageID, req.Title, req.ParentID, req.SortOrder, req.Protected, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toWikiPageResponse(page))
@@ -365,12 +366,12 @@ func (h *WikiPageHandler) UpdateWikiPageContent(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	page, err := h.wikiPageService.UpdateWikiPageContent(pageID, req.Content, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toWikiPageResponse(page))
@@ -394,12 +395,12 @@ func (h *WikiPageHandler) UpdateWikiPageStatus(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	page, err := h.wikiPageService.UpdateWikiPageStatus(pageID, req.Status, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toWikiPageResponse(page))
@@ -418,7 +419,7 @@ func (h *WikiPageHandler) DeleteWikiPage(c echo.Context) error {
 	}
 
 	if err := h.wikiPageService.DeleteWikiPage(pageID, userID); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.NoContent(http.StatusNoContent)
@@ -442,12 +443,12 @@ func (h *WikiPageHandler) CreateWikiPageChange(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	change, err := h.wikiPageService.CreateWikiPageChange(pageID, req.ItemType, req.ItemID, req.Content, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusCreated, toWikiPageChangeResponse(change))
@@ -467,7 +468,7 @@ func (h *WikiPageHandler) GetWikiPageChange(c echo.Context) error {
 
 	change, err := h.wikiPageService.GetWikiPageChange(changeID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 	if change == nil {
 		return echo.NewHTTPError(http.StatusNotFound, "Change not found")
@@ -494,12 +495,12 @@ func (h *WikiPageHandler) UpdateWikiPageChange(c echo.Context) error {
 	}
 
 	if err := c.Validate(&req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	change, err := h.wikiPageService.UpdateWikiPageChange(changeID, req.Content, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toWikiPageChangeResponse(change))
@@ -529,7 +530,7 @@ func (h *WikiPageHandler) ListWikiPageChanges(c echo.Context) error {
 
 	changes, total, err := h.wikiPageService.ListWikiPageChanges(pageID, page, pageSize, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	response := WikiPageChangesListResponse{
@@ -560,7 +561,7 @@ func (h *WikiPageHandler) GetPendingChanges(c echo.Context) error {
 
 	changes, err := h.wikiPageService.GetPendingChanges(pageID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	response := make([]WikiPageChangeResponse, len(changes))
@@ -592,7 +593,7 @@ func (h *WikiPageHandler) GetChangesByItem(c echo.Context) error {
 
 	changes, err := h.wikiPageService.GetChangesByItem(itemType, itemID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	response := make([]WikiPageChangeResponse, len(changes))
@@ -616,11 +617,11 @@ func (h *WikiPageHandler) MergeChanges(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	if err := h.wikiPageService.MergeChangesOnCompletion(req.ItemType, req.ItemID, userID); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{"message": "Changes merged successfully"})
@@ -644,12 +645,12 @@ func (h *WikiPageHandler) ResolveConflict(c echo.Context) error {
 	}
 
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	change, err := h.wikiPageService.ResolveConflict(changeID, req.Content, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toWikiPageChangeResponse(change))
@@ -669,7 +670,7 @@ func (h *WikiPageHandler) RejectChange(c echo.Context) error {
 
 	change, err := h.wikiPageService.RejectChange(changeID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, toWikiPageChangeResponse(change))
@@ -688,7 +689,7 @@ func (h *WikiPageHandler) DeleteWikiPageChange(c echo.Context) error {
 	}
 
 	if err := h.wikiPageService.DeleteWikiPageChange(changeID, userID); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.NoContent(http.StatusNoContent)
@@ -708,7 +709,7 @@ func (h *WikiPageHandler) PreviewMerge(c echo.Context) error {
 
 	content, err := h.wikiPageService.PreviewMerge(changeID, userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 
 	return c.JSON(http.StatusOK, PreviewMergeResponse{Content: content})
@@ -718,7 +719,7 @@ func (h *WikiPageHandler) PreviewMerge(c echo.Context) error {
 func (h *WikiPageHandler) RegisterRoutes(e *echo.Echo, authMiddleware *AuthMiddleware, projectMiddleware *ProjectMiddleware) {
 	// Wiki page routes
 	projectWiki := e.Group("/api/projects/:projectId/wiki", authMiddleware.RequireAuth, projectMiddleware.RequireProjectMember)
-	projectWiki.POST("", h.CreateWikiPage)
+	projectWiki.POST("", h.CreateWikiPage, projectMiddleware.RequirePermission(projects.PermissionItemWikiPage, projects.PermissionActionCreate))
 	projectWiki.GET("", h.ListWikiPages)
 	projectWiki.GET("/tree", h.GetWikiPageTree)
 	projectWiki.GET("/slug/:slug", h.GetWikiPageBySlug)
@@ -0,0 +1,28 @@
+package cachebus
+
+import "time"
+
+// Topics of the in-process caches kept in sync across API replicas
+const (
+	TopicProject = "project" // Project member cache entry, keyed by project ID
+	TopicGroup   = "group"   // Project member cache entries of a user group, keyed by group ID
+	TopicSession = "session" // Revoked session, keyed by session ID
+)
+
+// Invalidation tells the other replicas to evict a cache entry. An empty key means
+// invalidations may have been missed and the whole cache of the topic should be evicted.
+type Invalidation struct {
+	Topic string     `json:"topic"`
+	Key   string     `json:"key"`
+	Until *time.Time `json:"until,omitempty"` // Set for entries that expire, such as revoked sessions
+}
+
+// Handler applies an invalidation received from another replica
+type Handler func(invalidation Invalidation)
+
+// Bus carries cache invalidations between API replicas. Publishers evict their own cache
+// themselves; handlers only receive invalidations published by other replicas.
+type Bus interface {
+	Publish(invalidation Invalidation) error
+	Subscribe(topic string, handler Handler)
+}
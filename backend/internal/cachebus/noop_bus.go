@@ -0,0 +1,17 @@
+package cachebus
+
+// NoopBus is the bus of a single API instance, which has no other replicas to notify
+type NoopBus struct{}
+
+// NewNoopBus creates a bus that drops every invalidation
+func NewNoopBus() *NoopBus {
+	return &NoopBus{}
+}
+
+// Publish does nothing
+func (b *NoopBus) Publish(invalidation Invalidation) error {
+	return nil
+}
+
+// Subscribe does nothing since no invalidations are ever received
+func (b *NoopBus) Subscribe(topic string, handler Handler) {}
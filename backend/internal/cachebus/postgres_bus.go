@@ -0,0 +1,135 @@
+package cachebus
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+// reconnectDelay is how long the listener waits before reconnecting after losing its connection
+const reconnectDelay = 5 * time.Second
+
+// PostgresBus carries invalidations between replicas sharing a database with LISTEN/NOTIFY
+type PostgresBus struct {
+	db         *gorm.DB
+	connString string
+	channel    string
+	origin     string // Identifies this replica so it skips its own notifications
+	handlers   map[string][]Handler
+	mu         sync.RWMutex
+}
+
+// notification is the NOTIFY payload
+type notification struct {
+	Origin string `json:"origin"`
+	Invalidation
+}
+
+// NewPostgresBus creates a bus publishing through db and listening on a dedicated connection
+func NewPostgresBus(db *gorm.DB, connString, channel string) *PostgresBus {
+	return &PostgresBus{
+		db:         db,
+		connString: connString,
+		channel:    channel,
+		origin:     uuid.NewString(),
+		handlers:   make(map[string][]Handler),
+	}
+}
+
+// Publish notifies the other replicas listening on the channel
+func (b *PostgresBus) Publish(invalidation Invalidation) error {
+	payload, err := json.Marshal(notification{Origin: b.origin, Invalidation: invalidation})
+	if err != nil {
+		return err
+	}
+	return b.db.Exec("SELECT pg_notify(?, ?)", b.channel, string(payload)).Error
+}
+
+// Subscribe registers a handler for the invalidations of a topic published by other replicas
+func (b *PostgresBus) Subscribe(topic string, handler Handler) {
+	b.mu.Lock()
+	b.handlers[topic] = append(b.handlers[topic], handler)
+	b.mu.Unlock()
+}
+
+// Start listens for invalidations in the background, reconnecting when the connection drops
+func (b *PostgresBus) Start() {
+	go func() {
+		reconnecting := false
+		for {
+			err := b.listen(reconnecting)
+			log.Printf("cache invalidation listener disconnected: %v", err)
+			reconnecting = true
+			time.Sleep(reconnectDelay)
+		}
+	}()
+}
+
+func (b *PostgresBus) listen(reconnecting bool) error {
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, b.connString)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{b.channel}.Sanitize()); err != nil {
+		return err
+	}
+
+	// Invalidations published while disconnected were missed
+	if reconnecting {
+		b.resetAll()
+	}
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		b.receive(n.Payload)
+	}
+}
+
+// receive dispatches a NOTIFY payload to the handlers of its topic
+func (b *PostgresBus) receive(payload string) {
+	var n notification
+	if err := json.Unmarshal([]byte(payload), &n); err != nil {
+		log.Printf("invalid cache invalidation payload: %v", err)
+		return
+	}
+	if n.Origin == b.origin {
+		return
+	}
+	b.dispatch(n.Invalidation)
+}
+
+// resetAll tells every handler to evict its whole cache
+func (b *PostgresBus) resetAll() {
+	b.mu.RLock()
+	topics := make([]string, 0, len(b.handlers))
+	for topic := range b.handlers {
+		topics = append(topics, topic)
+	}
+	b.mu.RUnlock()
+
+	for _, topic := range topics {
+		b.dispatch(Invalidation{Topic: topic})
+	}
+}
+
+func (b *PostgresBus) dispatch(invalidation Invalidation) {
+	b.mu.RLock()
+	handlers := b.handlers[invalidation.Topic]
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(invalidation)
+	}
+}
@@ -0,0 +1,36 @@
+package cachebus
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPostgresBusReceive(t *testing.T) {
+	bus := NewPostgresBus(nil, "", "cache")
+	var received []Invalidation
+	bus.Subscribe(TopicProject, func(invalidation Invalidation) {
+		received = append(received, invalidation)
+	})
+
+	payload := func(origin, topic, key string) string {
+		data, err := json.Marshal(notification{Origin: origin, Invalidation: Invalidation{Topic: topic, Key: key}})
+		if err != nil {
+			t.Fatalf("json.Marshal() error = %v", err)
+		}
+		return string(data)
+	}
+
+	bus.receive(payload("other-replica", TopicProject, "7"))
+	bus.receive(payload(bus.origin, TopicProject, "8"))
+	bus.receive(payload("other-replica", TopicGroup, "9"))
+	bus.receive("not json")
+
+	if len(received) != 1 || received[0].Key != "7" {
+		t.Fatalf("received = %+v, want only the project 7 invalidation from the other replica", received)
+	}
+
+	bus.resetAll()
+	if len(received) != 2 || received[1].Key != "" {
+		t.Fatalf("received = %+v, want a reset invalidation after reconnecting", received)
+	}
+}
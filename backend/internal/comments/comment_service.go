@@ -20,6 +20,7 @@ type CommentService struct {
 	commentRepo *CommentRepository
 	userRepo    *users.UserRepository
 	memberRepo  *projects.ProjectMemberRepository
+	projectRepo *projects.ProjectRepository
 	ideaRepo    *ideas.IdeaRepository
 	issueRepo   *issues.IssueRepository
 	featureRepo *features.FeatureRepository
@@ -28,11 +29,12 @@ type CommentService struct {
 	wikiRepo    *wiki_pages.WikiPageRepository
 }
 
-func NewCommentService(commentRepo *CommentRepository, userRepo *users.UserRepository, memberRepo *projects.ProjectMemberRepository, ideaRepo *ideas.IdeaRepository, issueRepo *issues.IssueRepository, featureRepo *features.FeatureRepository, taskRepo *tasks.TaskRepository, ticketRepo *service_tickets.ServiceTicketRepository, wikiRepo *wiki_pages.WikiPageRepository) *CommentService {
+func NewCommentService(commentRepo *CommentRepository, userRepo *users.UserRepository, memberRepo *projects.ProjectMemberRepository, projectRepo *projects.ProjectRepository, ideaRepo *ideas.IdeaRepository, issueRepo *issues.IssueRepository, featureRepo *features.FeatureRepository, taskRepo *tasks.TaskRepository, ticketRepo *service_tickets.ServiceTicketRepository, wikiRepo *wiki_pages.WikiPageRepository) *CommentService {
 	return &CommentService{
 		commentRepo: commentRepo,
 		userRepo:    userRepo,
 		memberRepo:  memberRepo,
+		projectRepo: projectRepo,
 		ideaRepo:    ideaRepo,
 		issueRepo:   issueRepo,
 		featureRepo: featureRepo,
@@ -67,12 +69,17 @@ func (s *CommentService) CreateComment(itemID int, itemType string, content stri
 		return nil, err
 	}
 
-	isMember, err := s.memberRepo.IsUserMember(projectID, userID)
+	if err := s.projectRepo.EnsureWritable(projectID); err != nil {
+		return nil, err
+	}
+
+	permissionItemType := commentPermissionItemType(itemType)
+	allowed, err := s.memberRepo.HasPermission(projectID, userID, permissionItemType, projects.PermissionActionComment)
 	if err != nil {
 		return nil, err
 	}
-	if !isMember {
-		return nil, errors.New("user is not a member of this project")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(permissionItemType, projects.PermissionActionComment)
 	}
 
 	now := time.Now()
@@ -142,6 +149,10 @@ func (s *CommentService) UpdateComment(commentID int, content string, userID int
 		return nil, err
 	}
 
+	if err := s.projectRepo.EnsureWritable(projectID); err != nil {
+		return nil, err
+	}
+
 	isMember, err := s.memberRepo.IsUserMember(projectID, userID)
 	if err != nil {
 		return nil, err
@@ -188,6 +199,10 @@ func (s *CommentService) DeleteComment(commentID int, userID int) error {
 		return err
 	}
 
+	if err := s.projectRepo.EnsureWritable(projectID); err != nil {
+		return err
+	}
+
 	isMember, err := s.memberRepo.IsUserMember(projectID, userID)
 	if err != nil {
 		return err
@@ -327,6 +342,24 @@ func normalizeCommentItemType(itemType string) string {
 	}
 }
 
+// commentPermissionItemType maps a comment item type to the item type used by project role permissions
+func commentPermissionItemType(itemType string) string {
+	switch normalizeCommentItemType(itemType) {
+	case "ideas":
+		return projects.PermissionItemIdea
+	case "issues":
+		return projects.PermissionItemIssue
+	case "features":
+		return projects.PermissionItemFeature
+	case "tasks":
+		return projects.PermissionItemTask
+	case "service-tickets":
+		return projects.PermissionItemServiceTicket
+	default:
+		return projects.PermissionItemWikiPage
+	}
+}
+
 // GetCommentsByItemWithPagination retrieves comments for an item with pagination
 func (s *CommentService) GetCommentsByItemWithPagination(itemID int, itemType string, page, pageSize int) ([]Comment, int64, error) {
 	offset := (page - 1) * pageSize
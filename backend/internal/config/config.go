@@ -13,6 +13,9 @@ type Config struct {
 	Server      ServerConfig   `json:"server"`
 	Database    DatabaseConfig `json:"database"`
 	Auth        AuthConfig     `json:"auth"`
+	Mail        MailConfig     `json:"mail"`
+	Trash       TrashConfig    `json:"trash"`
+	CacheBus    CacheBusConfig `json:"cacheBus"`
 	AutoMigrate bool           `json:"autoMigrate"`
 }
 
@@ -32,9 +35,153 @@ type DatabaseConfig struct {
 }
 
 type AuthConfig struct {
-	JWTSecret            string `json:"jwtSecret"`
-	AccessTokenDuration  string `json:"accessTokenDuration"`
-	RefreshTokenDuration string `json:"refreshTokenDuration"`
+	JWTSecret                  string               `json:"jwtSecret"`
+	AccessTokenDuration        string               `json:"accessTokenDuration"`
+	RefreshTokenDuration       string               `json:"refreshTokenDuration"`
+	PasswordResetTokenDuration string               `json:"passwordResetTokenDuration"`
+	PasswordResetURL           string               `json:"passwordResetUrl"` // Frontend page that accepts ?token=
+	EmailChangeTokenDuration   string               `json:"emailChangeTokenDuration"`
+	EmailChangeURL             string               `json:"emailChangeUrl"` // Frontend page that confirms ?token=
+	PasswordPolicy             PasswordPolicyConfig `json:"passwordPolicy"`
+	LoginThrottle              LoginThrottleConfig  `json:"loginThrottle"`
+	Signing                    SigningConfig        `json:"signing"`
+	OIDC                       OIDCConfig           `json:"oidc"`
+}
+
+// SigningConfig selects how access tokens are signed
+type SigningConfig struct {
+	Algorithm   string             `json:"algorithm"`   // HS256 (uses jwtSecret), RS256 or EdDSA
+	KeyFile     string             `json:"keyFile"`     // Active private key PEM, generated on first start when missing
+	KeyID       string             `json:"keyId"`       // Defaults to the RFC 7638 thumbprint of the key
+	RetiredKeys []RetiredKeyConfig `json:"retiredKeys"` // Previous keys still accepted during the grace period
+	GracePeriod string             `json:"gracePeriod"` // How long retired keys verify tokens after retiredAt
+}
+
+// RetiredKeyConfig is a previous signing key kept for verification
+type RetiredKeyConfig struct {
+	KeyFile   string `json:"keyFile"`
+	KeyID     string `json:"keyId"`
+	RetiredAt string `json:"retiredAt"` // RFC 3339 time the key stopped signing
+}
+
+// GetGracePeriod returns how long retired keys stay valid for verification
+func (c *SigningConfig) GetGracePeriod() time.Duration {
+	return parseDurationOrDefault(c.GracePeriod, 24*time.Hour)
+}
+
+// LoginThrottleConfig configures backoff and lockout after failed logins
+type LoginThrottleConfig struct {
+	FreeAttempts       int    `json:"freeAttempts"`       // Failures allowed before backoff starts
+	BaseDelay          string `json:"baseDelay"`          // First backoff delay, doubled per further failure
+	MaxDelay           string `json:"maxDelay"`           // Upper bound for the backoff delay
+	LockoutThreshold   int    `json:"lockoutThreshold"`   // Failures per login ID before lockout, 0 disables
+	IPLockoutThreshold int    `json:"ipLockoutThreshold"` // Failures per IP address before lockout, 0 disables
+	LockoutDuration    string `json:"lockoutDuration"`
+	ResetAfter         string `json:"resetAfter"`    // Failures older than this are forgotten
+	PurgeInterval      string `json:"purgeInterval"` // How often forgotten failures are deleted
+}
+
+// GetBaseDelay returns the first backoff delay
+func (c *LoginThrottleConfig) GetBaseDelay() time.Duration {
+	return parseDurationOrDefault(c.BaseDelay, time.Second)
+}
+
+// GetMaxDelay returns the upper bound for the backoff delay
+func (c *LoginThrottleConfig) GetMaxDelay() time.Duration {
+	return parseDurationOrDefault(c.MaxDelay, 5*time.Minute)
+}
+
+// GetLockoutDuration returns how long a lockout lasts
+func (c *LoginThrottleConfig) GetLockoutDuration() time.Duration {
+	return parseDurationOrDefault(c.LockoutDuration, 15*time.Minute)
+}
+
+// GetResetAfter returns how long failures are remembered
+func (c *LoginThrottleConfig) GetResetAfter() time.Duration {
+	return parseDurationOrDefault(c.ResetAfter, time.Hour)
+}
+
+// GetPurgeInterval returns how often forgotten failures are deleted
+func (c *LoginThrottleConfig) GetPurgeInterval() time.Duration {
+	return parseDurationOrDefault(c.PurgeInterval, time.Hour)
+}
+
+func parseDurationOrDefault(value string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
+}
+
+// PasswordPolicyConfig configures the rules applied when a password is set
+type PasswordPolicyConfig struct {
+	MinLength             int    `json:"minLength"`
+	RequireUppercase      bool   `json:"requireUppercase"`
+	RequireLowercase      bool   `json:"requireLowercase"`
+	RequireDigit          bool   `json:"requireDigit"`
+	RequireSymbol         bool   `json:"requireSymbol"`
+	RejectCommonPasswords bool   `json:"rejectCommonPasswords"` // Check against the bundled common password list
+	HistoryCount          int    `json:"historyCount"`          // Previous passwords that cannot be reused
+	MaxAge                string `json:"maxAge"`                // Password expiry such as "2160h", empty for never
+}
+
+// GetMaxAge returns the password expiry duration, or zero when passwords never expire
+func (c *PasswordPolicyConfig) GetMaxAge() time.Duration {
+	if c.MaxAge == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(c.MaxAge)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
+
+// MailConfig selects how outgoing email is delivered
+type MailConfig struct {
+	Provider     string `json:"provider"` // smtp, file, log
+	From         string `json:"from"`
+	SMTPHost     string `json:"smtpHost"`
+	SMTPPort     int    `json:"smtpPort"`
+	SMTPUsername string `json:"smtpUsername"`
+	SMTPPassword string `json:"smtpPassword"`
+	FileDir      string `json:"fileDir"` // Used by the file provider
+}
+
+// TrashConfig configures how long deleted projects and items are kept before they are purged
+type TrashConfig struct {
+	RetentionPeriod string `json:"retentionPeriod"` // Time in the trash before purging, such as "720h"
+	PurgeInterval   string `json:"purgeInterval"`   // How often expired entries are purged
+}
+
+// GetRetentionPeriod returns how long deleted entries stay in the trash
+func (c *TrashConfig) GetRetentionPeriod() time.Duration {
+	return parseDurationOrDefault(c.RetentionPeriod, 30*24*time.Hour)
+}
+
+// GetPurgeInterval returns how often expired entries are purged
+func (c *TrashConfig) GetPurgeInterval() time.Duration {
+	return parseDurationOrDefault(c.PurgeInterval, time.Hour)
+}
+
+// CacheBusConfig selects how cache invalidations reach the other API replicas
+type CacheBusConfig struct {
+	Provider string `json:"provider"` // postgres when running several replicas, noop for a single instance
+	Channel  string `json:"channel"`  // Postgres notification channel
+}
+
+// OIDCConfig configures OpenID Connect login through an external identity provider
+type OIDCConfig struct {
+	Enabled           bool     `json:"enabled"`
+	IssuerURL         string   `json:"issuerUrl"`
+	ClientID          string   `json:"clientId"`
+	ClientSecret      string   `json:"clientSecret"`
+	RedirectURL       string   `json:"redirectUrl"`
+	Scopes            []string `json:"scopes"`
+	LoginIDClaim      string   `json:"loginIdClaim"`      // preferred_username, email or sub
+	AutoCreateUsers   bool     `json:"autoCreateUsers"`   // Create users on first login
+	PostLoginRedirect string   `json:"postLoginRedirect"` // Frontend path after a successful login
 }
 
 func (c *AuthConfig) GetAccessTokenDuration() time.Duration {
@@ -53,6 +200,22 @@ func (c *AuthConfig) GetRefreshTokenDuration() time.Duration {
 	return d
 }
 
+func (c *AuthConfig) GetPasswordResetTokenDuration() time.Duration {
+	d, err := time.ParseDuration(c.PasswordResetTokenDuration)
+	if err != nil {
+		return time.Hour
+	}
+	return d
+}
+
+func (c *AuthConfig) GetEmailChangeTokenDuration() time.Duration {
+	d, err := time.ParseDuration(c.EmailChangeTokenDuration)
+	if err != nil {
+		return 24 * time.Hour
+	}
+	return d
+}
+
 func (dc *DatabaseConfig) ToRepositoryConfig() *repositories.DatabaseConfig {
 	return &repositories.DatabaseConfig{
 		Host:     dc.Host,
@@ -81,9 +244,58 @@ func DefaultConfig() *Config {
 			SSLMode:  "disable",
 		},
 		Auth: AuthConfig{
-			JWTSecret:            "your-secret-key-change-this-in-production",
-			AccessTokenDuration:  "15m",
-			RefreshTokenDuration: "720h",
+			JWTSecret:                  "your-secret-key-change-this-in-production",
+			AccessTokenDuration:        "15m",
+			RefreshTokenDuration:       "720h",
+			PasswordResetTokenDuration: "1h",
+			PasswordResetURL:           "http://localhost:8080/reset-password",
+			EmailChangeTokenDuration:   "24h",
+			EmailChangeURL:             "http://localhost:8080/confirm-email",
+			PasswordPolicy: PasswordPolicyConfig{
+				MinLength:             8,
+				RequireUppercase:      true,
+				RequireLowercase:      true,
+				RequireDigit:          true,
+				RequireSymbol:         true,
+				RejectCommonPasswords: true,
+				HistoryCount:          0,
+				MaxAge:                "",
+			},
+			LoginThrottle: LoginThrottleConfig{
+				FreeAttempts:       3,
+				BaseDelay:          "1s",
+				MaxDelay:           "5m",
+				LockoutThreshold:   10,
+				IPLockoutThreshold: 50,
+				LockoutDuration:    "15m",
+				ResetAfter:         "1h",
+				PurgeInterval:      "1h",
+			},
+			Signing: SigningConfig{
+				Algorithm:   "HS256",
+				KeyFile:     "keys/jwt-signing.pem",
+				GracePeriod: "24h",
+			},
+			OIDC: OIDCConfig{
+				Enabled:           false,
+				Scopes:            []string{"openid", "profile", "email"},
+				LoginIDClaim:      "preferred_username",
+				AutoCreateUsers:   true,
+				PostLoginRedirect: "/",
+			},
+		},
+		Mail: MailConfig{
+			Provider: "log",
+			From:     "PJEasy <noreply@localhost>",
+			SMTPPort: 587,
+		},
+		Trash: TrashConfig{
+			RetentionPeriod: "720h",
+			PurgeInterval:   "1h",
+		},
+		CacheBus: CacheBusConfig{
+			Provider: "noop",
+			Channel:  "pjeasy_cache_invalidation",
 		},
 		AutoMigrate: true,
 	}
@@ -0,0 +1,329 @@
+package custom_fields
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/projects"
+)
+
+// Custom field types
+const (
+	FieldTypeText         = "text"
+	FieldTypeNumber       = "number"
+	FieldTypeDate         = "date"
+	FieldTypeSingleSelect = "single-select"
+	FieldTypeMultiSelect  = "multi-select"
+	FieldTypeUser         = "user"
+)
+
+// MaxTextLength limits the length of text values
+const MaxTextLength = 1000
+
+var fieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// OptionList is the list of choices of a select field, stored as JSON
+type OptionList []string
+
+func (o OptionList) Value() (driver.Value, error) {
+	if o == nil {
+		return "[]", nil
+	}
+
+	data, err := json.Marshal([]string(o))
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+func (o *OptionList) Scan(value interface{}) error {
+	if value == nil {
+		*o = OptionList{}
+		return nil
+	}
+
+	var raw []byte
+	switch typed := value.(type) {
+	case []byte:
+		raw = typed
+	case string:
+		raw = []byte(typed)
+	default:
+		return fmt.Errorf("unsupported OptionList value type %T", value)
+	}
+
+	if len(raw) == 0 {
+		*o = OptionList{}
+		return nil
+	}
+
+	var options []string
+	if err := json.Unmarshal(raw, &options); err != nil {
+		return err
+	}
+
+	*o = OptionList(options)
+	return nil
+}
+
+// CustomField is a field a project adds to the items of one item type
+type CustomField struct {
+	ID        int        `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProjectID int        `gorm:"not null;index:idx_custom_field_key,unique" json:"projectId"`
+	ItemType  string     `gorm:"not null;size:50;index:idx_custom_field_key,unique" json:"itemType"` // idea, issue, feature, task or service-ticket
+	Key       string     `gorm:"not null;size:50;index:idx_custom_field_key,unique" json:"key"`      // Name of the field in item requests, responses and filters
+	Name      string     `gorm:"not null;size:100" json:"name"`
+	FieldType string     `gorm:"not null;size:30" json:"fieldType"`
+	Options   OptionList `gorm:"type:text" json:"options"` // Choices of select fields
+	Required  bool       `gorm:"default:false" json:"required"`
+	SortOrder int        `gorm:"default:0" json:"sortOrder"`
+	CreatedBy int        `gorm:"not null" json:"createdBy"`
+	CreatedAt time.Time  `gorm:"not null" json:"createdAt"`
+	UpdatedAt time.Time  `gorm:"not null" json:"updatedAt"`
+}
+
+// TableName specifies the table name for GORM
+func (CustomField) TableName() string {
+	return "custom_fields"
+}
+
+// Values holds the custom field values of an item by field key. Text, date and single-select values
+// are strings, numbers are float64, multi-select values are string lists and users are user IDs.
+type Values map[string]interface{}
+
+// IsValidItemType checks if custom fields can be added to the item type
+func IsValidItemType(itemType string) bool {
+	switch itemType {
+	case projects.PermissionItemIdea, projects.PermissionItemIssue, projects.PermissionItemFeature,
+		projects.PermissionItemTask, projects.PermissionItemServiceTicket:
+		return true
+	}
+	return false
+}
+
+// IsValidFieldType checks if the field type is supported
+func IsValidFieldType(fieldType string) bool {
+	switch fieldType {
+	case FieldTypeText, FieldTypeNumber, FieldTypeDate, FieldTypeSingleSelect, FieldTypeMultiSelect, FieldTypeUser:
+		return true
+	}
+	return false
+}
+
+// IsSelect checks if the field chooses from options
+func (f *CustomField) IsSelect() bool {
+	return f.FieldType == FieldTypeSingleSelect || f.FieldType == FieldTypeMultiSelect
+}
+
+// Validate checks the field definition and normalizes its name and options
+func (f *CustomField) Validate() error {
+	if !IsValidItemType(f.ItemType) {
+		return errors.New("invalid item type")
+	}
+	if !fieldKeyPattern.MatchString(f.Key) {
+		return errors.New("key must start with a lowercase letter and contain only lowercase letters, digits and underscores")
+	}
+	if !IsValidFieldType(f.FieldType) {
+		return errors.New("invalid field type")
+	}
+
+	f.Name = strings.TrimSpace(f.Name)
+	if f.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(f.Name) > 100 {
+		return errors.New("name is too long")
+	}
+
+	if !f.IsSelect() {
+		if len(f.Options) > 0 {
+			return errors.New("only select fields have options")
+		}
+		f.Options = OptionList{}
+		return nil
+	}
+
+	options := make(OptionList, 0, len(f.Options))
+	seen := make(map[string]bool, len(f.Options))
+	for _, option := range f.Options {
+		option = strings.TrimSpace(option)
+		if option == "" {
+			return errors.New("options cannot be empty")
+		}
+		if seen[strings.ToLower(option)] {
+			return errors.New("duplicate option: " + option)
+		}
+		seen[strings.ToLower(option)] = true
+		options = append(options, option)
+	}
+	if len(options) == 0 {
+		return errors.New("select fields need at least one option")
+	}
+	f.Options = options
+	return nil
+}
+
+// option finds the option matching the value regardless of case
+func (f *CustomField) option(value string) (string, bool) {
+	for _, option := range f.Options {
+		if strings.EqualFold(option, value) {
+			return option, true
+		}
+	}
+	return "", false
+}
+
+// NormalizeValue converts a value from a request into the stored values of the field.
+// Null, empty strings and empty lists clear the field and return no values.
+func (f *CustomField) NormalizeValue(raw interface{}) ([]string, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	invalid := func(expected string) ([]string, error) {
+		return nil, errors.New(f.Key + " must be " + expected)
+	}
+
+	if f.FieldType == FieldTypeMultiSelect {
+		list, ok := raw.([]interface{})
+		if !ok {
+			if s, isString := raw.([]string); isString {
+				list = make([]interface{}, len(s))
+				for i := range s {
+					list[i] = s[i]
+				}
+			} else {
+				return invalid("a list of options")
+			}
+		}
+
+		var values []string
+		seen := make(map[string]bool, len(list))
+		for _, item := range list {
+			text, ok := item.(string)
+			if !ok {
+				return invalid("a list of options")
+			}
+			option, ok := f.option(strings.TrimSpace(text))
+			if !ok {
+				return nil, errors.New(f.Key + " has no option " + strconv.Quote(text))
+			}
+			if !seen[option] {
+				seen[option] = true
+				values = append(values, option)
+			}
+		}
+		return values, nil
+	}
+
+	switch f.FieldType {
+	case FieldTypeNumber:
+		var number float64
+		switch typed := raw.(type) {
+		case float64:
+			number = typed
+		case int:
+			number = float64(typed)
+		case string:
+			if strings.TrimSpace(typed) == "" {
+				return nil, nil
+			}
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(typed), 64)
+			if err != nil {
+				return invalid("a number")
+			}
+			number = parsed
+		default:
+			return invalid("a number")
+		}
+		if math.IsNaN(number) || math.IsInf(number, 0) {
+			return invalid("a number")
+		}
+		return []string{strconv.FormatFloat(number, 'f', -1, 64)}, nil
+
+	case FieldTypeUser:
+		var userID int
+		switch typed := raw.(type) {
+		case float64:
+			if typed != math.Trunc(typed) {
+				return invalid("a user ID")
+			}
+			userID = int(typed)
+		case int:
+			userID = typed
+		case string:
+			if strings.TrimSpace(typed) == "" {
+				return nil, nil
+			}
+			parsed, err := strconv.Atoi(strings.TrimSpace(typed))
+			if err != nil {
+				return invalid("a user ID")
+			}
+			userID = parsed
+		default:
+			return invalid("a user ID")
+		}
+		if userID <= 0 {
+			return invalid("a user ID")
+		}
+		return []string{strconv.Itoa(userID)}, nil
+	}
+
+	text, ok := raw.(string)
+	if !ok {
+		return invalid("a string")
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, nil
+	}
+
+	switch f.FieldType {
+	case FieldTypeDate:
+		if _, err := time.Parse("2006-01-02", text); err != nil {
+			return invalid("a date such as 2024-01-31")
+		}
+	case FieldTypeSingleSelect:
+		option, ok := f.option(text)
+		if !ok {
+			return nil, errors.New(f.Key + " has no option " + strconv.Quote(text))
+		}
+		text = option
+	default:
+		if len(text) > MaxTextLength {
+			return nil, fmt.Errorf("%s must be at most %d characters", f.Key, MaxTextLength)
+		}
+	}
+	return []string{text}, nil
+}
+
+// ResponseValue converts the stored values of the field into the value returned in item responses
+func (f *CustomField) ResponseValue(values []string) interface{} {
+	switch f.FieldType {
+	case FieldTypeMultiSelect:
+		list := make([]string, len(values))
+		copy(list, values)
+		return list
+	case FieldTypeNumber:
+		number, _ := strconv.ParseFloat(values[0], 64)
+		return number
+	case FieldTypeUser:
+		userID, _ := strconv.Atoi(values[0])
+		return userID
+	default:
+		return values[0]
+	}
+}
+
+// formatHistoryValue formats stored values for the change history
+func formatHistoryValue(values []string) string {
+	return strings.Join(values, ", ")
+}
@@ -0,0 +1,22 @@
+package custom_fields
+
+import "time"
+
+// CustomFieldChange records a change of a custom field value on an item
+type CustomFieldChange struct {
+	ID        int       `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProjectID int       `gorm:"not null;index" json:"projectId"`
+	ItemType  string    `gorm:"not null;size:50;index:idx_custom_field_change_item" json:"itemType"`
+	ItemID    int       `gorm:"not null;index:idx_custom_field_change_item" json:"itemId"`
+	FieldID   int       `gorm:"not null;index" json:"fieldId"`
+	FieldName string    `gorm:"not null;size:100" json:"fieldName"` // Kept so the history stays readable after the field is deleted
+	OldValue  string    `gorm:"type:text" json:"oldValue"`
+	NewValue  string    `gorm:"type:text" json:"newValue"`
+	ChangedBy *int      `gorm:"index" json:"changedBy,omitempty"`
+	ChangedAt time.Time `gorm:"not null;index" json:"changedAt"`
+}
+
+// TableName specifies the table name for GORM
+func (CustomFieldChange) TableName() string {
+	return "custom_field_changes"
+}
@@ -0,0 +1,23 @@
+package custom_fields
+
+import "github.com/dannyswat/pjeasy/internal/repositories"
+
+type CustomFieldChangeRepository struct {
+	uow *repositories.UnitOfWork
+}
+
+func NewCustomFieldChangeRepository(uow *repositories.UnitOfWork) *CustomFieldChangeRepository {
+	return &CustomFieldChangeRepository{uow: uow}
+}
+
+func (r *CustomFieldChangeRepository) Create(change *CustomFieldChange) error {
+	return r.uow.GetDB().Create(change).Error
+}
+
+func (r *CustomFieldChangeRepository) GetByItem(projectID int, itemType string, itemID int) ([]CustomFieldChange, error) {
+	var changes []CustomFieldChange
+	err := r.uow.GetDB().Where("project_id = ? AND item_type = ? AND item_id = ?", projectID, itemType, itemID).
+		Order("changed_at DESC, id DESC").
+		Find(&changes).Error
+	return changes, err
+}
@@ -0,0 +1,101 @@
+package custom_fields
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Filter operators. Comparisons are only supported by number and date fields.
+const (
+	FilterOpEqual        = "="
+	FilterOpGreater      = ">"
+	FilterOpGreaterEqual = ">="
+	FilterOpLess         = "<"
+	FilterOpLessEqual    = "<="
+)
+
+// FilterParamPrefix prefixes the list query parameters filtering on custom fields, as in cf.env=prod
+const FilterParamPrefix = "cf."
+
+// FieldFilter keeps the items whose custom field value matches
+type FieldFilter struct {
+	Field  CustomField
+	Op     string
+	Values []string // Alternatives of an equality filter, a single value for comparisons
+}
+
+// ParseFilter parses a filter on the field. Equality filters accept comma separated alternatives,
+// except on text fields; number and date fields also accept a leading >, >=, < or <=.
+func ParseFilter(field *CustomField, raw string) (FieldFilter, error) {
+	filter := FieldFilter{Field: *field, Op: FilterOpEqual}
+	raw = strings.TrimSpace(raw)
+
+	if field.FieldType == FieldTypeNumber || field.FieldType == FieldTypeDate {
+		for _, op := range []string{FilterOpGreaterEqual, FilterOpLessEqual, FilterOpGreater, FilterOpLess} {
+			if strings.HasPrefix(raw, op) {
+				filter.Op = op
+				raw = strings.TrimSpace(raw[len(op):])
+				break
+			}
+		}
+	}
+
+	alternatives := []string{raw}
+	if field.FieldType != FieldTypeText && filter.Op == FilterOpEqual {
+		alternatives = strings.Split(raw, ",")
+	}
+
+	single := *field
+	if single.FieldType == FieldTypeMultiSelect {
+		single.FieldType = FieldTypeSingleSelect
+	}
+	for _, alternative := range alternatives {
+		values, err := single.NormalizeValue(strings.TrimSpace(alternative))
+		if err != nil {
+			return FieldFilter{}, err
+		}
+		if len(values) == 0 {
+			return FieldFilter{}, errors.New("missing filter value for " + field.Key)
+		}
+		filter.Values = append(filter.Values, values[0])
+	}
+	return filter, nil
+}
+
+// condition returns the condition on custom_field_values rows matching the filter
+func (f *FieldFilter) condition() (string, []interface{}) {
+	switch f.Field.FieldType {
+	case FieldTypeNumber:
+		numbers := make([]float64, len(f.Values))
+		for i, value := range f.Values {
+			numbers[i], _ = strconv.ParseFloat(value, 64)
+		}
+		if f.Op == FilterOpEqual {
+			return "number_value IN ?", []interface{}{numbers}
+		}
+		return "number_value " + f.Op + " ?", []interface{}{numbers[0]}
+	case FieldTypeDate:
+		// Dates are stored as YYYY-MM-DD, which sorts like the dates
+		if f.Op == FilterOpEqual {
+			return "value IN ?", []interface{}{f.Values}
+		}
+		return "value " + f.Op + " ?", []interface{}{f.Values[0]}
+	case FieldTypeText:
+		return "LOWER(value) = ?", []interface{}{strings.ToLower(f.Values[0])}
+	default:
+		return "value IN ?", []interface{}{f.Values}
+	}
+}
+
+// ApplyFilters restricts a query on an item table to the items matching every filter
+func ApplyFilters(query *gorm.DB, table string, filters []FieldFilter) *gorm.DB {
+	for i := range filters {
+		condition, args := filters[i].condition()
+		args = append([]interface{}{filters[i].Field.ID, filters[i].Field.ItemType}, args...)
+		query = query.Where(table+".id IN (SELECT item_id FROM custom_field_values WHERE field_id = ? AND item_type = ? AND "+condition+")", args...)
+	}
+	return query
+}
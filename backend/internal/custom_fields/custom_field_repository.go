@@ -0,0 +1,67 @@
+package custom_fields
+
+import (
+	"github.com/dannyswat/pjeasy/internal/repositories"
+	"gorm.io/gorm"
+)
+
+type CustomFieldRepository struct {
+	uow *repositories.UnitOfWork
+}
+
+func NewCustomFieldRepository(uow *repositories.UnitOfWork) *CustomFieldRepository {
+	return &CustomFieldRepository{uow: uow}
+}
+
+// Create creates a new custom field
+func (r *CustomFieldRepository) Create(field *CustomField) error {
+	return r.uow.GetDB().Create(field).Error
+}
+
+// GetByID finds a custom field by ID
+func (r *CustomFieldRepository) GetByID(id int) (*CustomField, error) {
+	var field CustomField
+	err := r.uow.GetDB().First(&field, id).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &field, err
+}
+
+// GetByKey finds a custom field of an item type by key
+func (r *CustomFieldRepository) GetByKey(projectID int, itemType, key string) (*CustomField, error) {
+	var field CustomField
+	err := r.uow.GetDB().Where("project_id = ? AND item_type = ? AND key = ?", projectID, itemType, key).First(&field).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &field, err
+}
+
+// Update updates a custom field
+func (r *CustomFieldRepository) Update(field *CustomField) error {
+	return r.uow.GetDB().Save(field).Error
+}
+
+// Delete deletes a custom field
+func (r *CustomFieldRepository) Delete(id int) error {
+	return r.uow.GetDB().Delete(&CustomField{}, id).Error
+}
+
+// GetByProjectID returns the custom fields of a project in display order
+func (r *CustomFieldRepository) GetByProjectID(projectID int) ([]CustomField, error) {
+	var fields []CustomField
+	err := r.uow.GetDB().Where("project_id = ?", projectID).
+		Order("item_type ASC, sort_order ASC, id ASC").
+		Find(&fields).Error
+	return fields, err
+}
+
+// GetByItemType returns the custom fields of an item type in a project in display order
+func (r *CustomFieldRepository) GetByItemType(projectID int, itemType string) ([]CustomField, error) {
+	var fields []CustomField
+	err := r.uow.GetDB().Where("project_id = ? AND item_type = ?", projectID, itemType).
+		Order("sort_order ASC, id ASC").
+		Find(&fields).Error
+	return fields, err
+}
@@ -0,0 +1,440 @@
+package custom_fields
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/projects"
+	"github.com/dannyswat/pjeasy/internal/repositories"
+)
+
+type CustomFieldService struct {
+	fieldRepo   *CustomFieldRepository
+	valueRepo   *CustomFieldValueRepository
+	changeRepo  *CustomFieldChangeRepository
+	memberRepo  *projects.ProjectMemberRepository
+	projectRepo *projects.ProjectRepository
+	uowFactory  *repositories.UnitOfWorkFactory
+}
+
+func NewCustomFieldService(fieldRepo *CustomFieldRepository, valueRepo *CustomFieldValueRepository, changeRepo *CustomFieldChangeRepository, memberRepo *projects.ProjectMemberRepository, projectRepo *projects.ProjectRepository, uowFactory *repositories.UnitOfWorkFactory) *CustomFieldService {
+	return &CustomFieldService{
+		fieldRepo:   fieldRepo,
+		valueRepo:   valueRepo,
+		changeRepo:  changeRepo,
+		memberRepo:  memberRepo,
+		projectRepo: projectRepo,
+		uowFactory:  uowFactory,
+	}
+}
+
+// ensureAdmin rejects changes to the fields of archived projects and by users who are not project admins
+func (s *CustomFieldService) ensureAdmin(projectID int, userID int) error {
+	if err := s.projectRepo.EnsureWritable(projectID); err != nil {
+		return err
+	}
+
+	isAdmin, err := s.memberRepo.IsUserAdmin(projectID, userID)
+	if err != nil {
+		return err
+	}
+	if !isAdmin {
+		return errors.New("only project admins can manage custom fields")
+	}
+	return nil
+}
+
+func (s *CustomFieldService) ensureMember(projectID int, userID int) error {
+	isMember, err := s.memberRepo.IsUserMember(projectID, userID)
+	if err != nil {
+		return err
+	}
+	if !isMember {
+		return errors.New("user is not a member of this project")
+	}
+	return nil
+}
+
+// getProjectField finds a field of the project
+func (s *CustomFieldService) getProjectField(projectID, fieldID int) (*CustomField, error) {
+	field, err := s.fieldRepo.GetByID(fieldID)
+	if err != nil {
+		return nil, err
+	}
+	if field == nil || field.ProjectID != projectID {
+		return nil, errors.New("custom field not found")
+	}
+	return field, nil
+}
+
+// ListFields returns the custom fields of a project, optionally of one item type
+func (s *CustomFieldService) ListFields(projectID int, itemType string, userID int) ([]CustomField, error) {
+	if err := s.ensureMember(projectID, userID); err != nil {
+		return nil, err
+	}
+
+	if itemType == "" {
+		return s.fieldRepo.GetByProjectID(projectID)
+	}
+	if !IsValidItemType(itemType) {
+		return nil, errors.New("invalid item type")
+	}
+	return s.fieldRepo.GetByItemType(projectID, itemType)
+}
+
+// CreateField adds a custom field to an item type of the project
+func (s *CustomFieldService) CreateField(projectID int, itemType, key, name, fieldType string, options []string, required bool, sortOrder int, createdBy int) (*CustomField, error) {
+	if err := s.ensureAdmin(projectID, createdBy); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	field := &CustomField{
+		ProjectID: projectID,
+		ItemType:  itemType,
+		Key:       strings.TrimSpace(key),
+		Name:      name,
+		FieldType: fieldType,
+		Options:   OptionList(options),
+		Required:  required,
+		SortOrder: sortOrder,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := field.Validate(); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.fieldRepo.GetByKey(projectID, field.ItemType, field.Key)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.New("a custom field with this key already exists")
+	}
+
+	if err := s.fieldRepo.Create(field); err != nil {
+		return nil, err
+	}
+	return field, nil
+}
+
+// UpdateField updates a custom field. The key and type cannot change and options in use cannot be removed.
+func (s *CustomFieldService) UpdateField(projectID, fieldID int, name string, options []string, required bool, sortOrder int, updatedBy int) (*CustomField, error) {
+	if err := s.ensureAdmin(projectID, updatedBy); err != nil {
+		return nil, err
+	}
+
+	field, err := s.getProjectField(projectID, fieldID)
+	if err != nil {
+		return nil, err
+	}
+
+	previousOptions := field.Options
+	field.Name = name
+	field.Options = OptionList(options)
+	field.Required = required
+	field.SortOrder = sortOrder
+	field.UpdatedAt = time.Now()
+	if err := field.Validate(); err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for _, option := range previousOptions {
+		if _, kept := field.option(option); !kept {
+			removed = append(removed, option)
+		}
+	}
+	inUse, err := s.valueRepo.CountByFieldAndValues(field.ID, removed)
+	if err != nil {
+		return nil, err
+	}
+	if inUse > 0 {
+		return nil, errors.New("options in use by items cannot be removed")
+	}
+
+	if err := s.fieldRepo.Update(field); err != nil {
+		return nil, err
+	}
+	return field, nil
+}
+
+// DeleteField deletes a custom field with its values. The change history of the field is kept.
+func (s *CustomFieldService) DeleteField(projectID, fieldID int, deletedBy int) error {
+	if err := s.ensureAdmin(projectID, deletedBy); err != nil {
+		return err
+	}
+
+	field, err := s.getProjectField(projectID, fieldID)
+	if err != nil {
+		return err
+	}
+
+	uow := s.uowFactory.NewUnitOfWork()
+	if err := uow.BeginTransaction(); err != nil {
+		return err
+	}
+	defer uow.RollbackTransactionIfError()
+
+	if err := NewCustomFieldValueRepository(uow).DeleteByField(field.ID); err != nil {
+		return err
+	}
+	if err := NewCustomFieldRepository(uow).Delete(field.ID); err != nil {
+		return err
+	}
+
+	return uow.CommitTransaction()
+}
+
+// PendingValues are validated custom field values waiting to be saved with their item
+type PendingValues struct {
+	projectID int
+	itemType  string
+	isNew     bool
+	fields    map[int]*CustomField
+	values    map[int][]string // Values of the fields in the request by field ID
+}
+
+// PrepareValues validates the custom field values of a request creating or updating an item.
+// itemID is 0 for new items, which must have every required field. Fields missing from the
+// values of an existing item are left unchanged.
+func (s *CustomFieldService) PrepareValues(projectID int, itemType string, itemID int, input Values) (*PendingValues, error) {
+	fields, err := s.fieldRepo.GetByItemType(projectID, itemType)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := &PendingValues{
+		projectID: projectID,
+		itemType:  itemType,
+		isNew:     itemID == 0,
+		fields:    make(map[int]*CustomField, len(fields)),
+		values:    make(map[int][]string, len(input)),
+	}
+	byKey := make(map[string]*CustomField, len(fields))
+	for i := range fields {
+		pending.fields[fields[i].ID] = &fields[i]
+		byKey[fields[i].Key] = &fields[i]
+	}
+
+	for key, raw := range input {
+		field, ok := byKey[key]
+		if !ok {
+			return nil, errors.New("unknown custom field: " + key)
+		}
+
+		values, err := field.NormalizeValue(raw)
+		if err != nil {
+			return nil, err
+		}
+		if field.FieldType == FieldTypeUser && len(values) > 0 {
+			userID, _ := strconv.Atoi(values[0])
+			isMember, err := s.memberRepo.IsUserMember(projectID, userID)
+			if err != nil {
+				return nil, err
+			}
+			if !isMember {
+				return nil, errors.New(field.Key + " must be a member of this project")
+			}
+		}
+		pending.values[field.ID] = values
+	}
+
+	for _, field := range fields {
+		values, provided := pending.values[field.ID]
+		if field.Required && len(values) == 0 && (provided || pending.isNew) {
+			return nil, errors.New(field.Key + " is required")
+		}
+	}
+
+	return pending, nil
+}
+
+// SaveValues saves prepared values of an item in the unit of work of the item change and
+// records the changes of existing items in the custom field history
+func (s *CustomFieldService) SaveValues(uow *repositories.UnitOfWork, pending *PendingValues, itemID int, changedBy int) error {
+	if pending == nil || len(pending.values) == 0 {
+		return nil
+	}
+
+	valueRepo := NewCustomFieldValueRepository(uow)
+	changeRepo := NewCustomFieldChangeRepository(uow)
+
+	stored, err := valueRepo.GetByItems(pending.itemType, []int{itemID})
+	if err != nil {
+		return err
+	}
+	current := make(map[int][]string)
+	for _, value := range stored {
+		current[value.FieldID] = append(current[value.FieldID], value.Value)
+	}
+
+	now := time.Now()
+	for fieldID, values := range pending.values {
+		field := pending.fields[fieldID]
+		if equalValues(field, current[fieldID], values) {
+			continue
+		}
+
+		rows := make([]CustomFieldValue, len(values))
+		for i, value := range values {
+			rows[i] = CustomFieldValue{
+				ProjectID: pending.projectID,
+				FieldID:   fieldID,
+				ItemType:  pending.itemType,
+				ItemID:    itemID,
+				Value:     value,
+			}
+			if field.FieldType == FieldTypeNumber {
+				number, _ := strconv.ParseFloat(value, 64)
+				rows[i].NumberValue = &number
+			}
+		}
+		if err := valueRepo.ReplaceFieldValues(fieldID, pending.itemType, itemID, rows); err != nil {
+			return err
+		}
+
+		if pending.isNew {
+			continue
+		}
+		change := &CustomFieldChange{
+			ProjectID: pending.projectID,
+			ItemType:  pending.itemType,
+			ItemID:    itemID,
+			FieldID:   fieldID,
+			FieldName: field.Name,
+			OldValue:  formatHistoryValue(current[fieldID]),
+			NewValue:  formatHistoryValue(values),
+			ChangedBy: &changedBy,
+			ChangedAt: now,
+		}
+		if err := changeRepo.Create(change); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// equalValues checks if the stored values of a field are unchanged. The options of multi-select
+// fields are compared in any order, since their values are unique.
+func equalValues(field *CustomField, a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	if field.FieldType == FieldTypeMultiSelect {
+		stored := make(map[string]bool, len(a))
+		for _, value := range a {
+			stored[value] = true
+		}
+		for _, value := range b {
+			if !stored[value] {
+				return false
+			}
+		}
+		return true
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// GetValues returns the custom field values of an item by field key
+func (s *CustomFieldService) GetValues(projectID int, itemType string, itemID int) (Values, error) {
+	values, err := s.GetValuesForItems(projectID, itemType, []int{itemID})
+	if err != nil {
+		return nil, err
+	}
+	return values[itemID], nil
+}
+
+// GetValuesForItems returns the custom field values of items of a type in the project by item ID
+func (s *CustomFieldService) GetValuesForItems(projectID int, itemType string, itemIDs []int) (map[int]Values, error) {
+	result := make(map[int]Values)
+	if len(itemIDs) == 0 {
+		return result, nil
+	}
+
+	fields, err := s.fieldRepo.GetByItemType(projectID, itemType)
+	if err != nil || len(fields) == 0 {
+		return result, err
+	}
+	byID := make(map[int]*CustomField, len(fields))
+	for i := range fields {
+		byID[fields[i].ID] = &fields[i]
+	}
+
+	stored, err := s.valueRepo.GetByItems(itemType, itemIDs)
+	if err != nil {
+		return nil, err
+	}
+	grouped := make(map[int]map[int][]string)
+	for _, value := range stored {
+		if grouped[value.ItemID] == nil {
+			grouped[value.ItemID] = make(map[int][]string)
+		}
+		grouped[value.ItemID][value.FieldID] = append(grouped[value.ItemID][value.FieldID], value.Value)
+	}
+
+	for itemID, fieldValues := range grouped {
+		values := make(Values, len(fieldValues))
+		for fieldID, stored := range fieldValues {
+			if field, ok := byID[fieldID]; ok {
+				values[field.Key] = field.ResponseValue(stored)
+			}
+		}
+		result[itemID] = values
+	}
+	return result, nil
+}
+
+// ParseFilters parses custom field filters of a list request by field key
+func (s *CustomFieldService) ParseFilters(projectID int, itemType string, raw map[string]string) ([]FieldFilter, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	fields, err := s.fieldRepo.GetByItemType(projectID, itemType)
+	if err != nil {
+		return nil, err
+	}
+
+	filters := make([]FieldFilter, 0, len(raw))
+	for key, value := range raw {
+		var field *CustomField
+		for i := range fields {
+			if fields[i].Key == key {
+				field = &fields[i]
+			}
+		}
+		if field == nil {
+			return nil, errors.New("unknown custom field: " + key)
+		}
+
+		filter, err := ParseFilter(field, value)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
+
+// GetChanges returns the custom field history of an item, most recent first
+func (s *CustomFieldService) GetChanges(projectID int, itemType string, itemID int, userID int) ([]CustomFieldChange, error) {
+	if !IsValidItemType(itemType) {
+		return nil, errors.New("invalid item type")
+	}
+	if err := s.ensureMember(projectID, userID); err != nil {
+		return nil, err
+	}
+
+	return s.changeRepo.GetByItem(projectID, itemType, itemID)
+}
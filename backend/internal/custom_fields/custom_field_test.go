@@ -0,0 +1,139 @@
+package custom_fields
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCustomFieldValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   CustomField
+		wantErr bool
+	}{
+		{name: "text", field: CustomField{ItemType: "issue", Key: "env", Name: "Environment", FieldType: FieldTypeText}},
+		{name: "select", field: CustomField{ItemType: "task", Key: "team", Name: "Team", FieldType: FieldTypeSingleSelect, Options: OptionList{"Web", "API"}}},
+		{name: "plural item type", field: CustomField{ItemType: "issues", Key: "env", Name: "Environment", FieldType: FieldTypeText}, wantErr: true},
+		{name: "invalid key", field: CustomField{ItemType: "issue", Key: "Env", Name: "Environment", FieldType: FieldTypeText}, wantErr: true},
+		{name: "unknown field type", field: CustomField{ItemType: "issue", Key: "env", Name: "Environment", FieldType: "color"}, wantErr: true},
+		{name: "missing name", field: CustomField{ItemType: "issue", Key: "env", Name: " ", FieldType: FieldTypeText}, wantErr: true},
+		{name: "options on text field", field: CustomField{ItemType: "issue", Key: "env", Name: "Environment", FieldType: FieldTypeText, Options: OptionList{"prod"}}, wantErr: true},
+		{name: "select without options", field: CustomField{ItemType: "idea", Key: "team", Name: "Team", FieldType: FieldTypeMultiSelect}, wantErr: true},
+		{name: "duplicate options", field: CustomField{ItemType: "idea", Key: "team", Name: "Team", FieldType: FieldTypeMultiSelect, Options: OptionList{"Web", "web"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.field.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNormalizeValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   CustomField
+		raw     interface{}
+		want    []string
+		wantErr bool
+	}{
+		{name: "text is trimmed", field: CustomField{FieldType: FieldTypeText}, raw: "  prod ", want: []string{"prod"}},
+		{name: "empty text clears", field: CustomField{FieldType: FieldTypeText}, raw: "", want: nil},
+		{name: "null clears", field: CustomField{FieldType: FieldTypeNumber}, raw: nil, want: nil},
+		{name: "text too long", field: CustomField{FieldType: FieldTypeText}, raw: strings.Repeat("a", MaxTextLength+1), wantErr: true},
+		{name: "number", field: CustomField{FieldType: FieldTypeNumber}, raw: 2.50, want: []string{"2.5"}},
+		{name: "number from string", field: CustomField{FieldType: FieldTypeNumber}, raw: "10", want: []string{"10"}},
+		{name: "invalid number", field: CustomField{FieldType: FieldTypeNumber}, raw: "ten", wantErr: true},
+		{name: "date", field: CustomField{FieldType: FieldTypeDate}, raw: "2024-02-29", want: []string{"2024-02-29"}},
+		{name: "invalid date", field: CustomField{FieldType: FieldTypeDate}, raw: "2024-02-30", wantErr: true},
+		{name: "user", field: CustomField{FieldType: FieldTypeUser}, raw: float64(7), want: []string{"7"}},
+		{name: "fractional user", field: CustomField{FieldType: FieldTypeUser}, raw: 7.5, wantErr: true},
+		{name: "option case", field: CustomField{FieldType: FieldTypeSingleSelect, Options: OptionList{"Web", "API"}}, raw: "api", want: []string{"API"}},
+		{name: "unknown option", field: CustomField{FieldType: FieldTypeSingleSelect, Options: OptionList{"Web"}}, raw: "Mobile", wantErr: true},
+		{name: "multi select", field: CustomField{FieldType: FieldTypeMultiSelect, Options: OptionList{"Web", "API"}}, raw: []interface{}{"web", "API", "Web"}, want: []string{"Web", "API"}},
+		{name: "multi select needs a list", field: CustomField{FieldType: FieldTypeMultiSelect, Options: OptionList{"Web"}}, raw: "Web", wantErr: true},
+		{name: "empty multi select clears", field: CustomField{FieldType: FieldTypeMultiSelect, Options: OptionList{"Web"}}, raw: []interface{}{}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.field.Key = "field"
+			got, err := tt.field.NormalizeValue(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("NormalizeValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		field      CustomField
+		raw        string
+		wantOp     string
+		wantValues []string
+		wantSQL    string
+		wantErr    bool
+	}{
+		{name: "number comparison", field: CustomField{FieldType: FieldTypeNumber}, raw: ">=3", wantOp: FilterOpGreaterEqual, wantValues: []string{"3"}, wantSQL: "number_value >= ?"},
+		{name: "number alternatives", field: CustomField{FieldType: FieldTypeNumber}, raw: "1,2", wantOp: FilterOpEqual, wantValues: []string{"1", "2"}, wantSQL: "number_value IN ?"},
+		{name: "date before", field: CustomField{FieldType: FieldTypeDate}, raw: "<2024-01-01", wantOp: FilterOpLess, wantValues: []string{"2024-01-01"}, wantSQL: "value < ?"},
+		{name: "text keeps commas", field: CustomField{FieldType: FieldTypeText}, raw: "a,b", wantOp: FilterOpEqual, wantValues: []string{"a,b"}, wantSQL: "LOWER(value) = ?"},
+		{name: "text has no comparison", field: CustomField{FieldType: FieldTypeText}, raw: ">a", wantOp: FilterOpEqual, wantValues: []string{">a"}, wantSQL: "LOWER(value) = ?"},
+		{name: "multi select matches any option", field: CustomField{FieldType: FieldTypeMultiSelect, Options: OptionList{"Web", "API"}}, raw: "web,api", wantOp: FilterOpEqual, wantValues: []string{"Web", "API"}, wantSQL: "value IN ?"},
+		{name: "unknown option", field: CustomField{FieldType: FieldTypeSingleSelect, Options: OptionList{"Web"}}, raw: "Mobile", wantErr: true},
+		{name: "missing value", field: CustomField{FieldType: FieldTypeNumber}, raw: ">", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.field.Key = "field"
+			filter, err := ParseFilter(&tt.field, tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if filter.Op != tt.wantOp || !reflect.DeepEqual(filter.Values, tt.wantValues) {
+				t.Fatalf("ParseFilter() = %s %v, want %s %v", filter.Op, filter.Values, tt.wantOp, tt.wantValues)
+			}
+			if sql, _ := filter.condition(); sql != tt.wantSQL {
+				t.Fatalf("condition() = %q, want %q", sql, tt.wantSQL)
+			}
+		})
+	}
+}
+
+func TestEqualValues(t *testing.T) {
+	multi := &CustomField{FieldType: FieldTypeMultiSelect}
+	text := &CustomField{FieldType: FieldTypeText}
+
+	tests := []struct {
+		name  string
+		field *CustomField
+		a, b  []string
+		want  bool
+	}{
+		{name: "options in another order", field: multi, a: []string{"Red", "Blue"}, b: []string{"Blue", "Red"}, want: true},
+		{name: "option replaced", field: multi, a: []string{"Red", "Blue"}, b: []string{"Red", "Green"}, want: false},
+		{name: "option added", field: multi, a: []string{"Red"}, b: []string{"Red", "Blue"}, want: false},
+		{name: "same text", field: text, a: []string{"a"}, b: []string{"a"}, want: true},
+		{name: "cleared", field: text, a: []string{"a"}, b: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := equalValues(tt.field, tt.a, tt.b); got != tt.want {
+				t.Fatalf("equalValues() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
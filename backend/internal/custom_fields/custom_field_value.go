@@ -0,0 +1,17 @@
+package custom_fields
+
+// CustomFieldValue stores a value of a custom field on an item. Multi-select fields have one row per option.
+type CustomFieldValue struct {
+	ID          int      `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProjectID   int      `gorm:"not null;index" json:"projectId"`
+	FieldID     int      `gorm:"not null;index" json:"fieldId"`
+	ItemType    string   `gorm:"not null;size:50;index:idx_custom_field_value_item" json:"itemType"`
+	ItemID      int      `gorm:"not null;index:idx_custom_field_value_item" json:"itemId"`
+	Value       string   `gorm:"type:text;not null" json:"value"`
+	NumberValue *float64 `json:"numberValue,omitempty"` // Set for number fields so filters compare numerically
+}
+
+// TableName specifies the table name for GORM
+func (CustomFieldValue) TableName() string {
+	return "custom_field_values"
+}
@@ -0,0 +1,62 @@
+package custom_fields
+
+import (
+	"github.com/dannyswat/pjeasy/internal/repositories"
+)
+
+type CustomFieldValueRepository struct {
+	uow *repositories.UnitOfWork
+}
+
+func NewCustomFieldValueRepository(uow *repositories.UnitOfWork) *CustomFieldValueRepository {
+	return &CustomFieldValueRepository{uow: uow}
+}
+
+// GetByItems returns the custom field values of items of a type
+func (r *CustomFieldValueRepository) GetByItems(itemType string, itemIDs []int) ([]CustomFieldValue, error) {
+	var values []CustomFieldValue
+	if len(itemIDs) == 0 {
+		return values, nil
+	}
+	err := r.uow.GetDB().Where("item_type = ? AND item_id IN ?", itemType, itemIDs).
+		Order("id ASC").
+		Find(&values).Error
+	return values, err
+}
+
+// Create inserts values
+func (r *CustomFieldValueRepository) Create(values []CustomFieldValue) error {
+	if len(values) == 0 {
+		return nil
+	}
+	return r.uow.GetDB().Create(&values).Error
+}
+
+// ReplaceFieldValues replaces the values of a field on an item
+func (r *CustomFieldValueRepository) ReplaceFieldValues(fieldID int, itemType string, itemID int, values []CustomFieldValue) error {
+	db := r.uow.GetDB()
+	if err := db.Where("field_id = ? AND item_type = ? AND item_id = ?", fieldID, itemType, itemID).Delete(&CustomFieldValue{}).Error; err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return db.Create(&values).Error
+}
+
+// DeleteByField deletes every value of a field
+func (r *CustomFieldValueRepository) DeleteByField(fieldID int) error {
+	return r.uow.GetDB().Where("field_id = ?", fieldID).Delete(&CustomFieldValue{}).Error
+}
+
+// CountByFieldAndValues counts the values of a field that are one of the values
+func (r *CustomFieldValueRepository) CountByFieldAndValues(fieldID int, values []string) (int64, error) {
+	var count int64
+	if len(values) == 0 {
+		return 0, nil
+	}
+	err := r.uow.GetDB().Model(&CustomFieldValue{}).
+		Where("field_id = ? AND value IN ?", fieldID, values).
+		Count(&count).Error
+	return count, err
+}
@@ -2,31 +2,38 @@ package features
 
 import (
 	"time"
+
+	"github.com/dannyswat/pjeasy/internal/custom_fields"
+	"gorm.io/gorm"
 )
 
 // Feature represents a feature in the system
 type Feature struct {
-	ID                 int        `gorm:"primaryKey;autoIncrement" json:"id"`
-	RefNum             string     `gorm:"column:ref_num;not null;size:50;uniqueIndex:idx_project_feature_refnum,composite:projectId" json:"refNum"`
-	ProjectID          int        `gorm:"not null;index;uniqueIndex:idx_project_feature_refnum,composite:refNum" json:"projectId"`
-	Title              string     `gorm:"not null;size:255" json:"title"`
-	Description        string     `gorm:"type:text" json:"description"`
-	Status             string     `gorm:"not null;size:50;default:'Open'" json:"status"`     // Open, Assigned, InProgress, InReview, Completed, Rejected, Reopened, Closed
-	Priority           string     `gorm:"not null;size:50;default:'Normal'" json:"priority"` // Immediate, Urgent, High, Normal, Low
-	AssignedTo         int        `gorm:"index" json:"assignedTo,omitempty"`
-	SprintID           int        `gorm:"index" json:"sprintId,omitempty"`
-	Points             int        `gorm:"default:0" json:"points"`
-	Deadline           *time.Time `gorm:"index" json:"deadline,omitempty"`                          // Feature deadline
-	ReleaseID          *int       `gorm:"index" json:"releaseId,omitempty"`                         // Target release
-	DependsOnFeatureID *int       `gorm:"index" json:"dependsOnFeatureId,omitempty"`                // Blocking dependency on another feature
-	ItemType           string     `gorm:"size:50;index:idx_feature_item" json:"itemType,omitempty"` // Type of related item (e.g., "ideas", "designs", "service-tickets")
-	ItemID             *int       `gorm:"index:idx_feature_item" json:"itemId,omitempty"`           // ID of related item
-	LinkedIdeaLabel    string     `gorm:"->;column:linked_idea_label;-:migration" json:"linkedIdeaLabel,omitempty"`
-	Tags               string     `gorm:"type:text" json:"tags,omitempty"`        // Comma-separated tags
-	CascadeCompletion  bool       `gorm:"default:false" json:"cascadeCompletion"` // Auto-complete when all related tasks are completed
-	CreatedBy          int        `gorm:"not null;index" json:"createdBy"`
-	CreatedAt          time.Time  `gorm:"not null" json:"createdAt"`
-	UpdatedAt          time.Time  `gorm:"not null" json:"updatedAt"`
+	ID                  int                  `gorm:"primaryKey;autoIncrement" json:"id"`
+	RefNum              string               `gorm:"column:ref_num;not null;size:50;uniqueIndex:idx_project_feature_refnum,composite:projectId" json:"refNum"`
+	ProjectID           int                  `gorm:"not null;index;uniqueIndex:idx_project_feature_refnum,composite:refNum" json:"projectId"`
+	Title               string               `gorm:"not null;size:255" json:"title"`
+	Description         string               `gorm:"type:text" json:"description"`
+	Status              string               `gorm:"not null;size:50;default:'Open'" json:"status"`     // Open, Assigned, InProgress, InReview, Completed, Rejected, Reopened, Closed
+	Priority            string               `gorm:"not null;size:50;default:'Normal'" json:"priority"` // Immediate, Urgent, High, Normal, Low
+	AssignedTo          int                  `gorm:"index" json:"assignedTo,omitempty"`
+	SprintID            int                  `gorm:"index" json:"sprintId,omitempty"`
+	Points              int                  `gorm:"default:0" json:"points"`
+	Deadline            *time.Time           `gorm:"index" json:"deadline,omitempty"`                          // Feature deadline
+	ReleaseID           *int                 `gorm:"index" json:"releaseId,omitempty"`                         // Target release
+	DependsOnFeatureID  *int                 `gorm:"index" json:"dependsOnFeatureId,omitempty"`                // First of DependsOnFeatureIDs, kept for clients setting one dependency
+	DependsOnFeatureIDs []int                `gorm:"-" json:"dependsOnFeatureIds,omitempty"`                   // Features blocking this one, loaded by the service from blocks links
+	ItemType            string               `gorm:"size:50;index:idx_feature_item" json:"itemType,omitempty"` // Type of related item (e.g., "ideas", "designs", "service-tickets")
+	ItemID              *int                 `gorm:"index:idx_feature_item" json:"itemId,omitempty"`           // ID of related item
+	LinkedIdeaLabel     string               `gorm:"->;column:linked_idea_label;-:migration" json:"linkedIdeaLabel,omitempty"`
+	Tags                string               `gorm:"type:text" json:"tags,omitempty"`        // Comma-separated tags
+	CascadeCompletion   bool                 `gorm:"default:false" json:"cascadeCompletion"` // Auto-complete when all related tasks are completed
+	CustomFields        custom_fields.Values `gorm:"-" json:"customFields,omitempty"`        // Loaded by the service, stored in custom_field_values
+	CreatedBy           int                  `gorm:"not null;index" json:"createdBy"`
+	CreatedAt           time.Time            `gorm:"not null" json:"createdAt"`
+	UpdatedAt           time.Time            `gorm:"not null" json:"updatedAt"`
+	DeletedAt           gorm.DeletedAt       `gorm:"index" json:"-"` // Set while in the trash
+	DeletedBy           *int                 `json:"-"`
 }
 
 // TableName specifies the table name for GORM
@@ -0,0 +1,260 @@
+package features
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/item_links"
+	"github.com/dannyswat/pjeasy/internal/projects"
+)
+
+// Sources of the duration of a feature in the dependency graph
+const (
+	DurationSourceEstimate = "estimate" // Estimated hours of the tasks under the feature
+	DurationSourcePoints   = "points"
+	DurationSourceDeadline = "deadline" // Working days left from the earliest start to the deadline
+	DurationSourceDefault  = "default"
+	DurationSourceDone     = "done" // Completed, closed and rejected features need no more time
+)
+
+const (
+	HoursPerWorkingDay  = 8 // Converts task estimates to working days
+	WorkingDaysPerPoint = 1 // Converts feature points to working days
+	DefaultDurationDays = 1 // Duration of open features without estimates, points or deadline
+)
+
+// DependencyNode is a feature in the dependency graph with its schedule.
+// Days are counted in project working days from the first working day on or after today.
+type DependencyNode struct {
+	Feature           *Feature
+	EstimatedHours    float64
+	DurationDays      int
+	DurationSource    string
+	EarliestStartDay  int
+	EarliestFinishDay int
+	LatestStartDay    int
+	LatestFinishDay   int
+	SlackDays         int       // How long the feature can slip without delaying the last feature
+	EarliestStart     time.Time // First working day of the feature
+	EarliestFinish    time.Time // Last working day of the feature
+	Critical          bool      // An open feature without slack
+	Late              bool      // The earliest finish is after the deadline
+}
+
+// DependencyEdge is a blocks link from a feature to a feature depending on it
+type DependencyEdge struct {
+	LinkID        int
+	FromFeatureID int
+	ToFeatureID   int
+	Critical      bool // Both features are critical and the dependency finishes right before the feature starts
+}
+
+// DependencyGraph is the feature dependency graph of a project
+type DependencyGraph struct {
+	Nodes        []DependencyNode
+	Edges        []DependencyEdge
+	CriticalPath []int     // IDs of the features on the critical path, first to last
+	DurationDays int       // Working days until every feature is finished
+	Start        time.Time // First working day of the schedule
+	Finish       time.Time // Last working day of the schedule
+}
+
+// needsNoWork checks if a feature in the status takes no more time
+func needsNoWork(status string) bool {
+	switch status {
+	case FeatureStatusCompleted, FeatureStatusClosed, FeatureStatusRejected:
+		return true
+	default:
+		return false
+	}
+}
+
+// workCalendar numbers the working days of a project from a start day
+type workCalendar struct {
+	settings *projects.ProjectSettings
+	days     []time.Time
+}
+
+func newWorkCalendar(settings *projects.ProjectSettings, today time.Time) *workCalendar {
+	c := &workCalendar{settings: settings}
+	c.days = []time.Time{c.nextWorkingDay(c.day(today))}
+	return c
+}
+
+// day returns the calendar day of a time in the project's time zone
+func (c *workCalendar) day(t time.Time) time.Time {
+	local := t.In(c.settings.Location())
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+}
+
+func (c *workCalendar) nextWorkingDay(day time.Time) time.Time {
+	for i := 0; i < 7 && !c.settings.IsWorkingDay(day); i++ {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day
+}
+
+// date returns working day n, where day 0 is the start day
+func (c *workCalendar) date(n int) time.Time {
+	for len(c.days) <= n {
+		c.days = append(c.days, c.nextWorkingDay(c.days[len(c.days)-1].AddDate(0, 0, 1)))
+	}
+	return c.days[n]
+}
+
+// daysUntil counts the working days from the start day up to and including the day
+func (c *workCalendar) daysUntil(day time.Time) int {
+	n := 0
+	for !c.date(n).After(day) {
+		n++
+	}
+	return n
+}
+
+// duration returns the working days a feature still needs once its dependencies are finished
+func (c *workCalendar) duration(node *DependencyNode) (int, string) {
+	feature := node.Feature
+	switch {
+	case needsNoWork(feature.Status):
+		return 0, DurationSourceDone
+	case node.EstimatedHours > 0:
+		return int(math.Ceil(node.EstimatedHours / HoursPerWorkingDay)), DurationSourceEstimate
+	case feature.Points > 0:
+		return feature.Points * WorkingDaysPerPoint, DurationSourcePoints
+	case feature.Deadline != nil:
+		if days := c.daysUntil(c.day(*feature.Deadline)) - node.EarliestStartDay; days > 0 {
+			return days, DurationSourceDeadline
+		}
+	}
+	return DefaultDurationDays, DurationSourceDefault
+}
+
+// BuildDependencyGraph schedules the features from today by their blocks links and finds the critical path.
+// Links to features that are not given are left out.
+func BuildDependencyGraph(features []Feature, links []item_links.ItemLink, estimatedHours map[int]float64, settings *projects.ProjectSettings, today time.Time) (*DependencyGraph, error) {
+	calendar := newWorkCalendar(settings, today)
+
+	index := make(map[int]int, len(features))
+	nodes := make([]DependencyNode, len(features))
+	for i := range features {
+		index[features[i].ID] = i
+		nodes[i] = DependencyNode{Feature: &features[i], EstimatedHours: estimatedHours[features[i].ID]}
+	}
+
+	edges := make([]DependencyEdge, 0, len(links))
+	predecessors := make([][]int, len(nodes))
+	successors := make([][]int, len(nodes))
+	for _, link := range links {
+		if link.LinkType != item_links.LinkTypeBlocks || link.SourceType != projects.PermissionItemFeature || link.TargetType != projects.PermissionItemFeature {
+			continue
+		}
+		from, fromOK := index[link.SourceID]
+		to, toOK := index[link.TargetID]
+		if !fromOK || !toOK {
+			continue
+		}
+		edges = append(edges, DependencyEdge{LinkID: link.ID, FromFeatureID: link.SourceID, ToFeatureID: link.TargetID})
+		predecessors[to] = append(predecessors[to], from)
+		successors[from] = append(successors[from], to)
+	}
+
+	order, err := topologicalOrder(predecessors, successors)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := &DependencyGraph{Nodes: nodes, Edges: edges, CriticalPath: []int{}}
+
+	// Forward pass: a feature starts when the last of its dependencies is finished
+	for _, i := range order {
+		node := &nodes[i]
+		for _, p := range predecessors[i] {
+			if nodes[p].EarliestFinishDay > node.EarliestStartDay {
+				node.EarliestStartDay = nodes[p].EarliestFinishDay
+			}
+		}
+		node.DurationDays, node.DurationSource = calendar.duration(node)
+		node.EarliestFinishDay = node.EarliestStartDay + node.DurationDays
+		if node.EarliestFinishDay > graph.DurationDays {
+			graph.DurationDays = node.EarliestFinishDay
+		}
+	}
+
+	// Backward pass: a feature must finish before the features depending on it have to start
+	for k := len(order) - 1; k >= 0; k-- {
+		node := &nodes[order[k]]
+		node.LatestFinishDay = graph.DurationDays
+		for _, s := range successors[order[k]] {
+			if nodes[s].LatestStartDay < node.LatestFinishDay {
+				node.LatestFinishDay = nodes[s].LatestStartDay
+			}
+		}
+		node.LatestStartDay = node.LatestFinishDay - node.DurationDays
+		node.SlackDays = node.LatestStartDay - node.EarliestStartDay
+		node.Critical = node.DurationSource != DurationSourceDone && node.SlackDays == 0
+
+		node.EarliestStart = calendar.date(node.EarliestStartDay)
+		node.EarliestFinish = calendar.date(max(node.EarliestFinishDay-1, node.EarliestStartDay))
+		if deadline := node.Feature.Deadline; deadline != nil && node.DurationSource != DurationSourceDone {
+			node.Late = node.EarliestFinish.After(calendar.day(*deadline))
+		}
+	}
+
+	for i := range edges {
+		from, to := &nodes[index[edges[i].FromFeatureID]], &nodes[index[edges[i].ToFeatureID]]
+		edges[i].Critical = from.Critical && to.Critical && from.EarliestFinishDay == to.EarliestStartDay
+	}
+
+	// Walk back from the critical feature finishing last through critical dependencies
+	last := -1
+	for i := range nodes {
+		if nodes[i].Critical && (last < 0 || nodes[i].EarliestFinishDay > nodes[last].EarliestFinishDay) {
+			last = i
+		}
+	}
+	for last >= 0 {
+		graph.CriticalPath = append([]int{nodes[last].Feature.ID}, graph.CriticalPath...)
+		next := -1
+		for _, p := range predecessors[last] {
+			if nodes[p].Critical && nodes[p].EarliestFinishDay == nodes[last].EarliestStartDay &&
+				(next < 0 || nodes[p].Feature.ID < nodes[next].Feature.ID) {
+				next = p
+			}
+		}
+		last = next
+	}
+
+	graph.Start = calendar.date(0)
+	graph.Finish = calendar.date(max(graph.DurationDays-1, 0))
+	return graph, nil
+}
+
+// topologicalOrder orders the nodes so that every node comes after its predecessors
+func topologicalOrder(predecessors, successors [][]int) ([]int, error) {
+	remaining := make([]int, len(predecessors))
+	queue := make([]int, 0, len(predecessors))
+	for i := range predecessors {
+		remaining[i] = len(predecessors[i])
+		if remaining[i] == 0 {
+			queue = append(queue, i)
+		}
+	}
+
+	order := make([]int, 0, len(predecessors))
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		order = append(order, i)
+		for _, s := range successors[i] {
+			remaining[s]--
+			if remaining[s] == 0 {
+				queue = append(queue, s)
+			}
+		}
+	}
+	if len(order) < len(predecessors) {
+		return nil, errors.New("feature dependencies contain a cycle")
+	}
+	return order, nil
+}
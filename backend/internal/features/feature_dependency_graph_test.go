@@ -0,0 +1,98 @@
+package features
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/item_links"
+	"github.com/dannyswat/pjeasy/internal/projects"
+)
+
+func dependencyLink(id, from, to int) item_links.ItemLink {
+	return item_links.ItemLink{
+		ID:         id,
+		SourceType: projects.PermissionItemFeature,
+		SourceID:   from,
+		LinkType:   item_links.LinkTypeBlocks,
+		TargetType: projects.PermissionItemFeature,
+		TargetID:   to,
+	}
+}
+
+func TestBuildDependencyGraph(t *testing.T) {
+	date := func(day int) time.Time { return time.Date(2024, 6, day, 0, 0, 0, 0, time.UTC) }
+	deadline := func(day int) *time.Time { d := date(day); return &d }
+
+	features := []Feature{
+		{ID: 1, Status: FeatureStatusOpen, Points: 2},
+		{ID: 2, Status: FeatureStatusInProgress},
+		{ID: 3, Status: FeatureStatusOpen},
+		{ID: 4, Status: FeatureStatusCompleted, Points: 5},
+		{ID: 5, Status: FeatureStatusOpen, Deadline: deadline(11)},
+		{ID: 6, Status: FeatureStatusOpen, Points: 3, Deadline: deadline(4)},
+	}
+	links := []item_links.ItemLink{
+		dependencyLink(10, 1, 3),
+		dependencyLink(11, 2, 3),
+		dependencyLink(12, 4, 3),
+		dependencyLink(13, 1, 5),
+		dependencyLink(14, 1, 99), // Not in the graph
+	}
+	estimatedHours := map[int]float64{2: 20}
+
+	// Saturday, so the schedule starts on Monday June 3
+	graph, err := BuildDependencyGraph(features, links, estimatedHours, projects.DefaultProjectSettings(), date(1))
+	if err != nil {
+		t.Fatalf("BuildDependencyGraph() error = %v", err)
+	}
+
+	want := map[int]struct {
+		source         string
+		start, finish  int
+		slack          int
+		critical, late bool
+		finishDate     time.Time
+	}{
+		1: {source: DurationSourcePoints, start: 0, finish: 2, slack: 0, critical: true, finishDate: date(4)},
+		2: {source: DurationSourceEstimate, start: 0, finish: 3, slack: 3, finishDate: date(5)},
+		3: {source: DurationSourceDefault, start: 3, finish: 4, slack: 3, finishDate: date(6)},
+		4: {source: DurationSourceDone, start: 0, finish: 0, slack: 6, finishDate: date(3)},
+		5: {source: DurationSourceDeadline, start: 2, finish: 7, slack: 0, critical: true, finishDate: date(11)},
+		6: {source: DurationSourcePoints, start: 0, finish: 3, slack: 4, late: true, finishDate: date(5)},
+	}
+	for _, node := range graph.Nodes {
+		w := want[node.Feature.ID]
+		if node.DurationSource != w.source || node.EarliestStartDay != w.start || node.EarliestFinishDay != w.finish ||
+			node.SlackDays != w.slack || node.Critical != w.critical || node.Late != w.late || !node.EarliestFinish.Equal(w.finishDate) {
+			t.Errorf("feature %d = %s %d-%d slack %d critical %v late %v finish %s, want %+v", node.Feature.ID,
+				node.DurationSource, node.EarliestStartDay, node.EarliestFinishDay, node.SlackDays, node.Critical, node.Late,
+				node.EarliestFinish.Format("2006-01-02"), w)
+		}
+	}
+
+	if len(graph.Edges) != 4 {
+		t.Fatalf("len(Edges) = %d, want 4", len(graph.Edges))
+	}
+	for _, edge := range graph.Edges {
+		if wantCritical := edge.LinkID == 13; edge.Critical != wantCritical {
+			t.Errorf("edge %d critical = %v, want %v", edge.LinkID, edge.Critical, wantCritical)
+		}
+	}
+	if !reflect.DeepEqual(graph.CriticalPath, []int{1, 5}) {
+		t.Errorf("CriticalPath = %v, want [1 5]", graph.CriticalPath)
+	}
+	if graph.DurationDays != 7 || !graph.Start.Equal(date(3)) || !graph.Finish.Equal(date(11)) {
+		t.Errorf("schedule = %d days %s to %s, want 7 days 2024-06-03 to 2024-06-11",
+			graph.DurationDays, graph.Start.Format("2006-01-02"), graph.Finish.Format("2006-01-02"))
+	}
+}
+
+func TestBuildDependencyGraphRejectsCycles(t *testing.T) {
+	features := []Feature{{ID: 1, Status: FeatureStatusOpen}, {ID: 2, Status: FeatureStatusOpen}}
+	links := []item_links.ItemLink{dependencyLink(1, 1, 2), dependencyLink(2, 2, 1)}
+
+	if _, err := BuildDependencyGraph(features, links, nil, projects.DefaultProjectSettings(), time.Now()); err == nil {
+		t.Fatal("BuildDependencyGraph() error = nil, want a cycle error")
+	}
+}
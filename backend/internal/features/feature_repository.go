@@ -1,8 +1,13 @@
 package features
 
 import (
+	"time"
+
 	"strings"
 
+	"github.com/dannyswat/pjeasy/internal/custom_fields"
+	"github.com/dannyswat/pjeasy/internal/item_links"
+	"github.com/dannyswat/pjeasy/internal/projects"
 	"github.com/dannyswat/pjeasy/internal/repositories"
 	"gorm.io/gorm"
 )
@@ -44,7 +49,7 @@ func NewFeatureRepository(uow *repositories.UnitOfWork) *FeatureRepository {
 
 func withFeatureLinkedIdeaLabel(query *gorm.DB) *gorm.DB {
 	return query.
-		Joins("LEFT JOIN ideas linked_ideas ON features.item_type = ? AND features.item_id = linked_ideas.id", "ideas").
+		Joins("LEFT JOIN ideas linked_ideas ON features.item_type = ? AND features.item_id = linked_ideas.id AND linked_ideas.deleted_at IS NULL", "ideas").
 		Select("features.*, linked_ideas.label AS linked_idea_label")
 }
 
@@ -63,23 +68,66 @@ func (r *FeatureRepository) GetByID(id int) (*Feature, error) {
 	return &feature, err
 }
 
+// GetByIDs finds the features with the given IDs
+func (r *FeatureRepository) GetByIDs(ids []int) ([]Feature, error) {
+	var features []Feature
+	if len(ids) == 0 {
+		return features, nil
+	}
+	err := r.uow.GetDB().Where("id IN ?", ids).Order("id ASC").Find(&features).Error
+	return features, err
+}
+
 // HasDependents checks whether other features depend on the given feature.
 func (r *FeatureRepository) HasDependents(featureID int) (bool, error) {
 	var count int64
 	err := r.uow.GetDB().Model(&Feature{}).
-		Where("depends_on_feature_id = ?", featureID).
+		Where("id IN (SELECT target_id FROM item_links WHERE link_type = ? AND source_type = ? AND source_id = ? AND target_type = ?)",
+			item_links.LinkTypeBlocks, projects.PermissionItemFeature, featureID, projects.PermissionItemFeature).
 		Count(&count).Error
 	return count > 0, err
 }
 
+// GetEstimatedHours sums the estimated hours of the tasks under each feature through child-of links
+func (r *FeatureRepository) GetEstimatedHours(featureIDs []int) (map[int]float64, error) {
+	hours := make(map[int]float64, len(featureIDs))
+	if len(featureIDs) == 0 {
+		return hours, nil
+	}
+
+	var rows []struct {
+		FeatureID int
+		Hours     float64
+	}
+	err := r.uow.GetDB().Table("item_links").
+		Select("item_links.target_id AS feature_id, COALESCE(SUM(tasks.estimated_hours), 0) AS hours").
+		Joins("JOIN tasks ON tasks.id = item_links.source_id AND tasks.deleted_at IS NULL").
+		Where("item_links.link_type = ? AND item_links.source_type = ? AND item_links.target_type = ? AND item_links.target_id IN ?",
+			item_links.LinkTypeChildOf, projects.PermissionItemTask, projects.PermissionItemFeature, featureIDs).
+		Group("item_links.target_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		hours[row.FeatureID] = row.Hours
+	}
+	return hours, nil
+}
+
 // Update updates a feature
 func (r *FeatureRepository) Update(feature *Feature) error {
 	return r.uow.GetDB().Save(feature).Error
 }
 
-// Delete deletes a feature
-func (r *FeatureRepository) Delete(id int) error {
-	return r.uow.GetDB().Delete(&Feature{}, id).Error
+// Delete moves a feature to the trash. Trashed rows are excluded from every query until restored.
+func (r *FeatureRepository) Delete(id int, deletedBy int) error {
+	return r.uow.GetDB().Model(&Feature{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"deleted_at": time.Now(),
+			"deleted_by": deletedBy,
+		}).Error
 }
 
 // GetByProjectID returns all features for a project with pagination
@@ -258,17 +306,18 @@ func (r *FeatureRepository) GetByItemReference(projectID int, itemType string, i
 	var features []Feature
 	var total int64
 
-	query := r.uow.GetDB().Model(&Feature{}).
-		Where("features.project_id = ? AND features.item_type = ? AND features.item_id = ?", projectID, itemType, itemID)
+	children := func() *gorm.DB {
+		query := r.uow.GetDB().Model(&Feature{}).Where("features.project_id = ?", projectID)
+		return item_links.ChildrenOf(query, "features", itemType, itemID)
+	}
 
 	// Get total count
-	if err := query.Count(&total).Error; err != nil {
+	if err := children().Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Get paginated results
-	err := withFeatureLinkedIdeaLabel(r.uow.GetDB().Model(&Feature{}).
-		Where("features.project_id = ? AND features.item_type = ? AND features.item_id = ?", projectID, itemType, itemID)).Order("features.created_at DESC").
+	err := withFeatureLinkedIdeaLabel(children()).Order("features.created_at DESC").
 		Offset(offset).
 		Limit(limit).
 		Find(&features).Error
@@ -305,13 +354,14 @@ func (r *FeatureRepository) GetByProjectAndAssigneeLimited(projectID int, assign
 	return features, err
 }
 
-func (r *FeatureRepository) GetByProjectIDWithSelectorFilters(projectID int, statuses []string, priority string, search string, excludeFeatureID *int, dependencySelectable bool, selectedFeatureID *int, offset, limit int) ([]Feature, int64, error) {
+func (r *FeatureRepository) GetByProjectIDWithSelectorFilters(projectID int, statuses []string, priority string, search string, excludeFeatureID *int, dependencySelectable bool, selectedFeatureID *int, filters []custom_fields.FieldFilter, offset, limit int) ([]Feature, int64, error) {
 	var features []Feature
 	var total int64
 
 	query := r.uow.GetDB().Model(&Feature{}).Where("project_id = ?", projectID)
 	query = applyFeatureSearch(query, search)
 	query = applyFeatureDependencySelection(query, dependencySelectable, selectedFeatureID, excludeFeatureID)
+	query = custom_fields.ApplyFilters(query, "features", filters)
 
 	if len(statuses) == 1 {
 		query = query.Where("status = ?", statuses[0])
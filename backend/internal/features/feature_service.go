@@ -5,7 +5,9 @@ import (
 	"errors"
 	"time"
 
+	"github.com/dannyswat/pjeasy/internal/custom_fields"
 	"github.com/dannyswat/pjeasy/internal/htmlsanitizer"
+	"github.com/dannyswat/pjeasy/internal/item_links"
 	"github.com/dannyswat/pjeasy/internal/projects"
 	"github.com/dannyswat/pjeasy/internal/repositories"
 	"github.com/dannyswat/pjeasy/internal/sequences"
@@ -21,102 +23,181 @@ type FeatureService struct {
 	featureRepo         *FeatureRepository
 	memberRepo          *projects.ProjectMemberRepository
 	projectRepo         *projects.ProjectRepository
+	settingService      *projects.ProjectSettingService
 	sequenceRepo        *sequences.SequenceRepository
 	statusRepo          *status_changes.StatusChangeService
+	customFieldService  *custom_fields.CustomFieldService
+	linkRepo            *item_links.ItemLinkRepository
 	uowFactory          *repositories.UnitOfWorkFactory
 	statusChangeHandler StatusChangeHandler
 }
 
-func NewFeatureService(featureRepo *FeatureRepository, memberRepo *projects.ProjectMemberRepository, projectRepo *projects.ProjectRepository, sequenceRepo *sequences.SequenceRepository, statusRepo *status_changes.StatusChangeService, uowFactory *repositories.UnitOfWorkFactory) *FeatureService {
+func NewFeatureService(featureRepo *FeatureRepository, memberRepo *projects.ProjectMemberRepository, projectRepo *projects.ProjectRepository, settingService *projects.ProjectSettingService, sequenceRepo *sequences.SequenceRepository, statusRepo *status_changes.StatusChangeService, customFieldService *custom_fields.CustomFieldService, linkRepo *item_links.ItemLinkRepository, uowFactory *repositories.UnitOfWorkFactory) *FeatureService {
 	return &FeatureService{
-		featureRepo:  featureRepo,
-		memberRepo:   memberRepo,
-		projectRepo:  projectRepo,
-		sequenceRepo: sequenceRepo,
-		statusRepo:   statusRepo,
-		uowFactory:   uowFactory,
+		featureRepo:        featureRepo,
+		memberRepo:         memberRepo,
+		projectRepo:        projectRepo,
+		settingService:     settingService,
+		sequenceRepo:       sequenceRepo,
+		statusRepo:         statusRepo,
+		customFieldService: customFieldService,
+		linkRepo:           linkRepo,
+		uowFactory:         uowFactory,
 	}
 }
 
-// SetStatusChangeHandler sets the handler for status change events
-func (s *FeatureService) SetStatusChangeHandler(handler StatusChangeHandler) {
-	s.statusChangeHandler = handler
+// loadCustomFields fills in the custom field values of the features of a project
+func (s *FeatureService) loadCustomFields(projectID int, features []Feature) error {
+	ids := make([]int, len(features))
+	for i := range features {
+		ids[i] = features[i].ID
+	}
+	values, err := s.customFieldService.GetValuesForItems(projectID, projects.PermissionItemFeature, ids)
+	if err != nil {
+		return err
+	}
+	for i := range features {
+		features[i].CustomFields = values[features[i].ID]
+	}
+	return nil
 }
 
-func dependencyBlocksStatus(status string) bool {
-	switch status {
-	case FeatureStatusInProgress, FeatureStatusInReview, FeatureStatusCompleted, FeatureStatusReopened:
-		return true
-	default:
-		return false
+// loadDependencies fills in the features blocking each feature, oldest link first
+func (s *FeatureService) loadDependencies(features []Feature) error {
+	ids := make([]int, len(features))
+	for i := range features {
+		ids[i] = features[i].ID
 	}
+	links, err := s.linkRepo.GetByTargets(item_links.LinkTypeBlocks, projects.PermissionItemFeature, ids)
+	if err != nil {
+		return err
+	}
+	dependencies := make(map[int][]int)
+	for _, link := range links {
+		if link.SourceType == projects.PermissionItemFeature {
+			dependencies[link.TargetID] = append(dependencies[link.TargetID], link.SourceID)
+		}
+	}
+	for i := range features {
+		features[i].DependsOnFeatureIDs = dependencies[features[i].ID]
+	}
+	return nil
 }
 
-func (s *FeatureService) validateDependency(projectID int, featureID int, dependsOnFeatureID *int) error {
-	if dependsOnFeatureID == nil {
-		return nil
+// loadDetails fills in the custom field values and dependencies of a feature
+func (s *FeatureService) loadDetails(feature *Feature) error {
+	var err error
+	feature.CustomFields, err = s.customFieldService.GetValues(feature.ProjectID, projects.PermissionItemFeature, feature.ID)
+	if err != nil {
+		return err
 	}
+	feature.DependsOnFeatureIDs, err = s.linkRepo.GetDependencyIDs(feature.ID)
+	return err
+}
 
-	if featureID > 0 && *dependsOnFeatureID == featureID {
-		return errors.New("feature cannot depend on itself")
-	}
+// SetStatusChangeHandler sets the handler for status change events
+func (s *FeatureService) SetStatusChangeHandler(handler StatusChangeHandler) {
+	s.statusChangeHandler = handler
+}
 
-	visited := map[int]struct{}{}
-	currentID := *dependsOnFeatureID
+// dependencyBlocksTransition checks if a status change requires the dependencies of a feature to be completed.
+// Only starting work is blocked, so started features stay editable and can be reopened after an upstream change.
+func dependencyBlocksTransition(oldStatus, newStatus string) bool {
+	return oldStatus != newStatus && newStatus == FeatureStatusInProgress
+}
 
-	for currentID > 0 {
-		if currentID == featureID {
-			return errors.New("feature dependency cannot create a cycle")
-		}
-		if _, exists := visited[currentID]; exists {
-			return errors.New("feature dependency cannot create a cycle")
+// dependencyResolved checks if a dependency in the status no longer blocks the features depending on it
+func dependencyResolved(status string) bool {
+	return status == FeatureStatusCompleted || status == FeatureStatusClosed
+}
+
+// validateDependencies checks the features a feature depends on and returns them without duplicates.
+// A dependency is rejected when the feature already blocks it, directly or through other blocks links.
+func (s *FeatureService) validateDependencies(projectID int, featureID int, dependencyIDs []int) ([]int, error) {
+	seen := make(map[int]bool, len(dependencyIDs))
+	ids := make([]int, 0, len(dependencyIDs))
+	for _, id := range dependencyIDs {
+		if seen[id] {
+			continue
 		}
-		visited[currentID] = struct{}{}
+		seen[id] = true
 
-		dependency, err := s.featureRepo.GetByID(currentID)
+		if featureID > 0 && id == featureID {
+			return nil, errors.New("feature cannot depend on itself")
+		}
+		dependency, err := s.featureRepo.GetByID(id)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if dependency == nil {
-			return errors.New("dependency feature not found")
+			return nil, errors.New("dependency feature not found")
 		}
 		if dependency.ProjectID != projectID {
-			return errors.New("dependency feature belongs to a different project")
+			return nil, errors.New("dependency feature belongs to a different project")
 		}
-		if dependency.DependsOnFeatureID == nil {
-			return nil
+		if featureID > 0 {
+			cycle, err := s.linkRepo.Reaches(item_links.LinkTypeBlocks, projects.PermissionItemFeature, featureID, projects.PermissionItemFeature, id)
+			if err != nil {
+				return nil, err
+			}
+			if cycle {
+				return nil, errors.New("feature dependency cannot create a cycle")
+			}
 		}
-
-		currentID = *dependency.DependsOnFeatureID
+		ids = append(ids, id)
 	}
+	return ids, nil
+}
 
-	return nil
+// replaceFirstDependency returns the dependencies with the oldest one replaced, or removed when first is nil
+func replaceFirstDependency(dependencyIDs []int, first *int) []int {
+	ids := make([]int, 0, len(dependencyIDs)+1)
+	if first != nil {
+		ids = append(ids, *first)
+	}
+	if len(dependencyIDs) > 0 {
+		ids = append(ids, dependencyIDs[1:]...)
+	}
+	return ids
 }
 
-func (s *FeatureService) ensureDependencyCompleted(projectID int, dependsOnFeatureID *int, targetStatus string) error {
-	if dependsOnFeatureID == nil || !dependencyBlocksStatus(targetStatus) {
+// ensureDependenciesCompleted rejects starting a feature while a feature it depends on is still open,
+// unless the project settings allow it. Dependencies in the trash are ignored.
+func (s *FeatureService) ensureDependenciesCompleted(projectID int, dependencyIDs []int, oldStatus, targetStatus string) error {
+	if len(dependencyIDs) == 0 || !dependencyBlocksTransition(oldStatus, targetStatus) {
 		return nil
 	}
 
-	dependency, err := s.featureRepo.GetByID(*dependsOnFeatureID)
+	settings, err := s.settingService.GetProjectSettings(projectID)
 	if err != nil {
 		return err
 	}
-	if dependency == nil {
-		return errors.New("dependency feature not found")
+	if settings.AllowOpenDependencies {
+		return nil
 	}
-	if dependency.ProjectID != projectID {
-		return errors.New("dependency feature belongs to a different project")
+
+	dependencies, err := s.featureRepo.GetByIDs(dependencyIDs)
+	if err != nil {
+		return err
 	}
-	if dependency.Status != FeatureStatusCompleted {
-		return errors.New("dependency feature must be completed before this feature can be started")
+	if dependency := firstOpenDependency(projectID, dependencies); dependency != nil {
+		return errors.New("dependency feature " + dependency.RefNum + " must be completed before this feature can be started")
 	}
+	return nil
+}
 
+// firstOpenDependency returns the first dependency of the project that is not completed or closed
+func firstOpenDependency(projectID int, dependencies []Feature) *Feature {
+	for i := range dependencies {
+		if dependencies[i].ProjectID == projectID && !dependencyResolved(dependencies[i].Status) {
+			return &dependencies[i]
+		}
+	}
 	return nil
 }
 
 // CreateFeature creates a new feature
-func (s *FeatureService) CreateFeature(projectID int, title, description string, priority string, assignedTo int, sprintID int, points int, deadline *time.Time, releaseID *int, dependsOnFeatureID *int, itemType string, itemID *int, tags string, cascadeCompletion bool, createdBy int) (*Feature, error) {
+func (s *FeatureService) CreateFeature(projectID int, title, description string, priority string, assignedTo int, sprintID int, points int, deadline *time.Time, releaseID *int, dependsOnFeatureIDs []int, itemType string, itemID *int, tags string, cascadeCompletion bool, customFields custom_fields.Values, createdBy int) (*Feature, error) {
 	// Validate project exists
 	project, err := s.projectRepo.GetByID(projectID)
 	if err != nil {
@@ -126,13 +207,17 @@ func (s *FeatureService) CreateFeature(projectID int, title, description string,
 		return nil, errors.New("project not found")
 	}
 
-	// Check if user can modify project items.
-	canWrite, err := s.memberRepo.CanUserWriteProject(projectID, createdBy)
+	if err := project.EnsureWritable(); err != nil {
+		return nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(projectID, createdBy, projects.PermissionItemFeature, projects.PermissionActionCreate)
 	if err != nil {
 		return nil, err
 	}
-	if !canWrite {
-		return nil, errors.New("project users can only read project items")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemFeature, projects.PermissionActionCreate)
 	}
 
 	description = htmlsanitizer.Sanitize(description)
@@ -142,7 +227,11 @@ func (s *FeatureService) CreateFeature(projectID int, title, description string,
 		return nil, errors.New("invalid priority")
 	}
 	if priority == "" {
-		priority = FeaturePriorityNormal
+		settings, err := s.settingService.GetProjectSettings(projectID)
+		if err != nil {
+			return nil, err
+		}
+		priority = settings.DefaultPriority
 	}
 
 	// Validate assignee is a member if provided
@@ -156,7 +245,13 @@ func (s *FeatureService) CreateFeature(projectID int, title, description string,
 		}
 	}
 
-	if err := s.validateDependency(projectID, 0, dependsOnFeatureID); err != nil {
+	dependsOnFeatureIDs, err = s.validateDependencies(projectID, 0, dependsOnFeatureIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	pendingFields, err := s.customFieldService.PrepareValues(projectID, projects.PermissionItemFeature, 0, customFields)
+	if err != nil {
 		return nil, err
 	}
 
@@ -184,25 +279,24 @@ func (s *FeatureService) CreateFeature(projectID int, title, description string,
 
 	now := time.Now()
 	feature := &Feature{
-		RefNum:             refNum,
-		ProjectID:          projectID,
-		Title:              title,
-		Description:        description,
-		Status:             initialStatus,
-		Priority:           priority,
-		AssignedTo:         assignedTo,
-		SprintID:           sprintID,
-		Points:             points,
-		Deadline:           deadline,
-		ReleaseID:          releaseID,
-		DependsOnFeatureID: dependsOnFeatureID,
-		ItemType:           itemType,
-		ItemID:             itemID,
-		Tags:               tags,
-		CascadeCompletion:  cascadeCompletion,
-		CreatedBy:          createdBy,
-		CreatedAt:          now,
-		UpdatedAt:          now,
+		RefNum:            refNum,
+		ProjectID:         projectID,
+		Title:             title,
+		Description:       description,
+		Status:            initialStatus,
+		Priority:          priority,
+		AssignedTo:        assignedTo,
+		SprintID:          sprintID,
+		Points:            points,
+		Deadline:          deadline,
+		ReleaseID:         releaseID,
+		ItemType:          itemType,
+		ItemID:            itemID,
+		Tags:              tags,
+		CascadeCompletion: cascadeCompletion,
+		CreatedBy:         createdBy,
+		CreatedAt:         now,
+		UpdatedAt:         now,
 	}
 
 	// Create a new repository instance with the transaction UOW
@@ -212,15 +306,33 @@ func (s *FeatureService) CreateFeature(projectID int, title, description string,
 		return nil, err
 	}
 
+	if err := s.customFieldService.SaveValues(uow, pendingFields, feature.ID, createdBy); err != nil {
+		return nil, err
+	}
+	txLinkRepo := item_links.NewItemLinkRepository(uow)
+	if err := txLinkRepo.SetParent(feature.ProjectID, projects.PermissionItemFeature, feature.ID, feature.ItemType, feature.ItemID, createdBy); err != nil {
+		return nil, err
+	}
+	if err := txLinkRepo.SetDependencies(feature.ProjectID, feature.ID, dependsOnFeatureIDs, createdBy); err != nil {
+		return nil, err
+	}
+	if feature.DependsOnFeatureID, err = txLinkRepo.SyncDependency(feature.ID); err != nil {
+		return nil, err
+	}
+
 	if err := uow.CommitTransaction(); err != nil {
 		return nil, err
 	}
 
+	if err := s.loadDetails(feature); err != nil {
+		return nil, err
+	}
 	return feature, nil
 }
 
-// UpdateFeature updates a feature's details
-func (s *FeatureService) UpdateFeature(featureID int, title, description string, priority string, assignedTo int, sprintID int, points int, deadline *time.Time, releaseID *int, dependsOnFeatureID *int, itemType string, itemID *int, tags string, cascadeCompletion bool, updatedBy int) (*Feature, error) {
+// UpdateFeature updates a feature's details. A nil dependsOnFeatureIDs keeps the dependencies and only
+// replaces the oldest one with dependsOnFeatureID, as sent by clients knowing a single dependency.
+func (s *FeatureService) UpdateFeature(featureID int, title, description string, priority string, assignedTo int, sprintID int, points int, deadline *time.Time, releaseID *int, dependsOnFeatureIDs []int, dependsOnFeatureID *int, itemType string, itemID *int, tags string, cascadeCompletion bool, customFields custom_fields.Values, updatedBy int) (*Feature, error) {
 	feature, err := s.featureRepo.GetByID(featureID)
 	if err != nil {
 		return nil, err
@@ -229,13 +341,17 @@ func (s *FeatureService) UpdateFeature(featureID int, title, description string,
 		return nil, errors.New("feature not found")
 	}
 
-	// Check if user can modify project items.
-	canWrite, err := s.memberRepo.CanUserWriteProject(feature.ProjectID, updatedBy)
+	if err := s.projectRepo.EnsureWritable(feature.ProjectID); err != nil {
+		return nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(feature.ProjectID, updatedBy, projects.PermissionItemFeature, projects.PermissionActionEdit)
 	if err != nil {
 		return nil, err
 	}
-	if !canWrite {
-		return nil, errors.New("project users can only read project items")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemFeature, projects.PermissionActionEdit)
 	}
 
 	oldStatus := feature.Status
@@ -257,7 +373,16 @@ func (s *FeatureService) UpdateFeature(featureID int, title, description string,
 		}
 	}
 
-	if err := s.validateDependency(feature.ProjectID, featureID, dependsOnFeatureID); err != nil {
+	replaceFirst := dependsOnFeatureIDs == nil
+	if replaceFirst {
+		current, err := s.linkRepo.GetDependencyIDs(featureID)
+		if err != nil {
+			return nil, err
+		}
+		dependsOnFeatureIDs = replaceFirstDependency(current, dependsOnFeatureID)
+	}
+	dependsOnFeatureIDs, err = s.validateDependencies(feature.ProjectID, featureID, dependsOnFeatureIDs)
+	if err != nil {
 		return nil, err
 	}
 
@@ -270,7 +395,12 @@ func (s *FeatureService) UpdateFeature(featureID int, title, description string,
 	if err := s.statusRepo.ValidateTransition(feature.ProjectID, status_changes.ItemTypeFeature, oldStatus, newStatus); err != nil {
 		return nil, err
 	}
-	if err := s.ensureDependencyCompleted(feature.ProjectID, dependsOnFeatureID, newStatus); err != nil {
+	if err := s.ensureDependenciesCompleted(feature.ProjectID, dependsOnFeatureIDs, oldStatus, newStatus); err != nil {
+		return nil, err
+	}
+
+	pendingFields, err := s.customFieldService.PrepareValues(feature.ProjectID, projects.PermissionItemFeature, feature.ID, customFields)
+	if err != nil {
 		return nil, err
 	}
 
@@ -281,7 +411,6 @@ func (s *FeatureService) UpdateFeature(featureID int, title, description string,
 	feature.Points = points
 	feature.Deadline = deadline
 	feature.ReleaseID = releaseID
-	feature.DependsOnFeatureID = dependsOnFeatureID
 	feature.ItemType = itemType
 	feature.ItemID = itemID
 	feature.Tags = tags
@@ -290,7 +419,34 @@ func (s *FeatureService) UpdateFeature(featureID int, title, description string,
 	feature.Status = newStatus
 	feature.AssignedTo = assignedTo
 
-	if err := s.featureRepo.Update(feature); err != nil {
+	uow := s.uowFactory.NewUnitOfWork()
+	if err := uow.BeginTransaction(); err != nil {
+		return nil, err
+	}
+	defer uow.RollbackTransactionIfError()
+
+	if err := NewFeatureRepository(uow).Update(feature); err != nil {
+		return nil, err
+	}
+	if err := s.customFieldService.SaveValues(uow, pendingFields, feature.ID, updatedBy); err != nil {
+		return nil, err
+	}
+	txLinkRepo := item_links.NewItemLinkRepository(uow)
+	if err := txLinkRepo.SetParent(feature.ProjectID, projects.PermissionItemFeature, feature.ID, feature.ItemType, feature.ItemID, updatedBy); err != nil {
+		return nil, err
+	}
+	if replaceFirst {
+		err = txLinkRepo.ReplaceFirstDependency(feature.ProjectID, feature.ID, dependsOnFeatureID, updatedBy)
+	} else {
+		err = txLinkRepo.SetDependencies(feature.ProjectID, feature.ID, dependsOnFeatureIDs, updatedBy)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if feature.DependsOnFeatureID, err = txLinkRepo.SyncDependency(feature.ID); err != nil {
+		return nil, err
+	}
+	if err := uow.CommitTransaction(); err != nil {
 		return nil, err
 	}
 
@@ -298,6 +454,9 @@ func (s *FeatureService) UpdateFeature(featureID int, title, description string,
 		return nil, err
 	}
 
+	if err := s.loadDetails(feature); err != nil {
+		return nil, err
+	}
 	return feature, nil
 }
 
@@ -315,20 +474,28 @@ func (s *FeatureService) UpdateFeatureStatus(featureID int, status string, updat
 		return nil, errors.New("feature not found")
 	}
 
-	// Check if user can modify project items.
-	canWrite, err := s.memberRepo.CanUserWriteProject(feature.ProjectID, updatedBy)
+	if err := s.projectRepo.EnsureWritable(feature.ProjectID); err != nil {
+		return nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(feature.ProjectID, updatedBy, projects.PermissionItemFeature, projects.PermissionActionChangeStatus)
 	if err != nil {
 		return nil, err
 	}
-	if !canWrite {
-		return nil, errors.New("project users can only read project items")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemFeature, projects.PermissionActionChangeStatus)
 	}
 
 	oldStatus := feature.Status
 	if err := s.statusRepo.ValidateTransition(feature.ProjectID, status_changes.ItemTypeFeature, oldStatus, status); err != nil {
 		return nil, err
 	}
-	if err := s.ensureDependencyCompleted(feature.ProjectID, feature.DependsOnFeatureID, status); err != nil {
+	dependencyIDs, err := s.linkRepo.GetDependencyIDs(feature.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureDependenciesCompleted(feature.ProjectID, dependencyIDs, oldStatus, status); err != nil {
 		return nil, err
 	}
 
@@ -367,13 +534,17 @@ func (s *FeatureService) UpdateFeatureAssignee(featureID int, assignedTo int, up
 		return nil, errors.New("feature not found")
 	}
 
-	// Check if user can modify project items.
-	canWrite, err := s.memberRepo.CanUserWriteProject(feature.ProjectID, updatedBy)
+	if err := s.projectRepo.EnsureWritable(feature.ProjectID); err != nil {
+		return nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(feature.ProjectID, updatedBy, projects.PermissionItemFeature, projects.PermissionActionEdit)
 	if err != nil {
 		return nil, err
 	}
-	if !canWrite {
-		return nil, errors.New("project users can only read project items")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemFeature, projects.PermissionActionEdit)
 	}
 
 	oldStatus := feature.Status
@@ -422,7 +593,7 @@ func (s *FeatureService) UpdateFeatureAssignee(featureID int, assignedTo int, up
 	return s.featureRepo.GetByID(featureID)
 }
 
-// DeleteFeature deletes a feature
+// DeleteFeature moves a feature to the project trash
 func (s *FeatureService) DeleteFeature(featureID int, deletedBy int) error {
 	feature, err := s.featureRepo.GetByID(featureID)
 	if err != nil {
@@ -432,13 +603,17 @@ func (s *FeatureService) DeleteFeature(featureID int, deletedBy int) error {
 		return errors.New("feature not found")
 	}
 
-	// Check if user can modify project items.
-	canWrite, err := s.memberRepo.CanUserWriteProject(feature.ProjectID, deletedBy)
+	if err := s.projectRepo.EnsureWritable(feature.ProjectID); err != nil {
+		return err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(feature.ProjectID, deletedBy, projects.PermissionItemFeature, projects.PermissionActionDelete)
 	if err != nil {
 		return err
 	}
-	if !canWrite {
-		return errors.New("project users can only read project items")
+	if !allowed {
+		return projects.PermissionDeniedError(projects.PermissionItemFeature, projects.PermissionActionDelete)
 	}
 
 	hasDependents, err := s.featureRepo.HasDependents(featureID)
@@ -449,7 +624,7 @@ func (s *FeatureService) DeleteFeature(featureID int, deletedBy int) error {
 		return errors.New("feature has dependent features and cannot be deleted")
 	}
 
-	return s.featureRepo.Delete(featureID)
+	return s.featureRepo.Delete(featureID, deletedBy)
 }
 
 // GetFeature retrieves a feature by ID
@@ -471,11 +646,15 @@ func (s *FeatureService) GetFeature(featureID int, requestedBy int) (*Feature, e
 		return nil, errors.New("user is not a member of this project")
 	}
 
+	if err := s.loadDetails(feature); err != nil {
+		return nil, err
+	}
 	return feature, nil
 }
 
-// GetProjectFeatures retrieves all features for a project with optional filters
-func (s *FeatureService) GetProjectFeatures(projectID int, statuses []string, priority string, search string, excludeFeatureID *int, dependencySelectable bool, selectedFeatureID *int, page, pageSize int, requestedBy int) ([]Feature, int64, error) {
+// GetProjectFeatures retrieves all features for a project with optional filters.
+// fieldFilters filter on custom field values by field key.
+func (s *FeatureService) GetProjectFeatures(projectID int, statuses []string, priority string, search string, excludeFeatureID *int, dependencySelectable bool, selectedFeatureID *int, fieldFilters map[string]string, page, pageSize int, requestedBy int) ([]Feature, int64, error) {
 	// Check if user is a member or admin of the project
 	isMember, err := s.memberRepo.IsUserMember(projectID, requestedBy)
 	if err != nil {
@@ -485,9 +664,17 @@ func (s *FeatureService) GetProjectFeatures(projectID int, statuses []string, pr
 		return nil, 0, errors.New("user is not a member of this project")
 	}
 
+	filters, err := s.customFieldService.ParseFilters(projectID, projects.PermissionItemFeature, fieldFilters)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	offset := (page - 1) * pageSize
 
-	if dependencySelectable || excludeFeatureID != nil {
+	var features []Feature
+	var total int64
+
+	if dependencySelectable || excludeFeatureID != nil || len(filters) > 0 {
 		for _, status := range statuses {
 			if !IsValidStatus(status) {
 				return nil, 0, errors.New("invalid status: " + status)
@@ -497,19 +684,16 @@ func (s *FeatureService) GetProjectFeatures(projectID int, statuses []string, pr
 			return nil, 0, errors.New("invalid priority")
 		}
 
-		return s.featureRepo.GetByProjectIDWithSelectorFilters(projectID, statuses, priority, search, excludeFeatureID, dependencySelectable, selectedFeatureID, offset, pageSize)
-	}
-
-	// Apply filters based on parameters
-	if len(statuses) == 1 && priority != "" {
+		features, total, err = s.featureRepo.GetByProjectIDWithSelectorFilters(projectID, statuses, priority, search, excludeFeatureID, dependencySelectable, selectedFeatureID, filters, offset, pageSize)
+	} else if len(statuses) == 1 && priority != "" {
 		// Single status + priority - need to combine manually
-		return s.getFeaturesByStatusAndPriority(projectID, statuses[0], priority, search, offset, pageSize)
+		features, total, err = s.getFeaturesByStatusAndPriority(projectID, statuses[0], priority, search, offset, pageSize)
 	} else if len(statuses) == 1 {
 		// Single status
 		if !IsValidStatus(statuses[0]) {
 			return nil, 0, errors.New("invalid status")
 		}
-		return s.featureRepo.GetByProjectIDAndStatus(projectID, statuses[0], search, offset, pageSize)
+		features, total, err = s.featureRepo.GetByProjectIDAndStatus(projectID, statuses[0], search, offset, pageSize)
 	} else if len(statuses) > 1 {
 		// Multiple statuses - validate each and use IN query
 		for _, status := range statuses {
@@ -517,12 +701,24 @@ func (s *FeatureService) GetProjectFeatures(projectID int, statuses []string, pr
 				return nil, 0, errors.New("invalid status: " + status)
 			}
 		}
-		return s.featureRepo.GetByProjectIDAndStatuses(projectID, statuses, search, offset, pageSize)
+		features, total, err = s.featureRepo.GetByProjectIDAndStatuses(projectID, statuses, search, offset, pageSize)
 	} else if priority != "" {
-		return s.featureRepo.GetByProjectIDAndPriority(projectID, priority, search, offset, pageSize)
+		features, total, err = s.featureRepo.GetByProjectIDAndPriority(projectID, priority, search, offset, pageSize)
+	} else {
+		features, total, err = s.featureRepo.GetByProjectID(projectID, search, offset, pageSize)
 	}
 
-	return s.featureRepo.GetByProjectID(projectID, search, offset, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := s.loadCustomFields(projectID, features); err != nil {
+		return nil, 0, err
+	}
+	if err := s.loadDependencies(features); err != nil {
+		return nil, 0, err
+	}
+	return features, total, nil
 }
 
 // getFeaturesByStatusAndPriority is a helper to filter by both status and priority
@@ -598,6 +794,10 @@ func (s *FeatureService) UpdateFeatureStatusByWorkflow(featureID int, status str
 		return errors.New("feature not found")
 	}
 
+	if err := s.projectRepo.EnsureWritable(feature.ProjectID); err != nil {
+		return err
+	}
+
 	if feature.Status == status {
 		return nil
 	}
@@ -606,7 +806,11 @@ func (s *FeatureService) UpdateFeatureStatusByWorkflow(featureID int, status str
 	if err := s.statusRepo.ValidateTransition(feature.ProjectID, status_changes.ItemTypeFeature, oldStatus, status); err != nil {
 		return err
 	}
-	if err := s.ensureDependencyCompleted(feature.ProjectID, feature.DependsOnFeatureID, status); err != nil {
+	dependencyIDs, err := s.linkRepo.GetDependencyIDs(feature.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.ensureDependenciesCompleted(feature.ProjectID, dependencyIDs, oldStatus, status); err != nil {
 		return err
 	}
 
@@ -631,3 +835,45 @@ func (s *FeatureService) UpdateFeatureStatusByWorkflow(featureID int, status str
 
 	return nil
 }
+
+// GetDependencyGraph returns the features of a project linked by dependencies, scheduled from today
+// with the critical path marked
+func (s *FeatureService) GetDependencyGraph(projectID int, requestedBy int) (*DependencyGraph, error) {
+	isMember, err := s.memberRepo.IsUserMember(projectID, requestedBy)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, errors.New("user is not a member of this project")
+	}
+
+	links, err := s.linkRepo.GetByProject(projectID, item_links.LinkTypeBlocks, projects.PermissionItemFeature, projects.PermissionItemFeature)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[int]bool)
+	var ids []int
+	for _, link := range links {
+		for _, id := range []int{link.SourceID, link.TargetID} {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	features, err := s.featureRepo.GetByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	estimatedHours, err := s.featureRepo.GetEstimatedHours(ids)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := s.settingService.GetProjectSettings(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return BuildDependencyGraph(features, links, estimatedHours, settings, settings.Today(time.Now()))
+}
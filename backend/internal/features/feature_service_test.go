@@ -0,0 +1,78 @@
+package features
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReplaceFirstDependency(t *testing.T) {
+	id := func(v int) *int { return &v }
+
+	tests := []struct {
+		name    string
+		current []int
+		first   *int
+		want    []int
+	}{
+		{name: "no dependencies", current: nil, first: id(4), want: []int{4}},
+		{name: "replace oldest", current: []int{1, 2, 3}, first: id(4), want: []int{4, 2, 3}},
+		{name: "keep oldest", current: []int{1, 2}, first: id(1), want: []int{1, 2}},
+		{name: "remove oldest", current: []int{1, 2, 3}, first: nil, want: []int{2, 3}},
+		{name: "nothing to remove", current: nil, first: nil, want: []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := replaceFirstDependency(tt.current, tt.first); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("replaceFirstDependency() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnsureDependenciesCompletedOnlyBlocksStarting(t *testing.T) {
+	// The service has no repositories, so any transition that needs the dependencies checked panics
+	service := &FeatureService{}
+	dependencyIDs := []int{1}
+
+	tests := []struct {
+		name      string
+		oldStatus string
+		newStatus string
+	}{
+		{name: "edit started feature", oldStatus: FeatureStatusInProgress, newStatus: FeatureStatusInProgress},
+		{name: "edit feature in review", oldStatus: FeatureStatusInReview, newStatus: FeatureStatusInReview},
+		{name: "assign feature", oldStatus: FeatureStatusOpen, newStatus: FeatureStatusAssigned},
+		{name: "send to review", oldStatus: FeatureStatusInProgress, newStatus: FeatureStatusInReview},
+		{name: "complete feature", oldStatus: FeatureStatusInReview, newStatus: FeatureStatusCompleted},
+		{name: "reopen feature", oldStatus: FeatureStatusCompleted, newStatus: FeatureStatusReopened},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := service.ensureDependenciesCompleted(1, dependencyIDs, tt.oldStatus, tt.newStatus); err != nil {
+				t.Fatalf("ensureDependenciesCompleted() error = %v, want nil", err)
+			}
+		})
+	}
+
+	if !dependencyBlocksTransition(FeatureStatusAssigned, FeatureStatusInProgress) {
+		t.Fatal("starting a feature does not check its dependencies")
+	}
+}
+
+func TestFirstOpenDependency(t *testing.T) {
+	dependencies := []Feature{
+		{ID: 1, ProjectID: 1, RefNum: "F-1", Status: FeatureStatusCompleted},
+		{ID: 2, ProjectID: 2, RefNum: "F-2", Status: FeatureStatusOpen}, // Other project
+		{ID: 3, ProjectID: 1, RefNum: "F-3", Status: FeatureStatusClosed},
+		{ID: 4, ProjectID: 1, RefNum: "F-4", Status: FeatureStatusReopened},
+	}
+
+	if got := firstOpenDependency(1, dependencies); got == nil || got.ID != 4 {
+		t.Fatalf("firstOpenDependency() = %+v, want feature 4", got)
+	}
+	if got := firstOpenDependency(1, dependencies[:3]); got != nil {
+		t.Fatalf("firstOpenDependency() = %+v, want nil", got)
+	}
+}
@@ -2,25 +2,31 @@ package ideas
 
 import (
 	"time"
+
+	"github.com/dannyswat/pjeasy/internal/custom_fields"
+	"gorm.io/gorm"
 )
 
 // Idea represents an idea in the system
 type Idea struct {
-	ID                int       `gorm:"primaryKey;autoIncrement" json:"id"`
-	RefNum            string    `gorm:"column:ref_num;not null;size:50;uniqueIndex:idx_project_refnum,composite:projectId" json:"refNum"`
-	ProjectID         int       `gorm:"not null;index;uniqueIndex:idx_project_refnum,composite:refNum" json:"projectId"`
-	Title             string    `gorm:"not null;size:255" json:"title"`
-	Label             string    `gorm:"size:100" json:"label,omitempty"`
-	Description       string    `gorm:"type:text" json:"description"`
-	Status            string    `gorm:"not null;size:50;default:'Open'" json:"status"`         // Open, Closed
-	ReleaseID         *int      `gorm:"index" json:"releaseId,omitempty"`                      // Target release
-	ItemType          string    `gorm:"size:50;index:idx_idea_item" json:"itemType,omitempty"` // Type of related item (e.g., "service-tickets")
-	ItemID            *int      `gorm:"index:idx_idea_item" json:"itemId,omitempty"`           // ID of related item
-	Tags              string    `gorm:"type:text" json:"tags,omitempty"`                       // Comma-separated tags
-	CascadeCompletion bool      `gorm:"default:false" json:"cascadeCompletion"`                // Auto-complete when all related tasks/features are completed
-	CreatedBy         int       `gorm:"not null;index" json:"createdBy"`
-	CreatedAt         time.Time `gorm:"not null" json:"createdAt"`
-	UpdatedAt         time.Time `gorm:"not null" json:"updatedAt"`
+	ID                int                  `gorm:"primaryKey;autoIncrement" json:"id"`
+	RefNum            string               `gorm:"column:ref_num;not null;size:50;uniqueIndex:idx_project_refnum,composite:projectId" json:"refNum"`
+	ProjectID         int                  `gorm:"not null;index;uniqueIndex:idx_project_refnum,composite:refNum" json:"projectId"`
+	Title             string               `gorm:"not null;size:255" json:"title"`
+	Label             string               `gorm:"size:100" json:"label,omitempty"`
+	Description       string               `gorm:"type:text" json:"description"`
+	Status            string               `gorm:"not null;size:50;default:'Open'" json:"status"`         // Open, Closed
+	ReleaseID         *int                 `gorm:"index" json:"releaseId,omitempty"`                      // Target release
+	ItemType          string               `gorm:"size:50;index:idx_idea_item" json:"itemType,omitempty"` // Type of related item (e.g., "service-tickets")
+	ItemID            *int                 `gorm:"index:idx_idea_item" json:"itemId,omitempty"`           // ID of related item
+	Tags              string               `gorm:"type:text" json:"tags,omitempty"`                       // Comma-separated tags
+	CascadeCompletion bool                 `gorm:"default:false" json:"cascadeCompletion"`                // Auto-complete when all related tasks/features are completed
+	CustomFields      custom_fields.Values `gorm:"-" json:"customFields,omitempty"`                       // Loaded by the service, stored in custom_field_values
+	CreatedBy         int                  `gorm:"not null;index" json:"createdBy"`
+	CreatedAt         time.Time            `gorm:"not null" json:"createdAt"`
+	UpdatedAt         time.Time            `gorm:"not null" json:"updatedAt"`
+	DeletedAt         gorm.DeletedAt       `gorm:"index" json:"-"` // Set while in the trash
+	DeletedBy         *int                 `json:"-"`
 }
 
 // TableName specifies the table name for GORM
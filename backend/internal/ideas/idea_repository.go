@@ -1,6 +1,10 @@
 package ideas
 
 import (
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/custom_fields"
+	"github.com/dannyswat/pjeasy/internal/item_links"
 	"github.com/dannyswat/pjeasy/internal/repositories"
 	"gorm.io/gorm"
 )
@@ -33,9 +37,14 @@ func (r *IdeaRepository) Update(idea *Idea) error {
 	return r.uow.GetDB().Save(idea).Error
 }
 
-// Delete deletes an idea
-func (r *IdeaRepository) Delete(id int) error {
-	return r.uow.GetDB().Delete(&Idea{}, id).Error
+// Delete moves an idea to the trash. Trashed rows are excluded from every query until restored.
+func (r *IdeaRepository) Delete(id int, deletedBy int) error {
+	return r.uow.GetDB().Model(&Idea{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"deleted_at": time.Now(),
+			"deleted_by": deletedBy,
+		}).Error
 }
 
 // GetByProjectID returns all ideas for a project with pagination
@@ -103,6 +112,33 @@ func (r *IdeaRepository) GetByProjectIDAndStatuses(projectID int, statuses []str
 	return ideas, total, err
 }
 
+// GetByProjectIDWithFieldFilters returns ideas filtered by custom field values and optionally by statuses
+func (r *IdeaRepository) GetByProjectIDWithFieldFilters(projectID int, statuses []string, filters []custom_fields.FieldFilter, offset, limit int) ([]Idea, int64, error) {
+	var ideas []Idea
+	var total int64
+
+	filtered := func() *gorm.DB {
+		query := r.uow.GetDB().Model(&Idea{}).Where("ideas.project_id = ?", projectID)
+		if len(statuses) > 0 {
+			query = query.Where("ideas.status IN ?", statuses)
+		}
+		return custom_fields.ApplyFilters(query, "ideas", filters)
+	}
+
+	// Get total count
+	if err := filtered().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Get paginated results
+	err := filtered().Order("ideas.created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&ideas).Error
+
+	return ideas, total, err
+}
+
 // UpdateStatus updates only the status of an idea
 func (r *IdeaRepository) UpdateStatus(id int, status string) error {
 	return r.uow.GetDB().Model(&Idea{}).
@@ -115,8 +151,7 @@ func (r *IdeaRepository) GetByItemReference(projectID int, itemType string, item
 	var ideas []Idea
 	var total int64
 
-	query := r.uow.GetDB().Model(&Idea{}).
-		Where("project_id = ? AND item_type = ? AND item_id = ?", projectID, itemType, itemID)
+	query := item_links.ChildrenOf(r.uow.GetDB().Model(&Idea{}).Where("ideas.project_id = ?", projectID), "ideas", itemType, itemID)
 
 	// Get total count
 	if err := query.Count(&total).Error; err != nil {
@@ -124,7 +159,7 @@ func (r *IdeaRepository) GetByItemReference(projectID int, itemType string, item
 	}
 
 	// Get paginated results
-	err := query.Order("created_at DESC").
+	err := query.Order("ideas.created_at DESC").
 		Offset(offset).
 		Limit(limit).
 		Find(&ideas).Error
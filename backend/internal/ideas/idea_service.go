@@ -5,7 +5,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/dannyswat/pjeasy/internal/custom_fields"
 	"github.com/dannyswat/pjeasy/internal/htmlsanitizer"
+	"github.com/dannyswat/pjeasy/internal/item_links"
 	"github.com/dannyswat/pjeasy/internal/projects"
 	"github.com/dannyswat/pjeasy/internal/repositories"
 	"github.com/dannyswat/pjeasy/internal/sequences"
@@ -17,27 +19,45 @@ func normalizeIdeaLabel(label string) string {
 }
 
 type IdeaService struct {
-	ideaRepo     *IdeaRepository
-	memberRepo   *projects.ProjectMemberRepository
-	projectRepo  *projects.ProjectRepository
-	sequenceRepo *sequences.SequenceRepository
-	statusRepo   *status_changes.StatusChangeService
-	uowFactory   *repositories.UnitOfWorkFactory
+	ideaRepo           *IdeaRepository
+	memberRepo         *projects.ProjectMemberRepository
+	projectRepo        *projects.ProjectRepository
+	sequenceRepo       *sequences.SequenceRepository
+	statusRepo         *status_changes.StatusChangeService
+	customFieldService *custom_fields.CustomFieldService
+	uowFactory         *repositories.UnitOfWorkFactory
 }
 
-func NewIdeaService(ideaRepo *IdeaRepository, memberRepo *projects.ProjectMemberRepository, projectRepo *projects.ProjectRepository, sequenceRepo *sequences.SequenceRepository, statusRepo *status_changes.StatusChangeService, uowFactory *repositories.UnitOfWorkFactory) *IdeaService {
+func NewIdeaService(ideaRepo *IdeaRepository, memberRepo *projects.ProjectMemberRepository, projectRepo *projects.ProjectRepository, sequenceRepo *sequences.SequenceRepository, statusRepo *status_changes.StatusChangeService, customFieldService *custom_fields.CustomFieldService, uowFactory *repositories.UnitOfWorkFactory) *IdeaService {
 	return &IdeaService{
-		ideaRepo:     ideaRepo,
-		memberRepo:   memberRepo,
-		projectRepo:  projectRepo,
-		sequenceRepo: sequenceRepo,
-		statusRepo:   statusRepo,
-		uowFactory:   uowFactory,
+		ideaRepo:           ideaRepo,
+		memberRepo:         memberRepo,
+		projectRepo:        projectRepo,
+		sequenceRepo:       sequenceRepo,
+		statusRepo:         statusRepo,
+		customFieldService: customFieldService,
+		uowFactory:         uowFactory,
 	}
 }
 
+// loadCustomFields fills in the custom field values of the ideas of a project
+func (s *IdeaService) loadCustomFields(projectID int, ideas []Idea) error {
+	ids := make([]int, len(ideas))
+	for i := range ideas {
+		ids[i] = ideas[i].ID
+	}
+	values, err := s.customFieldService.GetValuesForItems(projectID, projects.PermissionItemIdea, ids)
+	if err != nil {
+		return err
+	}
+	for i := range ideas {
+		ideas[i].CustomFields = values[ideas[i].ID]
+	}
+	return nil
+}
+
 // CreateIdea creates a new idea
-func (s *IdeaService) CreateIdea(projectID int, title, label, description string, releaseID *int, itemType string, itemID *int, tags string, cascadeCompletion bool, createdBy int) (*Idea, error) {
+func (s *IdeaService) CreateIdea(projectID int, title, label, description string, releaseID *int, itemType string, itemID *int, tags string, cascadeCompletion bool, customFields custom_fields.Values, createdBy int) (*Idea, error) {
 	// Validate project exists
 	project, err := s.projectRepo.GetByID(projectID)
 	if err != nil {
@@ -47,18 +67,27 @@ func (s *IdeaService) CreateIdea(projectID int, title, label, description string
 		return nil, errors.New("project not found")
 	}
 
-	// Check if user can modify project items.
-	canWrite, err := s.memberRepo.CanUserWriteProject(projectID, createdBy)
+	if err := project.EnsureWritable(); err != nil {
+		return nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(projectID, createdBy, projects.PermissionItemIdea, projects.PermissionActionCreate)
 	if err != nil {
 		return nil, err
 	}
-	if !canWrite {
-		return nil, errors.New("project users can only read project items")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemIdea, projects.PermissionActionCreate)
 	}
 
 	description = htmlsanitizer.Sanitize(description)
 	label = normalizeIdeaLabel(label)
 
+	pendingFields, err := s.customFieldService.PrepareValues(projectID, projects.PermissionItemIdea, 0, customFields)
+	if err != nil {
+		return nil, err
+	}
+
 	uow := s.uowFactory.NewUnitOfWork()
 	// Begin transaction to generate RefNum and create idea
 	if err := uow.BeginTransaction(); err != nil {
@@ -102,15 +131,26 @@ func (s *IdeaService) CreateIdea(projectID int, title, label, description string
 		return nil, err
 	}
 
+	if err := s.customFieldService.SaveValues(uow, pendingFields, idea.ID, createdBy); err != nil {
+		return nil, err
+	}
+	if err := item_links.NewItemLinkRepository(uow).SetParent(idea.ProjectID, projects.PermissionItemIdea, idea.ID, idea.ItemType, idea.ItemID, createdBy); err != nil {
+		return nil, err
+	}
+
 	if err := uow.CommitTransaction(); err != nil {
 		return nil, err
 	}
 
+	idea.CustomFields, err = s.customFieldService.GetValues(projectID, projects.PermissionItemIdea, idea.ID)
+	if err != nil {
+		return nil, err
+	}
 	return idea, nil
 }
 
 // UpdateIdea updates an idea's details
-func (s *IdeaService) UpdateIdea(ideaID int, title, label, description string, releaseID *int, tags string, cascadeCompletion bool, updatedBy int) (*Idea, error) {
+func (s *IdeaService) UpdateIdea(ideaID int, title, label, description string, releaseID *int, tags string, cascadeCompletion bool, customFields custom_fields.Values, updatedBy int) (*Idea, error) {
 	idea, err := s.ideaRepo.GetByID(ideaID)
 	if err != nil {
 		return nil, err
@@ -119,17 +159,26 @@ func (s *IdeaService) UpdateIdea(ideaID int, title, label, description string, r
 		return nil, errors.New("idea not found")
 	}
 
-	// Check if user can modify project items.
-	canWrite, err := s.memberRepo.CanUserWriteProject(idea.ProjectID, updatedBy)
+	if err := s.projectRepo.EnsureWritable(idea.ProjectID); err != nil {
+		return nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(idea.ProjectID, updatedBy, projects.PermissionItemIdea, projects.PermissionActionEdit)
 	if err != nil {
 		return nil, err
 	}
-	if !canWrite {
-		return nil, errors.New("project users can only read project items")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemIdea, projects.PermissionActionEdit)
 	}
 
 	description = htmlsanitizer.Sanitize(description)
 
+	pendingFields, err := s.customFieldService.PrepareValues(idea.ProjectID, projects.PermissionItemIdea, idea.ID, customFields)
+	if err != nil {
+		return nil, err
+	}
+
 	idea.Title = title
 	idea.Label = normalizeIdeaLabel(label)
 	idea.Description = description
@@ -138,10 +187,26 @@ func (s *IdeaService) UpdateIdea(ideaID int, title, label, description string, r
 	idea.CascadeCompletion = cascadeCompletion
 	idea.UpdatedAt = time.Now()
 
-	if err := s.ideaRepo.Update(idea); err != nil {
+	uow := s.uowFactory.NewUnitOfWork()
+	if err := uow.BeginTransaction(); err != nil {
+		return nil, err
+	}
+	defer uow.RollbackTransactionIfError()
+
+	if err := NewIdeaRepository(uow).Update(idea); err != nil {
+		return nil, err
+	}
+	if err := s.customFieldService.SaveValues(uow, pendingFields, idea.ID, updatedBy); err != nil {
+		return nil, err
+	}
+	if err := uow.CommitTransaction(); err != nil {
 		return nil, err
 	}
 
+	idea.CustomFields, err = s.customFieldService.GetValues(idea.ProjectID, projects.PermissionItemIdea, idea.ID)
+	if err != nil {
+		return nil, err
+	}
 	return idea, nil
 }
 
@@ -159,13 +224,17 @@ func (s *IdeaService) UpdateIdeaStatus(ideaID int, status string, updatedBy int)
 		return nil, errors.New("idea not found")
 	}
 
-	// Check if user can modify project items.
-	canWrite, err := s.memberRepo.CanUserWriteProject(idea.ProjectID, updatedBy)
+	if err := s.projectRepo.EnsureWritable(idea.ProjectID); err != nil {
+		return nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(idea.ProjectID, updatedBy, projects.PermissionItemIdea, projects.PermissionActionChangeStatus)
 	if err != nil {
 		return nil, err
 	}
-	if !canWrite {
-		return nil, errors.New("project users can only read project items")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemIdea, projects.PermissionActionChangeStatus)
 	}
 
 	oldStatus := idea.Status
@@ -200,6 +269,10 @@ func (s *IdeaService) UpdateIdeaStatusByWorkflow(ideaID int, status string) erro
 		return errors.New("idea not found")
 	}
 
+	if err := s.projectRepo.EnsureWritable(idea.ProjectID); err != nil {
+		return err
+	}
+
 	if idea.Status == status {
 		return nil
 	}
@@ -216,7 +289,7 @@ func (s *IdeaService) UpdateIdeaStatusByWorkflow(ideaID int, status string) erro
 	return s.statusRepo.LogChange(idea.ProjectID, status_changes.ItemTypeIdea, idea.ID, oldStatus, status, nil)
 }
 
-// DeleteIdea deletes an idea
+// DeleteIdea moves an idea to the project trash
 func (s *IdeaService) DeleteIdea(ideaID int, deletedBy int) error {
 	idea, err := s.ideaRepo.GetByID(ideaID)
 	if err != nil {
@@ -226,16 +299,20 @@ func (s *IdeaService) DeleteIdea(ideaID int, deletedBy int) error {
 		return errors.New("idea not found")
 	}
 
-	// Check if user can modify project items.
-	canWrite, err := s.memberRepo.CanUserWriteProject(idea.ProjectID, deletedBy)
+	if err := s.projectRepo.EnsureWritable(idea.ProjectID); err != nil {
+		return err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(idea.ProjectID, deletedBy, projects.PermissionItemIdea, projects.PermissionActionDelete)
 	if err != nil {
 		return err
 	}
-	if !canWrite {
-		return errors.New("project users can only read project items")
+	if !allowed {
+		return projects.PermissionDeniedError(projects.PermissionItemIdea, projects.PermissionActionDelete)
 	}
 
-	return s.ideaRepo.Delete(ideaID)
+	return s.ideaRepo.Delete(ideaID, deletedBy)
 }
 
 // GetIdea retrieves a single idea
@@ -257,11 +334,16 @@ func (s *IdeaService) GetIdea(ideaID int, userID int) (*Idea, error) {
 		return nil, errors.New("user is not a member of this project")
 	}
 
+	idea.CustomFields, err = s.customFieldService.GetValues(idea.ProjectID, projects.PermissionItemIdea, idea.ID)
+	if err != nil {
+		return nil, err
+	}
 	return idea, nil
 }
 
-// GetProjectIdeas retrieves all ideas for a project with pagination and optional status filter
-func (s *IdeaService) GetProjectIdeas(projectID int, statuses []string, page, pageSize int, userID int) ([]Idea, int64, error) {
+// GetProjectIdeas retrieves all ideas for a project with pagination and optional status filter.
+// fieldFilters filter on custom field values by field key.
+func (s *IdeaService) GetProjectIdeas(projectID int, statuses []string, fieldFilters map[string]string, page, pageSize int, userID int) ([]Idea, int64, error) {
 	// Validate project exists
 	project, err := s.projectRepo.GetByID(projectID)
 	if err != nil {
@@ -285,7 +367,19 @@ func (s *IdeaService) GetProjectIdeas(projectID int, statuses []string, page, pa
 	var ideas []Idea
 	var total int64
 
-	if len(statuses) == 1 {
+	filters, err := s.customFieldService.ParseFilters(projectID, projects.PermissionItemIdea, fieldFilters)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(filters) > 0 {
+		for _, status := range statuses {
+			if !IsValidStatus(status) {
+				return nil, 0, errors.New("invalid status: " + status)
+			}
+		}
+		ideas, total, err = s.ideaRepo.GetByProjectIDWithFieldFilters(projectID, statuses, filters, offset, pageSize)
+	} else if len(statuses) == 1 {
 		// Single status
 		if !IsValidStatus(statuses[0]) {
 			return nil, 0, errors.New("invalid status")
@@ -307,6 +401,9 @@ func (s *IdeaService) GetProjectIdeas(projectID int, statuses []string, page, pa
 		return nil, 0, err
 	}
 
+	if err := s.loadCustomFields(projectID, ideas); err != nil {
+		return nil, 0, err
+	}
 	return ideas, total, nil
 }
 
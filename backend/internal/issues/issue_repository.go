@@ -1,6 +1,10 @@
 package issues
 
 import (
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/custom_fields"
+	"github.com/dannyswat/pjeasy/internal/item_links"
 	"github.com/dannyswat/pjeasy/internal/repositories"
 	"gorm.io/gorm"
 )
@@ -15,7 +19,7 @@ func NewIssueRepository(uow *repositories.UnitOfWork) *IssueRepository {
 
 func withIssueLinkedIdeaLabel(query *gorm.DB) *gorm.DB {
 	return query.
-		Joins("LEFT JOIN ideas linked_ideas ON issues.item_type = ? AND issues.item_id = linked_ideas.id", "ideas").
+		Joins("LEFT JOIN ideas linked_ideas ON issues.item_type = ? AND issues.item_id = linked_ideas.id AND linked_ideas.deleted_at IS NULL", "ideas").
 		Select("issues.*, linked_ideas.label AS linked_idea_label")
 }
 
@@ -39,9 +43,14 @@ func (r *IssueRepository) Update(issue *Issue) error {
 	return r.uow.GetDB().Save(issue).Error
 }
 
-// Delete deletes an issue
-func (r *IssueRepository) Delete(id int) error {
-	return r.uow.GetDB().Delete(&Issue{}, id).Error
+// Delete moves an issue to the trash. Trashed rows are excluded from every query until restored.
+func (r *IssueRepository) Delete(id int, deletedBy int) error {
+	return r.uow.GetDB().Model(&Issue{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"deleted_at": time.Now(),
+			"deleted_by": deletedBy,
+		}).Error
 }
 
 // GetByProjectID returns all issues for a project with pagination
@@ -111,6 +120,36 @@ func (r *IssueRepository) GetByProjectIDAndStatuses(projectID int, statuses []st
 	return issues, total, err
 }
 
+// GetByProjectIDWithFieldFilters returns issues filtered by custom field values and optionally by statuses and priority
+func (r *IssueRepository) GetByProjectIDWithFieldFilters(projectID int, statuses []string, priority string, filters []custom_fields.FieldFilter, offset, limit int) ([]Issue, int64, error) {
+	var issues []Issue
+	var total int64
+
+	filtered := func() *gorm.DB {
+		query := r.uow.GetDB().Model(&Issue{}).Where("issues.project_id = ?", projectID)
+		if len(statuses) > 0 {
+			query = query.Where("issues.status IN ?", statuses)
+		}
+		if priority != "" {
+			query = query.Where("issues.priority = ?", priority)
+		}
+		return custom_fields.ApplyFilters(query, "issues", filters)
+	}
+
+	// Get total count
+	if err := filtered().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Get paginated results
+	err := withIssueLinkedIdeaLabel(filtered()).Order("issues.created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&issues).Error
+
+	return issues, total, err
+}
+
 // GetByProjectIDAndPriority returns issues filtered by priority with pagination
 func (r *IssueRepository) GetByProjectIDAndPriority(projectID int, priority string, offset, limit int) ([]Issue, int64, error) {
 	var issues []Issue
@@ -196,17 +235,18 @@ func (r *IssueRepository) GetByItemReference(projectID int, itemType string, ite
 	var issues []Issue
 	var total int64
 
-	query := r.uow.GetDB().Model(&Issue{}).
-		Where("issues.project_id = ? AND issues.item_type = ? AND issues.item_id = ?", projectID, itemType, itemID)
+	children := func() *gorm.DB {
+		query := r.uow.GetDB().Model(&Issue{}).Where("issues.project_id = ?", projectID)
+		return item_links.ChildrenOf(query, "issues", itemType, itemID)
+	}
 
 	// Get total count
-	if err := query.Count(&total).Error; err != nil {
+	if err := children().Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Get paginated results
-	err := withIssueLinkedIdeaLabel(r.uow.GetDB().Model(&Issue{}).
-		Where("issues.project_id = ? AND issues.item_type = ? AND issues.item_id = ?", projectID, itemType, itemID)).Order("issues.created_at DESC").
+	err := withIssueLinkedIdeaLabel(children()).Order("issues.created_at DESC").
 		Offset(offset).
 		Limit(limit).
 		Find(&issues).Error
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"time"
 
+	"github.com/dannyswat/pjeasy/internal/custom_fields"
 	"github.com/dannyswat/pjeasy/internal/htmlsanitizer"
+	"github.com/dannyswat/pjeasy/internal/item_links"
 	"github.com/dannyswat/pjeasy/internal/projects"
 	"github.com/dannyswat/pjeasy/internal/repositories"
 	"github.com/dannyswat/pjeasy/internal/sequences"
@@ -21,30 +23,50 @@ type IssueService struct {
 	issueRepo           *IssueRepository
 	memberRepo          *projects.ProjectMemberRepository
 	projectRepo         *projects.ProjectRepository
+	settingService      *projects.ProjectSettingService
 	sequenceRepo        *sequences.SequenceRepository
 	statusRepo          *status_changes.StatusChangeService
+	customFieldService  *custom_fields.CustomFieldService
 	uowFactory          *repositories.UnitOfWorkFactory
 	statusChangeHandler StatusChangeHandler
 }
 
-func NewIssueService(issueRepo *IssueRepository, memberRepo *projects.ProjectMemberRepository, projectRepo *projects.ProjectRepository, sequenceRepo *sequences.SequenceRepository, statusRepo *status_changes.StatusChangeService, uowFactory *repositories.UnitOfWorkFactory) *IssueService {
+func NewIssueService(issueRepo *IssueRepository, memberRepo *projects.ProjectMemberRepository, projectRepo *projects.ProjectRepository, settingService *projects.ProjectSettingService, sequenceRepo *sequences.SequenceRepository, statusRepo *status_changes.StatusChangeService, customFieldService *custom_fields.CustomFieldService, uowFactory *repositories.UnitOfWorkFactory) *IssueService {
 	return &IssueService{
-		issueRepo:    issueRepo,
-		memberRepo:   memberRepo,
-		projectRepo:  projectRepo,
-		sequenceRepo: sequenceRepo,
-		statusRepo:   statusRepo,
-		uowFactory:   uowFactory,
+		issueRepo:          issueRepo,
+		memberRepo:         memberRepo,
+		projectRepo:        projectRepo,
+		settingService:     settingService,
+		sequenceRepo:       sequenceRepo,
+		statusRepo:         statusRepo,
+		customFieldService: customFieldService,
+		uowFactory:         uowFactory,
 	}
 }
 
+// loadCustomFields fills in the custom field values of the issues of a project
+func (s *IssueService) loadCustomFields(projectID int, issues []Issue) error {
+	ids := make([]int, len(issues))
+	for i := range issues {
+		ids[i] = issues[i].ID
+	}
+	values, err := s.customFieldService.GetValuesForItems(projectID, projects.PermissionItemIssue, ids)
+	if err != nil {
+		return err
+	}
+	for i := range issues {
+		issues[i].CustomFields = values[issues[i].ID]
+	}
+	return nil
+}
+
 // SetStatusChangeHandler sets the handler for status change events
 func (s *IssueService) SetStatusChangeHandler(handler StatusChangeHandler) {
 	s.statusChangeHandler = handler
 }
 
 // CreateIssue creates a new issue
-func (s *IssueService) CreateIssue(projectID int, title, description string, priority string, assignedTo int, sprintID int, points int, releaseID *int, itemType string, itemID *int, tags string, cascadeCompletion bool, createdBy int) (*Issue, error) {
+func (s *IssueService) CreateIssue(projectID int, title, description string, priority string, assignedTo int, sprintID int, points int, releaseID *int, itemType string, itemID *int, tags string, cascadeCompletion bool, customFields custom_fields.Values, createdBy int) (*Issue, error) {
 	// Validate project exists
 	project, err := s.projectRepo.GetByID(projectID)
 	if err != nil {
@@ -54,13 +76,17 @@ func (s *IssueService) CreateIssue(projectID int, title, description string, pri
 		return nil, errors.New("project not found")
 	}
 
-	// Check if user can modify project items.
-	canWrite, err := s.memberRepo.CanUserWriteProject(projectID, createdBy)
+	if err := project.EnsureWritable(); err != nil {
+		return nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(projectID, createdBy, projects.PermissionItemIssue, projects.PermissionActionCreate)
 	if err != nil {
 		return nil, err
 	}
-	if !canWrite {
-		return nil, errors.New("project users can only read project items")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemIssue, projects.PermissionActionCreate)
 	}
 
 	description = htmlsanitizer.Sanitize(description)
@@ -70,7 +96,11 @@ func (s *IssueService) CreateIssue(projectID int, title, description string, pri
 		return nil, errors.New("invalid priority")
 	}
 	if priority == "" {
-		priority = IssuePriorityNormal
+		settings, err := s.settingService.GetProjectSettings(projectID)
+		if err != nil {
+			return nil, err
+		}
+		priority = settings.DefaultPriority
 	}
 
 	// Validate assignee is a member if provided
@@ -84,6 +114,11 @@ func (s *IssueService) CreateIssue(projectID int, title, description string, pri
 		}
 	}
 
+	pendingFields, err := s.customFieldService.PrepareValues(projectID, projects.PermissionItemIssue, 0, customFields)
+	if err != nil {
+		return nil, err
+	}
+
 	uow := s.uowFactory.NewUnitOfWork()
 	// Begin transaction to generate RefNum and create issue
 	if err := uow.BeginTransaction(); err != nil {
@@ -134,15 +169,26 @@ func (s *IssueService) CreateIssue(projectID int, title, description string, pri
 		return nil, err
 	}
 
+	if err := s.customFieldService.SaveValues(uow, pendingFields, issue.ID, createdBy); err != nil {
+		return nil, err
+	}
+	if err := item_links.NewItemLinkRepository(uow).SetParent(issue.ProjectID, projects.PermissionItemIssue, issue.ID, issue.ItemType, issue.ItemID, createdBy); err != nil {
+		return nil, err
+	}
+
 	if err := uow.CommitTransaction(); err != nil {
 		return nil, err
 	}
 
+	issue.CustomFields, err = s.customFieldService.GetValues(projectID, projects.PermissionItemIssue, issue.ID)
+	if err != nil {
+		return nil, err
+	}
 	return issue, nil
 }
 
 // UpdateIssue updates an issue's details
-func (s *IssueService) UpdateIssue(issueID int, title, description string, priority string, assignedTo int, sprintID int, points int, releaseID *int, itemType string, itemID *int, tags string, cascadeCompletion bool, updatedBy int) (*Issue, error) {
+func (s *IssueService) UpdateIssue(issueID int, title, description string, priority string, assignedTo int, sprintID int, points int, releaseID *int, itemType string, itemID *int, tags string, cascadeCompletion bool, customFields custom_fields.Values, updatedBy int) (*Issue, error) {
 	issue, err := s.issueRepo.GetByID(issueID)
 	if err != nil {
 		return nil, err
@@ -151,13 +197,17 @@ func (s *IssueService) UpdateIssue(issueID int, title, description string, prior
 		return nil, errors.New("issue not found")
 	}
 
-	// Check if user can modify project items.
-	canWrite, err := s.memberRepo.CanUserWriteProject(issue.ProjectID, updatedBy)
+	if err := s.projectRepo.EnsureWritable(issue.ProjectID); err != nil {
+		return nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(issue.ProjectID, updatedBy, projects.PermissionItemIssue, projects.PermissionActionEdit)
 	if err != nil {
 		return nil, err
 	}
-	if !canWrite {
-		return nil, errors.New("project users can only read project items")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemIssue, projects.PermissionActionEdit)
 	}
 
 	oldStatus := issue.Status
@@ -189,6 +239,11 @@ func (s *IssueService) UpdateIssue(issueID int, title, description string, prior
 		return nil, err
 	}
 
+	pendingFields, err := s.customFieldService.PrepareValues(issue.ProjectID, projects.PermissionItemIssue, issue.ID, customFields)
+	if err != nil {
+		return nil, err
+	}
+
 	issue.Title = title
 	issue.Description = description
 	issue.Priority = priority
@@ -203,7 +258,22 @@ func (s *IssueService) UpdateIssue(issueID int, title, description string, prior
 	issue.Status = newStatus
 	issue.AssignedTo = assignedTo
 
-	if err := s.issueRepo.Update(issue); err != nil {
+	uow := s.uowFactory.NewUnitOfWork()
+	if err := uow.BeginTransaction(); err != nil {
+		return nil, err
+	}
+	defer uow.RollbackTransactionIfError()
+
+	if err := NewIssueRepository(uow).Update(issue); err != nil {
+		return nil, err
+	}
+	if err := s.customFieldService.SaveValues(uow, pendingFields, issue.ID, updatedBy); err != nil {
+		return nil, err
+	}
+	if err := item_links.NewItemLinkRepository(uow).SetParent(issue.ProjectID, projects.PermissionItemIssue, issue.ID, issue.ItemType, issue.ItemID, updatedBy); err != nil {
+		return nil, err
+	}
+	if err := uow.CommitTransaction(); err != nil {
 		return nil, err
 	}
 
@@ -211,6 +281,10 @@ func (s *IssueService) UpdateIssue(issueID int, title, description string, prior
 		return nil, err
 	}
 
+	issue.CustomFields, err = s.customFieldService.GetValues(issue.ProjectID, projects.PermissionItemIssue, issue.ID)
+	if err != nil {
+		return nil, err
+	}
 	return issue, nil
 }
 
@@ -228,13 +302,17 @@ func (s *IssueService) UpdateIssueStatus(issueID int, status string, updatedBy i
 		return nil, errors.New("issue not found")
 	}
 
-	// Check if user can modify project items.
-	canWrite, err := s.memberRepo.CanUserWriteProject(issue.ProjectID, updatedBy)
+	if err := s.projectRepo.EnsureWritable(issue.ProjectID); err != nil {
+		return nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(issue.ProjectID, updatedBy, projects.PermissionItemIssue, projects.PermissionActionChangeStatus)
 	if err != nil {
 		return nil, err
 	}
-	if !canWrite {
-		return nil, errors.New("project users can only read project items")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemIssue, projects.PermissionActionChangeStatus)
 	}
 
 	// Capture old status before update
@@ -279,13 +357,17 @@ func (s *IssueService) UpdateIssueAssignee(issueID int, assignedTo int, updatedB
 		return nil, errors.New("issue not found")
 	}
 
-	// Check if user can modify project items.
-	canWrite, err := s.memberRepo.CanUserWriteProject(issue.ProjectID, updatedBy)
+	if err := s.projectRepo.EnsureWritable(issue.ProjectID); err != nil {
+		return nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(issue.ProjectID, updatedBy, projects.PermissionItemIssue, projects.PermissionActionEdit)
 	if err != nil {
 		return nil, err
 	}
-	if !canWrite {
-		return nil, errors.New("project users can only read project items")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemIssue, projects.PermissionActionEdit)
 	}
 
 	oldStatus := issue.Status
@@ -334,7 +416,7 @@ func (s *IssueService) UpdateIssueAssignee(issueID int, assignedTo int, updatedB
 	return s.issueRepo.GetByID(issueID)
 }
 
-// DeleteIssue deletes an issue
+// DeleteIssue moves an issue to the project trash
 func (s *IssueService) DeleteIssue(issueID int, deletedBy int) error {
 	issue, err := s.issueRepo.GetByID(issueID)
 	if err != nil {
@@ -344,16 +426,20 @@ func (s *IssueService) DeleteIssue(issueID int, deletedBy int) error {
 		return errors.New("issue not found")
 	}
 
-	// Check if user can modify project items.
-	canWrite, err := s.memberRepo.CanUserWriteProject(issue.ProjectID, deletedBy)
+	if err := s.projectRepo.EnsureWritable(issue.ProjectID); err != nil {
+		return err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(issue.ProjectID, deletedBy, projects.PermissionItemIssue, projects.PermissionActionDelete)
 	if err != nil {
 		return err
 	}
-	if !canWrite {
-		return errors.New("project users can only read project items")
+	if !allowed {
+		return projects.PermissionDeniedError(projects.PermissionItemIssue, projects.PermissionActionDelete)
 	}
 
-	return s.issueRepo.Delete(issueID)
+	return s.issueRepo.Delete(issueID, deletedBy)
 }
 
 // GetIssue retrieves a single issue
@@ -375,11 +461,16 @@ func (s *IssueService) GetIssue(issueID int, userID int) (*Issue, error) {
 		return nil, errors.New("user is not a member of this project")
 	}
 
+	issue.CustomFields, err = s.customFieldService.GetValues(issue.ProjectID, projects.PermissionItemIssue, issue.ID)
+	if err != nil {
+		return nil, err
+	}
 	return issue, nil
 }
 
-// GetProjectIssues retrieves all issues for a project with pagination and optional filters
-func (s *IssueService) GetProjectIssues(projectID int, statuses []string, priority string, page, pageSize int, userID int) ([]Issue, int64, error) {
+// GetProjectIssues retrieves all issues for a project with pagination and optional filters.
+// fieldFilters filter on custom field values by field key.
+func (s *IssueService) GetProjectIssues(projectID int, statuses []string, priority string, fieldFilters map[string]string, page, pageSize int, userID int) ([]Issue, int64, error) {
 	// Validate project exists
 	project, err := s.projectRepo.GetByID(projectID)
 	if err != nil {
@@ -403,7 +494,22 @@ func (s *IssueService) GetProjectIssues(projectID int, statuses []string, priori
 	var issues []Issue
 	var total int64
 
-	if len(statuses) == 1 {
+	filters, err := s.customFieldService.ParseFilters(projectID, projects.PermissionItemIssue, fieldFilters)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(filters) > 0 {
+		for _, status := range statuses {
+			if !IsValidStatus(status) {
+				return nil, 0, errors.New("invalid status: " + status)
+			}
+		}
+		if priority != "" && !IsValidPriority(priority) {
+			return nil, 0, errors.New("invalid priority")
+		}
+		issues, total, err = s.issueRepo.GetByProjectIDWithFieldFilters(projectID, statuses, priority, filters, offset, pageSize)
+	} else if len(statuses) == 1 {
 		// Single status - use existing method
 		if !IsValidStatus(statuses[0]) {
 			return nil, 0, errors.New("invalid status")
@@ -430,6 +536,9 @@ func (s *IssueService) GetProjectIssues(projectID int, statuses []string, priori
 		return nil, 0, err
 	}
 
+	if err := s.loadCustomFields(projectID, issues); err != nil {
+		return nil, 0, err
+	}
 	return issues, total, nil
 }
 
@@ -507,6 +616,10 @@ func (s *IssueService) UpdateIssueStatusByWorkflow(issueID int, status string) e
 		return errors.New("issue not found")
 	}
 
+	if err := s.projectRepo.EnsureWritable(issue.ProjectID); err != nil {
+		return err
+	}
+
 	if issue.Status == status {
 		return nil
 	}
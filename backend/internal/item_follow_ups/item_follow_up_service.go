@@ -21,6 +21,7 @@ type ItemFollowUpService struct {
 	followUpRepo *ItemFollowUpRepository
 	userRepo     *users.UserRepository
 	memberRepo   *projects.ProjectMemberRepository
+	projectRepo  *projects.ProjectRepository
 	ideaRepo     *ideas.IdeaRepository
 	issueRepo    *issues.IssueRepository
 	featureRepo  *features.FeatureRepository
@@ -30,11 +31,12 @@ type ItemFollowUpService struct {
 	reviewRepo   *reviews.ReviewRepository
 }
 
-func NewItemFollowUpService(followUpRepo *ItemFollowUpRepository, userRepo *users.UserRepository, memberRepo *projects.ProjectMemberRepository, ideaRepo *ideas.IdeaRepository, issueRepo *issues.IssueRepository, featureRepo *features.FeatureRepository, taskRepo *tasks.TaskRepository, ticketRepo *service_tickets.ServiceTicketRepository, wikiRepo *wiki_pages.WikiPageRepository, reviewRepo *reviews.ReviewRepository) *ItemFollowUpService {
+func NewItemFollowUpService(followUpRepo *ItemFollowUpRepository, userRepo *users.UserRepository, memberRepo *projects.ProjectMemberRepository, projectRepo *projects.ProjectRepository, ideaRepo *ideas.IdeaRepository, issueRepo *issues.IssueRepository, featureRepo *features.FeatureRepository, taskRepo *tasks.TaskRepository, ticketRepo *service_tickets.ServiceTicketRepository, wikiRepo *wiki_pages.WikiPageRepository, reviewRepo *reviews.ReviewRepository) *ItemFollowUpService {
 	return &ItemFollowUpService{
 		followUpRepo: followUpRepo,
 		userRepo:     userRepo,
 		memberRepo:   memberRepo,
+		projectRepo:  projectRepo,
 		ideaRepo:     ideaRepo,
 		issueRepo:    issueRepo,
 		featureRepo:  featureRepo,
@@ -75,6 +77,10 @@ func (s *ItemFollowUpService) CreateItemFollowUp(itemID int, itemType string, fo
 		return nil, err
 	}
 
+	if err := s.projectRepo.EnsureWritable(projectID); err != nil {
+		return nil, err
+	}
+
 	isMember, err := s.memberRepo.IsUserMember(projectID, userID)
 	if err != nil {
 		return nil, err
@@ -145,6 +151,10 @@ func (s *ItemFollowUpService) UpdateItemFollowUp(followUpID int, followUpDate ti
 		return nil, err
 	}
 
+	if err := s.projectRepo.EnsureWritable(projectID); err != nil {
+		return nil, err
+	}
+
 	isMember, err := s.memberRepo.IsUserMember(projectID, userID)
 	if err != nil {
 		return nil, err
@@ -190,6 +200,10 @@ func (s *ItemFollowUpService) DeleteItemFollowUp(followUpID int, userID int) err
 		return err
 	}
 
+	if err := s.projectRepo.EnsureWritable(projectID); err != nil {
+		return err
+	}
+
 	isMember, err := s.memberRepo.IsUserMember(projectID, userID)
 	if err != nil {
 		return err
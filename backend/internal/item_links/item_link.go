@@ -0,0 +1,151 @@
+package item_links
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/projects"
+)
+
+// Link types. Links are directional: the source blocks, relates to, duplicates or is a child of the target.
+const (
+	LinkTypeBlocks     = "blocks"
+	LinkTypeRelates    = "relates"
+	LinkTypeDuplicates = "duplicates"
+	LinkTypeChildOf    = "child-of"
+)
+
+// maxCycleCheckDepth limits how far blocks and child-of chains are followed when checking for cycles
+const maxCycleCheckDepth = 100
+
+// Link directions as seen from an item
+const (
+	DirectionOutgoing = "outgoing" // The item is the source of the link
+	DirectionIncoming = "incoming" // The item is the target of the link
+)
+
+// ItemLink is a typed link from one item to another item of the same project
+type ItemLink struct {
+	ID         int       `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProjectID  int       `gorm:"not null;index" json:"projectId"`
+	SourceType string    `gorm:"not null;size:50;uniqueIndex:idx_item_link,priority:1;index:idx_item_link_source,priority:1" json:"sourceType"` // idea, issue, feature, task or service-ticket
+	SourceID   int       `gorm:"not null;uniqueIndex:idx_item_link,priority:2;index:idx_item_link_source,priority:2" json:"sourceId"`
+	LinkType   string    `gorm:"not null;size:30;uniqueIndex:idx_item_link,priority:3" json:"linkType"`
+	TargetType string    `gorm:"not null;size:50;uniqueIndex:idx_item_link,priority:4;index:idx_item_link_target,priority:1" json:"targetType"`
+	TargetID   int       `gorm:"not null;uniqueIndex:idx_item_link,priority:5;index:idx_item_link_target,priority:2" json:"targetId"`
+	CreatedBy  *int      `json:"createdBy,omitempty"` // Empty for links migrated from item references
+	CreatedAt  time.Time `gorm:"not null" json:"createdAt"`
+}
+
+// TableName specifies the table name for GORM
+func (ItemLink) TableName() string {
+	return "item_links"
+}
+
+// itemTable describes how the items of a type are stored
+type itemTable struct {
+	itemType  string
+	table     string
+	spelling  string // Spelling of the type in ItemType references to items of the type
+	refColumn string // Empty when the type has no reference number
+	hasParent bool   // Has an ItemType/ItemID reference kept in sync with its child-of link
+}
+
+var itemTables = []itemTable{
+	{itemType: projects.PermissionItemIdea, table: "ideas", spelling: "ideas", refColumn: "ref_num", hasParent: true},
+	{itemType: projects.PermissionItemIssue, table: "issues", spelling: "issues", refColumn: "ref_num", hasParent: true},
+	{itemType: projects.PermissionItemFeature, table: "features", spelling: "features", refColumn: "ref_num", hasParent: true},
+	{itemType: projects.PermissionItemTask, table: "tasks", spelling: "tasks", hasParent: true},
+	{itemType: projects.PermissionItemServiceTicket, table: "service_tickets", spelling: "service-tickets", refColumn: "ref_num"},
+}
+
+func tableForType(itemType string) *itemTable {
+	for i := range itemTables {
+		if itemTables[i].itemType == itemType {
+			return &itemTables[i]
+		}
+	}
+	return nil
+}
+
+// NormalizeItemType returns the item type of a singular or plural spelling, such as issues for issue.
+// ok is false for types that cannot be linked.
+func NormalizeItemType(spelling string) (itemType string, ok bool) {
+	spelling = strings.ToLower(strings.TrimSpace(spelling))
+	for _, t := range itemTables {
+		if spelling == t.itemType || spelling == t.spelling {
+			return t.itemType, true
+		}
+	}
+	return "", false
+}
+
+// IsValidLinkType checks if the link type is supported
+func IsValidLinkType(linkType string) bool {
+	switch linkType {
+	case LinkTypeBlocks, LinkTypeRelates, LinkTypeDuplicates, LinkTypeChildOf:
+		return true
+	}
+	return false
+}
+
+// Label returns how a link reads from its source, or from its target when incoming is set
+func Label(linkType string, incoming bool) string {
+	switch linkType {
+	case LinkTypeBlocks:
+		if incoming {
+			return "blocked by"
+		}
+		return "blocks"
+	case LinkTypeRelates:
+		return "relates to"
+	case LinkTypeDuplicates:
+		if incoming {
+			return "duplicated by"
+		}
+		return "duplicates"
+	case LinkTypeChildOf:
+		if incoming {
+			return "parent of"
+		}
+		return "child of"
+	}
+	return linkType
+}
+
+// Validate checks the link and normalizes its item types
+func (l *ItemLink) Validate() error {
+	if !IsValidLinkType(l.LinkType) {
+		return errors.New("invalid link type")
+	}
+
+	var ok bool
+	if l.SourceType, ok = NormalizeItemType(l.SourceType); !ok {
+		return errors.New("invalid source item type")
+	}
+	if l.TargetType, ok = NormalizeItemType(l.TargetType); !ok {
+		return errors.New("invalid target item type")
+	}
+	if l.SourceID <= 0 || l.TargetID <= 0 {
+		return errors.New("invalid item ID")
+	}
+	if l.SourceType == l.TargetType && l.SourceID == l.TargetID {
+		return errors.New("an item cannot be linked to itself")
+	}
+	return nil
+}
+
+// isDependency checks if the link mirrors the DependsOnFeatureID of its target
+func (l *ItemLink) isDependency() bool {
+	return l.LinkType == LinkTypeBlocks && l.SourceType == projects.PermissionItemFeature && l.TargetType == projects.PermissionItemFeature
+}
+
+// ItemSummary identifies the item at the other end of a link
+type ItemSummary struct {
+	ItemType string `json:"itemType"`
+	ID       int    `json:"id"`
+	RefNum   string `json:"refNum,omitempty"`
+	Title    string `json:"title"`
+	Status   string `json:"status"`
+}
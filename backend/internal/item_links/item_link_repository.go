@@ -0,0 +1,426 @@
+package item_links
+
+import (
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/projects"
+	"github.com/dannyswat/pjeasy/internal/repositories"
+	"gorm.io/gorm"
+)
+
+type ItemLinkRepository struct {
+	uow *repositories.UnitOfWork
+}
+
+func NewItemLinkRepository(uow *repositories.UnitOfWork) *ItemLinkRepository {
+	return &ItemLinkRepository{uow: uow}
+}
+
+// ChildrenOf restricts a query on an item table to the children of an item through child-of links.
+// The parent type may use any spelling of ItemType references; references to types that cannot be
+// linked are matched on the ItemType/ItemID columns.
+func ChildrenOf(query *gorm.DB, table, parentType string, parentID int) *gorm.DB {
+	var source *itemTable
+	for i := range itemTables {
+		if itemTables[i].table == table {
+			source = &itemTables[i]
+		}
+	}
+	parent, ok := NormalizeItemType(parentType)
+	if source == nil || !ok {
+		return query.Where(table+".item_type = ? AND "+table+".item_id = ?", parentType, parentID)
+	}
+	return query.Where(table+".id IN (SELECT source_id FROM item_links WHERE link_type = ? AND source_type = ? AND target_type = ? AND target_id = ?)",
+		LinkTypeChildOf, source.itemType, parent, parentID)
+}
+
+// Create creates a new link
+func (r *ItemLinkRepository) Create(link *ItemLink) error {
+	return r.uow.GetDB().Create(link).Error
+}
+
+// GetByID retrieves a link by ID
+func (r *ItemLinkRepository) GetByID(id int) (*ItemLink, error) {
+	var link ItemLink
+	err := r.uow.GetDB().First(&link, id).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &link, err
+}
+
+// Update updates a link
+func (r *ItemLinkRepository) Update(link *ItemLink) error {
+	return r.uow.GetDB().Save(link).Error
+}
+
+// Delete deletes a link
+func (r *ItemLinkRepository) Delete(id int) error {
+	return r.uow.GetDB().Delete(&ItemLink{}, id).Error
+}
+
+// GetByItem returns the links from and to an item
+func (r *ItemLinkRepository) GetByItem(itemType string, itemID int) ([]ItemLink, error) {
+	var links []ItemLink
+	err := r.uow.GetDB().
+		Where("(source_type = ? AND source_id = ?) OR (target_type = ? AND target_id = ?)", itemType, itemID, itemType, itemID).
+		Order("created_at ASC, id ASC").
+		Find(&links).Error
+	return links, err
+}
+
+// GetBySources returns the links of a type from the given items
+func (r *ItemLinkRepository) GetBySources(linkType, sourceType string, sourceIDs []int) ([]ItemLink, error) {
+	var links []ItemLink
+	if len(sourceIDs) == 0 {
+		return links, nil
+	}
+	err := r.uow.GetDB().
+		Where("link_type = ? AND source_type = ? AND source_id IN ?", linkType, sourceType, sourceIDs).
+		Find(&links).Error
+	return links, err
+}
+
+// GetByTargets returns the links of a type to the given items
+func (r *ItemLinkRepository) GetByTargets(linkType, targetType string, targetIDs []int) ([]ItemLink, error) {
+	var links []ItemLink
+	if len(targetIDs) == 0 {
+		return links, nil
+	}
+	err := r.uow.GetDB().
+		Where("link_type = ? AND target_type = ? AND target_id IN ?", linkType, targetType, targetIDs).
+		Order("created_at ASC, id ASC").
+		Find(&links).Error
+	return links, err
+}
+
+// GetByProjectID returns every link of the project, oldest first
+func (r *ItemLinkRepository) GetByProjectID(projectID int) ([]ItemLink, error) {
+	var links []ItemLink
+	err := r.uow.GetDB().Where("project_id = ?", projectID).Order("created_at ASC, id ASC").Find(&links).Error
+	return links, err
+}
+
+// GetByProject returns the links of a type between items of two types in the project
+func (r *ItemLinkRepository) GetByProject(projectID int, linkType, sourceType, targetType string) ([]ItemLink, error) {
+	var links []ItemLink
+	err := r.uow.GetDB().
+		Where("project_id = ? AND link_type = ? AND source_type = ? AND target_type = ?", projectID, linkType, sourceType, targetType).
+		Order("created_at ASC, id ASC").
+		Find(&links).Error
+	return links, err
+}
+
+// Reaches checks if following links of the type from an item leads to another item.
+// Chains longer than maxCycleCheckDepth are not followed to the end.
+func (r *ItemLinkRepository) Reaches(linkType, fromType string, fromID int, toType string, toID int) (bool, error) {
+	type node struct {
+		itemType string
+		id       int
+	}
+	visited := map[node]bool{{fromType, fromID}: true}
+	frontier := map[string][]int{fromType: {fromID}}
+
+	for depth := 0; depth < maxCycleCheckDepth && len(frontier) > 0; depth++ {
+		next := make(map[string][]int)
+		for itemType, ids := range frontier {
+			links, err := r.GetBySources(linkType, itemType, ids)
+			if err != nil {
+				return false, err
+			}
+			for _, link := range links {
+				target := node{link.TargetType, link.TargetID}
+				if target.itemType == toType && target.id == toID {
+					return true, nil
+				}
+				if !visited[target] {
+					visited[target] = true
+					next[target.itemType] = append(next[target.itemType], target.id)
+				}
+			}
+		}
+		frontier = next
+	}
+	return false, nil
+}
+
+// Exists checks if the same link exists. Relates links also match in the other direction.
+func (r *ItemLinkRepository) Exists(link *ItemLink) (bool, error) {
+	var count int64
+	query := r.uow.GetDB().Model(&ItemLink{}).
+		Where("link_type = ? AND id <> ?", link.LinkType, link.ID)
+	same := "(source_type = ? AND source_id = ? AND target_type = ? AND target_id = ?)"
+	if link.LinkType == LinkTypeRelates {
+		query = query.Where(same+" OR "+same,
+			link.SourceType, link.SourceID, link.TargetType, link.TargetID,
+			link.TargetType, link.TargetID, link.SourceType, link.SourceID)
+	} else {
+		query = query.Where(same, link.SourceType, link.SourceID, link.TargetType, link.TargetID)
+	}
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+// GetParentLink returns the child-of link of an item, or nil when it has no parent
+func (r *ItemLinkRepository) GetParentLink(itemType string, itemID int) (*ItemLink, error) {
+	var link ItemLink
+	err := r.uow.GetDB().
+		Where("link_type = ? AND source_type = ? AND source_id = ?", LinkTypeChildOf, itemType, itemID).
+		First(&link).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &link, err
+}
+
+// SetParent replaces the child-of link of an item after its ItemType/ItemID reference changed.
+// References to types that cannot be linked only remove the link. A new parent must be an item
+// of the project that is not in the trash and must not be a child of the item.
+func (r *ItemLinkRepository) SetParent(projectID int, itemType string, itemID int, parentType string, parentID *int, createdBy int) error {
+	normalized, ok := NormalizeItemType(parentType)
+	hasParent := ok && parentID != nil && *parentID > 0
+
+	current, err := r.GetParentLink(itemType, itemID)
+	if err != nil {
+		return err
+	}
+	if hasParent && current != nil && current.TargetType == normalized && current.TargetID == *parentID {
+		return nil
+	}
+
+	var link *ItemLink
+	if hasParent {
+		link = &ItemLink{
+			ProjectID:  projectID,
+			SourceType: itemType,
+			SourceID:   itemID,
+			LinkType:   LinkTypeChildOf,
+			TargetType: normalized,
+			TargetID:   *parentID,
+			CreatedBy:  &createdBy,
+			CreatedAt:  time.Now(),
+		}
+		if err := link.Validate(); err != nil {
+			return err
+		}
+		if err := checkLink(r, link); err != nil {
+			return err
+		}
+	}
+
+	if current != nil {
+		if err := r.Delete(current.ID); err != nil {
+			return err
+		}
+	}
+	if link == nil {
+		return nil
+	}
+	return r.Create(link)
+}
+
+// SetDependencies replaces the features blocking a feature with the given features
+func (r *ItemLinkRepository) SetDependencies(projectID int, featureID int, dependencyIDs []int, createdBy int) error {
+	current, err := r.GetByTargets(LinkTypeBlocks, projects.PermissionItemFeature, []int{featureID})
+	if err != nil {
+		return err
+	}
+
+	wanted := make(map[int]bool, len(dependencyIDs))
+	for _, id := range dependencyIDs {
+		wanted[id] = true
+	}
+	for _, link := range current {
+		if link.SourceType != projects.PermissionItemFeature {
+			continue
+		}
+		if wanted[link.SourceID] {
+			delete(wanted, link.SourceID)
+			continue
+		}
+		if err := r.Delete(link.ID); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	for _, id := range dependencyIDs {
+		if !wanted[id] {
+			continue
+		}
+		delete(wanted, id)
+		err := r.Create(&ItemLink{
+			ProjectID:  projectID,
+			SourceType: projects.PermissionItemFeature,
+			SourceID:   id,
+			LinkType:   LinkTypeBlocks,
+			TargetType: projects.PermissionItemFeature,
+			TargetID:   featureID,
+			CreatedBy:  &createdBy,
+			CreatedAt:  now,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReplaceFirstDependency replaces the oldest feature blocking a feature, the one mirrored by its
+// DependsOnFeatureID, and keeps the other dependencies. The link is updated in place so the new
+// dependency stays the oldest. A nil dependencyID removes the oldest dependency.
+func (r *ItemLinkRepository) ReplaceFirstDependency(projectID int, featureID int, dependencyID *int, createdBy int) error {
+	links, err := r.GetByTargets(LinkTypeBlocks, projects.PermissionItemFeature, []int{featureID})
+	if err != nil {
+		return err
+	}
+
+	var first *ItemLink
+	linked := false
+	for i := range links {
+		if links[i].SourceType != projects.PermissionItemFeature {
+			continue
+		}
+		if first == nil {
+			first = &links[i]
+		}
+		if dependencyID != nil && links[i].SourceID == *dependencyID {
+			linked = true
+		}
+	}
+
+	switch {
+	case dependencyID == nil || linked:
+		if first == nil || (dependencyID != nil && first.SourceID == *dependencyID) {
+			return nil
+		}
+		return r.Delete(first.ID)
+	case first != nil:
+		first.SourceID = *dependencyID
+		return r.Update(first)
+	}
+	return r.Create(&ItemLink{
+		ProjectID:  projectID,
+		SourceType: projects.PermissionItemFeature,
+		SourceID:   *dependencyID,
+		LinkType:   LinkTypeBlocks,
+		TargetType: projects.PermissionItemFeature,
+		TargetID:   featureID,
+		CreatedBy:  &createdBy,
+		CreatedAt:  time.Now(),
+	})
+}
+
+// GetDependencyIDs returns the features blocking a feature, oldest link first
+func (r *ItemLinkRepository) GetDependencyIDs(featureID int) ([]int, error) {
+	var ids []int
+	err := r.uow.GetDB().Model(&ItemLink{}).
+		Where("link_type = ? AND source_type = ? AND target_type = ? AND target_id = ?",
+			LinkTypeBlocks, projects.PermissionItemFeature, projects.PermissionItemFeature, featureID).
+		Order("created_at ASC, id ASC").
+		Pluck("source_id", &ids).Error
+	return ids, err
+}
+
+// SyncDependency sets the DependsOnFeatureID of a feature to the feature of its oldest blocking link
+func (r *ItemLinkRepository) SyncDependency(featureID int) (*int, error) {
+	ids, err := r.GetDependencyIDs(featureID)
+	if err != nil {
+		return nil, err
+	}
+	var dependsOnFeatureID *int
+	if len(ids) > 0 {
+		dependsOnFeatureID = &ids[0]
+	}
+	err = r.uow.GetDB().Table("features").Where("id = ?", featureID).Update("depends_on_feature_id", dependsOnFeatureID).Error
+	return dependsOnFeatureID, err
+}
+
+// UpdateParentReference sets the ItemType/ItemID reference of an item to mirror its child-of link
+func (r *ItemLinkRepository) UpdateParentReference(itemType string, itemID int, parentType string, parentID *int) error {
+	t := tableForType(itemType)
+	if t == nil || !t.hasParent {
+		return nil
+	}
+
+	spelling := ""
+	if parent := tableForType(parentType); parent != nil && parentID != nil {
+		spelling = parent.spelling
+	} else {
+		parentID = nil
+	}
+	return r.uow.GetDB().Table(t.table).Where("id = ?", itemID).Updates(map[string]interface{}{
+		"item_type": spelling,
+		"item_id":   parentID,
+	}).Error
+}
+
+// GetItems returns the items of a type in the project that are not in the trash
+func (r *ItemLinkRepository) GetItems(projectID int, itemType string, ids []int) ([]ItemSummary, error) {
+	t := tableForType(itemType)
+	if t == nil || len(ids) == 0 {
+		return nil, nil
+	}
+
+	refColumn := "''"
+	if t.refColumn != "" {
+		refColumn = "COALESCE(" + t.refColumn + ", '')"
+	}
+	var rows []struct {
+		ID     int
+		RefNum string
+		Title  string
+		Status string
+	}
+	err := r.uow.GetDB().Table(t.table).
+		Select("id, "+refColumn+" AS ref_num, title, status").
+		Where("project_id = ? AND id IN ? AND deleted_at IS NULL", projectID, ids).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]ItemSummary, len(rows))
+	for i, row := range rows {
+		items[i] = ItemSummary{ItemType: itemType, ID: row.ID, RefNum: row.RefNum, Title: row.Title, Status: row.Status}
+	}
+	return items, nil
+}
+
+// BackfillFromReferences creates the child-of and blocks links of the ItemType/ItemID references
+// and feature dependencies that have no link yet. projectID 0 backfills every project.
+func (r *ItemLinkRepository) BackfillFromReferences(projectID int) error {
+	db := r.uow.GetDB()
+	insert := "INSERT INTO item_links (project_id, source_type, source_id, link_type, target_type, target_id, created_at) "
+
+	for _, source := range itemTables {
+		if !source.hasParent {
+			continue
+		}
+		for _, target := range itemTables {
+			sql := insert + "SELECT s.project_id, ?, s.id, ?, ?, s.item_id, ? FROM " + source.table + " s " +
+				"JOIN " + target.table + " p ON p.id = s.item_id AND p.project_id = s.project_id " +
+				"WHERE s.item_type IN ? AND NOT EXISTS (SELECT 1 FROM item_links l WHERE l.link_type = ? AND l.source_type = ? AND l.source_id = s.id)"
+			args := []interface{}{source.itemType, LinkTypeChildOf, target.itemType, time.Now(),
+				[]string{target.itemType, target.spelling}, LinkTypeChildOf, source.itemType}
+			if projectID > 0 {
+				sql += " AND s.project_id = ?"
+				args = append(args, projectID)
+			}
+			if err := db.Exec(sql, args...).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	sql := insert + "SELECT f.project_id, ?, f.depends_on_feature_id, ?, ?, f.id, ? FROM features f " +
+		"JOIN features d ON d.id = f.depends_on_feature_id AND d.project_id = f.project_id " +
+		"WHERE NOT EXISTS (SELECT 1 FROM item_links l WHERE l.link_type = ? AND l.source_type = ? AND l.source_id = f.depends_on_feature_id AND l.target_type = ? AND l.target_id = f.id)"
+	args := []interface{}{projects.PermissionItemFeature, LinkTypeBlocks, projects.PermissionItemFeature, time.Now(),
+		LinkTypeBlocks, projects.PermissionItemFeature, projects.PermissionItemFeature}
+	if projectID > 0 {
+		sql += " AND f.project_id = ?"
+		args = append(args, projectID)
+	}
+	return db.Exec(sql, args...).Error
+}
@@ -0,0 +1,332 @@
+package item_links
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/projects"
+	"github.com/dannyswat/pjeasy/internal/repositories"
+)
+
+type ItemLinkService struct {
+	linkRepo    *ItemLinkRepository
+	memberRepo  *projects.ProjectMemberRepository
+	projectRepo *projects.ProjectRepository
+	uowFactory  *repositories.UnitOfWorkFactory
+}
+
+func NewItemLinkService(linkRepo *ItemLinkRepository, memberRepo *projects.ProjectMemberRepository, projectRepo *projects.ProjectRepository, uowFactory *repositories.UnitOfWorkFactory) *ItemLinkService {
+	return &ItemLinkService{
+		linkRepo:    linkRepo,
+		memberRepo:  memberRepo,
+		projectRepo: projectRepo,
+		uowFactory:  uowFactory,
+	}
+}
+
+// ItemLinkView is a link as seen from one of its items
+type ItemLinkView struct {
+	Link      ItemLink
+	Direction string      // outgoing when the item is the source of the link
+	Label     string      // The link type as read from the item, such as blocked by
+	Item      ItemSummary // The item at the other end of the link
+}
+
+// MigrateItemReferences creates the links of ItemType/ItemID references and feature dependencies
+// made before item links existed
+func (s *ItemLinkService) MigrateItemReferences() error {
+	return s.linkRepo.BackfillFromReferences(0)
+}
+
+// ensureCanEdit rejects link changes in archived projects and by users who cannot edit the source item
+func (s *ItemLinkService) ensureCanEdit(projectID int, sourceType string, userID int) error {
+	if err := s.projectRepo.EnsureWritable(projectID); err != nil {
+		return err
+	}
+
+	allowed, err := s.memberRepo.HasPermission(projectID, userID, sourceType, projects.PermissionActionEdit)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return projects.PermissionDeniedError(sourceType, projects.PermissionActionEdit)
+	}
+	return nil
+}
+
+// getProjectLink finds a link of the project
+func (s *ItemLinkService) getProjectLink(projectID, linkID int) (*ItemLink, error) {
+	link, err := s.linkRepo.GetByID(linkID)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil || link.ProjectID != projectID {
+		return nil, errors.New("link not found")
+	}
+	return link, nil
+}
+
+// ListItemLinks returns the links from and to an item. Links to items in the trash are left out.
+func (s *ItemLinkService) ListItemLinks(projectID int, itemType string, itemID int, userID int) ([]ItemLinkView, error) {
+	isMember, err := s.memberRepo.IsUserMember(projectID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, errors.New("user is not a member of this project")
+	}
+
+	itemType, ok := NormalizeItemType(itemType)
+	if !ok {
+		return nil, errors.New("invalid item type")
+	}
+	if _, err := getItem(s.linkRepo, projectID, itemType, itemID); err != nil {
+		return nil, err
+	}
+
+	links, err := s.linkRepo.GetByItem(itemType, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	otherIDs := make(map[string][]int)
+	for _, link := range links {
+		if link.SourceType == itemType && link.SourceID == itemID {
+			otherIDs[link.TargetType] = append(otherIDs[link.TargetType], link.TargetID)
+		} else {
+			otherIDs[link.SourceType] = append(otherIDs[link.SourceType], link.SourceID)
+		}
+	}
+	others := make(map[string]map[int]ItemSummary, len(otherIDs))
+	for otherType, ids := range otherIDs {
+		items, err := s.linkRepo.GetItems(projectID, otherType, ids)
+		if err != nil {
+			return nil, err
+		}
+		others[otherType] = make(map[int]ItemSummary, len(items))
+		for _, item := range items {
+			others[otherType][item.ID] = item
+		}
+	}
+
+	views := make([]ItemLinkView, 0, len(links))
+	for _, link := range links {
+		view := ItemLinkView{Link: link, Direction: DirectionOutgoing}
+		otherType, otherID := link.TargetType, link.TargetID
+		if !(link.SourceType == itemType && link.SourceID == itemID) {
+			view.Direction = DirectionIncoming
+			otherType, otherID = link.SourceType, link.SourceID
+		}
+		other, ok := others[otherType][otherID]
+		if !ok {
+			continue
+		}
+		view.Label = Label(link.LinkType, view.Direction == DirectionIncoming)
+		view.Item = other
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+// CreateLink links two items of the project. A new child-of link replaces the parent of the source item.
+func (s *ItemLinkService) CreateLink(projectID int, sourceType string, sourceID int, linkType string, targetType string, targetID int, createdBy int) (*ItemLink, error) {
+	link := &ItemLink{
+		ProjectID:  projectID,
+		SourceType: sourceType,
+		SourceID:   sourceID,
+		LinkType:   linkType,
+		TargetType: targetType,
+		TargetID:   targetID,
+		CreatedBy:  &createdBy,
+		CreatedAt:  time.Now(),
+	}
+	if err := link.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.ensureCanEdit(projectID, link.SourceType, createdBy); err != nil {
+		return nil, err
+	}
+	if err := checkLink(s.linkRepo, link); err != nil {
+		return nil, err
+	}
+
+	uow := s.uowFactory.NewUnitOfWork()
+	if err := uow.BeginTransaction(); err != nil {
+		return nil, err
+	}
+	defer uow.RollbackTransactionIfError()
+
+	txLinkRepo := NewItemLinkRepository(uow)
+	if err := attach(txLinkRepo, link); err != nil {
+		return nil, err
+	}
+	if err := txLinkRepo.Create(link); err != nil {
+		return nil, err
+	}
+	if err := syncDependencies(txLinkRepo, link); err != nil {
+		return nil, err
+	}
+
+	if err := uow.CommitTransaction(); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// UpdateLink changes the type of a link
+func (s *ItemLinkService) UpdateLink(projectID, linkID int, linkType string, updatedBy int) (*ItemLink, error) {
+	link, err := s.getProjectLink(projectID, linkID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureCanEdit(projectID, link.SourceType, updatedBy); err != nil {
+		return nil, err
+	}
+	if link.LinkType == linkType {
+		return link, nil
+	}
+
+	updated := *link
+	updated.LinkType = linkType
+	if err := updated.Validate(); err != nil {
+		return nil, err
+	}
+	if err := checkLink(s.linkRepo, &updated); err != nil {
+		return nil, err
+	}
+
+	uow := s.uowFactory.NewUnitOfWork()
+	if err := uow.BeginTransaction(); err != nil {
+		return nil, err
+	}
+	defer uow.RollbackTransactionIfError()
+
+	txLinkRepo := NewItemLinkRepository(uow)
+	if err := detach(txLinkRepo, link); err != nil {
+		return nil, err
+	}
+	if err := attach(txLinkRepo, &updated); err != nil {
+		return nil, err
+	}
+	if err := txLinkRepo.Update(&updated); err != nil {
+		return nil, err
+	}
+	if err := syncDependencies(txLinkRepo, link, &updated); err != nil {
+		return nil, err
+	}
+
+	if err := uow.CommitTransaction(); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteLink removes a link. Removing a child-of link clears the parent of the source item.
+func (s *ItemLinkService) DeleteLink(projectID, linkID int, deletedBy int) error {
+	link, err := s.getProjectLink(projectID, linkID)
+	if err != nil {
+		return err
+	}
+	if err := s.ensureCanEdit(projectID, link.SourceType, deletedBy); err != nil {
+		return err
+	}
+
+	uow := s.uowFactory.NewUnitOfWork()
+	if err := uow.BeginTransaction(); err != nil {
+		return err
+	}
+	defer uow.RollbackTransactionIfError()
+
+	txLinkRepo := NewItemLinkRepository(uow)
+	if err := detach(txLinkRepo, link); err != nil {
+		return err
+	}
+	if err := txLinkRepo.Delete(link.ID); err != nil {
+		return err
+	}
+	if err := syncDependencies(txLinkRepo, link); err != nil {
+		return err
+	}
+
+	return uow.CommitTransaction()
+}
+
+// getItem finds an item of the project that is not in the trash
+func getItem(linkRepo *ItemLinkRepository, projectID int, itemType string, itemID int) (*ItemSummary, error) {
+	items, err := linkRepo.GetItems(projectID, itemType, []int{itemID})
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, errors.New(itemType + " not found")
+	}
+	return &items[0], nil
+}
+
+// checkLink checks that both items belong to the project and that the link is new and creates no cycle
+func checkLink(linkRepo *ItemLinkRepository, link *ItemLink) error {
+	if _, err := getItem(linkRepo, link.ProjectID, link.SourceType, link.SourceID); err != nil {
+		return err
+	}
+	if _, err := getItem(linkRepo, link.ProjectID, link.TargetType, link.TargetID); err != nil {
+		return err
+	}
+
+	exists, err := linkRepo.Exists(link)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errors.New("the items are already linked this way")
+	}
+
+	if link.LinkType != LinkTypeBlocks && link.LinkType != LinkTypeChildOf {
+		return nil
+	}
+	reaches, err := linkRepo.Reaches(link.LinkType, link.TargetType, link.TargetID, link.SourceType, link.SourceID)
+	if err != nil {
+		return err
+	}
+	if reaches {
+		return errors.New("the link would create a cycle")
+	}
+	return nil
+}
+
+// attach updates the ItemType/ItemID reference a new child-of link mirrors
+func attach(linkRepo *ItemLinkRepository, link *ItemLink) error {
+	if link.LinkType != LinkTypeChildOf {
+		return nil
+	}
+	current, err := linkRepo.GetParentLink(link.SourceType, link.SourceID)
+	if err != nil {
+		return err
+	}
+	if current != nil && current.ID != link.ID {
+		if err := linkRepo.Delete(current.ID); err != nil {
+			return err
+		}
+	}
+	return linkRepo.UpdateParentReference(link.SourceType, link.SourceID, link.TargetType, &link.TargetID)
+}
+
+// detach clears the ItemType/ItemID reference a removed child-of link mirrored
+func detach(linkRepo *ItemLinkRepository, link *ItemLink) error {
+	if link.LinkType != LinkTypeChildOf {
+		return nil
+	}
+	return linkRepo.UpdateParentReference(link.SourceType, link.SourceID, "", nil)
+}
+
+// syncDependencies updates the DependsOnFeatureID of the features whose dependency links were written
+func syncDependencies(linkRepo *ItemLinkRepository, links ...*ItemLink) error {
+	for _, link := range links {
+		if !link.isDependency() {
+			continue
+		}
+		if _, err := linkRepo.SyncDependency(link.TargetID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package item_links
+
+import "testing"
+
+func TestNormalizeItemType(t *testing.T) {
+	tests := []struct {
+		spelling string
+		want     string
+		wantOK   bool
+	}{
+		{spelling: "issue", want: "issue", wantOK: true},
+		{spelling: "issues", want: "issue", wantOK: true},
+		{spelling: " Features ", want: "feature", wantOK: true},
+		{spelling: "service-tickets", want: "service-ticket", wantOK: true},
+		{spelling: "service-ticket", want: "service-ticket", wantOK: true},
+		{spelling: "wiki-page", wantOK: false},
+		{spelling: "", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spelling, func(t *testing.T) {
+			got, ok := NormalizeItemType(tt.spelling)
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("NormalizeItemType(%q) = %q, %v, want %q, %v", tt.spelling, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestLabel(t *testing.T) {
+	tests := []struct {
+		linkType string
+		incoming bool
+		want     string
+	}{
+		{linkType: LinkTypeBlocks, want: "blocks"},
+		{linkType: LinkTypeBlocks, incoming: true, want: "blocked by"},
+		{linkType: LinkTypeRelates, want: "relates to"},
+		{linkType: LinkTypeRelates, incoming: true, want: "relates to"},
+		{linkType: LinkTypeDuplicates, incoming: true, want: "duplicated by"},
+		{linkType: LinkTypeChildOf, want: "child of"},
+		{linkType: LinkTypeChildOf, incoming: true, want: "parent of"},
+	}
+
+	for _, tt := range tests {
+		if got := Label(tt.linkType, tt.incoming); got != tt.want {
+			t.Fatalf("Label(%q, %v) = %q, want %q", tt.linkType, tt.incoming, got, tt.want)
+		}
+	}
+}
+
+func TestItemLinkValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		link    ItemLink
+		wantErr bool
+	}{
+		{name: "plural types", link: ItemLink{SourceType: "tasks", SourceID: 1, LinkType: LinkTypeChildOf, TargetType: "features", TargetID: 2}},
+		{name: "same id of another type", link: ItemLink{SourceType: "issue", SourceID: 1, LinkType: LinkTypeRelates, TargetType: "feature", TargetID: 1}},
+		{name: "unknown link type", link: ItemLink{SourceType: "issue", SourceID: 1, LinkType: "follows", TargetType: "feature", TargetID: 2}, wantErr: true},
+		{name: "unknown item type", link: ItemLink{SourceType: "wiki-page", SourceID: 1, LinkType: LinkTypeRelates, TargetType: "feature", TargetID: 2}, wantErr: true},
+		{name: "missing target", link: ItemLink{SourceType: "issue", SourceID: 1, LinkType: LinkTypeBlocks, TargetType: "feature"}, wantErr: true},
+		{name: "self link", link: ItemLink{SourceType: "issues", SourceID: 3, LinkType: LinkTypeDuplicates, TargetType: "issue", TargetID: 3}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.link.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	link := ItemLink{SourceType: "features", SourceID: 1, LinkType: LinkTypeBlocks, TargetType: "feature", TargetID: 2}
+	if err := link.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if link.SourceType != "feature" || !link.isDependency() {
+		t.Fatalf("Validate() did not normalize the link to a feature dependency: %+v", link)
+	}
+}
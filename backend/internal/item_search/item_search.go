@@ -0,0 +1,338 @@
+package item_search
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Item types returned by a search, spelled as in project role permissions
+const (
+	ItemTypeIdea          = "idea"
+	ItemTypeIssue         = "issue"
+	ItemTypeFeature       = "feature"
+	ItemTypeTask          = "task"
+	ItemTypeServiceTicket = "service-ticket"
+)
+
+// Item is the common shape of the work items returned by a search
+type Item struct {
+	ItemType   string
+	ID         int
+	ProjectID  int
+	RefNum     string
+	Title      string
+	Status     string
+	Priority   string
+	AssigneeID *int
+	SprintID   *int
+	ReleaseID  *int
+	Tags       string
+	Deadline   *time.Time
+	CreatedBy  int
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// itemTable maps the unified item columns onto an item table. Empty columns are missing in the table.
+type itemTable struct {
+	itemType       string
+	spellings      []string
+	table          string
+	refColumn      string
+	priorityColumn string
+	assigneeColumn string
+	sprintColumn   string
+	releaseColumn  string
+	tagsColumn     string
+	deadlineColumn string
+}
+
+var itemTables = []itemTable{
+	{
+		itemType:      ItemTypeIdea,
+		spellings:     []string{"idea", "ideas"},
+		table:         "ideas",
+		refColumn:     "ref_num",
+		releaseColumn: "release_id",
+		tagsColumn:    "tags",
+	},
+	{
+		itemType:       ItemTypeIssue,
+		spellings:      []string{"issue", "issues"},
+		table:          "issues",
+		refColumn:      "ref_num",
+		priorityColumn: "priority",
+		assigneeColumn: "assigned_to",
+		sprintColumn:   "sprint_id",
+		releaseColumn:  "release_id",
+		tagsColumn:     "tags",
+	},
+	{
+		itemType:       ItemTypeFeature,
+		spellings:      []string{"feature", "features"},
+		table:          "features",
+		refColumn:      "ref_num",
+		priorityColumn: "priority",
+		assigneeColumn: "assigned_to",
+		sprintColumn:   "sprint_id",
+		releaseColumn:  "release_id",
+		tagsColumn:     "tags",
+		deadlineColumn: "deadline",
+	},
+	{
+		itemType:       ItemTypeTask,
+		spellings:      []string{"task", "tasks"},
+		table:          "tasks",
+		priorityColumn: "priority",
+		assigneeColumn: "assignee_id",
+		sprintColumn:   "sprint_id",
+		releaseColumn:  "release_id",
+		tagsColumn:     "tags",
+		deadlineColumn: "deadline",
+	},
+	{
+		itemType:       ItemTypeServiceTicket,
+		spellings:      []string{"service-ticket", "service-tickets", "ticket", "tickets"},
+		table:          "service_tickets",
+		refColumn:      "ref_num",
+		priorityColumn: "priority",
+	},
+}
+
+// tableForType finds the item table of an item type spelling used in queries
+func tableForType(spelling string) *itemTable {
+	for i := range itemTables {
+		for _, s := range itemTables[i].spellings {
+			if s == spelling {
+				return &itemTables[i]
+			}
+		}
+	}
+	return nil
+}
+
+// selectColumns selects the unified item columns from the table. Integer columns where 0
+// means unset are returned as NULL so every table reports missing links the same way.
+func (t *itemTable) selectColumns() string {
+	nullable := func(column, nullType string) string {
+		if column == "" {
+			return "NULL::" + nullType
+		}
+		return "NULLIF(" + column + ", 0)"
+	}
+	text := func(column string) string {
+		if column == "" {
+			return "''"
+		}
+		return "COALESCE(" + column + ", '')"
+	}
+	deadline := "NULL::timestamptz"
+	if t.deadlineColumn != "" {
+		deadline = t.deadlineColumn
+	}
+
+	return strings.Join([]string{
+		"'" + t.itemType + "' AS item_type",
+		"id", "project_id",
+		text(t.refColumn) + " AS ref_num",
+		"title", "status",
+		text(t.priorityColumn) + " AS priority",
+		nullable(t.assigneeColumn, "bigint") + " AS assignee_id",
+		nullable(t.sprintColumn, "bigint") + " AS sprint_id",
+		nullable(t.releaseColumn, "bigint") + " AS release_id",
+		text(t.tagsColumn) + " AS tags",
+		deadline + " AS deadline",
+		"created_by", "created_at", "updated_at",
+	}, ", ")
+}
+
+// SearchContext resolves the values of a query that depend on who searches and when
+type SearchContext struct {
+	ProjectID int
+	UserID    int
+	Now       time.Time
+}
+
+// condition is an SQL condition with its arguments
+type condition struct {
+	sql  string
+	args []interface{}
+}
+
+// compileTable returns the conditions selecting the items of the table matching the query.
+// ok is false when no item of the table can match.
+func compileTable(t *itemTable, query *Query, ctx SearchContext) (conditions []condition, ok bool) {
+	for _, clause := range query.Clauses {
+		cond, matchesAll, matchesNone := compileClause(t, clause, ctx)
+		if matchesNone {
+			return nil, false
+		}
+		if !matchesAll {
+			conditions = append(conditions, cond)
+		}
+	}
+	return conditions, true
+}
+
+// compileClause converts a clause into a condition on the table. A clause on a column the table
+// does not have matches every item when negated and no item otherwise.
+func compileClause(t *itemTable, clause Clause, ctx SearchContext) (cond condition, matchesAll, matchesNone bool) {
+	negated := clause.Op == OpNotEqual
+	missing := func() (condition, bool, bool) {
+		return condition{}, negated, !negated
+	}
+	equality := func(sql string, args ...interface{}) (condition, bool, bool) {
+		if negated {
+			sql = "NOT (" + sql + ")"
+		}
+		return condition{sql: sql, args: args}, false, false
+	}
+
+	switch clause.Field {
+	case FieldType:
+		matches := false
+		for _, value := range clause.Values {
+			if tableForType(strings.ToLower(value)) == t {
+				matches = true
+			}
+		}
+		if matches != negated {
+			return condition{}, true, false
+		}
+		return condition{}, false, true
+
+	case FieldStatus:
+		return equality("LOWER(status) IN ?", lowerValues(clause.Values))
+
+	case FieldPriority:
+		if t.priorityColumn == "" {
+			return missing()
+		}
+		return equality("LOWER("+t.priorityColumn+") IN ?", lowerValues(clause.Values))
+
+	case FieldAssignee:
+		if t.assigneeColumn == "" {
+			return missing()
+		}
+		sql, args := idCondition(t.assigneeColumn, clause.Values, ctx)
+		return equality(sql, args...)
+
+	case FieldCreator:
+		sql, args := idCondition("created_by", clause.Values, ctx)
+		return equality(sql, args...)
+
+	case FieldSprint:
+		if t.sprintColumn == "" {
+			return missing()
+		}
+		sql, args := idCondition(t.sprintColumn, clause.Values, ctx)
+		return equality(sql, args...)
+
+	case FieldRelease:
+		if t.releaseColumn == "" {
+			return missing()
+		}
+		sql, args := idCondition(t.releaseColumn, clause.Values, ctx)
+		return equality(sql, args...)
+
+	case FieldTag:
+		if t.tagsColumn == "" {
+			return missing()
+		}
+		parts := make([]string, len(clause.Values))
+		args := make([]interface{}, len(clause.Values))
+		for i, value := range clause.Values {
+			parts[i] = "(',' || LOWER(REPLACE(COALESCE(" + t.tagsColumn + ", ''), ' ', '')) || ',') LIKE ?"
+			args[i] = "%," + escapeLike(strings.ToLower(strings.ReplaceAll(value, " ", ""))) + ",%"
+		}
+		return equality("("+strings.Join(parts, " OR ")+")", args...)
+
+	case FieldCreated:
+		return dateCondition("created_at", clause, ctx)
+
+	case FieldUpdated:
+		return dateCondition("updated_at", clause, ctx)
+
+	case FieldDeadline:
+		if t.deadlineColumn == "" {
+			return missing()
+		}
+		return dateCondition(t.deadlineColumn, clause, ctx)
+
+	case FieldText:
+		pattern := "%" + escapeLike(clause.Values[0]) + "%"
+		if t.refColumn == "" {
+			return condition{sql: "title ILIKE ?", args: []interface{}{pattern}}, false, false
+		}
+		return condition{sql: "(title ILIKE ? OR " + t.refColumn + " ILIKE ?)", args: []interface{}{pattern, pattern}}, false, false
+	}
+
+	return condition{}, true, false
+}
+
+// idCondition matches an ID column against IDs, me, none or the active sprint
+func idCondition(column string, values []string, ctx SearchContext) (string, []interface{}) {
+	var parts []string
+	var args []interface{}
+	var ids []int
+	for _, value := range values {
+		switch strings.ToLower(value) {
+		case ValueMe:
+			ids = append(ids, ctx.UserID)
+		case ValueNone:
+			parts = append(parts, "COALESCE("+column+", 0) = 0")
+		case ValueActive:
+			parts = append(parts, column+" IN (SELECT id FROM sprints WHERE project_id = ? AND status = 'Active')")
+			args = append(args, ctx.ProjectID)
+		default:
+			id, _ := strconv.Atoi(value)
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) > 0 {
+		parts = append(parts, "COALESCE("+column+", 0) IN ?")
+		args = append(args, ids)
+	}
+	if len(parts) == 1 {
+		return parts[0], args
+	}
+	return "(" + strings.Join(parts, " OR ") + ")", args
+}
+
+// dateCondition compares a time column with an absolute date, which covers the whole day,
+// or with a time relative to now
+func dateCondition(column string, clause Clause, ctx SearchContext) (condition, bool, bool) {
+	value := clause.Values[0]
+	if offset, ok := parseRelativeDuration(value); ok {
+		return condition{sql: column + " " + clause.Op + " ?", args: []interface{}{ctx.Now.Add(offset)}}, false, false
+	}
+
+	dayStart, _ := time.Parse("2006-01-02", value)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+	switch clause.Op {
+	case OpGreater:
+		return condition{sql: column + " >= ?", args: []interface{}{dayEnd}}, false, false
+	case OpGreaterEqual:
+		return condition{sql: column + " >= ?", args: []interface{}{dayStart}}, false, false
+	case OpLess:
+		return condition{sql: column + " < ?", args: []interface{}{dayStart}}, false, false
+	case OpLessEqual:
+		return condition{sql: column + " < ?", args: []interface{}{dayEnd}}, false, false
+	default:
+		return condition{sql: "(" + column + " >= ? AND " + column + " < ?)", args: []interface{}{dayStart, dayEnd}}, false, false
+	}
+}
+
+func lowerValues(values []string) []string {
+	lowered := make([]string, len(values))
+	for i, value := range values {
+		lowered[i] = strings.ToLower(value)
+	}
+	return lowered
+}
+
+// escapeLike escapes the LIKE wildcards in a value
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
@@ -0,0 +1,62 @@
+package item_search
+
+import (
+	"strings"
+
+	"github.com/dannyswat/pjeasy/internal/repositories"
+)
+
+type ItemSearchRepository struct {
+	uow *repositories.UnitOfWork
+}
+
+func NewItemSearchRepository(uow *repositories.UnitOfWork) *ItemSearchRepository {
+	return &ItemSearchRepository{uow: uow}
+}
+
+// buildSearchSQL combines the matching items of every item table into one union.
+// It returns an empty statement when no table can match the query.
+func buildSearchSQL(query *Query, ctx SearchContext) (string, []interface{}) {
+	var selects []string
+	var args []interface{}
+	for i := range itemTables {
+		t := &itemTables[i]
+		conditions, ok := compileTable(t, query, ctx)
+		if !ok {
+			continue
+		}
+
+		where := []string{"project_id = ?", "deleted_at IS NULL"}
+		args = append(args, ctx.ProjectID)
+		for _, cond := range conditions {
+			where = append(where, cond.sql)
+			args = append(args, cond.args...)
+		}
+		selects = append(selects, "SELECT "+t.selectColumns()+" FROM "+t.table+" WHERE "+strings.Join(where, " AND "))
+	}
+	return strings.Join(selects, " UNION ALL "), args
+}
+
+// Search returns a page of the project's items matching the query, most recently updated first
+func (r *ItemSearchRepository) Search(query *Query, ctx SearchContext, offset, limit int) ([]Item, int64, error) {
+	union, args := buildSearchSQL(query, ctx)
+	if union == "" {
+		return []Item{}, 0, nil
+	}
+
+	db := r.uow.GetDB()
+	var total int64
+	if err := db.Raw("SELECT COUNT(*) FROM ("+union+") AS items", args...).Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var items []Item
+	pageArgs := append(append([]interface{}{}, args...), limit, offset)
+	err := db.Raw("SELECT * FROM ("+union+") AS items ORDER BY updated_at DESC, item_type ASC, id DESC LIMIT ? OFFSET ?", pageArgs...).
+		Scan(&items).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return items, total, nil
+}
@@ -0,0 +1,73 @@
+package item_search
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/projects"
+)
+
+type ItemSearchService struct {
+	searchRepo *ItemSearchRepository
+	textRepo   *TextSearchRepository
+	memberRepo *projects.ProjectMemberRepository
+}
+
+func NewItemSearchService(searchRepo *ItemSearchRepository, textRepo *TextSearchRepository, memberRepo *projects.ProjectMemberRepository) *ItemSearchService {
+	return &ItemSearchService{
+		searchRepo: searchRepo,
+		textRepo:   textRepo,
+		memberRepo: memberRepo,
+	}
+}
+
+// SearchItems returns the ideas, issues, features, tasks and service tickets of a project matching
+// the query. Parse errors are returned as *ParseError pointing at the bad token.
+func (s *ItemSearchService) SearchItems(projectID int, queryText string, page, pageSize int, userID int) ([]Item, int64, error) {
+	isMember, err := s.memberRepo.IsUserMember(projectID, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !isMember {
+		return nil, 0, errors.New("user is not a member of this project")
+	}
+
+	query, err := Parse(queryText)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ctx := SearchContext{ProjectID: projectID, UserID: userID, Now: time.Now()}
+	offset := (page - 1) * pageSize
+	return s.searchRepo.Search(query, ctx, offset, pageSize)
+}
+
+// SearchText returns the items, comments and wiki pages of a project matching the text, best
+// matches first. Limited readers only find the wiki pages and wiki comments they may read.
+func (s *ItemSearchService) SearchText(projectID int, text string, resultTypes []string, page, pageSize int, userID int) ([]TextSearchResult, int64, error) {
+	access, err := s.memberRepo.GetAccess(projectID, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !access.IsMember {
+		return nil, 0, errors.New("user is not a member of this project")
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, 0, errors.New("search text is required")
+	}
+
+	filter := TextSearchFilter{ProjectID: projectID, Text: text, LimitedReader: access.IsLimited}
+	for _, spelling := range resultTypes {
+		resultType, ok := NormalizeResultType(spelling)
+		if !ok {
+			return nil, 0, errors.New("invalid result type: " + spelling)
+		}
+		filter.ResultTypes = append(filter.ResultTypes, resultType)
+	}
+
+	offset := (page - 1) * pageSize
+	return s.textRepo.Search(filter, offset, pageSize)
+}
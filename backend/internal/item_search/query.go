@@ -0,0 +1,343 @@
+package item_search
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Query operators. ":" and "=" both mean equality and are parsed as OpEqual.
+const (
+	OpEqual        = "="
+	OpNotEqual     = "!="
+	OpGreater      = ">"
+	OpGreaterEqual = ">="
+	OpLess         = "<"
+	OpLessEqual    = "<="
+)
+
+// Query fields. Free text without a field matches titles and reference numbers.
+const (
+	FieldType     = "type"
+	FieldStatus   = "status"
+	FieldPriority = "priority"
+	FieldAssignee = "assignee"
+	FieldCreator  = "creator"
+	FieldTag      = "tag"
+	FieldSprint   = "sprint"
+	FieldRelease  = "release"
+	FieldCreated  = "created"
+	FieldUpdated  = "updated"
+	FieldDeadline = "deadline"
+	FieldText     = "text"
+)
+
+// Special values of the user, sprint and release fields
+const (
+	ValueMe     = "me"
+	ValueNone   = "none"
+	ValueActive = "active"
+)
+
+type fieldKind int
+
+const (
+	kindText fieldKind = iota
+	kindItemType
+	kindUser
+	kindSprint
+	kindRelease
+	kindDate
+)
+
+var fieldKinds = map[string]fieldKind{
+	FieldType:     kindItemType,
+	FieldStatus:   kindText,
+	FieldPriority: kindText,
+	FieldAssignee: kindUser,
+	FieldCreator:  kindUser,
+	FieldTag:      kindText,
+	FieldSprint:   kindSprint,
+	FieldRelease:  kindRelease,
+	FieldCreated:  kindDate,
+	FieldUpdated:  kindDate,
+	FieldDeadline: kindDate,
+}
+
+// Clause is one filter of a query. Values of a clause are alternatives; clauses are all required.
+type Clause struct {
+	Field    string
+	Op       string
+	Values   []string
+	Token    string // The token as written, for error messages
+	Position int    // 1-based position of the token in the query
+}
+
+// Query is a parsed item search query
+type Query struct {
+	Clauses []Clause
+}
+
+// ParseError reports the token of a query that could not be parsed
+type ParseError struct {
+	Message  string
+	Token    string
+	Position int // 1-based position of the token in the query
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s at position %d: %s", e.Message, e.Position, e.Token)
+}
+
+type token struct {
+	text     string
+	position int
+}
+
+// tokenize splits a query on whitespace outside double quotes
+func tokenize(input string) ([]token, error) {
+	var tokens []token
+	var current strings.Builder
+	start := 0 // 1-based position of the current token, 0 between tokens
+	quoted := false
+	quoteStart, quoteByte := 0, 0
+
+	position := 0
+	for i, r := range input {
+		position++
+		switch {
+		case r == '"':
+			if start == 0 {
+				start = position
+			}
+			if !quoted {
+				quoteStart, quoteByte = position, i
+			}
+			quoted = !quoted
+			current.WriteRune(r)
+		case !quoted && unicode.IsSpace(r):
+			if start > 0 {
+				tokens = append(tokens, token{text: current.String(), position: start})
+				current.Reset()
+				start = 0
+			}
+		default:
+			if start == 0 {
+				start = position
+			}
+			current.WriteRune(r)
+		}
+	}
+	if quoted {
+		return nil, &ParseError{Message: "unterminated quote", Token: input[quoteByte:], Position: quoteStart}
+	}
+	if start > 0 {
+		tokens = append(tokens, token{text: current.String(), position: start})
+	}
+	return tokens, nil
+}
+
+// splitValues splits a comma separated value list outside double quotes and removes the quotes
+func splitValues(raw string) []string {
+	var values []string
+	var current strings.Builder
+	quoted := false
+	for _, r := range raw {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			values = append(values, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	return append(values, current.String())
+}
+
+// splitOperator finds the field name and operator at the start of a token.
+// ok is false when the token has no field, which makes it free text.
+func splitOperator(text string) (field, op, rest string, ok bool) {
+	i := 0
+	for i < len(text) && (text[i] >= 'a' && text[i] <= 'z' || text[i] >= 'A' && text[i] <= 'Z') {
+		i++
+	}
+	if i == 0 || i == len(text) {
+		return "", "", "", false
+	}
+
+	field = strings.ToLower(text[:i])
+	rest = text[i:]
+	for _, candidate := range []string{OpNotEqual, OpGreaterEqual, OpLessEqual, OpGreater, OpLess, OpEqual, ":"} {
+		if strings.HasPrefix(rest, candidate) {
+			op = candidate
+			if op == ":" {
+				op = OpEqual
+			}
+			return field, op, rest[len(candidate):], true
+		}
+	}
+	return "", "", "", false
+}
+
+// Parse parses and validates a query such as
+// `type:issue,task status!=Closed assignee:me tag:backend updated>-7d sprint:active`
+func Parse(input string) (*Query, error) {
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	query := &Query{}
+	for _, tok := range tokens {
+		clause, err := parseClause(tok)
+		if err != nil {
+			return nil, err
+		}
+		query.Clauses = append(query.Clauses, clause)
+	}
+	return query, nil
+}
+
+func parseClause(tok token) (Clause, error) {
+	fail := func(format string, args ...interface{}) (Clause, error) {
+		return Clause{}, &ParseError{Message: fmt.Sprintf(format, args...), Token: tok.text, Position: tok.position}
+	}
+
+	text := tok.text
+	negated := strings.HasPrefix(text, "-") && len(text) > 1
+	if negated {
+		text = text[1:]
+	}
+
+	field, op, rawValue, ok := splitOperator(text)
+	if !ok {
+		if negated {
+			return fail("free text cannot be negated")
+		}
+		value := strings.Trim(tok.text, `"`)
+		if value == "" {
+			return fail("empty search text")
+		}
+		return Clause{Field: FieldText, Op: OpEqual, Values: []string{value}, Token: tok.text, Position: tok.position}, nil
+	}
+
+	kind, known := fieldKinds[field]
+	if !known {
+		return fail("unknown field %q", field)
+	}
+
+	if negated {
+		switch op {
+		case OpEqual:
+			op = OpNotEqual
+		case OpNotEqual:
+			op = OpEqual
+		default:
+			return fail("comparisons cannot be negated")
+		}
+	}
+
+	values := splitValues(rawValue)
+	for i, value := range values {
+		values[i] = strings.TrimSpace(value)
+		if values[i] == "" {
+			return fail("missing value for %s", field)
+		}
+	}
+
+	isComparison := op != OpEqual && op != OpNotEqual
+	if kind == kindDate {
+		if op == OpNotEqual {
+			return fail("%s supports :, >, >=, < and <=", field)
+		}
+		if len(values) > 1 {
+			return fail("%s takes a single date", field)
+		}
+		if op == OpEqual && !isAbsoluteDate(values[0]) {
+			return fail("%s: needs a date such as 2024-01-31; use > or < with relative dates", field)
+		}
+	} else if isComparison {
+		return fail("%s supports only : and !=", field)
+	}
+
+	for _, value := range values {
+		if err := validateValue(kind, value); err != "" {
+			return fail("%s", err)
+		}
+	}
+
+	return Clause{Field: field, Op: op, Values: values, Token: tok.text, Position: tok.position}, nil
+}
+
+func validateValue(kind fieldKind, value string) string {
+	lower := strings.ToLower(value)
+	switch kind {
+	case kindItemType:
+		if tableForType(lower) == nil {
+			return fmt.Sprintf("unknown item type %q", value)
+		}
+	case kindUser:
+		if lower != ValueMe && lower != ValueNone && !isID(value) {
+			return fmt.Sprintf("user must be me, none or a user ID, not %q", value)
+		}
+	case kindSprint:
+		if lower != ValueActive && lower != ValueNone && !isID(value) {
+			return fmt.Sprintf("sprint must be active, none or a sprint ID, not %q", value)
+		}
+	case kindRelease:
+		if lower != ValueNone && !isID(value) {
+			return fmt.Sprintf("release must be none or a release ID, not %q", value)
+		}
+	case kindDate:
+		if !isAbsoluteDate(value) {
+			if _, ok := parseRelativeDuration(value); !ok {
+				return fmt.Sprintf("invalid date %q, use 2024-01-31 or a relative time such as -7d", value)
+			}
+		}
+	}
+	return ""
+}
+
+func isID(value string) bool {
+	id, err := strconv.Atoi(value)
+	return err == nil && id > 0
+}
+
+func isAbsoluteDate(value string) bool {
+	_, err := time.Parse("2006-01-02", value)
+	return err == nil
+}
+
+// parseRelativeDuration parses relative times such as -7d, +2w or -12h
+func parseRelativeDuration(value string) (time.Duration, bool) {
+	if len(value) < 3 || (value[0] != '-' && value[0] != '+') {
+		return 0, false
+	}
+
+	amount, err := strconv.Atoi(value[1 : len(value)-1])
+	if err != nil || amount < 0 {
+		return 0, false
+	}
+
+	var unit time.Duration
+	switch value[len(value)-1] {
+	case 'h':
+		unit = time.Hour
+	case 'd':
+		unit = 24 * time.Hour
+	case 'w':
+		unit = 7 * 24 * time.Hour
+	default:
+		return 0, false
+	}
+
+	duration := time.Duration(amount) * unit
+	if value[0] == '-' {
+		duration = -duration
+	}
+	return duration, true
+}
@@ -0,0 +1,128 @@
+package item_search
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	query, err := Parse(`type:issue,task status!=Closed assignee:me tag:backend updated>-7d sprint:active -priority:Low "login page"`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := []Clause{
+		{Field: FieldType, Op: OpEqual, Values: []string{"issue", "task"}},
+		{Field: FieldStatus, Op: OpNotEqual, Values: []string{"Closed"}},
+		{Field: FieldAssignee, Op: OpEqual, Values: []string{"me"}},
+		{Field: FieldTag, Op: OpEqual, Values: []string{"backend"}},
+		{Field: FieldUpdated, Op: OpGreater, Values: []string{"-7d"}},
+		{Field: FieldSprint, Op: OpEqual, Values: []string{"active"}},
+		{Field: FieldPriority, Op: OpNotEqual, Values: []string{"Low"}},
+		{Field: FieldText, Op: OpEqual, Values: []string{"login page"}},
+	}
+	if len(query.Clauses) != len(want) {
+		t.Fatalf("Parse() returned %d clauses, want %d", len(query.Clauses), len(want))
+	}
+	for i, clause := range query.Clauses {
+		if clause.Field != want[i].Field || clause.Op != want[i].Op || !reflect.DeepEqual(clause.Values, want[i].Values) {
+			t.Fatalf("clause %d = %s %s %v, want %s %s %v", i, clause.Field, clause.Op, clause.Values, want[i].Field, want[i].Op, want[i].Values)
+		}
+	}
+
+	quoted, err := Parse(`status:"In Progress",Open`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got := quoted.Clauses[0].Values; !reflect.DeepEqual(got, []string{"In Progress", "Open"}) {
+		t.Fatalf("quoted values = %v", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		query    string
+		token    string
+		position int
+	}{
+		{query: "status:Open colour:red", token: "colour:red", position: 13},
+		{query: "type:issue,epic", token: "type:issue,epic", position: 1},
+		{query: "assignee:bob", token: "assignee:bob", position: 1},
+		{query: "  updated>yesterday", token: "updated>yesterday", position: 3},
+		{query: "status>Open", token: "status>Open", position: 1},
+		{query: "created:-7d", token: "created:-7d", position: 1},
+		{query: "tag:backend,", token: "tag:backend,", position: 1},
+		{query: `status:"In Progress`, token: `"In Progress`, position: 8},
+		{query: "é status:", token: "status:", position: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := Parse(tt.query)
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Parse() error = %v, want a ParseError", err)
+			}
+			if parseErr.Token != tt.token || parseErr.Position != tt.position {
+				t.Fatalf("ParseError points at %q at %d, want %q at %d", parseErr.Token, parseErr.Position, tt.token, tt.position)
+			}
+		})
+	}
+}
+
+func TestBuildSearchSQL(t *testing.T) {
+	ctx := SearchContext{ProjectID: 3, UserID: 7, Now: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name   string
+		query  string
+		tables []string
+	}{
+		{name: "empty query searches every table", query: "", tables: []string{"ideas", "issues", "features", "tasks", "service_tickets"}},
+		{name: "type filter", query: "type:issue,tasks", tables: []string{"issues", "tasks"}},
+		{name: "excluded type", query: "type!=idea", tables: []string{"issues", "features", "tasks", "service_tickets"}},
+		{name: "field missing from a table", query: "assignee:me", tables: []string{"issues", "features", "tasks"}},
+		{name: "negated field missing from a table", query: "deadline<2024-01-01 type:idea", tables: nil},
+		{name: "negation keeps tables without the field", query: "-tag:backend", tables: []string{"ideas", "issues", "features", "tasks", "service_tickets"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			sql, args := buildSearchSQL(query, ctx)
+			var tables []string
+			for _, part := range strings.Split(sql, " UNION ALL ") {
+				if i := strings.Index(part, " FROM "); i >= 0 {
+					tables = append(tables, strings.Fields(part[i+len(" FROM "):])[0])
+				}
+			}
+			if !reflect.DeepEqual(tables, tt.tables) {
+				t.Fatalf("buildSearchSQL() searches %v, want %v", tables, tt.tables)
+			}
+			if got, want := strings.Count(sql, "?"), len(args); got != want {
+				t.Fatalf("buildSearchSQL() has %d placeholders and %d arguments", got, want)
+			}
+		})
+	}
+}
+
+func TestDateCondition(t *testing.T) {
+	ctx := SearchContext{Now: time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)}
+
+	relative, _, _ := dateCondition("updated_at", Clause{Op: OpGreater, Values: []string{"-7d"}}, ctx)
+	if relative.sql != "updated_at > ?" || !relative.args[0].(time.Time).Equal(ctx.Now.AddDate(0, 0, -7)) {
+		t.Fatalf("relative condition = %s %v", relative.sql, relative.args)
+	}
+
+	after, _, _ := dateCondition("created_at", Clause{Op: OpGreater, Values: []string{"2024-03-01"}}, ctx)
+	if after.sql != "created_at >= ?" || !after.args[0].(time.Time).Equal(time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("after condition = %s %v", after.sql, after.args)
+	}
+}
@@ -0,0 +1,237 @@
+package item_search
+
+import (
+	"html"
+	"strings"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/wiki_pages"
+)
+
+// textSearchConfig is the Postgres text search configuration of both the indexes and the queries
+const textSearchConfig = "english"
+
+// Result types of a text search besides the item types
+const (
+	ResultTypeComment  = "comment"
+	ResultTypeWikiPage = "wiki-page"
+)
+
+// Snippet markers passed to ts_headline. They cannot appear in stored text and are replaced
+// with <mark> tags once the rest of the snippet has been escaped.
+const (
+	snippetStart = "\x01"
+	snippetStop  = "\x02"
+)
+
+// snippetOptions are the ts_headline options of the snippets
+var snippetOptions = `StartSel="` + snippetStart + `", StopSel="` + snippetStop + `", MaxWords=30, MinWords=12, MaxFragments=2, FragmentDelimiter=" … "`
+
+// TextSearchFilter selects the documents of a text search
+type TextSearchFilter struct {
+	ProjectID     int
+	Text          string
+	ResultTypes   []string // Empty searches every result type
+	LimitedReader bool     // Limited readers only find published or archived wiki pages that are not protected
+}
+
+// TextSearchResult is an item, comment or wiki page matching a text search
+type TextSearchResult struct {
+	ResultType string // An item type, comment or wiki-page
+	ID         int
+	ItemType   string // The type of the item a comment belongs to, otherwise the result type
+	ItemID     int    // The item a comment belongs to, otherwise the ID
+	RefNum     string
+	Title      string // The title of the item a comment belongs to, otherwise the title
+	Snippet    string // HTML escaped text with the matches wrapped in <mark>
+	Rank       float64
+	UpdatedAt  time.Time
+}
+
+// NormalizeResultType returns the result type of a spelling used to filter a text search.
+// ok is false for unknown result types.
+func NormalizeResultType(spelling string) (resultType string, ok bool) {
+	spelling = strings.ToLower(strings.TrimSpace(spelling))
+	switch spelling {
+	case ResultTypeComment, "comments":
+		return ResultTypeComment, true
+	case ResultTypeWikiPage, "wiki-pages", "wiki":
+		return ResultTypeWikiPage, true
+	}
+	if t := tableForType(spelling); t != nil {
+		return t.itemType, true
+	}
+	return "", false
+}
+
+// textDocument describes the text indexed for one table. The same expressions build the index
+// and the search conditions so Postgres can use the index.
+type textDocument struct {
+	table       string
+	indexName   string
+	titleColumn string // Empty when only the body is searched
+	bodyColumn  string
+	stripHTML   bool
+}
+
+func itemDocument(t *itemTable) textDocument {
+	return textDocument{
+		table:       t.table,
+		indexName:   "idx_" + t.table + "_text_search",
+		titleColumn: "title",
+		bodyColumn:  "description",
+	}
+}
+
+var wikiPageDocument = textDocument{
+	table:       "wiki_pages",
+	indexName:   "idx_wiki_pages_text_search",
+	titleColumn: "title",
+	bodyColumn:  "content",
+	stripHTML:   true,
+}
+
+var commentDocument = textDocument{
+	table:      "comments",
+	indexName:  "idx_comments_text_search",
+	bodyColumn: "content",
+	stripHTML:  true,
+}
+
+// body is the plain text of the document body. Columns are qualified with the alias when it is not empty.
+func (d *textDocument) body(alias string) string {
+	column := "COALESCE(" + qualify(alias, d.bodyColumn) + ", '')"
+	if d.stripHTML {
+		return "regexp_replace(" + column + ", '<[^>]*>', ' ', 'g')"
+	}
+	return column
+}
+
+// vector is the tsvector of the document, ranking title matches above body matches
+func (d *textDocument) vector(alias string) string {
+	body := "to_tsvector('" + textSearchConfig + "', " + d.body(alias) + ")"
+	if d.titleColumn == "" {
+		return body
+	}
+	return "(setweight(to_tsvector('" + textSearchConfig + "', COALESCE(" + qualify(alias, d.titleColumn) + ", '')), 'A') || setweight(" + body + ", 'B'))"
+}
+
+func (d *textDocument) indexStatement() string {
+	return "CREATE INDEX IF NOT EXISTS " + d.indexName + " ON " + d.table + " USING GIN ((" + d.vector("") + "))"
+}
+
+func qualify(alias, column string) string {
+	if alias == "" {
+		return column
+	}
+	return alias + "." + column
+}
+
+// TextSearchIndexStatements returns the statements creating the text search expression indexes
+func TextSearchIndexStatements() []string {
+	var statements []string
+	for i := range itemTables {
+		document := itemDocument(&itemTables[i])
+		statements = append(statements, document.indexStatement())
+	}
+	return append(statements, wikiPageDocument.indexStatement(), commentDocument.indexStatement())
+}
+
+// wikiPageSpellings are the item type spellings comments use for wiki pages
+var wikiPageSpellings = []string{"wiki", "wiki-page", "wiki-pages"}
+
+// readableWikiPage restricts wiki pages to the ones a limited reader may read
+func readableWikiPage(alias string) (string, []interface{}) {
+	sql := qualify(alias, "status") + " IN ? AND " + qualify(alias, "protected") + " = ?"
+	return sql, []interface{}{[]string{wiki_pages.WikiPageStatusPublished, wiki_pages.WikiPageStatusArchived}, false}
+}
+
+func includesResultType(filter TextSearchFilter, resultType string) bool {
+	if len(filter.ResultTypes) == 0 {
+		return true
+	}
+	for _, t := range filter.ResultTypes {
+		if t == resultType {
+			return true
+		}
+	}
+	return false
+}
+
+// buildTextSearchSQL combines the matching items, wiki pages and comments into one union. Every
+// select reads the query from the q common table expression, which the caller must define.
+func buildTextSearchSQL(filter TextSearchFilter) (string, []interface{}) {
+	var selects []string
+	var args []interface{}
+	add := func(sql string, sqlArgs ...interface{}) {
+		selects = append(selects, sql)
+		args = append(args, sqlArgs...)
+	}
+
+	for i := range itemTables {
+		t := &itemTables[i]
+		if !includesResultType(filter, t.itemType) {
+			continue
+		}
+		document := itemDocument(t)
+		add("SELECT '"+t.itemType+"' AS result_type, id, '"+t.itemType+"' AS item_type, id AS item_id, "+
+			itemRefColumn(t, "")+" AS ref_num, title, ts_rank("+document.vector("")+", q.query) AS rank, "+
+			document.body("")+" AS body, updated_at FROM "+t.table+", q "+
+			"WHERE project_id = ? AND deleted_at IS NULL AND "+document.vector("")+" @@ q.query",
+			filter.ProjectID)
+	}
+
+	if includesResultType(filter, ResultTypeWikiPage) {
+		where := "project_id = ? AND deleted_at IS NULL"
+		whereArgs := []interface{}{filter.ProjectID}
+		if filter.LimitedReader {
+			readable, readableArgs := readableWikiPage("")
+			where += " AND " + readable
+			whereArgs = append(whereArgs, readableArgs...)
+		}
+		add("SELECT '"+ResultTypeWikiPage+"' AS result_type, id, '"+ResultTypeWikiPage+"' AS item_type, id AS item_id, "+
+			"'' AS ref_num, title, ts_rank("+wikiPageDocument.vector("")+", q.query) AS rank, "+
+			wikiPageDocument.body("")+" AS body, updated_at FROM wiki_pages, q "+
+			"WHERE "+where+" AND "+wikiPageDocument.vector("")+" @@ q.query",
+			whereArgs...)
+	}
+
+	if includesResultType(filter, ResultTypeComment) {
+		for i := range itemTables {
+			t := &itemTables[i]
+			add(commentSelect(t.itemType, t.table, itemRefColumn(t, "p"), ""),
+				t.spellings, filter.ProjectID)
+		}
+		where, whereArgs := "", []interface{}{wikiPageSpellings, filter.ProjectID}
+		if filter.LimitedReader {
+			readable, readableArgs := readableWikiPage("p")
+			where = " AND " + readable
+			whereArgs = append(whereArgs, readableArgs...)
+		}
+		add(commentSelect(ResultTypeWikiPage, "wiki_pages", "''", where), whereArgs...)
+	}
+
+	return strings.Join(selects, " UNION ALL "), args
+}
+
+// commentSelect selects the matching comments on the items of a table in the project
+func commentSelect(itemType, table, refColumn, extraWhere string) string {
+	return "SELECT '" + ResultTypeComment + "' AS result_type, c.id, '" + itemType + "' AS item_type, c.item_id, " +
+		refColumn + " AS ref_num, p.title, ts_rank(" + commentDocument.vector("c") + ", q.query) AS rank, " +
+		commentDocument.body("c") + " AS body, c.updated_at FROM comments c JOIN " + table + " p ON p.id = c.item_id, q " +
+		"WHERE c.item_type IN ? AND p.project_id = ? AND p.deleted_at IS NULL" + extraWhere +
+		" AND " + commentDocument.vector("c") + " @@ q.query"
+}
+
+func itemRefColumn(t *itemTable, alias string) string {
+	if t.refColumn == "" {
+		return "''"
+	}
+	return "COALESCE(" + qualify(alias, t.refColumn) + ", '')"
+}
+
+// highlightSnippet escapes a ts_headline snippet of stripped HTML and wraps the matches in <mark>
+func highlightSnippet(headline string) string {
+	text := strings.Join(strings.Fields(html.UnescapeString(headline)), " ")
+	return strings.NewReplacer(snippetStart, "<mark>", snippetStop, "</mark>").Replace(html.EscapeString(text))
+}
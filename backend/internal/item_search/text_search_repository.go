@@ -0,0 +1,49 @@
+package item_search
+
+import (
+	"github.com/dannyswat/pjeasy/internal/repositories"
+)
+
+type TextSearchRepository struct {
+	uow *repositories.UnitOfWork
+}
+
+func NewTextSearchRepository(uow *repositories.UnitOfWork) *TextSearchRepository {
+	return &TextSearchRepository{uow: uow}
+}
+
+const textSearchQueryCTE = "WITH q AS (SELECT websearch_to_tsquery('" + textSearchConfig + "', ?) AS query) "
+
+// Search returns a page of the documents matching the filter, best matches first
+func (r *TextSearchRepository) Search(filter TextSearchFilter, offset, limit int) ([]TextSearchResult, int64, error) {
+	union, args := buildTextSearchSQL(filter)
+	if union == "" {
+		return []TextSearchResult{}, 0, nil
+	}
+
+	db := r.uow.GetDB()
+	var total int64
+	countArgs := append([]interface{}{filter.Text}, args...)
+	if err := db.Raw(textSearchQueryCTE+"SELECT COUNT(*) FROM ("+union+") AS matches", countArgs...).Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Snippets are only built for the returned page
+	const order = " ORDER BY rank DESC, updated_at DESC, result_type ASC, id DESC"
+	pageArgs := append([]interface{}{filter.Text, snippetOptions}, args...)
+	pageArgs = append(pageArgs, limit, offset)
+	var rows []TextSearchResult
+	err := db.Raw(textSearchQueryCTE+
+		"SELECT result_type, id, item_type, item_id, ref_num, title, rank, updated_at, "+
+		"ts_headline('"+textSearchConfig+"', body, q.query, ?) AS snippet "+
+		"FROM ("+union+order+" LIMIT ? OFFSET ?) AS matches, q"+order, pageArgs...).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for i := range rows {
+		rows[i].Snippet = highlightSnippet(rows[i].Snippet)
+	}
+	return rows, total, nil
+}
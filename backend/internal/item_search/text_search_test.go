@@ -0,0 +1,86 @@
+package item_search
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHighlightSnippet(t *testing.T) {
+	tests := []struct {
+		headline string
+		want     string
+	}{
+		{headline: "the \x01export\x02 timeout", want: "the <mark>export</mark> timeout"},
+		{headline: "a  <b> & c   \x01timeout\x02", want: "a &lt;b&gt; &amp; c <mark>timeout</mark>"},
+		{headline: "Tom &amp; Jerry \x01export\x02", want: "Tom &amp; Jerry <mark>export</mark>"},
+		{headline: "&lt;script&gt;", want: "&lt;script&gt;"},
+	}
+
+	for _, tt := range tests {
+		if got := highlightSnippet(tt.headline); got != tt.want {
+			t.Fatalf("highlightSnippet(%q) = %q, want %q", tt.headline, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeResultType(t *testing.T) {
+	tests := []struct {
+		spelling string
+		want     string
+		ok       bool
+	}{
+		{spelling: "issues", want: ItemTypeIssue, ok: true},
+		{spelling: " Tickets ", want: ItemTypeServiceTicket, ok: true},
+		{spelling: "comments", want: ResultTypeComment, ok: true},
+		{spelling: "wiki", want: ResultTypeWikiPage, ok: true},
+		{spelling: "sprint", ok: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := NormalizeResultType(tt.spelling)
+		if got != tt.want || ok != tt.ok {
+			t.Fatalf("NormalizeResultType(%q) = %q, %v, want %q, %v", tt.spelling, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestBuildTextSearchSQL(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  TextSearchFilter
+		selects int
+		limited bool
+	}{
+		{name: "every result type", filter: TextSearchFilter{ProjectID: 1}, selects: len(itemTables) + 1 + len(itemTables) + 1},
+		{name: "limited reader", filter: TextSearchFilter{ProjectID: 1, LimitedReader: true}, selects: len(itemTables) + 1 + len(itemTables) + 1, limited: true},
+		{name: "wiki pages only", filter: TextSearchFilter{ProjectID: 1, ResultTypes: []string{ResultTypeWikiPage}, LimitedReader: true}, selects: 1, limited: true},
+		{name: "issues only", filter: TextSearchFilter{ProjectID: 1, ResultTypes: []string{ItemTypeIssue}, LimitedReader: true}, selects: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args := buildTextSearchSQL(tt.filter)
+			if got := len(strings.Split(sql, " UNION ALL ")); got != tt.selects {
+				t.Fatalf("buildTextSearchSQL() has %d selects, want %d", got, tt.selects)
+			}
+			if got, want := strings.Count(sql, "?"), len(args); got != want {
+				t.Fatalf("buildTextSearchSQL() has %d placeholders and %d arguments", got, want)
+			}
+			if got := strings.Contains(sql, "protected = ?"); got != tt.limited {
+				t.Fatalf("wiki page restriction present = %v, want %v", got, tt.limited)
+			}
+		})
+	}
+}
+
+func TestTextSearchIndexesMatchSearchConditions(t *testing.T) {
+	sql, _ := buildTextSearchSQL(TextSearchFilter{ProjectID: 1})
+	// Comment columns are qualified in the search, which does not change the expression
+	sql = strings.ReplaceAll(sql, "c.content", "content")
+	for _, statement := range TextSearchIndexStatements() {
+		expression := statement[strings.Index(statement, "((")+2 : len(statement)-2]
+		if !strings.Contains(sql, expression+" @@ q.query") {
+			t.Fatalf("no search condition uses the index expression %s", expression)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package mailer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileMailerWritesMessage(t *testing.T) {
+	dir := t.TempDir()
+	m := NewFileMailer(dir, "PJEasy <noreply@example.com>")
+
+	err := m.Send(&Message{
+		To:      []string{"jane@example.com"},
+		Subject: "Reset your password",
+		Body:    "line one\nline two",
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one .eml file, got %v (err %v)", files, err)
+	}
+
+	content, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("read message: %v", err)
+	}
+	for _, want := range []string{"To: jane@example.com\r\n", "Subject: Reset your password\r\n", "line one\r\nline two"} {
+		if !strings.Contains(string(content), want) {
+			t.Fatalf("message missing %q:\n%s", want, content)
+		}
+	}
+}
+
+func TestMessageValidateRejectsHeaderInjection(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  Message
+	}{
+		{name: "no recipients", msg: Message{Subject: "hi"}},
+		{name: "newline in recipient", msg: Message{To: []string{"a@example.com\r\nBcc: b@example.com"}}},
+		{name: "newline in subject", msg: Message{To: []string{"a@example.com"}, Subject: "hi\r\nBcc: b@example.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.msg.Validate(); err == nil {
+				t.Fatalf("Validate() expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Mailer sends email messages
+type Mailer interface {
+	Send(msg *Message) error
+}
+
+// Validate checks the message has recipients and no header injection
+func (m *Message) Validate() error {
+	if len(m.To) == 0 {
+		return errors.New("message has no recipients")
+	}
+	for _, to := range m.To {
+		if strings.ContainsAny(to, "\r\n") || !strings.Contains(to, "@") {
+			return fmt.Errorf("invalid recipient: %q", to)
+		}
+	}
+	if strings.ContainsAny(m.Subject, "\r\n") {
+		return errors.New("invalid subject")
+	}
+	return nil
+}
+
+// Format renders the message in RFC 5322 format
+func (m *Message) Format(from string) []byte {
+	var sb strings.Builder
+	sb.WriteString("From: " + from + "\r\n")
+	sb.WriteString("To: " + strings.Join(m.To, ", ") + "\r\n")
+	sb.WriteString("Subject: " + m.Subject + "\r\n")
+	sb.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	sb.WriteString("\r\n")
+	sb.WriteString(strings.ReplaceAll(m.Body, "\n", "\r\n"))
+	return []byte(sb.String())
+}
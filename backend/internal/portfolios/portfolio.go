@@ -0,0 +1,151 @@
+package portfolios
+
+import (
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/projects"
+)
+
+// Item types counted in the portfolio, spelled as in project role permissions
+const (
+	ItemTypeIdea          = "idea"
+	ItemTypeIssue         = "issue"
+	ItemTypeFeature       = "feature"
+	ItemTypeTask          = "task"
+	ItemTypeServiceTicket = "service-ticket"
+)
+
+// UpcomingReleaseLimit is the number of upcoming releases listed per project
+const UpcomingReleaseLimit = 5
+
+// ProjectPortfolio is the status of one project in a portfolio
+type ProjectPortfolio struct {
+	Project          projects.Project
+	ItemCounts       []ItemTypeCounts
+	ActiveSprint     *SprintProgress
+	UpcomingReleases []UpcomingRelease
+	TicketBacklog    TicketBacklogAge
+	TimeLogged       TimeLogged
+}
+
+// ItemTypeCounts counts the open items of a type and how many of them are past their deadline.
+// Item types without deadlines are never overdue.
+type ItemTypeCounts struct {
+	ItemType string
+	Open     int
+	Overdue  int
+}
+
+// SprintProgress summarizes the items planned in the active sprint of a project
+type SprintProgress struct {
+	SprintID        int
+	Name            string
+	StartDate       *time.Time
+	EndDate         *time.Time
+	TotalItems      int
+	CompletedItems  int
+	TotalPoints     int
+	CompletedPoints int
+}
+
+// UpcomingRelease is an unfinished release with a target date
+type UpcomingRelease struct {
+	ID         int
+	Version    string
+	Status     string
+	TargetDate time.Time
+	Overdue    bool
+}
+
+// TicketBacklogAge summarizes how long the open service tickets of a project have been waiting
+type TicketBacklogAge struct {
+	OpenCount      int
+	AverageAgeDays float64
+	OldestAgeDays  int
+	UnderWeek      int // Opened less than 7 days ago
+	UnderMonth     int // Opened 7 to 30 days ago
+	OverMonth      int // Opened more than 30 days ago
+}
+
+// TimeLogged is the time logged on a project's daily boards over the portfolio period
+type TimeLogged struct {
+	TotalUnits int
+	TotalHours float64
+}
+
+// Portfolio is the status of a page of projects over a period
+type Portfolio struct {
+	Projects  []ProjectPortfolio
+	Total     int64
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+// itemTable describes how open and overdue items of a type are counted
+type itemTable struct {
+	itemType       string
+	table          string
+	doneStatuses   []string
+	deadlineColumn string // Empty when the item type has no deadline
+}
+
+var itemTables = []itemTable{
+	{itemType: ItemTypeIdea, table: "ideas", doneStatuses: []string{"Closed"}},
+	{itemType: ItemTypeIssue, table: "issues", doneStatuses: []string{"Completed", "Rejected", "Closed"}},
+	{itemType: ItemTypeFeature, table: "features", doneStatuses: []string{"Completed", "Rejected", "Closed"}, deadlineColumn: "deadline"},
+	{itemType: ItemTypeTask, table: "tasks", doneStatuses: []string{"Completed", "Rejected", "Closed"}, deadlineColumn: "deadline"},
+	{itemType: ItemTypeServiceTicket, table: "service_tickets", doneStatuses: []string{"Fulfilled", "Closed"}},
+}
+
+// sprintItemTables are the item tables planned in sprints and whether they carry story points
+var sprintItemTables = []struct {
+	table     string
+	hasPoints bool
+}{
+	{table: "issues", hasPoints: true},
+	{table: "features", hasPoints: true},
+	{table: "tasks"},
+}
+
+// sprintDoneStatuses are the statuses counted as completed in sprint progress
+var sprintDoneStatuses = []string{"Completed", "Closed"}
+
+// finishedReleaseStatuses are the release statuses excluded from upcoming releases
+var finishedReleaseStatuses = []string{"Completed", "Abandoned", "RolledBack"}
+
+// openTicketStatuses are the service ticket statuses counted in the backlog
+var openTicketStatuses = []string{"New", "Open"}
+
+// ticketBacklogAge summarizes the ages of open tickets created at the given times
+func ticketBacklogAge(createdAt []time.Time, now time.Time) TicketBacklogAge {
+	backlog := TicketBacklogAge{OpenCount: len(createdAt)}
+	if len(createdAt) == 0 {
+		return backlog
+	}
+
+	totalDays := 0.0
+	for _, created := range createdAt {
+		age := now.Sub(created)
+		days := age.Hours() / 24
+		totalDays += days
+		if int(days) > backlog.OldestAgeDays {
+			backlog.OldestAgeDays = int(days)
+		}
+
+		switch {
+		case days < 7:
+			backlog.UnderWeek++
+		case days <= 30:
+			backlog.UnderMonth++
+		default:
+			backlog.OverMonth++
+		}
+	}
+	backlog.AverageAgeDays = totalDays / float64(len(createdAt))
+	return backlog
+}
+
+// unitHours converts daily board time units to hours. One unit is one hour.
+func unitHours(units int) float64 {
+	return float64(units)
+}
@@ -0,0 +1,215 @@
+package portfolios
+
+import (
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/repositories"
+)
+
+// PortfolioRepository aggregates item, sprint, release and time log data across projects
+type PortfolioRepository struct {
+	uow *repositories.UnitOfWork
+}
+
+func NewPortfolioRepository(uow *repositories.UnitOfWork) *PortfolioRepository {
+	return &PortfolioRepository{uow: uow}
+}
+
+type itemCountRow struct {
+	ProjectID int
+	Open      int
+	Overdue   int
+}
+
+// CountOpenItems counts the open and overdue items of a type per project
+func (r *PortfolioRepository) CountOpenItems(t *itemTable, projectIDs []int, now time.Time) (map[int]ItemTypeCounts, error) {
+	overdue := "0"
+	args := []interface{}{}
+	if t.deadlineColumn != "" {
+		overdue = "COUNT(*) FILTER (WHERE " + t.deadlineColumn + " < ?)"
+		args = append(args, now)
+	}
+
+	var rows []itemCountRow
+	err := r.uow.GetDB().Table(t.table).
+		Select("project_id, COUNT(*) AS open, "+overdue+" AS overdue", args...).
+		Where("project_id IN ? AND deleted_at IS NULL AND status NOT IN ?", projectIDs, t.doneStatuses).
+		Group("project_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[int]ItemTypeCounts, len(rows))
+	for _, row := range rows {
+		counts[row.ProjectID] = ItemTypeCounts{ItemType: t.itemType, Open: row.Open, Overdue: row.Overdue}
+	}
+	return counts, nil
+}
+
+type activeSprintRow struct {
+	ID        int
+	ProjectID int
+	Name      string
+	StartDate *time.Time
+	EndDate   *time.Time
+}
+
+type sprintItemRow struct {
+	SprintID        int
+	TotalItems      int
+	CompletedItems  int
+	TotalPoints     int
+	CompletedPoints int
+}
+
+// GetActiveSprintProgress returns the progress of the active sprint of each project
+func (r *PortfolioRepository) GetActiveSprintProgress(projectIDs []int) (map[int]*SprintProgress, error) {
+	db := r.uow.GetDB()
+
+	var sprints []activeSprintRow
+	err := db.Table("sprints").
+		Select("id, project_id, name, start_date, end_date").
+		Where("project_id IN ? AND status = ?", projectIDs, "Active").
+		Order("id ASC").
+		Scan(&sprints).Error
+	if err != nil {
+		return nil, err
+	}
+
+	progress := make(map[int]*SprintProgress, len(sprints))
+	bySprint := make(map[int]*SprintProgress, len(sprints))
+	sprintIDs := make([]int, 0, len(sprints))
+	for _, sprint := range sprints {
+		if _, ok := progress[sprint.ProjectID]; ok {
+			continue
+		}
+		p := &SprintProgress{
+			SprintID:  sprint.ID,
+			Name:      sprint.Name,
+			StartDate: sprint.StartDate,
+			EndDate:   sprint.EndDate,
+		}
+		progress[sprint.ProjectID] = p
+		bySprint[sprint.ID] = p
+		sprintIDs = append(sprintIDs, sprint.ID)
+	}
+	if len(sprintIDs) == 0 {
+		return progress, nil
+	}
+
+	for _, t := range sprintItemTables {
+		points := "0 AS total_points, 0 AS completed_points"
+		args := []interface{}{sprintDoneStatuses}
+		if t.hasPoints {
+			points = "COALESCE(SUM(points), 0) AS total_points, COALESCE(SUM(points) FILTER (WHERE status IN ?), 0) AS completed_points"
+			args = append(args, sprintDoneStatuses)
+		}
+
+		var rows []sprintItemRow
+		err := db.Table(t.table).
+			Select("sprint_id, COUNT(*) AS total_items, COUNT(*) FILTER (WHERE status IN ?) AS completed_items, "+points, args...).
+			Where("sprint_id IN ? AND deleted_at IS NULL", sprintIDs).
+			Group("sprint_id").
+			Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range rows {
+			p := bySprint[row.SprintID]
+			if p == nil {
+				continue
+			}
+			p.TotalItems += row.TotalItems
+			p.CompletedItems += row.CompletedItems
+			p.TotalPoints += row.TotalPoints
+			p.CompletedPoints += row.CompletedPoints
+		}
+	}
+
+	return progress, nil
+}
+
+type upcomingReleaseRow struct {
+	ID         int
+	ProjectID  int
+	Version    string
+	Status     string
+	TargetDate time.Time
+}
+
+// ListUpcomingReleases returns the unfinished releases with a target date of each project, earliest target first
+func (r *PortfolioRepository) ListUpcomingReleases(projectIDs []int, now time.Time) (map[int][]UpcomingRelease, error) {
+	var rows []upcomingReleaseRow
+	err := r.uow.GetDB().Table("releases").
+		Select("id, project_id, version, status, target_date").
+		Where("project_id IN ? AND target_date IS NOT NULL AND status NOT IN ?", projectIDs, finishedReleaseStatuses).
+		Order("target_date ASC, id ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	releases := make(map[int][]UpcomingRelease)
+	for _, row := range rows {
+		if len(releases[row.ProjectID]) >= UpcomingReleaseLimit {
+			continue
+		}
+		releases[row.ProjectID] = append(releases[row.ProjectID], UpcomingRelease{
+			ID:         row.ID,
+			Version:    row.Version,
+			Status:     row.Status,
+			TargetDate: row.TargetDate,
+			Overdue:    row.TargetDate.Before(now),
+		})
+	}
+	return releases, nil
+}
+
+type openTicketRow struct {
+	ProjectID int
+	CreatedAt time.Time
+}
+
+// ListOpenTicketDates returns when the open service tickets of each project were created
+func (r *PortfolioRepository) ListOpenTicketDates(projectIDs []int) (map[int][]time.Time, error) {
+	var rows []openTicketRow
+	err := r.uow.GetDB().Table("service_tickets").
+		Select("project_id, created_at").
+		Where("project_id IN ? AND deleted_at IS NULL AND status IN ?", projectIDs, openTicketStatuses).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	dates := make(map[int][]time.Time)
+	for _, row := range rows {
+		dates[row.ProjectID] = append(dates[row.ProjectID], row.CreatedAt)
+	}
+	return dates, nil
+}
+
+type timeLoggedRow struct {
+	ProjectID  int
+	TotalUnits int
+}
+
+// SumTimeLogged returns the time units logged on each project between two dates, inclusive
+func (r *PortfolioRepository) SumTimeLogged(projectIDs []int, startDate, endDate time.Time) (map[int]int, error) {
+	var rows []timeLoggedRow
+	err := r.uow.GetDB().Table("user_daily_time_logs").
+		Select("project_id, COALESCE(SUM(duration_units), 0) AS total_units").
+		Where("project_id IN ? AND log_date >= ? AND log_date <= ?", projectIDs, startDate, endDate).
+		Group("project_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	units := make(map[int]int, len(rows))
+	for _, row := range rows {
+		units[row.ProjectID] = row.TotalUnits
+	}
+	return units, nil
+}
@@ -0,0 +1,118 @@
+package portfolios
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/projects"
+	userroles "github.com/dannyswat/pjeasy/internal/user_roles"
+)
+
+// MaxPeriodDays is the longest period time logged can be summed over
+const MaxPeriodDays = 366
+
+type PortfolioService struct {
+	portfolioRepo *PortfolioRepository
+	projectRepo   *projects.ProjectRepository
+	adminService  *userroles.SystemAdminService
+}
+
+func NewPortfolioService(portfolioRepo *PortfolioRepository, projectRepo *projects.ProjectRepository, adminService *userroles.SystemAdminService) *PortfolioService {
+	return &PortfolioService{
+		portfolioRepo: portfolioRepo,
+		projectRepo:   projectRepo,
+		adminService:  adminService,
+	}
+}
+
+// GetPortfolio returns the status of the projects a user oversees: every project for system admins,
+// otherwise the projects the user administers. Time logged is summed between the two dates, inclusive.
+func (s *PortfolioService) GetPortfolio(userID int, includeArchived bool, startDate, endDate time.Time, page, pageSize int) (*Portfolio, error) {
+	if endDate.Before(startDate) {
+		return nil, errors.New("end date must not be before start date")
+	}
+	if endDate.Sub(startDate) >= MaxPeriodDays*24*time.Hour {
+		return nil, errors.New("period cannot be longer than a year")
+	}
+
+	isSystemAdmin, err := s.adminService.IsUserAdmin(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := (page - 1) * pageSize
+	var projectList []projects.Project
+	var total int64
+	if isSystemAdmin {
+		projectList, total, err = s.projectRepo.GetAll(includeArchived, offset, pageSize)
+	} else {
+		projectList, total, err = s.projectRepo.GetByAdminUserID(userID, includeArchived, offset, pageSize)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	portfolio := &Portfolio{
+		Projects:  make([]ProjectPortfolio, len(projectList)),
+		Total:     total,
+		StartDate: startDate,
+		EndDate:   endDate,
+	}
+	if len(projectList) == 0 {
+		return portfolio, nil
+	}
+
+	projectIDs := make([]int, len(projectList))
+	for i, project := range projectList {
+		projectIDs[i] = project.ID
+	}
+
+	now := time.Now()
+	counts := make([]map[int]ItemTypeCounts, len(itemTables))
+	for i := range itemTables {
+		counts[i], err = s.portfolioRepo.CountOpenItems(&itemTables[i], projectIDs, now)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sprints, err := s.portfolioRepo.GetActiveSprintProgress(projectIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	releases, err := s.portfolioRepo.ListUpcomingReleases(projectIDs, now)
+	if err != nil {
+		return nil, err
+	}
+
+	ticketDates, err := s.portfolioRepo.ListOpenTicketDates(projectIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	timeLogged, err := s.portfolioRepo.SumTimeLogged(projectIDs, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, project := range projectList {
+		itemCounts := make([]ItemTypeCounts, len(itemTables))
+		for j, t := range itemTables {
+			itemCounts[j] = counts[j][project.ID]
+			itemCounts[j].ItemType = t.itemType
+		}
+
+		units := timeLogged[project.ID]
+		portfolio.Projects[i] = ProjectPortfolio{
+			Project:          project,
+			ItemCounts:       itemCounts,
+			ActiveSprint:     sprints[project.ID],
+			UpcomingReleases: releases[project.ID],
+			TicketBacklog:    ticketBacklogAge(ticketDates[project.ID], now),
+			TimeLogged:       TimeLogged{TotalUnits: units, TotalHours: unitHours(units)},
+		}
+	}
+
+	return portfolio, nil
+}
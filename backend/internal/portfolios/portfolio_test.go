@@ -0,0 +1,54 @@
+package portfolios
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/projects"
+)
+
+func TestItemTablesUsePermissionItemTypes(t *testing.T) {
+	for _, table := range itemTables {
+		if !projects.IsValidPermission(table.itemType, projects.PermissionActionCreate) {
+			t.Fatalf("item type %q is not a permission item type", table.itemType)
+		}
+	}
+}
+
+func TestTicketBacklogAge(t *testing.T) {
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) time.Time {
+		return now.Add(-time.Duration(days) * 24 * time.Hour)
+	}
+
+	tests := []struct {
+		name      string
+		createdAt []time.Time
+		want      TicketBacklogAge
+	}{
+		{
+			name: "empty backlog",
+			want: TicketBacklogAge{},
+		},
+		{
+			name:      "tickets in every age bucket",
+			createdAt: []time.Time{daysAgo(2), daysAgo(10), daysAgo(30), daysAgo(46)},
+			want: TicketBacklogAge{
+				OpenCount:      4,
+				AverageAgeDays: 22,
+				OldestAgeDays:  46,
+				UnderWeek:      1,
+				UnderMonth:     2,
+				OverMonth:      1,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ticketBacklogAge(tt.createdAt, now); got != tt.want {
+				t.Fatalf("ticketBacklogAge() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package project_archives
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/comments"
+	"github.com/dannyswat/pjeasy/internal/custom_fields"
+	"github.com/dannyswat/pjeasy/internal/features"
+	"github.com/dannyswat/pjeasy/internal/ideas"
+	"github.com/dannyswat/pjeasy/internal/issues"
+	"github.com/dannyswat/pjeasy/internal/item_follow_ups"
+	"github.com/dannyswat/pjeasy/internal/item_links"
+	"github.com/dannyswat/pjeasy/internal/projects"
+	"github.com/dannyswat/pjeasy/internal/releases"
+	"github.com/dannyswat/pjeasy/internal/reviews"
+	"github.com/dannyswat/pjeasy/internal/sequences"
+	"github.com/dannyswat/pjeasy/internal/service_tickets"
+	"github.com/dannyswat/pjeasy/internal/sprints"
+	"github.com/dannyswat/pjeasy/internal/status_changes"
+	"github.com/dannyswat/pjeasy/internal/tasks"
+	"github.com/dannyswat/pjeasy/internal/user_dailies"
+	"github.com/dannyswat/pjeasy/internal/wiki_pages"
+)
+
+// FormatVersion is the archive layout written by ExportProject. Imports accept this version and older ones.
+const FormatVersion = 1
+
+// Archive paths. Entities are stored as one JSON array per file under data/, uploaded files under files/.
+const (
+	manifestFile     = "manifest.json"
+	dataDir          = "data/"
+	projectFilesDir  = "files/project/"
+	imageFilesDir    = "files/images/"
+	maxArchiveFileMB = 50
+)
+
+// ArchiveManifest describes the archive and the project it was exported from
+type ArchiveManifest struct {
+	FormatVersion   int       `json:"formatVersion"`
+	ExportedAt      time.Time `json:"exportedAt"`
+	SourceProjectID int       `json:"sourceProjectId"`
+	ProjectName     string    `json:"projectName"`
+}
+
+// ArchiveUser identifies a user referenced by the archive. Users are matched by login ID on import.
+type ArchiveUser struct {
+	ID      int    `json:"id"`
+	LoginID string `json:"loginId"`
+	Name    string `json:"name"`
+}
+
+// ProjectArchive holds every entity of one project. IDs are those of the source instance.
+type ProjectArchive struct {
+	Manifest           ArchiveManifest
+	Project            projects.Project
+	Users              []ArchiveUser
+	Roles              []projects.ProjectRole
+	Members            []projects.ProjectMember
+	Settings           []projects.ProjectSetting
+	Sequences          []sequences.Sequence
+	SequenceNumbers    []sequences.SequenceNumber
+	StatusFlows        []status_changes.StatusFlow
+	Releases           []releases.Release
+	Sprints            []sprints.Sprint
+	ServiceTickets     []service_tickets.ServiceTicket
+	Ideas              []ideas.Idea
+	Features           []features.Feature
+	Issues             []issues.Issue
+	Tasks              []tasks.Task
+	ItemLinks          []item_links.ItemLink
+	CustomFields       []custom_fields.CustomField
+	CustomFieldValues  []custom_fields.CustomFieldValue
+	CustomFieldChanges []custom_fields.CustomFieldChange
+	WikiPages          []wiki_pages.WikiPage
+	WikiPageChanges    []wiki_pages.WikiPageChange
+	Comments           []comments.Comment
+	FollowUps          []item_follow_ups.ItemFollowUp
+	StatusChanges      []status_changes.StatusChange
+	Reviews            []reviews.Review
+	ReviewItems        []reviews.ReviewItem
+	UserDailyItems     []user_dailies.UserDailyItem
+	UserDailyTimeLogs  []user_dailies.UserDailyTimeLog
+}
+
+// archiveEntry pairs an archive file with the value it is encoded from and decoded into
+type archiveEntry struct {
+	name  string
+	value any
+}
+
+// entries lists the JSON files of the archive in import order
+func (a *ProjectArchive) entries() []archiveEntry {
+	return []archiveEntry{
+		{manifestFile, &a.Manifest},
+		{dataDir + "project.json", &a.Project},
+		{dataDir + "users.json", &a.Users},
+		{dataDir + "roles.json", &a.Roles},
+		{dataDir + "members.json", &a.Members},
+		{dataDir + "project_settings.json", &a.Settings},
+		{dataDir + "sequences.json", &a.Sequences},
+		{dataDir + "sequence_numbers.json", &a.SequenceNumbers},
+		{dataDir + "status_flows.json", &a.StatusFlows},
+		{dataDir + "releases.json", &a.Releases},
+		{dataDir + "sprints.json", &a.Sprints},
+		{dataDir + "service_tickets.json", &a.ServiceTickets},
+		{dataDir + "ideas.json", &a.Ideas},
+		{dataDir + "features.json", &a.Features},
+		{dataDir + "issues.json", &a.Issues},
+		{dataDir + "tasks.json", &a.Tasks},
+		{dataDir + "item_links.json", &a.ItemLinks},
+		{dataDir + "custom_fields.json", &a.CustomFields},
+		{dataDir + "custom_field_values.json", &a.CustomFieldValues},
+		{dataDir + "custom_field_changes.json", &a.CustomFieldChanges},
+		{dataDir + "wiki_pages.json", &a.WikiPages},
+		{dataDir + "wiki_page_changes.json", &a.WikiPageChanges},
+		{dataDir + "comments.json", &a.Comments},
+		{dataDir + "item_follow_ups.json", &a.FollowUps},
+		{dataDir + "status_changes.json", &a.StatusChanges},
+		{dataDir + "reviews.json", &a.Reviews},
+		{dataDir + "review_items.json", &a.ReviewItems},
+		{dataDir + "user_daily_items.json", &a.UserDailyItems},
+		{dataDir + "user_daily_time_logs.json", &a.UserDailyTimeLogs},
+	}
+}
+
+// Item kinds used to remap references. Each module spells item types differently
+// ("ideas", "idea", "service-tickets", "service_ticket", "wiki"), so references are normalized first.
+const (
+	kindIdea          = "idea"
+	kindIssue         = "issue"
+	kindFeature       = "feature"
+	kindTask          = "task"
+	kindServiceTicket = "service-ticket"
+	kindWikiPage      = "wiki-page"
+	kindSprint        = "sprint"
+	kindRelease       = "release"
+	kindReview        = "review"
+)
+
+// itemKind normalizes an item type spelling to its kind, or returns "" for unknown types
+func itemKind(itemType string) string {
+	normalized := strings.ToLower(strings.TrimSpace(itemType))
+	normalized = strings.ReplaceAll(normalized, "_", "-")
+	normalized = strings.ReplaceAll(normalized, " ", "-")
+	switch normalized {
+	case "idea", "ideas":
+		return kindIdea
+	case "issue", "issues":
+		return kindIssue
+	case "feature", "features":
+		return kindFeature
+	case "task", "tasks":
+		return kindTask
+	case "service-ticket", "service-tickets", "serviceticket", "servicetickets":
+		return kindServiceTicket
+	case "wiki", "wiki-page", "wiki-pages", "wikipage", "wikipages":
+		return kindWikiPage
+	case "sprint", "sprints":
+		return kindSprint
+	case "release", "releases":
+		return kindRelease
+	case "review", "reviews":
+		return kindReview
+	}
+	return ""
+}
+
+// idMap maps IDs of the source project to the imported rows, by item kind
+type idMap map[string]map[int]int
+
+func (m idMap) set(kind string, sourceID, newID int) {
+	if m[kind] == nil {
+		m[kind] = make(map[int]int)
+	}
+	m[kind][sourceID] = newID
+}
+
+// get returns the imported ID of a row, or false when the row was not imported
+func (m idMap) get(kind string, sourceID int) (int, bool) {
+	newID, ok := m[kind][sourceID]
+	return newID, ok
+}
+
+// getPtr remaps an optional reference, clearing it when the row was not imported
+func (m idMap) getPtr(kind string, sourceID *int) *int {
+	if sourceID == nil {
+		return nil
+	}
+	newID, ok := m.get(kind, *sourceID)
+	if !ok {
+		return nil
+	}
+	return &newID
+}
+
+// getItem remaps a reference stored as an item type and ID, keeping the original type spelling
+func (m idMap) getItem(itemType string, sourceID int) (int, bool) {
+	return m.get(itemKind(itemType), sourceID)
+}
+
+// remapRef remaps an optional item reference, clearing both fields when the item was not imported
+func (m idMap) remapRef(itemType string, itemID *int) (string, *int) {
+	if itemType == "" || itemID == nil {
+		return "", nil
+	}
+	newID, ok := m.getItem(itemType, *itemID)
+	if !ok {
+		return "", nil
+	}
+	return itemType, &newID
+}
+
+// refNumSet tracks the reference numbers taken in the target project, by sequence item type
+type refNumSet map[string]map[string]bool
+
+// claim takes a reference number, returning false when it is empty or already taken
+func (s refNumSet) claim(sequenceType, refNum string) bool {
+	if refNum == "" || s[sequenceType][refNum] {
+		return false
+	}
+	if s[sequenceType] == nil {
+		s[sequenceType] = make(map[string]bool)
+	}
+	s[sequenceType][refNum] = true
+	return true
+}
+
+var imageURLPattern = regexp.MustCompile(`/uploads/images/([A-Za-z0-9._-]+)`)
+
+// referencedImages returns the uploaded image file names linked from the content
+func referencedImages(content string) []string {
+	var names []string
+	for _, match := range imageURLPattern.FindAllStringSubmatch(content, -1) {
+		names = append(names, match[1])
+	}
+	return names
+}
+
+// diagramURLRewriter points diagram links of the source project at the imported project
+func diagramURLRewriter(sourceProjectID, projectID int) *strings.Replacer {
+	return strings.NewReplacer(
+		fmt.Sprintf("/api/projects/%d/diagrams/", sourceProjectID),
+		fmt.Sprintf("/api/projects/%d/diagrams/", projectID),
+	)
+}
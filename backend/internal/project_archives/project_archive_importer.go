@@ -0,0 +1,660 @@
+package project_archives
+
+import (
+	"strings"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/custom_fields"
+	"github.com/dannyswat/pjeasy/internal/features"
+	"github.com/dannyswat/pjeasy/internal/ideas"
+	"github.com/dannyswat/pjeasy/internal/issues"
+	"github.com/dannyswat/pjeasy/internal/item_links"
+	"github.com/dannyswat/pjeasy/internal/projects"
+	"github.com/dannyswat/pjeasy/internal/repositories"
+	"github.com/dannyswat/pjeasy/internal/sequences"
+	"github.com/dannyswat/pjeasy/internal/tasks"
+	"github.com/dannyswat/pjeasy/internal/wiki_pages"
+)
+
+// archiveImporter writes the entities of an archive into a new project using one transaction
+type archiveImporter struct {
+	uow         *repositories.UnitOfWork
+	archiveRepo *ProjectArchiveRepository
+	sequences   *sequences.SequenceRepository
+	archive     *ProjectArchive
+	users       userIDMap
+	importedBy  int
+	ids         idMap
+	refNums     refNumSet
+	rewriter    *strings.Replacer
+	now         time.Time
+
+	project    *projects.Project
+	projectID  int
+	renumbered int
+}
+
+func (im *archiveImporter) run() error {
+	steps := []func() error{
+		im.importProject,
+		im.importAccess,
+		im.importSettings,
+		im.importSequences,
+		im.importStatusFlows,
+		im.importSchedule,
+		im.importItems,
+		im.importCustomFields,
+		im.importWikiPages,
+		im.importActivity,
+		im.importReviews,
+		im.importTimeLogs,
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (im *archiveImporter) importProject() error {
+	source := im.archive.Project
+	project := &projects.Project{
+		Name:          source.Name,
+		Description:   source.Description,
+		RepositoryURL: source.RepositoryURL,
+		IsArchived:    source.IsArchived,
+		IsTemplate:    source.IsTemplate,
+		CreatedBy:     im.users.author(source.CreatedBy),
+		CreatedAt:     source.CreatedAt,
+		UpdatedAt:     im.now,
+		ArchivedAt:    source.ArchivedAt,
+	}
+	if err := im.archiveRepo.Create(project); err != nil {
+		return err
+	}
+
+	im.project = project
+	im.projectID = project.ID
+	im.rewriter = diagramURLRewriter(im.archive.Manifest.SourceProjectID, project.ID)
+	project.Description = im.rewriter.Replace(project.Description)
+	return im.archiveRepo.Save(project)
+}
+
+// importAccess copies roles and the members matched on this instance, then makes the importer an admin
+func (im *archiveImporter) importAccess() error {
+	sourceRoles := im.archive.Roles
+	if len(sourceRoles) == 0 {
+		sourceRoles = projects.BuiltInRoles(0)
+	}
+
+	roleIDs := make(map[int]int, len(sourceRoles))
+	roles := make([]*projects.ProjectRole, 0, len(sourceRoles))
+	for i := range sourceRoles {
+		role := sourceRoles[i]
+		sourceID := role.ID
+		role.ID = 0
+		role.ProjectID = im.projectID
+		role.Permissions = append([]projects.ProjectRolePermission(nil), role.Permissions...)
+		for j := range role.Permissions {
+			role.Permissions[j].RoleID = 0
+		}
+		if role.CreatedAt.IsZero() {
+			role.CreatedAt = im.now
+		}
+		role.UpdatedAt = im.now
+		if err := im.archiveRepo.Create(&role); err != nil {
+			return err
+		}
+		roleIDs[sourceID] = role.ID
+		roles = append(roles, &role)
+	}
+
+	roleFor := func(member projects.ProjectMember) *projects.ProjectRole {
+		if roleID, ok := roleIDs[member.RoleID]; ok && member.RoleID != 0 {
+			for _, role := range roles {
+				if role.ID == roleID {
+					return role
+				}
+			}
+		}
+		key := projects.BuiltInRoleKeyFor(member.IsAdmin, member.IsUser)
+		for _, role := range roles {
+			if role.BuiltInKey == key {
+				return role
+			}
+		}
+		return nil
+	}
+	var adminRole *projects.ProjectRole
+	for _, role := range roles {
+		if role.IsAdmin && (adminRole == nil || role.BuiltInKey == projects.BuiltInRoleAdmin) {
+			adminRole = role
+		}
+	}
+
+	added := make(map[int]*projects.ProjectMember)
+	for _, source := range im.archive.Members {
+		userID, ok := im.users.get(source.UserID)
+		if !ok || added[userID] != nil {
+			continue
+		}
+		member := &projects.ProjectMember{
+			ProjectID: im.projectID,
+			UserID:    userID,
+			IsAdmin:   source.IsAdmin,
+			IsUser:    source.IsUser,
+			AddedAt:   source.AddedAt,
+			AddedBy:   im.users.author(source.AddedBy),
+		}
+		if role := roleFor(source); role != nil {
+			member.ApplyRole(role)
+		}
+		if err := im.archiveRepo.Create(member); err != nil {
+			return err
+		}
+		added[userID] = member
+	}
+
+	member := added[im.importedBy]
+	if member != nil && member.IsAdmin {
+		return nil
+	}
+	if member == nil {
+		member = &projects.ProjectMember{ProjectID: im.projectID, UserID: im.importedBy, AddedAt: im.now, AddedBy: im.importedBy}
+	}
+	member.IsAdmin = true
+	member.IsUser = false
+	if adminRole != nil {
+		member.ApplyRole(adminRole)
+	}
+	if member.ID == 0 {
+		return im.archiveRepo.Create(member)
+	}
+	return im.archiveRepo.Save(member)
+}
+
+func (im *archiveImporter) importSettings() error {
+	for _, setting := range im.archive.Settings {
+		setting.ProjectID = im.projectID
+		setting.UpdatedBy = im.users.author(setting.UpdatedBy)
+		if err := im.archiveRepo.Create(&setting); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importSequences copies the sequence settings and counters, adding any missing default sequences
+func (im *archiveImporter) importSequences() error {
+	for _, sequence := range im.archive.Sequences {
+		sequence.ID = 0
+		sequence.ProjectID = im.projectID
+		if err := im.sequences.CreateSequence(&sequence); err != nil {
+			return err
+		}
+	}
+	if err := sequences.NewSequenceService(im.sequences).GenerateProjectSequences(im.projectID); err != nil {
+		return err
+	}
+	for _, number := range im.archive.SequenceNumbers {
+		if err := im.sequences.ResetSequenceNumber(im.uow, im.projectID, number.ItemType, number.NextNumber); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (im *archiveImporter) importStatusFlows() error {
+	for _, flow := range im.archive.StatusFlows {
+		flow.ID = 0
+		flow.ProjectID = im.projectID
+		if err := im.archiveRepo.Create(&flow); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importSchedule copies releases and sprints. Sprint milestones are not part of the archive.
+func (im *archiveImporter) importSchedule() error {
+	for _, release := range im.archive.Releases {
+		sourceID := release.ID
+		release.ID = 0
+		release.ProjectID = im.projectID
+		release.Description = im.rewriter.Replace(release.Description)
+		release.CreatedBy = im.users.author(release.CreatedBy)
+		if err := im.archiveRepo.Create(&release); err != nil {
+			return err
+		}
+		im.ids.set(kindRelease, sourceID, release.ID)
+	}
+
+	for _, sprint := range im.archive.Sprints {
+		sourceID := sprint.ID
+		sprint.ID = 0
+		sprint.ProjectID = im.projectID
+		sprint.Goal = im.rewriter.Replace(sprint.Goal)
+		sprint.MilestoneID = nil
+		sprint.ReleaseID = im.ids.getPtr(kindRelease, sprint.ReleaseID)
+		sprint.CreatedBy = im.users.author(sprint.CreatedBy)
+		if err := im.archiveRepo.Create(&sprint); err != nil {
+			return err
+		}
+		im.ids.set(kindSprint, sourceID, sprint.ID)
+	}
+	return nil
+}
+
+// refNum keeps the archived reference number unless it is already taken in the project
+func (im *archiveImporter) refNum(sequenceType, refNum string) (string, error) {
+	if im.refNums.claim(sequenceType, refNum) {
+		return refNum, nil
+	}
+	im.renumbered++
+	for {
+		next, err := im.sequences.GetNextNumber(im.uow, im.projectID, sequenceType)
+		if err != nil {
+			return "", err
+		}
+		if im.refNums.claim(sequenceType, next) {
+			return next, nil
+		}
+	}
+}
+
+// sprintID remaps the sprint of items that use 0 for no sprint
+func (im *archiveImporter) sprintID(sourceID int) int {
+	sprintID, _ := im.ids.get(kindSprint, sourceID)
+	return sprintID
+}
+
+// importItems copies items, then points references between items at the imported rows
+func (im *archiveImporter) importItems() error {
+	var err error
+	for _, ticket := range im.archive.ServiceTickets {
+		sourceID := ticket.ID
+		ticket.ID = 0
+		ticket.ProjectID = im.projectID
+		if ticket.RefNum, err = im.refNum(sequenceTypeServiceTicket, ticket.RefNum); err != nil {
+			return err
+		}
+		ticket.Description = im.rewriter.Replace(ticket.Description)
+		ticket.CreatedBy = im.users.author(ticket.CreatedBy)
+		if err := im.archiveRepo.Create(&ticket); err != nil {
+			return err
+		}
+		im.ids.set(kindServiceTicket, sourceID, ticket.ID)
+	}
+
+	importedIdeas := make([]*ideas.Idea, 0, len(im.archive.Ideas))
+	for i := range im.archive.Ideas {
+		idea := im.archive.Ideas[i]
+		sourceID := idea.ID
+		idea.ID = 0
+		idea.ProjectID = im.projectID
+		if idea.RefNum, err = im.refNum(sequenceTypeIdea, idea.RefNum); err != nil {
+			return err
+		}
+		idea.Description = im.rewriter.Replace(idea.Description)
+		idea.ReleaseID = im.ids.getPtr(kindRelease, idea.ReleaseID)
+		idea.CreatedBy = im.users.author(idea.CreatedBy)
+		if err := im.archiveRepo.Create(&idea); err != nil {
+			return err
+		}
+		im.ids.set(kindIdea, sourceID, idea.ID)
+		importedIdeas = append(importedIdeas, &idea)
+	}
+
+	importedFeatures := make([]*features.Feature, 0, len(im.archive.Features))
+	for i := range im.archive.Features {
+		feature := im.archive.Features[i]
+		sourceID := feature.ID
+		feature.ID = 0
+		feature.ProjectID = im.projectID
+		if feature.RefNum, err = im.refNum(sequenceTypeFeature, feature.RefNum); err != nil {
+			return err
+		}
+		feature.Description = im.rewriter.Replace(feature.Description)
+		feature.AssignedTo = im.users.assignee(feature.AssignedTo)
+		feature.SprintID = im.sprintID(feature.SprintID)
+		feature.ReleaseID = im.ids.getPtr(kindRelease, feature.ReleaseID)
+		feature.CreatedBy = im.users.author(feature.CreatedBy)
+		if err := im.archiveRepo.Create(&feature); err != nil {
+			return err
+		}
+		im.ids.set(kindFeature, sourceID, feature.ID)
+		importedFeatures = append(importedFeatures, &feature)
+	}
+
+	importedIssues := make([]*issues.Issue, 0, len(im.archive.Issues))
+	for i := range im.archive.Issues {
+		issue := im.archive.Issues[i]
+		sourceID := issue.ID
+		issue.ID = 0
+		issue.ProjectID = im.projectID
+		if issue.RefNum, err = im.refNum(sequenceTypeIssue, issue.RefNum); err != nil {
+			return err
+		}
+		issue.Description = im.rewriter.Replace(issue.Description)
+		issue.AssignedTo = im.users.assignee(issue.AssignedTo)
+		issue.SprintID = im.sprintID(issue.SprintID)
+		issue.ReleaseID = im.ids.getPtr(kindRelease, issue.ReleaseID)
+		issue.CreatedBy = im.users.author(issue.CreatedBy)
+		if err := im.archiveRepo.Create(&issue); err != nil {
+			return err
+		}
+		im.ids.set(kindIssue, sourceID, issue.ID)
+		importedIssues = append(importedIssues, &issue)
+	}
+
+	importedTasks := make([]*tasks.Task, 0, len(im.archive.Tasks))
+	for i := range im.archive.Tasks {
+		task := im.archive.Tasks[i]
+		sourceID := task.ID
+		task.ID = 0
+		task.ProjectID = im.projectID
+		task.Description = im.rewriter.Replace(task.Description)
+		task.AssigneeID = im.users.assigneePtr(task.AssigneeID)
+		task.SprintID = im.ids.getPtr(kindSprint, task.SprintID)
+		task.ReleaseID = im.ids.getPtr(kindRelease, task.ReleaseID)
+		task.CreatedBy = im.users.author(task.CreatedBy)
+		if err := im.archiveRepo.Create(&task); err != nil {
+			return err
+		}
+		im.ids.set(kindTask, sourceID, task.ID)
+		importedTasks = append(importedTasks, &task)
+	}
+
+	// Point references at the imported items now that every item has its new ID
+	for _, idea := range importedIdeas {
+		if idea.ItemID == nil {
+			continue
+		}
+		idea.ItemType, idea.ItemID = im.ids.remapRef(idea.ItemType, idea.ItemID)
+		if err := im.archiveRepo.Save(idea); err != nil {
+			return err
+		}
+	}
+	for _, feature := range importedFeatures {
+		if feature.ItemID == nil && feature.DependsOnFeatureID == nil {
+			continue
+		}
+		feature.ItemType, feature.ItemID = im.ids.remapRef(feature.ItemType, feature.ItemID)
+		feature.DependsOnFeatureID = im.ids.getPtr(kindFeature, feature.DependsOnFeatureID)
+		if err := im.archiveRepo.Save(feature); err != nil {
+			return err
+		}
+	}
+	for _, issue := range importedIssues {
+		if issue.ItemID == nil {
+			continue
+		}
+		issue.ItemType, issue.ItemID = im.ids.remapRef(issue.ItemType, issue.ItemID)
+		if err := im.archiveRepo.Save(issue); err != nil {
+			return err
+		}
+	}
+	for _, task := range importedTasks {
+		if task.ItemID == nil {
+			continue
+		}
+		task.ItemType, task.ItemID = im.ids.remapRef(task.ItemType, task.ItemID)
+		if err := im.archiveRepo.Save(task); err != nil {
+			return err
+		}
+	}
+
+	for _, link := range im.archive.ItemLinks {
+		sourceID, sourceOK := im.ids.getItem(link.SourceType, link.SourceID)
+		targetID, targetOK := im.ids.getItem(link.TargetType, link.TargetID)
+		if !sourceOK || !targetOK {
+			continue
+		}
+		link.ID = 0
+		link.ProjectID = im.projectID
+		link.SourceID = sourceID
+		link.TargetID = targetID
+		link.CreatedBy = im.users.assigneePtr(link.CreatedBy)
+		if err := im.archiveRepo.Create(&link); err != nil {
+			return err
+		}
+	}
+
+	// Archives exported before item links existed only have the references and dependencies
+	return item_links.NewItemLinkRepository(im.uow).BackfillFromReferences(im.projectID)
+}
+
+// importCustomFields copies the custom fields with the values and history of the imported items.
+// User values of unmatched users are dropped, and history of fields deleted before the export keeps only the field name.
+func (im *archiveImporter) importCustomFields() error {
+	fields := make(map[int]*custom_fields.CustomField, len(im.archive.CustomFields))
+	for i := range im.archive.CustomFields {
+		field := im.archive.CustomFields[i]
+		sourceID := field.ID
+		field.ID = 0
+		field.ProjectID = im.projectID
+		field.CreatedBy = im.users.author(field.CreatedBy)
+		if err := im.archiveRepo.Create(&field); err != nil {
+			return err
+		}
+		fields[sourceID] = &field
+	}
+
+	for _, value := range im.archive.CustomFieldValues {
+		field, ok := fields[value.FieldID]
+		if !ok {
+			continue
+		}
+		itemID, ok := im.ids.getItem(value.ItemType, value.ItemID)
+		if !ok {
+			continue
+		}
+		if field.FieldType == custom_fields.FieldTypeUser {
+			if value.Value, ok = im.users.userValue(value.Value); !ok {
+				continue
+			}
+		}
+		value.ID = 0
+		value.ProjectID = im.projectID
+		value.FieldID = field.ID
+		value.ItemID = itemID
+		if err := im.archiveRepo.Create(&value); err != nil {
+			return err
+		}
+	}
+
+	for _, change := range im.archive.CustomFieldChanges {
+		itemID, ok := im.ids.getItem(change.ItemType, change.ItemID)
+		if !ok {
+			continue
+		}
+		field, ok := fields[change.FieldID]
+		change.FieldID = 0
+		if ok {
+			change.FieldID = field.ID
+			if field.FieldType == custom_fields.FieldTypeUser {
+				change.OldValue, _ = im.users.userValue(change.OldValue)
+				change.NewValue, _ = im.users.userValue(change.NewValue)
+			}
+		}
+		change.ID = 0
+		change.ProjectID = im.projectID
+		change.ItemID = itemID
+		change.ChangedBy = im.users.assigneePtr(change.ChangedBy)
+		if err := im.archiveRepo.Create(&change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rewriteHashed rewrites diagram links in versioned wiki content and recomputes its hash when it changed
+func (im *archiveImporter) rewriteHashed(content, hash string) (string, string) {
+	rewritten := im.rewriter.Replace(content)
+	if rewritten == content {
+		return content, hash
+	}
+	return rewritten, wiki_pages.ComputeHash(rewritten)
+}
+
+func (im *archiveImporter) importWikiPages() error {
+	for _, page := range wiki_pages.SortParentsFirst(im.archive.WikiPages) {
+		sourceID := page.ID
+		page.ID = 0
+		page.ProjectID = im.projectID
+		page.ParentID = im.ids.getPtr(kindWikiPage, page.ParentID)
+		page.Content, page.ContentHash = im.rewriteHashed(page.Content, page.ContentHash)
+		page.CreatedBy = im.users.author(page.CreatedBy)
+		page.UpdatedBy = im.users.author(page.UpdatedBy)
+		if err := im.archiveRepo.Create(&page); err != nil {
+			return err
+		}
+		im.ids.set(kindWikiPage, sourceID, page.ID)
+	}
+
+	for _, change := range im.archive.WikiPageChanges {
+		pageID, ok := im.ids.get(kindWikiPage, change.WikiPageID)
+		if !ok {
+			continue
+		}
+		itemID, ok := im.ids.getItem(change.ItemType, change.ItemID)
+		if !ok {
+			continue
+		}
+		change.ID = 0
+		change.WikiPageID = pageID
+		change.ProjectID = im.projectID
+		change.ItemID = itemID
+		change.Base, change.BaseHash = im.rewriteHashed(change.Base, change.BaseHash)
+		change.Snapshot, change.SnapshotHash = im.rewriteHashed(change.Snapshot, change.SnapshotHash)
+		change.CreatedBy = im.users.author(change.CreatedBy)
+		if err := im.archiveRepo.Create(&change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importActivity copies comments, follow-ups and status history of the imported items
+func (im *archiveImporter) importActivity() error {
+	for _, comment := range im.archive.Comments {
+		itemID, ok := im.ids.getItem(comment.ItemType, comment.ItemID)
+		if !ok {
+			continue
+		}
+		comment.ID = 0
+		comment.ItemID = itemID
+		comment.Content = im.rewriter.Replace(comment.Content)
+		comment.CreatedBy = im.users.author(comment.CreatedBy)
+		if err := im.archiveRepo.Create(&comment); err != nil {
+			return err
+		}
+	}
+
+	for _, followUp := range im.archive.FollowUps {
+		itemID, ok := im.ids.getItem(followUp.ItemType, followUp.ItemID)
+		if !ok {
+			continue
+		}
+		followUp.ID = 0
+		followUp.ItemID = itemID
+		followUp.Content = im.rewriter.Replace(followUp.Content)
+		followUp.CreatedBy = im.users.author(followUp.CreatedBy)
+		if err := im.archiveRepo.Create(&followUp); err != nil {
+			return err
+		}
+	}
+
+	for _, change := range im.archive.StatusChanges {
+		itemID, ok := im.ids.getItem(change.ItemType, change.ItemID)
+		if !ok {
+			continue
+		}
+		change.ID = 0
+		change.ProjectID = im.projectID
+		change.ItemID = itemID
+		change.ChangedBy = im.users.assigneePtr(change.ChangedBy)
+		if err := im.archiveRepo.Create(&change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (im *archiveImporter) importReviews() error {
+	for _, review := range im.archive.Reviews {
+		sourceID := review.ID
+		review.ID = 0
+		review.ProjectID = im.projectID
+		review.SprintID = im.ids.getPtr(kindSprint, review.SprintID)
+		review.Description = im.rewriter.Replace(review.Description)
+		review.Summary = im.rewriter.Replace(review.Summary)
+		review.CreatedBy = im.users.author(review.CreatedBy)
+		if err := im.archiveRepo.Create(&review); err != nil {
+			return err
+		}
+		im.ids.set(kindReview, sourceID, review.ID)
+	}
+
+	for _, item := range im.archive.ReviewItems {
+		reviewID, ok := im.ids.get(kindReview, item.ReviewID)
+		if !ok {
+			continue
+		}
+		// Review items are snapshots, so they are kept even when the item itself is gone
+		itemID, _ := im.ids.getItem(item.ItemType, item.ItemID)
+		item.ID = 0
+		item.ReviewID = reviewID
+		item.ItemID = itemID
+		item.AssignedTo = im.users.assignee(item.AssignedTo)
+		if err := im.archiveRepo.Create(&item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importTimeLogs copies daily items and time logs of matched users. Personal logs are not
+// reassigned to the importer.
+func (im *archiveImporter) importTimeLogs() error {
+	dailyItemIDs := make(map[int]int, len(im.archive.UserDailyItems))
+	for _, dailyItem := range im.archive.UserDailyItems {
+		userID, ok := im.users.get(dailyItem.UserID)
+		if !ok {
+			continue
+		}
+		itemID, ok := im.ids.getItem(dailyItem.ItemType, dailyItem.ItemID)
+		if !ok {
+			continue
+		}
+		sourceID := dailyItem.ID
+		dailyItem.ID = 0
+		dailyItem.UserID = userID
+		dailyItem.ProjectID = im.projectID
+		dailyItem.ItemID = itemID
+		if err := im.archiveRepo.Create(&dailyItem); err != nil {
+			return err
+		}
+		dailyItemIDs[sourceID] = dailyItem.ID
+	}
+
+	for _, timeLog := range im.archive.UserDailyTimeLogs {
+		userID, ok := im.users.get(timeLog.UserID)
+		if !ok {
+			continue
+		}
+		dailyItemID, ok := dailyItemIDs[timeLog.UserDailyItemID]
+		if !ok {
+			continue
+		}
+		timeLog.ID = 0
+		timeLog.UserID = userID
+		timeLog.ProjectID = im.projectID
+		timeLog.UserDailyItemID = dailyItemID
+		if err := im.archiveRepo.Create(&timeLog); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package project_archives
+
+import (
+	"github.com/dannyswat/pjeasy/internal/comments"
+	"github.com/dannyswat/pjeasy/internal/custom_fields"
+	"github.com/dannyswat/pjeasy/internal/features"
+	"github.com/dannyswat/pjeasy/internal/ideas"
+	"github.com/dannyswat/pjeasy/internal/issues"
+	"github.com/dannyswat/pjeasy/internal/item_follow_ups"
+	"github.com/dannyswat/pjeasy/internal/item_links"
+	"github.com/dannyswat/pjeasy/internal/projects"
+	"github.com/dannyswat/pjeasy/internal/releases"
+	"github.com/dannyswat/pjeasy/internal/repositories"
+	"github.com/dannyswat/pjeasy/internal/reviews"
+	"github.com/dannyswat/pjeasy/internal/sequences"
+	"github.com/dannyswat/pjeasy/internal/service_tickets"
+	"github.com/dannyswat/pjeasy/internal/sprints"
+	"github.com/dannyswat/pjeasy/internal/status_changes"
+	"github.com/dannyswat/pjeasy/internal/tasks"
+	"github.com/dannyswat/pjeasy/internal/user_dailies"
+	"github.com/dannyswat/pjeasy/internal/users"
+	"github.com/dannyswat/pjeasy/internal/wiki_pages"
+	"gorm.io/gorm"
+)
+
+// itemTables are the tables of items that comments and follow-ups can be attached to, by item kind
+var itemTables = map[string]string{
+	kindIdea:          "ideas",
+	kindIssue:         "issues",
+	kindFeature:       "features",
+	kindTask:          "tasks",
+	kindServiceTicket: "service_tickets",
+	kindWikiPage:      "wiki_pages",
+}
+
+// ProjectArchiveRepository reads and writes every table of a project for exports and imports
+type ProjectArchiveRepository struct {
+	uow *repositories.UnitOfWork
+}
+
+func NewProjectArchiveRepository(uow *repositories.UnitOfWork) *ProjectArchiveRepository {
+	return &ProjectArchiveRepository{uow: uow}
+}
+
+// findByProject returns all rows of a table that belong to the project, in creation order
+func findByProject[T any](db *gorm.DB, projectID int) ([]T, error) {
+	var rows []T
+	err := db.Where("project_id = ?", projectID).Order("id ASC").Find(&rows).Error
+	return rows, err
+}
+
+// findByProjectItems returns rows referencing project items by item type and ID.
+// Item types are spelled differently across modules, so matches are checked against the normalized kind.
+func findByProjectItems[T any](db *gorm.DB, projectID int, itemType func(*T) string) ([]T, error) {
+	var matched []T
+	for _, kind := range []string{kindIdea, kindIssue, kindFeature, kindTask, kindServiceTicket, kindWikiPage} {
+		var rows []T
+		err := db.Where("item_id IN (SELECT id FROM "+itemTables[kind]+" WHERE project_id = ? AND deleted_at IS NULL)", projectID).
+			Order("id ASC").
+			Find(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		for i := range rows {
+			if itemKind(itemType(&rows[i])) == kind {
+				matched = append(matched, rows[i])
+			}
+		}
+	}
+	return matched, nil
+}
+
+// LoadArchive reads every entity of the project. Users and the manifest are filled in by the caller.
+func (r *ProjectArchiveRepository) LoadArchive(projectID int) (*ProjectArchive, error) {
+	db := r.uow.GetDB()
+	archive := &ProjectArchive{}
+
+	if err := db.Where("id = ?", projectID).First(&archive.Project).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Preload("Permissions").Where("project_id = ?", projectID).Order("id ASC").Find(&archive.Roles).Error; err != nil {
+		return nil, err
+	}
+
+	if err := db.Where("project_id = ?", projectID).Order("key ASC").Find(&archive.Settings).Error; err != nil {
+		return nil, err
+	}
+
+	var err error
+	if archive.Members, err = findByProject[projects.ProjectMember](db, projectID); err != nil {
+		return nil, err
+	}
+	if archive.Sequences, err = findByProject[sequences.Sequence](db, projectID); err != nil {
+		return nil, err
+	}
+	if archive.SequenceNumbers, err = findByProject[sequences.SequenceNumber](db, projectID); err != nil {
+		return nil, err
+	}
+	if archive.StatusFlows, err = findByProject[status_changes.StatusFlow](db, projectID); err != nil {
+		return nil, err
+	}
+	if archive.Releases, err = findByProject[releases.Release](db, projectID); err != nil {
+		return nil, err
+	}
+	if archive.Sprints, err = findByProject[sprints.Sprint](db, projectID); err != nil {
+		return nil, err
+	}
+	if archive.ServiceTickets, err = findByProject[service_tickets.ServiceTicket](db, projectID); err != nil {
+		return nil, err
+	}
+	if archive.Ideas, err = findByProject[ideas.Idea](db, projectID); err != nil {
+		return nil, err
+	}
+	if archive.Features, err = findByProject[features.Feature](db, projectID); err != nil {
+		return nil, err
+	}
+	if archive.Issues, err = findByProject[issues.Issue](db, projectID); err != nil {
+		return nil, err
+	}
+	if archive.Tasks, err = findByProject[tasks.Task](db, projectID); err != nil {
+		return nil, err
+	}
+	if archive.ItemLinks, err = findByProject[item_links.ItemLink](db, projectID); err != nil {
+		return nil, err
+	}
+	if archive.CustomFields, err = findByProject[custom_fields.CustomField](db, projectID); err != nil {
+		return nil, err
+	}
+	if archive.CustomFieldValues, err = findByProject[custom_fields.CustomFieldValue](db, projectID); err != nil {
+		return nil, err
+	}
+	if archive.CustomFieldChanges, err = findByProject[custom_fields.CustomFieldChange](db, projectID); err != nil {
+		return nil, err
+	}
+	if archive.WikiPages, err = findByProject[wiki_pages.WikiPage](db, projectID); err != nil {
+		return nil, err
+	}
+	if archive.WikiPageChanges, err = findByProject[wiki_pages.WikiPageChange](db, projectID); err != nil {
+		return nil, err
+	}
+	if archive.StatusChanges, err = findByProject[status_changes.StatusChange](db, projectID); err != nil {
+		return nil, err
+	}
+	if archive.Reviews, err = findByProject[reviews.Review](db, projectID); err != nil {
+		return nil, err
+	}
+	if archive.UserDailyItems, err = findByProject[user_dailies.UserDailyItem](db, projectID); err != nil {
+		return nil, err
+	}
+	if archive.UserDailyTimeLogs, err = findByProject[user_dailies.UserDailyTimeLog](db, projectID); err != nil {
+		return nil, err
+	}
+
+	if archive.Comments, err = findByProjectItems(db, projectID, func(c *comments.Comment) string { return c.ItemType }); err != nil {
+		return nil, err
+	}
+	if archive.FollowUps, err = findByProjectItems(db, projectID, func(f *item_follow_ups.ItemFollowUp) string { return f.ItemType }); err != nil {
+		return nil, err
+	}
+
+	err = db.Where("review_id IN (SELECT id FROM reviews WHERE project_id = ?)", projectID).
+		Order("id ASC").
+		Find(&archive.ReviewItems).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return archive, nil
+}
+
+// GetUsers returns the users with the given IDs
+func (r *ProjectArchiveRepository) GetUsers(userIDs []int) ([]users.User, error) {
+	var result []users.User
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+	err := r.uow.GetDB().Where("id IN ?", userIDs).Order("id ASC").Find(&result).Error
+	return result, err
+}
+
+// Create inserts a row of any project table
+func (r *ProjectArchiveRepository) Create(value any) error {
+	return r.uow.GetDB().Create(value).Error
+}
+
+// Save updates a row of any project table
+func (r *ProjectArchiveRepository) Save(value any) error {
+	return r.uow.GetDB().Save(value).Error
+}
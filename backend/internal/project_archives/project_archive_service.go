@@ -0,0 +1,555 @@
+package project_archives
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/custom_fields"
+	"github.com/dannyswat/pjeasy/internal/projects"
+	"github.com/dannyswat/pjeasy/internal/repositories"
+	"github.com/dannyswat/pjeasy/internal/sequences"
+	"github.com/dannyswat/pjeasy/internal/users"
+)
+
+// Sequence item types of the items that carry reference numbers
+const (
+	sequenceTypeIdea          = "ideas"
+	sequenceTypeIssue         = "issues"
+	sequenceTypeFeature       = "features"
+	sequenceTypeServiceTicket = "service_tickets"
+)
+
+// ImportResult describes a finished import
+type ImportResult struct {
+	Project         *projects.Project
+	UnmatchedUsers  []string // Login IDs without a user on this instance; their content is attributed to the importer
+	RenumberedItems int      // Items that received a new reference number because theirs was taken
+}
+
+type ProjectArchiveService struct {
+	uowFactory     *repositories.UnitOfWorkFactory
+	memberRepo     *projects.ProjectMemberRepository
+	userRepo       *users.UserRepository
+	uploadRootDir  string
+	imageUploadDir string
+}
+
+func NewProjectArchiveService(uowFactory *repositories.UnitOfWorkFactory, memberRepo *projects.ProjectMemberRepository, userRepo *users.UserRepository, uploadRootDir, imageUploadDir string) *ProjectArchiveService {
+	return &ProjectArchiveService{
+		uowFactory:     uowFactory,
+		memberRepo:     memberRepo,
+		userRepo:       userRepo,
+		uploadRootDir:  uploadRootDir,
+		imageUploadDir: imageUploadDir,
+	}
+}
+
+func (s *ProjectArchiveService) projectFilesDir(projectID int) string {
+	return filepath.Join(s.uploadRootDir, "project", strconv.Itoa(projectID))
+}
+
+// LoadProjectArchive reads every entity of a project for export. Only project admins can export.
+func (s *ProjectArchiveService) LoadProjectArchive(projectID int, requestedBy int) (*ProjectArchive, error) {
+	isAdmin, err := s.memberRepo.IsUserAdmin(projectID, requestedBy)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin {
+		return nil, errors.New("only project admins can export the project")
+	}
+
+	archiveRepo := NewProjectArchiveRepository(s.uowFactory.NewUnitOfWork())
+	archive, err := archiveRepo.LoadArchive(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	archiveUsers, err := archiveRepo.GetUsers(archive.userIDs())
+	if err != nil {
+		return nil, err
+	}
+	archive.Users = make([]ArchiveUser, 0, len(archiveUsers))
+	for _, user := range archiveUsers {
+		archive.Users = append(archive.Users, ArchiveUser{ID: user.ID, LoginID: user.LoginID, Name: user.Name})
+	}
+
+	archive.Manifest = ArchiveManifest{
+		FormatVersion:   FormatVersion,
+		ExportedAt:      time.Now(),
+		SourceProjectID: projectID,
+		ProjectName:     archive.Project.Name,
+	}
+	return archive, nil
+}
+
+// WriteProjectArchive writes the archive as a zip file, including the project's uploaded
+// diagrams and the images its content links to
+func (s *ProjectArchiveService) WriteProjectArchive(archive *ProjectArchive, w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	for _, entry := range archive.entries() {
+		file, err := zw.Create(entry.name)
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(entry.value); err != nil {
+			return err
+		}
+	}
+
+	projectDir := s.projectFilesDir(archive.Project.ID)
+	err := filepath.WalkDir(projectDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(projectDir, path)
+		if err != nil {
+			return err
+		}
+		return addFile(zw, projectFilesDir+filepath.ToSlash(rel), path)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range archive.imageNames() {
+		path := filepath.Join(s.imageUploadDir, name)
+		if _, err := os.Stat(path); err != nil {
+			continue // Images removed from the upload directory are left out
+		}
+		if err := addFile(zw, imageFilesDir+name, path); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+func addFile(zw *zip.Writer, name, path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// ImportProject creates a new project from an archive. IDs are remapped, users are matched by
+// login ID and the importer becomes a project admin. Reference numbers that are already taken are regenerated.
+// The caller must be a system admin, since matched users receive memberships and time logs.
+func (s *ProjectArchiveService) ImportProject(r io.ReaderAt, size int64, importedBy int) (*ImportResult, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, errors.New("invalid project archive")
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, file := range zr.File {
+		files[file.Name] = file
+	}
+
+	archive := &ProjectArchive{}
+	if files[manifestFile] == nil {
+		return nil, errors.New("project archive has no manifest")
+	}
+	for _, entry := range archive.entries() {
+		file := files[entry.name]
+		if file == nil {
+			continue // Entities added in later format versions are optional
+		}
+		if err := readJSON(file, entry.value); err != nil {
+			return nil, err
+		}
+		if entry.name == manifestFile && (archive.Manifest.FormatVersion < 1 || archive.Manifest.FormatVersion > FormatVersion) {
+			return nil, fmt.Errorf("unsupported project archive format version %d", archive.Manifest.FormatVersion)
+		}
+	}
+	if strings.TrimSpace(archive.Project.Name) == "" {
+		return nil, errors.New("project archive has no project")
+	}
+
+	userMap, unmatched, err := s.matchUsers(archive.Users, importedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	uow := s.uowFactory.NewUnitOfWork()
+	if err := uow.BeginTransaction(); err != nil {
+		return nil, err
+	}
+	defer uow.RollbackTransactionIfError()
+
+	importer := &archiveImporter{
+		uow:         uow,
+		archiveRepo: NewProjectArchiveRepository(uow),
+		sequences:   sequences.NewSequenceRepository(uow),
+		archive:     archive,
+		users:       userMap,
+		importedBy:  importedBy,
+		ids:         make(idMap),
+		refNums:     make(refNumSet),
+		now:         time.Now(),
+	}
+	if err := importer.run(); err != nil {
+		return nil, err
+	}
+
+	written, err := s.writeImportedFiles(zr.File, importer.projectID)
+	if err != nil {
+		s.removeImportedFiles(importer.projectID, written)
+		return nil, err
+	}
+
+	if err := uow.CommitTransaction(); err != nil {
+		s.removeImportedFiles(importer.projectID, written)
+		return nil, err
+	}
+
+	return &ImportResult{
+		Project:         importer.project,
+		UnmatchedUsers:  unmatched,
+		RenumberedItems: importer.renumbered,
+	}, nil
+}
+
+func readJSON(file *zip.File, value any) error {
+	if file.UncompressedSize64 > maxArchiveFileMB<<20 {
+		return fmt.Errorf("%s is too large", file.Name)
+	}
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err := json.NewDecoder(io.LimitReader(src, maxArchiveFileMB<<20)).Decode(value); err != nil {
+		return fmt.Errorf("invalid %s: %w", file.Name, err)
+	}
+	return nil
+}
+
+// matchUsers maps archive user IDs to users of this instance with the same login ID
+func (s *ProjectArchiveService) matchUsers(archiveUsers []ArchiveUser, importedBy int) (userIDMap, []string, error) {
+	userMap := userIDMap{matched: make(map[int]int), fallback: importedBy}
+	unmatched := make([]string, 0)
+	for _, archiveUser := range archiveUsers {
+		user, err := s.userRepo.GetByLoginID(archiveUser.LoginID)
+		if err != nil {
+			return userMap, nil, err
+		}
+		if user == nil {
+			unmatched = append(unmatched, archiveUser.LoginID)
+			continue
+		}
+		userMap.matched[archiveUser.ID] = user.ID
+	}
+	sort.Strings(unmatched)
+	return userMap, unmatched, nil
+}
+
+// writeImportedFiles copies uploaded files from the archive. Images keep their global name and
+// existing images are not overwritten. It returns the images it created.
+func (s *ProjectArchiveService) writeImportedFiles(files []*zip.File, projectID int) ([]string, error) {
+	projectDir := s.projectFilesDir(projectID)
+	var written []string
+	for _, file := range files {
+		var dest string
+		switch {
+		case strings.HasPrefix(file.Name, projectFilesDir):
+			rel := strings.TrimPrefix(file.Name, projectFilesDir)
+			if !filepath.IsLocal(rel) {
+				return written, fmt.Errorf("invalid file path %s", file.Name)
+			}
+			dest = filepath.Join(projectDir, filepath.FromSlash(rel))
+		case strings.HasPrefix(file.Name, imageFilesDir):
+			name := strings.TrimPrefix(file.Name, imageFilesDir)
+			if !filepath.IsLocal(name) || filepath.Base(name) != name {
+				return written, fmt.Errorf("invalid file path %s", file.Name)
+			}
+			dest = filepath.Join(s.imageUploadDir, name)
+			if _, err := os.Stat(dest); err == nil {
+				continue
+			}
+			written = append(written, dest)
+		default:
+			continue
+		}
+
+		if err := extractFile(file, dest); err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+func extractFile(file *zip.File, dest string) error {
+	if file.FileInfo().IsDir() {
+		return nil
+	}
+	if file.UncompressedSize64 > maxArchiveFileMB<<20 {
+		return fmt.Errorf("%s is too large", file.Name)
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, io.LimitReader(src, maxArchiveFileMB<<20)); err != nil {
+		_ = os.Remove(dest)
+		return err
+	}
+	return nil
+}
+
+func (s *ProjectArchiveService) removeImportedFiles(projectID int, images []string) {
+	if projectID != 0 {
+		_ = os.RemoveAll(s.projectFilesDir(projectID))
+	}
+	for _, path := range images {
+		_ = os.Remove(path)
+	}
+}
+
+// userIDMap maps archive user IDs to users of this instance
+type userIDMap struct {
+	matched  map[int]int
+	fallback int // The importer, who is credited with content of unmatched users
+}
+
+// get returns the matched user, or false when the user does not exist on this instance
+func (m userIDMap) get(userID int) (int, bool) {
+	matched, ok := m.matched[userID]
+	return matched, ok
+}
+
+// author returns the matched user, falling back to the importer
+func (m userIDMap) author(userID int) int {
+	if matched, ok := m.get(userID); ok {
+		return matched
+	}
+	return m.fallback
+}
+
+// assignee returns the matched user, or 0 so that items of unmatched users become unassigned
+func (m userIDMap) assignee(userID int) int {
+	matched, _ := m.get(userID)
+	return matched
+}
+
+// assigneePtr is assignee for optional user references
+func (m userIDMap) assigneePtr(userID *int) *int {
+	if userID == nil {
+		return nil
+	}
+	matched, ok := m.get(*userID)
+	if !ok {
+		return nil
+	}
+	return &matched
+}
+
+// userValue remaps a user custom field value, returning false when the user does not exist on this instance
+func (m userIDMap) userValue(value string) (string, bool) {
+	userID, err := strconv.Atoi(value)
+	if err != nil {
+		return "", false
+	}
+	matched, ok := m.get(userID)
+	if !ok {
+		return "", false
+	}
+	return strconv.Itoa(matched), true
+}
+
+// userIDs returns every user referenced by the archive
+func (a *ProjectArchive) userIDs() []int {
+	seen := make(map[int]bool)
+	var ids []int
+	add := func(userIDs ...int) {
+		for _, id := range userIDs {
+			if id != 0 && !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	addPtr := func(userID *int) {
+		if userID != nil {
+			add(*userID)
+		}
+	}
+
+	add(a.Project.CreatedBy)
+	for _, m := range a.Members {
+		add(m.UserID, m.AddedBy)
+	}
+	for _, st := range a.Settings {
+		add(st.UpdatedBy)
+	}
+	for _, r := range a.Releases {
+		add(r.CreatedBy)
+	}
+	for _, sp := range a.Sprints {
+		add(sp.CreatedBy)
+	}
+	for _, t := range a.ServiceTickets {
+		add(t.CreatedBy)
+	}
+	for _, i := range a.Ideas {
+		add(i.CreatedBy)
+	}
+	for _, f := range a.Features {
+		add(f.CreatedBy, f.AssignedTo)
+	}
+	for _, i := range a.Issues {
+		add(i.CreatedBy, i.AssignedTo)
+	}
+	for _, t := range a.Tasks {
+		add(t.CreatedBy)
+		addPtr(t.AssigneeID)
+	}
+	for _, l := range a.ItemLinks {
+		addPtr(l.CreatedBy)
+	}
+	userFields := make(map[int]bool)
+	for _, f := range a.CustomFields {
+		add(f.CreatedBy)
+		if f.FieldType == custom_fields.FieldTypeUser {
+			userFields[f.ID] = true
+		}
+	}
+	for _, v := range a.CustomFieldValues {
+		if userID, err := strconv.Atoi(v.Value); err == nil && userFields[v.FieldID] {
+			add(userID)
+		}
+	}
+	for _, c := range a.CustomFieldChanges {
+		addPtr(c.ChangedBy)
+		if userFields[c.FieldID] {
+			for _, value := range []string{c.OldValue, c.NewValue} {
+				if userID, err := strconv.Atoi(value); err == nil {
+					add(userID)
+				}
+			}
+		}
+	}
+	for _, p := range a.WikiPages {
+		add(p.CreatedBy, p.UpdatedBy)
+	}
+	for _, c := range a.WikiPageChanges {
+		add(c.CreatedBy)
+	}
+	for _, c := range a.Comments {
+		add(c.CreatedBy)
+	}
+	for _, f := range a.FollowUps {
+		add(f.CreatedBy)
+	}
+	for _, c := range a.StatusChanges {
+		addPtr(c.ChangedBy)
+	}
+	for _, r := range a.Reviews {
+		add(r.CreatedBy)
+	}
+	for _, i := range a.ReviewItems {
+		add(i.AssignedTo)
+	}
+	for _, d := range a.UserDailyItems {
+		add(d.UserID)
+	}
+	for _, l := range a.UserDailyTimeLogs {
+		add(l.UserID)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// imageNames returns the uploaded images linked from the archive's content
+func (a *ProjectArchive) imageNames() []string {
+	seen := make(map[string]bool)
+	var names []string
+	add := func(contents ...string) {
+		for _, content := range contents {
+			for _, name := range referencedImages(content) {
+				if !seen[name] {
+					seen[name] = true
+					names = append(names, name)
+				}
+			}
+		}
+	}
+
+	add(a.Project.Description)
+	for _, r := range a.Releases {
+		add(r.Description)
+	}
+	for _, sp := range a.Sprints {
+		add(sp.Goal)
+	}
+	for _, t := range a.ServiceTickets {
+		add(t.Description)
+	}
+	for _, i := range a.Ideas {
+		add(i.Description)
+	}
+	for _, f := range a.Features {
+		add(f.Description)
+	}
+	for _, i := range a.Issues {
+		add(i.Description)
+	}
+	for _, t := range a.Tasks {
+		add(t.Description)
+	}
+	for _, p := range a.WikiPages {
+		add(p.Content)
+	}
+	for _, c := range a.WikiPageChanges {
+		add(c.Base, c.Snapshot)
+	}
+	for _, c := range a.Comments {
+		add(c.Content)
+	}
+	for _, f := range a.FollowUps {
+		add(f.Content)
+	}
+	for _, r := range a.Reviews {
+		add(r.Description, r.Summary)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,138 @@
+package project_archives
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dannyswat/pjeasy/internal/custom_fields"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestItemKind(t *testing.T) {
+	tests := []struct {
+		itemType string
+		want     string
+	}{
+		{itemType: "ideas", want: kindIdea},
+		{itemType: "Issue", want: kindIssue},
+		{itemType: "service-tickets", want: kindServiceTicket},
+		{itemType: "service_ticket", want: kindServiceTicket},
+		{itemType: "wiki", want: kindWikiPage},
+		{itemType: "wiki-pages", want: kindWikiPage},
+		{itemType: "sprint", want: kindSprint},
+		{itemType: "designs", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := itemKind(tt.itemType); got != tt.want {
+			t.Errorf("itemKind(%q) = %q, want %q", tt.itemType, got, tt.want)
+		}
+	}
+}
+
+func TestIDMapRemapRef(t *testing.T) {
+	ids := make(idMap)
+	ids.set(kindFeature, 10, 110)
+
+	tests := []struct {
+		name     string
+		itemType string
+		itemID   *int
+		wantType string
+		wantID   *int
+	}{
+		{name: "imported item keeps its type spelling", itemType: "features", itemID: intPtr(10), wantType: "features", wantID: intPtr(110)},
+		{name: "item not imported", itemType: "features", itemID: intPtr(11)},
+		{name: "unknown type", itemType: "designs", itemID: intPtr(10)},
+		{name: "no reference", itemType: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotType, gotID := ids.remapRef(tt.itemType, tt.itemID)
+			if gotType != tt.wantType || !reflect.DeepEqual(gotID, tt.wantID) {
+				t.Fatalf("remapRef() = %q, %v, want %q, %v", gotType, gotID, tt.wantType, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestRefNumSetClaim(t *testing.T) {
+	refs := make(refNumSet)
+
+	if !refs.claim("issues", "ISSU0001") {
+		t.Fatal("first claim should succeed")
+	}
+	if refs.claim("issues", "ISSU0001") {
+		t.Fatal("claiming a taken reference number should fail")
+	}
+	if !refs.claim("features", "ISSU0001") {
+		t.Fatal("reference numbers are tracked per sequence type")
+	}
+	if refs.claim("issues", "") {
+		t.Fatal("an empty reference number cannot be claimed")
+	}
+}
+
+func TestUserIDMap(t *testing.T) {
+	users := userIDMap{matched: map[int]int{1: 101}, fallback: 7}
+
+	if got := users.author(1); got != 101 {
+		t.Errorf("author(1) = %d, want 101", got)
+	}
+	if got := users.author(2); got != 7 {
+		t.Errorf("author(2) = %d, want the importer", got)
+	}
+	if got := users.assignee(2); got != 0 {
+		t.Errorf("assignee(2) = %d, want unassigned", got)
+	}
+	if got := users.assigneePtr(intPtr(2)); got != nil {
+		t.Errorf("assigneePtr(2) = %v, want nil", *got)
+	}
+	if got, ok := users.userValue("1"); !ok || got != "101" {
+		t.Errorf("userValue(1) = %q, %v, want 101", got, ok)
+	}
+	if _, ok := users.userValue("2"); ok {
+		t.Errorf("userValue(2) matched an unmatched user")
+	}
+}
+
+func TestArchiveUserIDsIncludeCustomFieldUsers(t *testing.T) {
+	archive := &ProjectArchive{
+		CustomFields: []custom_fields.CustomField{
+			{ID: 1, FieldType: custom_fields.FieldTypeUser, CreatedBy: 2},
+			{ID: 2, FieldType: custom_fields.FieldTypeNumber, CreatedBy: 2},
+		},
+		CustomFieldValues: []custom_fields.CustomFieldValue{
+			{FieldID: 1, Value: "3"},
+			{FieldID: 2, Value: "99"}, // A number, not a user
+		},
+		CustomFieldChanges: []custom_fields.CustomFieldChange{
+			{FieldID: 1, OldValue: "4", NewValue: "3", ChangedBy: intPtr(5)},
+		},
+	}
+
+	if got, want := archive.userIDs(), []int{2, 3, 4, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("userIDs() = %v, want %v", got, want)
+	}
+}
+
+func TestContentLinks(t *testing.T) {
+	content := `<p><img src="/uploads/images/3_a1b2.png"><img src="/api/projects/5/diagrams/flow.svg"></p>` +
+		`<a href="/api/projects/15/diagrams/other.png">x</a><img src="/uploads/images/3_c3d4.webp">`
+
+	images := referencedImages(content)
+	if want := []string{"3_a1b2.png", "3_c3d4.webp"}; !reflect.DeepEqual(images, want) {
+		t.Errorf("referencedImages() = %v, want %v", images, want)
+	}
+
+	got := diagramURLRewriter(5, 42).Replace(content)
+	want := `<p><img src="/uploads/images/3_a1b2.png"><img src="/api/projects/42/diagrams/flow.svg"></p>` +
+		`<a href="/api/projects/15/diagrams/other.png">x</a><img src="/uploads/images/3_c3d4.webp">`
+	if got != want {
+		t.Errorf("rewritten content = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,465 @@
+package project_templates
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/custom_fields"
+	"github.com/dannyswat/pjeasy/internal/features"
+	"github.com/dannyswat/pjeasy/internal/ideas"
+	"github.com/dannyswat/pjeasy/internal/issues"
+	"github.com/dannyswat/pjeasy/internal/item_links"
+	"github.com/dannyswat/pjeasy/internal/projects"
+	"github.com/dannyswat/pjeasy/internal/repositories"
+	"github.com/dannyswat/pjeasy/internal/sequences"
+	"github.com/dannyswat/pjeasy/internal/service_tickets"
+	"github.com/dannyswat/pjeasy/internal/status_changes"
+	"github.com/dannyswat/pjeasy/internal/tasks"
+	"github.com/dannyswat/pjeasy/internal/wiki_pages"
+)
+
+// Item type names used by item references (ItemType and ItemID)
+const (
+	refTypeIdea          = "ideas"
+	refTypeIssue         = "issues"
+	refTypeFeature       = "features"
+	refTypeTask          = "tasks"
+	refTypeServiceTicket = "service-tickets"
+)
+
+// Statuses of items that are still open and copied with OpenItems
+var (
+	openIdeaStatuses          = []string{ideas.IdeaStatusOpen}
+	openIssueStatuses         = []string{issues.IssueStatusOpen, issues.IssueStatusAssigned, issues.IssueStatusInProgress, issues.IssueStatusInReview, issues.IssueStatusReopened}
+	openFeatureStatuses       = []string{features.FeatureStatusOpen, features.FeatureStatusAssigned, features.FeatureStatusInProgress, features.FeatureStatusInReview, features.FeatureStatusReopened}
+	openTaskStatuses          = []string{tasks.TaskStatusOpen, tasks.TaskStatusInProgress, tasks.TaskStatusOnHold, tasks.TaskStatusBlocked, tasks.TaskStatusReopened}
+	openServiceTicketStatuses = []string{service_tickets.ServiceTicketStatusNew, service_tickets.ServiceTicketStatusOpen}
+)
+
+// TemplateCopier copies status flows, sequences, custom fields, the wiki tree and open items of a template project
+type TemplateCopier struct{}
+
+func NewTemplateCopier() *TemplateCopier {
+	return &TemplateCopier{}
+}
+
+// CopyProjectTemplate copies the selected parts of the template into the new project using the caller's transaction.
+// Custom field definitions are always copied, since they configure the items of the project.
+func (c *TemplateCopier) CopyProjectTemplate(uow *repositories.UnitOfWork, options projects.ProjectTemplateOptions, projectID int, createdBy int) error {
+	fields, err := copyCustomFields(uow, options.TemplateProjectID, projectID, createdBy)
+	if err != nil {
+		return err
+	}
+	if options.StatusFlows {
+		if err := copyStatusFlows(uow, options.TemplateProjectID, projectID); err != nil {
+			return err
+		}
+	}
+	if options.Sequences {
+		if err := copySequences(uow, options.TemplateProjectID, projectID); err != nil {
+			return err
+		}
+	}
+	if options.WikiPages {
+		if err := copyWikiPages(uow, options.TemplateProjectID, projectID, createdBy); err != nil {
+			return err
+		}
+	}
+	if options.OpenItems {
+		// Items need reference numbers, so make sure the default sequences exist
+		if err := sequences.NewSequenceService(sequences.NewSequenceRepository(uow)).GenerateProjectSequences(projectID); err != nil {
+			return err
+		}
+		keepAssignee := func(userID int) bool {
+			return options.Members || userID == createdBy
+		}
+		if err := copyOpenItems(uow, options.TemplateProjectID, projectID, createdBy, fields, keepAssignee); err != nil {
+			return err
+		}
+		// Link the copied items as their references describe
+		if err := item_links.NewItemLinkRepository(uow).BackfillFromReferences(projectID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyStatusFlows(uow *repositories.UnitOfWork, templateProjectID, projectID int) error {
+	flowRepo := status_changes.NewStatusFlowRepository(uow)
+	flows, err := flowRepo.GetByProjectID(templateProjectID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, flow := range flows {
+		flow.ID = 0
+		flow.ProjectID = projectID
+		flow.CreatedAt = now
+		flow.UpdatedAt = now
+		if err := flowRepo.Create(&flow); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copySequences(uow *repositories.UnitOfWork, templateProjectID, projectID int) error {
+	sequenceRepo := sequences.NewSequenceRepository(uow)
+	templateSequences, err := sequenceRepo.ListSequencesByProject(templateProjectID)
+	if err != nil {
+		return err
+	}
+
+	for _, sequence := range templateSequences {
+		sequence.ID = 0
+		sequence.ProjectID = projectID
+		if err := sequenceRepo.CreateSequence(&sequence); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyCustomFields copies the custom field definitions and returns the copies by template field ID
+func copyCustomFields(uow *repositories.UnitOfWork, templateProjectID, projectID, createdBy int) (map[int]*custom_fields.CustomField, error) {
+	fieldRepo := custom_fields.NewCustomFieldRepository(uow)
+	templateFields, err := fieldRepo.GetByProjectID(templateProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	fields := make(map[int]*custom_fields.CustomField, len(templateFields))
+	for i := range templateFields {
+		field := templateFields[i]
+		templateFieldID := field.ID
+		field.ID = 0
+		field.ProjectID = projectID
+		field.CreatedBy = createdBy
+		field.CreatedAt = now
+		field.UpdatedAt = now
+		if err := fieldRepo.Create(&field); err != nil {
+			return nil, err
+		}
+		fields[templateFieldID] = &field
+	}
+	return fields, nil
+}
+
+func copyWikiPages(uow *repositories.UnitOfWork, templateProjectID, projectID, createdBy int) error {
+	pageRepo := wiki_pages.NewWikiPageRepository(uow)
+	pages, err := pageRepo.GetAllByProjectID(templateProjectID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	pageIDs := make(map[int]int, len(pages))
+	for _, page := range wiki_pages.SortParentsFirst(pages) {
+		templatePageID := page.ID
+		page.ID = 0
+		page.ProjectID = projectID
+		page.Version = 1
+		page.CreatedBy = createdBy
+		page.UpdatedBy = createdBy
+		page.CreatedAt = now
+		page.UpdatedAt = now
+		if page.ParentID != nil {
+			parentID, ok := pageIDs[*page.ParentID]
+			if ok {
+				page.ParentID = &parentID
+			} else {
+				page.ParentID = nil
+			}
+		}
+		if err := pageRepo.Create(&page); err != nil {
+			return err
+		}
+		pageIDs[templatePageID] = page.ID
+	}
+	return nil
+}
+
+// itemIDMap maps item IDs of the template project to the copied items, by reference type
+type itemIDMap map[string]map[int]int
+
+func (m itemIDMap) set(itemType string, templateID, newID int) {
+	if m[itemType] == nil {
+		m[itemType] = make(map[int]int)
+	}
+	m[itemType][templateID] = newID
+}
+
+// remap returns the reference to the copied item, or an empty reference when the item was not copied
+func (m itemIDMap) remap(itemType string, itemID *int) (string, *int) {
+	if itemType == "" || itemID == nil {
+		return "", nil
+	}
+	newID, ok := m[itemType][*itemID]
+	if !ok {
+		return "", nil
+	}
+	return itemType, &newID
+}
+
+// linkRefTypes maps the item types of item links to reference types
+var linkRefTypes = map[string]string{
+	projects.PermissionItemIdea:          refTypeIdea,
+	projects.PermissionItemIssue:         refTypeIssue,
+	projects.PermissionItemFeature:       refTypeFeature,
+	projects.PermissionItemTask:          refTypeTask,
+	projects.PermissionItemServiceTicket: refTypeServiceTicket,
+}
+
+// copyItemLinks copies the links between copied items. Child-of links are created from the copied references.
+func copyItemLinks(uow *repositories.UnitOfWork, templateProjectID, projectID, createdBy int, ids itemIDMap) error {
+	linkRepo := item_links.NewItemLinkRepository(uow)
+	links, err := linkRepo.GetByProjectID(templateProjectID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, link := range links {
+		if link.LinkType == item_links.LinkTypeChildOf {
+			continue
+		}
+		_, sourceID := ids.remap(linkRefTypes[link.SourceType], &link.SourceID)
+		_, targetID := ids.remap(linkRefTypes[link.TargetType], &link.TargetID)
+		if sourceID == nil || targetID == nil {
+			continue
+		}
+		link.ID = 0
+		link.ProjectID = projectID
+		link.SourceID = *sourceID
+		link.TargetID = *targetID
+		link.CreatedBy = &createdBy
+		link.CreatedAt = now
+		if err := linkRepo.Create(&link); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyCustomFieldValues copies the custom field values of the copied items. User values are
+// kept only for users who keep their assignments.
+func copyCustomFieldValues(uow *repositories.UnitOfWork, projectID int, fields map[int]*custom_fields.CustomField, ids itemIDMap, keepAssignee func(userID int) bool) error {
+	valueRepo := custom_fields.NewCustomFieldValueRepository(uow)
+	for itemType, refType := range linkRefTypes {
+		templateIDs := make([]int, 0, len(ids[refType]))
+		for templateID := range ids[refType] {
+			templateIDs = append(templateIDs, templateID)
+		}
+		values, err := valueRepo.GetByItems(itemType, templateIDs)
+		if err != nil {
+			return err
+		}
+
+		copied := make([]custom_fields.CustomFieldValue, 0, len(values))
+		for _, value := range values {
+			field, ok := fields[value.FieldID]
+			if !ok {
+				continue
+			}
+			if field.FieldType == custom_fields.FieldTypeUser {
+				if userID, err := strconv.Atoi(value.Value); err != nil || !keepAssignee(userID) {
+					continue
+				}
+			}
+			value.ID = 0
+			value.ProjectID = projectID
+			value.FieldID = field.ID
+			value.ItemID = ids[refType][value.ItemID]
+			copied = append(copied, value)
+		}
+		if err := valueRepo.Create(copied); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyOpenItems copies items that are not closed with their custom field values. Sprints, releases and
+// deadlines belong to the template's schedule and are cleared; references between copied items are kept.
+func copyOpenItems(uow *repositories.UnitOfWork, templateProjectID, projectID, createdBy int, fields map[int]*custom_fields.CustomField, keepAssignee func(userID int) bool) error {
+	sequenceRepo := sequences.NewSequenceRepository(uow)
+	ideaRepo := ideas.NewIdeaRepository(uow)
+	issueRepo := issues.NewIssueRepository(uow)
+	featureRepo := features.NewFeatureRepository(uow)
+	taskRepo := tasks.NewTaskRepository(uow)
+	ticketRepo := service_tickets.NewServiceTicketRepository(uow)
+
+	now := time.Now()
+	ids := make(itemIDMap)
+
+	templateTickets, _, err := ticketRepo.GetByProjectIDWithFilters(templateProjectID, openServiceTicketStatuses, "", nil, "", 0, -1)
+	if err != nil {
+		return err
+	}
+	for _, ticket := range templateTickets {
+		templateID := ticket.ID
+		refNum, err := sequenceRepo.GetNextNumber(uow, projectID, "service_tickets")
+		if err != nil {
+			return err
+		}
+		ticket.ID = 0
+		ticket.ProjectID = projectID
+		ticket.RefNum = refNum
+		ticket.CreatedBy = createdBy
+		ticket.CreatedAt = now
+		ticket.UpdatedAt = now
+		if err := ticketRepo.Create(&ticket); err != nil {
+			return err
+		}
+		ids.set(refTypeServiceTicket, templateID, ticket.ID)
+	}
+
+	templateIdeas, _, err := ideaRepo.GetByProjectIDAndStatuses(templateProjectID, openIdeaStatuses, 0, -1)
+	if err != nil {
+		return err
+	}
+	copiedIdeas := make([]*ideas.Idea, 0, len(templateIdeas))
+	for i := range templateIdeas {
+		idea := templateIdeas[i]
+		templateID := idea.ID
+		refNum, err := sequenceRepo.GetNextNumber(uow, projectID, "ideas")
+		if err != nil {
+			return err
+		}
+		idea.ID = 0
+		idea.ProjectID = projectID
+		idea.RefNum = refNum
+		idea.ReleaseID = nil
+		idea.CreatedBy = createdBy
+		idea.CreatedAt = now
+		idea.UpdatedAt = now
+		if err := ideaRepo.Create(&idea); err != nil {
+			return err
+		}
+		ids.set(refTypeIdea, templateID, idea.ID)
+		copiedIdeas = append(copiedIdeas, &idea)
+	}
+
+	templateFeatures, _, err := featureRepo.GetByProjectIDAndStatuses(templateProjectID, openFeatureStatuses, "", 0, -1)
+	if err != nil {
+		return err
+	}
+	copiedFeatures := make([]*features.Feature, 0, len(templateFeatures))
+	for i := range templateFeatures {
+		feature := templateFeatures[i]
+		templateID := feature.ID
+		refNum, err := sequenceRepo.GetNextNumber(uow, projectID, "features")
+		if err != nil {
+			return err
+		}
+		feature.ID = 0
+		feature.ProjectID = projectID
+		feature.RefNum = refNum
+		feature.SprintID = 0
+		feature.ReleaseID = nil
+		feature.Deadline = nil
+		if !keepAssignee(feature.AssignedTo) {
+			feature.AssignedTo = 0
+		}
+		feature.CreatedBy = createdBy
+		feature.CreatedAt = now
+		feature.UpdatedAt = now
+		if err := featureRepo.Create(&feature); err != nil {
+			return err
+		}
+		ids.set(refTypeFeature, templateID, feature.ID)
+		copiedFeatures = append(copiedFeatures, &feature)
+	}
+
+	templateIssues, _, err := issueRepo.GetByProjectIDAndStatuses(templateProjectID, openIssueStatuses, 0, -1)
+	if err != nil {
+		return err
+	}
+	copiedIssues := make([]*issues.Issue, 0, len(templateIssues))
+	for i := range templateIssues {
+		issue := templateIssues[i]
+		templateID := issue.ID
+		refNum, err := sequenceRepo.GetNextNumber(uow, projectID, "issues")
+		if err != nil {
+			return err
+		}
+		issue.ID = 0
+		issue.ProjectID = projectID
+		issue.RefNum = refNum
+		issue.SprintID = 0
+		issue.ReleaseID = nil
+		if !keepAssignee(issue.AssignedTo) {
+			issue.AssignedTo = 0
+		}
+		issue.CreatedBy = createdBy
+		issue.CreatedAt = now
+		issue.UpdatedAt = now
+		if err := issueRepo.Create(&issue); err != nil {
+			return err
+		}
+		ids.set(refTypeIssue, templateID, issue.ID)
+		copiedIssues = append(copiedIssues, &issue)
+	}
+
+	templateTasks, _, err := taskRepo.GetByProjectIDAndStatuses(templateProjectID, openTaskStatuses, 0, -1)
+	if err != nil {
+		return err
+	}
+	copiedTasks := make([]*tasks.Task, 0, len(templateTasks))
+	for i := range templateTasks {
+		task := templateTasks[i]
+		templateID := task.ID
+		task.ID = 0
+		task.ProjectID = projectID
+		task.SprintID = nil
+		task.ReleaseID = nil
+		task.Deadline = nil
+		if task.AssigneeID != nil && !keepAssignee(*task.AssigneeID) {
+			task.AssigneeID = nil
+		}
+		task.CreatedBy = createdBy
+		task.CreatedAt = now
+		task.UpdatedAt = now
+		if err := taskRepo.Create(&task); err != nil {
+			return err
+		}
+		ids.set(refTypeTask, templateID, task.ID)
+		copiedTasks = append(copiedTasks, &task)
+	}
+
+	// Point references at the copied items now that every item has its new ID
+	for _, idea := range copiedIdeas {
+		idea.ItemType, idea.ItemID = ids.remap(idea.ItemType, idea.ItemID)
+		if err := ideaRepo.Update(idea); err != nil {
+			return err
+		}
+	}
+	for _, feature := range copiedFeatures {
+		feature.ItemType, feature.ItemID = ids.remap(feature.ItemType, feature.ItemID)
+		if feature.DependsOnFeatureID != nil {
+			_, feature.DependsOnFeatureID = ids.remap(refTypeFeature, feature.DependsOnFeatureID)
+		}
+		if err := featureRepo.Update(feature); err != nil {
+			return err
+		}
+	}
+	for _, issue := range copiedIssues {
+		issue.ItemType, issue.ItemID = ids.remap(issue.ItemType, issue.ItemID)
+		if err := issueRepo.Update(issue); err != nil {
+			return err
+		}
+	}
+	for _, task := range copiedTasks {
+		task.ItemType, task.ItemID = ids.remap(task.ItemType, task.ItemID)
+		if err := taskRepo.Update(task); err != nil {
+			return err
+		}
+	}
+
+	if err := copyCustomFieldValues(uow, projectID, fields, ids, keepAssignee); err != nil {
+		return err
+	}
+	return copyItemLinks(uow, templateProjectID, projectID, createdBy, ids)
+}
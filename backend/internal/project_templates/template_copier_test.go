@@ -0,0 +1,37 @@
+package project_templates
+
+import (
+	"reflect"
+	"testing"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestItemIDMapRemap(t *testing.T) {
+	ids := make(itemIDMap)
+	ids.set(refTypeFeature, 10, 110)
+
+	tests := []struct {
+		name     string
+		itemType string
+		itemID   *int
+		wantType string
+		wantID   *int
+	}{
+		{name: "copied item", itemType: refTypeFeature, itemID: intPtr(10), wantType: refTypeFeature, wantID: intPtr(110)},
+		{name: "item not copied", itemType: refTypeFeature, itemID: intPtr(11)},
+		{name: "type not copied", itemType: "designs", itemID: intPtr(10)},
+		{name: "no reference", itemType: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotType, gotID := ids.remap(tt.itemType, tt.itemID)
+			if gotType != tt.wantType || !reflect.DeepEqual(gotID, tt.wantID) {
+				t.Fatalf("remap() = %q, %v, want %q, %v", gotType, gotID, tt.wantType, tt.wantID)
+			}
+		})
+	}
+}
@@ -1,23 +1,41 @@
 package projects
 
 import (
+	"errors"
 	"time"
+
+	"gorm.io/gorm"
 )
 
+// ErrProjectArchived rejects changes to an archived project. Archived projects are read-only
+// until they are unarchived; only unarchiving and exporting are allowed.
+var ErrProjectArchived = errors.New("project is archived and read-only")
+
 // Project represents a project in the system
 type Project struct {
-	ID            int       `gorm:"primaryKey;autoIncrement" json:"id"`
-	Name          string    `gorm:"not null;size:255" json:"name"`
-	Description   string    `gorm:"type:text" json:"description"`
-	RepositoryURL string    `gorm:"size:2048" json:"repositoryUrl"`
-	IsArchived    bool      `gorm:"default:false;index" json:"isArchived"`
-	CreatedBy     int       `gorm:"not null;index" json:"createdBy"`
-	CreatedAt     time.Time `gorm:"not null" json:"createdAt"`
-	UpdatedAt     time.Time `gorm:"not null" json:"updatedAt"`
-	ArchivedAt    time.Time `json:"archivedAt,omitempty"`
+	ID            int            `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name          string         `gorm:"not null;size:255" json:"name"`
+	Description   string         `gorm:"type:text" json:"description"`
+	RepositoryURL string         `gorm:"size:2048" json:"repositoryUrl"`
+	IsArchived    bool           `gorm:"default:false;index" json:"isArchived"`
+	IsTemplate    bool           `gorm:"default:false;index" json:"isTemplate"` // New projects can be created from templates
+	CreatedBy     int            `gorm:"not null;index" json:"createdBy"`
+	CreatedAt     time.Time      `gorm:"not null" json:"createdAt"`
+	UpdatedAt     time.Time      `gorm:"not null" json:"updatedAt"`
+	ArchivedAt    time.Time      `json:"archivedAt,omitempty"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"` // Set while in the trash
+	DeletedBy     *int           `json:"-"`
 }
 
 // TableName specifies the table name for GORM
 func (Project) TableName() string {
 	return "projects"
 }
+
+// EnsureWritable returns ErrProjectArchived when the project is archived
+func (p *Project) EnsureWritable() error {
+	if p.IsArchived {
+		return ErrProjectArchived
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package projects
+
+import (
+	"time"
+)
+
+// ProjectGroup grants every member of a user group access to a project with a role
+type ProjectGroup struct {
+	ID        int       `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProjectID int       `gorm:"not null;index:idx_project_group,unique" json:"projectId"`
+	GroupID   int       `gorm:"not null;index:idx_project_group,unique;index" json:"groupId"`
+	RoleID    int       `gorm:"not null;index" json:"roleId"`
+	IsAdmin   bool      `gorm:"default:false" json:"isAdmin"` // IsAdmin and IsUser mirror the role's IsAdmin and IsLimited
+	IsUser    bool      `gorm:"default:false" json:"isUser"`
+	AddedAt   time.Time `gorm:"not null" json:"addedAt"`
+	AddedBy   int       `gorm:"not null" json:"addedBy"`
+}
+
+// ApplyRole assigns the role and updates the flags derived from it
+func (g *ProjectGroup) ApplyRole(role *ProjectRole) {
+	g.RoleID = role.ID
+	g.IsAdmin = role.IsAdmin
+	g.IsUser = role.IsLimited
+}
+
+// TableName specifies the table name for GORM
+func (ProjectGroup) TableName() string {
+	return "project_groups"
+}
+
+// ProjectAccess is a user's effective access to a project, the union of their direct membership and group grants
+type ProjectAccess struct {
+	IsMember  bool
+	IsAdmin   bool
+	IsLimited bool  // Every grant the user holds is limited to project user access
+	RoleIDs   []int // Roles whose permissions the user holds
+}
+
+// MergeProjectAccess combines a direct membership, which may be nil, with the group grants a user receives
+func MergeProjectAccess(member *ProjectMember, grants []ProjectGroup) ProjectAccess {
+	var access ProjectAccess
+	limited := true
+	grant := func(roleID int, isAdmin, isUser bool) {
+		access.IsMember = true
+		access.IsAdmin = access.IsAdmin || isAdmin
+		limited = limited && isUser && !isAdmin
+		if roleID != 0 {
+			access.RoleIDs = append(access.RoleIDs, roleID)
+		}
+	}
+
+	if member != nil {
+		grant(member.RoleID, member.IsAdmin, member.IsUser)
+	}
+	for _, g := range grants {
+		grant(g.RoleID, g.IsAdmin, g.IsUser)
+	}
+
+	access.IsLimited = access.IsMember && limited
+	return access
+}
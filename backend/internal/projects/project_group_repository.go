@@ -0,0 +1,97 @@
+package projects
+
+import (
+	"github.com/dannyswat/pjeasy/internal/repositories"
+	"gorm.io/gorm"
+)
+
+type ProjectGroupRepository struct {
+	uow *repositories.UnitOfWork
+}
+
+func NewProjectGroupRepository(uow *repositories.UnitOfWork) *ProjectGroupRepository {
+	return &ProjectGroupRepository{uow: uow}
+}
+
+// Create grants a group access to a project
+func (r *ProjectGroupRepository) Create(grant *ProjectGroup) error {
+	return r.uow.GetDB().Create(grant).Error
+}
+
+// GetByProjectID returns the groups granted access to a project
+func (r *ProjectGroupRepository) GetByProjectID(projectID int) ([]ProjectGroup, error) {
+	var grants []ProjectGroup
+	err := r.uow.GetDB().Where("project_id = ?", projectID).
+		Order("added_at ASC").
+		Find(&grants).Error
+	return grants, err
+}
+
+// GetByProjectAndGroup finds the grant of a group in a project
+func (r *ProjectGroupRepository) GetByProjectAndGroup(projectID, groupID int) (*ProjectGroup, error) {
+	var grant ProjectGroup
+	err := r.uow.GetDB().Where("project_id = ? AND group_id = ?", projectID, groupID).
+		First(&grant).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &grant, err
+}
+
+// GetByProjectAndUser returns the grants a user receives in a project through their groups
+func (r *ProjectGroupRepository) GetByProjectAndUser(projectID, userID int) ([]ProjectGroup, error) {
+	var grants []ProjectGroup
+	err := r.uow.GetDB().
+		Joins("JOIN user_group_members gm ON gm.group_id = project_groups.group_id").
+		Where("project_groups.project_id = ? AND gm.user_id = ?", projectID, userID).
+		Find(&grants).Error
+	return grants, err
+}
+
+// GetGroupUserIDs returns the users of every group granted access to a project, keyed by group ID
+func (r *ProjectGroupRepository) GetGroupUserIDs(projectID int) (map[int][]int, error) {
+	var rows []struct {
+		GroupID int
+		UserID  int
+	}
+	err := r.uow.GetDB().Table("user_group_members gm").
+		Select("gm.group_id, gm.user_id").
+		Joins("JOIN project_groups pg ON pg.group_id = gm.group_id").
+		Where("pg.project_id = ?", projectID).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := make(map[int][]int)
+	for _, row := range rows {
+		userIDs[row.GroupID] = append(userIDs[row.GroupID], row.UserID)
+	}
+	return userIDs, nil
+}
+
+// Update updates a grant
+func (r *ProjectGroupRepository) Update(grant *ProjectGroup) error {
+	return r.uow.GetDB().Save(grant).Error
+}
+
+// Delete revokes the access of a group to a project
+func (r *ProjectGroupRepository) Delete(projectID, groupID int) error {
+	return r.uow.GetDB().Where("project_id = ? AND group_id = ?", projectID, groupID).
+		Delete(&ProjectGroup{}).Error
+}
+
+// DeleteByGroupID revokes the access of a group to every project
+func (r *ProjectGroupRepository) DeleteByGroupID(groupID int) error {
+	return r.uow.GetDB().Where("group_id = ?", groupID).Delete(&ProjectGroup{}).Error
+}
+
+// DeleteAllByProject revokes the access of every group to a project
+func (r *ProjectGroupRepository) DeleteAllByProject(projectID int) error {
+	return r.uow.GetDB().Where("project_id = ?", projectID).Delete(&ProjectGroup{}).Error
+}
+
+// SetLimitedByRole updates the limited access flag of every grant holding the role
+func (r *ProjectGroupRepository) SetLimitedByRole(roleID int, isLimited bool) error {
+	return r.uow.GetDB().Model(&ProjectGroup{}).Where("role_id = ?", roleID).Update("is_user", isLimited).Error
+}
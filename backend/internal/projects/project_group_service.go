@@ -0,0 +1,203 @@
+package projects
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/repositories"
+	"github.com/dannyswat/pjeasy/internal/user_groups"
+)
+
+type ProjectGroupService struct {
+	grantRepo   *ProjectGroupRepository
+	roleRepo    *ProjectRoleRepository
+	projectRepo *ProjectRepository
+	memberRepo  *ProjectMemberRepository
+	groupRepo   *user_groups.UserGroupRepository
+	memberCache *ProjectMemberCache
+}
+
+func NewProjectGroupService(grantRepo *ProjectGroupRepository, roleRepo *ProjectRoleRepository, projectRepo *ProjectRepository, memberRepo *ProjectMemberRepository, groupRepo *user_groups.UserGroupRepository, memberCache *ProjectMemberCache) *ProjectGroupService {
+	return &ProjectGroupService{
+		grantRepo:   grantRepo,
+		roleRepo:    roleRepo,
+		projectRepo: projectRepo,
+		memberRepo:  memberRepo,
+		groupRepo:   groupRepo,
+		memberCache: memberCache,
+	}
+}
+
+// ProjectGroupWithInfo represents a group grant with group details
+type ProjectGroupWithInfo struct {
+	Grant ProjectGroup           `json:"grant"`
+	Group *user_groups.UserGroup `json:"group"`
+}
+
+// ListGroups returns the groups granted access to a project
+func (s *ProjectGroupService) ListGroups(projectID int, requestedBy int) ([]ProjectGroupWithInfo, error) {
+	isMember, err := s.memberRepo.IsUserMember(projectID, requestedBy)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, errors.New("user is not a member of this project")
+	}
+
+	grants, err := s.grantRepo.GetByProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ProjectGroupWithInfo, 0, len(grants))
+	for _, grant := range grants {
+		group, err := s.groupRepo.GetByID(grant.GroupID)
+		if err != nil || group == nil {
+			continue // Skip if group not found
+		}
+		result = append(result, ProjectGroupWithInfo{
+			Grant: grant,
+			Group: group,
+		})
+	}
+	return result, nil
+}
+
+// AddGroup grants every member of a group access to a project with a role
+func (s *ProjectGroupService) AddGroup(projectID, groupID, roleID int, addedBy int) (*ProjectGroupWithInfo, error) {
+	if err := s.ensureAdmin(projectID, addedBy); err != nil {
+		return nil, err
+	}
+
+	group, err := s.groupRepo.GetByID(groupID)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, errors.New("group not found")
+	}
+
+	existing, err := s.grantRepo.GetByProjectAndGroup(projectID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.New("group is already added to this project")
+	}
+
+	role, err := s.getProjectRole(projectID, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	grant := &ProjectGroup{
+		ProjectID: projectID,
+		GroupID:   groupID,
+		AddedAt:   time.Now(),
+		AddedBy:   addedBy,
+	}
+	grant.ApplyRole(role)
+
+	if err := s.grantRepo.Create(grant); err != nil {
+		return nil, err
+	}
+
+	s.memberCache.InvalidateProject(projectID)
+	return &ProjectGroupWithInfo{Grant: *grant, Group: group}, nil
+}
+
+// UpdateGroupRole changes the role a group holds in a project
+func (s *ProjectGroupService) UpdateGroupRole(projectID, groupID, roleID int, updatedBy int) (*ProjectGroup, error) {
+	if err := s.ensureAdmin(projectID, updatedBy); err != nil {
+		return nil, err
+	}
+
+	grant, err := s.getGrant(projectID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := s.getProjectRole(projectID, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	grant.ApplyRole(role)
+	if err := s.grantRepo.Update(grant); err != nil {
+		return nil, err
+	}
+
+	s.memberCache.InvalidateProject(projectID)
+	return grant, nil
+}
+
+// RemoveGroup revokes the access of a group to a project. Members keep any direct membership.
+func (s *ProjectGroupService) RemoveGroup(projectID, groupID int, removedBy int) error {
+	if err := s.ensureAdmin(projectID, removedBy); err != nil {
+		return err
+	}
+
+	if _, err := s.getGrant(projectID, groupID); err != nil {
+		return err
+	}
+
+	if err := s.grantRepo.Delete(projectID, groupID); err != nil {
+		return err
+	}
+
+	s.memberCache.InvalidateProject(projectID)
+	return nil
+}
+
+// GroupMembersChanged refreshes the cached access of projects the group is granted to
+func (s *ProjectGroupService) GroupMembersChanged(groupID int) {
+	s.memberCache.InvalidateGroup(groupID)
+}
+
+// GroupDeleted revokes the access of a deleted group to every project in the unit of work deleting
+// the group. The caller refreshes the cached access through GroupMembersChanged after committing.
+func (s *ProjectGroupService) GroupDeleted(uow *repositories.UnitOfWork, groupID int) error {
+	return NewProjectGroupRepository(uow).DeleteByGroupID(groupID)
+}
+
+// ensureAdmin rejects changes to archived projects and by users who are not project admins
+func (s *ProjectGroupService) ensureAdmin(projectID, userID int) error {
+	if err := s.projectRepo.EnsureWritable(projectID); err != nil {
+		return err
+	}
+
+	isAdmin, err := s.memberRepo.IsUserAdmin(projectID, userID)
+	if err != nil {
+		return err
+	}
+	if !isAdmin {
+		return errors.New("only project admins can manage project groups")
+	}
+	return nil
+}
+
+func (s *ProjectGroupService) getGrant(projectID, groupID int) (*ProjectGroup, error) {
+	grant, err := s.grantRepo.GetByProjectAndGroup(projectID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if grant == nil {
+		return nil, errors.New("group is not added to this project")
+	}
+	return grant, nil
+}
+
+func (s *ProjectGroupService) getProjectRole(projectID, roleID int) (*ProjectRole, error) {
+	if _, err := ensureBuiltInRoles(s.roleRepo, projectID); err != nil {
+		return nil, err
+	}
+
+	role, err := s.roleRepo.GetByID(roleID)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil || role.ProjectID != projectID {
+		return nil, errors.New("role not found")
+	}
+	return role, nil
+}
@@ -0,0 +1,53 @@
+package projects
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeProjectAccess(t *testing.T) {
+	limitedMember := &ProjectMember{RoleID: 3, IsUser: true}
+	fullMember := &ProjectMember{RoleID: 2}
+
+	tests := []struct {
+		name   string
+		member *ProjectMember
+		grants []ProjectGroup
+		want   ProjectAccess
+	}{
+		{name: "no grants", want: ProjectAccess{}},
+		{name: "direct member only", member: fullMember, want: ProjectAccess{IsMember: true, RoleIDs: []int{2}}},
+		{name: "group grant only", grants: []ProjectGroup{{RoleID: 2}}, want: ProjectAccess{IsMember: true, RoleIDs: []int{2}}},
+		{
+			name:   "limited direct member widened by group",
+			member: limitedMember,
+			grants: []ProjectGroup{{RoleID: 5}},
+			want:   ProjectAccess{IsMember: true, RoleIDs: []int{3, 5}},
+		},
+		{
+			name:   "limited everywhere stays limited",
+			member: limitedMember,
+			grants: []ProjectGroup{{RoleID: 3, IsUser: true}},
+			want:   ProjectAccess{IsMember: true, IsLimited: true, RoleIDs: []int{3, 3}},
+		},
+		{
+			name:   "admin through group",
+			member: fullMember,
+			grants: []ProjectGroup{{RoleID: 1, IsAdmin: true}},
+			want:   ProjectAccess{IsMember: true, IsAdmin: true, RoleIDs: []int{2, 1}},
+		},
+		{
+			name:   "unassigned role is ignored",
+			member: &ProjectMember{},
+			want:   ProjectAccess{IsMember: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MergeProjectAccess(tt.member, tt.grants); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("MergeProjectAccess() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
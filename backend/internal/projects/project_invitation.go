@@ -1,18 +1,31 @@
 package projects
 
-import "time"
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/users"
+)
+
+// ErrInvitationDomainNotAllowed rejects users without a verified address in the allowed domains of a link
+var ErrInvitationDomainNotAllowed = errors.New("a verified email address in an allowed domain is required to use this invitation link")
 
 // ProjectInvitation grants a reusable project membership link.
 type ProjectInvitation struct {
-	ID        int        `gorm:"primaryKey;autoIncrement" json:"id"`
-	ProjectID int        `gorm:"not null;index" json:"projectId"`
-	Token     string     `gorm:"not null;uniqueIndex" json:"token"`
-	TokenHash string     `gorm:"not null;uniqueIndex;size:64" json:"-"`
-	IsUser    bool       `gorm:"default:false" json:"isUser"`
-	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
-	CreatedBy int        `gorm:"not null" json:"createdBy"`
-	CreatedAt time.Time  `gorm:"not null" json:"createdAt"`
-	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	ID               int        `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProjectID        int        `gorm:"not null;index" json:"projectId"`
+	Token            string     `gorm:"not null;uniqueIndex" json:"token"`
+	TokenHash        string     `gorm:"not null;uniqueIndex;size:64" json:"-"`
+	IsUser           bool       `gorm:"default:false" json:"isUser"`
+	ExpiresAt        *time.Time `json:"expiresAt,omitempty"`
+	MaxUses          *int       `json:"maxUses,omitempty"`                         // Nil allows unlimited uses
+	UseCount         int        `gorm:"not null;default:0" json:"useCount"`        // Acceptances and membership requests made through the link
+	AllowedDomains   string     `gorm:"type:text" json:"allowedDomains,omitempty"` // Comma separated; empty allows any user
+	RequiresApproval bool       `gorm:"default:false" json:"requiresApproval"`     // Accepting creates a membership request for admins to review
+	CreatedBy        int        `gorm:"not null" json:"createdBy"`
+	CreatedAt        time.Time  `gorm:"not null" json:"createdAt"`
+	RevokedAt        *time.Time `json:"revokedAt,omitempty"`
 }
 
 func (i ProjectInvitation) TableName() string {
@@ -36,5 +49,110 @@ func (i ProjectInvitation) IsActive(now time.Time) bool {
 		return false
 	}
 
-	return true
+	return !i.IsUsedUp()
+}
+
+// IsUsedUp checks if the link has reached its maximum number of uses
+func (i ProjectInvitation) IsUsedUp() bool {
+	return i.MaxUses != nil && i.UseCount >= *i.MaxUses
+}
+
+// Domains returns the allowed email or login ID domains
+func (i ProjectInvitation) Domains() []string {
+	if i.AllowedDomains == "" {
+		return nil
+	}
+	return strings.Split(i.AllowedDomains, ",")
+}
+
+// AllowsUser checks the user's verified email and provisioned login ID against the allowed domains.
+// Addresses the user typed in themselves are not trusted.
+func (i ProjectInvitation) AllowsUser(user *users.User) bool {
+	if len(i.Domains()) == 0 {
+		return true
+	}
+	if user.IsEmailVerified() && i.AllowsAddress(user.Email) {
+		return true
+	}
+	return user.LoginIDProvisioned && i.AllowsAddress(user.LoginID)
+}
+
+// AllowsAddress checks if the domain of an email-like address is allowed
+func (i ProjectInvitation) AllowsAddress(address string) bool {
+	domains := i.Domains()
+	if len(domains) == 0 {
+		return true
+	}
+
+	at := strings.LastIndex(address, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(address[at+1:])
+	for _, allowed := range domains {
+		if domain == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// ProjectInvitationOptions holds the restrictions of a new invitation link
+type ProjectInvitationOptions struct {
+	IsUser           bool
+	ExpiresAt        *time.Time
+	MaxUses          *int
+	AllowedDomains   []string
+	RequiresApproval bool
+}
+
+// normalizeInvitationDomains lowercases and deduplicates domains, accepting an optional leading "@"
+func normalizeInvitationDomains(domains []string) (string, error) {
+	seen := make(map[string]bool, len(domains))
+	normalized := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "@"))
+		if domain == "" {
+			continue
+		}
+		if strings.ContainsAny(domain, "@, \t") || !strings.Contains(domain, ".") ||
+			strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+			return "", errors.New("invalid allowed domain: " + domain)
+		}
+		if !seen[domain] {
+			seen[domain] = true
+			normalized = append(normalized, domain)
+		}
+	}
+	return strings.Join(normalized, ","), nil
+}
+
+// Invitation acceptance statuses
+const (
+	InvitationAcceptanceJoined   = "Joined"   // The user joined directly through the link
+	InvitationAcceptancePending  = "Pending"  // Waiting for a project admin to approve the membership request
+	InvitationAcceptanceApproved = "Approved" // The membership request was approved and the user joined
+	InvitationAcceptanceRejected = "Rejected"
+)
+
+// ProjectInvitationAcceptance records a user accepting an invitation link.
+// For links requiring approval it is also the membership request reviewed by project admins.
+type ProjectInvitationAcceptance struct {
+	ID           int        `gorm:"primaryKey;autoIncrement" json:"id"`
+	InvitationID int        `gorm:"not null;index" json:"invitationId"`
+	ProjectID    int        `gorm:"not null;index" json:"projectId"`
+	UserID       int        `gorm:"not null;index" json:"userId"`
+	Status       string     `gorm:"size:20;not null" json:"status"`
+	AcceptedAt   time.Time  `gorm:"not null" json:"acceptedAt"`
+	ReviewedBy   *int       `json:"reviewedBy,omitempty"`
+	ReviewedAt   *time.Time `json:"reviewedAt,omitempty"`
+}
+
+func (ProjectInvitationAcceptance) TableName() string {
+	return "project_invitation_acceptances"
+}
+
+// IsPending checks if the acceptance is a membership request waiting for review
+func (a ProjectInvitationAcceptance) IsPending() bool {
+	return a.Status == InvitationAcceptancePending
 }
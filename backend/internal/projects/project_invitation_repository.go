@@ -46,3 +46,61 @@ func (r *ProjectInvitationRepository) GetByID(id int) (*ProjectInvitation, error
 func (r *ProjectInvitationRepository) Update(invitation *ProjectInvitation) error {
 	return r.uow.GetDB().Save(invitation).Error
 }
+
+// IncrementUseCount counts a use of the link, returning false when it has no uses left
+func (r *ProjectInvitationRepository) IncrementUseCount(invitationID int) (bool, error) {
+	result := r.uow.GetDB().Model(&ProjectInvitation{}).
+		Where("id = ? AND (max_uses IS NULL OR use_count < max_uses)", invitationID).
+		UpdateColumn("use_count", gorm.Expr("use_count + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (r *ProjectInvitationRepository) CreateAcceptance(acceptance *ProjectInvitationAcceptance) error {
+	return r.uow.GetDB().Create(acceptance).Error
+}
+
+func (r *ProjectInvitationRepository) UpdateAcceptance(acceptance *ProjectInvitationAcceptance) error {
+	return r.uow.GetDB().Save(acceptance).Error
+}
+
+func (r *ProjectInvitationRepository) GetAcceptanceByID(id int) (*ProjectInvitationAcceptance, error) {
+	var acceptance ProjectInvitationAcceptance
+	err := r.uow.GetDB().First(&acceptance, id).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+
+	return &acceptance, err
+}
+
+// GetPendingAcceptance returns the user's membership request waiting for review in the project
+func (r *ProjectInvitationRepository) GetPendingAcceptance(projectID, userID int) (*ProjectInvitationAcceptance, error) {
+	var acceptance ProjectInvitationAcceptance
+	err := r.uow.GetDB().
+		Where("project_id = ? AND user_id = ? AND status = ?", projectID, userID, InvitationAcceptancePending).
+		First(&acceptance).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+
+	return &acceptance, err
+}
+
+func (r *ProjectInvitationRepository) ListAcceptancesByProjectID(projectID int) ([]ProjectInvitationAcceptance, error) {
+	var acceptances []ProjectInvitationAcceptance
+	err := r.uow.GetDB().Where("project_id = ?", projectID).Order("accepted_at ASC").Find(&acceptances).Error
+	return acceptances, err
+}
+
+func (r *ProjectInvitationRepository) ListPendingAcceptances(projectID int) ([]ProjectInvitationAcceptance, error) {
+	var acceptances []ProjectInvitationAcceptance
+	err := r.uow.GetDB().
+		Where("project_id = ? AND status = ?", projectID, InvitationAcceptancePending).
+		Order("accepted_at ASC").
+		Find(&acceptances).Error
+	return acceptances, err
+}
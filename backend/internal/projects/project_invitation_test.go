@@ -0,0 +1,95 @@
+package projects
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/users"
+)
+
+func TestProjectInvitationIsActive(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+	two := 2
+
+	tests := []struct {
+		name       string
+		invitation ProjectInvitation
+		want       bool
+	}{
+		{name: "unrestricted", invitation: ProjectInvitation{}, want: true},
+		{name: "revoked", invitation: ProjectInvitation{RevokedAt: &past}, want: false},
+		{name: "expired", invitation: ProjectInvitation{ExpiresAt: &past}, want: false},
+		{name: "not yet expired", invitation: ProjectInvitation{ExpiresAt: &future}, want: true},
+		{name: "uses left", invitation: ProjectInvitation{MaxUses: &two, UseCount: 1}, want: true},
+		{name: "used up", invitation: ProjectInvitation{MaxUses: &two, UseCount: 2}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.invitation.IsActive(now); got != tt.want {
+				t.Fatalf("IsActive() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeInvitationDomains(t *testing.T) {
+	tests := []struct {
+		name    string
+		domains []string
+		want    string
+		wantErr bool
+	}{
+		{name: "none", want: ""},
+		{name: "lowercased and deduplicated", domains: []string{"Example.com", "@example.com", " corp.example.org "}, want: "example.com,corp.example.org"},
+		{name: "blank entries skipped", domains: []string{"", " "}, want: ""},
+		{name: "email address rejected", domains: []string{"alice@example.com"}, wantErr: true},
+		{name: "missing top level domain", domains: []string{"localhost"}, wantErr: true},
+		{name: "leading dot rejected", domains: []string{".example.com"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeInvitationDomains(tt.domains)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("normalizeInvitationDomains() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("normalizeInvitationDomains() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProjectInvitationAllowsUser(t *testing.T) {
+	invitation := ProjectInvitation{AllowedDomains: "example.com,partner.org"}
+	verifiedAt := time.Now()
+
+	tests := []struct {
+		name string
+		user users.User
+		want bool
+	}{
+		{name: "verified email domain allowed", user: users.User{LoginID: "alice", Email: "alice@Example.com", EmailVerifiedAt: &verifiedAt}, want: true},
+		{name: "unverified email", user: users.User{LoginID: "alice", Email: "alice@example.com"}, want: false},
+		{name: "provisioned login ID domain allowed", user: users.User{LoginID: "bob@partner.org", LoginIDProvisioned: true}, want: true},
+		{name: "self chosen login ID", user: users.User{LoginID: "bob@partner.org"}, want: false},
+		{name: "other domain", user: users.User{LoginID: "eve", Email: "eve@example.net", EmailVerifiedAt: &verifiedAt}, want: false},
+		{name: "subdomain is not the domain", user: users.User{LoginID: "mallory@evil.example.com", LoginIDProvisioned: true}, want: false},
+		{name: "no address", user: users.User{LoginID: "carol"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := invitation.AllowsUser(&tt.user); got != tt.want {
+				t.Fatalf("AllowsUser(%+v) = %v, want %v", tt.user, got, tt.want)
+			}
+		})
+	}
+
+	if !(ProjectInvitation{}).AllowsUser(&users.User{LoginID: "carol"}) {
+		t.Fatalf("AllowsUser() = false for a link without allowed domains")
+	}
+}
@@ -11,13 +11,16 @@ type ProjectMember struct {
 	UserID    int       `gorm:"not null;index:idx_project_user,unique;index" json:"userId"`
 	IsAdmin   bool      `gorm:"default:false" json:"isAdmin"`
 	IsUser    bool      `gorm:"default:false" json:"isUser"`
+	RoleID    int       `gorm:"not null;default:0;index" json:"roleId"` // IsAdmin and IsUser mirror the role's IsAdmin and IsLimited
 	AddedAt   time.Time `gorm:"not null" json:"addedAt"`
 	AddedBy   int       `gorm:"not null" json:"addedBy"`
 }
 
-// CanWriteProject returns true when the member can modify project items.
-func (m ProjectMember) CanWriteProject() bool {
-	return m.IsAdmin || !m.IsUser
+// ApplyRole assigns the role and updates the flags derived from it
+func (m *ProjectMember) ApplyRole(role *ProjectRole) {
+	m.RoleID = role.ID
+	m.IsAdmin = role.IsAdmin
+	m.IsUser = role.IsLimited
 }
 
 // TableName specifies the table name for GORM
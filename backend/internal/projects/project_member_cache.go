@@ -1,8 +1,12 @@
 package projects
 
 import (
+	"log"
+	"strconv"
 	"sync"
 	"time"
+
+	"github.com/dannyswat/pjeasy/internal/cachebus"
 )
 
 // ProjectMemberCache provides caching for project member lookups
@@ -10,20 +14,29 @@ type ProjectMemberCache struct {
 	cache      map[int]*projectMemberCacheEntry
 	mu         sync.RWMutex
 	memberRepo *ProjectMemberRepository
+	roleRepo   *ProjectRoleRepository
+	groupRepo  *ProjectGroupRepository
 	ttl        time.Duration
+	bus        cachebus.Bus
 }
 
 type projectMemberCacheEntry struct {
-	members   []ProjectMember
-	expiresAt time.Time
+	members    []ProjectMember
+	groups     []ProjectGroup
+	groupUsers map[int][]int // User IDs of each granted group
+	roles      map[int]*ProjectRole
+	expiresAt  time.Time
 }
 
 // NewProjectMemberCache creates a new project member cache
-func NewProjectMemberCache(memberRepo *ProjectMemberRepository, ttl time.Duration) *ProjectMemberCache {
+func NewProjectMemberCache(memberRepo *ProjectMemberRepository, roleRepo *ProjectRoleRepository, groupRepo *ProjectGroupRepository, ttl time.Duration) *ProjectMemberCache {
 	cache := &ProjectMemberCache{
 		cache:      make(map[int]*projectMemberCacheEntry),
 		memberRepo: memberRepo,
+		roleRepo:   roleRepo,
+		groupRepo:  groupRepo,
 		ttl:        ttl,
+		bus:        cachebus.NewNoopBus(),
 	}
 
 	// Start background cleanup goroutine
@@ -32,15 +45,36 @@ func NewProjectMemberCache(memberRepo *ProjectMemberRepository, ttl time.Duratio
 	return cache
 }
 
-// GetProjectMembers retrieves project members from cache or database
+// GetProjectMembers retrieves the direct members of a project from cache or database
 func (c *ProjectMemberCache) GetProjectMembers(projectID int) ([]ProjectMember, error) {
+	entry, err := c.getEntry(projectID)
+	if err != nil {
+		return nil, err
+	}
+	return entry.members, nil
+}
+
+func (c *ProjectMemberCache) getEntry(projectID int) (*projectMemberCacheEntry, error) {
 	c.mu.RLock()
 	entry, exists := c.cache[projectID]
 	c.mu.RUnlock()
 
 	// Check if cache entry exists and is not expired
 	if exists && time.Now().Before(entry.expiresAt) {
-		return entry.members, nil
+		return entry, nil
+	}
+
+	// Projects in the trash grant no access until restored
+	deleted, err := c.memberRepo.IsProjectDeleted(projectID)
+	if err != nil {
+		return nil, err
+	}
+	if deleted {
+		entry = &projectMemberCacheEntry{roles: map[int]*ProjectRole{}, expiresAt: time.Now().Add(c.ttl)}
+		c.mu.Lock()
+		c.cache[projectID] = entry
+		c.mu.Unlock()
+		return entry, nil
 	}
 
 	// Fetch from database
@@ -48,59 +82,101 @@ func (c *ProjectMemberCache) GetProjectMembers(projectID int) ([]ProjectMember,
 	if err != nil {
 		return nil, err
 	}
+	groups, err := c.groupRepo.GetByProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	groupUsers, err := c.groupRepo.GetGroupUserIDs(projectID)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := c.roleRepo.GetByProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry = &projectMemberCacheEntry{
+		members:    members,
+		groups:     groups,
+		groupUsers: groupUsers,
+		roles:      make(map[int]*ProjectRole, len(roles)),
+		expiresAt:  time.Now().Add(c.ttl),
+	}
+	for i := range roles {
+		entry.roles[roles[i].ID] = &roles[i]
+	}
 
 	// Update cache
 	c.mu.Lock()
-	c.cache[projectID] = &projectMemberCacheEntry{
-		members:   members,
-		expiresAt: time.Now().Add(c.ttl),
-	}
+	c.cache[projectID] = entry
 	c.mu.Unlock()
 
-	return members, nil
+	return entry, nil
 }
 
-// IsUserMember checks if a user is a member of a project using cache
-func (c *ProjectMemberCache) IsUserMember(projectID, userID int) (bool, error) {
-	members, err := c.GetProjectMembers(projectID)
-	if err != nil {
-		return false, err
+// access merges the direct membership of a user with the grants of the groups they belong to
+func (e *projectMemberCacheEntry) access(userID int) ProjectAccess {
+	var member *ProjectMember
+	for i := range e.members {
+		if e.members[i].UserID == userID {
+			member = &e.members[i]
+			break
+		}
 	}
 
-	for _, member := range members {
-		if member.UserID == userID {
-			return true, nil
+	var grants []ProjectGroup
+	for _, group := range e.groups {
+		for _, groupUserID := range e.groupUsers[group.GroupID] {
+			if groupUserID == userID {
+				grants = append(grants, group)
+				break
+			}
 		}
 	}
 
-	return false, nil
+	return MergeProjectAccess(member, grants)
 }
 
-// IsUserAdmin checks if a user is an admin of a project using cache
-func (c *ProjectMemberCache) IsUserAdmin(projectID, userID int) (bool, error) {
-	members, err := c.GetProjectMembers(projectID)
+// GetAccess returns the effective access of a user to a project using cache
+func (c *ProjectMemberCache) GetAccess(projectID, userID int) (ProjectAccess, error) {
+	entry, err := c.getEntry(projectID)
 	if err != nil {
-		return false, err
+		return ProjectAccess{}, err
 	}
+	return entry.access(userID), nil
+}
 
-	for _, member := range members {
-		if member.UserID == userID && member.IsAdmin {
-			return true, nil
-		}
-	}
+// IsUserMember checks if a user is a member of a project, directly or through a group, using cache
+func (c *ProjectMemberCache) IsUserMember(projectID, userID int) (bool, error) {
+	access, err := c.GetAccess(projectID, userID)
+	return access.IsMember, err
+}
 
-	return false, nil
+// IsUserAdmin checks if a user is an admin of a project, directly or through a group, using cache
+func (c *ProjectMemberCache) IsUserAdmin(projectID, userID int) (bool, error) {
+	access, err := c.GetAccess(projectID, userID)
+	return access.IsAdmin, err
 }
 
 // IsUserProjectUser checks if a user has ProjectUser limited access using cache.
 func (c *ProjectMemberCache) IsUserProjectUser(projectID, userID int) (bool, error) {
-	members, err := c.GetProjectMembers(projectID)
+	access, err := c.GetAccess(projectID, userID)
+	return access.IsLimited, err
+}
+
+// HasPermission checks if any role the user holds in the project grants the action on the item type using cache
+func (c *ProjectMemberCache) HasPermission(projectID, userID int, itemType, action string) (bool, error) {
+	entry, err := c.getEntry(projectID)
 	if err != nil {
 		return false, err
 	}
 
-	for _, member := range members {
-		if member.UserID == userID && member.IsUser && !member.IsAdmin {
+	access := entry.access(userID)
+	if access.IsAdmin {
+		return true, nil
+	}
+	for _, roleID := range access.RoleIDs {
+		if role, ok := entry.roles[roleID]; ok && role.HasPermission(itemType, action) {
 			return true, nil
 		}
 	}
@@ -108,31 +184,71 @@ func (c *ProjectMemberCache) IsUserProjectUser(projectID, userID int) (bool, err
 	return false, nil
 }
 
-// CanUserWriteProject checks if a user can modify project items using cache.
-func (c *ProjectMemberCache) CanUserWriteProject(projectID, userID int) (bool, error) {
-	members, err := c.GetProjectMembers(projectID)
-	if err != nil {
-		return false, err
-	}
-
-	for _, member := range members {
-		if member.UserID == userID {
-			return member.CanWriteProject(), nil
+// SetBus shares invalidations with the other API replicas through the bus
+func (c *ProjectMemberCache) SetBus(bus cachebus.Bus) {
+	c.bus = bus
+	bus.Subscribe(cachebus.TopicProject, func(invalidation cachebus.Invalidation) {
+		if projectID, err := strconv.Atoi(invalidation.Key); err == nil {
+			c.evictProject(projectID)
+		} else {
+			c.clear()
 		}
+	})
+	bus.Subscribe(cachebus.TopicGroup, func(invalidation cachebus.Invalidation) {
+		if groupID, err := strconv.Atoi(invalidation.Key); err == nil {
+			c.evictGroup(groupID)
+		} else {
+			c.clear()
+		}
+	})
+}
+
+// InvalidateProject clears the cache for a specific project on every replica
+func (c *ProjectMemberCache) InvalidateProject(projectID int) {
+	c.evictProject(projectID)
+	c.publish(cachebus.TopicProject, projectID)
+}
+
+// InvalidateGroup clears the cache of every project the group has been granted access to on every replica
+func (c *ProjectMemberCache) InvalidateGroup(groupID int) {
+	c.evictGroup(groupID)
+	c.publish(cachebus.TopicGroup, groupID)
+}
+
+// Clear removes all entries from the cache on every replica
+func (c *ProjectMemberCache) Clear() {
+	c.clear()
+	if err := c.bus.Publish(cachebus.Invalidation{Topic: cachebus.TopicProject}); err != nil {
+		log.Printf("failed to publish project cache invalidation: %v", err)
 	}
+}
 
-	return false, nil
+func (c *ProjectMemberCache) publish(topic string, id int) {
+	if err := c.bus.Publish(cachebus.Invalidation{Topic: topic, Key: strconv.Itoa(id)}); err != nil {
+		log.Printf("failed to publish %s cache invalidation: %v", topic, err)
+	}
 }
 
-// InvalidateProject clears the cache for a specific project
-func (c *ProjectMemberCache) InvalidateProject(projectID int) {
+func (c *ProjectMemberCache) evictProject(projectID int) {
 	c.mu.Lock()
 	delete(c.cache, projectID)
 	c.mu.Unlock()
 }
 
-// Clear removes all entries from the cache
-func (c *ProjectMemberCache) Clear() {
+func (c *ProjectMemberCache) evictGroup(groupID int) {
+	c.mu.Lock()
+	for projectID, entry := range c.cache {
+		for _, group := range entry.groups {
+			if group.GroupID == groupID {
+				delete(c.cache, projectID)
+				break
+			}
+		}
+	}
+	c.mu.Unlock()
+}
+
+func (c *ProjectMemberCache) clear() {
 	c.mu.Lock()
 	c.cache = make(map[int]*projectMemberCacheEntry)
 	c.mu.Unlock()
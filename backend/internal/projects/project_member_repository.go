@@ -55,46 +55,87 @@ func (r *ProjectMemberRepository) DeleteAllByProject(projectID int) error {
 		Delete(&ProjectMember{}).Error
 }
 
-// IsUserMember checks if a user is a member of a project
+// GetAccess returns the effective access of a user, merging their direct membership with their group grants.
+// Projects in the trash grant no access.
+func (r *ProjectMemberRepository) GetAccess(projectID, userID int) (ProjectAccess, error) {
+	deleted, err := r.IsProjectDeleted(projectID)
+	if err != nil || deleted {
+		return ProjectAccess{}, err
+	}
+	return r.GetAccessIncludingDeleted(projectID, userID)
+}
+
+// GetAccessIncludingDeleted returns the effective access of a user to a project that may be in the trash
+func (r *ProjectMemberRepository) GetAccessIncludingDeleted(projectID, userID int) (ProjectAccess, error) {
+	member, err := r.GetByProjectAndUser(projectID, userID)
+	if err != nil {
+		return ProjectAccess{}, err
+	}
+	grants, err := NewProjectGroupRepository(r.uow).GetByProjectAndUser(projectID, userID)
+	if err != nil {
+		return ProjectAccess{}, err
+	}
+	return MergeProjectAccess(member, grants), nil
+}
+
+// IsUserMember checks if a user is a member of a project directly or through a group
 func (r *ProjectMemberRepository) IsUserMember(projectID, userID int) (bool, error) {
-	var count int64
-	err := r.uow.GetDB().Model(&ProjectMember{}).
-		Where("project_id = ? AND user_id = ?", projectID, userID).
-		Count(&count).Error
-	return count > 0, err
+	access, err := r.GetAccess(projectID, userID)
+	return access.IsMember, err
 }
 
-// IsUserAdmin checks if a user is an admin of a project
+// IsUserAdmin checks if a user is an admin of a project directly or through a group
 func (r *ProjectMemberRepository) IsUserAdmin(projectID, userID int) (bool, error) {
-	var count int64
-	err := r.uow.GetDB().Model(&ProjectMember{}).
-		Where("project_id = ? AND user_id = ? AND is_admin = ?", projectID, userID, true).
-		Count(&count).Error
-	return count > 0, err
+	access, err := r.GetAccess(projectID, userID)
+	return access.IsAdmin, err
 }
 
 // IsUserProjectUser checks if a user has ProjectUser limited access in a project.
+// Users holding any unlimited grant, directly or through a group, are not limited.
 func (r *ProjectMemberRepository) IsUserProjectUser(projectID, userID int) (bool, error) {
-	member, err := r.GetByProjectAndUser(projectID, userID)
-	if err != nil {
+	access, err := r.GetAccess(projectID, userID)
+	return access.IsLimited, err
+}
+
+// HasPermission checks if any role the user holds in the project grants the action on the item type.
+// Project admins have every permission.
+func (r *ProjectMemberRepository) HasPermission(projectID, userID int, itemType, action string) (bool, error) {
+	access, err := r.GetAccess(projectID, userID)
+	if err != nil || !access.IsMember {
 		return false, err
 	}
-	if member == nil {
+	if access.IsAdmin {
+		return true, nil
+	}
+	if len(access.RoleIDs) == 0 {
 		return false, nil
 	}
-	return member.IsUser && !member.IsAdmin, nil
+
+	var count int64
+	err = r.uow.GetDB().Model(&ProjectRolePermission{}).
+		Where("role_id IN ? AND item_type = ? AND action = ?", access.RoleIDs, itemType, action).
+		Count(&count).Error
+	return count > 0, err
 }
 
-// CanUserWriteProject checks if a user can modify project items.
-func (r *ProjectMemberRepository) CanUserWriteProject(projectID, userID int) (bool, error) {
-	member, err := r.GetByProjectAndUser(projectID, userID)
-	if err != nil {
-		return false, err
+// AssignRoleToUnassigned sets the role of members without one whose flags match
+func (r *ProjectMemberRepository) AssignRoleToUnassigned(projectID int, isAdmin, isUser bool, roleID int) error {
+	db := r.uow.GetDB().Model(&ProjectMember{}).
+		Where("project_id = ? AND (role_id IS NULL OR role_id = 0)", projectID)
+	switch {
+	case isAdmin:
+		db = db.Where("is_admin = ?", true)
+	case isUser:
+		db = db.Where("is_admin = ? AND is_user = ?", false, true)
+	default:
+		db = db.Where("is_admin = ? AND is_user = ?", false, false)
 	}
-	if member == nil {
-		return false, nil
-	}
-	return member.CanWriteProject(), nil
+	return db.Update("role_id", roleID).Error
+}
+
+// SetLimitedByRole updates the limited access flag of every member holding the role
+func (r *ProjectMemberRepository) SetLimitedByRole(roleID int, isLimited bool) error {
+	return r.uow.GetDB().Model(&ProjectMember{}).Where("role_id = ?", roleID).Update("is_user", isLimited).Error
 }
 
 // GetUserIDsByProject returns all user IDs in a project
@@ -105,3 +146,12 @@ func (r *ProjectMemberRepository) GetUserIDsByProject(projectID int) ([]int, err
 		Pluck("user_id", &userIDs).Error
 	return userIDs, err
 }
+
+// IsProjectDeleted checks if a project is in the trash
+func (r *ProjectMemberRepository) IsProjectDeleted(projectID int) (bool, error) {
+	var count int64
+	err := r.uow.GetDB().Unscoped().Model(&Project{}).
+		Where("id = ? AND deleted_at IS NOT NULL", projectID).
+		Count(&count).Error
+	return count > 0, err
+}
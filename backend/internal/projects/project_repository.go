@@ -1,10 +1,20 @@
 package projects
 
 import (
+	"time"
+
 	"github.com/dannyswat/pjeasy/internal/repositories"
 	"gorm.io/gorm"
 )
 
+// userProjectCondition matches projects a user belongs to directly or through a group
+const userProjectCondition = "(projects.id IN (SELECT project_id FROM project_members WHERE user_id = ?) OR " +
+	"projects.id IN (SELECT pg.project_id FROM project_groups pg JOIN user_group_members gm ON gm.group_id = pg.group_id WHERE gm.user_id = ?))"
+
+// adminProjectCondition matches projects a user administers directly or through a group
+const adminProjectCondition = "(projects.id IN (SELECT project_id FROM project_members WHERE user_id = ? AND is_admin = true) OR " +
+	"projects.id IN (SELECT pg.project_id FROM project_groups pg JOIN user_group_members gm ON gm.group_id = pg.group_id WHERE gm.user_id = ? AND pg.is_admin = true))"
+
 type ProjectRepository struct {
 	uow *repositories.UnitOfWork
 }
@@ -33,9 +43,14 @@ func (r *ProjectRepository) Update(project *Project) error {
 	return r.uow.GetDB().Save(project).Error
 }
 
-// Delete deletes a project
-func (r *ProjectRepository) Delete(id int) error {
-	return r.uow.GetDB().Delete(&Project{}, id).Error
+// Delete moves a project to the trash. Trashed rows are excluded from every query until restored.
+func (r *ProjectRepository) Delete(id int, deletedBy int) error {
+	return r.uow.GetDB().Model(&Project{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"deleted_at": time.Now(),
+			"deleted_by": deletedBy,
+		}).Error
 }
 
 // GetAll returns all projects with optional filters
@@ -63,14 +78,13 @@ func (r *ProjectRepository) GetAll(includeArchived bool, offset, limit int) ([]P
 	return projects, total, err
 }
 
-// GetByUserID returns all projects where user is a member
+// GetByUserID returns all projects where user is a member directly or through a group
 func (r *ProjectRepository) GetByUserID(userID int, includeArchived bool, offset, limit int) ([]Project, int64, error) {
 	var projects []Project
 	var total int64
 
 	query := r.uow.GetDB().Model(&Project{}).
-		Joins("JOIN project_members ON projects.id = project_members.project_id").
-		Where("project_members.user_id = ?", userID)
+		Where(userProjectCondition, userID, userID)
 
 	if !includeArchived {
 		query = query.Where("projects.is_archived = ?", false)
@@ -90,6 +104,41 @@ func (r *ProjectRepository) GetByUserID(userID int, includeArchived bool, offset
 	return projects, total, err
 }
 
+// GetByAdminUserID returns all projects the user administers directly or through a group
+func (r *ProjectRepository) GetByAdminUserID(userID int, includeArchived bool, offset, limit int) ([]Project, int64, error) {
+	var projects []Project
+	var total int64
+
+	query := r.uow.GetDB().Model(&Project{}).
+		Where(adminProjectCondition, userID, userID)
+
+	if !includeArchived {
+		query = query.Where("projects.is_archived = ?", false)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("projects.name ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&projects).Error
+
+	return projects, total, err
+}
+
+// GetTemplatesByUserID returns the active template projects where user is a member directly or through a group
+func (r *ProjectRepository) GetTemplatesByUserID(userID int) ([]Project, error) {
+	var projects []Project
+	err := r.uow.GetDB().
+		Where("projects.is_template = ? AND projects.is_archived = ?", true, false).
+		Where(userProjectCondition, userID, userID).
+		Order("projects.name ASC").
+		Find(&projects).Error
+	return projects, err
+}
+
 // Archive archives a project
 func (r *ProjectRepository) Archive(id int) error {
 	return r.uow.GetDB().Model(&Project{}).
@@ -109,3 +158,49 @@ func (r *ProjectRepository) Unarchive(id int) error {
 			"archived_at": nil,
 		}).Error
 }
+
+// EnsureWritable returns ErrProjectArchived when the project is archived
+func (r *ProjectRepository) EnsureWritable(projectID int) error {
+	var count int64
+	err := r.uow.GetDB().Model(&Project{}).
+		Where("id = ? AND is_archived = ?", projectID, true).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrProjectArchived
+	}
+	return nil
+}
+
+// GetDeletedByID finds a project in the trash by ID
+func (r *ProjectRepository) GetDeletedByID(id int) (*Project, error) {
+	var project Project
+	err := r.uow.GetDB().Unscoped().Where("deleted_at IS NOT NULL").First(&project, id).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &project, err
+}
+
+// GetDeletedByAdminUserID returns the projects in the trash that the user administers
+func (r *ProjectRepository) GetDeletedByAdminUserID(userID int) ([]Project, error) {
+	var projects []Project
+	err := r.uow.GetDB().Unscoped().
+		Where("projects.deleted_at IS NOT NULL").
+		Where(adminProjectCondition, userID, userID).
+		Order("projects.deleted_at DESC").
+		Find(&projects).Error
+	return projects, err
+}
+
+// Restore takes a project out of the trash
+func (r *ProjectRepository) Restore(id int) error {
+	return r.uow.GetDB().Unscoped().Model(&Project{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"deleted_by": nil,
+		}).Error
+}
@@ -0,0 +1,227 @@
+package projects
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// ProjectRole is a named set of permissions assigned to project members
+type ProjectRole struct {
+	ID          int                     `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProjectID   int                     `gorm:"not null;index:idx_project_role_name,unique" json:"projectId"`
+	Name        string                  `gorm:"not null;size:100;index:idx_project_role_name,unique" json:"name"`
+	Description string                  `gorm:"size:500" json:"description"`
+	BuiltInKey  string                  `gorm:"size:30" json:"builtInKey,omitempty"` // Empty for custom roles
+	IsAdmin     bool                    `gorm:"default:false" json:"isAdmin"`        // Grants project administration and every permission
+	IsLimited   bool                    `gorm:"default:false" json:"isLimited"`      // Limited readers who may only change their own service tickets
+	Permissions []ProjectRolePermission `gorm:"foreignKey:RoleID" json:"permissions"`
+	CreatedAt   time.Time               `json:"createdAt"`
+	UpdatedAt   time.Time               `json:"updatedAt"`
+}
+
+// TableName specifies the table name for GORM
+func (ProjectRole) TableName() string {
+	return "project_roles"
+}
+
+// IsBuiltIn checks if the role is one of the roles every project starts with
+func (r *ProjectRole) IsBuiltIn() bool {
+	return r.BuiltInKey != ""
+}
+
+// HasPermission checks if the role grants the action on the item type
+func (r *ProjectRole) HasPermission(itemType, action string) bool {
+	if r.IsAdmin {
+		return true
+	}
+	for _, permission := range r.Permissions {
+		if permission.ItemType == itemType && permission.Action == action {
+			return true
+		}
+	}
+	return false
+}
+
+// ProjectRolePermission grants one action on one item type
+type ProjectRolePermission struct {
+	RoleID   int    `gorm:"primaryKey" json:"-"`
+	ItemType string `gorm:"primaryKey;size:30" json:"itemType"`
+	Action   string `gorm:"primaryKey;size:30" json:"action"`
+}
+
+// TableName specifies the table name for GORM
+func (ProjectRolePermission) TableName() string {
+	return "project_role_permissions"
+}
+
+// Item types that permissions are granted on
+const (
+	PermissionItemIdea          = "idea"
+	PermissionItemIssue         = "issue"
+	PermissionItemFeature       = "feature"
+	PermissionItemTask          = "task"
+	PermissionItemServiceTicket = "service-ticket"
+	PermissionItemWikiPage      = "wiki-page"
+	PermissionItemRelease       = "release"
+	PermissionItemSprint        = "sprint"
+)
+
+// Actions that can be granted on an item type
+const (
+	PermissionActionCreate       = "create"
+	PermissionActionEdit         = "edit"
+	PermissionActionChangeStatus = "change_status"
+	PermissionActionDelete       = "delete"
+	PermissionActionComment      = "comment"
+	PermissionActionManageSprint = "manage_sprint"
+)
+
+// Built-in role keys. Existing members were migrated from the IsAdmin and IsUser flags to these roles.
+const (
+	BuiltInRoleAdmin       = "admin"
+	BuiltInRoleMember      = "member"
+	BuiltInRoleProjectUser = "project_user"
+)
+
+var itemActions = []string{
+	PermissionActionCreate,
+	PermissionActionEdit,
+	PermissionActionChangeStatus,
+	PermissionActionDelete,
+	PermissionActionComment,
+}
+
+// permissionMatrix lists the actions that apply to each item type
+var permissionMatrix = map[string][]string{
+	PermissionItemIdea:          itemActions,
+	PermissionItemIssue:         itemActions,
+	PermissionItemFeature:       itemActions,
+	PermissionItemTask:          itemActions,
+	PermissionItemServiceTicket: itemActions,
+	PermissionItemWikiPage:      itemActions,
+	PermissionItemRelease:       {PermissionActionCreate, PermissionActionEdit, PermissionActionChangeStatus, PermissionActionDelete},
+	PermissionItemSprint:        {PermissionActionManageSprint},
+}
+
+// PermissionItemTypes returns the item types in display order
+func PermissionItemTypes() []string {
+	return []string{
+		PermissionItemIdea,
+		PermissionItemIssue,
+		PermissionItemFeature,
+		PermissionItemTask,
+		PermissionItemServiceTicket,
+		PermissionItemWikiPage,
+		PermissionItemRelease,
+		PermissionItemSprint,
+	}
+}
+
+// PermissionActions returns the actions that apply to the item type
+func PermissionActions(itemType string) []string {
+	return permissionMatrix[itemType]
+}
+
+// IsValidPermission checks if the action applies to the item type
+func IsValidPermission(itemType, action string) bool {
+	for _, allowed := range permissionMatrix[itemType] {
+		if allowed == action {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizePermissions validates permissions and removes duplicates
+func NormalizePermissions(permissions []ProjectRolePermission) ([]ProjectRolePermission, error) {
+	seen := make(map[ProjectRolePermission]bool, len(permissions))
+	normalized := make([]ProjectRolePermission, 0, len(permissions))
+	for _, permission := range permissions {
+		permission = ProjectRolePermission{
+			ItemType: strings.TrimSpace(permission.ItemType),
+			Action:   strings.TrimSpace(permission.Action),
+		}
+		if !IsValidPermission(permission.ItemType, permission.Action) {
+			return nil, errors.New("invalid permission " + permission.ItemType + ":" + permission.Action)
+		}
+		if seen[permission] {
+			continue
+		}
+		seen[permission] = true
+		normalized = append(normalized, permission)
+	}
+	return normalized, nil
+}
+
+var permissionActionVerbs = map[string]string{
+	PermissionActionCreate:       "creating",
+	PermissionActionEdit:         "editing",
+	PermissionActionChangeStatus: "changing the status of",
+	PermissionActionDelete:       "deleting",
+	PermissionActionComment:      "commenting on",
+}
+
+// PermissionDeniedError describes the permission the user's project role lacks
+func PermissionDeniedError(itemType, action string) error {
+	if action == PermissionActionManageSprint {
+		return errors.New("your project role does not allow managing sprints")
+	}
+	return errors.New("your project role does not allow " + permissionActionVerbs[action] + " " + strings.ReplaceAll(itemType, "-", " ") + "s")
+}
+
+// BuiltInRoles returns the roles created for every project
+func BuiltInRoles(projectID int) []ProjectRole {
+	var adminPermissions, memberPermissions, projectUserPermissions []ProjectRolePermission
+	for _, itemType := range PermissionItemTypes() {
+		for _, action := range permissionMatrix[itemType] {
+			permission := ProjectRolePermission{ItemType: itemType, Action: action}
+			adminPermissions = append(adminPermissions, permission)
+			if action != PermissionActionManageSprint {
+				memberPermissions = append(memberPermissions, permission)
+			}
+			if action == PermissionActionComment ||
+				(itemType == PermissionItemServiceTicket && action != PermissionActionDelete) {
+				projectUserPermissions = append(projectUserPermissions, permission)
+			}
+		}
+	}
+
+	return []ProjectRole{
+		{
+			ProjectID:   projectID,
+			Name:        "Admin",
+			Description: "Manages the project, its members and sprints",
+			BuiltInKey:  BuiltInRoleAdmin,
+			IsAdmin:     true,
+			Permissions: adminPermissions,
+		},
+		{
+			ProjectID:   projectID,
+			Name:        "Member",
+			Description: "Creates and updates every project item",
+			BuiltInKey:  BuiltInRoleMember,
+			Permissions: memberPermissions,
+		},
+		{
+			ProjectID:   projectID,
+			Name:        "Project User",
+			Description: "Reads the project, raises service tickets and comments",
+			BuiltInKey:  BuiltInRoleProjectUser,
+			IsLimited:   true,
+			Permissions: projectUserPermissions,
+		},
+	}
+}
+
+// BuiltInRoleKeyFor maps the legacy member flags to a built-in role
+func BuiltInRoleKeyFor(isAdmin, isUser bool) string {
+	switch {
+	case isAdmin:
+		return BuiltInRoleAdmin
+	case isUser:
+		return BuiltInRoleProjectUser
+	default:
+		return BuiltInRoleMember
+	}
+}
@@ -0,0 +1,105 @@
+package projects
+
+import (
+	"github.com/dannyswat/pjeasy/internal/repositories"
+	"gorm.io/gorm"
+)
+
+type ProjectRoleRepository struct {
+	uow *repositories.UnitOfWork
+}
+
+func NewProjectRoleRepository(uow *repositories.UnitOfWork) *ProjectRoleRepository {
+	return &ProjectRoleRepository{uow: uow}
+}
+
+// Create adds a role together with its permissions
+func (r *ProjectRoleRepository) Create(role *ProjectRole) error {
+	return r.uow.GetDB().Create(role).Error
+}
+
+// GetByID returns a role with its permissions
+func (r *ProjectRoleRepository) GetByID(roleID int) (*ProjectRole, error) {
+	var role ProjectRole
+	err := r.uow.GetDB().Preload("Permissions").Where("id = ?", roleID).First(&role).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &role, err
+}
+
+// GetByProjectID returns all roles of a project, built-in roles first
+func (r *ProjectRoleRepository) GetByProjectID(projectID int) ([]ProjectRole, error) {
+	var roles []ProjectRole
+	err := r.uow.GetDB().Preload("Permissions").Where("project_id = ?", projectID).
+		Order("built_in_key = '' ASC, id ASC").
+		Find(&roles).Error
+	return roles, err
+}
+
+// GetByName finds a role of a project by name
+func (r *ProjectRoleRepository) GetByName(projectID int, name string) (*ProjectRole, error) {
+	var role ProjectRole
+	err := r.uow.GetDB().Where("project_id = ? AND LOWER(name) = LOWER(?)", projectID, name).First(&role).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &role, err
+}
+
+// GetBuiltIn finds a built-in role of a project
+func (r *ProjectRoleRepository) GetBuiltIn(projectID int, key string) (*ProjectRole, error) {
+	var role ProjectRole
+	err := r.uow.GetDB().Preload("Permissions").Where("project_id = ? AND built_in_key = ?", projectID, key).First(&role).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &role, err
+}
+
+// Update saves the role and replaces its permissions
+func (r *ProjectRoleRepository) Update(role *ProjectRole) error {
+	db := r.uow.GetDB()
+	if err := db.Omit("Permissions").Save(role).Error; err != nil {
+		return err
+	}
+	if err := db.Where("role_id = ?", role.ID).Delete(&ProjectRolePermission{}).Error; err != nil {
+		return err
+	}
+	if len(role.Permissions) == 0 {
+		return nil
+	}
+	for i := range role.Permissions {
+		role.Permissions[i].RoleID = role.ID
+	}
+	return db.Create(&role.Permissions).Error
+}
+
+// Delete removes a role and its permissions
+func (r *ProjectRoleRepository) Delete(roleID int) error {
+	db := r.uow.GetDB()
+	if err := db.Where("role_id = ?", roleID).Delete(&ProjectRolePermission{}).Error; err != nil {
+		return err
+	}
+	return db.Delete(&ProjectRole{}, roleID).Error
+}
+
+// CountMembers returns the number of members and groups assigned to a role
+func (r *ProjectRoleRepository) CountMembers(roleID int) (int64, error) {
+	var memberCount, groupCount int64
+	if err := r.uow.GetDB().Model(&ProjectMember{}).Where("role_id = ?", roleID).Count(&memberCount).Error; err != nil {
+		return 0, err
+	}
+	err := r.uow.GetDB().Model(&ProjectGroup{}).Where("role_id = ?", roleID).Count(&groupCount).Error
+	return memberCount + groupCount, err
+}
+
+// GetProjectIDsWithoutRoles returns projects that have members not yet assigned to a role
+func (r *ProjectRoleRepository) GetProjectIDsWithoutRoles() ([]int, error) {
+	var projectIDs []int
+	err := r.uow.GetDB().Model(&ProjectMember{}).
+		Where("role_id IS NULL OR role_id = 0").
+		Distinct().
+		Pluck("project_id", &projectIDs).Error
+	return projectIDs, err
+}
@@ -0,0 +1,334 @@
+package projects
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/repositories"
+)
+
+type ProjectRoleService struct {
+	uowFactory  *repositories.UnitOfWorkFactory
+	roleRepo    *ProjectRoleRepository
+	projectRepo *ProjectRepository
+	memberRepo  *ProjectMemberRepository
+	memberCache *ProjectMemberCache
+}
+
+func NewProjectRoleService(uowFactory *repositories.UnitOfWorkFactory, roleRepo *ProjectRoleRepository, projectRepo *ProjectRepository, memberRepo *ProjectMemberRepository, memberCache *ProjectMemberCache) *ProjectRoleService {
+	return &ProjectRoleService{
+		uowFactory:  uowFactory,
+		roleRepo:    roleRepo,
+		projectRepo: projectRepo,
+		memberRepo:  memberRepo,
+		memberCache: memberCache,
+	}
+}
+
+// ensureBuiltInRoles creates the built-in roles a project is missing and returns them by key
+func ensureBuiltInRoles(roleRepo *ProjectRoleRepository, projectID int) (map[string]*ProjectRole, error) {
+	roles := make(map[string]*ProjectRole)
+	for _, builtIn := range BuiltInRoles(projectID) {
+		role, err := roleRepo.GetBuiltIn(projectID, builtIn.BuiltInKey)
+		if err != nil {
+			return nil, err
+		}
+		if role == nil {
+			role = &builtIn
+			now := time.Now()
+			role.CreatedAt = now
+			role.UpdatedAt = now
+			if err := roleRepo.Create(role); err != nil {
+				return nil, err
+			}
+		}
+		roles[role.BuiltInKey] = role
+	}
+	return roles, nil
+}
+
+// builtInRoleFor returns the built-in role matching the legacy admin and project user flags
+func builtInRoleFor(roleRepo *ProjectRoleRepository, projectID int, isAdmin, isUser bool) (*ProjectRole, error) {
+	roles, err := ensureBuiltInRoles(roleRepo, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return roles[BuiltInRoleKeyFor(isAdmin, isUser)], nil
+}
+
+// MigrateMemberRoles assigns built-in roles to members created before project roles existed
+func (s *ProjectRoleService) MigrateMemberRoles() error {
+	projectIDs, err := s.roleRepo.GetProjectIDsWithoutRoles()
+	if err != nil {
+		return err
+	}
+
+	for _, projectID := range projectIDs {
+		uow := s.uowFactory.NewUnitOfWork()
+		if err := uow.BeginTransaction(); err != nil {
+			return err
+		}
+		txRoleRepo := NewProjectRoleRepository(uow)
+		txMemberRepo := NewProjectMemberRepository(uow)
+
+		roles, err := ensureBuiltInRoles(txRoleRepo, projectID)
+		if err != nil {
+			uow.RollbackTransaction()
+			return err
+		}
+		for _, flags := range [][2]bool{{true, false}, {false, true}, {false, false}} {
+			role := roles[BuiltInRoleKeyFor(flags[0], flags[1])]
+			if err := txMemberRepo.AssignRoleToUnassigned(projectID, flags[0], flags[1], role.ID); err != nil {
+				uow.RollbackTransaction()
+				return err
+			}
+		}
+
+		if err := uow.CommitTransaction(); err != nil {
+			return err
+		}
+		s.memberCache.InvalidateProject(projectID)
+	}
+
+	return nil
+}
+
+// ListRoles returns the roles of a project
+func (s *ProjectRoleService) ListRoles(projectID int, requestedBy int) ([]ProjectRole, error) {
+	isMember, err := s.memberRepo.IsUserMember(projectID, requestedBy)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, errors.New("user is not a member of this project")
+	}
+
+	if _, err := ensureBuiltInRoles(s.roleRepo, projectID); err != nil {
+		return nil, err
+	}
+	return s.roleRepo.GetByProjectID(projectID)
+}
+
+// CreateRole adds a custom role to a project
+func (s *ProjectRoleService) CreateRole(projectID int, name, description string, isLimited bool, permissions []ProjectRolePermission, createdBy int) (*ProjectRole, error) {
+	if err := s.ensureAdmin(projectID, createdBy); err != nil {
+		return nil, err
+	}
+
+	name, permissions, err := s.validateRole(projectID, 0, name, permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	role := &ProjectRole{
+		ProjectID:   projectID,
+		Name:        name,
+		Description: strings.TrimSpace(description),
+		IsLimited:   isLimited,
+		Permissions: permissions,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.roleRepo.Create(role); err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+// UpdateRole changes a custom role. Members holding the role get the new permissions immediately.
+func (s *ProjectRoleService) UpdateRole(projectID, roleID int, name, description string, isLimited bool, permissions []ProjectRolePermission, updatedBy int) (*ProjectRole, error) {
+	if err := s.ensureAdmin(projectID, updatedBy); err != nil {
+		return nil, err
+	}
+
+	role, err := s.getProjectRole(projectID, roleID)
+	if err != nil {
+		return nil, err
+	}
+	if role.IsBuiltIn() {
+		return nil, errors.New("built-in roles cannot be changed")
+	}
+
+	name, permissions, err = s.validateRole(projectID, roleID, name, permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	uow := s.uowFactory.NewUnitOfWork()
+	if err := uow.BeginTransaction(); err != nil {
+		return nil, err
+	}
+	defer uow.RollbackTransactionIfError()
+
+	role.Name = name
+	role.Description = strings.TrimSpace(description)
+	role.Permissions = permissions
+	limitedChanged := role.IsLimited != isLimited
+	role.IsLimited = isLimited
+	role.UpdatedAt = time.Now()
+	if err := NewProjectRoleRepository(uow).Update(role); err != nil {
+		return nil, err
+	}
+
+	// Keep the flag derived from the role in sync for members and groups holding it
+	if limitedChanged {
+		if err := NewProjectMemberRepository(uow).SetLimitedByRole(role.ID, isLimited); err != nil {
+			return nil, err
+		}
+		if err := NewProjectGroupRepository(uow).SetLimitedByRole(role.ID, isLimited); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := uow.CommitTransaction(); err != nil {
+		return nil, err
+	}
+
+	s.memberCache.InvalidateProject(projectID)
+	return role, nil
+}
+
+// DeleteRole removes a custom role that no member or group holds
+func (s *ProjectRoleService) DeleteRole(projectID, roleID int, deletedBy int) error {
+	if err := s.ensureAdmin(projectID, deletedBy); err != nil {
+		return err
+	}
+
+	role, err := s.getProjectRole(projectID, roleID)
+	if err != nil {
+		return err
+	}
+	if role.IsBuiltIn() {
+		return errors.New("built-in roles cannot be deleted")
+	}
+
+	memberCount, err := s.roleRepo.CountMembers(roleID)
+	if err != nil {
+		return err
+	}
+	if memberCount > 0 {
+		return errors.New("role is assigned to members or groups")
+	}
+
+	uow := s.uowFactory.NewUnitOfWork()
+	if err := uow.BeginTransaction(); err != nil {
+		return err
+	}
+	defer uow.RollbackTransactionIfError()
+
+	if err := NewProjectRoleRepository(uow).Delete(roleID); err != nil {
+		return err
+	}
+
+	if err := uow.CommitTransaction(); err != nil {
+		return err
+	}
+
+	s.memberCache.InvalidateProject(projectID)
+	return nil
+}
+
+// AssignMemberRole gives a member one of the project's roles
+func (s *ProjectRoleService) AssignMemberRole(projectID, userID, roleID int, updatedBy int) (*ProjectMember, error) {
+	if err := s.ensureAdmin(projectID, updatedBy); err != nil {
+		return nil, err
+	}
+
+	role, err := s.getProjectRole(projectID, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	member, err := s.memberRepo.GetByProjectAndUser(projectID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, errors.New("user is not a member of this project")
+	}
+
+	if member.IsAdmin && !role.IsAdmin {
+		if err := ensureNotLastAdmin(s.memberRepo, projectID); err != nil {
+			return nil, err
+		}
+	}
+
+	member.ApplyRole(role)
+	if err := s.memberRepo.Update(member); err != nil {
+		return nil, err
+	}
+
+	s.memberCache.InvalidateProject(projectID)
+	return member, nil
+}
+
+// ensureAdmin rejects changes to archived projects and by users who are not project admins
+func (s *ProjectRoleService) ensureAdmin(projectID, userID int) error {
+	if err := s.projectRepo.EnsureWritable(projectID); err != nil {
+		return err
+	}
+
+	isAdmin, err := s.memberRepo.IsUserAdmin(projectID, userID)
+	if err != nil {
+		return err
+	}
+	if !isAdmin {
+		return errors.New("only project admins can manage project roles")
+	}
+	return nil
+}
+
+func (s *ProjectRoleService) getProjectRole(projectID, roleID int) (*ProjectRole, error) {
+	role, err := s.roleRepo.GetByID(roleID)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil || role.ProjectID != projectID {
+		return nil, errors.New("role not found")
+	}
+	return role, nil
+}
+
+func (s *ProjectRoleService) validateRole(projectID, roleID int, name string, permissions []ProjectRolePermission) (string, []ProjectRolePermission, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", nil, errors.New("role name is required")
+	}
+
+	existing, err := s.roleRepo.GetByName(projectID, name)
+	if err != nil {
+		return "", nil, err
+	}
+	if existing != nil && existing.ID != roleID {
+		return "", nil, errors.New("a role with this name already exists")
+	}
+
+	permissions, err = NormalizePermissions(permissions)
+	if err != nil {
+		return "", nil, err
+	}
+	return name, permissions, nil
+}
+
+// ensureNotLastAdmin fails when the project has only one admin left
+func ensureNotLastAdmin(memberRepo *ProjectMemberRepository, projectID int) error {
+	members, err := memberRepo.GetByProjectID(projectID)
+	if err != nil {
+		return err
+	}
+
+	adminCount := 0
+	for _, m := range members {
+		if m.IsAdmin {
+			adminCount++
+		}
+	}
+
+	if adminCount <= 1 {
+		return errors.New("cannot remove the last admin from project")
+	}
+	return nil
+}
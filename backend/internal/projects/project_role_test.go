@@ -0,0 +1,68 @@
+package projects
+
+import "testing"
+
+func TestBuiltInRolesMatchLegacyFlags(t *testing.T) {
+	roles := make(map[string]ProjectRole)
+	for _, role := range BuiltInRoles(1) {
+		roles[role.BuiltInKey] = role
+	}
+
+	tests := []struct {
+		name     string
+		isAdmin  bool
+		isUser   bool
+		itemType string
+		action   string
+		want     bool
+	}{
+		{name: "admin manages sprints", isAdmin: true, itemType: PermissionItemSprint, action: PermissionActionManageSprint, want: true},
+		{name: "member creates issues", itemType: PermissionItemIssue, action: PermissionActionCreate, want: true},
+		{name: "member deletes service tickets", itemType: PermissionItemServiceTicket, action: PermissionActionDelete, want: true},
+		{name: "member cannot manage sprints", itemType: PermissionItemSprint, action: PermissionActionManageSprint, want: false},
+		{name: "project user raises service tickets", isUser: true, itemType: PermissionItemServiceTicket, action: PermissionActionCreate, want: true},
+		{name: "project user comments", isUser: true, itemType: PermissionItemFeature, action: PermissionActionComment, want: true},
+		{name: "project user cannot edit features", isUser: true, itemType: PermissionItemFeature, action: PermissionActionEdit, want: false},
+		{name: "project user cannot delete service tickets", isUser: true, itemType: PermissionItemServiceTicket, action: PermissionActionDelete, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			role := roles[BuiltInRoleKeyFor(tt.isAdmin, tt.isUser)]
+			if got := role.HasPermission(tt.itemType, tt.action); got != tt.want {
+				t.Fatalf("HasPermission(%q, %q) = %v, want %v", tt.itemType, tt.action, got, tt.want)
+			}
+
+			var member ProjectMember
+			member.ApplyRole(&role)
+			if member.IsAdmin != tt.isAdmin || member.IsUser != tt.isUser {
+				t.Fatalf("ApplyRole() flags = %v/%v, want %v/%v", member.IsAdmin, member.IsUser, tt.isAdmin, tt.isUser)
+			}
+		})
+	}
+}
+
+func TestNormalizePermissions(t *testing.T) {
+	permissions, err := NormalizePermissions([]ProjectRolePermission{
+		{ItemType: PermissionItemIssue, Action: PermissionActionChangeStatus},
+		{ItemType: " issue ", Action: "change_status"},
+		{ItemType: PermissionItemWikiPage, Action: PermissionActionComment},
+	})
+	if err != nil {
+		t.Fatalf("NormalizePermissions() error = %v", err)
+	}
+	if len(permissions) != 2 {
+		t.Fatalf("NormalizePermissions() = %v, want duplicates removed", permissions)
+	}
+
+	invalid := [][2]string{
+		{PermissionItemSprint, PermissionActionCreate},
+		{PermissionItemRelease, PermissionActionComment},
+		{"project", PermissionActionEdit},
+	}
+	for _, permission := range invalid {
+		if _, err := NormalizePermissions([]ProjectRolePermission{{ItemType: permission[0], Action: permission[1]}}); err == nil {
+			t.Fatalf("NormalizePermissions() accepted %s:%s", permission[0], permission[1])
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/dannyswat/pjeasy/internal/repositories"
 	"github.com/dannyswat/pjeasy/internal/sequences"
 	"github.com/dannyswat/pjeasy/internal/users"
 )
@@ -17,22 +18,32 @@ type ProjectService struct {
 	projectRepo    *ProjectRepository
 	memberRepo     *ProjectMemberRepository
 	invitationRepo *ProjectInvitationRepository
+	roleRepo       *ProjectRoleRepository
 	memberCache    *ProjectMemberCache
 	userRepo       *users.UserRepository
 	sequenceRepo   *sequences.SequenceRepository
+	uowFactory     *repositories.UnitOfWorkFactory
+	templateCopier ProjectTemplateCopier
 }
 
-func NewProjectService(projectRepo *ProjectRepository, memberRepo *ProjectMemberRepository, invitationRepo *ProjectInvitationRepository, userRepo *users.UserRepository, sequenceRepo *sequences.SequenceRepository, memberCache *ProjectMemberCache) *ProjectService {
+func NewProjectService(projectRepo *ProjectRepository, memberRepo *ProjectMemberRepository, invitationRepo *ProjectInvitationRepository, roleRepo *ProjectRoleRepository, userRepo *users.UserRepository, sequenceRepo *sequences.SequenceRepository, memberCache *ProjectMemberCache, uowFactory *repositories.UnitOfWorkFactory) *ProjectService {
 	return &ProjectService{
 		projectRepo:    projectRepo,
 		memberRepo:     memberRepo,
 		invitationRepo: invitationRepo,
+		roleRepo:       roleRepo,
 		memberCache:    memberCache,
 		userRepo:       userRepo,
 		sequenceRepo:   sequenceRepo,
+		uowFactory:     uowFactory,
 	}
 }
 
+// SetTemplateCopier sets the copier for the template parts owned by other modules
+func (s *ProjectService) SetTemplateCopier(copier ProjectTemplateCopier) {
+	s.templateCopier = copier
+}
+
 // ProjectWithMembers represents a project with its members
 type ProjectWithMembers struct {
 	Project Project              `json:"project"`
@@ -51,12 +62,20 @@ type ProjectInvitationDetails struct {
 }
 
 type ProjectInvitationListItem struct {
-	Invitation *ProjectInvitation `json:"invitation"`
-	Project    *Project           `json:"project"`
+	Invitation  *ProjectInvitation             `json:"invitation"`
+	Project     *Project                       `json:"project"`
+	Acceptances []InvitationAcceptanceWithUser `json:"acceptances"`
 }
 
-// CreateProject creates a new project and adds creator as admin
-func (s *ProjectService) CreateProject(name, description, repositoryURL string, createdBy int) (*Project, error) {
+// InvitationAcceptanceWithUser represents an invitation acceptance or membership request with user details
+type InvitationAcceptanceWithUser struct {
+	Acceptance ProjectInvitationAcceptance `json:"acceptance"`
+	User       *users.User                 `json:"user"`
+}
+
+// CreateProject creates a new project and adds creator as admin.
+// When a template is given, the selected parts of it are copied in the same transaction.
+func (s *ProjectService) CreateProject(name, description, repositoryURL string, createdBy int, template *ProjectTemplateOptions) (*Project, error) {
 	// Validate creator exists
 	creator, err := s.userRepo.GetByID(createdBy)
 	if err != nil {
@@ -66,6 +85,18 @@ func (s *ProjectService) CreateProject(name, description, repositoryURL string,
 		return nil, errors.New("creator user not found")
 	}
 
+	if template != nil {
+		if err := s.validateTemplate(template.TemplateProjectID, createdBy); err != nil {
+			return nil, err
+		}
+	}
+
+	uow := s.uowFactory.NewUnitOfWork()
+	if err := uow.BeginTransaction(); err != nil {
+		return nil, err
+	}
+	defer uow.RollbackTransactionIfError()
+
 	now := time.Now()
 	project := &Project{
 		Name:          name,
@@ -77,7 +108,12 @@ func (s *ProjectService) CreateProject(name, description, repositoryURL string,
 		UpdatedAt:     now,
 	}
 
-	if err := s.projectRepo.Create(project); err != nil {
+	if err := NewProjectRepository(uow).Create(project); err != nil {
+		return nil, err
+	}
+
+	adminRole, err := builtInRoleFor(NewProjectRoleRepository(uow), project.ID, true, false)
+	if err != nil {
 		return nil, err
 	}
 
@@ -85,14 +121,29 @@ func (s *ProjectService) CreateProject(name, description, repositoryURL string,
 	member := &ProjectMember{
 		ProjectID: project.ID,
 		UserID:    createdBy,
-		IsAdmin:   true,
 		AddedAt:   now,
 		AddedBy:   createdBy,
 	}
+	member.ApplyRole(adminRole)
 
-	if err := s.memberRepo.Create(member); err != nil {
-		// Rollback project creation if member addition fails
-		s.projectRepo.Delete(project.ID)
+	if err := NewProjectMemberRepository(uow).Create(member); err != nil {
+		return nil, err
+	}
+
+	if template != nil {
+		if template.Members {
+			if err := copyTemplateAccess(uow, template.TemplateProjectID, project.ID, createdBy); err != nil {
+				return nil, err
+			}
+		}
+		if s.templateCopier != nil {
+			if err := s.templateCopier.CopyProjectTemplate(uow, *template, project.ID, createdBy); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := uow.CommitTransaction(); err != nil {
 		return nil, err
 	}
 
@@ -102,6 +153,62 @@ func (s *ProjectService) CreateProject(name, description, repositoryURL string,
 	return project, nil
 }
 
+// validateTemplate checks that a project can be used as a template by the user
+func (s *ProjectService) validateTemplate(templateProjectID, userID int) error {
+	template, err := s.projectRepo.GetByID(templateProjectID)
+	if err != nil {
+		return err
+	}
+	if template == nil || !template.IsTemplate {
+		return errors.New("template project not found")
+	}
+
+	// Limited members cannot see everything a template copies, such as draft and protected wiki pages
+	access, err := s.memberRepo.GetAccess(templateProjectID, userID)
+	if err != nil {
+		return err
+	}
+	if !access.IsMember || access.IsLimited {
+		return errors.New("only full members of the template project can use it")
+	}
+	return nil
+}
+
+// SetProjectTemplate marks or unmarks a project as a template for new projects
+func (s *ProjectService) SetProjectTemplate(projectID int, isTemplate bool, updatedBy int) (*Project, error) {
+	project, err := s.projectRepo.GetByID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	if project == nil {
+		return nil, errors.New("project not found")
+	}
+	if err := project.EnsureWritable(); err != nil {
+		return nil, err
+	}
+
+	isAdmin, err := s.memberRepo.IsUserAdmin(projectID, updatedBy)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin {
+		return nil, errors.New("only project admins can change project templates")
+	}
+
+	project.IsTemplate = isTemplate
+	project.UpdatedAt = time.Now()
+	if err := s.projectRepo.Update(project); err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+// GetTemplateProjects returns the template projects the user can create projects from
+func (s *ProjectService) GetTemplateProjects(userID int) ([]Project, error) {
+	return s.projectRepo.GetTemplatesByUserID(userID)
+}
+
 // UpdateProject updates a project's basic information
 func (s *ProjectService) UpdateProject(projectID int, name, description, repositoryURL string, updatedBy int) (*Project, error) {
 	project, err := s.projectRepo.GetByID(projectID)
@@ -111,6 +218,9 @@ func (s *ProjectService) UpdateProject(projectID int, name, description, reposit
 	if project == nil {
 		return nil, errors.New("project not found")
 	}
+	if err := project.EnsureWritable(); err != nil {
+		return nil, err
+	}
 
 	// Check if user is project admin
 	isAdmin, err := s.memberRepo.IsUserAdmin(projectID, updatedBy)
@@ -138,6 +248,11 @@ func (s *ProjectService) GetProject(projectID int) (*Project, error) {
 	return s.projectRepo.GetByID(projectID)
 }
 
+// EnsureWritable returns ErrProjectArchived when the project is archived
+func (s *ProjectService) EnsureWritable(projectID int) error {
+	return s.projectRepo.EnsureWritable(projectID)
+}
+
 // GetProjectWithMembers returns a project with all its members
 func (s *ProjectService) GetProjectWithMembers(projectID int) (*ProjectWithMembers, error) {
 	project, err := s.projectRepo.GetByID(projectID)
@@ -177,7 +292,7 @@ func (s *ProjectService) GetAllProjects(includeArchived bool, page, pageSize int
 	return s.projectRepo.GetAll(includeArchived, offset, pageSize)
 }
 
-// GetUserProjects returns all projects where user is a member
+// GetUserProjects returns all projects where user is a member directly or through a group
 func (s *ProjectService) GetUserProjects(userID int, includeArchived bool, page, pageSize int) ([]Project, int64, error) {
 	offset := (page - 1) * pageSize
 	return s.projectRepo.GetByUserID(userID, includeArchived, offset, pageSize)
@@ -211,6 +326,9 @@ func (s *ProjectService) AddMember(projectID, userID int, isAdmin bool, isUser b
 	if project == nil {
 		return errors.New("project not found")
 	}
+	if err := project.EnsureWritable(); err != nil {
+		return err
+	}
 
 	// Check if user exists
 	user, err := s.userRepo.GetByID(userID)
@@ -239,14 +357,18 @@ func (s *ProjectService) AddMember(projectID, userID int, isAdmin bool, isUser b
 		return errors.New("user is already a member")
 	}
 
+	role, err := builtInRoleFor(s.roleRepo, projectID, isAdmin, isUser)
+	if err != nil {
+		return err
+	}
+
 	member := &ProjectMember{
 		ProjectID: projectID,
 		UserID:    userID,
-		IsAdmin:   isAdmin,
-		IsUser:    isUser,
 		AddedAt:   time.Now(),
 		AddedBy:   addedBy,
 	}
+	member.ApplyRole(role)
 
 	if err := s.memberRepo.Create(member); err != nil {
 		return err
@@ -259,6 +381,10 @@ func (s *ProjectService) AddMember(projectID, userID int, isAdmin bool, isUser b
 
 // RemoveMember removes a user from a project
 func (s *ProjectService) RemoveMember(projectID, userID int, removedBy int) error {
+	if err := s.projectRepo.EnsureWritable(projectID); err != nil {
+		return err
+	}
+
 	// Check if removing user is project admin
 	isAdmin, err := s.memberRepo.IsUserAdmin(projectID, removedBy)
 	if err != nil {
@@ -309,6 +435,10 @@ func (s *ProjectService) UpdateMemberRole(projectID, userID int, isAdmin bool, i
 		return errors.New("member cannot be both project admin and project user")
 	}
 
+	if err := s.projectRepo.EnsureWritable(projectID); err != nil {
+		return err
+	}
+
 	// Check if updating user is project admin
 	isUpdaterAdmin, err := s.memberRepo.IsUserAdmin(projectID, updatedBy)
 	if err != nil {
@@ -345,8 +475,12 @@ func (s *ProjectService) UpdateMemberRole(projectID, userID int, isAdmin bool, i
 		}
 	}
 
-	member.IsAdmin = isAdmin
-	member.IsUser = isUser
+	role, err := builtInRoleFor(s.roleRepo, projectID, isAdmin, isUser)
+	if err != nil {
+		return err
+	}
+
+	member.ApplyRole(role)
 	if err := s.memberRepo.Update(member); err != nil {
 		return err
 	}
@@ -367,7 +501,13 @@ func (s *ProjectService) ArchiveProject(projectID int, archivedBy int) error {
 		return errors.New("only project admins can archive project")
 	}
 
-	return s.projectRepo.Archive(projectID)
+	if err := s.projectRepo.Archive(projectID); err != nil {
+		return err
+	}
+
+	// Other replicas cache project access too
+	s.memberCache.InvalidateProject(projectID)
+	return nil
 }
 
 // UnarchiveProject unarchives a project
@@ -381,7 +521,73 @@ func (s *ProjectService) UnarchiveProject(projectID int, unarchivedBy int) error
 		return errors.New("only project admins can unarchive project")
 	}
 
-	return s.projectRepo.Unarchive(projectID)
+	if err := s.projectRepo.Unarchive(projectID); err != nil {
+		return err
+	}
+
+	// Other replicas cache project access too
+	s.memberCache.InvalidateProject(projectID)
+	return nil
+}
+
+// DeleteProject moves a project to the trash. Its members lose access until it is restored.
+func (s *ProjectService) DeleteProject(projectID int, deletedBy int) error {
+	project, err := s.projectRepo.GetByID(projectID)
+	if err != nil {
+		return err
+	}
+	if project == nil {
+		return errors.New("project not found")
+	}
+	if err := project.EnsureWritable(); err != nil {
+		return err
+	}
+
+	isAdmin, err := s.memberRepo.IsUserAdmin(projectID, deletedBy)
+	if err != nil {
+		return err
+	}
+	if !isAdmin {
+		return errors.New("only project admins can delete project")
+	}
+
+	if err := s.projectRepo.Delete(projectID, deletedBy); err != nil {
+		return err
+	}
+
+	s.memberCache.InvalidateProject(projectID)
+	return nil
+}
+
+// GetDeletedProjects returns the projects in the trash that the user can restore
+func (s *ProjectService) GetDeletedProjects(userID int) ([]Project, error) {
+	return s.projectRepo.GetDeletedByAdminUserID(userID)
+}
+
+// RestoreProject takes a project out of the trash
+func (s *ProjectService) RestoreProject(projectID int, restoredBy int) (*Project, error) {
+	project, err := s.projectRepo.GetDeletedByID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	if project == nil {
+		return nil, errors.New("project not found in trash")
+	}
+
+	access, err := s.memberRepo.GetAccessIncludingDeleted(projectID, restoredBy)
+	if err != nil {
+		return nil, err
+	}
+	if !access.IsAdmin {
+		return nil, errors.New("only project admins can restore project")
+	}
+
+	if err := s.projectRepo.Restore(projectID); err != nil {
+		return nil, err
+	}
+
+	s.memberCache.InvalidateProject(projectID)
+	return s.projectRepo.GetByID(projectID)
 }
 
 // IsUserProjectAdmin checks if a user is a project admin
@@ -389,7 +595,12 @@ func (s *ProjectService) IsUserProjectAdmin(projectID, userID int) (bool, error)
 	return s.memberRepo.IsUserAdmin(projectID, userID)
 }
 
-func (s *ProjectService) CreateInvitation(projectID int, isUser bool, expiresAt *time.Time, createdBy int) (*ProjectInvitation, string, error) {
+// IsUserProjectMember checks if a user is a member of the project
+func (s *ProjectService) IsUserProjectMember(projectID, userID int) (bool, error) {
+	return s.memberRepo.IsUserMember(projectID, userID)
+}
+
+func (s *ProjectService) CreateInvitation(projectID int, options ProjectInvitationOptions, createdBy int) (*ProjectInvitation, string, error) {
 	project, err := s.projectRepo.GetByID(projectID)
 	if err != nil {
 		return nil, "", err
@@ -397,8 +608,8 @@ func (s *ProjectService) CreateInvitation(projectID int, isUser bool, expiresAt
 	if project == nil {
 		return nil, "", errors.New("project not found")
 	}
-	if project.IsArchived {
-		return nil, "", errors.New("cannot generate invitations for archived projects")
+	if err := project.EnsureWritable(); err != nil {
+		return nil, "", err
 	}
 
 	isAdmin, err := s.memberRepo.IsUserAdmin(projectID, createdBy)
@@ -409,9 +620,17 @@ func (s *ProjectService) CreateInvitation(projectID int, isUser bool, expiresAt
 		return nil, "", errors.New("only project admins can generate invitation links")
 	}
 
-	if expiresAt != nil && expiresAt.Before(time.Now()) {
+	if options.ExpiresAt != nil && options.ExpiresAt.Before(time.Now()) {
 		return nil, "", errors.New("invitation expiry must be in the future")
 	}
+	if options.MaxUses != nil && *options.MaxUses < 1 {
+		return nil, "", errors.New("invitation maximum uses must be at least 1")
+	}
+
+	allowedDomains, err := normalizeInvitationDomains(options.AllowedDomains)
+	if err != nil {
+		return nil, "", err
+	}
 
 	token, err := generateInvitationToken()
 	if err != nil {
@@ -419,13 +638,16 @@ func (s *ProjectService) CreateInvitation(projectID int, isUser bool, expiresAt
 	}
 
 	invitation := &ProjectInvitation{
-		ProjectID: projectID,
-		Token:     token,
-		TokenHash: hashInvitationToken(token),
-		IsUser:    isUser,
-		ExpiresAt: expiresAt,
-		CreatedBy: createdBy,
-		CreatedAt: time.Now(),
+		ProjectID:        projectID,
+		Token:            token,
+		TokenHash:        hashInvitationToken(token),
+		IsUser:           options.IsUser,
+		ExpiresAt:        options.ExpiresAt,
+		MaxUses:          options.MaxUses,
+		AllowedDomains:   allowedDomains,
+		RequiresApproval: options.RequiresApproval,
+		CreatedBy:        createdBy,
+		CreatedAt:        time.Now(),
 	}
 
 	if err := s.invitationRepo.Create(invitation); err != nil {
@@ -439,6 +661,20 @@ func (s *ProjectService) ResolveInvitation(token string) (*ProjectInvitationDeta
 	return s.getActiveInvitationDetails(token)
 }
 
+// CheckInvitationDomain checks the login ID and email of a user about to register with the link.
+// They are typed in by the user, so AcceptInvitation checks again once the email is verified.
+func (s *ProjectService) CheckInvitationDomain(token string, loginID, email string) error {
+	details, err := s.getActiveInvitationDetails(token)
+	if err != nil {
+		return err
+	}
+	if !details.Invitation.AllowsAddress(email) && !details.Invitation.AllowsAddress(loginID) {
+		return errors.New("your email domain is not allowed to use this invitation link")
+	}
+	return nil
+}
+
+// ListInvitations returns the invitation links of a project with the users who accepted each of them
 func (s *ProjectService) ListInvitations(projectID int, requestedBy int) ([]ProjectInvitationListItem, error) {
 	project, err := s.projectRepo.GetByID(projectID)
 	if err != nil {
@@ -461,12 +697,27 @@ func (s *ProjectService) ListInvitations(projectID int, requestedBy int) ([]Proj
 		return nil, err
 	}
 
+	acceptances, err := s.invitationRepo.ListAcceptancesByProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	acceptancesWithUsers, err := s.withAcceptanceUsers(acceptances)
+	if err != nil {
+		return nil, err
+	}
+	byInvitation := make(map[int][]InvitationAcceptanceWithUser)
+	for _, acceptance := range acceptancesWithUsers {
+		invitationID := acceptance.Acceptance.InvitationID
+		byInvitation[invitationID] = append(byInvitation[invitationID], acceptance)
+	}
+
 	items := make([]ProjectInvitationListItem, 0, len(invitations))
 	for index := range invitations {
 		invitation := invitations[index]
 		items = append(items, ProjectInvitationListItem{
-			Invitation: &invitation,
-			Project:    project,
+			Invitation:  &invitation,
+			Project:     project,
+			Acceptances: byInvitation[invitation.ID],
 		})
 	}
 
@@ -481,6 +732,9 @@ func (s *ProjectService) RevokeInvitation(projectID int, invitationID int, revok
 	if project == nil {
 		return errors.New("project not found")
 	}
+	if err := project.EnsureWritable(); err != nil {
+		return err
+	}
 
 	isAdmin, err := s.memberRepo.IsUserAdmin(projectID, revokedBy)
 	if err != nil {
@@ -506,11 +760,14 @@ func (s *ProjectService) RevokeInvitation(projectID int, invitationID int, revok
 	return s.invitationRepo.Update(invitation)
 }
 
-func (s *ProjectService) AcceptInvitation(token string, userID int) (*ProjectMember, error) {
+// AcceptInvitation uses an invitation link. The user joins the project directly,
+// or a pending membership request is created when the link requires admin approval.
+func (s *ProjectService) AcceptInvitation(token string, userID int) (*ProjectInvitationAcceptance, error) {
 	details, err := s.getActiveInvitationDetails(token)
 	if err != nil {
 		return nil, err
 	}
+	invitation := details.Invitation
 
 	user, err := s.userRepo.GetByID(userID)
 	if err != nil {
@@ -519,39 +776,211 @@ func (s *ProjectService) AcceptInvitation(token string, userID int) (*ProjectMem
 	if user == nil {
 		return nil, errors.New("user not found")
 	}
+	if !invitation.AllowsUser(user) {
+		return nil, ErrInvitationDomainNotAllowed
+	}
 
 	member, err := s.memberRepo.GetByProjectAndUser(details.Project.ID, userID)
 	if err != nil {
 		return nil, err
 	}
+	if member != nil && (invitation.IsUser || !member.IsUser) {
+		// The user already has the access granted by the link, so no use is recorded
+		return &ProjectInvitationAcceptance{
+			InvitationID: invitation.ID,
+			ProjectID:    details.Project.ID,
+			UserID:       userID,
+			Status:       InvitationAcceptanceJoined,
+			AcceptedAt:   member.AddedAt,
+		}, nil
+	}
 
-	if member == nil {
-		member = &ProjectMember{
-			ProjectID: details.Project.ID,
-			UserID:    userID,
-			IsAdmin:   false,
-			IsUser:    details.Invitation.IsUser,
-			AddedAt:   time.Now(),
-			AddedBy:   details.Invitation.CreatedBy,
-		}
+	pending, err := s.invitationRepo.GetPendingAcceptance(details.Project.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if pending != nil {
+		return pending, nil
+	}
 
-		if err := s.memberRepo.Create(member); err != nil {
-			return nil, err
-		}
+	uow := s.uowFactory.NewUnitOfWork()
+	if err := uow.BeginTransaction(); err != nil {
+		return nil, err
+	}
+	defer uow.RollbackTransactionIfError()
+
+	invitationRepo := NewProjectInvitationRepository(uow)
+	used, err := invitationRepo.IncrementUseCount(invitation.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, errors.New("invitation link has reached its maximum number of uses")
+	}
+
+	acceptance := &ProjectInvitationAcceptance{
+		InvitationID: invitation.ID,
+		ProjectID:    details.Project.ID,
+		UserID:       userID,
+		Status:       InvitationAcceptanceJoined,
+		AcceptedAt:   time.Now(),
+	}
+	if invitation.RequiresApproval {
+		acceptance.Status = InvitationAcceptancePending
+	} else if err := grantInvitationAccess(uow, invitation, userID, invitation.CreatedBy); err != nil {
+		return nil, err
+	}
+
+	if err := invitationRepo.CreateAcceptance(acceptance); err != nil {
+		return nil, err
+	}
 
+	if err := uow.CommitTransaction(); err != nil {
+		return nil, err
+	}
+
+	if !acceptance.IsPending() {
 		s.memberCache.InvalidateProject(details.Project.ID)
-		return member, nil
 	}
 
-	if !details.Invitation.IsUser && member.IsUser {
-		member.IsUser = false
-		if err := s.memberRepo.Update(member); err != nil {
+	return acceptance, nil
+}
+
+// ListMembershipRequests returns the membership requests of a project waiting for admin approval
+func (s *ProjectService) ListMembershipRequests(projectID int, requestedBy int) ([]InvitationAcceptanceWithUser, error) {
+	isAdmin, err := s.memberRepo.IsUserAdmin(projectID, requestedBy)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin {
+		return nil, errors.New("only project admins can view membership requests")
+	}
+
+	acceptances, err := s.invitationRepo.ListPendingAcceptances(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.withAcceptanceUsers(acceptances)
+}
+
+// ReviewMembershipRequest approves or rejects a pending membership request.
+// Approved users join with the role of the invitation they used.
+func (s *ProjectService) ReviewMembershipRequest(projectID, requestID int, approve bool, reviewedBy int) (*ProjectInvitationAcceptance, error) {
+	project, err := s.projectRepo.GetByID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	if project == nil {
+		return nil, errors.New("project not found")
+	}
+
+	isAdmin, err := s.memberRepo.IsUserAdmin(projectID, reviewedBy)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin {
+		return nil, errors.New("only project admins can review membership requests")
+	}
+
+	acceptance, err := s.invitationRepo.GetAcceptanceByID(requestID)
+	if err != nil {
+		return nil, err
+	}
+	if acceptance == nil || acceptance.ProjectID != projectID {
+		return nil, errors.New("membership request not found")
+	}
+	if !acceptance.IsPending() {
+		return nil, errors.New("membership request has already been reviewed")
+	}
+	if approve && project.IsArchived {
+		return nil, ErrProjectArchived
+	}
+
+	invitation, err := s.invitationRepo.GetByID(acceptance.InvitationID)
+	if err != nil {
+		return nil, err
+	}
+	if invitation == nil {
+		return nil, errors.New("invitation not found")
+	}
+
+	uow := s.uowFactory.NewUnitOfWork()
+	if err := uow.BeginTransaction(); err != nil {
+		return nil, err
+	}
+	defer uow.RollbackTransactionIfError()
+
+	now := time.Now()
+	acceptance.Status = InvitationAcceptanceRejected
+	acceptance.ReviewedBy = &reviewedBy
+	acceptance.ReviewedAt = &now
+	if approve {
+		acceptance.Status = InvitationAcceptanceApproved
+		if err := grantInvitationAccess(uow, invitation, acceptance.UserID, reviewedBy); err != nil {
 			return nil, err
 		}
-		s.memberCache.InvalidateProject(details.Project.ID)
 	}
 
-	return member, nil
+	if err := NewProjectInvitationRepository(uow).UpdateAcceptance(acceptance); err != nil {
+		return nil, err
+	}
+
+	if err := uow.CommitTransaction(); err != nil {
+		return nil, err
+	}
+
+	if approve {
+		s.memberCache.InvalidateProject(projectID)
+	}
+
+	return acceptance, nil
+}
+
+// grantInvitationAccess adds the user with the role of the invitation, or widens a project user to a member
+func grantInvitationAccess(uow *repositories.UnitOfWork, invitation *ProjectInvitation, userID int, addedBy int) error {
+	memberRepo := NewProjectMemberRepository(uow)
+	member, err := memberRepo.GetByProjectAndUser(invitation.ProjectID, userID)
+	if err != nil {
+		return err
+	}
+	if member != nil && (invitation.IsUser || !member.IsUser) {
+		return nil
+	}
+
+	role, err := builtInRoleFor(NewProjectRoleRepository(uow), invitation.ProjectID, false, invitation.IsUser)
+	if err != nil {
+		return err
+	}
+
+	if member != nil {
+		member.ApplyRole(role)
+		return memberRepo.Update(member)
+	}
+
+	member = &ProjectMember{
+		ProjectID: invitation.ProjectID,
+		UserID:    userID,
+		AddedAt:   time.Now(),
+		AddedBy:   addedBy,
+	}
+	member.ApplyRole(role)
+	return memberRepo.Create(member)
+}
+
+func (s *ProjectService) withAcceptanceUsers(acceptances []ProjectInvitationAcceptance) ([]InvitationAcceptanceWithUser, error) {
+	result := make([]InvitationAcceptanceWithUser, 0, len(acceptances))
+	for _, acceptance := range acceptances {
+		user, err := s.userRepo.GetByID(acceptance.UserID)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, InvitationAcceptanceWithUser{
+			Acceptance: acceptance,
+			User:       user,
+		})
+	}
+	return result, nil
 }
 
 func (s *ProjectService) getActiveInvitationDetails(token string) (*ProjectInvitationDetails, error) {
@@ -566,6 +995,9 @@ func (s *ProjectService) getActiveInvitationDetails(token string) (*ProjectInvit
 	if invitation == nil {
 		return nil, errors.New("invitation link not found")
 	}
+	if invitation.IsUsedUp() {
+		return nil, errors.New("invitation link has reached its maximum number of uses")
+	}
 	if !invitation.IsActive(time.Now()) {
 		return nil, errors.New("invitation link has expired")
 	}
@@ -577,8 +1009,8 @@ func (s *ProjectService) getActiveInvitationDetails(token string) (*ProjectInvit
 	if project == nil {
 		return nil, errors.New("project not found")
 	}
-	if project.IsArchived {
-		return nil, errors.New("project is archived")
+	if err := project.EnsureWritable(); err != nil {
+		return nil, err
 	}
 
 	return &ProjectInvitationDetails{
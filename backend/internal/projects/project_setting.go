@@ -0,0 +1,199 @@
+package projects
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProjectSetting stores one setting of a project managed by project admins
+type ProjectSetting struct {
+	ProjectID int       `gorm:"primaryKey" json:"projectId"`
+	Key       string    `gorm:"primaryKey;size:100" json:"key"`
+	Value     string    `gorm:"type:text" json:"value"`
+	UpdatedBy int       `json:"updatedBy"`
+	UpdatedAt time.Time `gorm:"not null" json:"updatedAt"`
+}
+
+// TableName specifies the table name for GORM
+func (ProjectSetting) TableName() string {
+	return "project_settings"
+}
+
+// ProjectSetting keys
+const (
+	ProjectSettingTimezone                 = "timezone"
+	ProjectSettingWorkingDays              = "working_days"
+	ProjectSettingDefaultPriority          = "default_priority"
+	ProjectSettingDefaultCascadeCompletion = "default_cascade_completion"
+	ProjectSettingDefaultSprintLengthDays  = "default_sprint_length_days"
+	ProjectSettingAllowOpenDependencies    = "allow_open_dependencies"
+)
+
+// MaxSprintLengthDays limits the default sprint length
+const MaxSprintLengthDays = 90
+
+// itemPriorities are the priorities shared by issues, features, tasks and service tickets
+var itemPriorities = []string{"Immediate", "Urgent", "High", "Normal", "Low"}
+
+// ProjectSettings holds the typed settings of a project
+type ProjectSettings struct {
+	Timezone                 string         `json:"timezone"`                 // IANA time zone used for the project's calendar days
+	WorkingDays              []time.Weekday `json:"workingDays"`              // 0 is Sunday
+	DefaultPriority          string         `json:"defaultPriority"`          // Priority of new items created without one
+	DefaultCascadeCompletion bool           `json:"defaultCascadeCompletion"` // CascadeCompletion of new service tickets created without one
+	DefaultSprintLengthDays  int            `json:"defaultSprintLengthDays"`  // Used for sprints created or started without an end date
+	AllowOpenDependencies    bool           `json:"allowOpenDependencies"`    // Features can be started while a feature they depend on is still open
+}
+
+// DefaultProjectSettings returns the settings of projects that have not changed them
+func DefaultProjectSettings() *ProjectSettings {
+	return &ProjectSettings{
+		Timezone:                "UTC",
+		WorkingDays:             []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		DefaultPriority:         "Normal",
+		DefaultSprintLengthDays: 14,
+	}
+}
+
+// Validate checks the settings and normalizes the working days
+func (s *ProjectSettings) Validate() error {
+	s.Timezone = strings.TrimSpace(s.Timezone)
+	if s.Timezone == "" {
+		return errors.New("timezone is required")
+	}
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return errors.New("invalid timezone")
+	}
+
+	if len(s.WorkingDays) == 0 {
+		return errors.New("at least one working day is required")
+	}
+	seen := make(map[time.Weekday]bool, len(s.WorkingDays))
+	days := make([]time.Weekday, 0, len(s.WorkingDays))
+	for _, day := range s.WorkingDays {
+		if day < time.Sunday || day > time.Saturday {
+			return errors.New("invalid working day")
+		}
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, day)
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i] < days[j] })
+	s.WorkingDays = days
+
+	if !isItemPriority(s.DefaultPriority) {
+		return errors.New("invalid default priority")
+	}
+
+	if s.DefaultSprintLengthDays < 1 || s.DefaultSprintLengthDays > MaxSprintLengthDays {
+		return errors.New("default sprint length must be between 1 and " + strconv.Itoa(MaxSprintLengthDays) + " days")
+	}
+	return nil
+}
+
+func isItemPriority(priority string) bool {
+	for _, allowed := range itemPriorities {
+		if priority == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// Location returns the project's time zone, falling back to UTC
+func (s *ProjectSettings) Location() *time.Location {
+	location, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// Today returns the start of the current day in the project's time zone
+func (s *ProjectSettings) Today(now time.Time) time.Time {
+	local := now.In(s.Location())
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+}
+
+// IsWorkingDay checks if the calendar day is one of the project's working days
+func (s *ProjectSettings) IsWorkingDay(date time.Time) bool {
+	for _, day := range s.WorkingDays {
+		if date.Weekday() == day {
+			return true
+		}
+	}
+	return false
+}
+
+// SprintEndDate returns the end of a default length sprint starting on the given day.
+// An end date falling on a non-working day is moved back to the last working day.
+func (s *ProjectSettings) SprintEndDate(start time.Time) time.Time {
+	end := start.AddDate(0, 0, s.DefaultSprintLengthDays-1)
+	for end.After(start) && !s.IsWorkingDay(end) {
+		end = end.AddDate(0, 0, -1)
+	}
+	return end
+}
+
+// values encodes the settings as stored setting values by key
+func (s *ProjectSettings) values() map[string]string {
+	days := make([]string, 0, len(s.WorkingDays))
+	for _, day := range s.WorkingDays {
+		days = append(days, strconv.Itoa(int(day)))
+	}
+	return map[string]string{
+		ProjectSettingTimezone:                 s.Timezone,
+		ProjectSettingWorkingDays:              strings.Join(days, ","),
+		ProjectSettingDefaultPriority:          s.DefaultPriority,
+		ProjectSettingDefaultCascadeCompletion: strconv.FormatBool(s.DefaultCascadeCompletion),
+		ProjectSettingDefaultSprintLengthDays:  strconv.Itoa(s.DefaultSprintLengthDays),
+		ProjectSettingAllowOpenDependencies:    strconv.FormatBool(s.AllowOpenDependencies),
+	}
+}
+
+// projectSettingsFrom builds the typed settings from stored values. Missing or unreadable values keep their defaults.
+func projectSettingsFrom(stored []ProjectSetting) *ProjectSettings {
+	settings := DefaultProjectSettings()
+	for _, setting := range stored {
+		switch setting.Key {
+		case ProjectSettingTimezone:
+			if _, err := time.LoadLocation(setting.Value); err == nil && setting.Value != "" {
+				settings.Timezone = setting.Value
+			}
+		case ProjectSettingWorkingDays:
+			var days []time.Weekday
+			for _, part := range strings.Split(setting.Value, ",") {
+				day, err := strconv.Atoi(strings.TrimSpace(part))
+				if err != nil || day < int(time.Sunday) || day > int(time.Saturday) {
+					days = nil
+					break
+				}
+				days = append(days, time.Weekday(day))
+			}
+			if len(days) > 0 {
+				settings.WorkingDays = days
+			}
+		case ProjectSettingDefaultPriority:
+			if isItemPriority(setting.Value) {
+				settings.DefaultPriority = setting.Value
+			}
+		case ProjectSettingDefaultCascadeCompletion:
+			if value, err := strconv.ParseBool(setting.Value); err == nil {
+				settings.DefaultCascadeCompletion = value
+			}
+		case ProjectSettingDefaultSprintLengthDays:
+			if value, err := strconv.Atoi(setting.Value); err == nil && value >= 1 && value <= MaxSprintLengthDays {
+				settings.DefaultSprintLengthDays = value
+			}
+		case ProjectSettingAllowOpenDependencies:
+			if value, err := strconv.ParseBool(setting.Value); err == nil {
+				settings.AllowOpenDependencies = value
+			}
+		}
+	}
+	return settings
+}
@@ -0,0 +1,25 @@
+package projects
+
+import (
+	"github.com/dannyswat/pjeasy/internal/repositories"
+)
+
+type ProjectSettingRepository struct {
+	uow *repositories.UnitOfWork
+}
+
+func NewProjectSettingRepository(uow *repositories.UnitOfWork) *ProjectSettingRepository {
+	return &ProjectSettingRepository{uow: uow}
+}
+
+// GetByProjectID returns the stored settings of a project
+func (r *ProjectSettingRepository) GetByProjectID(projectID int) ([]ProjectSetting, error) {
+	var settings []ProjectSetting
+	err := r.uow.GetDB().Where("project_id = ?", projectID).Find(&settings).Error
+	return settings, err
+}
+
+// Save creates or updates a project setting
+func (r *ProjectSettingRepository) Save(setting *ProjectSetting) error {
+	return r.uow.GetDB().Save(setting).Error
+}
@@ -0,0 +1,91 @@
+package projects
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/repositories"
+)
+
+type ProjectSettingService struct {
+	uowFactory  *repositories.UnitOfWorkFactory
+	settingRepo *ProjectSettingRepository
+	projectRepo *ProjectRepository
+	memberRepo  *ProjectMemberRepository
+}
+
+func NewProjectSettingService(uowFactory *repositories.UnitOfWorkFactory, settingRepo *ProjectSettingRepository, projectRepo *ProjectRepository, memberRepo *ProjectMemberRepository) *ProjectSettingService {
+	return &ProjectSettingService{
+		uowFactory:  uowFactory,
+		settingRepo: settingRepo,
+		projectRepo: projectRepo,
+		memberRepo:  memberRepo,
+	}
+}
+
+// GetProjectSettings returns the settings services apply when creating items, sprints and daily boards
+func (s *ProjectSettingService) GetProjectSettings(projectID int) (*ProjectSettings, error) {
+	stored, err := s.settingRepo.GetByProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	return projectSettingsFrom(stored), nil
+}
+
+// GetSettings returns the settings of a project to one of its members
+func (s *ProjectSettingService) GetSettings(projectID int, requestedBy int) (*ProjectSettings, error) {
+	isMember, err := s.memberRepo.IsUserMember(projectID, requestedBy)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, errors.New("user is not a member of this project")
+	}
+
+	return s.GetProjectSettings(projectID)
+}
+
+// UpdateSettings validates and saves every setting of a project
+func (s *ProjectSettingService) UpdateSettings(projectID int, settings *ProjectSettings, updatedBy int) (*ProjectSettings, error) {
+	if err := s.projectRepo.EnsureWritable(projectID); err != nil {
+		return nil, err
+	}
+
+	isAdmin, err := s.memberRepo.IsUserAdmin(projectID, updatedBy)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin {
+		return nil, errors.New("only project admins can update project settings")
+	}
+
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+
+	uow := s.uowFactory.NewUnitOfWork()
+	if err := uow.BeginTransaction(); err != nil {
+		return nil, err
+	}
+	defer uow.RollbackTransactionIfError()
+
+	settingRepo := NewProjectSettingRepository(uow)
+	now := time.Now()
+	for key, value := range settings.values() {
+		if err := settingRepo.Save(&ProjectSetting{
+			ProjectID: projectID,
+			Key:       key,
+			Value:     value,
+			UpdatedBy: updatedBy,
+			UpdatedAt: now,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := uow.CommitTransaction(); err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
@@ -0,0 +1,120 @@
+package projects
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestProjectSettingsValidate(t *testing.T) {
+	valid := func() *ProjectSettings { return DefaultProjectSettings() }
+
+	tests := []struct {
+		name    string
+		modify  func(s *ProjectSettings)
+		wantErr bool
+	}{
+		{name: "defaults", modify: func(s *ProjectSettings) {}},
+		{name: "missing timezone", modify: func(s *ProjectSettings) { s.Timezone = " " }, wantErr: true},
+		{name: "unknown timezone", modify: func(s *ProjectSettings) { s.Timezone = "Mars/Olympus" }, wantErr: true},
+		{name: "no working days", modify: func(s *ProjectSettings) { s.WorkingDays = nil }, wantErr: true},
+		{name: "invalid working day", modify: func(s *ProjectSettings) { s.WorkingDays = []time.Weekday{7} }, wantErr: true},
+		{name: "invalid priority", modify: func(s *ProjectSettings) { s.DefaultPriority = "Whenever" }, wantErr: true},
+		{name: "zero sprint length", modify: func(s *ProjectSettings) { s.DefaultSprintLengthDays = 0 }, wantErr: true},
+		{name: "sprint length too long", modify: func(s *ProjectSettings) { s.DefaultSprintLengthDays = MaxSprintLengthDays + 1 }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := valid()
+			tt.modify(settings)
+			if err := settings.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProjectSettingsValidateNormalizesWorkingDays(t *testing.T) {
+	settings := DefaultProjectSettings()
+	settings.WorkingDays = []time.Weekday{time.Saturday, time.Monday, time.Saturday, time.Sunday}
+	if err := settings.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	want := []time.Weekday{time.Sunday, time.Monday, time.Saturday}
+	if !reflect.DeepEqual(settings.WorkingDays, want) {
+		t.Fatalf("WorkingDays = %v, want %v", settings.WorkingDays, want)
+	}
+}
+
+func TestProjectSettingsFrom(t *testing.T) {
+	saved := &ProjectSettings{
+		Timezone:                 "Asia/Hong_Kong",
+		WorkingDays:              []time.Weekday{time.Sunday, time.Monday, time.Tuesday},
+		DefaultPriority:          "High",
+		DefaultCascadeCompletion: true,
+		DefaultSprintLengthDays:  10,
+		AllowOpenDependencies:    true,
+	}
+	var stored []ProjectSetting
+	for key, value := range saved.values() {
+		stored = append(stored, ProjectSetting{Key: key, Value: value})
+	}
+	if got := projectSettingsFrom(stored); !reflect.DeepEqual(got, saved) {
+		t.Fatalf("projectSettingsFrom(values()) = %+v, want %+v", got, saved)
+	}
+
+	unreadable := []ProjectSetting{
+		{Key: ProjectSettingTimezone, Value: "Nowhere/Town"},
+		{Key: ProjectSettingWorkingDays, Value: "1,x,3"},
+		{Key: ProjectSettingDefaultPriority, Value: "Someday"},
+		{Key: ProjectSettingDefaultCascadeCompletion, Value: "maybe"},
+		{Key: ProjectSettingDefaultSprintLengthDays, Value: "365"},
+		{Key: ProjectSettingAllowOpenDependencies, Value: "sometimes"},
+	}
+	if got, want := projectSettingsFrom(unreadable), DefaultProjectSettings(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("projectSettingsFrom(unreadable) = %+v, want defaults %+v", got, want)
+	}
+}
+
+func TestProjectSettingsSprintEndDate(t *testing.T) {
+	monday := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		length int
+		start  time.Time
+		want   time.Time
+	}{
+		{name: "ends on a working day", length: 12, start: monday, want: time.Date(2024, 6, 14, 0, 0, 0, 0, time.UTC)},
+		{name: "weekend end moves back to friday", length: 14, start: monday, want: time.Date(2024, 6, 14, 0, 0, 0, 0, time.UTC)},
+		{name: "one day sprint", length: 1, start: monday, want: monday},
+		{name: "never before the start", length: 2, start: time.Date(2024, 6, 8, 0, 0, 0, 0, time.UTC), want: time.Date(2024, 6, 8, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := DefaultProjectSettings()
+			settings.DefaultSprintLengthDays = tt.length
+			if got := settings.SprintEndDate(tt.start); !got.Equal(tt.want) {
+				t.Fatalf("SprintEndDate(%v) = %v, want %v", tt.start, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProjectSettingsToday(t *testing.T) {
+	settings := DefaultProjectSettings()
+	settings.Timezone = "Asia/Tokyo"
+
+	// 20:00 UTC on a Friday is already Saturday in Tokyo
+	now := time.Date(2024, 6, 7, 20, 0, 0, 0, time.UTC)
+	today := settings.Today(now)
+	if today.Day() != 8 || today.Hour() != 0 {
+		t.Fatalf("Today() = %v, want the start of June 8 in Tokyo", today)
+	}
+	if settings.IsWorkingDay(today) {
+		t.Fatalf("IsWorkingDay(%v) = true, want false for a Saturday", today)
+	}
+}
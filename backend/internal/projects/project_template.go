@@ -0,0 +1,114 @@
+package projects
+
+import (
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/repositories"
+)
+
+// ProjectTemplateOptions selects the parts of a template project copied into a new project
+type ProjectTemplateOptions struct {
+	TemplateProjectID int
+	StatusFlows       bool
+	Sequences         bool
+	WikiPages         bool
+	Members           bool // Members, group grants and custom roles
+	OpenItems         bool // Ideas, issues, features, tasks and service tickets that are not closed
+}
+
+// ProjectTemplateCopier copies the parts of a template project owned by other modules.
+// It runs inside the transaction that creates the new project.
+type ProjectTemplateCopier interface {
+	CopyProjectTemplate(uow *repositories.UnitOfWork, options ProjectTemplateOptions, projectID int, createdBy int) error
+}
+
+// copyTemplateAccess copies custom roles, members and group grants of a template project.
+// The creator keeps the admin membership the new project was created with.
+func copyTemplateAccess(uow *repositories.UnitOfWork, templateProjectID, projectID, createdBy int) error {
+	roleRepo := NewProjectRoleRepository(uow)
+	memberRepo := NewProjectMemberRepository(uow)
+	groupRepo := NewProjectGroupRepository(uow)
+
+	builtInRoles, err := ensureBuiltInRoles(roleRepo, projectID)
+	if err != nil {
+		return err
+	}
+
+	templateRoles, err := roleRepo.GetByProjectID(templateProjectID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	roleMap := make(map[int]*ProjectRole, len(templateRoles))
+	for _, templateRole := range templateRoles {
+		if templateRole.IsBuiltIn() {
+			roleMap[templateRole.ID] = builtInRoles[templateRole.BuiltInKey]
+			continue
+		}
+
+		permissions := make([]ProjectRolePermission, 0, len(templateRole.Permissions))
+		for _, permission := range templateRole.Permissions {
+			permissions = append(permissions, ProjectRolePermission{ItemType: permission.ItemType, Action: permission.Action})
+		}
+		role := &ProjectRole{
+			ProjectID:   projectID,
+			Name:        templateRole.Name,
+			Description: templateRole.Description,
+			IsLimited:   templateRole.IsLimited,
+			Permissions: permissions,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		if err := roleRepo.Create(role); err != nil {
+			return err
+		}
+		roleMap[templateRole.ID] = role
+	}
+
+	roleFor := func(roleID int, isAdmin, isUser bool) *ProjectRole {
+		if role, ok := roleMap[roleID]; ok && role != nil {
+			return role
+		}
+		return builtInRoles[BuiltInRoleKeyFor(isAdmin, isUser)]
+	}
+
+	members, err := memberRepo.GetByProjectID(templateProjectID)
+	if err != nil {
+		return err
+	}
+	for _, templateMember := range members {
+		if templateMember.UserID == createdBy {
+			continue
+		}
+		member := &ProjectMember{
+			ProjectID: projectID,
+			UserID:    templateMember.UserID,
+			AddedAt:   now,
+			AddedBy:   createdBy,
+		}
+		member.ApplyRole(roleFor(templateMember.RoleID, templateMember.IsAdmin, templateMember.IsUser))
+		if err := memberRepo.Create(member); err != nil {
+			return err
+		}
+	}
+
+	grants, err := groupRepo.GetByProjectID(templateProjectID)
+	if err != nil {
+		return err
+	}
+	for _, templateGrant := range grants {
+		grant := &ProjectGroup{
+			ProjectID: projectID,
+			GroupID:   templateGrant.GroupID,
+			AddedAt:   now,
+			AddedBy:   createdBy,
+		}
+		grant.ApplyRole(roleFor(templateGrant.RoleID, templateGrant.IsAdmin, templateGrant.IsUser))
+		if err := groupRepo.Create(grant); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -50,12 +50,16 @@ func (s *ReleaseService) CreateRelease(projectID int, version, description strin
 		return nil, errors.New("project not found")
 	}
 
-	canWrite, err := s.memberRepo.CanUserWriteProject(projectID, createdBy)
+	if err := project.EnsureWritable(); err != nil {
+		return nil, err
+	}
+
+	allowed, err := s.memberRepo.HasPermission(projectID, createdBy, projects.PermissionItemRelease, projects.PermissionActionCreate)
 	if err != nil {
 		return nil, err
 	}
-	if !canWrite {
-		return nil, errors.New("project users can only read project items")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemRelease, projects.PermissionActionCreate)
 	}
 
 	if version == "" {
@@ -103,12 +107,16 @@ func (s *ReleaseService) UpdateRelease(releaseID int, version, description strin
 		return nil, errors.New("release not found")
 	}
 
-	canWrite, err := s.memberRepo.CanUserWriteProject(release.ProjectID, updatedBy)
+	if err := s.projectRepo.EnsureWritable(release.ProjectID); err != nil {
+		return nil, err
+	}
+
+	allowed, err := s.memberRepo.HasPermission(release.ProjectID, updatedBy, projects.PermissionItemRelease, projects.PermissionActionEdit)
 	if err != nil {
 		return nil, err
 	}
-	if !canWrite {
-		return nil, errors.New("project users can only read project items")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemRelease, projects.PermissionActionEdit)
 	}
 
 	if version == "" {
@@ -143,12 +151,16 @@ func (s *ReleaseService) UpdateReleaseStatus(releaseID int, status string, confi
 		return nil, errors.New("release not found")
 	}
 
-	canWrite, err := s.memberRepo.CanUserWriteProject(release.ProjectID, updatedBy)
+	if err := s.projectRepo.EnsureWritable(release.ProjectID); err != nil {
+		return nil, err
+	}
+
+	allowed, err := s.memberRepo.HasPermission(release.ProjectID, updatedBy, projects.PermissionItemRelease, projects.PermissionActionChangeStatus)
 	if err != nil {
 		return nil, err
 	}
-	if !canWrite {
-		return nil, errors.New("project users can only read project items")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemRelease, projects.PermissionActionChangeStatus)
 	}
 
 	oldStatus := release.Status
@@ -202,12 +214,16 @@ func (s *ReleaseService) CompleteRelease(releaseID int, confirmedItems []Confirm
 		return nil, errors.New("release not found")
 	}
 
-	canWrite, err := s.memberRepo.CanUserWriteProject(release.ProjectID, updatedBy)
+	if err := s.projectRepo.EnsureWritable(release.ProjectID); err != nil {
+		return nil, err
+	}
+
+	allowed, err := s.memberRepo.HasPermission(release.ProjectID, updatedBy, projects.PermissionItemRelease, projects.PermissionActionChangeStatus)
 	if err != nil {
 		return nil, err
 	}
-	if !canWrite {
-		return nil, errors.New("project users can only read project items")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemRelease, projects.PermissionActionChangeStatus)
 	}
 
 	oldStatus := release.Status
@@ -223,7 +239,7 @@ func (s *ReleaseService) CompleteRelease(releaseID int, confirmedItems []Confirm
 
 		for _, config := range completedReleaseItemConfigs() {
 			ids := groupedItems[config.key]
-			query := tx.Table(config.table).Where("project_id = ? AND release_id = ?", release.ProjectID, releaseID)
+			query := notTrashed(tx.Table(config.table), config.table).Where("project_id = ? AND release_id = ?", release.ProjectID, releaseID)
 			if len(ids) > 0 {
 				query = query.Where("id NOT IN ?", ids)
 			}
@@ -284,7 +300,7 @@ func (s *ReleaseService) markReleaseItemsCompleted(tx *gorm.DB, projectID int, i
 	}
 
 	var rows []releasableItemStatusRow
-	if err := tx.Table(config.table).
+	if err := notTrashed(tx.Table(config.table), config.table).
 		Select("id, status").
 		Where("project_id = ? AND id IN ? AND release_id IS NOT NULL", projectID, ids).
 		Find(&rows).Error; err != nil {
@@ -337,12 +353,16 @@ func (s *ReleaseService) DeleteRelease(releaseID int, deletedBy int) error {
 		return errors.New("release not found")
 	}
 
-	canWrite, err := s.memberRepo.CanUserWriteProject(release.ProjectID, deletedBy)
+	if err := s.projectRepo.EnsureWritable(release.ProjectID); err != nil {
+		return err
+	}
+
+	allowed, err := s.memberRepo.HasPermission(release.ProjectID, deletedBy, projects.PermissionItemRelease, projects.PermissionActionDelete)
 	if err != nil {
 		return err
 	}
-	if !canWrite {
-		return errors.New("project users can only read project items")
+	if !allowed {
+		return projects.PermissionDeniedError(projects.PermissionItemRelease, projects.PermissionActionDelete)
 	}
 
 	// Unlink all items associated with this release
@@ -442,13 +462,13 @@ func (s *ReleaseService) GetReleaseItems(releaseID int, requestedBy int) ([]Rele
 
 	// Query features
 	var featureItems []ReleaseItem
-	db.Table("features").Select("id, ref_num, title, description, status, 'feature' as item_type").
+	notTrashed(db.Table("features"), "features").Select("id, ref_num, title, description, status, 'feature' as item_type").
 		Where("release_id = ?", releaseID).Scan(&featureItems)
 	items = append(items, featureItems...)
 
 	// Query issues
 	var issueItems []ReleaseItem
-	db.Table("issues").Select("id, ref_num, title, description, status, 'issue' as item_type").
+	notTrashed(db.Table("issues"), "issues").Select("id, ref_num, title, description, status, 'issue' as item_type").
 		Where("release_id = ?", releaseID).Scan(&issueItems)
 	items = append(items, issueItems...)
 
@@ -473,16 +493,16 @@ func (s *ReleaseService) GetReleaseItems(releaseID int, requestedBy int) ([]Rele
 			) as description,
 			t.status,
 			'task' as item_type`).
-		Joins("LEFT JOIN ideas linked_ideas ON t.item_type = ? AND t.item_id = linked_ideas.id", "ideas").
-		Joins("LEFT JOIN features linked_features ON t.item_type = ? AND t.item_id = linked_features.id", "features").
-		Joins("LEFT JOIN issues linked_issues ON t.item_type = ? AND t.item_id = linked_issues.id", "issues").
-		Joins("LEFT JOIN service_tickets linked_service_tickets ON t.item_type = ? AND t.item_id = linked_service_tickets.id", "service-tickets").
-		Where("t.release_id = ?", releaseID).Scan(&taskItems)
+		Joins("LEFT JOIN ideas linked_ideas ON t.item_type = ? AND t.item_id = linked_ideas.id AND linked_ideas.deleted_at IS NULL", "ideas").
+		Joins("LEFT JOIN features linked_features ON t.item_type = ? AND t.item_id = linked_features.id AND linked_features.deleted_at IS NULL", "features").
+		Joins("LEFT JOIN issues linked_issues ON t.item_type = ? AND t.item_id = linked_issues.id AND linked_issues.deleted_at IS NULL", "issues").
+		Joins("LEFT JOIN service_tickets linked_service_tickets ON t.item_type = ? AND t.item_id = linked_service_tickets.id AND linked_service_tickets.deleted_at IS NULL", "service-tickets").
+		Where("t.release_id = ? AND t.deleted_at IS NULL", releaseID).Scan(&taskItems)
 	items = append(items, taskItems...)
 
 	// Query ideas
 	var ideaItems []ReleaseItem
-	db.Table("ideas").Select("id, ref_num, title, description, status, 'idea' as item_type").
+	notTrashed(db.Table("ideas"), "ideas").Select("id, ref_num, title, description, status, 'idea' as item_type").
 		Where("release_id = ?", releaseID).Scan(&ideaItems)
 	items = append(items, ideaItems...)
 
@@ -523,7 +543,7 @@ func (s *ReleaseService) GetReleaseCandidateItems(projectID int, releaseID *int,
 			refNumSelect = "ref_num"
 		}
 
-		query := db.Table(table).
+		query := notTrashed(db.Table(table), table).
 			Select("id, "+refNumSelect+", "+titleColumn+" as title, status, ? as item_type, CASE WHEN release_id IS NOT NULL THEN true ELSE false END as linked", itemType).
 			Where("project_id = ?", projectID)
 
@@ -584,7 +604,7 @@ func syncReleaseWorkItems(tx *gorm.DB, releaseID int, projectID int, confirmedIt
 		ids := groupedItems[itemConfig.itemType]
 		if len(ids) > 0 {
 			var count int64
-			if err := tx.Table(itemConfig.table).
+			if err := notTrashed(tx.Table(itemConfig.table), itemConfig.table).
 				Where("project_id = ? AND id IN ?", projectID, ids).
 				Where("release_id IS NULL OR release_id = ?", releaseID).
 				Count(&count).Error; err != nil {
@@ -610,7 +630,7 @@ func syncReleaseWorkItems(tx *gorm.DB, releaseID int, projectID int, confirmedIt
 		}
 
 		if unlinkMissing {
-			query := tx.Table(itemConfig.table).Where("project_id = ? AND release_id = ?", projectID, releaseID)
+			query := notTrashed(tx.Table(itemConfig.table), itemConfig.table).Where("project_id = ? AND release_id = ?", projectID, releaseID)
 			if len(ids) > 0 {
 				query = query.Where("id NOT IN ?", ids)
 			}
@@ -623,6 +643,15 @@ func syncReleaseWorkItems(tx *gorm.DB, releaseID int, projectID int, confirmedIt
 	return nil
 }
 
+// notTrashed excludes items in the project trash from a query on a release item table
+func notTrashed(query *gorm.DB, table string) *gorm.DB {
+	switch table {
+	case "features", "issues", "tasks", "ideas":
+		return query.Where(table + ".deleted_at IS NULL")
+	}
+	return query
+}
+
 func groupReleaseItems(confirmedItems []ConfirmedReleaseItem) (map[string][]int, error) {
 	groupedItems := map[string][]int{
 		"feature": {},
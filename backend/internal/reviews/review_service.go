@@ -64,6 +64,10 @@ func (s *ReviewService) CreateSprintReview(projectID int, sprintID int, title, d
 		return nil, errors.New("project not found")
 	}
 
+	if err := project.EnsureWritable(); err != nil {
+		return nil, err
+	}
+
 	// Check if user is a manager
 	isManager, err := s.memberRepo.IsUserAdmin(projectID, createdBy)
 	if err != nil {
@@ -136,6 +140,10 @@ func (s *ReviewService) CreateCustomReview(projectID int, title, description str
 		return nil, errors.New("project not found")
 	}
 
+	if err := project.EnsureWritable(); err != nil {
+		return nil, err
+	}
+
 	// Check if user is a manager
 	isManager, err := s.memberRepo.IsUserAdmin(projectID, createdBy)
 	if err != nil {
@@ -243,6 +251,10 @@ func (s *ReviewService) UpdateReview(reviewID int, title, description, summary s
 		return nil, errors.New("review not found")
 	}
 
+	if err := s.projectRepo.EnsureWritable(review.ProjectID); err != nil {
+		return nil, err
+	}
+
 	isManager, err := s.memberRepo.IsUserAdmin(review.ProjectID, updatedBy)
 	if err != nil {
 		return nil, err
@@ -277,6 +289,10 @@ func (s *ReviewService) PublishReview(reviewID int, userID int) (*Review, error)
 		return nil, errors.New("review not found")
 	}
 
+	if err := s.projectRepo.EnsureWritable(review.ProjectID); err != nil {
+		return nil, err
+	}
+
 	isManager, err := s.memberRepo.IsUserAdmin(review.ProjectID, userID)
 	if err != nil {
 		return nil, err
@@ -316,6 +332,10 @@ func (s *ReviewService) DeleteReview(reviewID int, userID int) error {
 		return errors.New("review not found")
 	}
 
+	if err := s.projectRepo.EnsureWritable(review.ProjectID); err != nil {
+		return err
+	}
+
 	isManager, err := s.memberRepo.IsUserAdmin(review.ProjectID, userID)
 	if err != nil {
 		return err
@@ -342,6 +362,10 @@ func (s *ReviewService) RegenerateReviewItems(reviewID int, userID int) (*Review
 		return nil, errors.New("review not found")
 	}
 
+	if err := s.projectRepo.EnsureWritable(review.ProjectID); err != nil {
+		return nil, err
+	}
+
 	isManager, err := s.memberRepo.IsUserAdmin(review.ProjectID, userID)
 	if err != nil {
 		return nil, err
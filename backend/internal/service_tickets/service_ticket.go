@@ -2,21 +2,27 @@ package service_tickets
 
 import (
 	"time"
+
+	"github.com/dannyswat/pjeasy/internal/custom_fields"
+	"gorm.io/gorm"
 )
 
 // ServiceTicket represents a service ticket in the system
 type ServiceTicket struct {
-	ID                int       `gorm:"primaryKey;autoIncrement" json:"id"`
-	RefNum            string    `gorm:"column:ref_num;not null;size:50;uniqueIndex:idx_project_refnum,composite:projectId" json:"refNum"`
-	ProjectID         int       `gorm:"not null;index;uniqueIndex:idx_project_refnum,composite:refNum" json:"projectId"`
-	Title             string    `gorm:"not null;size:255" json:"title"`
-	Description       string    `gorm:"type:text" json:"description"`
-	Status            string    `gorm:"not null;size:50;default:'New'" json:"status"`      // New, Open, Fulfilled, Closed
-	Priority          string    `gorm:"not null;size:50;default:'Normal'" json:"priority"` // Immediate, Urgent, High, Normal, Low
-	CascadeCompletion bool      `gorm:"default:false" json:"cascadeCompletion"`            // Auto-complete when all related issues/features/tasks are completed
-	CreatedBy         int       `gorm:"not null;index" json:"createdBy"`
-	CreatedAt         time.Time `gorm:"not null" json:"createdAt"`
-	UpdatedAt         time.Time `gorm:"not null" json:"updatedAt"`
+	ID                int                  `gorm:"primaryKey;autoIncrement" json:"id"`
+	RefNum            string               `gorm:"column:ref_num;not null;size:50;uniqueIndex:idx_project_refnum,composite:projectId" json:"refNum"`
+	ProjectID         int                  `gorm:"not null;index;uniqueIndex:idx_project_refnum,composite:refNum" json:"projectId"`
+	Title             string               `gorm:"not null;size:255" json:"title"`
+	Description       string               `gorm:"type:text" json:"description"`
+	Status            string               `gorm:"not null;size:50;default:'New'" json:"status"`      // New, Open, Fulfilled, Closed
+	Priority          string               `gorm:"not null;size:50;default:'Normal'" json:"priority"` // Immediate, Urgent, High, Normal, Low
+	CascadeCompletion bool                 `gorm:"default:false" json:"cascadeCompletion"`            // Auto-complete when all related issues/features/tasks are completed
+	CustomFields      custom_fields.Values `gorm:"-" json:"customFields,omitempty"`                   // Loaded by the service, stored in custom_field_values
+	CreatedBy         int                  `gorm:"not null;index" json:"createdBy"`
+	CreatedAt         time.Time            `gorm:"not null" json:"createdAt"`
+	UpdatedAt         time.Time            `gorm:"not null" json:"updatedAt"`
+	DeletedAt         gorm.DeletedAt       `gorm:"index" json:"-"` // Set while in the trash
+	DeletedBy         *int                 `json:"-"`
 }
 
 // TableName specifies the table name for GORM
@@ -1,6 +1,9 @@
 package service_tickets
 
 import (
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/custom_fields"
 	"github.com/dannyswat/pjeasy/internal/repositories"
 	"gorm.io/gorm"
 )
@@ -33,9 +36,14 @@ func (r *ServiceTicketRepository) Update(ticket *ServiceTicket) error {
 	return r.uow.GetDB().Save(ticket).Error
 }
 
-// Delete deletes a service ticket
-func (r *ServiceTicketRepository) Delete(id int) error {
-	return r.uow.GetDB().Delete(&ServiceTicket{}, id).Error
+// Delete moves a service ticket to the trash. Trashed rows are excluded from every query until restored.
+func (r *ServiceTicketRepository) Delete(id int, deletedBy int) error {
+	return r.uow.GetDB().Model(&ServiceTicket{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"deleted_at": time.Now(),
+			"deleted_by": deletedBy,
+		}).Error
 }
 
 // GetByProjectID returns all service tickets for a project with pagination
@@ -59,8 +67,8 @@ func (r *ServiceTicketRepository) GetByProjectID(projectID int, offset, limit in
 	return tickets, total, err
 }
 
-// GetByProjectIDWithFilters returns service tickets filtered by status and priority with pagination
-func (r *ServiceTicketRepository) GetByProjectIDWithFilters(projectID int, statuses []string, priority, sortBy string, offset, limit int) ([]ServiceTicket, int64, error) {
+// GetByProjectIDWithFilters returns service tickets filtered by status, priority and custom field values with pagination
+func (r *ServiceTicketRepository) GetByProjectIDWithFilters(projectID int, statuses []string, priority string, filters []custom_fields.FieldFilter, sortBy string, offset, limit int) ([]ServiceTicket, int64, error) {
 	var tickets []ServiceTicket
 	var total int64
 
@@ -76,6 +84,8 @@ func (r *ServiceTicketRepository) GetByProjectIDWithFilters(projectID int, statu
 		query = query.Where("priority = ?", priority)
 	}
 
+	query = custom_fields.ApplyFilters(query, "service_tickets", filters)
+
 	// Get total count
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"time"
 
+	"github.com/dannyswat/pjeasy/internal/custom_fields"
 	"github.com/dannyswat/pjeasy/internal/htmlsanitizer"
 	"github.com/dannyswat/pjeasy/internal/projects"
 	"github.com/dannyswat/pjeasy/internal/repositories"
@@ -12,27 +13,47 @@ import (
 )
 
 type ServiceTicketService struct {
-	ticketRepo   *ServiceTicketRepository
-	memberRepo   *projects.ProjectMemberRepository
-	projectRepo  *projects.ProjectRepository
-	sequenceRepo *sequences.SequenceRepository
-	statusRepo   *status_changes.StatusChangeService
-	uowFactory   *repositories.UnitOfWorkFactory
+	ticketRepo         *ServiceTicketRepository
+	memberRepo         *projects.ProjectMemberRepository
+	projectRepo        *projects.ProjectRepository
+	settingService     *projects.ProjectSettingService
+	sequenceRepo       *sequences.SequenceRepository
+	statusRepo         *status_changes.StatusChangeService
+	customFieldService *custom_fields.CustomFieldService
+	uowFactory         *repositories.UnitOfWorkFactory
 }
 
-func NewServiceTicketService(ticketRepo *ServiceTicketRepository, memberRepo *projects.ProjectMemberRepository, projectRepo *projects.ProjectRepository, sequenceRepo *sequences.SequenceRepository, statusRepo *status_changes.StatusChangeService, uowFactory *repositories.UnitOfWorkFactory) *ServiceTicketService {
+func NewServiceTicketService(ticketRepo *ServiceTicketRepository, memberRepo *projects.ProjectMemberRepository, projectRepo *projects.ProjectRepository, settingService *projects.ProjectSettingService, sequenceRepo *sequences.SequenceRepository, statusRepo *status_changes.StatusChangeService, customFieldService *custom_fields.CustomFieldService, uowFactory *repositories.UnitOfWorkFactory) *ServiceTicketService {
 	return &ServiceTicketService{
-		ticketRepo:   ticketRepo,
-		memberRepo:   memberRepo,
-		projectRepo:  projectRepo,
-		sequenceRepo: sequenceRepo,
-		statusRepo:   statusRepo,
-		uowFactory:   uowFactory,
+		ticketRepo:         ticketRepo,
+		memberRepo:         memberRepo,
+		projectRepo:        projectRepo,
+		settingService:     settingService,
+		sequenceRepo:       sequenceRepo,
+		statusRepo:         statusRepo,
+		customFieldService: customFieldService,
+		uowFactory:         uowFactory,
 	}
 }
 
-// CreateServiceTicket creates a new service ticket
-func (s *ServiceTicketService) CreateServiceTicket(projectID int, title, description, priority string, cascadeCompletion bool, createdBy int) (*ServiceTicket, error) {
+// loadCustomFields fills in the custom field values of the service tickets of a project
+func (s *ServiceTicketService) loadCustomFields(projectID int, tickets []ServiceTicket) error {
+	ids := make([]int, len(tickets))
+	for i := range tickets {
+		ids[i] = tickets[i].ID
+	}
+	values, err := s.customFieldService.GetValuesForItems(projectID, projects.PermissionItemServiceTicket, ids)
+	if err != nil {
+		return err
+	}
+	for i := range tickets {
+		tickets[i].CustomFields = values[tickets[i].ID]
+	}
+	return nil
+}
+
+// CreateServiceTicket creates a new service ticket. Priority and cascadeCompletion fall back to the project settings when not given.
+func (s *ServiceTicketService) CreateServiceTicket(projectID int, title, description, priority string, cascadeCompletion *bool, customFields custom_fields.Values, createdBy int) (*ServiceTicket, error) {
 	// Validate project exists
 	project, err := s.projectRepo.GetByID(projectID)
 	if err != nil {
@@ -42,24 +63,41 @@ func (s *ServiceTicketService) CreateServiceTicket(projectID int, title, descrip
 		return nil, errors.New("project not found")
 	}
 
-	// Check if user has access to this project (any role including User)
-	isMember, err := s.memberRepo.IsUserMember(projectID, createdBy)
+	if err := project.EnsureWritable(); err != nil {
+		return nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(projectID, createdBy, projects.PermissionItemServiceTicket, projects.PermissionActionCreate)
 	if err != nil {
 		return nil, err
 	}
-	if !isMember {
-		return nil, errors.New("user does not have access to this project")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemServiceTicket, projects.PermissionActionCreate)
 	}
 
 	description = htmlsanitizer.Sanitize(description)
 
+	settings, err := s.settingService.GetProjectSettings(projectID)
+	if err != nil {
+		return nil, err
+	}
+
 	// Validate priority
 	if priority == "" {
-		priority = ServiceTicketPriorityNormal
+		priority = settings.DefaultPriority
 	}
 	if !IsValidPriority(priority) {
 		return nil, errors.New("invalid priority")
 	}
+	if cascadeCompletion == nil {
+		cascadeCompletion = &settings.DefaultCascadeCompletion
+	}
+
+	pendingFields, err := s.customFieldService.PrepareValues(projectID, projects.PermissionItemServiceTicket, 0, customFields)
+	if err != nil {
+		return nil, err
+	}
 
 	uow := s.uowFactory.NewUnitOfWork()
 	// Begin transaction to generate RefNum and create ticket
@@ -87,7 +125,7 @@ func (s *ServiceTicketService) CreateServiceTicket(projectID int, title, descrip
 		Description:       description,
 		Status:            ServiceTicketStatusNew,
 		Priority:          priority,
-		CascadeCompletion: cascadeCompletion,
+		CascadeCompletion: *cascadeCompletion,
 		CreatedBy:         createdBy,
 		CreatedAt:         now,
 		UpdatedAt:         now,
@@ -100,15 +138,23 @@ func (s *ServiceTicketService) CreateServiceTicket(projectID int, title, descrip
 		return nil, err
 	}
 
+	if err := s.customFieldService.SaveValues(uow, pendingFields, ticket.ID, createdBy); err != nil {
+		return nil, err
+	}
+
 	if err := uow.CommitTransaction(); err != nil {
 		return nil, err
 	}
 
+	ticket.CustomFields, err = s.customFieldService.GetValues(projectID, projects.PermissionItemServiceTicket, ticket.ID)
+	if err != nil {
+		return nil, err
+	}
 	return ticket, nil
 }
 
 // UpdateServiceTicket updates an existing service ticket
-func (s *ServiceTicketService) UpdateServiceTicket(ticketID int, title, description, priority string, cascadeCompletion bool, updatedBy int) (*ServiceTicket, error) {
+func (s *ServiceTicketService) UpdateServiceTicket(ticketID int, title, description, priority string, cascadeCompletion bool, customFields custom_fields.Values, updatedBy int) (*ServiceTicket, error) {
 	ticket, err := s.ticketRepo.GetByID(ticketID)
 	if err != nil {
 		return nil, err
@@ -117,13 +163,17 @@ func (s *ServiceTicketService) UpdateServiceTicket(ticketID int, title, descript
 		return nil, errors.New("service ticket not found")
 	}
 
-	// Check if user is a member of the project.
-	isMember, err := s.memberRepo.IsUserMember(ticket.ProjectID, updatedBy)
+	if err := s.projectRepo.EnsureWritable(ticket.ProjectID); err != nil {
+		return nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(ticket.ProjectID, updatedBy, projects.PermissionItemServiceTicket, projects.PermissionActionEdit)
 	if err != nil {
 		return nil, err
 	}
-	if !isMember {
-		return nil, errors.New("user is not a member of this project")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemServiceTicket, projects.PermissionActionEdit)
 	}
 
 	isProjectUser, err := s.memberRepo.IsUserProjectUser(ticket.ProjectID, updatedBy)
@@ -141,6 +191,11 @@ func (s *ServiceTicketService) UpdateServiceTicket(ticketID int, title, descript
 		return nil, errors.New("invalid priority")
 	}
 
+	pendingFields, err := s.customFieldService.PrepareValues(ticket.ProjectID, projects.PermissionItemServiceTicket, ticket.ID, customFields)
+	if err != nil {
+		return nil, err
+	}
+
 	// Update fields
 	ticket.Title = title
 	ticket.Description = description
@@ -150,10 +205,26 @@ func (s *ServiceTicketService) UpdateServiceTicket(ticketID int, title, descript
 	ticket.CascadeCompletion = cascadeCompletion
 	ticket.UpdatedAt = time.Now()
 
-	if err := s.ticketRepo.Update(ticket); err != nil {
+	uow := s.uowFactory.NewUnitOfWork()
+	if err := uow.BeginTransaction(); err != nil {
+		return nil, err
+	}
+	defer uow.RollbackTransactionIfError()
+
+	if err := NewServiceTicketRepository(uow).Update(ticket); err != nil {
+		return nil, err
+	}
+	if err := s.customFieldService.SaveValues(uow, pendingFields, ticket.ID, updatedBy); err != nil {
+		return nil, err
+	}
+	if err := uow.CommitTransaction(); err != nil {
 		return nil, err
 	}
 
+	ticket.CustomFields, err = s.customFieldService.GetValues(ticket.ProjectID, projects.PermissionItemServiceTicket, ticket.ID)
+	if err != nil {
+		return nil, err
+	}
 	return ticket, nil
 }
 
@@ -167,13 +238,17 @@ func (s *ServiceTicketService) UpdateServiceTicketStatus(ticketID int, status st
 		return nil, errors.New("service ticket not found")
 	}
 
-	// Check if user is a member of the project.
-	isMember, err := s.memberRepo.IsUserMember(ticket.ProjectID, updatedBy)
+	if err := s.projectRepo.EnsureWritable(ticket.ProjectID); err != nil {
+		return nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(ticket.ProjectID, updatedBy, projects.PermissionItemServiceTicket, projects.PermissionActionChangeStatus)
 	if err != nil {
 		return nil, err
 	}
-	if !isMember {
-		return nil, errors.New("user is not a member of this project")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemServiceTicket, projects.PermissionActionChangeStatus)
 	}
 
 	isProjectUser, err := s.memberRepo.IsUserProjectUser(ticket.ProjectID, updatedBy)
@@ -208,7 +283,7 @@ func (s *ServiceTicketService) UpdateServiceTicketStatus(ticketID int, status st
 	return ticket, nil
 }
 
-// DeleteServiceTicket deletes a service ticket
+// DeleteServiceTicket moves a service ticket to the project trash
 func (s *ServiceTicketService) DeleteServiceTicket(ticketID int, deletedBy int) error {
 	ticket, err := s.ticketRepo.GetByID(ticketID)
 	if err != nil {
@@ -218,24 +293,28 @@ func (s *ServiceTicketService) DeleteServiceTicket(ticketID int, deletedBy int)
 		return errors.New("service ticket not found")
 	}
 
-	// Check if user is a member of the project.
-	isMember, err := s.memberRepo.IsUserMember(ticket.ProjectID, deletedBy)
+	if err := s.projectRepo.EnsureWritable(ticket.ProjectID); err != nil {
+		return err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(ticket.ProjectID, deletedBy, projects.PermissionItemServiceTicket, projects.PermissionActionDelete)
 	if err != nil {
 		return err
 	}
-	if !isMember {
-		return errors.New("user is not a member of this project")
+	if !allowed {
+		return projects.PermissionDeniedError(projects.PermissionItemServiceTicket, projects.PermissionActionDelete)
 	}
 
 	isProjectUser, err := s.memberRepo.IsUserProjectUser(ticket.ProjectID, deletedBy)
 	if err != nil {
 		return err
 	}
-	if isProjectUser {
-		return errors.New("project users cannot delete service tickets")
+	if isProjectUser && ticket.CreatedBy != deletedBy {
+		return errors.New("project users can only delete their own service tickets")
 	}
 
-	return s.ticketRepo.Delete(ticketID)
+	return s.ticketRepo.Delete(ticketID, deletedBy)
 }
 
 // GetServiceTicket retrieves a service ticket by ID
@@ -257,11 +336,16 @@ func (s *ServiceTicketService) GetServiceTicket(ticketID int, userID int) (*Serv
 		return nil, errors.New("user does not have access to this project")
 	}
 
+	ticket.CustomFields, err = s.customFieldService.GetValues(ticket.ProjectID, projects.PermissionItemServiceTicket, ticket.ID)
+	if err != nil {
+		return nil, err
+	}
 	return ticket, nil
 }
 
-// ListServiceTickets returns a paginated list of service tickets for a project
-func (s *ServiceTicketService) ListServiceTickets(projectID, page, pageSize int, statuses []string, priority, sortBy string, userID int) ([]ServiceTicket, int64, error) {
+// ListServiceTickets returns a paginated list of service tickets for a project.
+// fieldFilters filter on custom field values by field key.
+func (s *ServiceTicketService) ListServiceTickets(projectID, page, pageSize int, statuses []string, priority string, fieldFilters map[string]string, sortBy string, userID int) ([]ServiceTicket, int64, error) {
 	// Check if user has access to the project
 	isMember, err := s.memberRepo.IsUserMember(projectID, userID)
 	if err != nil {
@@ -278,9 +362,22 @@ func (s *ServiceTicketService) ListServiceTickets(projectID, page, pageSize int,
 		}
 	}
 
+	filters, err := s.customFieldService.ParseFilters(projectID, projects.PermissionItemServiceTicket, fieldFilters)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	offset := (page - 1) * pageSize
 
-	return s.ticketRepo.GetByProjectIDWithFilters(projectID, statuses, priority, sortBy, offset, pageSize)
+	tickets, total, err := s.ticketRepo.GetByProjectIDWithFilters(projectID, statuses, priority, filters, sortBy, offset, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := s.loadCustomFields(projectID, tickets); err != nil {
+		return nil, 0, err
+	}
+	return tickets, total, nil
 }
 
 // CountNewServiceTickets returns the count of service tickets with status "New" for a project
@@ -319,6 +416,10 @@ func (s *ServiceTicketService) UpdateServiceTicketStatusByWorkflow(ticketID int,
 		return errors.New("invalid status")
 	}
 
+	if err := s.projectRepo.EnsureWritable(ticket.ProjectID); err != nil {
+		return err
+	}
+
 	// Only update if the current status is not already the target status
 	if ticket.Status == status {
 		return nil // Already at target status, no-op
@@ -14,15 +14,16 @@ import (
 )
 
 type SprintService struct {
-	sprintRepo  *SprintRepository
-	taskRepo    *tasks.TaskRepository
-	featureRepo *features.FeatureRepository
-	issueRepo   *issues.IssueRepository
-	releaseRepo *releases.ReleaseRepository
-	memberRepo  *projects.ProjectMemberRepository
-	projectRepo *projects.ProjectRepository
-	statusRepo  *status_changes.StatusChangeService
-	uowFactory  *repositories.UnitOfWorkFactory
+	sprintRepo     *SprintRepository
+	taskRepo       *tasks.TaskRepository
+	featureRepo    *features.FeatureRepository
+	issueRepo      *issues.IssueRepository
+	releaseRepo    *releases.ReleaseRepository
+	memberRepo     *projects.ProjectMemberRepository
+	projectRepo    *projects.ProjectRepository
+	settingService *projects.ProjectSettingService
+	statusRepo     *status_changes.StatusChangeService
+	uowFactory     *repositories.UnitOfWorkFactory
 }
 
 func NewSprintService(
@@ -33,19 +34,21 @@ func NewSprintService(
 	releaseRepo *releases.ReleaseRepository,
 	memberRepo *projects.ProjectMemberRepository,
 	projectRepo *projects.ProjectRepository,
+	settingService *projects.ProjectSettingService,
 	statusRepo *status_changes.StatusChangeService,
 	uowFactory *repositories.UnitOfWorkFactory,
 ) *SprintService {
 	return &SprintService{
-		sprintRepo:  sprintRepo,
-		taskRepo:    taskRepo,
-		featureRepo: featureRepo,
-		issueRepo:   issueRepo,
-		releaseRepo: releaseRepo,
-		memberRepo:  memberRepo,
-		projectRepo: projectRepo,
-		statusRepo:  statusRepo,
-		uowFactory:  uowFactory,
+		sprintRepo:     sprintRepo,
+		taskRepo:       taskRepo,
+		featureRepo:    featureRepo,
+		issueRepo:      issueRepo,
+		releaseRepo:    releaseRepo,
+		memberRepo:     memberRepo,
+		projectRepo:    projectRepo,
+		settingService: settingService,
+		statusRepo:     statusRepo,
+		uowFactory:     uowFactory,
 	}
 }
 
@@ -66,13 +69,17 @@ func (s *SprintService) CreateSprint(projectID int, name, goal string, startDate
 		return nil, errors.New("project not found")
 	}
 
-	// Check if user is a manager of the project
-	isManager, err := s.memberRepo.IsUserAdmin(projectID, createdBy)
+	if err := project.EnsureWritable(); err != nil {
+		return nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(projectID, createdBy, projects.PermissionItemSprint, projects.PermissionActionManageSprint)
 	if err != nil {
 		return nil, err
 	}
-	if !isManager {
-		return nil, errors.New("only project managers can create sprints")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemSprint, projects.PermissionActionManageSprint)
 	}
 
 	now := time.Now()
@@ -80,6 +87,16 @@ func (s *SprintService) CreateSprint(projectID int, name, goal string, startDate
 		return nil, err
 	}
 
+	// Sprints planned with only a start date get the project's default length
+	if startDate != nil && endDate == nil {
+		settings, err := s.settingService.GetProjectSettings(projectID)
+		if err != nil {
+			return nil, err
+		}
+		end := settings.SprintEndDate(*startDate)
+		endDate = &end
+	}
+
 	sprint := &Sprint{
 		ProjectID:   projectID,
 		Name:        name,
@@ -110,13 +127,17 @@ func (s *SprintService) UpdateSprint(sprintID int, name, goal string, startDate,
 		return nil, errors.New("sprint not found")
 	}
 
-	// Check if user is a manager of the project
-	isManager, err := s.memberRepo.IsUserAdmin(sprint.ProjectID, updatedBy)
+	if err := s.projectRepo.EnsureWritable(sprint.ProjectID); err != nil {
+		return nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(sprint.ProjectID, updatedBy, projects.PermissionItemSprint, projects.PermissionActionManageSprint)
 	if err != nil {
 		return nil, err
 	}
-	if !isManager {
-		return nil, errors.New("only project managers can update sprints")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemSprint, projects.PermissionActionManageSprint)
 	}
 
 	// Cannot update closed sprints
@@ -148,13 +169,17 @@ func (s *SprintService) StartSprint(sprintID int, userID int) (*Sprint, error) {
 		return nil, errors.New("sprint not found")
 	}
 
-	// Check if user is a manager of the project
-	isManager, err := s.memberRepo.IsUserAdmin(sprint.ProjectID, userID)
+	if err := s.projectRepo.EnsureWritable(sprint.ProjectID); err != nil {
+		return nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(sprint.ProjectID, userID, projects.PermissionItemSprint, projects.PermissionActionManageSprint)
 	if err != nil {
 		return nil, err
 	}
-	if !isManager {
-		return nil, errors.New("only project managers can start sprints")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemSprint, projects.PermissionActionManageSprint)
 	}
 
 	// Can only start sprints that are in Planning status
@@ -175,10 +200,19 @@ func (s *SprintService) StartSprint(sprintID int, userID int) (*Sprint, error) {
 		return nil, err
 	}
 
+	settings, err := s.settingService.GetProjectSettings(sprint.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
 	sprint.Status = SprintStatusActive
 	if sprint.StartDate == nil {
-		now := time.Now()
-		sprint.StartDate = &now
+		today := settings.Today(time.Now())
+		sprint.StartDate = &today
+	}
+	if sprint.EndDate == nil {
+		end := settings.SprintEndDate(*sprint.StartDate)
+		sprint.EndDate = &end
 	}
 	sprint.UpdatedAt = time.Now()
 
@@ -203,13 +237,17 @@ func (s *SprintService) CloseSprint(sprintID int, createNewSprint bool, newSprin
 		return nil, nil, errors.New("sprint not found")
 	}
 
-	// Check if user is a manager of the project
-	isManager, err := s.memberRepo.IsUserAdmin(sprint.ProjectID, userID)
+	if err := s.projectRepo.EnsureWritable(sprint.ProjectID); err != nil {
+		return nil, nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(sprint.ProjectID, userID, projects.PermissionItemSprint, projects.PermissionActionManageSprint)
 	if err != nil {
 		return nil, nil, err
 	}
-	if !isManager {
-		return nil, nil, errors.New("only project managers can close sprints")
+	if !allowed {
+		return nil, nil, projects.PermissionDeniedError(projects.PermissionItemSprint, projects.PermissionActionManageSprint)
 	}
 
 	// Can only close sprints that are Active
@@ -245,6 +283,15 @@ func (s *SprintService) CloseSprint(sprintID int, createNewSprint bool, newSprin
 
 	// If createNewSprint is true, create a new sprint and copy in-progress tasks
 	if createNewSprint {
+		if newSprintEndDate == nil {
+			settings, err := s.settingService.GetProjectSettings(sprint.ProjectID)
+			if err != nil {
+				return sprint, nil, err
+			}
+			end := settings.SprintEndDate(settings.Today(now))
+			newSprintEndDate = &end
+		}
+
 		// Get all tasks in the closed sprint that are in progress (not Completed or Closed)
 		inProgressTasks, _, err := s.getInProgressTasksForSprint(sprintID)
 		if err != nil {
@@ -312,13 +359,17 @@ func (s *SprintService) DeleteSprint(sprintID int, userID int) error {
 		return errors.New("sprint not found")
 	}
 
-	// Check if user is a manager of the project
-	isManager, err := s.memberRepo.IsUserAdmin(sprint.ProjectID, userID)
+	if err := s.projectRepo.EnsureWritable(sprint.ProjectID); err != nil {
+		return err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(sprint.ProjectID, userID, projects.PermissionItemSprint, projects.PermissionActionManageSprint)
 	if err != nil {
 		return err
 	}
-	if !isManager {
-		return errors.New("only project managers can delete sprints")
+	if !allowed {
+		return projects.PermissionDeniedError(projects.PermissionItemSprint, projects.PermissionActionManageSprint)
 	}
 
 	// Can only delete sprints that are in Planning status
@@ -446,13 +497,17 @@ func (s *SprintService) AddTaskToSprint(taskID int, sprintID int, userID int) (*
 		return nil, errors.New("task does not belong to the same project as the sprint")
 	}
 
-	// Check if user is a member of the project
-	isMember, err := s.memberRepo.IsUserMember(sprint.ProjectID, userID)
+	if err := s.projectRepo.EnsureWritable(sprint.ProjectID); err != nil {
+		return nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(sprint.ProjectID, userID, projects.PermissionItemTask, projects.PermissionActionEdit)
 	if err != nil {
 		return nil, err
 	}
-	if !isMember {
-		return nil, errors.New("user is not a member of this project")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemTask, projects.PermissionActionEdit)
 	}
 
 	// Update the task's sprint ID
@@ -477,13 +532,17 @@ func (s *SprintService) RemoveTaskFromSprint(taskID int, userID int) (*tasks.Tas
 		return nil, errors.New("task not found")
 	}
 
-	// Check if user is a member of the project
-	isMember, err := s.memberRepo.IsUserMember(task.ProjectID, userID)
+	if err := s.projectRepo.EnsureWritable(task.ProjectID); err != nil {
+		return nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(task.ProjectID, userID, projects.PermissionItemTask, projects.PermissionActionEdit)
 	if err != nil {
 		return nil, err
 	}
-	if !isMember {
-		return nil, errors.New("user is not a member of this project")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemTask, projects.PermissionActionEdit)
 	}
 
 	// Remove the task from the sprint
@@ -592,12 +651,16 @@ func (s *SprintService) AddCompletedItemsToRelease(sprintID int, releaseID int,
 		return nil, errors.New("release does not belong to the same project as the sprint")
 	}
 
-	canWrite, err := s.memberRepo.CanUserWriteProject(sprint.ProjectID, userID)
+	if err := s.projectRepo.EnsureWritable(sprint.ProjectID); err != nil {
+		return nil, err
+	}
+
+	allowed, err := s.memberRepo.HasPermission(sprint.ProjectID, userID, projects.PermissionItemRelease, projects.PermissionActionEdit)
 	if err != nil {
 		return nil, err
 	}
-	if !canWrite {
-		return nil, errors.New("project users can only read project items")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemRelease, projects.PermissionActionEdit)
 	}
 
 	completedFeatures, err := s.featureRepo.GetByProjectIDAndSprintID(sprint.ProjectID, sprintID)
@@ -9,13 +9,14 @@ import (
 )
 
 type StatusChangeService struct {
-	repo       *StatusChangeRepository
-	flowRepo   *StatusFlowRepository
-	memberRepo *projects.ProjectMemberRepository
+	repo        *StatusChangeRepository
+	flowRepo    *StatusFlowRepository
+	memberRepo  *projects.ProjectMemberRepository
+	projectRepo *projects.ProjectRepository
 }
 
-func NewStatusChangeService(repo *StatusChangeRepository, flowRepo *StatusFlowRepository, memberRepo *projects.ProjectMemberRepository) *StatusChangeService {
-	return &StatusChangeService{repo: repo, flowRepo: flowRepo, memberRepo: memberRepo}
+func NewStatusChangeService(repo *StatusChangeRepository, flowRepo *StatusFlowRepository, memberRepo *projects.ProjectMemberRepository, projectRepo *projects.ProjectRepository) *StatusChangeService {
+	return &StatusChangeService{repo: repo, flowRepo: flowRepo, memberRepo: memberRepo, projectRepo: projectRepo}
 }
 
 func (s *StatusChangeService) LogChange(projectID int, itemType string, itemID int, oldStatus, newStatus string, changedBy *int) error {
@@ -178,7 +179,12 @@ func (s *StatusChangeService) DeleteStatusFlow(projectID int, flowID int, userID
 	return s.flowRepo.Delete(flowID)
 }
 
+// ensureManager checks that the user can change the status workflows of a writable project
 func (s *StatusChangeService) ensureManager(projectID int, userID int) error {
+	if err := s.projectRepo.EnsureWritable(projectID); err != nil {
+		return err
+	}
+
 	isManager, err := s.memberRepo.IsUserAdmin(projectID, userID)
 	if err != nil {
 		return err
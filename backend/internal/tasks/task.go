@@ -2,28 +2,34 @@ package tasks
 
 import (
 	"time"
+
+	"github.com/dannyswat/pjeasy/internal/custom_fields"
+	"gorm.io/gorm"
 )
 
 // Task represents a task in the system
 type Task struct {
-	ID              int        `gorm:"primaryKey;autoIncrement" json:"id"`
-	ProjectID       int        `gorm:"not null;index" json:"projectId"`
-	Title           string     `gorm:"not null;size:255" json:"title"`
-	Description     string     `gorm:"type:text" json:"description"`
-	Status          string     `gorm:"not null;size:50;default:'Open'" json:"status"`      // Open, In Progress, On Hold, Blocked, Completed, Rejected, Reopened, Closed
-	Priority        string     `gorm:"not null;size:50;default:'Normal'" json:"priority"`  // Immediate, Urgent, High, Normal, Low
-	EstimatedHours  float64    `gorm:"type:decimal(10,2)" json:"estimatedHours,omitempty"` // Estimated time in hours
-	AssigneeID      *int       `gorm:"index" json:"assigneeId,omitempty"`                  // Assigned user ID (nullable)
-	Deadline        *time.Time `json:"deadline,omitempty"`                                 // Optional deadline
-	SprintID        *int       `gorm:"index" json:"sprintId,omitempty"`                    // Optional sprint association
-	ReleaseID       *int       `gorm:"index" json:"releaseId,omitempty"`                   // Target release
-	ItemType        string     `gorm:"size:50;index:idx_item" json:"itemType,omitempty"`   // Type of related item (e.g., "idea", "epic")
-	ItemID          *int       `gorm:"index:idx_item" json:"itemId,omitempty"`             // ID of related item
-	LinkedIdeaLabel string     `gorm:"->;column:linked_idea_label;-:migration" json:"linkedIdeaLabel,omitempty"`
-	Tags            string     `gorm:"type:text" json:"tags,omitempty"` // Comma-separated tags
-	CreatedBy       int        `gorm:"not null;index" json:"createdBy"`
-	CreatedAt       time.Time  `gorm:"not null" json:"createdAt"`
-	UpdatedAt       time.Time  `gorm:"not null" json:"updatedAt"`
+	ID              int                  `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProjectID       int                  `gorm:"not null;index" json:"projectId"`
+	Title           string               `gorm:"not null;size:255" json:"title"`
+	Description     string               `gorm:"type:text" json:"description"`
+	Status          string               `gorm:"not null;size:50;default:'Open'" json:"status"`      // Open, In Progress, On Hold, Blocked, Completed, Rejected, Reopened, Closed
+	Priority        string               `gorm:"not null;size:50;default:'Normal'" json:"priority"`  // Immediate, Urgent, High, Normal, Low
+	EstimatedHours  float64              `gorm:"type:decimal(10,2)" json:"estimatedHours,omitempty"` // Estimated time in hours
+	AssigneeID      *int                 `gorm:"index" json:"assigneeId,omitempty"`                  // Assigned user ID (nullable)
+	Deadline        *time.Time           `json:"deadline,omitempty"`                                 // Optional deadline
+	SprintID        *int                 `gorm:"index" json:"sprintId,omitempty"`                    // Optional sprint association
+	ReleaseID       *int                 `gorm:"index" json:"releaseId,omitempty"`                   // Target release
+	ItemType        string               `gorm:"size:50;index:idx_item" json:"itemType,omitempty"`   // Type of related item (e.g., "idea", "epic")
+	ItemID          *int                 `gorm:"index:idx_item" json:"itemId,omitempty"`             // ID of related item
+	LinkedIdeaLabel string               `gorm:"->;column:linked_idea_label;-:migration" json:"linkedIdeaLabel,omitempty"`
+	Tags            string               `gorm:"type:text" json:"tags,omitempty"` // Comma-separated tags
+	CustomFields    custom_fields.Values `gorm:"-" json:"customFields,omitempty"` // Loaded by the service, stored in custom_field_values
+	CreatedBy       int                  `gorm:"not null;index" json:"createdBy"`
+	CreatedAt       time.Time            `gorm:"not null" json:"createdAt"`
+	UpdatedAt       time.Time            `gorm:"not null" json:"updatedAt"`
+	DeletedAt       gorm.DeletedAt       `gorm:"index" json:"-"` // Set while in the trash
+	DeletedBy       *int                 `json:"-"`
 }
 
 // TableName specifies the table name for GORM
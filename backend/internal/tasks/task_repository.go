@@ -1,6 +1,10 @@
 package tasks
 
 import (
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/custom_fields"
+	"github.com/dannyswat/pjeasy/internal/item_links"
 	"github.com/dannyswat/pjeasy/internal/repositories"
 	"gorm.io/gorm"
 )
@@ -15,7 +19,7 @@ func NewTaskRepository(uow *repositories.UnitOfWork) *TaskRepository {
 
 func withTaskLinkedIdeaLabel(query *gorm.DB) *gorm.DB {
 	return query.
-		Joins("LEFT JOIN ideas linked_ideas ON tasks.item_type = ? AND tasks.item_id = linked_ideas.id", "ideas").
+		Joins("LEFT JOIN ideas linked_ideas ON tasks.item_type = ? AND tasks.item_id = linked_ideas.id AND linked_ideas.deleted_at IS NULL", "ideas").
 		Select("tasks.*, linked_ideas.label AS linked_idea_label")
 }
 
@@ -39,9 +43,14 @@ func (r *TaskRepository) Update(task *Task) error {
 	return r.uow.GetDB().Save(task).Error
 }
 
-// Delete deletes a task
-func (r *TaskRepository) Delete(id int) error {
-	return r.uow.GetDB().Delete(&Task{}, id).Error
+// Delete moves a task to the trash. Trashed rows are excluded from every query until restored.
+func (r *TaskRepository) Delete(id int, deletedBy int) error {
+	return r.uow.GetDB().Model(&Task{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"deleted_at": time.Now(),
+			"deleted_by": deletedBy,
+		}).Error
 }
 
 // GetByProjectID returns all tasks for a project with pagination
@@ -111,6 +120,33 @@ func (r *TaskRepository) GetByProjectIDAndStatuses(projectID int, statuses []str
 	return tasks, total, err
 }
 
+// GetByProjectIDWithFieldFilters returns tasks filtered by custom field values and optionally by statuses
+func (r *TaskRepository) GetByProjectIDWithFieldFilters(projectID int, statuses []string, filters []custom_fields.FieldFilter, offset, limit int) ([]Task, int64, error) {
+	var tasks []Task
+	var total int64
+
+	filtered := func() *gorm.DB {
+		query := r.uow.GetDB().Model(&Task{}).Where("tasks.project_id = ?", projectID)
+		if len(statuses) > 0 {
+			query = query.Where("tasks.status IN ?", statuses)
+		}
+		return custom_fields.ApplyFilters(query, "tasks", filters)
+	}
+
+	// Get total count
+	if err := filtered().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Get paginated results
+	err := withTaskLinkedIdeaLabel(filtered()).Order("tasks.created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&tasks).Error
+
+	return tasks, total, err
+}
+
 // GetByAssigneeID returns tasks assigned to a specific user with pagination
 func (r *TaskRepository) GetByAssigneeID(assigneeID int, offset, limit int) ([]Task, int64, error) {
 	var tasks []Task
@@ -172,17 +208,18 @@ func (r *TaskRepository) GetByItemReference(projectID int, itemType string, item
 	var tasks []Task
 	var total int64
 
-	query := r.uow.GetDB().Model(&Task{}).
-		Where("tasks.project_id = ? AND tasks.item_type = ? AND tasks.item_id = ?", projectID, itemType, itemID)
+	children := func() *gorm.DB {
+		query := r.uow.GetDB().Model(&Task{}).Where("tasks.project_id = ?", projectID)
+		return item_links.ChildrenOf(query, "tasks", itemType, itemID)
+	}
 
 	// Get total count
-	if err := query.Count(&total).Error; err != nil {
+	if err := children().Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Get paginated results
-	err := withTaskLinkedIdeaLabel(r.uow.GetDB().Model(&Task{}).
-		Where("tasks.project_id = ? AND tasks.item_type = ? AND tasks.item_id = ?", projectID, itemType, itemID)).Order("tasks.created_at DESC").
+	err := withTaskLinkedIdeaLabel(children()).Order("tasks.created_at DESC").
 		Offset(offset).
 		Limit(limit).
 		Find(&tasks).Error
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"time"
 
+	"github.com/dannyswat/pjeasy/internal/custom_fields"
 	"github.com/dannyswat/pjeasy/internal/htmlsanitizer"
+	"github.com/dannyswat/pjeasy/internal/item_links"
 	"github.com/dannyswat/pjeasy/internal/projects"
 	"github.com/dannyswat/pjeasy/internal/repositories"
 	"github.com/dannyswat/pjeasy/internal/sequences"
@@ -26,34 +28,54 @@ type TaskService struct {
 	taskRepo            *TaskRepository
 	memberRepo          *projects.ProjectMemberRepository
 	projectRepo         *projects.ProjectRepository
+	settingService      *projects.ProjectSettingService
 	sequenceRepo        *sequences.SequenceRepository
 	serviceTicketRepo   *service_tickets.ServiceTicketRepository
 	wikiChangeMerger    WikiChangeMerger
 	statusRepo          *status_changes.StatusChangeService
+	customFieldService  *custom_fields.CustomFieldService
 	uowFactory          *repositories.UnitOfWorkFactory
 	statusChangeHandler StatusChangeHandler
 }
 
-func NewTaskService(taskRepo *TaskRepository, memberRepo *projects.ProjectMemberRepository, projectRepo *projects.ProjectRepository, sequenceRepo *sequences.SequenceRepository, serviceTicketRepo *service_tickets.ServiceTicketRepository, wikiChangeMerger WikiChangeMerger, statusRepo *status_changes.StatusChangeService, uowFactory *repositories.UnitOfWorkFactory) *TaskService {
+func NewTaskService(taskRepo *TaskRepository, memberRepo *projects.ProjectMemberRepository, projectRepo *projects.ProjectRepository, settingService *projects.ProjectSettingService, sequenceRepo *sequences.SequenceRepository, serviceTicketRepo *service_tickets.ServiceTicketRepository, wikiChangeMerger WikiChangeMerger, statusRepo *status_changes.StatusChangeService, customFieldService *custom_fields.CustomFieldService, uowFactory *repositories.UnitOfWorkFactory) *TaskService {
 	return &TaskService{
-		taskRepo:          taskRepo,
-		memberRepo:        memberRepo,
-		projectRepo:       projectRepo,
-		sequenceRepo:      sequenceRepo,
-		serviceTicketRepo: serviceTicketRepo,
-		wikiChangeMerger:  wikiChangeMerger,
-		statusRepo:        statusRepo,
-		uowFactory:        uowFactory,
+		taskRepo:           taskRepo,
+		memberRepo:         memberRepo,
+		projectRepo:        projectRepo,
+		settingService:     settingService,
+		sequenceRepo:       sequenceRepo,
+		serviceTicketRepo:  serviceTicketRepo,
+		wikiChangeMerger:   wikiChangeMerger,
+		statusRepo:         statusRepo,
+		customFieldService: customFieldService,
+		uowFactory:         uowFactory,
 	}
 }
 
+// loadCustomFields fills in the custom field values of the tasks of a project
+func (s *TaskService) loadCustomFields(projectID int, tasks []Task) error {
+	ids := make([]int, len(tasks))
+	for i := range tasks {
+		ids[i] = tasks[i].ID
+	}
+	values, err := s.customFieldService.GetValuesForItems(projectID, projects.PermissionItemTask, ids)
+	if err != nil {
+		return err
+	}
+	for i := range tasks {
+		tasks[i].CustomFields = values[tasks[i].ID]
+	}
+	return nil
+}
+
 // SetStatusChangeHandler sets the handler for status change events
 func (s *TaskService) SetStatusChangeHandler(handler StatusChangeHandler) {
 	s.statusChangeHandler = handler
 }
 
 // CreateTask creates a new task
-func (s *TaskService) CreateTask(projectID int, title, description, status, priority, tags string, estimatedHours float64, assigneeID *int, deadline *time.Time, sprintID *int, releaseID *int, itemType string, itemID *int, createdBy int) (*Task, error) {
+func (s *TaskService) CreateTask(projectID int, title, description, status, priority, tags string, estimatedHours float64, assigneeID *int, deadline *time.Time, sprintID *int, releaseID *int, itemType string, itemID *int, customFields custom_fields.Values, createdBy int) (*Task, error) {
 	// Validate project exists
 	project, err := s.projectRepo.GetByID(projectID)
 	if err != nil {
@@ -63,13 +85,17 @@ func (s *TaskService) CreateTask(projectID int, title, description, status, prio
 		return nil, errors.New("project not found")
 	}
 
-	// Check if user can modify project items.
-	canWrite, err := s.memberRepo.CanUserWriteProject(projectID, createdBy)
+	if err := project.EnsureWritable(); err != nil {
+		return nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(projectID, createdBy, projects.PermissionItemTask, projects.PermissionActionCreate)
 	if err != nil {
 		return nil, err
 	}
-	if !canWrite {
-		return nil, errors.New("project users can only read project items")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemTask, projects.PermissionActionCreate)
 	}
 
 	description = htmlsanitizer.Sanitize(description)
@@ -86,7 +112,11 @@ func (s *TaskService) CreateTask(projectID int, title, description, status, prio
 
 	// Validate priority
 	if priority == "" {
-		priority = TaskPriorityNormal
+		settings, err := s.settingService.GetProjectSettings(projectID)
+		if err != nil {
+			return nil, err
+		}
+		priority = settings.DefaultPriority
 	} else if !IsValidPriority(priority) {
 		return nil, errors.New("invalid priority")
 	}
@@ -102,6 +132,11 @@ func (s *TaskService) CreateTask(projectID int, title, description, status, prio
 		}
 	}
 
+	pendingFields, err := s.customFieldService.PrepareValues(projectID, projects.PermissionItemTask, 0, customFields)
+	if err != nil {
+		return nil, err
+	}
+
 	now := time.Now()
 	task := &Task{
 		ProjectID:      projectID,
@@ -122,7 +157,22 @@ func (s *TaskService) CreateTask(projectID int, title, description, status, prio
 		UpdatedAt:      now,
 	}
 
-	if err := s.taskRepo.Create(task); err != nil {
+	uow := s.uowFactory.NewUnitOfWork()
+	if err := uow.BeginTransaction(); err != nil {
+		return nil, err
+	}
+	defer uow.RollbackTransactionIfError()
+
+	if err := NewTaskRepository(uow).Create(task); err != nil {
+		return nil, err
+	}
+	if err := s.customFieldService.SaveValues(uow, pendingFields, task.ID, createdBy); err != nil {
+		return nil, err
+	}
+	if err := item_links.NewItemLinkRepository(uow).SetParent(task.ProjectID, projects.PermissionItemTask, task.ID, task.ItemType, task.ItemID, createdBy); err != nil {
+		return nil, err
+	}
+	if err := uow.CommitTransaction(); err != nil {
 		return nil, err
 	}
 
@@ -136,11 +186,15 @@ func (s *TaskService) CreateTask(projectID int, title, description, status, prio
 		}
 	}
 
+	task.CustomFields, err = s.customFieldService.GetValues(projectID, projects.PermissionItemTask, task.ID)
+	if err != nil {
+		return nil, err
+	}
 	return task, nil
 }
 
 // UpdateTask updates a task's details
-func (s *TaskService) UpdateTask(taskID int, title, description, priority, tags string, estimatedHours float64, assigneeID *int, deadline *time.Time, sprintID *int, releaseID *int, itemType string, itemID *int, updatedBy int) (*Task, error) {
+func (s *TaskService) UpdateTask(taskID int, title, description, priority, tags string, estimatedHours float64, assigneeID *int, deadline *time.Time, sprintID *int, releaseID *int, itemType string, itemID *int, customFields custom_fields.Values, updatedBy int) (*Task, error) {
 	task, err := s.taskRepo.GetByID(taskID)
 	if err != nil {
 		return nil, err
@@ -149,13 +203,17 @@ func (s *TaskService) UpdateTask(taskID int, title, description, priority, tags
 		return nil, errors.New("task not found")
 	}
 
-	// Check if user can modify project items.
-	canWrite, err := s.memberRepo.CanUserWriteProject(task.ProjectID, updatedBy)
+	if err := s.projectRepo.EnsureWritable(task.ProjectID); err != nil {
+		return nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(task.ProjectID, updatedBy, projects.PermissionItemTask, projects.PermissionActionEdit)
 	if err != nil {
 		return nil, err
 	}
-	if !canWrite {
-		return nil, errors.New("project users can only read project items")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemTask, projects.PermissionActionEdit)
 	}
 
 	description = htmlsanitizer.Sanitize(description)
@@ -176,6 +234,11 @@ func (s *TaskService) UpdateTask(taskID int, title, description, priority, tags
 		}
 	}
 
+	pendingFields, err := s.customFieldService.PrepareValues(task.ProjectID, projects.PermissionItemTask, task.ID, customFields)
+	if err != nil {
+		return nil, err
+	}
+
 	task.Title = title
 	task.Description = description
 	if priority != "" {
@@ -191,10 +254,29 @@ func (s *TaskService) UpdateTask(taskID int, title, description, priority, tags
 	task.Tags = tags
 	task.UpdatedAt = time.Now()
 
-	if err := s.taskRepo.Update(task); err != nil {
+	uow := s.uowFactory.NewUnitOfWork()
+	if err := uow.BeginTransaction(); err != nil {
 		return nil, err
 	}
+	defer uow.RollbackTransactionIfError()
 
+	if err := NewTaskRepository(uow).Update(task); err != nil {
+		return nil, err
+	}
+	if err := s.customFieldService.SaveValues(uow, pendingFields, task.ID, updatedBy); err != nil {
+		return nil, err
+	}
+	if err := item_links.NewItemLinkRepository(uow).SetParent(task.ProjectID, projects.PermissionItemTask, task.ID, task.ItemType, task.ItemID, updatedBy); err != nil {
+		return nil, err
+	}
+	if err := uow.CommitTransaction(); err != nil {
+		return nil, err
+	}
+
+	task.CustomFields, err = s.customFieldService.GetValues(task.ProjectID, projects.PermissionItemTask, task.ID)
+	if err != nil {
+		return nil, err
+	}
 	return task, nil
 }
 
@@ -212,13 +294,17 @@ func (s *TaskService) UpdateTaskStatus(taskID int, status string, updatedBy int)
 		return nil, errors.New("task not found")
 	}
 
-	// Check if user can modify project items.
-	canWrite, err := s.memberRepo.CanUserWriteProject(task.ProjectID, updatedBy)
+	if err := s.projectRepo.EnsureWritable(task.ProjectID); err != nil {
+		return nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(task.ProjectID, updatedBy, projects.PermissionItemTask, projects.PermissionActionChangeStatus)
 	if err != nil {
 		return nil, err
 	}
-	if !canWrite {
-		return nil, errors.New("project users can only read project items")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemTask, projects.PermissionActionChangeStatus)
 	}
 
 	oldStatus := task.Status
@@ -267,13 +353,17 @@ func (s *TaskService) UpdateTaskAssignee(taskID int, assigneeID *int, updatedBy
 		return nil, errors.New("task not found")
 	}
 
-	// Check if user can modify project items.
-	canWrite, err := s.memberRepo.CanUserWriteProject(task.ProjectID, updatedBy)
+	if err := s.projectRepo.EnsureWritable(task.ProjectID); err != nil {
+		return nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(task.ProjectID, updatedBy, projects.PermissionItemTask, projects.PermissionActionEdit)
 	if err != nil {
 		return nil, err
 	}
-	if !canWrite {
-		return nil, errors.New("project users can only read project items")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemTask, projects.PermissionActionEdit)
 	}
 
 	// Validate assignee if provided
@@ -295,7 +385,7 @@ func (s *TaskService) UpdateTaskAssignee(taskID int, assigneeID *int, updatedBy
 	return s.taskRepo.GetByID(taskID)
 }
 
-// DeleteTask deletes a task
+// DeleteTask moves a task to the project trash
 func (s *TaskService) DeleteTask(taskID int, deletedBy int) error {
 	task, err := s.taskRepo.GetByID(taskID)
 	if err != nil {
@@ -305,16 +395,20 @@ func (s *TaskService) DeleteTask(taskID int, deletedBy int) error {
 		return errors.New("task not found")
 	}
 
-	// Check if user can modify project items.
-	canWrite, err := s.memberRepo.CanUserWriteProject(task.ProjectID, deletedBy)
+	if err := s.projectRepo.EnsureWritable(task.ProjectID); err != nil {
+		return err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(task.ProjectID, deletedBy, projects.PermissionItemTask, projects.PermissionActionDelete)
 	if err != nil {
 		return err
 	}
-	if !canWrite {
-		return errors.New("project users can only read project items")
+	if !allowed {
+		return projects.PermissionDeniedError(projects.PermissionItemTask, projects.PermissionActionDelete)
 	}
 
-	return s.taskRepo.Delete(taskID)
+	return s.taskRepo.Delete(taskID, deletedBy)
 }
 
 // GetTask retrieves a single task
@@ -336,11 +430,16 @@ func (s *TaskService) GetTask(taskID int, userID int) (*Task, error) {
 		return nil, errors.New("user is not a member of this project")
 	}
 
+	task.CustomFields, err = s.customFieldService.GetValues(task.ProjectID, projects.PermissionItemTask, task.ID)
+	if err != nil {
+		return nil, err
+	}
 	return task, nil
 }
 
-// GetProjectTasks retrieves all tasks for a project with pagination and optional status filter
-func (s *TaskService) GetProjectTasks(projectID int, statuses []string, page, pageSize int, userID int) ([]Task, int64, error) {
+// GetProjectTasks retrieves all tasks for a project with pagination and optional status filter.
+// fieldFilters filter on custom field values by field key.
+func (s *TaskService) GetProjectTasks(projectID int, statuses []string, fieldFilters map[string]string, page, pageSize int, userID int) ([]Task, int64, error) {
 	// Validate project exists
 	project, err := s.projectRepo.GetByID(projectID)
 	if err != nil {
@@ -364,7 +463,19 @@ func (s *TaskService) GetProjectTasks(projectID int, statuses []string, page, pa
 	var tasks []Task
 	var total int64
 
-	if len(statuses) == 1 {
+	filters, err := s.customFieldService.ParseFilters(projectID, projects.PermissionItemTask, fieldFilters)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(filters) > 0 {
+		for _, status := range statuses {
+			if !IsValidStatus(status) {
+				return nil, 0, errors.New("invalid status: " + status)
+			}
+		}
+		tasks, total, err = s.taskRepo.GetByProjectIDWithFieldFilters(projectID, statuses, filters, offset, pageSize)
+	} else if len(statuses) == 1 {
 		// Single status - use existing method
 		if !IsValidStatus(statuses[0]) {
 			return nil, 0, errors.New("invalid status")
@@ -386,6 +497,9 @@ func (s *TaskService) GetProjectTasks(projectID int, statuses []string, page, pa
 		return nil, 0, err
 	}
 
+	if err := s.loadCustomFields(projectID, tasks); err != nil {
+		return nil, 0, err
+	}
 	return tasks, total, nil
 }
 
@@ -0,0 +1,138 @@
+package trash
+
+import (
+	"time"
+)
+
+// Item types in the trash, spelled like status changes and role permissions
+const (
+	ItemTypeIdea          = "idea"
+	ItemTypeIssue         = "issue"
+	ItemTypeFeature       = "feature"
+	ItemTypeTask          = "task"
+	ItemTypeServiceTicket = "service-ticket"
+	ItemTypeWikiPage      = "wiki-page"
+)
+
+// TrashItem is a deleted item waiting to be restored or purged
+type TrashItem struct {
+	ItemType  string    `json:"itemType"`
+	ID        int       `json:"id"`
+	ProjectID int       `json:"projectId"`
+	RefNum    string    `json:"refNum,omitempty"`
+	Title     string    `json:"title"`
+	DeletedAt time.Time `json:"deletedAt"`
+	DeletedBy *int      `json:"deletedBy,omitempty"`
+	PurgeAt   time.Time `json:"purgeAt"` // When the item is purged unless restored
+}
+
+// linkColumn is a foreign key of an item that is cleared on restore when its target no longer exists
+type linkColumn struct {
+	column      string
+	targetTable string
+	softDeleted bool        // Targets in the trash count as missing
+	emptyValue  interface{} // Value stored when the link is cleared
+}
+
+// itemTable describes how the items of a type are stored
+type itemTable struct {
+	itemType  string
+	table     string
+	refColumn string       // Column shown as the reference number, empty when the type has none
+	spellings []string     // Item type spellings used by comments, follow-ups and item links
+	links     []linkColumn // Foreign keys checked on restore
+	itemLink  bool         // Has an ItemType/ItemID link to another item
+}
+
+var (
+	sprintLink    = linkColumn{column: "sprint_id", targetTable: "sprints", emptyValue: 0}
+	sprintPtrLink = linkColumn{column: "sprint_id", targetTable: "sprints", emptyValue: nil}
+	releaseLink   = linkColumn{column: "release_id", targetTable: "releases", emptyValue: nil}
+)
+
+// itemTables lists the item types that can be moved to the trash, in display order
+var itemTables = []itemTable{
+	{
+		itemType:  ItemTypeIdea,
+		table:     "ideas",
+		refColumn: "ref_num",
+		spellings: []string{"idea", "ideas"},
+		links:     []linkColumn{releaseLink},
+		itemLink:  true,
+	},
+	{
+		itemType:  ItemTypeIssue,
+		table:     "issues",
+		refColumn: "ref_num",
+		spellings: []string{"issue", "issues"},
+		links:     []linkColumn{sprintLink, releaseLink},
+		itemLink:  true,
+	},
+	{
+		itemType:  ItemTypeFeature,
+		table:     "features",
+		refColumn: "ref_num",
+		spellings: []string{"feature", "features"},
+		links: []linkColumn{
+			sprintLink,
+			releaseLink,
+			{column: "depends_on_feature_id", targetTable: "features", softDeleted: true, emptyValue: nil},
+		},
+		itemLink: true,
+	},
+	{
+		itemType:  ItemTypeTask,
+		table:     "tasks",
+		spellings: []string{"task", "tasks"},
+		links:     []linkColumn{sprintPtrLink, releaseLink},
+		itemLink:  true,
+	},
+	{
+		itemType:  ItemTypeServiceTicket,
+		table:     "service_tickets",
+		refColumn: "ref_num",
+		spellings: []string{"service-ticket", "service-tickets"},
+	},
+	{
+		itemType:  ItemTypeWikiPage,
+		table:     "wiki_pages",
+		spellings: []string{"wiki-page", "wiki-pages", "wiki"},
+		links: []linkColumn{
+			{column: "parent_id", targetTable: "wiki_pages", softDeleted: true, emptyValue: nil},
+		},
+	},
+}
+
+// tableForItemType returns the storage of an item type, or nil for types that have no trash
+func tableForItemType(itemType string) *itemTable {
+	for i := range itemTables {
+		if itemTables[i].itemType == itemType {
+			return &itemTables[i]
+		}
+	}
+	return nil
+}
+
+// tableForSpelling returns the storage of the item type an item link spelling refers to
+func tableForSpelling(spelling string) *itemTable {
+	for i := range itemTables {
+		for _, s := range itemTables[i].spellings {
+			if s == spelling {
+				return &itemTables[i]
+			}
+		}
+	}
+	return nil
+}
+
+// paginate returns the page of items between offset and offset+limit
+func paginate(items []TrashItem, offset, limit int) []TrashItem {
+	if offset >= len(items) {
+		return []TrashItem{}
+	}
+	end := offset + limit
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[offset:end]
+}
@@ -0,0 +1,279 @@
+package trash
+
+import (
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/repositories"
+	"gorm.io/gorm"
+)
+
+// TrashRepository reads and purges deleted rows, which are hidden from the item repositories
+type TrashRepository struct {
+	uow *repositories.UnitOfWork
+}
+
+func NewTrashRepository(uow *repositories.UnitOfWork) *TrashRepository {
+	return &TrashRepository{uow: uow}
+}
+
+type trashRow struct {
+	ID        int
+	ProjectID int
+	RefNum    string
+	Title     string
+	DeletedAt time.Time
+	DeletedBy *int
+}
+
+func (r *TrashRepository) deletedRows(t *itemTable) *gorm.DB {
+	refColumn := "''"
+	if t.refColumn != "" {
+		refColumn = t.refColumn
+	}
+	return r.uow.GetDB().Table(t.table).
+		Select("id, project_id, " + refColumn + " AS ref_num, title, deleted_at, deleted_by").
+		Where("deleted_at IS NOT NULL")
+}
+
+func toTrashItem(t *itemTable, row trashRow) TrashItem {
+	return TrashItem{
+		ItemType:  t.itemType,
+		ID:        row.ID,
+		ProjectID: row.ProjectID,
+		RefNum:    row.RefNum,
+		Title:     row.Title,
+		DeletedAt: row.DeletedAt,
+		DeletedBy: row.DeletedBy,
+	}
+}
+
+// ListDeleted returns the deleted items of a type in the project, most recently deleted first
+func (r *TrashRepository) ListDeleted(t *itemTable, projectID int) ([]TrashItem, error) {
+	var rows []trashRow
+	err := r.deletedRows(t).
+		Where("project_id = ?", projectID).
+		Order("deleted_at DESC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]TrashItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, toTrashItem(t, row))
+	}
+	return items, nil
+}
+
+// GetDeleted finds a deleted item of a type by ID
+func (r *TrashRepository) GetDeleted(t *itemTable, id int) (*TrashItem, error) {
+	var rows []trashRow
+	err := r.deletedRows(t).Where("id = ?", id).Limit(1).Scan(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	item := toTrashItem(t, rows[0])
+	return &item, nil
+}
+
+// ListExpiredIDs returns the IDs of items of a type deleted before the cutoff
+func (r *TrashRepository) ListExpiredIDs(t *itemTable, before time.Time) ([]int, error) {
+	var ids []int
+	err := r.uow.GetDB().Table(t.table).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// ListExpiredProjectIDs returns the IDs of projects deleted before the cutoff
+func (r *TrashRepository) ListExpiredProjectIDs(before time.Time) ([]int, error) {
+	var ids []int
+	err := r.uow.GetDB().Table("projects").
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// Restore takes an item out of the trash
+func (r *TrashRepository) Restore(t *itemTable, id int) error {
+	return r.uow.GetDB().Table(t.table).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"deleted_by": nil,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// ClearMissingLinks clears the links of an item whose targets were purged or are in the trash
+func (r *TrashRepository) ClearMissingLinks(t *itemTable, id int) error {
+	db := r.uow.GetDB()
+	for _, link := range t.links {
+		target := "SELECT id FROM " + link.targetTable
+		if link.softDeleted {
+			target += " WHERE deleted_at IS NULL"
+		}
+		query := db.Table(t.table).
+			Where("id = ?", id).
+			Where(link.column + " IS NOT NULL").
+			Where(link.column + " NOT IN (" + target + ")")
+		if link.emptyValue != nil {
+			query = query.Where(link.column+" <> ?", link.emptyValue)
+		}
+		if err := query.Update(link.column, link.emptyValue).Error; err != nil {
+			return err
+		}
+	}
+
+	if !t.itemLink {
+		return nil
+	}
+
+	var row struct {
+		ItemType string
+		ItemID   *int
+	}
+	if err := db.Table(t.table).Select("item_type, item_id").Where("id = ?", id).Scan(&row).Error; err != nil {
+		return err
+	}
+	if row.ItemID == nil {
+		return nil
+	}
+	if target := tableForSpelling(row.ItemType); target != nil {
+		var count int64
+		err := db.Table(target.table).Where("id = ? AND deleted_at IS NULL", *row.ItemID).Count(&count).Error
+		if err != nil || count > 0 {
+			return err
+		}
+	}
+	err := db.Exec("DELETE FROM item_links WHERE link_type = 'child-of' AND source_type = ? AND source_id = ?", t.itemType, id).Error
+	if err != nil {
+		return err
+	}
+	return db.Table(t.table).Where("id = ?", id).Updates(map[string]interface{}{
+		"item_type": "",
+		"item_id":   nil,
+	}).Error
+}
+
+// IsWikiSlugTaken checks if a wiki page outside the trash uses the slug
+func (r *TrashRepository) IsWikiSlugTaken(projectID int, slug string, excludeID int) (bool, error) {
+	var count int64
+	err := r.uow.GetDB().Table("wiki_pages").
+		Where("project_id = ? AND slug = ? AND id <> ? AND deleted_at IS NULL", projectID, slug, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetWikiSlug returns the slug of a wiki page, including pages in the trash
+func (r *TrashRepository) GetWikiSlug(id int) (string, error) {
+	var slugs []string
+	err := r.uow.GetDB().Table("wiki_pages").Where("id = ?", id).Pluck("slug", &slugs).Error
+	if err != nil || len(slugs) == 0 {
+		return "", err
+	}
+	return slugs[0], nil
+}
+
+// Purge permanently deletes an item with its history and clears the links of other items to it
+func (r *TrashRepository) Purge(t *itemTable, id int) error {
+	db := r.uow.GetDB()
+
+	for _, table := range []string{"comments", "item_follow_ups"} {
+		if err := db.Exec("DELETE FROM "+table+" WHERE item_type IN ? AND item_id = ?", t.spellings, id).Error; err != nil {
+			return err
+		}
+	}
+	for _, table := range []string{"status_changes", "custom_field_values", "custom_field_changes"} {
+		if err := db.Exec("DELETE FROM "+table+" WHERE item_type = ? AND item_id = ?", t.itemType, id).Error; err != nil {
+			return err
+		}
+	}
+	err := db.Exec("DELETE FROM item_links WHERE (source_type = ? AND source_id = ?) OR (target_type = ? AND target_id = ?)", t.itemType, id, t.itemType, id).Error
+	if err != nil {
+		return err
+	}
+	err = db.Exec("DELETE FROM user_daily_time_logs WHERE user_daily_item_id IN (SELECT id FROM user_daily_items WHERE item_type = ? AND item_id = ?)", t.itemType, id).Error
+	if err != nil {
+		return err
+	}
+	if err := db.Exec("DELETE FROM user_daily_items WHERE item_type = ? AND item_id = ?", t.itemType, id).Error; err != nil {
+		return err
+	}
+
+	switch t.itemType {
+	case ItemTypeWikiPage:
+		if err := db.Exec("DELETE FROM wiki_page_changes WHERE wiki_page_id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := db.Exec("UPDATE wiki_pages SET parent_id = NULL WHERE parent_id = ?", id).Error; err != nil {
+			return err
+		}
+	case ItemTypeFeature, ItemTypeIssue:
+		if err := db.Exec("DELETE FROM wiki_page_changes WHERE item_type = ? AND item_id = ?", t.itemType, id).Error; err != nil {
+			return err
+		}
+	}
+	if t.itemType == ItemTypeFeature {
+		// Dependent features fall back to their next oldest dependency
+		err := db.Exec("UPDATE features SET depends_on_feature_id = (SELECT l.source_id FROM item_links l WHERE l.link_type = 'blocks' AND l.source_type = ? AND l.target_type = ? AND l.target_id = features.id ORDER BY l.created_at, l.id LIMIT 1) WHERE depends_on_feature_id = ?",
+			ItemTypeFeature, ItemTypeFeature, id).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, other := range itemTables {
+		if !other.itemLink {
+			continue
+		}
+		err := db.Exec("UPDATE "+other.table+" SET item_type = '', item_id = NULL WHERE item_type IN ? AND item_id = ?", t.spellings, id).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return db.Exec("DELETE FROM "+t.table+" WHERE id = ?", id).Error
+}
+
+// PurgeProject permanently deletes a project with everything that belongs to it
+func (r *TrashRepository) PurgeProject(projectID int) error {
+	db := r.uow.GetDB()
+
+	for _, t := range itemTables {
+		for _, table := range []string{"comments", "item_follow_ups"} {
+			err := db.Exec("DELETE FROM "+table+" WHERE item_type IN ? AND item_id IN (SELECT id FROM "+t.table+" WHERE project_id = ?)", t.spellings, projectID).Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	statements := []string{
+		"DELETE FROM review_items WHERE review_id IN (SELECT id FROM reviews WHERE project_id = ?)",
+		"DELETE FROM project_role_permissions WHERE role_id IN (SELECT id FROM project_roles WHERE project_id = ?)",
+		"DELETE FROM project_invitation_acceptances WHERE project_id = ?",
+		"DELETE FROM user_daily_time_logs WHERE project_id = ?",
+		"UPDATE user_preferences SET default_project_id = NULL WHERE default_project_id = ?",
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement, projectID).Error; err != nil {
+			return err
+		}
+	}
+
+	tables := []string{
+		"user_daily_items", "reviews", "status_changes", "wiki_page_changes",
+		"custom_field_values", "custom_field_changes", "custom_fields", "item_links",
+		"wiki_pages", "tasks", "issues", "features", "ideas", "service_tickets",
+		"sprints", "releases", "status_flows", "sequence_numbers", "sequences",
+		"project_settings", "project_invitations", "project_groups", "project_members", "project_roles",
+	}
+	for _, table := range tables {
+		if err := db.Exec("DELETE FROM "+table+" WHERE project_id = ?", projectID).Error; err != nil {
+			return err
+		}
+	}
+
+	return db.Exec("DELETE FROM projects WHERE id = ?", projectID).Error
+}
@@ -0,0 +1,227 @@
+package trash
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/projects"
+	"github.com/dannyswat/pjeasy/internal/repositories"
+)
+
+type TrashService struct {
+	uowFactory  *repositories.UnitOfWorkFactory
+	trashRepo   *TrashRepository
+	projectRepo *projects.ProjectRepository
+	memberRepo  *projects.ProjectMemberRepository
+	retention   time.Duration
+}
+
+func NewTrashService(uowFactory *repositories.UnitOfWorkFactory, trashRepo *TrashRepository, projectRepo *projects.ProjectRepository, memberRepo *projects.ProjectMemberRepository, retention time.Duration) *TrashService {
+	return &TrashService{
+		uowFactory:  uowFactory,
+		trashRepo:   trashRepo,
+		projectRepo: projectRepo,
+		memberRepo:  memberRepo,
+		retention:   retention,
+	}
+}
+
+// ListTrash returns the deleted items of a project, most recently deleted first.
+// Only item types the user's project role can delete are listed.
+func (s *TrashService) ListTrash(projectID int, page, pageSize int, userID int) ([]TrashItem, int64, error) {
+	var items []TrashItem
+	for i := range itemTables {
+		t := &itemTables[i]
+		allowed, err := s.memberRepo.HasPermission(projectID, userID, t.itemType, projects.PermissionActionDelete)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !allowed {
+			continue
+		}
+
+		deleted, err := s.trashRepo.ListDeleted(t, projectID)
+		if err != nil {
+			return nil, 0, err
+		}
+		items = append(items, deleted...)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	for i := range items {
+		items[i].PurgeAt = items[i].DeletedAt.Add(s.retention)
+	}
+
+	offset := (page - 1) * pageSize
+	return paginate(items, offset, pageSize), int64(len(items)), nil
+}
+
+// getDeletedItem finds an item of the project in the trash
+func (s *TrashService) getDeletedItem(projectID int, itemType string, itemID int) (*itemTable, *TrashItem, error) {
+	t := tableForItemType(itemType)
+	if t == nil {
+		return nil, nil, errors.New("invalid item type")
+	}
+
+	item, err := s.trashRepo.GetDeleted(t, itemID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if item == nil || item.ProjectID != projectID {
+		return nil, nil, errors.New("item not found in trash")
+	}
+	return t, item, nil
+}
+
+// RestoreItem takes an item out of the trash. Links to sprints, releases and items
+// that were purged or are still in the trash are cleared.
+func (s *TrashService) RestoreItem(projectID int, itemType string, itemID int, userID int) (*TrashItem, error) {
+	if err := s.projectRepo.EnsureWritable(projectID); err != nil {
+		return nil, err
+	}
+
+	t, item, err := s.getDeletedItem(projectID, itemType, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed, err := s.memberRepo.HasPermission(projectID, userID, t.itemType, projects.PermissionActionDelete)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, projects.PermissionDeniedError(t.itemType, projects.PermissionActionDelete)
+	}
+
+	if t.itemType == ItemTypeWikiPage {
+		slug, err := s.trashRepo.GetWikiSlug(itemID)
+		if err != nil {
+			return nil, err
+		}
+		taken, err := s.trashRepo.IsWikiSlugTaken(projectID, slug, itemID)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, errors.New("another wiki page already uses the slug " + slug)
+		}
+	}
+
+	uow := s.uowFactory.NewUnitOfWork()
+	if err := uow.BeginTransaction(); err != nil {
+		return nil, err
+	}
+	defer uow.RollbackTransactionIfError()
+
+	trashRepo := NewTrashRepository(uow)
+	if err := trashRepo.Restore(t, itemID); err != nil {
+		return nil, err
+	}
+	if err := trashRepo.ClearMissingLinks(t, itemID); err != nil {
+		return nil, err
+	}
+
+	if err := uow.CommitTransaction(); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// PurgeItem permanently deletes an item in the trash. Only project admins can purge.
+func (s *TrashService) PurgeItem(projectID int, itemType string, itemID int, userID int) error {
+	if err := s.projectRepo.EnsureWritable(projectID); err != nil {
+		return err
+	}
+
+	isAdmin, err := s.memberRepo.IsUserAdmin(projectID, userID)
+	if err != nil {
+		return err
+	}
+	if !isAdmin {
+		return errors.New("only project admins can purge items from the trash")
+	}
+
+	t, _, err := s.getDeletedItem(projectID, itemType, itemID)
+	if err != nil {
+		return err
+	}
+
+	return s.purge(t, itemID)
+}
+
+func (s *TrashService) purge(t *itemTable, itemID int) error {
+	uow := s.uowFactory.NewUnitOfWork()
+	if err := uow.BeginTransaction(); err != nil {
+		return err
+	}
+	defer uow.RollbackTransactionIfError()
+
+	if err := NewTrashRepository(uow).Purge(t, itemID); err != nil {
+		return err
+	}
+
+	return uow.CommitTransaction()
+}
+
+// PurgeExpired permanently deletes the projects and items that stayed in the trash longer than the retention period
+func (s *TrashService) PurgeExpired() error {
+	before := time.Now().Add(-s.retention)
+
+	projectIDs, err := s.trashRepo.ListExpiredProjectIDs(before)
+	if err != nil {
+		return err
+	}
+	for _, projectID := range projectIDs {
+		if err := s.purgeProject(projectID); err != nil {
+			return err
+		}
+	}
+
+	for i := range itemTables {
+		t := &itemTables[i]
+		ids, err := s.trashRepo.ListExpiredIDs(t, before)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := s.purge(t, id); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *TrashService) purgeProject(projectID int) error {
+	uow := s.uowFactory.NewUnitOfWork()
+	if err := uow.BeginTransaction(); err != nil {
+		return err
+	}
+	defer uow.RollbackTransactionIfError()
+
+	if err := NewTrashRepository(uow).PurgeProject(projectID); err != nil {
+		return err
+	}
+
+	return uow.CommitTransaction()
+}
+
+// StartPurging purges expired trash in the background at the given interval
+func (s *TrashService) StartPurging(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := s.PurgeExpired(); err != nil {
+				log.Printf("failed to purge expired trash: %v", err)
+			}
+		}
+	}()
+}
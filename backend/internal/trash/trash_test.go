@@ -0,0 +1,73 @@
+package trash
+
+import (
+	"testing"
+
+	"github.com/dannyswat/pjeasy/internal/projects"
+)
+
+func TestItemTablesUsePermissionItemTypes(t *testing.T) {
+	for _, table := range itemTables {
+		if !projects.IsValidPermission(table.itemType, projects.PermissionActionDelete) {
+			t.Fatalf("item type %q has no delete permission", table.itemType)
+		}
+	}
+}
+
+func TestTableForSpelling(t *testing.T) {
+	tests := []struct {
+		spelling string
+		want     string
+	}{
+		{spelling: "ideas", want: "ideas"},
+		{spelling: "idea", want: "ideas"},
+		{spelling: "service-tickets", want: "service_tickets"},
+		{spelling: "wiki", want: "wiki_pages"},
+		{spelling: "epic", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spelling, func(t *testing.T) {
+			got := ""
+			if table := tableForSpelling(tt.spelling); table != nil {
+				got = table.table
+			}
+			if got != tt.want {
+				t.Fatalf("tableForSpelling(%q) = %q, want %q", tt.spelling, got, tt.want)
+			}
+		})
+	}
+
+	if tableForItemType("sprint") != nil {
+		t.Fatalf("tableForItemType(sprint) returned a table for a type without a trash")
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	items := []TrashItem{{ID: 1}, {ID: 2}, {ID: 3}}
+
+	tests := []struct {
+		name   string
+		offset int
+		limit  int
+		want   []int
+	}{
+		{name: "first page", offset: 0, limit: 2, want: []int{1, 2}},
+		{name: "last page", offset: 2, limit: 2, want: []int{3}},
+		{name: "past the end", offset: 3, limit: 2, want: []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := paginate(items, tt.offset, tt.limit)
+			if len(page) != len(tt.want) {
+				t.Fatalf("paginate() returned %d items, want %d", len(page), len(tt.want))
+			}
+			for i, item := range page {
+				if item.ID != tt.want[i] {
+					t.Fatalf("paginate()[%d].ID = %d, want %d", i, item.ID, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -89,6 +89,7 @@ type UserDailyService struct {
 	itemRepo       *UserDailyItemRepository
 	timeLogRepo    *UserDailyTimeLogRepository
 	projectRepo    *projects.ProjectRepository
+	settingService *projects.ProjectSettingService
 	memberRepo     *projects.ProjectMemberRepository
 	featureRepo    *features.FeatureRepository
 	issueRepo      *issues.IssueRepository
@@ -98,11 +99,12 @@ type UserDailyService struct {
 	taskService    *tasks.TaskService
 }
 
-func NewUserDailyService(itemRepo *UserDailyItemRepository, timeLogRepo *UserDailyTimeLogRepository, projectRepo *projects.ProjectRepository, memberRepo *projects.ProjectMemberRepository, featureRepo *features.FeatureRepository, issueRepo *issues.IssueRepository, taskRepo *tasks.TaskRepository, featureService *features.FeatureService, issueService *issues.IssueService, taskService *tasks.TaskService) *UserDailyService {
+func NewUserDailyService(itemRepo *UserDailyItemRepository, timeLogRepo *UserDailyTimeLogRepository, projectRepo *projects.ProjectRepository, settingService *projects.ProjectSettingService, memberRepo *projects.ProjectMemberRepository, featureRepo *features.FeatureRepository, issueRepo *issues.IssueRepository, taskRepo *tasks.TaskRepository, featureService *features.FeatureService, issueService *issues.IssueService, taskService *tasks.TaskService) *UserDailyService {
 	return &UserDailyService{
 		itemRepo:       itemRepo,
 		timeLogRepo:    timeLogRepo,
 		projectRepo:    projectRepo,
+		settingService: settingService,
 		memberRepo:     memberRepo,
 		featureRepo:    featureRepo,
 		issueRepo:      issueRepo,
@@ -174,7 +176,7 @@ func (s *UserDailyService) GetBoard(userID int, workDate time.Time) (*UserDailyB
 		})
 	}
 
-	candidates, err := s.listCandidateItems(userID, existingKeys)
+	candidates, err := s.listCandidateItems(userID, workDate, existingKeys)
 	if err != nil {
 		return nil, err
 	}
@@ -210,6 +212,9 @@ func (s *UserDailyService) AddItem(userID int, workDate time.Time, itemType stri
 	if err != nil {
 		return nil, err
 	}
+	if err := s.projectRepo.EnsureWritable(source.ProjectID); err != nil {
+		return nil, err
+	}
 	now := time.Now().UTC()
 	item := &UserDailyItem{
 		UserID:    userID,
@@ -245,6 +250,9 @@ func (s *UserDailyService) RemoveItem(userID int, dailyItemID int) error {
 	if item == nil || item.UserID != userID {
 		return errors.New("daily item not found")
 	}
+	if err := s.projectRepo.EnsureWritable(item.ProjectID); err != nil {
+		return err
+	}
 	if err := s.timeLogRepo.DeleteByDailyItemID(item.ID); err != nil {
 		return err
 	}
@@ -305,6 +313,9 @@ func (s *UserDailyService) CreateTimeLog(userID int, dailyItemID int, startUnit
 	if item == nil || item.UserID != userID {
 		return nil, errors.New("daily item not found")
 	}
+	if err := s.projectRepo.EnsureWritable(item.ProjectID); err != nil {
+		return nil, err
+	}
 	if err := validateDurationUnits(durationUnits); err != nil {
 		return nil, err
 	}
@@ -342,6 +353,9 @@ func (s *UserDailyService) UpdateTimeLog(userID int, timeLogID int, startUnit in
 	if log == nil || log.UserID != userID {
 		return nil, errors.New("time log not found")
 	}
+	if err := s.projectRepo.EnsureWritable(log.ProjectID); err != nil {
+		return nil, err
+	}
 	if err := validateDurationUnits(durationUnits); err != nil {
 		return nil, err
 	}
@@ -379,6 +393,9 @@ func (s *UserDailyService) DeleteTimeLog(userID int, timeLogID int) error {
 	if log == nil || log.UserID != userID {
 		return errors.New("time log not found")
 	}
+	if err := s.projectRepo.EnsureWritable(log.ProjectID); err != nil {
+		return err
+	}
 	if err := s.timeLogRepo.Delete(log.ID); err != nil {
 		return err
 	}
@@ -594,7 +611,8 @@ func (s *UserDailyService) getProjectName(projectID int) (string, error) {
 	return project.Name, nil
 }
 
-func (s *UserDailyService) listCandidateItems(userID int, existingKeys map[string]struct{}) ([]UserDailyCandidate, error) {
+// listCandidateItems suggests the user's open items, leaving out projects for which the day is not a working day
+func (s *UserDailyService) listCandidateItems(userID int, workDate time.Time, existingKeys map[string]struct{}) ([]UserDailyCandidate, error) {
 	projectsList, _, err := s.projectRepo.GetByUserID(userID, false, 0, 200)
 	if err != nil {
 		return nil, err
@@ -605,6 +623,14 @@ func (s *UserDailyService) listCandidateItems(userID int, existingKeys map[strin
 		projectID := project.ID
 		projectName := project.Name
 
+		settings, err := s.settingService.GetProjectSettings(projectID)
+		if err != nil {
+			return nil, err
+		}
+		if !settings.IsWorkingDay(workDate) {
+			continue
+		}
+
 		projectTasks, err := s.taskRepo.GetByProjectAndAssigneeOrderByDeadline(projectID, userID, 100, []string{tasks.TaskStatusCompleted, tasks.TaskStatusClosed, tasks.TaskStatusRejected})
 		if err != nil {
 			return nil, err
@@ -0,0 +1,31 @@
+package user_groups
+
+import "time"
+
+// UserGroup is a system-wide set of users that can be granted access to projects
+type UserGroup struct {
+	ID          int       `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name        string    `gorm:"not null;size:100;uniqueIndex" json:"name"`
+	Description string    `gorm:"size:500" json:"description"`
+	CreatedBy   int       `gorm:"not null" json:"createdBy"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// TableName specifies the table name for GORM
+func (UserGroup) TableName() string {
+	return "user_groups"
+}
+
+// UserGroupMember links a user to a group
+type UserGroupMember struct {
+	GroupID int       `gorm:"primaryKey" json:"groupId"`
+	UserID  int       `gorm:"primaryKey;index" json:"userId"`
+	AddedAt time.Time `gorm:"not null" json:"addedAt"`
+	AddedBy int       `gorm:"not null" json:"addedBy"`
+}
+
+// TableName specifies the table name for GORM
+func (UserGroupMember) TableName() string {
+	return "user_group_members"
+}
@@ -0,0 +1,132 @@
+package user_groups
+
+import (
+	"strings"
+
+	"github.com/dannyswat/pjeasy/internal/repositories"
+	"gorm.io/gorm"
+)
+
+type UserGroupRepository struct {
+	uow *repositories.UnitOfWork
+}
+
+func NewUserGroupRepository(uow *repositories.UnitOfWork) *UserGroupRepository {
+	return &UserGroupRepository{uow: uow}
+}
+
+// Create adds a group
+func (r *UserGroupRepository) Create(group *UserGroup) error {
+	return r.uow.GetDB().Create(group).Error
+}
+
+// GetByID finds a group by ID
+func (r *UserGroupRepository) GetByID(groupID int) (*UserGroup, error) {
+	var group UserGroup
+	err := r.uow.GetDB().Where("id = ?", groupID).First(&group).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &group, err
+}
+
+// GetByName finds a group by name, ignoring case
+func (r *UserGroupRepository) GetByName(name string) (*UserGroup, error) {
+	var group UserGroup
+	err := r.uow.GetDB().Where("LOWER(name) = LOWER(?)", name).First(&group).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &group, err
+}
+
+// Search returns groups whose name contains the query
+func (r *UserGroupRepository) Search(query string, offset, limit int) ([]UserGroup, int64, error) {
+	var groups []UserGroup
+	var total int64
+
+	db := r.uow.GetDB().Model(&UserGroup{})
+	if trimmedQuery := strings.TrimSpace(query); trimmedQuery != "" {
+		db = db.Where("name ILIKE ?", "%"+trimmedQuery+"%")
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := db.Order("name ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&groups).Error
+
+	return groups, total, err
+}
+
+// Update updates a group
+func (r *UserGroupRepository) Update(group *UserGroup) error {
+	return r.uow.GetDB().Save(group).Error
+}
+
+// Delete removes a group and its members
+func (r *UserGroupRepository) Delete(groupID int) error {
+	db := r.uow.GetDB()
+	if err := db.Where("group_id = ?", groupID).Delete(&UserGroupMember{}).Error; err != nil {
+		return err
+	}
+	return db.Delete(&UserGroup{}, groupID).Error
+}
+
+// AddMember adds a user to a group
+func (r *UserGroupRepository) AddMember(member *UserGroupMember) error {
+	return r.uow.GetDB().Create(member).Error
+}
+
+// RemoveMember removes a user from a group
+func (r *UserGroupRepository) RemoveMember(groupID, userID int) error {
+	return r.uow.GetDB().Where("group_id = ? AND user_id = ?", groupID, userID).
+		Delete(&UserGroupMember{}).Error
+}
+
+// GetMembers returns the members of a group
+func (r *UserGroupRepository) GetMembers(groupID int) ([]UserGroupMember, error) {
+	var members []UserGroupMember
+	err := r.uow.GetDB().Where("group_id = ?", groupID).
+		Order("added_at ASC").
+		Find(&members).Error
+	return members, err
+}
+
+// IsMember checks if a user belongs to a group
+func (r *UserGroupRepository) IsMember(groupID, userID int) (bool, error) {
+	var count int64
+	err := r.uow.GetDB().Model(&UserGroupMember{}).
+		Where("group_id = ? AND user_id = ?", groupID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CountMembers returns the number of members of each group
+func (r *UserGroupRepository) CountMembers(groupIDs []int) (map[int]int64, error) {
+	var rows []struct {
+		GroupID int
+		Count   int64
+	}
+	counts := make(map[int]int64, len(groupIDs))
+	if len(groupIDs) == 0 {
+		return counts, nil
+	}
+
+	err := r.uow.GetDB().Model(&UserGroupMember{}).
+		Select("group_id, COUNT(*) AS count").
+		Where("group_id IN ?", groupIDs).
+		Group("group_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.GroupID] = row.Count
+	}
+	return counts, nil
+}
@@ -0,0 +1,261 @@
+package user_groups
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/repositories"
+	"github.com/dannyswat/pjeasy/internal/users"
+)
+
+// GroupChangeHandler is notified when a group changes so that grants and caches built on it stay current
+type GroupChangeHandler interface {
+	GroupMembersChanged(groupID int)
+	GroupDeleted(uow *repositories.UnitOfWork, groupID int) error // Runs in the unit of work deleting the group
+}
+
+type UserGroupService struct {
+	uowFactory    *repositories.UnitOfWorkFactory
+	groupRepo     *UserGroupRepository
+	userRepo      *users.UserRepository
+	changeHandler GroupChangeHandler
+}
+
+func NewUserGroupService(uowFactory *repositories.UnitOfWorkFactory, groupRepo *UserGroupRepository, userRepo *users.UserRepository) *UserGroupService {
+	return &UserGroupService{
+		uowFactory: uowFactory,
+		groupRepo:  groupRepo,
+		userRepo:   userRepo,
+	}
+}
+
+// SetGroupChangeHandler sets the handler notified when group membership changes or a group is deleted
+func (s *UserGroupService) SetGroupChangeHandler(handler GroupChangeHandler) {
+	s.changeHandler = handler
+}
+
+// GroupWithMemberCount represents a group with the number of its members
+type GroupWithMemberCount struct {
+	Group       UserGroup `json:"group"`
+	MemberCount int64     `json:"memberCount"`
+}
+
+// GroupMemberWithUser represents a group member with user details
+type GroupMemberWithUser struct {
+	Member UserGroupMember `json:"member"`
+	User   *users.User     `json:"user"`
+}
+
+// ListGroups searches groups by name
+func (s *UserGroupService) ListGroups(query string, page, pageSize int) ([]GroupWithMemberCount, int64, error) {
+	offset := (page - 1) * pageSize
+	groups, total, err := s.groupRepo.Search(query, offset, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	groupIDs := make([]int, 0, len(groups))
+	for _, group := range groups {
+		groupIDs = append(groupIDs, group.ID)
+	}
+	counts, err := s.groupRepo.CountMembers(groupIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result := make([]GroupWithMemberCount, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, GroupWithMemberCount{
+			Group:       group,
+			MemberCount: counts[group.ID],
+		})
+	}
+	return result, total, nil
+}
+
+// GetGroup returns a group by ID
+func (s *UserGroupService) GetGroup(groupID int) (*UserGroup, error) {
+	group, err := s.groupRepo.GetByID(groupID)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, errors.New("group not found")
+	}
+	return group, nil
+}
+
+// GetGroupMembers returns the members of a group with their user details
+func (s *UserGroupService) GetGroupMembers(groupID int) ([]GroupMemberWithUser, error) {
+	if _, err := s.GetGroup(groupID); err != nil {
+		return nil, err
+	}
+
+	members, err := s.groupRepo.GetMembers(groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]GroupMemberWithUser, 0, len(members))
+	for _, member := range members {
+		user, err := s.userRepo.GetByID(member.UserID)
+		if err != nil || user == nil {
+			continue // Skip if user not found
+		}
+		result = append(result, GroupMemberWithUser{
+			Member: member,
+			User:   user,
+		})
+	}
+	return result, nil
+}
+
+// CreateGroup adds a new group
+func (s *UserGroupService) CreateGroup(name, description string, createdBy int) (*UserGroup, error) {
+	name, err := s.validateName(0, name)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	group := &UserGroup{
+		Name:        name,
+		Description: strings.TrimSpace(description),
+		CreatedBy:   createdBy,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.groupRepo.Create(group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// UpdateGroup renames a group or changes its description
+func (s *UserGroupService) UpdateGroup(groupID int, name, description string) (*UserGroup, error) {
+	group, err := s.GetGroup(groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err = s.validateName(groupID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	group.Name = name
+	group.Description = strings.TrimSpace(description)
+	group.UpdatedAt = time.Now()
+	if err := s.groupRepo.Update(group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// DeleteGroup removes a group, its members and every project access granted to it
+func (s *UserGroupService) DeleteGroup(groupID int) error {
+	if _, err := s.GetGroup(groupID); err != nil {
+		return err
+	}
+
+	uow := s.uowFactory.NewUnitOfWork()
+	if err := uow.BeginTransaction(); err != nil {
+		return err
+	}
+	defer uow.RollbackTransactionIfError()
+
+	if s.changeHandler != nil {
+		if err := s.changeHandler.GroupDeleted(uow, groupID); err != nil {
+			return err
+		}
+	}
+	if err := NewUserGroupRepository(uow).Delete(groupID); err != nil {
+		return err
+	}
+	if err := uow.CommitTransaction(); err != nil {
+		return err
+	}
+
+	// The group no longer grants access, so cached access built on it is refreshed
+	if s.changeHandler != nil {
+		s.changeHandler.GroupMembersChanged(groupID)
+	}
+	return nil
+}
+
+// AddMemberByLoginID adds a user to a group using their login ID
+func (s *UserGroupService) AddMemberByLoginID(groupID int, loginID string, addedBy int) (*GroupMemberWithUser, error) {
+	if _, err := s.GetGroup(groupID); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByLoginID(strings.TrimSpace(loginID))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	isMember, err := s.groupRepo.IsMember(groupID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if isMember {
+		return nil, errors.New("user is already a member of this group")
+	}
+
+	member := &UserGroupMember{
+		GroupID: groupID,
+		UserID:  user.ID,
+		AddedAt: time.Now(),
+		AddedBy: addedBy,
+	}
+	if err := s.groupRepo.AddMember(member); err != nil {
+		return nil, err
+	}
+
+	s.notifyMembersChanged(groupID)
+	return &GroupMemberWithUser{Member: *member, User: user}, nil
+}
+
+// RemoveMember removes a user from a group
+func (s *UserGroupService) RemoveMember(groupID, userID int) error {
+	isMember, err := s.groupRepo.IsMember(groupID, userID)
+	if err != nil {
+		return err
+	}
+	if !isMember {
+		return errors.New("user is not a member of this group")
+	}
+
+	if err := s.groupRepo.RemoveMember(groupID, userID); err != nil {
+		return err
+	}
+
+	s.notifyMembersChanged(groupID)
+	return nil
+}
+
+func (s *UserGroupService) notifyMembersChanged(groupID int) {
+	if s.changeHandler != nil {
+		s.changeHandler.GroupMembersChanged(groupID)
+	}
+}
+
+func (s *UserGroupService) validateName(groupID int, name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("group name is required")
+	}
+
+	existing, err := s.groupRepo.GetByName(name)
+	if err != nil {
+		return "", err
+	}
+	if existing != nil && existing.ID != groupID {
+		return "", errors.New("a group with this name already exists")
+	}
+	return name, nil
+}
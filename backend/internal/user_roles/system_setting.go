@@ -0,0 +1,23 @@
+package userroles
+
+import (
+	"time"
+)
+
+// SystemSetting stores a system-wide setting managed by system admins
+type SystemSetting struct {
+	Key       string    `gorm:"primaryKey;size:100" json:"key"`
+	Value     string    `gorm:"type:text" json:"value"`
+	UpdatedBy int       `json:"updatedBy"`
+	UpdatedAt time.Time `gorm:"not null" json:"updatedAt"`
+}
+
+// TableName specifies the table name for GORM
+func (SystemSetting) TableName() string {
+	return "system_settings"
+}
+
+// SystemSetting keys
+const (
+	SettingRequireTwoFactor = "require_two_factor"
+)
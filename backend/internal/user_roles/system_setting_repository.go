@@ -0,0 +1,29 @@
+package userroles
+
+import (
+	"github.com/dannyswat/pjeasy/internal/repositories"
+	"gorm.io/gorm"
+)
+
+type SystemSettingRepository struct {
+	uow *repositories.UnitOfWork
+}
+
+func NewSystemSettingRepository(uow *repositories.UnitOfWork) *SystemSettingRepository {
+	return &SystemSettingRepository{uow: uow}
+}
+
+// GetByKey finds a system setting by key
+func (r *SystemSettingRepository) GetByKey(key string) (*SystemSetting, error) {
+	var setting SystemSetting
+	err := r.uow.GetDB().Where("key = ?", key).First(&setting).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &setting, err
+}
+
+// Save creates or updates a system setting
+func (r *SystemSettingRepository) Save(setting *SystemSetting) error {
+	return r.uow.GetDB().Save(setting).Error
+}
@@ -0,0 +1,67 @@
+package userroles
+
+import (
+	"strconv"
+	"time"
+)
+
+type SystemSettingService struct {
+	settingRepo *SystemSettingRepository
+}
+
+func NewSystemSettingService(settingRepo *SystemSettingRepository) *SystemSettingService {
+	return &SystemSettingService{
+		settingRepo: settingRepo,
+	}
+}
+
+// SecuritySettings holds the system-wide security options
+type SecuritySettings struct {
+	RequireTwoFactor bool `json:"requireTwoFactor"`
+}
+
+// GetSecuritySettings returns the current security settings
+func (s *SystemSettingService) GetSecuritySettings() (*SecuritySettings, error) {
+	requireTwoFactor, err := s.getBool(SettingRequireTwoFactor)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SecuritySettings{
+		RequireTwoFactor: requireTwoFactor,
+	}, nil
+}
+
+// UpdateSecuritySettings saves the security settings
+func (s *SystemSettingService) UpdateSecuritySettings(settings *SecuritySettings, updatedBy int) error {
+	return s.setBool(SettingRequireTwoFactor, settings.RequireTwoFactor, updatedBy)
+}
+
+// IsTwoFactorRequired checks if every user must use two-factor authentication
+func (s *SystemSettingService) IsTwoFactorRequired() (bool, error) {
+	return s.getBool(SettingRequireTwoFactor)
+}
+
+func (s *SystemSettingService) getBool(key string) (bool, error) {
+	setting, err := s.settingRepo.GetByKey(key)
+	if err != nil {
+		return false, err
+	}
+	if setting == nil {
+		return false, nil
+	}
+	value, err := strconv.ParseBool(setting.Value)
+	if err != nil {
+		return false, nil
+	}
+	return value, nil
+}
+
+func (s *SystemSettingService) setBool(key string, value bool, updatedBy int) error {
+	return s.settingRepo.Save(&SystemSetting{
+		Key:       key,
+		Value:     strconv.FormatBool(value),
+		UpdatedBy: updatedBy,
+		UpdatedAt: time.Now(),
+	})
+}
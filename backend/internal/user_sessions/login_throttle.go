@@ -0,0 +1,140 @@
+package user_sessions
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LoginThrottle tracks failed logins for a login ID or an IP address
+type LoginThrottle struct {
+	Key           string     `gorm:"primaryKey;size:300" json:"key"`
+	Kind          string     `gorm:"not null;size:20;index" json:"kind"` // login_id, ip
+	Value         string     `gorm:"not null;size:255" json:"value"`
+	FailureCount  int        `gorm:"not null;default:0" json:"failureCount"`
+	LastFailureAt time.Time  `json:"lastFailureAt"`
+	LockedUntil   *time.Time `gorm:"index" json:"lockedUntil,omitempty"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+// TableName specifies the table name for GORM
+func (LoginThrottle) TableName() string {
+	return "login_throttles"
+}
+
+// LoginThrottle kinds
+const (
+	ThrottleKindLoginID = "login_id"
+	ThrottleKindIP      = "ip"
+)
+
+func throttleKey(kind, value string) string {
+	return kind + ":" + value
+}
+
+func normalizeThrottleLoginID(loginID string) string {
+	return strings.ToLower(strings.TrimSpace(loginID))
+}
+
+// IsLocked checks if the subject is temporarily locked out
+func (t *LoginThrottle) IsLocked(now time.Time) bool {
+	return t.LockedUntil != nil && now.Before(*t.LockedUntil)
+}
+
+// LoginThrottlePolicy controls backoff and lockout after failed logins
+type LoginThrottlePolicy struct {
+	FreeAttempts       int           // Failures allowed before backoff starts
+	BaseDelay          time.Duration // First backoff delay, doubled for every further failure
+	MaxDelay           time.Duration // Upper bound for the backoff delay
+	LockoutThreshold   int           // Failures per login ID before a temporary lockout
+	IPLockoutThreshold int           // Failures per IP address before a temporary lockout
+	LockoutDuration    time.Duration
+	ResetAfter         time.Duration // Failures older than this are forgotten
+}
+
+// DefaultLoginThrottlePolicy returns conservative defaults
+func DefaultLoginThrottlePolicy() *LoginThrottlePolicy {
+	return &LoginThrottlePolicy{
+		FreeAttempts:       3,
+		BaseDelay:          time.Second,
+		MaxDelay:           5 * time.Minute,
+		LockoutThreshold:   10,
+		IPLockoutThreshold: 50,
+		LockoutDuration:    15 * time.Minute,
+		ResetAfter:         time.Hour,
+	}
+}
+
+func (p *LoginThrottlePolicy) thresholdFor(kind string) int {
+	if kind == ThrottleKindIP {
+		return p.IPLockoutThreshold
+	}
+	return p.LockoutThreshold
+}
+
+// RetryAfter returns how long the subject must wait before the next attempt
+func (p *LoginThrottlePolicy) RetryAfter(t *LoginThrottle, now time.Time) time.Duration {
+	if t == nil {
+		return 0
+	}
+	if t.IsLocked(now) {
+		return t.LockedUntil.Sub(now)
+	}
+	if p.isStale(t, now) || t.FailureCount < p.FreeAttempts {
+		return 0
+	}
+
+	next := t.LastFailureAt.Add(p.backoffDelay(t.FailureCount - p.FreeAttempts))
+	if now.Before(next) {
+		return next.Sub(now)
+	}
+	return 0
+}
+
+// RegisterFailure counts a failure and reports whether it triggered a lockout
+func (p *LoginThrottlePolicy) RegisterFailure(t *LoginThrottle, now time.Time) bool {
+	if p.isStale(t, now) || (t.LockedUntil != nil && !t.IsLocked(now)) {
+		t.FailureCount = 0
+		t.LockedUntil = nil
+	}
+
+	t.FailureCount++
+	t.LastFailureAt = now
+	t.UpdatedAt = now
+
+	threshold := p.thresholdFor(t.Kind)
+	if threshold > 0 && t.FailureCount >= threshold && !t.IsLocked(now) {
+		lockedUntil := now.Add(p.LockoutDuration)
+		t.LockedUntil = &lockedUntil
+		return true
+	}
+	return false
+}
+
+func (p *LoginThrottlePolicy) isStale(t *LoginThrottle, now time.Time) bool {
+	return p.ResetAfter > 0 && now.Sub(t.LastFailureAt) > p.ResetAfter
+}
+
+func (p *LoginThrottlePolicy) backoffDelay(step int) time.Duration {
+	delay := p.BaseDelay
+	for i := 0; i < step && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// LoginThrottledError is returned when a login attempt is rejected by throttling
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *LoginThrottledError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("account temporarily locked, try again in %s", e.RetryAfter.Round(time.Second))
+	}
+	return fmt.Sprintf("too many failed login attempts, try again in %s", e.RetryAfter.Round(time.Second))
+}
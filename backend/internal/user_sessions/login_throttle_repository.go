@@ -0,0 +1,74 @@
+package user_sessions
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/repositories"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LoginThrottleRepository struct {
+	uow *repositories.UnitOfWork
+}
+
+func NewLoginThrottleRepository(uow *repositories.UnitOfWork) *LoginThrottleRepository {
+	return &LoginThrottleRepository{uow: uow}
+}
+
+func (r *LoginThrottleRepository) GetByKey(key string) (*LoginThrottle, error) {
+	var throttle LoginThrottle
+	err := r.uow.GetDB().Where("key = ?", key).First(&throttle).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+// GetForUpdate returns the throttle row locked for update, creating it when missing.
+// Must be called within a transaction.
+func (r *LoginThrottleRepository) GetForUpdate(kind, value string) (*LoginThrottle, error) {
+	key := throttleKey(kind, value)
+	err := r.uow.GetDB().Clauses(clause.OnConflict{DoNothing: true}).Create(&LoginThrottle{
+		Key:       key,
+		Kind:      kind,
+		Value:     value,
+		UpdatedAt: time.Now(),
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var throttle LoginThrottle
+	err = r.uow.GetDB().Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(&throttle).Error
+	if err != nil {
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+func (r *LoginThrottleRepository) Save(throttle *LoginThrottle) error {
+	return r.uow.GetDB().Save(throttle).Error
+}
+
+func (r *LoginThrottleRepository) DeleteByKey(key string) error {
+	return r.uow.GetDB().Where("key = ?", key).Delete(&LoginThrottle{}).Error
+}
+
+// GetLocked returns subjects that are currently locked out
+func (r *LoginThrottleRepository) GetLocked() ([]LoginThrottle, error) {
+	var throttles []LoginThrottle
+	err := r.uow.GetDB().Where("locked_until > ?", time.Now()).Order("locked_until DESC").Find(&throttles).Error
+	return throttles, err
+}
+
+// DeleteStale removes rows without recent failures or active lockouts
+func (r *LoginThrottleRepository) DeleteStale(olderThan time.Time) error {
+	return r.uow.GetDB().
+		Where("last_failure_at < ? AND (locked_until IS NULL OR locked_until < ?)", olderThan, time.Now()).
+		Delete(&LoginThrottle{}).Error
+}
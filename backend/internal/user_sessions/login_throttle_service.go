@@ -0,0 +1,155 @@
+package user_sessions
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/repositories"
+)
+
+type LoginThrottleService struct {
+	uowFactory   *repositories.UnitOfWorkFactory
+	throttleRepo *LoginThrottleRepository
+	policy       *LoginThrottlePolicy
+}
+
+func NewLoginThrottleService(uowFactory *repositories.UnitOfWorkFactory, throttleRepo *LoginThrottleRepository, policy *LoginThrottlePolicy) *LoginThrottleService {
+	if policy == nil {
+		policy = DefaultLoginThrottlePolicy()
+	}
+	return &LoginThrottleService{
+		uowFactory:   uowFactory,
+		throttleRepo: throttleRepo,
+		policy:       policy,
+	}
+}
+
+// Check returns a *LoginThrottledError when the login ID or IP address must wait
+func (s *LoginThrottleService) Check(loginID, ipAddress string) error {
+	now := time.Now()
+	var result *LoginThrottledError
+
+	for _, subject := range s.subjects(loginID, ipAddress) {
+		throttle, err := s.throttleRepo.GetByKey(throttleKey(subject.kind, subject.value))
+		if err != nil {
+			return err
+		}
+		retryAfter := s.policy.RetryAfter(throttle, now)
+		if retryAfter <= 0 {
+			continue
+		}
+		if result == nil || retryAfter > result.RetryAfter {
+			result = &LoginThrottledError{RetryAfter: retryAfter, Locked: throttle.IsLocked(now)}
+		}
+	}
+
+	if result != nil {
+		return result
+	}
+	return nil
+}
+
+// RecordFailure counts a failed attempt and returns the subjects locked out by it
+func (s *LoginThrottleService) RecordFailure(loginID, ipAddress string) ([]LoginThrottle, error) {
+	uow := s.uowFactory.NewUnitOfWork()
+	throttleRepo := NewLoginThrottleRepository(uow)
+
+	uow.BeginTransaction()
+	defer uow.RollbackTransactionIfError()
+
+	var locked []LoginThrottle
+	now := time.Now()
+	for _, subject := range s.subjects(loginID, ipAddress) {
+		throttle, err := throttleRepo.GetForUpdate(subject.kind, subject.value)
+		if err != nil {
+			return nil, err
+		}
+		if s.policy.RegisterFailure(throttle, now) {
+			locked = append(locked, *throttle)
+		}
+		if err := throttleRepo.Save(throttle); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := uow.CommitTransaction(); err != nil {
+		return nil, err
+	}
+	return locked, nil
+}
+
+// RecordSuccess clears the failure counter of the login ID
+func (s *LoginThrottleService) RecordSuccess(loginID string) error {
+	loginID = normalizeThrottleLoginID(loginID)
+	if loginID == "" {
+		return nil
+	}
+	return s.throttleRepo.DeleteByKey(throttleKey(ThrottleKindLoginID, loginID))
+}
+
+// Unlock clears the lockout of a login ID or IP address
+func (s *LoginThrottleService) Unlock(kind, value string) (*LoginThrottle, error) {
+	switch kind {
+	case ThrottleKindLoginID:
+		value = normalizeThrottleLoginID(value)
+	case ThrottleKindIP:
+	default:
+		return nil, errors.New("invalid lock type")
+	}
+	if value == "" {
+		return nil, errors.New("value is required")
+	}
+
+	throttle, err := s.throttleRepo.GetByKey(throttleKey(kind, value))
+	if err != nil {
+		return nil, err
+	}
+	if throttle == nil {
+		return nil, errors.New("no lock found")
+	}
+	if err := s.throttleRepo.DeleteByKey(throttle.Key); err != nil {
+		return nil, err
+	}
+	return throttle, nil
+}
+
+// ListLocked returns login IDs and IP addresses that are currently locked out
+func (s *LoginThrottleService) ListLocked() ([]LoginThrottle, error) {
+	return s.throttleRepo.GetLocked()
+}
+
+// PurgeStale deletes login IDs and IP addresses whose failures are forgotten and that are not locked
+func (s *LoginThrottleService) PurgeStale() error {
+	return s.throttleRepo.DeleteStale(time.Now().Add(-s.policy.ResetAfter))
+}
+
+// StartPurging deletes stale throttle rows in the background at the given interval
+func (s *LoginThrottleService) StartPurging(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := s.PurgeStale(); err != nil {
+				log.Printf("failed to purge stale login throttles: %v", err)
+			}
+		}
+	}()
+}
+
+type throttleSubject struct {
+	kind  string
+	value string
+}
+
+func (s *LoginThrottleService) subjects(loginID, ipAddress string) []throttleSubject {
+	subjects := make([]throttleSubject, 0, 2)
+	if loginID = normalizeThrottleLoginID(loginID); loginID != "" {
+		subjects = append(subjects, throttleSubject{kind: ThrottleKindLoginID, value: loginID})
+	}
+	if ipAddress != "" {
+		subjects = append(subjects, throttleSubject{kind: ThrottleKindIP, value: ipAddress})
+	}
+	return subjects
+}
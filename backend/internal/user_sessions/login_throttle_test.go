@@ -0,0 +1,75 @@
+package user_sessions
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginThrottlePolicyRetryAfter(t *testing.T) {
+	policy := DefaultLoginThrottlePolicy()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		failures int
+		elapsed  time.Duration
+		want     time.Duration
+	}{
+		{name: "within free attempts", failures: 2, elapsed: 0, want: 0},
+		{name: "first backoff", failures: 3, elapsed: 0, want: time.Second},
+		{name: "doubles per failure", failures: 5, elapsed: time.Second, want: 3 * time.Second},
+		{name: "capped at max delay", failures: 15, elapsed: 0, want: 5 * time.Minute},
+		{name: "backoff elapsed", failures: 4, elapsed: 3 * time.Second, want: 0},
+		{name: "failures forgotten", failures: 9, elapsed: 2 * time.Hour, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			throttle := &LoginThrottle{
+				Kind:          ThrottleKindLoginID,
+				FailureCount:  tt.failures,
+				LastFailureAt: now.Add(-tt.elapsed),
+			}
+			if got := policy.RetryAfter(throttle, now); got != tt.want {
+				t.Fatalf("RetryAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoginThrottlePolicyLockout(t *testing.T) {
+	policy := DefaultLoginThrottlePolicy()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	throttle := &LoginThrottle{Kind: ThrottleKindLoginID}
+
+	for i := 1; i < policy.LockoutThreshold; i++ {
+		if policy.RegisterFailure(throttle, now) {
+			t.Fatalf("RegisterFailure() locked after %d failures", i)
+		}
+	}
+	if !policy.RegisterFailure(throttle, now) {
+		t.Fatalf("RegisterFailure() did not lock after %d failures", policy.LockoutThreshold)
+	}
+	if got := policy.RetryAfter(throttle, now); got != policy.LockoutDuration {
+		t.Fatalf("RetryAfter() = %v, want %v", got, policy.LockoutDuration)
+	}
+
+	// A failure during the lockout does not extend it
+	if policy.RegisterFailure(throttle, now.Add(time.Minute)) {
+		t.Fatalf("RegisterFailure() reported a new lockout while locked")
+	}
+
+	// The counter starts over once the lockout expired
+	afterLockout := now.Add(policy.LockoutDuration + time.Second)
+	policy.RegisterFailure(throttle, afterLockout)
+	if throttle.FailureCount != 1 || throttle.LockedUntil != nil {
+		t.Fatalf("after lockout FailureCount = %d, LockedUntil = %v", throttle.FailureCount, throttle.LockedUntil)
+	}
+
+	t.Run("ip uses its own threshold", func(t *testing.T) {
+		ipThrottle := &LoginThrottle{Kind: ThrottleKindIP, FailureCount: policy.LockoutThreshold, LastFailureAt: now}
+		if policy.RegisterFailure(ipThrottle, now) {
+			t.Fatalf("RegisterFailure() locked IP at login ID threshold")
+		}
+	})
+}
@@ -0,0 +1,100 @@
+package user_sessions
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PersonalAccessToken is a long-lived API token owned by a user for automation.
+// Only the SHA256 hash of the token is stored.
+type PersonalAccessToken struct {
+	ID                int        `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID            int        `gorm:"not null;index" json:"userId"`
+	Name              string     `gorm:"not null;size:100" json:"name"`
+	TokenHash         string     `gorm:"not null;uniqueIndex;size:64" json:"-"` // SHA256 hash
+	TokenPrefix       string     `gorm:"not null;size:20" json:"tokenPrefix"`   // Leading characters shown to identify the token
+	Scope             string     `gorm:"not null;size:20" json:"scope"`         // read, write
+	AllowedProjectIDs string     `gorm:"type:text" json:"-"`                    // Comma-separated project IDs, empty for all projects
+	ExpiresAt         time.Time  `gorm:"not null" json:"expiresAt"`
+	LastUsedAt        *time.Time `json:"lastUsedAt,omitempty"`
+	CreatedAt         time.Time  `gorm:"not null" json:"createdAt"`
+	RevokedAt         *time.Time `json:"revokedAt,omitempty"`
+}
+
+// TableName specifies the table name for GORM
+func (PersonalAccessToken) TableName() string {
+	return "personal_access_tokens"
+}
+
+// PersonalAccessTokenScope constants
+const (
+	TokenScopeRead  = "read"
+	TokenScopeWrite = "write"
+
+	// PersonalAccessTokenPrefix marks bearer tokens that are personal access tokens rather than JWTs
+	PersonalAccessTokenPrefix = "pjpat_"
+)
+
+// IsValidTokenScope checks if the provided scope is valid
+func IsValidTokenScope(scope string) bool {
+	return scope == TokenScopeRead || scope == TokenScopeWrite
+}
+
+// IsPersonalAccessToken checks if a bearer token has the personal access token format
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
+
+func (t *PersonalAccessToken) IsValid() bool {
+	return t.RevokedAt == nil && time.Now().Before(t.ExpiresAt)
+}
+
+// CanWrite checks if the token allows state-changing requests
+func (t *PersonalAccessToken) CanWrite() bool {
+	return t.Scope == TokenScopeWrite
+}
+
+// ProjectIDs returns the projects the token is restricted to, or nil when unrestricted
+func (t *PersonalAccessToken) ProjectIDs() []int {
+	if strings.TrimSpace(t.AllowedProjectIDs) == "" {
+		return nil
+	}
+	parts := strings.Split(t.AllowedProjectIDs, ",")
+	ids := make([]int, 0, len(parts))
+	for _, part := range parts {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// SetProjectIDs stores the projects the token is restricted to
+func (t *PersonalAccessToken) SetProjectIDs(projectIDs []int) {
+	parts := make([]string, 0, len(projectIDs))
+	seen := make(map[int]bool)
+	for _, id := range projectIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		parts = append(parts, strconv.Itoa(id))
+	}
+	t.AllowedProjectIDs = strings.Join(parts, ",")
+}
+
+// AllowsProject checks if the token may be used for the given project
+func (t *PersonalAccessToken) AllowsProject(projectID int) bool {
+	ids := t.ProjectIDs()
+	if ids == nil {
+		return true
+	}
+	for _, id := range ids {
+		if id == projectID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package user_sessions
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/repositories"
+	"gorm.io/gorm"
+)
+
+type PersonalAccessTokenRepository struct {
+	uow *repositories.UnitOfWork
+}
+
+func NewPersonalAccessTokenRepository(uow *repositories.UnitOfWork) *PersonalAccessTokenRepository {
+	return &PersonalAccessTokenRepository{uow: uow}
+}
+
+func (r *PersonalAccessTokenRepository) Create(token *PersonalAccessToken) error {
+	return r.uow.GetDB().Create(token).Error
+}
+
+func (r *PersonalAccessTokenRepository) GetByID(id int) (*PersonalAccessToken, error) {
+	var token PersonalAccessToken
+	err := r.uow.GetDB().Where("id = ?", id).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *PersonalAccessTokenRepository) GetByTokenHash(tokenHash string) (*PersonalAccessToken, error) {
+	var token PersonalAccessToken
+	err := r.uow.GetDB().Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *PersonalAccessTokenRepository) GetByUserID(userID int) ([]*PersonalAccessToken, error) {
+	var tokens []*PersonalAccessToken
+	err := r.uow.GetDB().Where("user_id = ? AND revoked_at IS NULL", userID).Order("created_at DESC").Find(&tokens).Error
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (r *PersonalAccessTokenRepository) Revoke(id int) error {
+	now := time.Now()
+	return r.uow.GetDB().Model(&PersonalAccessToken{}).Where("id = ? AND revoked_at IS NULL", id).Update("revoked_at", now).Error
+}
+
+func (r *PersonalAccessTokenRepository) RevokeAllByUserID(userID int) error {
+	now := time.Now()
+	return r.uow.GetDB().Model(&PersonalAccessToken{}).Where("user_id = ? AND revoked_at IS NULL", userID).Update("revoked_at", now).Error
+}
+
+func (r *PersonalAccessTokenRepository) UpdateLastUsedAt(id int, usedAt time.Time) error {
+	return r.uow.GetDB().Model(&PersonalAccessToken{}).Where("id = ?", id).Update("last_used_at", usedAt).Error
+}
@@ -0,0 +1,134 @@
+package user_sessions
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/users"
+)
+
+const (
+	maxPersonalAccessTokenLifetime = 366 * 24 * time.Hour
+	lastUsedUpdateInterval         = time.Minute
+)
+
+type PersonalAccessTokenService struct {
+	tokenRepo    *PersonalAccessTokenRepository
+	userService  *users.UserService
+	tokenService *TokenService
+}
+
+func NewPersonalAccessTokenService(tokenRepo *PersonalAccessTokenRepository, userService *users.UserService, tokenService *TokenService) *PersonalAccessTokenService {
+	return &PersonalAccessTokenService{
+		tokenRepo:    tokenRepo,
+		userService:  userService,
+		tokenService: tokenService,
+	}
+}
+
+// CreateToken creates a personal access token and returns it with the plain token value.
+// The plain value is only available at creation time.
+func (s *PersonalAccessTokenService) CreateToken(userID int, name, scope string, expiresAt time.Time, projectIDs []int) (*PersonalAccessToken, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", errors.New("token name is required")
+	}
+	if !IsValidTokenScope(scope) {
+		return nil, "", errors.New("invalid token scope")
+	}
+	now := time.Now()
+	if !expiresAt.After(now) {
+		return nil, "", errors.New("expiry must be in the future")
+	}
+	if expiresAt.Sub(now) > maxPersonalAccessTokenLifetime {
+		return nil, "", errors.New("expiry cannot be more than one year ahead")
+	}
+	for _, projectID := range projectIDs {
+		if projectID <= 0 {
+			return nil, "", errors.New("invalid project ID")
+		}
+	}
+
+	randomPart, err := s.tokenService.GenerateRefreshToken()
+	if err != nil {
+		return nil, "", err
+	}
+	plainToken := PersonalAccessTokenPrefix + strings.TrimRight(randomPart, "=")
+
+	token := &PersonalAccessToken{
+		UserID:      userID,
+		Name:        name,
+		TokenHash:   s.tokenService.HashRefreshToken(plainToken),
+		TokenPrefix: plainToken[:len(PersonalAccessTokenPrefix)+6],
+		Scope:       scope,
+		ExpiresAt:   expiresAt,
+		CreatedAt:   now,
+	}
+	token.SetProjectIDs(projectIDs)
+
+	if err := s.tokenRepo.Create(token); err != nil {
+		return nil, "", err
+	}
+
+	return token, plainToken, nil
+}
+
+// ListTokens returns the user's tokens that have not been revoked
+func (s *PersonalAccessTokenService) ListTokens(userID int) ([]*PersonalAccessToken, error) {
+	return s.tokenRepo.GetByUserID(userID)
+}
+
+// RevokeToken revokes one of the user's tokens
+func (s *PersonalAccessTokenService) RevokeToken(userID, tokenID int) error {
+	token, err := s.tokenRepo.GetByID(tokenID)
+	if err != nil {
+		return err
+	}
+	if token == nil || token.UserID != userID {
+		return errors.New("token not found")
+	}
+
+	return s.tokenRepo.Revoke(tokenID)
+}
+
+// RevokeAllUserTokens revokes every token owned by the user
+func (s *PersonalAccessTokenService) RevokeAllUserTokens(userID int) error {
+	return s.tokenRepo.RevokeAllByUserID(userID)
+}
+
+// Authenticate resolves a plain token to its record and owner
+func (s *PersonalAccessTokenService) Authenticate(plainToken string) (*PersonalAccessToken, *users.User, error) {
+	if !IsPersonalAccessToken(plainToken) {
+		return nil, nil, errors.New("invalid token")
+	}
+
+	token, err := s.tokenRepo.GetByTokenHash(s.tokenService.HashRefreshToken(plainToken))
+	if err != nil {
+		return nil, nil, err
+	}
+	if token == nil || !token.IsValid() {
+		return nil, nil, errors.New("invalid or expired token")
+	}
+
+	user, err := s.userService.GetUserByID(token.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil {
+		return nil, nil, errors.New("user not found")
+	}
+	if !user.IsActive() {
+		return nil, nil, errors.New("account is deactivated")
+	}
+
+	// Avoid a write on every request from busy automation
+	now := time.Now()
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > lastUsedUpdateInterval {
+		if err := s.tokenRepo.UpdateLastUsedAt(token.ID, now); err == nil {
+			token.LastUsedAt = &now
+		}
+	}
+
+	return token, user, nil
+}
@@ -0,0 +1,61 @@
+package user_sessions
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPersonalAccessTokenProjectRestriction(t *testing.T) {
+	tests := []struct {
+		name       string
+		projectIDs []int
+		projectID  int
+		want       bool
+	}{
+		{name: "unrestricted", projectIDs: nil, projectID: 7, want: true},
+		{name: "allowed project", projectIDs: []int{3, 7}, projectID: 7, want: true},
+		{name: "other project", projectIDs: []int{3, 7}, projectID: 8, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := &PersonalAccessToken{}
+			token.SetProjectIDs(tt.projectIDs)
+			if got := token.AllowsProject(tt.projectID); got != tt.want {
+				t.Fatalf("AllowsProject(%d) = %v, want %v", tt.projectID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPersonalAccessTokenSetProjectIDsDeduplicates(t *testing.T) {
+	token := &PersonalAccessToken{}
+	token.SetProjectIDs([]int{5, 2, 5})
+
+	if token.AllowedProjectIDs != "5,2" {
+		t.Fatalf("AllowedProjectIDs = %q, want %q", token.AllowedProjectIDs, "5,2")
+	}
+}
+
+func TestPersonalAccessTokenIsValid(t *testing.T) {
+	now := time.Now()
+	revokedAt := now.Add(-time.Minute)
+
+	tests := []struct {
+		name  string
+		token PersonalAccessToken
+		want  bool
+	}{
+		{name: "active", token: PersonalAccessToken{ExpiresAt: now.Add(time.Hour)}, want: true},
+		{name: "expired", token: PersonalAccessToken{ExpiresAt: now.Add(-time.Hour)}, want: false},
+		{name: "revoked", token: PersonalAccessToken{ExpiresAt: now.Add(time.Hour), RevokedAt: &revokedAt}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.token.IsValid(); got != tt.want {
+				t.Fatalf("IsValid() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
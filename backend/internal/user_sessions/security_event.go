@@ -0,0 +1,57 @@
+package user_sessions
+
+import "time"
+
+// SecurityEvent records an authentication related event for auditing
+type SecurityEvent struct {
+	ID        int       `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    *int      `gorm:"index" json:"userId,omitempty"`
+	LoginID   string    `gorm:"size:255;index" json:"loginId"`
+	EventType string    `gorm:"not null;size:50;index" json:"eventType"`
+	IPAddress string    `gorm:"size:64" json:"ipAddress"`
+	UserAgent string    `gorm:"size:512" json:"userAgent"`
+	Details   string    `gorm:"size:500" json:"details,omitempty"`
+	CreatedAt time.Time `gorm:"index" json:"createdAt"`
+}
+
+// TableName specifies the table name for GORM
+func (SecurityEvent) TableName() string {
+	return "security_events"
+}
+
+// Security event types
+const (
+	SecurityEventLoginSucceeded      = "login_succeeded"
+	SecurityEventLoginFailed         = "login_failed"
+	SecurityEventAccountLocked       = "account_locked"
+	SecurityEventAccountUnlocked     = "account_unlocked"
+	SecurityEventTokenRefreshed      = "token_refreshed"
+	SecurityEventSessionRevoked      = "session_revoked"
+	SecurityEventAllSessionRevoked   = "all_sessions_revoked"
+	SecurityEventPasswordChanged     = "password_changed"
+	SecurityEventPasswordResetForced = "password_reset_forced"
+	SecurityEventUserDeactivated     = "user_deactivated"
+	SecurityEventUserReactivated     = "user_reactivated"
+)
+
+// IsValidSecurityEventType checks if the event type is known
+func IsValidSecurityEventType(eventType string) bool {
+	switch eventType {
+	case SecurityEventLoginSucceeded, SecurityEventLoginFailed, SecurityEventAccountLocked,
+		SecurityEventAccountUnlocked, SecurityEventTokenRefreshed, SecurityEventSessionRevoked,
+		SecurityEventAllSessionRevoked, SecurityEventPasswordChanged, SecurityEventPasswordResetForced,
+		SecurityEventUserDeactivated, SecurityEventUserReactivated:
+		return true
+	}
+	return false
+}
+
+// SecurityEventFilter narrows security event queries; zero values are ignored
+type SecurityEventFilter struct {
+	UserID    *int
+	LoginID   string
+	EventType string
+	IPAddress string
+	From      *time.Time
+	To        *time.Time
+}
@@ -0,0 +1,60 @@
+package user_sessions
+
+import (
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/repositories"
+)
+
+type SecurityEventRepository struct {
+	uow *repositories.UnitOfWork
+}
+
+func NewSecurityEventRepository(uow *repositories.UnitOfWork) *SecurityEventRepository {
+	return &SecurityEventRepository{uow: uow}
+}
+
+func (r *SecurityEventRepository) Create(event *SecurityEvent) error {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	return r.uow.GetDB().Create(event).Error
+}
+
+// List returns events matching the filter, newest first
+func (r *SecurityEventRepository) List(filter SecurityEventFilter, offset, limit int) ([]SecurityEvent, int64, error) {
+	query := r.uow.GetDB().Model(&SecurityEvent{})
+
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.LoginID != "" {
+		query = query.Where("login_id = ?", filter.LoginID)
+	}
+	if filter.EventType != "" {
+		query = query.Where("event_type = ?", filter.EventType)
+	}
+	if filter.IPAddress != "" {
+		query = query.Where("ip_address = ?", filter.IPAddress)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []SecurityEvent
+	err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&events).Error
+	return events, total, err
+}
+
+// DeleteOlderThan removes events created before the given time
+func (r *SecurityEventRepository) DeleteOlderThan(before time.Time) error {
+	return r.uow.GetDB().Where("created_at < ?", before).Delete(&SecurityEvent{}).Error
+}
@@ -0,0 +1,49 @@
+package user_sessions
+
+import (
+	"errors"
+	"log"
+)
+
+type SecurityEventService struct {
+	eventRepo *SecurityEventRepository
+}
+
+func NewSecurityEventService(eventRepo *SecurityEventRepository) *SecurityEventService {
+	return &SecurityEventService{eventRepo: eventRepo}
+}
+
+// Record stores an event. Failures are logged and never block the caller.
+func (s *SecurityEventService) Record(eventType string, userID *int, loginID, ipAddress, userAgent, details string) {
+	event := &SecurityEvent{
+		UserID:    userID,
+		LoginID:   truncate(loginID, 255),
+		EventType: eventType,
+		IPAddress: truncate(ipAddress, 64),
+		UserAgent: truncate(userAgent, 512),
+		Details:   truncate(details, 500),
+	}
+	if err := s.eventRepo.Create(event); err != nil {
+		log.Printf("failed to record security event %s: %v", eventType, err)
+	}
+}
+
+// ListEvents returns events matching the filter with pagination
+func (s *SecurityEventService) ListEvents(filter SecurityEventFilter, page, pageSize int) ([]SecurityEvent, int64, error) {
+	if filter.EventType != "" && !IsValidSecurityEventType(filter.EventType) {
+		return nil, 0, errors.New("invalid event type")
+	}
+	return s.eventRepo.List(filter, (page-1)*pageSize, pageSize)
+}
+
+// ListUserEvents returns the events of a single user with pagination
+func (s *SecurityEventService) ListUserEvents(userID int, page, pageSize int) ([]SecurityEvent, int64, error) {
+	return s.ListEvents(SecurityEventFilter{UserID: &userID}, page, pageSize)
+}
+
+func truncate(value string, maxLen int) string {
+	if len(value) <= maxLen {
+		return value
+	}
+	return value[:maxLen]
+}
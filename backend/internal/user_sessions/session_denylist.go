@@ -0,0 +1,134 @@
+package user_sessions
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/cachebus"
+	"github.com/google/uuid"
+)
+
+// SessionDenylist remembers revoked sessions until their outstanding access tokens expire.
+// Access tokens are stateless, so without it a revoked session keeps working for up to
+// the access token lifetime.
+type SessionDenylist struct {
+	entries map[uuid.UUID]time.Time
+	mu      sync.RWMutex
+	ttl     time.Duration
+	bus     cachebus.Bus
+	restore func() error
+}
+
+// NewSessionDenylist creates a denylist keeping entries for ttl, normally the access token duration
+func NewSessionDenylist(ttl time.Duration) *SessionDenylist {
+	denylist := &SessionDenylist{
+		entries: make(map[uuid.UUID]time.Time),
+		ttl:     ttl,
+		bus:     cachebus.NewNoopBus(),
+	}
+
+	// Start background cleanup goroutine
+	go denylist.cleanupExpired()
+
+	return denylist
+}
+
+// SetBus shares revoked sessions with the other API replicas through the bus. An
+// invalidation without a key means the bus may have missed revocations, for example
+// while reconnecting, so the denylist is reloaded through the restore function.
+func (d *SessionDenylist) SetBus(bus cachebus.Bus) {
+	d.bus = bus
+	bus.Subscribe(cachebus.TopicSession, func(invalidation cachebus.Invalidation) {
+		if invalidation.Key == "" {
+			d.reload()
+			return
+		}
+
+		sessionID, err := uuid.Parse(invalidation.Key)
+		if err != nil || invalidation.Until == nil {
+			return
+		}
+		d.AddUntil(*invalidation.Until, sessionID)
+	})
+}
+
+// SetRestore sets the function reloading revoked sessions from the database
+func (d *SessionDenylist) SetRestore(restore func() error) {
+	d.mu.Lock()
+	d.restore = restore
+	d.mu.Unlock()
+}
+
+// reload restores revoked sessions after the bus reported lost invalidations
+func (d *SessionDenylist) reload() {
+	d.mu.RLock()
+	restore := d.restore
+	d.mu.RUnlock()
+
+	if restore == nil {
+		return
+	}
+	if err := restore(); err != nil {
+		log.Printf("failed to restore session denylist: %v", err)
+	}
+}
+
+// Add denies the session on every replica until every access token issued for it has expired
+func (d *SessionDenylist) Add(sessionIDs ...uuid.UUID) {
+	until := time.Now().Add(d.ttl)
+	d.AddUntil(until, sessionIDs...)
+
+	for _, sessionID := range sessionIDs {
+		invalidation := cachebus.Invalidation{Topic: cachebus.TopicSession, Key: sessionID.String(), Until: &until}
+		if err := d.bus.Publish(invalidation); err != nil {
+			log.Printf("failed to publish revoked session: %v", err)
+		}
+	}
+}
+
+// AddUntil denies the sessions until the given time
+func (d *SessionDenylist) AddUntil(until time.Time, sessionIDs ...uuid.UUID) {
+	if !time.Now().Before(until) {
+		return
+	}
+
+	d.mu.Lock()
+	for _, sessionID := range sessionIDs {
+		if existing, ok := d.entries[sessionID]; !ok || existing.Before(until) {
+			d.entries[sessionID] = until
+		}
+	}
+	d.mu.Unlock()
+}
+
+// Contains checks if the session has been revoked recently
+func (d *SessionDenylist) Contains(sessionID uuid.UUID) bool {
+	d.mu.RLock()
+	until, ok := d.entries[sessionID]
+	d.mu.RUnlock()
+
+	return ok && time.Now().Before(until)
+}
+
+// TTL returns how long revoked sessions are kept
+func (d *SessionDenylist) TTL() time.Duration {
+	return d.ttl
+}
+
+// cleanupExpired periodically removes expired entries
+func (d *SessionDenylist) cleanupExpired() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		now := time.Now()
+		d.mu.Lock()
+		for sessionID, until := range d.entries {
+			if !now.Before(until) {
+				delete(d.entries, sessionID)
+			}
+		}
+		d.mu.Unlock()
+	}
+}
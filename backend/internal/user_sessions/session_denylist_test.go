@@ -0,0 +1,109 @@
+package user_sessions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/cachebus"
+	"github.com/google/uuid"
+)
+
+// loopbackBus delivers published invalidations to the subscribers of another denylist
+type loopbackBus struct {
+	handlers map[string][]cachebus.Handler
+	peer     *loopbackBus
+}
+
+func (b *loopbackBus) Publish(invalidation cachebus.Invalidation) error {
+	for _, handler := range b.peer.handlers[invalidation.Topic] {
+		handler(invalidation)
+	}
+	return nil
+}
+
+func (b *loopbackBus) Subscribe(topic string, handler cachebus.Handler) {
+	b.handlers[topic] = append(b.handlers[topic], handler)
+}
+
+func TestSessionDenylist(t *testing.T) {
+	denylist := NewSessionDenylist(time.Minute)
+	revoked := uuid.New()
+	expired := uuid.New()
+
+	denylist.Add(revoked)
+	denylist.AddUntil(time.Now().Add(-time.Second), expired)
+
+	tests := []struct {
+		name      string
+		sessionID uuid.UUID
+		want      bool
+	}{
+		{name: "revoked session", sessionID: revoked, want: true},
+		{name: "entry already expired", sessionID: expired, want: false},
+		{name: "other session", sessionID: uuid.New(), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := denylist.Contains(tt.sessionID); got != tt.want {
+				t.Fatalf("Contains() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionDenylistSharesRevokedSessions(t *testing.T) {
+	busA := &loopbackBus{handlers: map[string][]cachebus.Handler{}}
+	busB := &loopbackBus{handlers: map[string][]cachebus.Handler{}, peer: busA}
+	busA.peer = busB
+
+	replicaA := NewSessionDenylist(time.Minute)
+	replicaA.SetBus(busA)
+	replicaB := NewSessionDenylist(time.Minute)
+	replicaB.SetBus(busB)
+
+	revoked := uuid.New()
+	replicaA.Add(revoked)
+
+	if !replicaB.Contains(revoked) {
+		t.Fatalf("session revoked on one replica is not denied on the other")
+	}
+}
+
+func TestSessionDenylistRestoresAfterBusReset(t *testing.T) {
+	bus := &loopbackBus{handlers: map[string][]cachebus.Handler{}}
+	bus.peer = bus
+
+	denylist := NewSessionDenylist(time.Minute)
+	denylist.SetBus(bus)
+	missed := uuid.New()
+	denylist.SetRestore(func() error {
+		denylist.AddUntil(time.Now().Add(time.Minute), missed)
+		return nil
+	})
+
+	// A reconnecting bus publishes an invalidation without a key
+	bus.Publish(cachebus.Invalidation{Topic: cachebus.TopicSession})
+
+	if !denylist.Contains(missed) {
+		t.Fatalf("session revoked while the bus was disconnected is not denied after the reset")
+	}
+}
+
+func TestAccessTokenCarriesSessionID(t *testing.T) {
+	tokenService := NewTokenService("test-secret", time.Minute, time.Hour)
+	sessionID := uuid.New()
+
+	token, err := tokenService.GenerateAccessToken(1, "jane", sessionID)
+	if err != nil {
+		t.Fatalf("GenerateAccessToken() error = %v", err)
+	}
+
+	claims, err := tokenService.ValidateAccessToken(token)
+	if err != nil {
+		t.Fatalf("ValidateAccessToken() error = %v", err)
+	}
+	if claims.SessionID != sessionID.String() {
+		t.Fatalf("SessionID = %q, want %q", claims.SessionID, sessionID)
+	}
+}
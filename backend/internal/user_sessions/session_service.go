@@ -2,6 +2,8 @@ package user_sessions
 
 import (
 	"errors"
+	"fmt"
+	"log"
 	"time"
 
 	"github.com/dannyswat/pjeasy/internal/users"
@@ -9,35 +11,238 @@ import (
 )
 
 type SessionService struct {
-	userService  *users.UserService
-	sessionRepo  *UserSessionRepository
-	tokenService *TokenService
+	userService     *users.UserService
+	sessionRepo     *UserSessionRepository
+	tokenService    *TokenService
+	throttleService *LoginThrottleService
+	eventService    *SecurityEventService
+	denylist        *SessionDenylist
+	twoFactorPolicy TwoFactorPolicy
 }
 
+// TwoFactorPolicy reports whether system admins require two-factor authentication for everyone
+type TwoFactorPolicy interface {
+	IsTwoFactorRequired() (bool, error)
+}
+
+// Login status values
+const (
+	LoginStatusAuthenticated               = "authenticated"
+	LoginStatusTwoFactorRequired           = "two_factor_required"
+	LoginStatusTwoFactorEnrollmentRequired = "two_factor_enrollment_required"
+	LoginStatusPasswordChangeRequired      = "password_change_required"
+)
+
+// LoginResult carries either a new session or a challenge token for the next login step
 type LoginResult struct {
-	SessionID    uuid.UUID
-	User         *users.User
-	AccessToken  string
-	RefreshToken string
+	Status         string
+	SessionID      uuid.UUID
+	User           *users.User
+	AccessToken    string
+	RefreshToken   string
+	ChallengeToken string
+	RecoveryCodes  []string
 }
 
-func NewSessionService(userService *users.UserService, sessionRepo *UserSessionRepository, tokenService *TokenService) *SessionService {
+func NewSessionService(userService *users.UserService, sessionRepo *UserSessionRepository, tokenService *TokenService, throttleService *LoginThrottleService, eventService *SecurityEventService, denylist *SessionDenylist) *SessionService {
 	return &SessionService{
-		userService:  userService,
-		sessionRepo:  sessionRepo,
-		tokenService: tokenService,
+		userService:     userService,
+		sessionRepo:     sessionRepo,
+		tokenService:    tokenService,
+		throttleService: throttleService,
+		eventService:    eventService,
+		denylist:        denylist,
 	}
 }
 
+// SetTwoFactorPolicy sets the policy used to enforce two-factor authentication for everyone
+func (s *SessionService) SetTwoFactorPolicy(policy TwoFactorPolicy) {
+	s.twoFactorPolicy = policy
+}
+
 func (s *SessionService) Login(loginID, password, userAgent, ipAddress string) (*LoginResult, error) {
+	// Reject attempts while the login ID or IP address is backing off or locked
+	if err := s.throttleService.Check(loginID, ipAddress); err != nil {
+		return nil, err
+	}
+
 	// Authenticate user
 	user, err := s.userService.AuthenticateWithPassword(loginID, password)
 	if err != nil {
+		s.recordLoginFailure(loginID, userAgent, ipAddress, err.Error())
+		return nil, err
+	}
+
+	// Users with TOTP must pass the second step before a session is created
+	enabled, err := s.userService.IsTwoFactorEnabled(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return s.challenge(user, LoginStatusTwoFactorRequired, ChallengePurposeTwoFactor)
+	}
+
+	required, err := s.isTwoFactorRequired()
+	if err != nil {
+		return nil, err
+	}
+	if required {
+		return s.challenge(user, LoginStatusTwoFactorEnrollmentRequired, ChallengePurposeTwoFactorEnroll)
+	}
+
+	return s.completePasswordLogin(user, userAgent, ipAddress)
+}
+
+// CompletePasswordChange sets a new password for a user whose password expired and creates the session
+func (s *SessionService) CompletePasswordChange(challengeToken, newPassword, userAgent, ipAddress string) (*LoginResult, error) {
+	user, err := s.resolveChallenge(challengeToken, ChallengePurposePasswordChange)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userService.ReplaceExpiredPassword(user.ID, newPassword); err != nil {
+		return nil, err
+	}
+
+	return s.createSession(user, userAgent, ipAddress)
+}
+
+// completePasswordLogin runs after every factor passed; an expired password must be changed first
+func (s *SessionService) completePasswordLogin(user *users.User, userAgent, ipAddress string) (*LoginResult, error) {
+	expired, err := s.userService.IsPasswordExpired(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if expired {
+		return s.challenge(user, LoginStatusPasswordChangeRequired, ChallengePurposePasswordChange)
+	}
+
+	return s.createSession(user, userAgent, ipAddress)
+}
+
+// CompleteTwoFactorLogin verifies the second factor for a challenge and creates the session
+func (s *SessionService) CompleteTwoFactorLogin(challengeToken, code, userAgent, ipAddress string) (*LoginResult, error) {
+	user, err := s.resolveChallenge(challengeToken, ChallengePurposeTwoFactor)
+	if err != nil {
+		return nil, err
+	}
+
+	// Second factor guesses count against the same login ID as password failures
+	if err := s.throttleService.Check(user.LoginID, ipAddress); err != nil {
 		return nil, err
 	}
 
+	valid, err := s.userService.VerifySecondFactor(user.ID, code)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		s.recordLoginFailure(user.LoginID, userAgent, ipAddress, users.ErrInvalidVerificationCode.Error())
+		return nil, users.ErrInvalidVerificationCode
+	}
+
+	return s.completePasswordLogin(user, userAgent, ipAddress)
+}
+
+// BeginTwoFactorEnrollment starts TOTP enrollment for a user who must enable 2FA before logging in
+func (s *SessionService) BeginTwoFactorEnrollment(challengeToken string) (*users.TOTPEnrollment, error) {
+	user, err := s.resolveChallenge(challengeToken, ChallengePurposeTwoFactorEnroll)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.userService.BeginTOTPEnrollment(user.ID)
+}
+
+// CompleteTwoFactorEnrollment confirms the TOTP secret and creates the session
+func (s *SessionService) CompleteTwoFactorEnrollment(challengeToken, code, userAgent, ipAddress string) (*LoginResult, error) {
+	user, err := s.resolveChallenge(challengeToken, ChallengePurposeTwoFactorEnroll)
+	if err != nil {
+		return nil, err
+	}
+
+	// Enrollment codes are guessed against the password challenge, so they are throttled like logins
+	if err := s.throttleService.Check(user.LoginID, ipAddress); err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := s.userService.ConfirmTOTPEnrollment(user.ID, code)
+	if errors.Is(err, users.ErrInvalidVerificationCode) {
+		s.recordLoginFailure(user.LoginID, userAgent, ipAddress, err.Error())
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.completePasswordLogin(user, userAgent, ipAddress)
+	if err != nil {
+		return nil, err
+	}
+	result.RecoveryCodes = recoveryCodes
+	return result, nil
+}
+
+func (s *SessionService) isTwoFactorRequired() (bool, error) {
+	if s.twoFactorPolicy == nil {
+		return false, nil
+	}
+	return s.twoFactorPolicy.IsTwoFactorRequired()
+}
+
+func (s *SessionService) challenge(user *users.User, status, purpose string) (*LoginResult, error) {
+	challengeToken, err := s.tokenService.GenerateChallengeToken(user.ID, user.LoginID, purpose)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResult{
+		Status:         status,
+		User:           user,
+		ChallengeToken: challengeToken,
+	}, nil
+}
+
+func (s *SessionService) resolveChallenge(challengeToken, purpose string) (*users.User, error) {
+	claims, err := s.tokenService.ValidateChallengeToken(challengeToken, purpose)
+	if err != nil {
+		return nil, errors.New("invalid or expired challenge token")
+	}
+
+	user, err := s.userService.GetUserByID(claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+	if !user.IsActive() {
+		return nil, errors.New("account is deactivated")
+	}
+	return user, nil
+}
+
+// LoginWithOIDC creates a session for the user linked to a verified OIDC identity
+func (s *SessionService) LoginWithOIDC(identity *users.OIDCIdentity, loginIDClaim string, allowCreate bool, userAgent, ipAddress string) (*LoginResult, error) {
+	user, err := s.userService.AuthenticateWithOIDC(identity, loginIDClaim, allowCreate)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.createSession(user, userAgent, ipAddress)
+}
+
+// createSession issues tokens and persists a new session for an authenticated user
+func (s *SessionService) createSession(user *users.User, userAgent, ipAddress string) (*LoginResult, error) {
+	if !user.IsActive() {
+		return nil, errors.New("account is deactivated")
+	}
+
+	// The session ID is embedded in the access token so revocation can deny it
+	sessionID := uuid.New()
+
 	// Generate access token
-	accessToken, err := s.tokenService.GenerateAccessToken(user.ID, user.LoginID)
+	accessToken, err := s.tokenService.GenerateAccessToken(user.ID, user.LoginID, sessionID)
 	if err != nil {
 		return nil, err
 	}
@@ -53,6 +258,7 @@ func (s *SessionService) Login(loginID, password, userAgent, ipAddress string) (
 
 	// Create session with UUID
 	session := &UserSession{
+		ID:               sessionID,
 		UserID:           user.ID,
 		RefreshTokenHash: tokenHash,
 		ExpiresAt:        time.Now().Add(s.tokenService.GetRefreshTokenDuration()),
@@ -60,13 +266,21 @@ func (s *SessionService) Login(loginID, password, userAgent, ipAddress string) (
 		IPAddress:        ipAddress,
 	}
 
-	// Save session to get the UUID
 	err = s.sessionRepo.Create(session)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := s.throttleService.RecordSuccess(user.LoginID); err != nil {
+		log.Printf("failed to reset login throttle for %s: %v", user.LoginID, err)
+	}
+	if err := s.userService.RecordLogin(user.ID); err != nil {
+		log.Printf("failed to record last login of user %d: %v", user.ID, err)
+	}
+	s.recordEvent(SecurityEventLoginSucceeded, user, userAgent, ipAddress, "")
+
 	return &LoginResult{
+		Status:       LoginStatusAuthenticated,
 		SessionID:    session.ID,
 		User:         user,
 		AccessToken:  accessToken,
@@ -74,7 +288,7 @@ func (s *SessionService) Login(loginID, password, userAgent, ipAddress string) (
 	}, nil
 }
 
-func (s *SessionService) RefreshToken(sessionIDStr string, refreshToken string) (*LoginResult, error) {
+func (s *SessionService) RefreshToken(sessionIDStr string, refreshToken string, userAgent, ipAddress string) (*LoginResult, error) {
 	// Parse session ID
 	sessionID, err := uuid.Parse(sessionIDStr)
 	if err != nil {
@@ -111,8 +325,17 @@ func (s *SessionService) RefreshToken(sessionIDStr string, refreshToken string)
 		return nil, errors.New("user not found")
 	}
 
+	// Sessions of deactivated users end at the next refresh at the latest
+	if !user.IsActive() {
+		if err := s.sessionRepo.Revoke(session.ID); err != nil {
+			return nil, err
+		}
+		s.denylist.Add(session.ID)
+		return nil, errors.New("account is deactivated")
+	}
+
 	// Generate new access token
-	accessToken, err := s.tokenService.GenerateAccessToken(user.ID, user.LoginID)
+	accessToken, err := s.tokenService.GenerateAccessToken(user.ID, user.LoginID, session.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -125,8 +348,11 @@ func (s *SessionService) RefreshToken(sessionIDStr string, refreshToken string)
 		return nil, err
 	}
 
+	s.recordEvent(SecurityEventTokenRefreshed, user, userAgent, ipAddress, "")
+
 	// Return same session and refresh token
 	return &LoginResult{
+		Status:       LoginStatusAuthenticated,
 		SessionID:    session.ID,
 		User:         user,
 		AccessToken:  accessToken,
@@ -134,7 +360,7 @@ func (s *SessionService) RefreshToken(sessionIDStr string, refreshToken string)
 	}, nil
 }
 
-func (s *SessionService) RevokeSession(sessionIDStr string, refreshToken string) error {
+func (s *SessionService) RevokeSession(sessionIDStr string, refreshToken string, userAgent, ipAddress string) error {
 	// Parse session ID
 	sessionID, err := uuid.Parse(sessionIDStr)
 	if err != nil {
@@ -156,13 +382,278 @@ func (s *SessionService) RevokeSession(sessionIDStr string, refreshToken string)
 		return errors.New("invalid refresh token")
 	}
 
-	return s.sessionRepo.Revoke(sessionID)
+	if err := s.sessionRepo.Revoke(sessionID); err != nil {
+		return err
+	}
+	s.denylist.Add(sessionID)
+
+	s.recordUserEvent(SecurityEventSessionRevoked, session.UserID, userAgent, ipAddress, "session "+session.ID.String())
+	return nil
 }
 
-func (s *SessionService) RevokeAllUserSessions(userID int) error {
-	return s.sessionRepo.RevokeAllByUserID(userID)
+// ChangePassword changes the user's password and revokes every other session of the user.
+// The current session is kept when currentSessionIDStr identifies one of the user's sessions.
+func (s *SessionService) ChangePassword(userID int, currentSessionIDStr, currentPassword, newPassword, userAgent, ipAddress string) error {
+	err := s.checkCurrentPassword(userID, userAgent, ipAddress, func() error {
+		return s.userService.ChangePassword(userID, currentPassword, newPassword)
+	})
+	if err != nil {
+		return err
+	}
+	s.recordUserEvent(SecurityEventPasswordChanged, userID, userAgent, ipAddress, "")
+
+	currentSessionID, err := uuid.Parse(currentSessionIDStr)
+	if err != nil {
+		return s.revokeAllSessions(userID, uuid.Nil)
+	}
+
+	session, err := s.sessionRepo.GetByID(currentSessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil || session.UserID != userID {
+		return s.revokeAllSessions(userID, uuid.Nil)
+	}
+
+	return s.revokeAllSessions(userID, currentSessionID)
+}
+
+// VerifyCurrentPassword checks the password of a signed in user before a sensitive account change
+func (s *SessionService) VerifyCurrentPassword(userID int, currentPassword, userAgent, ipAddress string) error {
+	return s.checkCurrentPassword(userID, userAgent, ipAddress, func() error {
+		return s.userService.VerifyPassword(userID, currentPassword)
+	})
+}
+
+// checkCurrentPassword runs a check of the user's current password under the login throttle.
+// A stolen access token could otherwise be used to guess the password, so wrong passwords count as failed logins.
+func (s *SessionService) checkCurrentPassword(userID int, userAgent, ipAddress string, check func() error) error {
+	user, err := s.userService.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("user not found")
+	}
+
+	if err := s.throttleService.Check(user.LoginID, ipAddress); err != nil {
+		return err
+	}
+
+	err = check()
+	if errors.Is(err, users.ErrIncorrectCurrentPassword) {
+		s.recordLoginFailure(user.LoginID, userAgent, ipAddress, err.Error())
+	}
+	return err
+}
+
+func (s *SessionService) RevokeAllUserSessions(userID int, userAgent, ipAddress string) error {
+	if err := s.revokeAllSessions(userID, uuid.Nil); err != nil {
+		return err
+	}
+
+	s.recordUserEvent(SecurityEventAllSessionRevoked, userID, userAgent, ipAddress, "")
+	return nil
+}
+
+// revokeAllSessions revokes the user's sessions except keepSessionID and denies their access tokens
+func (s *SessionService) revokeAllSessions(userID int, keepSessionID uuid.UUID) error {
+	sessionIDs, err := s.sessionRepo.GetActiveIDsByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	if keepSessionID == uuid.Nil {
+		err = s.sessionRepo.RevokeAllByUserID(userID)
+	} else {
+		err = s.sessionRepo.RevokeAllByUserIDExcept(userID, keepSessionID)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, sessionID := range sessionIDs {
+		if sessionID != keepSessionID {
+			s.denylist.Add(sessionID)
+		}
+	}
+	return nil
 }
 
 func (s *SessionService) GetUserSessions(userID int) ([]*UserSession, error) {
 	return s.sessionRepo.GetByUserID(userID)
 }
+
+// GetActiveSessions returns the user's sessions that are neither revoked nor expired
+func (s *SessionService) GetActiveSessions(userID int) ([]*UserSession, error) {
+	sessions, err := s.sessionRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	active := make([]*UserSession, 0, len(sessions))
+	for _, session := range sessions {
+		if session.IsValid() {
+			active = append(active, session)
+		}
+	}
+	return active, nil
+}
+
+// RevokeUserSession revokes one of the user's sessions by ID without its refresh token.
+// revokedBy is the user performing the action, either the owner or a system admin.
+func (s *SessionService) RevokeUserSession(userID int, sessionIDStr string, revokedBy int, userAgent, ipAddress string) error {
+	sessionID, err := uuid.Parse(sessionIDStr)
+	if err != nil {
+		return errors.New("invalid session ID")
+	}
+
+	session, err := s.sessionRepo.GetByID(sessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil || session.UserID != userID {
+		return errors.New("session not found")
+	}
+	if session.RevokedAt != nil {
+		return errors.New("session already revoked")
+	}
+
+	if err := s.sessionRepo.Revoke(sessionID); err != nil {
+		return err
+	}
+	s.denylist.Add(sessionID)
+
+	details := "session " + session.ID.String()
+	if revokedBy != userID {
+		details = fmt.Sprintf("%s revoked by user %d", details, revokedBy)
+	}
+	s.recordUserEvent(SecurityEventSessionRevoked, userID, userAgent, ipAddress, details)
+	return nil
+}
+
+// IsSessionRevoked checks if access tokens of the session must be rejected
+func (s *SessionService) IsSessionRevoked(sessionIDStr string) bool {
+	sessionID, err := uuid.Parse(sessionIDStr)
+	if err != nil {
+		return true
+	}
+	return s.denylist.Contains(sessionID)
+}
+
+// RestoreDenylist reloads sessions revoked while their access tokens may still be valid,
+// so a restart does not re-enable them
+func (s *SessionService) RestoreDenylist() error {
+	sessions, err := s.sessionRepo.GetRevokedSince(time.Now().Add(-s.denylist.TTL()))
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		s.denylist.AddUntil(session.RevokedAt.Add(s.denylist.TTL()), session.ID)
+	}
+	return nil
+}
+
+// GetLockedLogins returns login IDs and IP addresses that are currently locked out
+func (s *SessionService) GetLockedLogins() ([]LoginThrottle, error) {
+	return s.throttleService.ListLocked()
+}
+
+// UnlockLogin clears the lockout of a login ID or IP address on behalf of an admin
+func (s *SessionService) UnlockLogin(kind, value string, unlockedBy int, userAgent, ipAddress string) error {
+	throttle, err := s.throttleService.Unlock(kind, value)
+	if err != nil {
+		return err
+	}
+
+	details := fmt.Sprintf("%s %s unlocked by user %d", throttle.Kind, throttle.Value, unlockedBy)
+	if throttle.Kind == ThrottleKindLoginID {
+		s.eventService.Record(SecurityEventAccountUnlocked, s.lookupUserID(throttle.Value), throttle.Value, ipAddress, userAgent, details)
+	} else {
+		s.eventService.Record(SecurityEventAccountUnlocked, nil, "", ipAddress, userAgent, details)
+	}
+	return nil
+}
+
+// recordLoginFailure logs a failed login step and counts it towards backoff and lockout
+func (s *SessionService) recordLoginFailure(loginID, userAgent, ipAddress, reason string) {
+	userID := s.lookupUserID(loginID)
+	s.eventService.Record(SecurityEventLoginFailed, userID, loginID, ipAddress, userAgent, reason)
+
+	locked, err := s.throttleService.RecordFailure(loginID, ipAddress)
+	if err != nil {
+		log.Printf("failed to record login failure for %s: %v", loginID, err)
+		return
+	}
+	for _, throttle := range locked {
+		details := fmt.Sprintf("%s %s locked until %s", throttle.Kind, throttle.Value, throttle.LockedUntil.Format(time.RFC3339))
+		s.eventService.Record(SecurityEventAccountLocked, userID, loginID, ipAddress, userAgent, details)
+	}
+}
+
+func (s *SessionService) recordEvent(eventType string, user *users.User, userAgent, ipAddress, details string) {
+	userID := user.ID
+	s.eventService.Record(eventType, &userID, user.LoginID, ipAddress, userAgent, details)
+}
+
+func (s *SessionService) recordUserEvent(eventType string, userID int, userAgent, ipAddress, details string) {
+	loginID := ""
+	if user, err := s.userService.GetUserByID(userID); err == nil && user != nil {
+		loginID = user.LoginID
+	}
+	s.eventService.Record(eventType, &userID, loginID, ipAddress, userAgent, details)
+}
+
+func (s *SessionService) lookupUserID(loginID string) *int {
+	user, err := s.userService.GetUserByLoginID(loginID)
+	if err != nil || user == nil {
+		return nil
+	}
+	return &user.ID
+}
+
+// DeactivateUser blocks a user from logging in and ends all of their sessions
+func (s *SessionService) DeactivateUser(userID, deactivatedBy int, userAgent, ipAddress string) (*users.User, error) {
+	if userID == deactivatedBy {
+		return nil, errors.New("cannot deactivate your own account")
+	}
+
+	user, err := s.userService.DeactivateUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.revokeAllSessions(userID, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	s.recordEvent(SecurityEventUserDeactivated, user, userAgent, ipAddress, fmt.Sprintf("deactivated by user %d", deactivatedBy))
+	return user, nil
+}
+
+// ReactivateUser allows a deactivated user to log in again
+func (s *SessionService) ReactivateUser(userID, reactivatedBy int, userAgent, ipAddress string) (*users.User, error) {
+	user, err := s.userService.ReactivateUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordEvent(SecurityEventUserReactivated, user, userAgent, ipAddress, fmt.Sprintf("reactivated by user %d", reactivatedBy))
+	return user, nil
+}
+
+// ForcePasswordReset expires the user's password and ends their sessions,
+// so the next login has to set a new password
+func (s *SessionService) ForcePasswordReset(userID, requestedBy int, userAgent, ipAddress string) error {
+	if err := s.userService.ExpirePassword(userID); err != nil {
+		return err
+	}
+
+	if err := s.revokeAllSessions(userID, uuid.Nil); err != nil {
+		return err
+	}
+
+	s.recordUserEvent(SecurityEventPasswordResetForced, userID, userAgent, ipAddress, fmt.Sprintf("requested by user %d", requestedBy))
+	return nil
+}
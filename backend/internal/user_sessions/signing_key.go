@@ -0,0 +1,292 @@
+package user_sessions
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported signing algorithms
+const (
+	SigningAlgorithmHS256 = "HS256"
+	SigningAlgorithmRS256 = "RS256"
+	SigningAlgorithmEdDSA = "EdDSA"
+
+	rsaSigningKeyBits = 2048
+)
+
+// SigningKey is an asymmetric key used to sign or verify JWTs
+type SigningKey struct {
+	ID         string
+	Algorithm  string
+	PrivateKey crypto.Signer
+	RetiredAt  *time.Time // Nil for keys that may sign new tokens
+}
+
+// NewSigningKey wraps a private key, deriving the algorithm from the key type.
+// An empty id defaults to the RFC 7638 thumbprint of the public key.
+func NewSigningKey(id string, privateKey crypto.Signer, retiredAt *time.Time) (*SigningKey, error) {
+	var algorithm string
+	switch privateKey.(type) {
+	case *rsa.PrivateKey:
+		algorithm = SigningAlgorithmRS256
+	case ed25519.PrivateKey:
+		algorithm = SigningAlgorithmEdDSA
+	default:
+		return nil, errors.New("unsupported signing key type")
+	}
+
+	key := &SigningKey{
+		Algorithm:  algorithm,
+		PrivateKey: privateKey,
+		RetiredAt:  retiredAt,
+	}
+	if id == "" {
+		thumbprint, err := key.Thumbprint()
+		if err != nil {
+			return nil, err
+		}
+		id = thumbprint
+	}
+	key.ID = id
+	return key, nil
+}
+
+func (k *SigningKey) signingMethod() jwt.SigningMethod {
+	if k.Algorithm == SigningAlgorithmEdDSA {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
+
+// PublicKey returns the key used to verify signatures
+func (k *SigningKey) PublicKey() crypto.PublicKey {
+	return k.PrivateKey.Public()
+}
+
+// JWK describes a public key in JSON Web Key format
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet is the document published at /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK returns the public part of the key
+func (k *SigningKey) JWK() JWK {
+	jwk := JWK{Kid: k.ID, Use: "sig", Alg: k.Algorithm}
+	switch publicKey := k.PublicKey().(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+	}
+	return jwk
+}
+
+// Thumbprint returns the RFC 7638 SHA-256 thumbprint of the public key
+func (k *SigningKey) Thumbprint() (string, error) {
+	jwk := k.JWK()
+
+	// Required members only, in lexicographic order
+	var members interface{}
+	switch jwk.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{E: jwk.E, Kty: jwk.Kty, N: jwk.N}
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{Crv: jwk.Crv, Kty: jwk.Kty, X: jwk.X}
+	default:
+		return "", errors.New("unsupported signing key type")
+	}
+
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// KeySet holds the active signing key and retired keys kept for verification
+type KeySet struct {
+	active      *SigningKey
+	keys        map[string]*SigningKey
+	gracePeriod time.Duration
+}
+
+// NewKeySet creates a key set. Exactly one key must be active (not retired).
+// Retired keys verify tokens until RetiredAt plus gracePeriod.
+func NewKeySet(keys []*SigningKey, gracePeriod time.Duration) (*KeySet, error) {
+	keySet := &KeySet{
+		keys:        make(map[string]*SigningKey, len(keys)),
+		gracePeriod: gracePeriod,
+	}
+
+	for _, key := range keys {
+		if _, exists := keySet.keys[key.ID]; exists {
+			return nil, fmt.Errorf("duplicate signing key id %q", key.ID)
+		}
+		keySet.keys[key.ID] = key
+		if key.RetiredAt == nil {
+			if keySet.active != nil {
+				return nil, errors.New("only one signing key can be active")
+			}
+			keySet.active = key
+		}
+	}
+
+	if keySet.active == nil {
+		return nil, errors.New("no active signing key")
+	}
+	return keySet, nil
+}
+
+// Active returns the key used to sign new tokens
+func (ks *KeySet) Active() *SigningKey {
+	return ks.active
+}
+
+// VerificationKey returns the key with the kid if it may still verify tokens
+func (ks *KeySet) VerificationKey(kid string, now time.Time) (*SigningKey, bool) {
+	key, ok := ks.keys[kid]
+	if !ok || !ks.isUsable(key, now) {
+		return nil, false
+	}
+	return key, true
+}
+
+// JWKS returns the public keys that may still verify tokens
+func (ks *KeySet) JWKS(now time.Time) *JWKSet {
+	set := &JWKSet{Keys: []JWK{ks.active.JWK()}}
+	for _, key := range ks.keys {
+		if key != ks.active && ks.isUsable(key, now) {
+			set.Keys = append(set.Keys, key.JWK())
+		}
+	}
+	return set
+}
+
+func (ks *KeySet) isUsable(key *SigningKey, now time.Time) bool {
+	return key.RetiredAt == nil || now.Before(key.RetiredAt.Add(ks.gracePeriod))
+}
+
+// GenerateSigningKey creates a new private key for the algorithm
+func GenerateSigningKey(algorithm string) (crypto.Signer, error) {
+	switch algorithm {
+	case SigningAlgorithmRS256:
+		return rsa.GenerateKey(rand.Reader, rsaSigningKeyBits)
+	case SigningAlgorithmEdDSA:
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		return privateKey, err
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+}
+
+// LoadSigningKeyPEM reads a PKCS#8 or PKCS#1 private key from a PEM file
+func LoadSigningKeyPEM(path string) (crypto.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in %s", path)
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key in %s", path)
+		}
+		return signer, nil
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q in %s", block.Type, path)
+	}
+}
+
+// WriteSigningKeyPEM stores a private key as PKCS#8 PEM readable only by the owner
+func WriteSigningKeyPEM(path string, privateKey crypto.Signer) error {
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return err
+		}
+	}
+
+	// O_EXCL keeps concurrent first starts from overwriting each other's key
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return pem.Encode(file, &pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+// LoadOrGenerateSigningKey loads the PEM file, generating and saving a new key when it does not exist
+func LoadOrGenerateSigningKey(path, algorithm string) (crypto.Signer, error) {
+	privateKey, err := LoadSigningKeyPEM(path)
+	if err == nil || !errors.Is(err, os.ErrNotExist) {
+		return privateKey, err
+	}
+
+	privateKey, err = GenerateSigningKey(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if err := WriteSigningKeyPEM(path, privateKey); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return LoadSigningKeyPEM(path)
+		}
+		return nil, err
+	}
+	return privateKey, nil
+}
@@ -0,0 +1,130 @@
+package user_sessions
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func newTestSigningKey(t *testing.T, algorithm string, retiredAt *time.Time) *SigningKey {
+	t.Helper()
+
+	privateKey, err := GenerateSigningKey(algorithm)
+	if err != nil {
+		t.Fatalf("GenerateSigningKey(%s) error = %v", algorithm, err)
+	}
+	key, err := NewSigningKey("", privateKey, retiredAt)
+	if err != nil {
+		t.Fatalf("NewSigningKey() error = %v", err)
+	}
+	return key
+}
+
+func TestTokenServiceAsymmetricSigning(t *testing.T) {
+	for _, algorithm := range []string{SigningAlgorithmRS256, SigningAlgorithmEdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			keySet, err := NewKeySet([]*SigningKey{newTestSigningKey(t, algorithm, nil)}, time.Hour)
+			if err != nil {
+				t.Fatalf("NewKeySet() error = %v", err)
+			}
+			tokenService := NewTokenService("secret", time.Minute, time.Hour)
+			tokenService.SetKeySet(keySet)
+
+			token, err := tokenService.GenerateAccessToken(1, "jane", uuid.New())
+			if err != nil {
+				t.Fatalf("GenerateAccessToken() error = %v", err)
+			}
+			if _, err := tokenService.ValidateAccessToken(token); err != nil {
+				t.Fatalf("ValidateAccessToken() error = %v", err)
+			}
+
+			jwks := tokenService.JWKS()
+			if len(jwks.Keys) != 1 || jwks.Keys[0].Alg != algorithm || jwks.Keys[0].Kid != keySet.Active().ID {
+				t.Fatalf("unexpected JWKS %+v", jwks)
+			}
+
+			// HS256 tokens signed with the old shared secret are no longer accepted
+			hmacToken, _ := NewTokenService("secret", time.Minute, time.Hour).GenerateAccessToken(1, "jane", uuid.New())
+			if _, err := tokenService.ValidateAccessToken(hmacToken); err == nil {
+				t.Fatalf("ValidateAccessToken() accepted an HS256 token")
+			}
+		})
+	}
+}
+
+func TestKeySetRotation(t *testing.T) {
+	now := time.Now()
+	recentlyRetired := now.Add(-30 * time.Minute)
+	longRetired := now.Add(-2 * time.Hour)
+
+	oldKey := newTestSigningKey(t, SigningAlgorithmRS256, nil)
+	oldKeySet, err := NewKeySet([]*SigningKey{oldKey}, time.Hour)
+	if err != nil {
+		t.Fatalf("NewKeySet() error = %v", err)
+	}
+	oldService := NewTokenService("secret", time.Minute, time.Hour)
+	oldService.SetKeySet(oldKeySet)
+	oldToken, err := oldService.GenerateAccessToken(1, "jane", uuid.New())
+	if err != nil {
+		t.Fatalf("GenerateAccessToken() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		retiredAt time.Time
+		wantValid bool
+		wantKeys  int
+	}{
+		{name: "within grace period", retiredAt: recentlyRetired, wantValid: true, wantKeys: 2},
+		{name: "after grace period", retiredAt: longRetired, wantValid: false, wantKeys: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retiredKey := *oldKey
+			retiredKey.RetiredAt = &tt.retiredAt
+			keySet, err := NewKeySet([]*SigningKey{newTestSigningKey(t, SigningAlgorithmEdDSA, nil), &retiredKey}, time.Hour)
+			if err != nil {
+				t.Fatalf("NewKeySet() error = %v", err)
+			}
+			tokenService := NewTokenService("secret", time.Minute, time.Hour)
+			tokenService.SetKeySet(keySet)
+
+			_, err = tokenService.ValidateAccessToken(oldToken)
+			if (err == nil) != tt.wantValid {
+				t.Fatalf("ValidateAccessToken() error = %v, want valid %v", err, tt.wantValid)
+			}
+			if got := len(tokenService.JWKS().Keys); got != tt.wantKeys {
+				t.Fatalf("JWKS has %d keys, want %d", got, tt.wantKeys)
+			}
+		})
+	}
+
+	t.Run("two active keys", func(t *testing.T) {
+		_, err := NewKeySet([]*SigningKey{newTestSigningKey(t, SigningAlgorithmEdDSA, nil), oldKey}, time.Hour)
+		if err == nil {
+			t.Fatalf("NewKeySet() expected error for two active keys")
+		}
+	})
+}
+
+func TestLoadOrGenerateSigningKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", "signing.pem")
+
+	generated, err := LoadOrGenerateSigningKey(path, SigningAlgorithmEdDSA)
+	if err != nil {
+		t.Fatalf("LoadOrGenerateSigningKey() error = %v", err)
+	}
+	loaded, err := LoadOrGenerateSigningKey(path, SigningAlgorithmEdDSA)
+	if err != nil {
+		t.Fatalf("LoadOrGenerateSigningKey() reload error = %v", err)
+	}
+
+	generatedKey, _ := NewSigningKey("", generated, nil)
+	loadedKey, _ := NewSigningKey("", loaded, nil)
+	if generatedKey.ID != loadedKey.ID {
+		t.Fatalf("reloaded key id %s, want %s", loadedKey.ID, generatedKey.ID)
+	}
+}
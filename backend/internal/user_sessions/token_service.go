@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 // TokenService handles token generation and validation with security best practices:
@@ -17,18 +18,31 @@ import (
 // - Only hashed refresh tokens are stored in database (SHA256)
 // - Session ID and refresh token validated separately
 // - Token rotation on refresh to limit exposure window
+// - Optional RS256/EdDSA signing with key IDs so other services can verify tokens via JWKS
 type TokenService struct {
 	jwtSecret            []byte
+	keySet               *KeySet // Nil signs with the shared HS256 secret
 	accessTokenDuration  time.Duration
 	refreshTokenDuration time.Duration
 }
 
 type TokenClaims struct {
-	UserID  int    `json:"user_id"`
-	LoginID string `json:"login_id"`
+	UserID    int    `json:"user_id"`
+	LoginID   string `json:"login_id"`
+	SessionID string `json:"sid,omitempty"`     // Session the access token was issued for
+	Purpose   string `json:"purpose,omitempty"` // Empty for access tokens, set for login challenge tokens
 	jwt.RegisteredClaims
 }
 
+// Challenge token purposes used during multi-step login
+const (
+	ChallengePurposeTwoFactor       = "2fa"
+	ChallengePurposeTwoFactorEnroll = "2fa-enroll"
+	ChallengePurposePasswordChange  = "password-change"
+
+	challengeTokenDuration = 5 * time.Minute
+)
+
 func NewTokenService(jwtSecret string, accessTokenDuration, refreshTokenDuration time.Duration) *TokenService {
 	return &TokenService{
 		jwtSecret:            []byte(jwtSecret),
@@ -37,18 +51,65 @@ func NewTokenService(jwtSecret string, accessTokenDuration, refreshTokenDuration
 	}
 }
 
-func (s *TokenService) GenerateAccessToken(userID int, loginID string) (string, error) {
+// SetKeySet switches signing to the active asymmetric key of the set.
+// Tokens are then verified by their kid header and HS256 tokens are rejected.
+func (s *TokenService) SetKeySet(keySet *KeySet) {
+	s.keySet = keySet
+}
+
+// JWKS returns the public verification keys, empty when signing with HS256
+func (s *TokenService) JWKS() *JWKSet {
+	if s.keySet == nil {
+		return &JWKSet{Keys: []JWK{}}
+	}
+	return s.keySet.JWKS(time.Now())
+}
+
+// signClaims signs the claims with the active key
+func (s *TokenService) signClaims(claims TokenClaims) (string, error) {
+	if s.keySet == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString(s.jwtSecret)
+	}
+
+	key := s.keySet.Active()
+	token := jwt.NewWithClaims(key.signingMethod(), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.PrivateKey)
+}
+
+// verificationKey resolves the key for a token being parsed
+func (s *TokenService) verificationKey(token *jwt.Token) (interface{}, error) {
+	if s.keySet == nil {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return s.jwtSecret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.keySet.VerificationKey(kid, time.Now())
+	if !ok {
+		return nil, errors.New("unknown signing key")
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.PublicKey(), nil
+}
+
+func (s *TokenService) GenerateAccessToken(userID int, loginID string, sessionID uuid.UUID) (string, error) {
 	claims := TokenClaims{
-		UserID:  userID,
-		LoginID: loginID,
+		UserID:    userID,
+		LoginID:   loginID,
+		SessionID: sessionID.String(),
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.accessTokenDuration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.jwtSecret)
+	return s.signClaims(claims)
 }
 
 // GenerateRefreshToken creates a random 256-bit token
@@ -74,24 +135,57 @@ func (s *TokenService) ValidateRefreshToken(token, storedHash string) bool {
 }
 
 func (s *TokenService) ValidateAccessToken(tokenString string) (*TokenClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &TokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return s.jwtSecret, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &TokenClaims{}, s.verificationKey)
 
 	if err != nil {
 		return nil, err
 	}
 
 	if claims, ok := token.Claims.(*TokenClaims); ok && token.Valid {
+		// Challenge tokens must never be accepted as access tokens
+		if claims.Purpose != "" {
+			return nil, errors.New("invalid token")
+		}
 		return claims, nil
 	}
 
 	return nil, errors.New("invalid token")
 }
 
+// GenerateChallengeToken creates a short-lived token proving the first login factor succeeded
+func (s *TokenService) GenerateChallengeToken(userID int, loginID, purpose string) (string, error) {
+	claims := TokenClaims{
+		UserID:  userID,
+		LoginID: loginID,
+		Purpose: purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(challengeTokenDuration)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	return s.signClaims(claims)
+}
+
+// ValidateChallengeToken validates a challenge token issued for the given purpose
+func (s *TokenService) ValidateChallengeToken(tokenString, purpose string) (*TokenClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &TokenClaims{}, s.verificationKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*TokenClaims); ok && token.Valid && claims.Purpose == purpose {
+		return claims, nil
+	}
+
+	return nil, errors.New("invalid challenge token")
+}
+
+func (s *TokenService) GetAccessTokenDuration() time.Duration {
+	return s.accessTokenDuration
+}
+
 func (s *TokenService) GetRefreshTokenDuration() time.Duration {
 	return s.refreshTokenDuration
 }
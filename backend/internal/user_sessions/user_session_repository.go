@@ -71,3 +71,22 @@ func (r *UserSessionRepository) RevokeAllByUserID(userID int) error {
 func (r *UserSessionRepository) DeleteExpired() error {
 	return r.uow.GetDB().Where("expires_at < ?", time.Now()).Delete(&UserSession{}).Error
 }
+
+func (r *UserSessionRepository) RevokeAllByUserIDExcept(userID int, keepSessionID uuid.UUID) error {
+	now := time.Now()
+	return r.uow.GetDB().Model(&UserSession{}).Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, keepSessionID).Update("revoked_at", now).Error
+}
+
+// GetActiveIDsByUserID returns the IDs of the user's sessions that are not revoked
+func (r *UserSessionRepository) GetActiveIDsByUserID(userID int) ([]uuid.UUID, error) {
+	var sessionIDs []uuid.UUID
+	err := r.uow.GetDB().Model(&UserSession{}).Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).Pluck("id", &sessionIDs).Error
+	return sessionIDs, err
+}
+
+// GetRevokedSince returns sessions revoked after the given time
+func (r *UserSessionRepository) GetRevokedSince(since time.Time) ([]*UserSession, error) {
+	var sessions []*UserSession
+	err := r.uow.GetDB().Where("revoked_at > ?", since).Find(&sessions).Error
+	return sessions, err
+}
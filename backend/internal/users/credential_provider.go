@@ -5,3 +5,10 @@ type CredentialProvider interface {
 	GenerateSecretValue(credential string) (string, error)
 	Validate(credential string, secretValue string) (bool, error)
 }
+
+// TimeStepCredentialProvider validates one-time codes bound to a time step,
+// so a step that was accepted once can be rejected afterwards
+type TimeStepCredentialProvider interface {
+	CredentialProvider
+	ValidateStep(credential string, secretValue string, lastUsedStep int64) (int64, bool, error)
+}
@@ -0,0 +1,195 @@
+package users
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/mailer"
+	"github.com/dannyswat/pjeasy/internal/repositories"
+)
+
+// PasswordVerifier checks the current password of a signed in user and throttles repeated failures
+type PasswordVerifier interface {
+	VerifyCurrentPassword(userID int, currentPassword, userAgent, ipAddress string) error
+}
+
+type EmailChangeService struct {
+	uowFactory       *repositories.UnitOfWorkFactory
+	tokenRepo        *EmailChangeTokenRepository
+	userService      *UserService
+	passwordVerifier PasswordVerifier
+	mailer           mailer.Mailer
+	confirmURL       string
+	tokenDuration    time.Duration
+}
+
+func NewEmailChangeService(uowFactory *repositories.UnitOfWorkFactory, tokenRepo *EmailChangeTokenRepository, userService *UserService, mailer mailer.Mailer, confirmURL string, tokenDuration time.Duration) *EmailChangeService {
+	return &EmailChangeService{
+		uowFactory:    uowFactory,
+		tokenRepo:     tokenRepo,
+		userService:   userService,
+		mailer:        mailer,
+		confirmURL:    confirmURL,
+		tokenDuration: tokenDuration,
+	}
+}
+
+// SetPasswordVerifier sets the verifier that throttles current password checks
+func (s *EmailChangeService) SetPasswordVerifier(verifier PasswordVerifier) {
+	s.passwordVerifier = verifier
+}
+
+// RequestEmailChange verifies the current password and emails a confirmation link to the new address.
+// The user's email stays unchanged until the link is opened.
+func (s *EmailChangeService) RequestEmailChange(userID int, currentPassword, email, userAgent, ipAddress string) error {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return errors.New("email is required")
+	}
+
+	user, err := s.userService.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("user not found")
+	}
+	if strings.EqualFold(user.Email, email) && user.IsEmailVerified() {
+		return errors.New("email is already set to this address")
+	}
+
+	if err := s.verifyPassword(userID, currentPassword, userAgent, ipAddress); err != nil {
+		return err
+	}
+
+	plainToken, err := generateResetToken()
+	if err != nil {
+		return err
+	}
+
+	uow := s.uowFactory.NewUnitOfWork()
+	tokenRepo := NewEmailChangeTokenRepository(uow)
+
+	uow.BeginTransaction()
+	defer uow.RollbackTransactionIfError()
+
+	// Only the most recent link stays usable
+	if err := tokenRepo.InvalidateAllByUserID(userID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := tokenRepo.Create(&EmailChangeToken{
+		UserID:      userID,
+		Email:       email,
+		TokenHash:   hashResetToken(plainToken),
+		ExpiresAt:   now.Add(s.tokenDuration),
+		RequestedIP: ipAddress,
+		CreatedAt:   now,
+	}); err != nil {
+		return err
+	}
+
+	if err := uow.CommitTransaction(); err != nil {
+		return err
+	}
+
+	if err := s.mailer.Send(&mailer.Message{
+		To:      []string{email},
+		Subject: "Confirm your new PJEasy email address",
+		Body: fmt.Sprintf("Hi %s,\n\nOpen the link below within %s to use this address for your PJEasy account:\n\n%s\n\n"+
+			"If you did not request this, you can ignore this email.\n",
+			user.Name, s.tokenDuration, s.buildConfirmLink(plainToken)),
+	}); err != nil {
+		return err
+	}
+
+	// Let the owner of the current address know in case the request was not theirs
+	if current := user.ContactEmail(); current != "" && !strings.EqualFold(current, email) {
+		if err := s.mailer.Send(&mailer.Message{
+			To:      []string{current},
+			Subject: "Your PJEasy email address is being changed",
+			Body: fmt.Sprintf("Hi %s,\n\nA change of your account email to %s was requested. "+
+				"It takes effect once the new address is confirmed.\n\n"+
+				"If you did not request this, change your password and sign out your other sessions.\n",
+				user.Name, email),
+		}); err != nil {
+			log.Printf("failed to notify user %d of an email change: %v", userID, err)
+		}
+	}
+	return nil
+}
+
+// ConfirmEmailChange sets the address of an email change token as the user's verified email
+func (s *EmailChangeService) ConfirmEmailChange(plainToken string) (*User, error) {
+	token, err := s.tokenRepo.GetByTokenHash(hashResetToken(plainToken))
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || !token.IsValid() {
+		return nil, errors.New("invalid or expired confirmation token")
+	}
+
+	user, err := s.userService.GetUserByID(token.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("invalid or expired confirmation token")
+	}
+	if !user.IsActive() {
+		return nil, errors.New("account is deactivated")
+	}
+
+	uow := s.uowFactory.NewUnitOfWork()
+	tokenRepo := NewEmailChangeTokenRepository(uow)
+	userRepo := NewUserRepository(uow)
+
+	uow.BeginTransaction()
+	defer uow.RollbackTransactionIfError()
+
+	consumed, err := tokenRepo.MarkUsed(token.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, errors.New("invalid or expired confirmation token")
+	}
+
+	now := time.Now()
+	user.Email = token.Email
+	user.EmailVerifiedAt = &now
+	user.UpdatedAt = now
+	if err := userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	if err := uow.CommitTransaction(); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *EmailChangeService) buildConfirmLink(plainToken string) string {
+	link, err := url.Parse(s.confirmURL)
+	if err != nil {
+		return s.confirmURL + "?token=" + url.QueryEscape(plainToken)
+	}
+	query := link.Query()
+	query.Set("token", plainToken)
+	link.RawQuery = query.Encode()
+	return link.String()
+}
+
+// verifyPassword checks the current password through the throttling verifier when one is set
+func (s *EmailChangeService) verifyPassword(userID int, currentPassword, userAgent, ipAddress string) error {
+	if s.passwordVerifier != nil {
+		return s.passwordVerifier.VerifyCurrentPassword(userID, currentPassword, userAgent, ipAddress)
+	}
+	return s.userService.VerifyPassword(userID, currentPassword)
+}
@@ -0,0 +1,25 @@
+package users
+
+import "time"
+
+// EmailChangeToken is a single-use token emailed to a new address to confirm it.
+// The address only replaces the user's email once the token is used. Only the SHA256 hash is stored.
+type EmailChangeToken struct {
+	ID          int        `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID      int        `gorm:"not null;index" json:"userId"`
+	Email       string     `gorm:"not null;size:255" json:"email"`
+	TokenHash   string     `gorm:"not null;uniqueIndex;size:64" json:"-"`
+	ExpiresAt   time.Time  `gorm:"not null" json:"expiresAt"`
+	UsedAt      *time.Time `json:"usedAt,omitempty"`
+	RequestedIP string     `gorm:"size:64" json:"requestedIp"`
+	CreatedAt   time.Time  `gorm:"not null" json:"createdAt"`
+}
+
+// TableName specifies the table name for GORM
+func (EmailChangeToken) TableName() string {
+	return "email_change_tokens"
+}
+
+func (t *EmailChangeToken) IsValid() bool {
+	return t.UsedAt == nil && time.Now().Before(t.ExpiresAt)
+}
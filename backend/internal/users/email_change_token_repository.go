@@ -0,0 +1,48 @@
+package users
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/repositories"
+	"gorm.io/gorm"
+)
+
+type EmailChangeTokenRepository struct {
+	uow *repositories.UnitOfWork
+}
+
+func NewEmailChangeTokenRepository(uow *repositories.UnitOfWork) *EmailChangeTokenRepository {
+	return &EmailChangeTokenRepository{uow: uow}
+}
+
+func (r *EmailChangeTokenRepository) Create(token *EmailChangeToken) error {
+	return r.uow.GetDB().Create(token).Error
+}
+
+func (r *EmailChangeTokenRepository) GetByTokenHash(tokenHash string) (*EmailChangeToken, error) {
+	var token EmailChangeToken
+	err := r.uow.GetDB().Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkUsed marks the token as used and reports whether this call consumed it
+func (r *EmailChangeTokenRepository) MarkUsed(id int) (bool, error) {
+	result := r.uow.GetDB().Model(&EmailChangeToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// InvalidateAllByUserID marks every unused token of the user as used
+func (r *EmailChangeTokenRepository) InvalidateAllByUserID(userID int) error {
+	return r.uow.GetDB().Model(&EmailChangeToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
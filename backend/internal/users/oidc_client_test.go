@@ -0,0 +1,245 @@
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type mockIdentityProvider struct {
+	server    *httptest.Server
+	key       *rsa.PrivateKey
+	challenge string
+	nonce     string
+	subject   string
+	audience  string
+}
+
+func newMockIdentityProvider(t *testing.T) *mockIdentityProvider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	idp := &mockIdentityProvider{key: key, subject: "user-123", audience: "pjeasy"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test-key",
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.Form.Get("code") != "valid-code" || PKCEChallengeS256(r.Form.Get("code_verifier")) != idp.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "opaque",
+			"token_type":   "Bearer",
+			"id_token":     idp.signIDToken(t),
+		})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *mockIdentityProvider) signIDToken(t *testing.T) string {
+	t.Helper()
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":                idp.server.URL,
+		"sub":                idp.subject,
+		"aud":                idp.audience,
+		"exp":                now.Add(time.Minute).Unix(),
+		"iat":                now.Unix(),
+		"nonce":              idp.nonce,
+		"email":              "jane@example.com",
+		"name":               "Jane Doe",
+		"preferred_username": "jane",
+	})
+	token.Header["kid"] = "test-key"
+	signed, err := token.SignedString(idp.key)
+	if err != nil {
+		t.Fatalf("sign id token: %v", err)
+	}
+	return signed
+}
+
+func TestOIDCClientAuthorizationCodeFlow(t *testing.T) {
+	idp := newMockIdentityProvider(t)
+	client := NewOIDCClient(OIDCClientConfig{
+		IssuerURL:   idp.server.URL,
+		ClientID:    "pjeasy",
+		RedirectURL: "http://localhost/api/auth/oidc/callback",
+	})
+	ctx := context.Background()
+
+	verifier, _ := GenerateOIDCRandomString()
+	authURL, err := client.AuthCodeURL(ctx, "state-1", "nonce-1", verifier)
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("parse auth url: %v", err)
+	}
+	query := parsed.Query()
+	if !strings.HasSuffix(parsed.Path, "/authorize") || query.Get("code_challenge_method") != "S256" || query.Get("state") != "state-1" {
+		t.Fatalf("unexpected auth url %q", authURL)
+	}
+	idp.challenge = query.Get("code_challenge")
+	idp.nonce = query.Get("nonce")
+
+	identity, err := client.Exchange(ctx, "valid-code", verifier, "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	if identity.Subject != "user-123" || identity.Issuer != idp.server.URL {
+		t.Fatalf("unexpected identity %+v", identity)
+	}
+	if got := identity.LoginID("preferred_username"); got != "jane" {
+		t.Fatalf("LoginID() = %q, want jane", got)
+	}
+	if got := identity.LoginID("email"); got != "jane@example.com" {
+		t.Fatalf("LoginID(email) = %q, want jane@example.com", got)
+	}
+
+	t.Run("wrong verifier", func(t *testing.T) {
+		if _, err := client.Exchange(ctx, "valid-code", "other-verifier", "nonce-1"); err == nil {
+			t.Fatalf("Exchange() expected error for wrong PKCE verifier")
+		}
+	})
+
+	t.Run("nonce mismatch", func(t *testing.T) {
+		if _, err := client.Exchange(ctx, "valid-code", verifier, "other-nonce"); err == nil {
+			t.Fatalf("Exchange() expected error for nonce mismatch")
+		}
+	})
+
+	t.Run("wrong audience", func(t *testing.T) {
+		idp.audience = "someone-else"
+		defer func() { idp.audience = "pjeasy" }()
+		if _, err := client.Exchange(ctx, "valid-code", verifier, "nonce-1"); err == nil {
+			t.Fatalf("Exchange() expected error for wrong audience")
+		}
+	})
+}
+
+func TestOIDCCredentialValidate(t *testing.T) {
+	provider := &OIDCCredential{}
+	identity := &OIDCIdentity{Issuer: "https://idp.example.com", Subject: "abc"}
+
+	secret, err := provider.GenerateSecretValue(identity.IdentityKey())
+	if err != nil {
+		t.Fatalf("GenerateSecretValue() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		credential string
+		want       bool
+	}{
+		{name: "same identity", credential: "https://idp.example.com|abc", want: true},
+		{name: "other subject", credential: "https://idp.example.com|abd", want: false},
+		{name: "other issuer", credential: "https://evil.example.com|abc", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := provider.Validate(tt.credential, secret)
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("Validate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyOIDCIdentity(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name            string
+		identity        OIDCIdentity
+		loginIDClaim    string
+		loginID         string
+		wantEmail       string
+		wantVerified    bool
+		wantProvisioned bool
+	}{
+		{
+			name:            "verified email and username",
+			identity:        OIDCIdentity{Email: "alice@example.com", EmailVerified: true, PreferredUsername: "alice"},
+			loginID:         "alice",
+			wantEmail:       "alice@example.com",
+			wantVerified:    true,
+			wantProvisioned: true,
+		},
+		{
+			name:            "unverified email is not kept",
+			identity:        OIDCIdentity{Email: "bob@example.com", PreferredUsername: "bob"},
+			loginID:         "bob",
+			wantProvisioned: true,
+		},
+		{
+			name:         "login ID from an unverified email claim",
+			identity:     OIDCIdentity{Email: "eve@example.com", Subject: "42"},
+			loginIDClaim: "email",
+			loginID:      "eve@example.com",
+		},
+		{
+			name:     "login ID the provider did not assign",
+			identity: OIDCIdentity{PreferredUsername: "carol", Subject: "7"},
+			loginID:  "carol@example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &User{LoginID: tt.loginID}
+			applyOIDCIdentity(user, &tt.identity, tt.loginIDClaim, now)
+			if user.Email != tt.wantEmail || user.IsEmailVerified() != tt.wantVerified || user.LoginIDProvisioned != tt.wantProvisioned {
+				t.Fatalf("applyOIDCIdentity() = email %q verified %v provisioned %v, want %q %v %v",
+					user.Email, user.IsEmailVerified(), user.LoginIDProvisioned, tt.wantEmail, tt.wantVerified, tt.wantProvisioned)
+			}
+		})
+	}
+}
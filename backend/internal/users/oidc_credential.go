@@ -0,0 +1,24 @@
+package users
+
+import "crypto/subtle"
+
+// OIDCCredential links a user to an external OpenID Connect identity.
+// The secret value is the issuer and subject pair of the identity.
+type OIDCCredential struct {
+}
+
+const (
+	CredentialTypeOIDC CredentialType = "oidc"
+)
+
+func (oc *OIDCCredential) GetType() CredentialType {
+	return CredentialTypeOIDC
+}
+
+func (oc *OIDCCredential) GenerateSecretValue(credential string) (string, error) {
+	return credential, nil
+}
+
+func (oc *OIDCCredential) Validate(credential string, secretValue string) (bool, error) {
+	return subtle.ConstantTimeCompare([]byte(credential), []byte(secretValue)) == 1, nil
+}
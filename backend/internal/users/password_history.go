@@ -0,0 +1,16 @@
+package users
+
+import "time"
+
+// PasswordHistory keeps previous password hashes so they cannot be reused
+type PasswordHistory struct {
+	ID          int       `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID      int       `gorm:"not null;index" json:"userId"`
+	SecretValue string    `gorm:"not null" json:"-"`
+	CreatedAt   time.Time `gorm:"not null" json:"createdAt"`
+}
+
+// TableName specifies the table name for GORM
+func (PasswordHistory) TableName() string {
+	return "password_histories"
+}
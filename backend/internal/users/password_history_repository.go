@@ -0,0 +1,31 @@
+package users
+
+import (
+	"github.com/dannyswat/pjeasy/internal/repositories"
+)
+
+type PasswordHistoryRepository struct {
+	uow *repositories.UnitOfWork
+}
+
+func NewPasswordHistoryRepository(uow *repositories.UnitOfWork) *PasswordHistoryRepository {
+	return &PasswordHistoryRepository{uow: uow}
+}
+
+func (r *PasswordHistoryRepository) Create(history *PasswordHistory) error {
+	return r.uow.GetDB().Create(history).Error
+}
+
+// GetRecentByUserID returns the most recent password hashes of a user, newest first
+func (r *PasswordHistoryRepository) GetRecentByUserID(userID int, limit int) ([]PasswordHistory, error) {
+	var histories []PasswordHistory
+	err := r.uow.GetDB().Where("user_id = ?", userID).Order("created_at DESC, id DESC").Limit(limit).Find(&histories).Error
+	return histories, err
+}
+
+// TrimByUserID keeps only the newest entries of a user
+func (r *PasswordHistoryRepository) TrimByUserID(userID int, keep int) error {
+	keepIDs := r.uow.GetDB().Model(&PasswordHistory{}).Select("id").
+		Where("user_id = ?", userID).Order("created_at DESC, id DESC").Limit(keep)
+	return r.uow.GetDB().Where("user_id = ? AND id NOT IN (?)", userID, keepIDs).Delete(&PasswordHistory{}).Error
+}
@@ -0,0 +1,128 @@
+package users
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+var commonPasswords = loadCommonPasswords(commonPasswordList)
+
+// PasswordPolicy describes the rules new passwords must satisfy
+type PasswordPolicy struct {
+	MinLength             int
+	RequireUppercase      bool
+	RequireLowercase      bool
+	RequireDigit          bool
+	RequireSymbol         bool
+	RejectCommonPasswords bool
+	HistoryCount          int           // Number of previous passwords that cannot be reused
+	MaxAge                time.Duration // Zero means passwords never expire
+}
+
+// DefaultPasswordPolicy matches the complexity rules applied before the policy was configurable
+func DefaultPasswordPolicy() *PasswordPolicy {
+	return &PasswordPolicy{
+		MinLength:             8,
+		RequireUppercase:      true,
+		RequireLowercase:      true,
+		RequireDigit:          true,
+		RequireSymbol:         true,
+		RejectCommonPasswords: true,
+	}
+}
+
+// Validate checks a candidate password against the policy
+func (p *PasswordPolicy) Validate(password, loginID string) error {
+	if len([]rune(password)) < p.MinLength {
+		return fmt.Errorf("password must be at least %d characters", p.MinLength)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+
+	if p.RequireUppercase && !hasUpper {
+		return errors.New("password must contain an uppercase letter")
+	}
+	if p.RequireLowercase && !hasLower {
+		return errors.New("password must contain a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		return errors.New("password must contain a number")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		return errors.New("password must contain a special character")
+	}
+
+	if p.RejectCommonPasswords {
+		lowered := strings.ToLower(password)
+		if commonPasswords[lowered] {
+			return errors.New("password is too common")
+		}
+		if loginID != "" && lowered == strings.ToLower(loginID) {
+			return errors.New("password must not match the login ID")
+		}
+	}
+
+	return nil
+}
+
+// ExpiryFrom returns when a password set at the given time expires, or the zero time when it never does
+func (p *PasswordPolicy) ExpiryFrom(changedAt time.Time) time.Time {
+	if p.MaxAge <= 0 {
+		return time.Time{}
+	}
+	return changedAt.Add(p.MaxAge)
+}
+
+// Requirements returns a human-readable list of the policy rules
+func (p *PasswordPolicy) Requirements() []string {
+	requirements := []string{fmt.Sprintf("At least %d characters", p.MinLength)}
+	if p.RequireUppercase {
+		requirements = append(requirements, "At least one uppercase letter (A-Z)")
+	}
+	if p.RequireLowercase {
+		requirements = append(requirements, "At least one lowercase letter (a-z)")
+	}
+	if p.RequireDigit {
+		requirements = append(requirements, "At least one number (0-9)")
+	}
+	if p.RequireSymbol {
+		requirements = append(requirements, "At least one special character (!@#$%^&*...)")
+	}
+	if p.RejectCommonPasswords {
+		requirements = append(requirements, "Not a commonly used password")
+	}
+	if p.HistoryCount > 0 {
+		requirements = append(requirements, fmt.Sprintf("Different from your last %d passwords", p.HistoryCount))
+	}
+	return requirements
+}
+
+func loadCommonPasswords(list string) map[string]bool {
+	passwords := make(map[string]bool)
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			passwords[strings.ToLower(line)] = true
+		}
+	}
+	return passwords
+}
@@ -0,0 +1,80 @@
+package users
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	policy := DefaultPasswordPolicy()
+
+	tests := []struct {
+		name     string
+		password string
+		loginID  string
+		wantErr  bool
+	}{
+		{name: "strong password", password: "Correct-Horse-9", loginID: "jane", wantErr: false},
+		{name: "too short", password: "Ab1!", loginID: "jane", wantErr: true},
+		{name: "missing uppercase", password: "correct-horse-9", loginID: "jane", wantErr: true},
+		{name: "missing lowercase", password: "CORRECT-HORSE-9", loginID: "jane", wantErr: true},
+		{name: "missing digit", password: "Correct-Horse", loginID: "jane", wantErr: true},
+		{name: "missing symbol", password: "CorrectHorse9", loginID: "jane", wantErr: true},
+		{name: "common password", password: "P@ssw0rd1", loginID: "jane", wantErr: true},
+		{name: "same as login ID", password: "Jane.Doe-2024", loginID: "jane.doe-2024", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.password, tt.loginID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate(%q) error = %v, wantErr %v", tt.password, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyRelaxedRules(t *testing.T) {
+	policy := &PasswordPolicy{MinLength: 12}
+
+	if err := policy.Validate("alllowercaseletters", ""); err != nil {
+		t.Fatalf("Validate() error = %v, want nil", err)
+	}
+	if err := policy.Validate("short", ""); err == nil {
+		t.Fatalf("Validate() expected minimum length error")
+	}
+}
+
+func TestPasswordPolicyExpiryFrom(t *testing.T) {
+	changedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if got := (&PasswordPolicy{}).ExpiryFrom(changedAt); !got.IsZero() {
+		t.Fatalf("ExpiryFrom() = %v, want zero time when MaxAge is not set", got)
+	}
+
+	policy := &PasswordPolicy{MaxAge: 90 * 24 * time.Hour}
+	want := changedAt.Add(90 * 24 * time.Hour)
+	if got := policy.ExpiryFrom(changedAt); !got.Equal(want) {
+		t.Fatalf("ExpiryFrom() = %v, want %v", got, want)
+	}
+}
+
+func TestIsCredentialExpired(t *testing.T) {
+	tests := []struct {
+		name        string
+		expireAfter time.Time
+		want        bool
+	}{
+		{name: "never expires", expireAfter: time.Time{}, want: false},
+		{name: "expires later", expireAfter: time.Now().Add(time.Hour), want: false},
+		{name: "expired", expireAfter: time.Now().Add(-time.Hour), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isCredentialExpired(&UserCredential{ExpireAfter: tt.expireAfter}); got != tt.want {
+				t.Fatalf("isCredentialExpired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
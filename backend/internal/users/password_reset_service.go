@@ -0,0 +1,174 @@
+package users
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/mailer"
+	"github.com/dannyswat/pjeasy/internal/repositories"
+)
+
+type PasswordResetService struct {
+	uowFactory    *repositories.UnitOfWorkFactory
+	resetRepo     *PasswordResetTokenRepository
+	userService   *UserService
+	mailer        mailer.Mailer
+	resetURL      string
+	tokenDuration time.Duration
+}
+
+func NewPasswordResetService(uowFactory *repositories.UnitOfWorkFactory, resetRepo *PasswordResetTokenRepository, userService *UserService, mailer mailer.Mailer, resetURL string, tokenDuration time.Duration) *PasswordResetService {
+	return &PasswordResetService{
+		uowFactory:    uowFactory,
+		resetRepo:     resetRepo,
+		userService:   userService,
+		mailer:        mailer,
+		resetURL:      resetURL,
+		tokenDuration: tokenDuration,
+	}
+}
+
+// RequestReset emails a reset link to the user. Unknown login IDs and accounts without
+// a password or email address are ignored so callers cannot probe for accounts.
+func (s *PasswordResetService) RequestReset(loginID, ipAddress string) error {
+	user, err := s.userService.repo.GetByLoginID(loginID)
+	if err != nil {
+		return err
+	}
+	if user == nil || !user.IsActive() || user.ContactEmail() == "" {
+		return nil
+	}
+
+	hasPassword, err := s.userService.HasPassword(user.ID)
+	if err != nil {
+		return err
+	}
+	if !hasPassword {
+		return nil
+	}
+
+	plainToken, err := generateResetToken()
+	if err != nil {
+		return err
+	}
+
+	uow := s.uowFactory.NewUnitOfWork()
+	resetRepo := NewPasswordResetTokenRepository(uow)
+
+	uow.BeginTransaction()
+	defer uow.RollbackTransactionIfError()
+
+	// Only the most recent link stays usable
+	if err := resetRepo.InvalidateAllByUserID(user.ID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := resetRepo.Create(&PasswordResetToken{
+		UserID:      user.ID,
+		TokenHash:   hashResetToken(plainToken),
+		ExpiresAt:   now.Add(s.tokenDuration),
+		RequestedIP: ipAddress,
+		CreatedAt:   now,
+	}); err != nil {
+		return err
+	}
+
+	if err := uow.CommitTransaction(); err != nil {
+		return err
+	}
+
+	return s.mailer.Send(&mailer.Message{
+		To:      []string{user.ContactEmail()},
+		Subject: "Reset your PJEasy password",
+		Body: fmt.Sprintf("Hi %s,\n\nWe received a request to reset your password. "+
+			"Open the link below within %s to choose a new one:\n\n%s\n\n"+
+			"If you did not request this, you can ignore this email.\n",
+			user.Name, s.tokenDuration, s.buildResetLink(plainToken)),
+	})
+}
+
+// ResetPassword sets a new password using a reset token and returns the affected user
+func (s *PasswordResetService) ResetPassword(plainToken, newPassword string) (*User, error) {
+	token, err := s.resetRepo.GetByTokenHash(hashResetToken(plainToken))
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || !token.IsValid() {
+		return nil, errors.New("invalid or expired reset token")
+	}
+
+	user, err := s.userService.GetUserByID(token.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("invalid or expired reset token")
+	}
+	if !user.IsActive() {
+		return nil, errors.New("account is deactivated")
+	}
+
+	uow := s.uowFactory.NewUnitOfWork()
+	resetRepo := NewPasswordResetTokenRepository(uow)
+	credRepo := NewUserCredentialRepository(uow)
+
+	uow.BeginTransaction()
+	defer uow.RollbackTransactionIfError()
+
+	consumed, err := resetRepo.MarkUsed(token.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, errors.New("invalid or expired reset token")
+	}
+
+	credential, err := credRepo.GetByUserIDAndType(user.ID, s.userService.passwordProvider.GetType())
+	if err != nil {
+		return nil, err
+	}
+	if credential == nil {
+		return nil, errors.New("password login is not enabled for this account")
+	}
+
+	if err := s.userService.setPassword(uow, user.ID, credential, newPassword); err != nil {
+		return nil, err
+	}
+
+	if err := uow.CommitTransaction(); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *PasswordResetService) buildResetLink(plainToken string) string {
+	link, err := url.Parse(s.resetURL)
+	if err != nil {
+		return s.resetURL + "?token=" + url.QueryEscape(plainToken)
+	}
+	query := link.Query()
+	query.Set("token", plainToken)
+	link.RawQuery = query.Encode()
+	return link.String()
+}
+
+func generateResetToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashResetToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
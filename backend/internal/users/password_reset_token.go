@@ -0,0 +1,24 @@
+package users
+
+import "time"
+
+// PasswordResetToken is a single-use token emailed to a user who forgot their password.
+// Only the SHA256 hash of the token is stored.
+type PasswordResetToken struct {
+	ID          int        `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID      int        `gorm:"not null;index" json:"userId"`
+	TokenHash   string     `gorm:"not null;uniqueIndex;size:64" json:"-"`
+	ExpiresAt   time.Time  `gorm:"not null" json:"expiresAt"`
+	UsedAt      *time.Time `json:"usedAt,omitempty"`
+	RequestedIP string     `gorm:"size:64" json:"requestedIp"`
+	CreatedAt   time.Time  `gorm:"not null" json:"createdAt"`
+}
+
+// TableName specifies the table name for GORM
+func (PasswordResetToken) TableName() string {
+	return "password_reset_tokens"
+}
+
+func (t *PasswordResetToken) IsValid() bool {
+	return t.UsedAt == nil && time.Now().Before(t.ExpiresAt)
+}
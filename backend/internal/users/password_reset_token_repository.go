@@ -0,0 +1,52 @@
+package users
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dannyswat/pjeasy/internal/repositories"
+	"gorm.io/gorm"
+)
+
+type PasswordResetTokenRepository struct {
+	uow *repositories.UnitOfWork
+}
+
+func NewPasswordResetTokenRepository(uow *repositories.UnitOfWork) *PasswordResetTokenRepository {
+	return &PasswordResetTokenRepository{uow: uow}
+}
+
+func (r *PasswordResetTokenRepository) Create(token *PasswordResetToken) error {
+	return r.uow.GetDB().Create(token).Error
+}
+
+func (r *PasswordResetTokenRepository) GetByTokenHash(tokenHash string) (*PasswordResetToken, error) {
+	var token PasswordResetToken
+	err := r.uow.GetDB().Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkUsed marks the token as used and reports whether this call consumed it
+func (r *PasswordResetTokenRepository) MarkUsed(id int) (bool, error) {
+	result := r.uow.GetDB().Model(&PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// InvalidateAllByUserID marks every unused token of the user as used
+func (r *PasswordResetTokenRepository) InvalidateAllByUserID(userID int) error {
+	return r.uow.GetDB().Model(&PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
+
+func (r *PasswordResetTokenRepository) DeleteExpired() error {
+	return r.uow.GetDB().Where("expires_at < ?", time.Now()).Delete(&PasswordResetToken{}).Error
+}
@@ -0,0 +1,62 @@
+package users
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+)
+
+// RecoveryCodeCredential stores single-use two-factor recovery codes as SHA256 hashes
+type RecoveryCodeCredential struct {
+}
+
+const (
+	CredentialTypeRecoveryCode CredentialType = "recovery_code"
+
+	recoveryCodeCount    = 10
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz123456789"
+)
+
+func (rc *RecoveryCodeCredential) GetType() CredentialType {
+	return CredentialTypeRecoveryCode
+}
+
+func (rc *RecoveryCodeCredential) GenerateSecretValue(credential string) (string, error) {
+	hash := sha256.Sum256([]byte(normalizeRecoveryCode(credential)))
+	return hex.EncodeToString(hash[:]), nil
+}
+
+func (rc *RecoveryCodeCredential) Validate(credential string, secretValue string) (bool, error) {
+	hashed, err := rc.GenerateSecretValue(credential)
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare([]byte(hashed), []byte(secretValue)) == 1, nil
+}
+
+// GenerateRecoveryCodes returns a fresh set of human friendly recovery codes (xxxxx-xxxxx)
+func GenerateRecoveryCodes() ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		var sb strings.Builder
+		for j, v := range b {
+			if j == 5 {
+				sb.WriteByte('-')
+			}
+			sb.WriteByte(recoveryCodeAlphabet[int(v)&31])
+		}
+		codes = append(codes, sb.String())
+	}
+	return codes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, " ", "")
+}
@@ -0,0 +1,140 @@
+package users
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTPCredential validates RFC 6238 time-based one-time passwords.
+// The secret value is the base32 encoded shared secret.
+type TOTPCredential struct {
+}
+
+const (
+	CredentialTypeTOTP        CredentialType = "totp"
+	CredentialTypeTOTPPending CredentialType = "totp_pending"
+
+	totpPeriod     = 30
+	totpDigits     = 6
+	totpSkewSteps  = 1
+	totpSecretSize = 20
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func (tc *TOTPCredential) GetType() CredentialType {
+	return CredentialTypeTOTP
+}
+
+// GenerateSecretValue normalizes a base32 secret, generating a new one when empty
+func (tc *TOTPCredential) GenerateSecretValue(credential string) (string, error) {
+	if credential == "" {
+		return GenerateTOTPSecret()
+	}
+	secret := normalizeTOTPSecret(credential)
+	if _, err := totpEncoding.DecodeString(secret); err != nil {
+		return "", fmt.Errorf("invalid TOTP secret: %w", err)
+	}
+	return secret, nil
+}
+
+// Validate checks a code against the secret allowing one step of clock skew
+func (tc *TOTPCredential) Validate(credential string, secretValue string) (bool, error) {
+	return ValidateTOTPCode(secretValue, credential, time.Now())
+}
+
+// ValidateStep checks a code like Validate but only for time steps after lastUsedStep,
+// and returns the step the code belongs to
+func (tc *TOTPCredential) ValidateStep(credential string, secretValue string, lastUsedStep int64) (int64, bool, error) {
+	return ValidateTOTPCodeAfter(secretValue, credential, time.Now(), lastUsedStep)
+}
+
+// GenerateTOTPSecret returns a new random base32 encoded secret
+func GenerateTOTPSecret() (string, error) {
+	b := make([]byte, totpSecretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// GenerateTOTPCode returns the code for the secret at the given time
+func GenerateTOTPCode(secret string, at time.Time) (string, error) {
+	key, err := totpEncoding.DecodeString(normalizeTOTPSecret(secret))
+	if err != nil {
+		return "", fmt.Errorf("invalid TOTP secret: %w", err)
+	}
+	return totpCodeForCounter(key, uint64(at.Unix()/totpPeriod)), nil
+}
+
+// ValidateTOTPCode checks a code for the given time allowing one step of clock skew
+func ValidateTOTPCode(secret, code string, at time.Time) (bool, error) {
+	_, valid, err := ValidateTOTPCodeAfter(secret, code, at, -1)
+	return valid, err
+}
+
+// ValidateTOTPCodeAfter checks a code for the given time allowing one step of clock skew,
+// ignoring steps up to lastUsedStep, and returns the matching step
+func ValidateTOTPCodeAfter(secret, code string, at time.Time, lastUsedStep int64) (int64, bool, error) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != totpDigits {
+		return 0, false, nil
+	}
+
+	key, err := totpEncoding.DecodeString(normalizeTOTPSecret(secret))
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid TOTP secret: %w", err)
+	}
+
+	counter := at.Unix() / totpPeriod
+	for step := max(counter-totpSkewSteps, lastUsedStep+1); step <= counter+totpSkewSteps; step++ {
+		expected := totpCodeForCounter(key, uint64(step))
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// TOTPProvisioningURI builds the otpauth:// URI used by authenticator apps
+func TOTPProvisioningURI(issuer, accountName, secret string) string {
+	label := url.PathEscape(issuer + ":" + accountName)
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprintf("%d", totpDigits))
+	query.Set("period", fmt.Sprintf("%d", totpPeriod))
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+func totpCodeForCounter(key []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%mod)
+}
+
+func normalizeTOTPSecret(secret string) string {
+	secret = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(secret), " ", ""))
+	return strings.TrimRight(secret, "=")
+}
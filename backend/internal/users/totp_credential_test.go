@@ -0,0 +1,121 @@
+package users
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+func TestGenerateTOTPCodeRFC6238Vectors(t *testing.T) {
+	// RFC 6238 appendix B SHA1 vectors truncated to six digits
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+		{unix: 20000000000, want: "353130"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got, err := GenerateTOTPCode(secret, time.Unix(tt.unix, 0))
+			if err != nil {
+				t.Fatalf("GenerateTOTPCode() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("GenerateTOTPCode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPCodeAllowsOneStepOfSkew(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret() error = %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name   string
+		offset time.Duration
+		want   bool
+	}{
+		{name: "current step", offset: 0, want: true},
+		{name: "previous step", offset: -30 * time.Second, want: true},
+		{name: "next step", offset: 30 * time.Second, want: true},
+		{name: "two steps old", offset: -60 * time.Second, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := GenerateTOTPCode(secret, now.Add(tt.offset))
+			if err != nil {
+				t.Fatalf("GenerateTOTPCode() error = %v", err)
+			}
+			got, err := ValidateTOTPCode(secret, code, now)
+			if err != nil {
+				t.Fatalf("ValidateTOTPCode() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("ValidateTOTPCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPCodeAfterRejectsUsedSteps(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret() error = %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	step := now.Unix() / totpPeriod
+
+	code, err := GenerateTOTPCode(secret, now)
+	if err != nil {
+		t.Fatalf("GenerateTOTPCode() error = %v", err)
+	}
+
+	got, valid, err := ValidateTOTPCodeAfter(secret, code, now, step-1)
+	if err != nil || !valid || got != step {
+		t.Fatalf("ValidateTOTPCodeAfter() = %d, %v, %v, want %d, true, nil", got, valid, err, step)
+	}
+
+	// The same code is still inside the skew window one step later but its step was used
+	if _, valid, _ := ValidateTOTPCodeAfter(secret, code, now.Add(30*time.Second), step); valid {
+		t.Fatalf("ValidateTOTPCodeAfter() accepted a replayed code")
+	}
+}
+
+func TestRecoveryCodeCredential(t *testing.T) {
+	provider := &RecoveryCodeCredential{}
+	codes, err := GenerateRecoveryCodes()
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes() error = %v", err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("GenerateRecoveryCodes() returned %d codes, want %d", len(codes), recoveryCodeCount)
+	}
+
+	hashed, err := provider.GenerateSecretValue(codes[0])
+	if err != nil {
+		t.Fatalf("GenerateSecretValue() error = %v", err)
+	}
+	if hashed == codes[0] {
+		t.Fatalf("GenerateSecretValue() must not store the plain code")
+	}
+
+	if ok, _ := provider.Validate(" "+codes[0]+" ", hashed); !ok {
+		t.Fatalf("Validate() rejected the issued code")
+	}
+	if ok, _ := provider.Validate(codes[1], hashed); ok {
+		t.Fatalf("Validate() accepted a different code")
+	}
+}
@@ -1,12 +1,47 @@
 package users
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 type User struct {
-	ID              int
-	LoginID         string
-	Name            string
-	ProfileImageURL string
-	CreatedAt       time.Time
-	UpdatedAt       time.Time
+	ID                 int
+	LoginID            string
+	LoginIDProvisioned bool // The login ID was assigned by the OIDC provider instead of chosen at registration
+	Name               string
+	Email              string
+	EmailVerifiedAt    *time.Time // Set when the address was confirmed by link or by the OIDC provider
+	ProfileImageURL    string
+	LastLoginAt        *time.Time
+	DeactivatedAt      *time.Time // Deactivated users cannot log in but keep memberships and assignments
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+}
+
+// User status filters for the admin directory
+const (
+	UserStatusActive      = "active"
+	UserStatusDeactivated = "deactivated"
+)
+
+// IsActive checks if the user is allowed to log in
+func (u *User) IsActive() bool {
+	return u.DeactivatedAt == nil
+}
+
+// IsEmailVerified checks if the user proved they own the email address
+func (u *User) IsEmailVerified() bool {
+	return u.Email != "" && u.EmailVerifiedAt != nil
+}
+
+// ContactEmail returns the address used for account email, falling back to an email-like login ID
+func (u *User) ContactEmail() string {
+	if u.Email != "" {
+		return u.Email
+	}
+	if strings.Contains(u.LoginID, "@") {
+		return u.LoginID
+	}
+	return ""
 }
@@ -9,6 +9,7 @@ type UserCredential struct {
 	SecretValue        string
 	LastSecretChangeAt time.Time
 	ExpireAfter        time.Time
+	LastUsedStep       int64 // Time step of the last accepted TOTP code, so a code cannot be replayed
 }
 
 type CredentialType string
@@ -50,3 +50,40 @@ func (r *UserCredentialRepository) GetByUserIDAndType(userID int, credType Crede
 	}
 	return &credential, nil
 }
+
+func (r *UserCredentialRepository) GetByTypeAndSecretValue(credType CredentialType, secretValue string) (*UserCredential, error) {
+	var credential UserCredential
+	err := r.uow.GetDB().Where("type = ? AND secret_value = ?", credType, secretValue).First(&credential).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &credential, nil
+}
+
+func (r *UserCredentialRepository) ListByUserIDAndType(userID int, credType CredentialType) ([]UserCredential, error) {
+	var credentials []UserCredential
+	err := r.uow.GetDB().Where("user_id = ? AND type = ?", userID, credType).Order("id ASC").Find(&credentials).Error
+	return credentials, err
+}
+
+func (r *UserCredentialRepository) DeleteByUserIDAndType(userID int, credType CredentialType) error {
+	return r.uow.GetDB().Where("user_id = ? AND type = ?", userID, credType).Delete(&UserCredential{}).Error
+}
+
+// AdvanceLastUsedStep records an accepted time step and reports whether it is newer than the last one,
+// so two requests cannot both accept the same code
+func (r *UserCredentialRepository) AdvanceLastUsedStep(id int, step int64) (bool, error) {
+	result := r.uow.GetDB().Model(&UserCredential{}).
+		Where("id = ? AND last_used_step < ?", id, step).
+		Update("last_used_step", step)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Consume deletes a single-use credential and reports whether this call removed it
+func (r *UserCredentialRepository) Consume(id int) (bool, error) {
+	result := r.uow.GetDB().Delete(&UserCredential{}, id)
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,56 @@
+package users
+
+import (
+	"errors"
+	"regexp"
+	"time"
+)
+
+// UserPreference stores per-user settings used by the UI
+type UserPreference struct {
+	UserID           int       `gorm:"primaryKey" json:"userId"`
+	Timezone         string    `gorm:"size:64" json:"timezone"`
+	Locale           string    `gorm:"size:35" json:"locale"`
+	DefaultProjectID *int      `json:"defaultProjectId,omitempty"` // Landing project after login
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+// TableName specifies the table name for GORM
+func (UserPreference) TableName() string {
+	return "user_preferences"
+}
+
+// Defaults applied when a user has not saved preferences
+const (
+	DefaultTimezone = "UTC"
+	DefaultLocale   = "en"
+)
+
+// Language with optional script and region, e.g. en, en-US, zh-Hant-HK
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Z][a-z]{3})?(-([A-Z]{2}|[0-9]{3}))?$`)
+
+// DefaultUserPreference returns the preferences of a user who has not saved any
+func DefaultUserPreference(userID int) *UserPreference {
+	return &UserPreference{
+		UserID:   userID,
+		Timezone: DefaultTimezone,
+		Locale:   DefaultLocale,
+	}
+}
+
+// Validate checks the timezone is a known IANA zone and the locale is a BCP 47 tag
+func (p *UserPreference) Validate() error {
+	if p.Timezone == "" {
+		return errors.New("timezone is required")
+	}
+	if _, err := time.LoadLocation(p.Timezone); err != nil {
+		return errors.New("unknown timezone")
+	}
+	if !localePattern.MatchString(p.Locale) {
+		return errors.New("invalid locale")
+	}
+	if p.DefaultProjectID != nil && *p.DefaultProjectID <= 0 {
+		return errors.New("invalid default project")
+	}
+	return nil
+}
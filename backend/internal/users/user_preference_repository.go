@@ -0,0 +1,33 @@
+package users
+
+import (
+	"errors"
+
+	"github.com/dannyswat/pjeasy/internal/repositories"
+	"gorm.io/gorm"
+)
+
+type UserPreferenceRepository struct {
+	uow *repositories.UnitOfWork
+}
+
+func NewUserPreferenceRepository(uow *repositories.UnitOfWork) *UserPreferenceRepository {
+	return &UserPreferenceRepository{uow: uow}
+}
+
+func (r *UserPreferenceRepository) GetByUserID(userID int) (*UserPreference, error) {
+	var preference UserPreference
+	err := r.uow.GetDB().Where("user_id = ?", userID).First(&preference).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &preference, nil
+}
+
+// Save creates or replaces the preferences of a user
+func (r *UserPreferenceRepository) Save(preference *UserPreference) error {
+	return r.uow.GetDB().Save(preference).Error
+}
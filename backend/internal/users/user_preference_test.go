@@ -0,0 +1,31 @@
+package users
+
+import "testing"
+
+func TestUserPreferenceValidate(t *testing.T) {
+	projectID := 3
+	invalidProjectID := 0
+
+	tests := []struct {
+		name       string
+		preference UserPreference
+		wantErr    bool
+	}{
+		{name: "defaults", preference: *DefaultUserPreference(1)},
+		{name: "region and project", preference: UserPreference{Timezone: "Asia/Hong_Kong", Locale: "zh-HK", DefaultProjectID: &projectID}},
+		{name: "script subtag", preference: UserPreference{Timezone: "Europe/London", Locale: "zh-Hant-TW"}},
+		{name: "unknown timezone", preference: UserPreference{Timezone: "Mars/Olympus", Locale: "en"}, wantErr: true},
+		{name: "missing timezone", preference: UserPreference{Locale: "en"}, wantErr: true},
+		{name: "bad locale", preference: UserPreference{Timezone: "UTC", Locale: "english"}, wantErr: true},
+		{name: "bad project", preference: UserPreference{Timezone: "UTC", Locale: "en", DefaultProjectID: &invalidProjectID}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.preference.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@ package users
 
 import (
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/dannyswat/pjeasy/internal/repositories"
 	"gorm.io/gorm"
@@ -43,6 +45,44 @@ func (r *UserRepository) GetByLoginID(loginID string) (*User, error) {
 	return &user, nil
 }
 
+// Search returns users whose login ID or name contains the query, filtered by status
+func (r *UserRepository) Search(query, status string, offset, limit int) ([]User, int64, error) {
+	db := r.uow.GetDB().Model(&User{})
+
+	if trimmedQuery := strings.TrimSpace(query); trimmedQuery != "" {
+		pattern := "%" + trimmedQuery + "%"
+		db = db.Where("login_id ILIKE ? OR name ILIKE ?", pattern, pattern)
+	}
+	switch status {
+	case UserStatusActive:
+		db = db.Where("deactivated_at IS NULL")
+	case UserStatusDeactivated:
+		db = db.Where("deactivated_at IS NOT NULL")
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []User
+	err := db.Order("login_id ASC").Offset(offset).Limit(limit).Find(&users).Error
+	return users, total, err
+}
+
+// UpdateLastLoginAt records when the user last logged in
+func (r *UserRepository) UpdateLastLoginAt(userID int, loginAt time.Time) error {
+	return r.uow.GetDB().Model(&User{}).Where("id = ?", userID).Update("last_login_at", loginAt).Error
+}
+
+// UpdateDeactivatedAt sets or clears the deactivation time
+func (r *UserRepository) UpdateDeactivatedAt(userID int, deactivatedAt *time.Time) error {
+	return r.uow.GetDB().Model(&User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"deactivated_at": deactivatedAt,
+		"updated_at":     time.Now(),
+	}).Error
+}
+
 func (r *UserRepository) Update(user *User) error {
 	return r.uow.GetDB().Save(user).Error
 }
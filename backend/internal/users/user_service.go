@@ -2,6 +2,8 @@ package users
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/dannyswat/pjeasy/internal/repositories"
@@ -11,19 +13,60 @@ type UserService struct {
 	uowFactory       *repositories.UnitOfWorkFactory
 	repo             *UserRepository
 	credRepo         *UserCredentialRepository
+	preferenceRepo   *UserPreferenceRepository
 	passwordProvider CredentialProvider
+	oidcProvider     CredentialProvider
+	totpProvider     TimeStepCredentialProvider
+	recoveryProvider CredentialProvider
+	passwordPolicy   *PasswordPolicy
 }
 
-func NewUserService(uowFactory *repositories.UnitOfWorkFactory, repo *UserRepository, credRepo *UserCredentialRepository, passwordProvider CredentialProvider) *UserService {
+// TOTPIssuer is the issuer name shown in authenticator apps
+const TOTPIssuer = "PJEasy"
+
+// ErrInvalidVerificationCode is returned when a TOTP or recovery code is wrong or was already used
+var ErrInvalidVerificationCode = errors.New("invalid verification code")
+
+// ErrIncorrectCurrentPassword is returned when the current password confirming an account change is wrong
+var ErrIncorrectCurrentPassword = errors.New("current password is incorrect")
+
+// TOTPEnrollment holds the secret a user adds to their authenticator app
+type TOTPEnrollment struct {
+	Secret          string
+	ProvisioningURI string
+}
+
+// TwoFactorStatus summarizes a user's two-factor authentication setup
+type TwoFactorStatus struct {
+	Enabled                bool
+	RecoveryCodesRemaining int
+}
+
+func NewUserService(uowFactory *repositories.UnitOfWorkFactory, repo *UserRepository, credRepo *UserCredentialRepository, preferenceRepo *UserPreferenceRepository, passwordProvider CredentialProvider, oidcProvider CredentialProvider, totpProvider TimeStepCredentialProvider, recoveryProvider CredentialProvider) *UserService {
 	return &UserService{
 		uowFactory:       uowFactory,
 		repo:             repo,
 		credRepo:         credRepo,
+		preferenceRepo:   preferenceRepo,
 		passwordProvider: passwordProvider,
+		oidcProvider:     oidcProvider,
+		totpProvider:     totpProvider,
+		recoveryProvider: recoveryProvider,
+		passwordPolicy:   DefaultPasswordPolicy(),
 	}
 }
 
-func (s *UserService) RegisterWithPassword(loginID, name, password string) (*User, error) {
+// SetPasswordPolicy sets the policy applied whenever a password is set
+func (s *UserService) SetPasswordPolicy(policy *PasswordPolicy) {
+	s.passwordPolicy = policy
+}
+
+// GetPasswordPolicy returns the policy applied whenever a password is set
+func (s *UserService) GetPasswordPolicy() *PasswordPolicy {
+	return s.passwordPolicy
+}
+
+func (s *UserService) RegisterWithPassword(loginID, name, email, password string) (*User, error) {
 	// Check if user already exists
 	existingUser, err := s.repo.GetByLoginID(loginID)
 	if err != nil {
@@ -33,9 +76,14 @@ func (s *UserService) RegisterWithPassword(loginID, name, password string) (*Use
 		return nil, errors.New("user with this login ID already exists")
 	}
 
+	if err := s.passwordPolicy.Validate(password, loginID); err != nil {
+		return nil, err
+	}
+
 	user := &User{
 		LoginID:   loginID,
 		Name:      name,
+		Email:     email,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -57,11 +105,13 @@ func (s *UserService) RegisterWithPassword(loginID, name, password string) (*Use
 	}
 
 	// Create user credential
+	now := time.Now()
 	credential := &UserCredential{
 		UserID:             user.ID,
 		Type:               s.passwordProvider.GetType(),
 		SecretValue:        hashedPassword,
-		LastSecretChangeAt: time.Now(),
+		LastSecretChangeAt: now,
+		ExpireAfter:        s.passwordPolicy.ExpiryFrom(now),
 	}
 
 	err = credRepo.Create(credential)
@@ -104,10 +154,696 @@ func (s *UserService) AuthenticateWithPassword(loginID, password string) (*User,
 	if !valid {
 		return nil, errors.New("invalid credentials")
 	}
+	if !user.IsActive() {
+		return nil, errors.New("account is deactivated")
+	}
 
 	return user, nil
 }
 
+// AuthenticateWithOIDC resolves the user linked to a verified OIDC identity.
+// When no link exists and allowCreate is set, a new user is created just-in-time.
+func (s *UserService) AuthenticateWithOIDC(identity *OIDCIdentity, loginIDClaim string, allowCreate bool) (*User, error) {
+	if identity == nil || identity.Subject == "" {
+		return nil, errors.New("invalid credentials")
+	}
+
+	identityKey, err := s.oidcProvider.GenerateSecretValue(identity.IdentityKey())
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := s.credRepo.GetByTypeAndSecretValue(s.oidcProvider.GetType(), identityKey)
+	if err != nil {
+		return nil, err
+	}
+	if credential != nil {
+		valid, err := s.oidcProvider.Validate(identity.IdentityKey(), credential.SecretValue)
+		if err != nil {
+			return nil, err
+		}
+		if !valid {
+			return nil, errors.New("invalid credentials")
+		}
+
+		user, err := s.repo.GetByID(credential.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, errors.New("invalid credentials")
+		}
+		if !user.IsActive() {
+			return nil, errors.New("account is deactivated")
+		}
+		if applyOIDCIdentity(user, identity, loginIDClaim, time.Now()) {
+			if err := s.repo.Update(user); err != nil {
+				return nil, err
+			}
+		}
+		return user, nil
+	}
+
+	if !allowCreate {
+		return nil, errors.New("no user is linked to this identity")
+	}
+
+	loginID := identity.LoginID(loginIDClaim)
+	if loginID == "" {
+		return nil, errors.New("identity does not provide a login ID")
+	}
+
+	// Never attach an external identity to an existing account implicitly
+	existingUser, err := s.repo.GetByLoginID(loginID)
+	if err != nil {
+		return nil, err
+	}
+	if existingUser != nil {
+		return nil, errors.New("user with this login ID already exists")
+	}
+
+	now := time.Now()
+	user := &User{
+		LoginID:   loginID,
+		Name:      identity.DisplayName(),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	applyOIDCIdentity(user, identity, loginIDClaim, now)
+
+	uow := s.uowFactory.NewUnitOfWork()
+	userRepo := NewUserRepository(uow)
+	credRepo := NewUserCredentialRepository(uow)
+
+	uow.BeginTransaction()
+	defer uow.RollbackTransactionIfError()
+
+	if err := userRepo.Create(user); err != nil {
+		return nil, err
+	}
+
+	if err := credRepo.Create(&UserCredential{
+		UserID:             user.ID,
+		Type:               s.oidcProvider.GetType(),
+		SecretValue:        identityKey,
+		LastSecretChangeAt: now,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := uow.CommitTransaction(); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// applyOIDCIdentity marks the email and login ID the provider vouches for as verified
+// and reports whether the user changed
+func applyOIDCIdentity(user *User, identity *OIDCIdentity, loginIDClaim string, now time.Time) bool {
+	changed := false
+	if identity.EmailVerified && identity.Email != "" && user.EmailVerifiedAt == nil &&
+		(user.Email == "" || strings.EqualFold(user.Email, identity.Email)) {
+		user.Email = identity.Email
+		user.EmailVerifiedAt = &now
+		changed = true
+	}
+
+	// A login ID taken from the email claim is only as trustworthy as the email
+	if !user.LoginIDProvisioned && identity.LoginID(loginIDClaim) == user.LoginID &&
+		(identity.EmailVerified || !strings.EqualFold(user.LoginID, identity.Email)) {
+		user.LoginIDProvisioned = true
+		changed = true
+	}
+
+	if changed {
+		user.UpdatedAt = now
+	}
+	return changed
+}
+
+// GetUserByLoginID returns the user with the login ID, or nil when none exists
+func (s *UserService) GetUserByLoginID(loginID string) (*User, error) {
+	return s.repo.GetByLoginID(loginID)
+}
+
 func (s *UserService) GetUserByID(userID int) (*User, error) {
 	return s.repo.GetByID(userID)
 }
+
+// UpdateProfile changes the user's display name. The email is changed through EmailChangeService.
+func (s *UserService) UpdateProfile(userID int, name string) (*User, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+
+	user, err := s.repo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	user.Name = name
+	user.UpdatedAt = time.Now()
+	if err := s.repo.Update(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// UpdateProfileImage sets the user's avatar URL and returns the previous one
+func (s *UserService) UpdateProfileImage(userID int, imageURL string) (string, error) {
+	user, err := s.repo.GetByID(userID)
+	if err != nil {
+		return "", err
+	}
+	if user == nil {
+		return "", errors.New("user not found")
+	}
+
+	previous := user.ProfileImageURL
+	user.ProfileImageURL = imageURL
+	user.UpdatedAt = time.Now()
+	if err := s.repo.Update(user); err != nil {
+		return "", err
+	}
+	return previous, nil
+}
+
+// GetPreferences returns the user's saved preferences, or the defaults when none are saved
+func (s *UserService) GetPreferences(userID int) (*UserPreference, error) {
+	preference, err := s.preferenceRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if preference == nil {
+		return DefaultUserPreference(userID), nil
+	}
+	return preference, nil
+}
+
+// UpdatePreferences validates and stores the user's preferences
+func (s *UserService) UpdatePreferences(preference *UserPreference) (*UserPreference, error) {
+	if err := preference.Validate(); err != nil {
+		return nil, err
+	}
+
+	preference.UpdatedAt = time.Now()
+	if err := s.preferenceRepo.Save(preference); err != nil {
+		return nil, err
+	}
+	return preference, nil
+}
+
+// ListUsers searches users by login ID or name for the admin directory
+func (s *UserService) ListUsers(search, status string, page, pageSize int) ([]User, int64, error) {
+	if status != "" && status != UserStatusActive && status != UserStatusDeactivated {
+		return nil, 0, errors.New("invalid user status")
+	}
+
+	offset := (page - 1) * pageSize
+	return s.repo.Search(search, status, offset, pageSize)
+}
+
+// RecordLogin stores the time of a successful login
+func (s *UserService) RecordLogin(userID int) error {
+	return s.repo.UpdateLastLoginAt(userID, time.Now())
+}
+
+// DeactivateUser blocks the user from logging in. Memberships and assigned items are kept.
+func (s *UserService) DeactivateUser(userID int) (*User, error) {
+	user, err := s.repo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+	if !user.IsActive() {
+		return nil, errors.New("user is already deactivated")
+	}
+
+	now := time.Now()
+	if err := s.repo.UpdateDeactivatedAt(userID, &now); err != nil {
+		return nil, err
+	}
+	user.DeactivatedAt = &now
+	return user, nil
+}
+
+// ReactivateUser allows a deactivated user to log in again
+func (s *UserService) ReactivateUser(userID int) (*User, error) {
+	user, err := s.repo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+	if user.IsActive() {
+		return nil, errors.New("user is not deactivated")
+	}
+
+	if err := s.repo.UpdateDeactivatedAt(userID, nil); err != nil {
+		return nil, err
+	}
+	user.DeactivatedAt = nil
+	return user, nil
+}
+
+// ExpirePassword makes the user's password expire now so the next login must set a new one
+func (s *UserService) ExpirePassword(userID int) error {
+	credential, err := s.credRepo.GetByUserIDAndType(userID, s.passwordProvider.GetType())
+	if err != nil {
+		return err
+	}
+	if credential == nil {
+		return errors.New("password login is not enabled for this account")
+	}
+
+	credential.ExpireAfter = time.Now()
+	return s.credRepo.Update(credential)
+}
+
+// ChangePassword replaces the user's password after verifying the current one
+func (s *UserService) ChangePassword(userID int, currentPassword, newPassword string) error {
+	credential, err := s.checkCurrentPassword(userID, currentPassword)
+	if err != nil {
+		return err
+	}
+
+	return s.replacePassword(userID, credential, newPassword)
+}
+
+// VerifyPassword checks the user's current password before a sensitive account change
+func (s *UserService) VerifyPassword(userID int, currentPassword string) error {
+	_, err := s.checkCurrentPassword(userID, currentPassword)
+	return err
+}
+
+func (s *UserService) checkCurrentPassword(userID int, currentPassword string) (*UserCredential, error) {
+	credential, err := s.credRepo.GetByUserIDAndType(userID, s.passwordProvider.GetType())
+	if err != nil {
+		return nil, err
+	}
+	if credential == nil {
+		return nil, errors.New("password login is not enabled for this account")
+	}
+
+	valid, err := s.passwordProvider.Validate(currentPassword, credential.SecretValue)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, ErrIncorrectCurrentPassword
+	}
+	return credential, nil
+}
+
+// ReplaceExpiredPassword sets a new password for a user whose password has expired.
+// The caller must already have verified the expired password.
+func (s *UserService) ReplaceExpiredPassword(userID int, newPassword string) error {
+	credential, err := s.credRepo.GetByUserIDAndType(userID, s.passwordProvider.GetType())
+	if err != nil {
+		return err
+	}
+	if credential == nil {
+		return errors.New("password login is not enabled for this account")
+	}
+	if !isCredentialExpired(credential) {
+		return errors.New("password has not expired")
+	}
+
+	return s.replacePassword(userID, credential, newPassword)
+}
+
+// IsPasswordExpired checks if the user's password is past its ExpireAfter time
+func (s *UserService) IsPasswordExpired(userID int) (bool, error) {
+	credential, err := s.credRepo.GetByUserIDAndType(userID, s.passwordProvider.GetType())
+	if err != nil {
+		return false, err
+	}
+	if credential == nil {
+		return false, nil
+	}
+	return isCredentialExpired(credential), nil
+}
+
+func (s *UserService) replacePassword(userID int, credential *UserCredential, newPassword string) error {
+	uow := s.uowFactory.NewUnitOfWork()
+
+	uow.BeginTransaction()
+	defer uow.RollbackTransactionIfError()
+
+	if err := s.setPassword(uow, userID, credential, newPassword); err != nil {
+		return err
+	}
+
+	return uow.CommitTransaction()
+}
+
+// HasPassword checks if the user can log in with a password
+func (s *UserService) HasPassword(userID int) (bool, error) {
+	credential, err := s.credRepo.GetByUserIDAndType(userID, s.passwordProvider.GetType())
+	if err != nil {
+		return false, err
+	}
+	return credential != nil, nil
+}
+
+// setPassword applies the password policy, records history and stores the new hash.
+// Must be called within a transaction on uow.
+func (s *UserService) setPassword(uow *repositories.UnitOfWork, userID int, credential *UserCredential, newPassword string) error {
+	user, err := NewUserRepository(uow).GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("user not found")
+	}
+
+	if err := s.passwordPolicy.Validate(newPassword, user.LoginID); err != nil {
+		return err
+	}
+
+	historyRepo := NewPasswordHistoryRepository(uow)
+	if s.passwordPolicy.HistoryCount > 0 {
+		// The current password counts as one of the last N passwords
+		previousHashes := []string{credential.SecretValue}
+		histories, err := historyRepo.GetRecentByUserID(userID, s.passwordPolicy.HistoryCount-1)
+		if err != nil {
+			return err
+		}
+		for _, history := range histories {
+			previousHashes = append(previousHashes, history.SecretValue)
+		}
+
+		for _, previousHash := range previousHashes {
+			reused, err := s.passwordProvider.Validate(newPassword, previousHash)
+			if err != nil {
+				return err
+			}
+			if reused {
+				return fmt.Errorf("password must be different from your last %d passwords", s.passwordPolicy.HistoryCount)
+			}
+		}
+
+		if err := historyRepo.Create(&PasswordHistory{
+			UserID:      userID,
+			SecretValue: credential.SecretValue,
+			CreatedAt:   time.Now(),
+		}); err != nil {
+			return err
+		}
+	}
+	if err := historyRepo.TrimByUserID(userID, max(s.passwordPolicy.HistoryCount-1, 0)); err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.passwordProvider.GenerateSecretValue(newPassword)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	credential.SecretValue = hashedPassword
+	credential.LastSecretChangeAt = now
+	credential.ExpireAfter = s.passwordPolicy.ExpiryFrom(now)
+	return NewUserCredentialRepository(uow).Update(credential)
+}
+
+func isCredentialExpired(credential *UserCredential) bool {
+	return !credential.ExpireAfter.IsZero() && time.Now().After(credential.ExpireAfter)
+}
+
+// IsTwoFactorEnabled checks if the user has a confirmed TOTP credential
+func (s *UserService) IsTwoFactorEnabled(userID int) (bool, error) {
+	credential, err := s.credRepo.GetByUserIDAndType(userID, s.totpProvider.GetType())
+	if err != nil {
+		return false, err
+	}
+	return credential != nil, nil
+}
+
+// GetTwoFactorStatus returns whether TOTP is enabled and how many recovery codes are left
+func (s *UserService) GetTwoFactorStatus(userID int) (*TwoFactorStatus, error) {
+	enabled, err := s.IsTwoFactorEnabled(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, err := s.credRepo.ListByUserIDAndType(userID, s.recoveryProvider.GetType())
+	if err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorStatus{
+		Enabled:                enabled,
+		RecoveryCodesRemaining: len(codes),
+	}, nil
+}
+
+// BeginTOTPEnrollment creates a pending TOTP secret that must be confirmed with a valid code
+func (s *UserService) BeginTOTPEnrollment(userID int) (*TOTPEnrollment, error) {
+	user, err := s.repo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	enabled, err := s.IsTwoFactorEnabled(userID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := s.totpProvider.GenerateSecretValue("")
+	if err != nil {
+		return nil, err
+	}
+
+	uow := s.uowFactory.NewUnitOfWork()
+	credRepo := NewUserCredentialRepository(uow)
+
+	uow.BeginTransaction()
+	defer uow.RollbackTransactionIfError()
+
+	// Only one pending enrollment is kept per user
+	if err := credRepo.DeleteByUserIDAndType(userID, CredentialTypeTOTPPending); err != nil {
+		return nil, err
+	}
+
+	if err := credRepo.Create(&UserCredential{
+		UserID:             userID,
+		Type:               CredentialTypeTOTPPending,
+		SecretValue:        secret,
+		LastSecretChangeAt: time.Now(),
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := uow.CommitTransaction(); err != nil {
+		return nil, err
+	}
+
+	return &TOTPEnrollment{
+		Secret:          secret,
+		ProvisioningURI: TOTPProvisioningURI(TOTPIssuer, user.LoginID, secret),
+	}, nil
+}
+
+// ConfirmTOTPEnrollment activates the pending secret and returns a new set of recovery codes
+func (s *UserService) ConfirmTOTPEnrollment(userID int, code string) ([]string, error) {
+	pending, err := s.credRepo.GetByUserIDAndType(userID, CredentialTypeTOTPPending)
+	if err != nil {
+		return nil, err
+	}
+	if pending == nil {
+		return nil, errors.New("no pending two-factor enrollment")
+	}
+
+	valid, err := s.acceptTOTPCode(pending, code)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, ErrInvalidVerificationCode
+	}
+
+	uow := s.uowFactory.NewUnitOfWork()
+	credRepo := NewUserCredentialRepository(uow)
+
+	uow.BeginTransaction()
+	defer uow.RollbackTransactionIfError()
+
+	if err := credRepo.DeleteByUserIDAndType(userID, s.totpProvider.GetType()); err != nil {
+		return nil, err
+	}
+
+	pending.Type = s.totpProvider.GetType()
+	pending.LastSecretChangeAt = time.Now()
+	if err := credRepo.Update(pending); err != nil {
+		return nil, err
+	}
+
+	codes, err := s.replaceRecoveryCodes(credRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uow.CommitTransaction(); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// DisableTOTP removes the TOTP secret and recovery codes after verifying a second factor
+func (s *UserService) DisableTOTP(userID int, code string) error {
+	valid, err := s.VerifySecondFactor(userID, code)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return ErrInvalidVerificationCode
+	}
+
+	uow := s.uowFactory.NewUnitOfWork()
+	credRepo := NewUserCredentialRepository(uow)
+
+	uow.BeginTransaction()
+	defer uow.RollbackTransactionIfError()
+
+	for _, credType := range []CredentialType{s.totpProvider.GetType(), CredentialTypeTOTPPending, s.recoveryProvider.GetType()} {
+		if err := credRepo.DeleteByUserIDAndType(userID, credType); err != nil {
+			return err
+		}
+	}
+
+	return uow.CommitTransaction()
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after verifying a TOTP code
+func (s *UserService) RegenerateRecoveryCodes(userID int, code string) ([]string, error) {
+	credential, err := s.credRepo.GetByUserIDAndType(userID, s.totpProvider.GetType())
+	if err != nil {
+		return nil, err
+	}
+	if credential == nil {
+		return nil, errors.New("two-factor authentication is not enabled")
+	}
+
+	valid, err := s.acceptTOTPCode(credential, code)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, ErrInvalidVerificationCode
+	}
+
+	uow := s.uowFactory.NewUnitOfWork()
+	credRepo := NewUserCredentialRepository(uow)
+
+	uow.BeginTransaction()
+	defer uow.RollbackTransactionIfError()
+
+	codes, err := s.replaceRecoveryCodes(credRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uow.CommitTransaction(); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// VerifySecondFactor accepts a TOTP code or consumes a single-use recovery code
+func (s *UserService) VerifySecondFactor(userID int, code string) (bool, error) {
+	credential, err := s.credRepo.GetByUserIDAndType(userID, s.totpProvider.GetType())
+	if err != nil {
+		return false, err
+	}
+	if credential == nil {
+		return false, errors.New("two-factor authentication is not enabled")
+	}
+
+	valid, err := s.acceptTOTPCode(credential, code)
+	if err != nil {
+		return false, err
+	}
+	if valid {
+		return true, nil
+	}
+
+	recoveryCodes, err := s.credRepo.ListByUserIDAndType(userID, s.recoveryProvider.GetType())
+	if err != nil {
+		return false, err
+	}
+	for _, recoveryCode := range recoveryCodes {
+		valid, err := s.recoveryProvider.Validate(code, recoveryCode.SecretValue)
+		if err != nil {
+			return false, err
+		}
+		if valid {
+			// A concurrent login may have consumed the same code already
+			return s.credRepo.Consume(recoveryCode.ID)
+		}
+	}
+
+	return false, nil
+}
+
+// acceptTOTPCode validates a code for a time step after the last accepted one and records the step,
+// so a captured code cannot be replayed within the clock skew window
+func (s *UserService) acceptTOTPCode(credential *UserCredential, code string) (bool, error) {
+	step, valid, err := s.totpProvider.ValidateStep(code, credential.SecretValue, credential.LastUsedStep)
+	if err != nil || !valid {
+		return false, err
+	}
+
+	// A concurrent request may have accepted the same step already
+	accepted, err := s.credRepo.AdvanceLastUsedStep(credential.ID, step)
+	if err != nil || !accepted {
+		return false, err
+	}
+	credential.LastUsedStep = step
+	return true, nil
+}
+
+func (s *UserService) replaceRecoveryCodes(credRepo *UserCredentialRepository, userID int) ([]string, error) {
+	if err := credRepo.DeleteByUserIDAndType(userID, s.recoveryProvider.GetType()); err != nil {
+		return nil, err
+	}
+
+	codes, err := GenerateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, code := range codes {
+		hashed, err := s.recoveryProvider.GenerateSecretValue(code)
+		if err != nil {
+			return nil, err
+		}
+		if err := credRepo.Create(&UserCredential{
+			UserID:             userID,
+			Type:               s.recoveryProvider.GetType(),
+			SecretValue:        hashed,
+			LastSecretChangeAt: now,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return codes, nil
+}
@@ -2,25 +2,29 @@ package wiki_pages
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // WikiPage represents a wiki page in the system
 type WikiPage struct {
-	ID          int       `gorm:"primaryKey;autoIncrement" json:"id"`
-	ProjectID   int       `gorm:"not null;index;uniqueIndex:idx_project_wiki_slug,composite:slug" json:"projectId"`
-	Slug        string    `gorm:"not null;size:255;uniqueIndex:idx_project_wiki_slug,composite:projectId" json:"slug"` // URL-friendly identifier
-	Title       string    `gorm:"not null;size:255" json:"title"`
-	Protected   bool      `gorm:"not null;default:false" json:"protected"`
-	Content     string    `gorm:"type:text" json:"content"`             // Current merged content (HTML)
-	ContentHash string    `gorm:"size:64" json:"contentHash,omitempty"` // Hash of content for version control
-	Version     int       `gorm:"not null;default:1" json:"version"`    // Current version number
-	Status      string    `gorm:"not null;size:50;default:'Draft'" json:"status"`
-	ParentID    *int      `gorm:"index" json:"parentId,omitempty"` // For hierarchical structure
-	SortOrder   int       `gorm:"default:0" json:"sortOrder"`      // Order within siblings
-	CreatedBy   int       `gorm:"not null;index" json:"createdBy"`
-	UpdatedBy   int       `gorm:"index" json:"updatedBy"`
-	CreatedAt   time.Time `gorm:"not null" json:"createdAt"`
-	UpdatedAt   time.Time `gorm:"not null" json:"updatedAt"`
+	ID          int            `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProjectID   int            `gorm:"not null;index;uniqueIndex:idx_project_wiki_slug,composite:slug" json:"projectId"`
+	Slug        string         `gorm:"not null;size:255;uniqueIndex:idx_project_wiki_slug,composite:projectId,where:deleted_at IS NULL" json:"slug"` // URL-friendly identifier, unique among pages not in the trash
+	Title       string         `gorm:"not null;size:255" json:"title"`
+	Protected   bool           `gorm:"not null;default:false" json:"protected"`
+	Content     string         `gorm:"type:text" json:"content"`             // Current merged content (HTML)
+	ContentHash string         `gorm:"size:64" json:"contentHash,omitempty"` // Hash of content for version control
+	Version     int            `gorm:"not null;default:1" json:"version"`    // Current version number
+	Status      string         `gorm:"not null;size:50;default:'Draft'" json:"status"`
+	ParentID    *int           `gorm:"index" json:"parentId,omitempty"` // For hierarchical structure
+	SortOrder   int            `gorm:"default:0" json:"sortOrder"`      // Order within siblings
+	CreatedBy   int            `gorm:"not null;index" json:"createdBy"`
+	UpdatedBy   int            `gorm:"index" json:"updatedBy"`
+	CreatedAt   time.Time      `gorm:"not null" json:"createdAt"`
+	UpdatedAt   time.Time      `gorm:"not null" json:"updatedAt"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"` // Set while in the trash
+	DeletedBy   *int           `json:"-"`
 }
 
 // TableName specifies the table name for GORM
@@ -43,3 +47,30 @@ func IsValidWikiPageStatus(status string) bool {
 	}
 	return false
 }
+
+// SortParentsFirst orders wiki pages so every page comes after its parent, keeping sibling order.
+// Pages whose parent is missing are treated as top level pages.
+func SortParentsFirst(pages []WikiPage) []WikiPage {
+	known := make(map[int]bool, len(pages))
+	for _, page := range pages {
+		known[page.ID] = true
+	}
+
+	children := make(map[int][]WikiPage)
+	var ordered, queue []WikiPage
+	for _, page := range pages {
+		if page.ParentID == nil || !known[*page.ParentID] || *page.ParentID == page.ID {
+			queue = append(queue, page)
+			continue
+		}
+		children[*page.ParentID] = append(children[*page.ParentID], page)
+	}
+
+	for len(queue) > 0 {
+		page := queue[0]
+		queue = queue[1:]
+		ordered = append(ordered, page)
+		queue = append(queue, children[page.ID]...)
+	}
+	return ordered
+}
@@ -1,6 +1,8 @@
 package wiki_pages
 
 import (
+	"time"
+
 	"github.com/dannyswat/pjeasy/internal/repositories"
 	"gorm.io/gorm"
 )
@@ -43,9 +45,14 @@ func (r *WikiPageRepository) Update(page *WikiPage) error {
 	return r.uow.GetDB().Save(page).Error
 }
 
-// Delete deletes a wiki page
-func (r *WikiPageRepository) Delete(id int) error {
-	return r.uow.GetDB().Delete(&WikiPage{}, id).Error
+// Delete moves a wiki page to the trash. Trashed rows are excluded from every query until restored.
+func (r *WikiPageRepository) Delete(id int, deletedBy int) error {
+	return r.uow.GetDB().Model(&WikiPage{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"deleted_at": time.Now(),
+			"deleted_by": deletedBy,
+		}).Error
 }
 
 // GetByProjectID returns all wiki pages for a project with pagination
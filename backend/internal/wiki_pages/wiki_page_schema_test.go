@@ -26,4 +26,8 @@ func TestWikiPageSlugIndexIncludesProjectID(t *testing.T) {
 	if len(fields) != 2 || !fields["project_id"] || !fields["slug"] {
 		t.Fatalf("idx_project_wiki_slug fields = %#v", fields)
 	}
+
+	if index.Where != "deleted_at IS NULL" {
+		t.Fatalf("idx_project_wiki_slug where = %q, want pages in the trash excluded", index.Where)
+	}
 }
\ No newline at end of file
@@ -73,22 +73,22 @@ func generateSlug(title string) string {
 	return slug
 }
 
-// computeHash computes SHA256 hash of content
-func computeHash(content string) string {
+// ComputeHash computes the SHA256 hash used to version page content
+func ComputeHash(content string) string {
 	hash := sha256.Sum256([]byte(content))
 	return hex.EncodeToString(hash[:])
 }
 
 func (s *WikiPageService) isLimitedWikiReader(projectID int, userID int) (bool, error) {
-	member, err := s.memberRepo.GetByProjectAndUser(projectID, userID)
+	access, err := s.memberRepo.GetAccess(projectID, userID)
 	if err != nil {
 		return false, err
 	}
-	if member == nil {
+	if !access.IsMember {
 		return false, errors.New("user is not a member of this project")
 	}
 
-	return member.IsUser && !member.IsAdmin, nil
+	return access.IsLimited, nil
 }
 
 func canReadWikiPageStatus(status string, isLimitedReader bool) bool {
@@ -126,13 +126,17 @@ func (s *WikiPageService) CreateWikiPage(projectID int, title, content string, p
 		return nil, errors.New("project not found")
 	}
 
-	// Check if user can modify wiki content.
-	canWrite, err := s.memberRepo.CanUserWriteProject(projectID, createdBy)
+	if err := project.EnsureWritable(); err != nil {
+		return nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(projectID, createdBy, projects.PermissionItemWikiPage, projects.PermissionActionCreate)
 	if err != nil {
 		return nil, err
 	}
-	if !canWrite {
-		return nil, errors.New("project users can only read project wiki")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemWikiPage, projects.PermissionActionCreate)
 	}
 
 	content = htmlsanitizer.Sanitize(content)
@@ -167,7 +171,7 @@ func (s *WikiPageService) CreateWikiPage(projectID int, title, content string, p
 		}
 	}
 
-	contentHash := computeHash(content)
+	contentHash := ComputeHash(content)
 	now := time.Now()
 	if err := s.statusRepo.ValidateTransition(projectID, status_changes.ItemTypeWikiPage, "", WikiPageStatusDraft); err != nil {
 		return nil, err
@@ -239,13 +243,17 @@ func (s *WikiPageService) UpdateWikiPage(pageID int, title string, parentID *int
 		return nil, errors.New("wiki page not found")
 	}
 
-	// Check if user can modify wiki content.
-	canWrite, err := s.memberRepo.CanUserWriteProject(page.ProjectID, updatedBy)
+	if err := s.projectRepo.EnsureWritable(page.ProjectID); err != nil {
+		return nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(page.ProjectID, updatedBy, projects.PermissionItemWikiPage, projects.PermissionActionEdit)
 	if err != nil {
 		return nil, err
 	}
-	if !canWrite {
-		return nil, errors.New("project users can only read project wiki")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemWikiPage, projects.PermissionActionEdit)
 	}
 
 	// Validate parent if provided
@@ -297,13 +305,17 @@ func (s *WikiPageService) UpdateWikiPageContent(pageID int, content string, upda
 		return nil, errors.New("wiki page not found")
 	}
 
-	// Check if user can modify wiki content.
-	canWrite, err := s.memberRepo.CanUserWriteProject(page.ProjectID, updatedBy)
+	if err := s.projectRepo.EnsureWritable(page.ProjectID); err != nil {
+		return nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(page.ProjectID, updatedBy, projects.PermissionItemWikiPage, projects.PermissionActionEdit)
 	if err != nil {
 		return nil, err
 	}
-	if !canWrite {
-		return nil, errors.New("project users can only read project wiki")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemWikiPage, projects.PermissionActionEdit)
 	}
 
 	content = htmlsanitizer.Sanitize(content)
@@ -311,7 +323,7 @@ func (s *WikiPageService) UpdateWikiPageContent(pageID int, content string, upda
 		return nil, errors.New("content is required")
 	}
 
-	contentHash := computeHash(content)
+	contentHash := ComputeHash(content)
 	page.Content = content
 	page.ContentHash = contentHash
 	page.Version = page.Version + 1
@@ -335,13 +347,17 @@ func (s *WikiPageService) UpdateWikiPageStatus(pageID int, status string, update
 		return nil, errors.New("wiki page not found")
 	}
 
-	// Check if user can modify wiki content.
-	canWrite, err := s.memberRepo.CanUserWriteProject(page.ProjectID, updatedBy)
+	if err := s.projectRepo.EnsureWritable(page.ProjectID); err != nil {
+		return nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(page.ProjectID, updatedBy, projects.PermissionItemWikiPage, projects.PermissionActionChangeStatus)
 	if err != nil {
 		return nil, err
 	}
-	if !canWrite {
-		return nil, errors.New("project users can only read project wiki")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemWikiPage, projects.PermissionActionChangeStatus)
 	}
 
 	// Validate status
@@ -369,7 +385,7 @@ func (s *WikiPageService) UpdateWikiPageStatus(pageID int, status string, update
 	return page, nil
 }
 
-// DeleteWikiPage deletes a wiki page
+// DeleteWikiPage moves a wiki page to the project trash
 func (s *WikiPageService) DeleteWikiPage(pageID int, userID int) error {
 	page, err := s.pageRepo.GetByID(pageID)
 	if err != nil {
@@ -379,13 +395,17 @@ func (s *WikiPageService) DeleteWikiPage(pageID int, userID int) error {
 		return errors.New("wiki page not found")
 	}
 
-	// Check if user can modify wiki content.
-	canWrite, err := s.memberRepo.CanUserWriteProject(page.ProjectID, userID)
+	if err := s.projectRepo.EnsureWritable(page.ProjectID); err != nil {
+		return err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(page.ProjectID, userID, projects.PermissionItemWikiPage, projects.PermissionActionDelete)
 	if err != nil {
 		return err
 	}
-	if !canWrite {
-		return errors.New("project users can only read project wiki")
+	if !allowed {
+		return projects.PermissionDeniedError(projects.PermissionItemWikiPage, projects.PermissionActionDelete)
 	}
 
 	// Check if page has children
@@ -397,7 +417,7 @@ func (s *WikiPageService) DeleteWikiPage(pageID int, userID int) error {
 		return errors.New("cannot delete wiki page with child pages")
 	}
 
-	return s.pageRepo.Delete(pageID)
+	return s.pageRepo.Delete(pageID, userID)
 }
 
 // GetWikiPage returns a wiki page by ID
@@ -502,13 +522,17 @@ func (s *WikiPageService) CreateWikiPageChange(wikiPageID int, itemType string,
 		return nil, errors.New("wiki page not found")
 	}
 
-	// Check if user can modify wiki content.
-	canWrite, err := s.memberRepo.CanUserWriteProject(page.ProjectID, createdBy)
+	if err := s.projectRepo.EnsureWritable(page.ProjectID); err != nil {
+		return nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(page.ProjectID, createdBy, projects.PermissionItemWikiPage, projects.PermissionActionEdit)
 	if err != nil {
 		return nil, err
 	}
-	if !canWrite {
-		return nil, errors.New("project users can only read project wiki")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemWikiPage, projects.PermissionActionEdit)
 	}
 
 	newContent = htmlsanitizer.Sanitize(newContent)
@@ -563,7 +587,7 @@ func (s *WikiPageService) CreateWikiPageChange(wikiPageID int, itemType string,
 		return nil, errors.New("failed to serialize delta: " + err.Error())
 	}
 
-	snapshotHash := computeHash(newContent)
+	snapshotHash := ComputeHash(newContent)
 	now := time.Now()
 
 	change := &WikiPageChange{
@@ -600,13 +624,13 @@ func (s *WikiPageService) GetWikiPageChange(changeID int, userID int) (*WikiPage
 		return nil, nil
 	}
 
-	// Check if user can modify wiki content.
-	canWrite, err := s.memberRepo.CanUserWriteProject(change.ProjectID, userID)
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(change.ProjectID, userID, projects.PermissionItemWikiPage, projects.PermissionActionEdit)
 	if err != nil {
 		return nil, err
 	}
-	if !canWrite {
-		return nil, errors.New("project users can only read project wiki")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemWikiPage, projects.PermissionActionEdit)
 	}
 
 	return change, nil
@@ -632,6 +656,10 @@ func (s *WikiPageService) UpdateWikiPageChange(changeID int, newContent string,
 		return nil, errors.New("only pending changes can be updated")
 	}
 
+	if err := s.projectRepo.EnsureWritable(change.ProjectID); err != nil {
+		return nil, err
+	}
+
 	// Check if user is a member of the project
 	isMember, err := s.memberRepo.IsUserMember(change.ProjectID, userID)
 	if err != nil {
@@ -666,7 +694,7 @@ func (s *WikiPageService) UpdateWikiPageChange(changeID int, newContent string,
 		return nil, errors.New("failed to serialize delta: " + err.Error())
 	}
 
-	snapshotHash := computeHash(newContent)
+	snapshotHash := ComputeHash(newContent)
 
 	// Update the change (Base and BaseHash remain unchanged)
 	change.Delta = string(deltaJSON)
@@ -747,13 +775,17 @@ func (s *WikiPageService) MergeChangesOnCompletion(itemType string, itemID int,
 		return nil // No changes to merge
 	}
 
-	// Check if user can modify wiki content.
-	canWrite, err := s.memberRepo.CanUserWriteProject(pendingChanges[0].ProjectID, userID)
+	if err := s.projectRepo.EnsureWritable(pendingChanges[0].ProjectID); err != nil {
+		return err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(pendingChanges[0].ProjectID, userID, projects.PermissionItemWikiPage, projects.PermissionActionEdit)
 	if err != nil {
 		return err
 	}
-	if !canWrite {
-		return errors.New("project users can only read project wiki")
+	if !allowed {
+		return projects.PermissionDeniedError(projects.PermissionItemWikiPage, projects.PermissionActionEdit)
 	}
 
 	uow := s.uowFactory.NewUnitOfWork()
@@ -834,7 +866,7 @@ func (s *WikiPageService) MergeChangesOnCompletion(itemType string, itemID int,
 
 						// MergeAll succeeded
 						currentContent = mergedContent
-						currentHash = computeHash(currentContent)
+						currentHash = ComputeHash(currentContent)
 						// Mark all remaining changes as merged
 						for j := i; j < len(pageChanges); j++ {
 							pageChanges[j].Status = WikiPageChangeStatusMerged
@@ -855,7 +887,7 @@ func (s *WikiPageService) MergeChangesOnCompletion(itemType string, itemID int,
 				currentContent = change.Snapshot
 			}
 
-			currentHash = computeHash(currentContent)
+			currentHash = ComputeHash(currentContent)
 
 			// Mark change as merged
 			change.Status = WikiPageChangeStatusMerged
@@ -871,7 +903,7 @@ func (s *WikiPageService) MergeChangesOnCompletion(itemType string, itemID int,
 
 		// Update wiki page with merged content
 		currentContent = htmlsanitizer.Sanitize(currentContent)
-		currentHash = computeHash(currentContent)
+		currentHash = ComputeHash(currentContent)
 		page.Content = currentContent
 		page.ContentHash = currentHash
 		page.Version = page.Version + 1
@@ -964,13 +996,17 @@ func (s *WikiPageService) ResolveConflict(changeID int, resolvedContent string,
 		return nil, errors.New("change not found")
 	}
 
-	// Check if user can modify wiki content.
-	canWrite, err := s.memberRepo.CanUserWriteProject(change.ProjectID, userID)
+	if err := s.projectRepo.EnsureWritable(change.ProjectID); err != nil {
+		return nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(change.ProjectID, userID, projects.PermissionItemWikiPage, projects.PermissionActionEdit)
 	if err != nil {
 		return nil, err
 	}
-	if !canWrite {
-		return nil, errors.New("project users can only read project wiki")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemWikiPage, projects.PermissionActionEdit)
 	}
 
 	if change.Status != WikiPageChangeStatusConflict {
@@ -984,7 +1020,7 @@ func (s *WikiPageService) ResolveConflict(changeID int, resolvedContent string,
 
 	// Update the snapshot with resolved content
 	change.Snapshot = resolvedContent
-	change.SnapshotHash = computeHash(resolvedContent)
+	change.SnapshotHash = ComputeHash(resolvedContent)
 	change.Status = WikiPageChangeStatusPending // Return to pending so it can be merged
 	change.UpdatedAt = time.Now()
 
@@ -1028,13 +1064,17 @@ func (s *WikiPageService) RejectChange(changeID int, userID int) (*WikiPageChang
 		return nil, errors.New("change not found")
 	}
 
-	// Check if user can modify wiki content.
-	canWrite, err := s.memberRepo.CanUserWriteProject(change.ProjectID, userID)
+	if err := s.projectRepo.EnsureWritable(change.ProjectID); err != nil {
+		return nil, err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(change.ProjectID, userID, projects.PermissionItemWikiPage, projects.PermissionActionEdit)
 	if err != nil {
 		return nil, err
 	}
-	if !canWrite {
-		return nil, errors.New("project users can only read project wiki")
+	if !allowed {
+		return nil, projects.PermissionDeniedError(projects.PermissionItemWikiPage, projects.PermissionActionEdit)
 	}
 
 	change.Status = WikiPageChangeStatusRejected
@@ -1062,13 +1102,17 @@ func (s *WikiPageService) DeleteWikiPageChange(changeID int, userID int) error {
 		return errors.New("only pending changes can be deleted")
 	}
 
-	// Check if user can modify wiki content.
-	canWrite, err := s.memberRepo.CanUserWriteProject(change.ProjectID, userID)
+	if err := s.projectRepo.EnsureWritable(change.ProjectID); err != nil {
+		return err
+	}
+
+	// Check if the user's project role allows this
+	allowed, err := s.memberRepo.HasPermission(change.ProjectID, userID, projects.PermissionItemWikiPage, projects.PermissionActionEdit)
 	if err != nil {
 		return err
 	}
-	if !canWrite {
-		return errors.New("project users can only read project wiki")
+	if !allowed {
+		return projects.PermissionDeniedError(projects.PermissionItemWikiPage, projects.PermissionActionEdit)
 	}
 
 	return s.changeRepo.Delete(changeID)
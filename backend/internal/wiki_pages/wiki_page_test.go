@@ -0,0 +1,30 @@
+package wiki_pages
+
+import (
+	"reflect"
+	"testing"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestSortParentsFirst(t *testing.T) {
+	pages := []WikiPage{
+		{ID: 4, ParentID: intPtr(2)},
+		{ID: 2, ParentID: intPtr(1)},
+		{ID: 1},
+		{ID: 3, ParentID: intPtr(1)},
+		{ID: 5, ParentID: intPtr(99)}, // Parent outside the project
+	}
+
+	var got []int
+	for _, page := range SortParentsFirst(pages) {
+		got = append(got, page.ID)
+	}
+
+	want := []int{1, 5, 2, 3, 4}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("SortParentsFirst() = %v, want %v", got, want)
+	}
+}
@@ -6,7 +6,7 @@ import (
 	"github.com/dannyswat/pjeasy/internal/tasks"
 )
 
-// ItemRepository defines the interface for querying items by reference
+// ItemRepository defines the interface for querying the children of an item through its child-of links
 type ItemRepository[T any] interface {
 	GetByItemReference(projectID int, itemType string, itemID int, offset, limit int) ([]T, int64, error)
 }